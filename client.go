@@ -21,6 +21,15 @@
 //	    CustomVault: customVault,
 //	})
 //
+// Registering a provider so it can be selected by name:
+//
+//	omnivault.RegisterProvider("myprovider", func(cfg omnivault.Config) (vault.Vault, error) {
+//	    return myprovider.New(...)
+//	})
+//	client, err := omnivault.NewClient(omnivault.Config{
+//	    Provider: "myprovider",
+//	})
+//
 // Using the resolver for URI-based secret references:
 //
 //	resolver := omnivault.NewResolver()
@@ -32,16 +41,19 @@ package omnivault
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/agentplexus/omnivault/providers/cache"
 	"github.com/agentplexus/omnivault/vault"
 )
 
 // Config holds configuration for creating a new Client.
 type Config struct {
-	// Provider is the name of a built-in provider to use.
-	// Ignored if CustomVault is set.
+	// Provider is the name of a built-in provider, or one added with
+	// RegisterProvider. Ignored if CustomVault is set.
 	Provider ProviderName
 
 	// CustomVault allows injecting a custom vault implementation.
@@ -60,6 +72,11 @@ type Config struct {
 
 	// Extra contains additional provider-specific options.
 	Extra map[string]any
+
+	// CacheTTL enables in-process caching of secrets returned by Get.
+	// Cached secrets are served until the TTL expires or the path is
+	// written or deleted through the Client. Zero disables caching.
+	CacheTTL time.Duration
 }
 
 // Client wraps a vault provider with additional functionality.
@@ -67,6 +84,7 @@ type Client struct {
 	vault  vault.Vault
 	config Config
 	logger *slog.Logger
+	cache  *cache.Provider // wraps vault when caching is enabled
 }
 
 // NewClient creates a new Client with the given configuration.
@@ -90,30 +108,59 @@ func NewClient(config Config) (*Client, error) {
 		logger = slog.Default()
 	}
 
-	return &Client{
+	client := &Client{
 		vault:  v,
 		config: config,
 		logger: logger,
-	}, nil
+	}
+	if config.CacheTTL > 0 {
+		client.cache = cache.New(v, config.CacheTTL)
+	}
+
+	return client, nil
 }
 
 // Get retrieves a secret from the vault.
+// If caching is enabled, a cached copy is returned while it is fresh.
 func (c *Client) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	if c.cache != nil {
+		return c.cache.Get(ctx, path)
+	}
 	return c.vault.Get(ctx, path)
 }
 
 // GetValue retrieves only the value of a secret (convenience method).
 func (c *Client) GetValue(ctx context.Context, path string) (string, error) {
-	secret, err := c.vault.Get(ctx, path)
+	secret, err := c.Get(ctx, path)
 	if err != nil {
 		return "", err
 	}
 	return secret.String(), nil
 }
 
+// GetValueOr retrieves the value of a secret, or fallback if the secret
+// does not exist. Errors other than vault.ErrSecretNotFound are returned.
+func (c *Client) GetValueOr(ctx context.Context, path, fallback string) (string, error) {
+	value, err := c.GetValue(ctx, path)
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return fallback, nil
+	}
+	return value, err
+}
+
+// GetBytes retrieves the value of a secret as bytes (convenience method).
+// For secrets stored as strings, the bytes of the string value are returned.
+func (c *Client) GetBytes(ctx context.Context, path string) ([]byte, error) {
+	secret, err := c.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return secret.Bytes(), nil
+}
+
 // GetField retrieves a specific field from a secret.
 func (c *Client) GetField(ctx context.Context, path, field string) (string, error) {
-	secret, err := c.vault.Get(ctx, path)
+	secret, err := c.Get(ctx, path)
 	if err != nil {
 		return "", err
 	}
@@ -122,17 +169,77 @@ func (c *Client) GetField(ctx context.Context, path, field string) (string, erro
 
 // Set stores a secret in the vault.
 func (c *Client) Set(ctx context.Context, path string, secret *vault.Secret) error {
-	return c.vault.Set(ctx, path, secret)
+	err := c.vault.Set(ctx, path, secret)
+	c.InvalidateCache(path)
+	return err
 }
 
 // SetValue stores a simple string value as a secret (convenience method).
 func (c *Client) SetValue(ctx context.Context, path, value string) error {
-	return c.vault.Set(ctx, path, &vault.Secret{Value: value})
+	return c.Set(ctx, path, &vault.Secret{Value: value})
+}
+
+// SetBytes stores a binary value as a secret (convenience method).
+// The provider must support binary values; see Capabilities.
+func (c *Client) SetBytes(ctx context.Context, path string, data []byte) error {
+	return c.Set(ctx, path, &vault.Secret{
+		ValueBytes: data,
+		Metadata:   vault.Metadata{Path: path},
+	})
+}
+
+// SetSecretWithFields stores a secret with a value and named fields
+// (convenience method). Either may be empty.
+func (c *Client) SetSecretWithFields(ctx context.Context, path, value string, fields map[string]string) error {
+	return c.Set(ctx, path, &vault.Secret{
+		Value:    value,
+		Fields:   fields,
+		Metadata: vault.Metadata{Path: path},
+	})
 }
 
 // Delete removes a secret from the vault.
 func (c *Client) Delete(ctx context.Context, path string) error {
-	return c.vault.Delete(ctx, path)
+	err := c.vault.Delete(ctx, path)
+	c.InvalidateCache(path)
+	return err
+}
+
+// InvalidateCache removes a path from the client's secret cache, forcing the
+// next Get to read from the provider. It is a no-op when caching is disabled.
+func (c *Client) InvalidateCache(path string) {
+	if c.cache != nil {
+		c.cache.Invalidate(path)
+	}
+}
+
+// Watch reports changes to secrets under prefix if the provider supports
+// watching, and returns vault.ErrNotSupported otherwise. Changed paths are
+// removed from the client's cache before the event is delivered.
+func (c *Client) Watch(ctx context.Context, prefix string) (<-chan vault.WatchEvent, error) {
+	w, ok := vault.AsWatchable(c.vault)
+	if !ok {
+		return nil, vault.NewVaultError("Watch", prefix, c.Name(), vault.ErrNotSupported)
+	}
+
+	events, err := w.Watch(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan vault.WatchEvent)
+	go func() {
+		defer close(out)
+		for event := range events {
+			c.InvalidateCache(event.Path)
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
 }
 
 // Exists checks if a secret exists.
@@ -163,6 +270,9 @@ func (c *Client) Vault() vault.Vault {
 
 // Close releases any resources held by the client.
 func (c *Client) Close() error {
+	if c.cache != nil {
+		c.cache.Clear()
+	}
 	return c.vault.Close()
 }
 
@@ -0,0 +1,205 @@
+package omnivault
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omnivault/providers/memory"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// countingVault wraps a vault and counts Get calls.
+type countingVault struct {
+	vault.Vault
+	gets atomic.Int32
+}
+
+func (v *countingVault) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	v.gets.Add(1)
+	return v.Vault.Get(ctx, path)
+}
+
+// failingVault wraps a vault and fails every Get with err.
+type failingVault struct {
+	vault.Vault
+	err error
+}
+
+func (v *failingVault) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	return nil, vault.NewVaultError("Get", path, "failing", v.err)
+}
+
+func TestClientGetValueOr(t *testing.T) {
+	ctx := context.Background()
+	client, err := NewClient(Config{CustomVault: memory.NewWithSecrets(map[string]string{"port": "5432"})})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if got, err := client.GetValueOr(ctx, "port", "3306"); err != nil || got != "5432" {
+		t.Errorf("Expected present value '5432', got %q, %v", got, err)
+	}
+	if got, err := client.GetValueOr(ctx, "missing", "3306"); err != nil || got != "3306" {
+		t.Errorf("Expected fallback '3306', got %q, %v", got, err)
+	}
+
+	locked, err := NewClient(Config{CustomVault: &failingVault{Vault: memory.New(), err: vault.ErrAccessDenied}})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if got, err := locked.GetValueOr(ctx, "port", "3306"); !errors.Is(err, vault.ErrAccessDenied) || got != "" {
+		t.Errorf("Expected ErrAccessDenied without fallback, got %q, %v", got, err)
+	}
+}
+
+func TestClientCache(t *testing.T) {
+	ctx := context.Background()
+	backing := &countingVault{Vault: memory.NewWithSecrets(map[string]string{"api-key": "v1"})}
+
+	client, err := NewClient(Config{CustomVault: backing, CacheTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		value, err := client.GetValue(ctx, "api-key")
+		if err != nil {
+			t.Fatalf("Failed to get secret: %v", err)
+		}
+		if value != "v1" {
+			t.Errorf("Expected value 'v1', got '%s'", value)
+		}
+	}
+	if n := backing.gets.Load(); n != 1 {
+		t.Errorf("Expected 1 provider Get, got %d", n)
+	}
+
+	// Mutating a returned secret must not affect the cache
+	secret, _ := client.Get(ctx, "api-key")
+	secret.Value = "mutated"
+	if value, _ := client.GetValue(ctx, "api-key"); value != "v1" {
+		t.Errorf("Expected cached value 'v1', got '%s'", value)
+	}
+
+	// Writes through the client invalidate the cache
+	if err := client.SetValue(ctx, "api-key", "v2"); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	if value, _ := client.GetValue(ctx, "api-key"); value != "v2" {
+		t.Errorf("Expected value 'v2' after Set, got '%s'", value)
+	}
+	if n := backing.gets.Load(); n != 2 {
+		t.Errorf("Expected 2 provider Gets, got %d", n)
+	}
+
+	// Explicit invalidation
+	client.InvalidateCache("api-key")
+	_, _ = client.Get(ctx, "api-key")
+	if n := backing.gets.Load(); n != 3 {
+		t.Errorf("Expected 3 provider Gets, got %d", n)
+	}
+}
+
+func TestClientCacheExpiry(t *testing.T) {
+	ctx := context.Background()
+	backing := &countingVault{Vault: memory.NewWithSecrets(map[string]string{"api-key": "v1"})}
+
+	client, _ := NewClient(Config{CustomVault: backing, CacheTTL: 10 * time.Millisecond})
+
+	_, _ = client.Get(ctx, "api-key")
+	time.Sleep(20 * time.Millisecond)
+	_, _ = client.Get(ctx, "api-key")
+
+	if n := backing.gets.Load(); n != 2 {
+		t.Errorf("Expected expired entry to be refetched, got %d provider Gets", n)
+	}
+}
+
+func TestClientWithoutCache(t *testing.T) {
+	ctx := context.Background()
+	backing := &countingVault{Vault: memory.NewWithSecrets(map[string]string{"api-key": "v1"})}
+
+	client, _ := NewClient(Config{CustomVault: backing})
+
+	_, _ = client.Get(ctx, "api-key")
+	_, _ = client.Get(ctx, "api-key")
+
+	if n := backing.gets.Load(); n != 2 {
+		t.Errorf("Expected 2 provider Gets without caching, got %d", n)
+	}
+}
+
+func TestClientBytes(t *testing.T) {
+	ctx := context.Background()
+
+	client, err := NewClient(Config{Provider: ProviderMemory})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Not valid UTF-8
+	data := []byte{0x00, 0xff, 0xfe, 0x80, 0x7f}
+	if err := client.SetBytes(ctx, "certs/key.der", data); err != nil {
+		t.Fatalf("Failed to set bytes: %v", err)
+	}
+
+	got, err := client.GetBytes(ctx, "certs/key.der")
+	if err != nil {
+		t.Fatalf("Failed to get bytes: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("Expected %x, got %x", data, got)
+	}
+
+	secret, err := client.Get(ctx, "certs/key.der")
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if secret.Metadata.Path != "certs/key.der" {
+		t.Errorf("Expected metadata path 'certs/key.der', got '%s'", secret.Metadata.Path)
+	}
+
+	// String secrets are returned as their value bytes
+	if err := client.SetValue(ctx, "api-key", "plain"); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	got, err = client.GetBytes(ctx, "api-key")
+	if err != nil {
+		t.Fatalf("Failed to get bytes: %v", err)
+	}
+	if string(got) != "plain" {
+		t.Errorf("Expected 'plain', got %q", got)
+	}
+}
+
+func TestClientSetSecretWithFields(t *testing.T) {
+	ctx := context.Background()
+
+	client, err := NewClient(Config{Provider: ProviderMemory})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	fields := map[string]string{"username": "admin", "host": "db.local"}
+	if err := client.SetSecretWithFields(ctx, "postgres/prod", "dsn", fields); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+
+	secret, err := client.Get(ctx, "postgres/prod")
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if secret.Value != "dsn" {
+		t.Errorf("Expected value 'dsn', got '%s'", secret.Value)
+	}
+	if username, _ := client.GetField(ctx, "postgres/prod", "username"); username != "admin" {
+		t.Errorf("Expected username 'admin', got '%s'", username)
+	}
+	if secret.Metadata.Path != "postgres/prod" {
+		t.Errorf("Expected metadata path 'postgres/prod', got '%s'", secret.Metadata.Path)
+	}
+}
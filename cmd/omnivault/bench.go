@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/agentplexus/omnivault/internal/store"
+)
+
+// kdfCandidates are the Argon2id parameter sets tried by bench-kdf,
+// ordered from weakest to strongest.
+var kdfCandidates = []store.Argon2Params{
+	{Time: 1, Memory: 32 * 1024, Threads: 4, KeyLen: 32},
+	{Time: 2, Memory: 32 * 1024, Threads: 4, KeyLen: 32},
+	{Time: 3, Memory: 64 * 1024, Threads: 4, KeyLen: 32},
+	{Time: 4, Memory: 64 * 1024, Threads: 4, KeyLen: 32},
+	{Time: 3, Memory: 128 * 1024, Threads: 4, KeyLen: 32},
+	{Time: 4, Memory: 256 * 1024, Threads: 4, KeyLen: 32},
+}
+
+func cmdBenchKDF(args []string) error {
+	fs := flag.NewFlagSet("bench-kdf", flag.ContinueOnError)
+	target := fs.Duration("target", 500*time.Millisecond, "target unlock time")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fmt.Printf("Benchmarking Argon2id key derivation (target: %s)\n\n", *target)
+	fmt.Printf("%-6s %-10s %-8s %s\n", "TIME", "MEMORY", "THREADS", "DURATION")
+
+	defaults := store.DefaultArgon2Params()
+	var recommended *store.Argon2Params
+	var recommendedTime time.Duration
+
+	for i, params := range kdfCandidates {
+		elapsed := store.BenchmarkParams(params, "omnivault-benchmark")
+
+		marker := ""
+		if params == defaults {
+			marker = " (default)"
+		}
+		fmt.Printf("%-6d %-10s %-8d %s%s\n", params.Time, fmt.Sprintf("%d MB", params.Memory/1024), params.Threads, elapsed.Round(time.Millisecond), marker)
+
+		// Recommend the strongest parameters that stay within the target
+		if elapsed <= *target || recommended == nil {
+			recommended = &kdfCandidates[i]
+			recommendedTime = elapsed
+		}
+	}
+
+	fmt.Printf("\nRecommended: time=%d memory=%dMB threads=%d (%s)\n",
+		recommended.Time, recommended.Memory/1024, recommended.Threads, recommendedTime.Round(time.Millisecond))
+	return nil
+}
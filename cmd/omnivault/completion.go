@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentplexus/omnivault/internal/client"
+	"github.com/agentplexus/omnivault/internal/daemon"
+)
+
+// completeCommand is the hidden command the completion scripts run to
+// suggest secret paths.
+const completeCommand = "__complete-paths"
+
+// completionCommands are the commands offered by shell completion.
+var completionCommands = []string{
+	"init", "unlock", "lock", "passwd", "status", "info", "session",
+	"get", "set", "list", "tree", "delete", "mv", "cp", "rotate", "restore", "trash", "import", "diff", "alias",
+	"tag", "expire", "protect", "unprotect",
+	"daemon", "migrate-paths", "providers", "bench-kdf", "completion", "version", "help",
+}
+
+// pathCommands are the commands whose arguments are completed as secret
+// paths, including aliases.
+var pathCommands = []string{"get", "delete", "rm", "mv", "move", "cp", "copy"}
+
+// completionTimeout bounds the daemon queries made while completing, so a
+// stuck daemon doesn't hang the shell.
+const completionTimeout = 2 * time.Second
+
+func cmdCompletion(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: omnivault completion <bash|zsh|fish>")
+	}
+	return writeCompletion(os.Stdout, args[0])
+}
+
+// writeCompletion writes the completion script for shell.
+func writeCompletion(w io.Writer, shell string) error {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashCompletion
+	case "zsh":
+		script = zshCompletion
+	case "fish":
+		script = fishCompletion
+	default:
+		return fmt.Errorf("unsupported shell %q: use bash, zsh, or fish", shell)
+	}
+
+	script = strings.NewReplacer(
+		"@COMMANDS@", strings.Join(completionCommands, " "),
+		"@PATH_COMMANDS@", strings.Join(pathCommands, "|"),
+		"@PATH_COMMANDS_LIST@", strings.Join(pathCommands, " "),
+		"@COMPLETE@", completeCommand,
+	).Replace(script)
+	_, err := io.WriteString(w, script)
+	return err
+}
+
+// cmdCompletePaths prints the path suggestions for a partial path, one per
+// line. It prints nothing if the daemon is not running or the vault is
+// locked.
+func cmdCompletePaths(args []string) error {
+	partial := ""
+	if len(args) > 0 {
+		partial = args[0]
+	}
+
+	c := client.New()
+	if !c.IsDaemonRunning() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	for _, path := range suggestPaths(ctx, c, partial) {
+		fmt.Println(path)
+	}
+	return nil
+}
+
+// pathLister is the part of the daemon client used to complete paths.
+type pathLister interface {
+	GetStatus(ctx context.Context) (*daemon.StatusResponse, error)
+	ListSecrets(ctx context.Context, prefix string) (*daemon.ListResponse, error)
+}
+
+var _ pathLister = (*client.Client)(nil)
+
+// suggestPaths returns the secret paths starting with partial, completed up
+// to the next "/", so "db" suggests "db/" rather than every secret under
+// it. It returns nil if the vault is locked or the daemon fails.
+func suggestPaths(ctx context.Context, c pathLister, partial string) []string {
+	status, err := c.GetStatus(ctx)
+	if err != nil || status.Locked {
+		return nil
+	}
+
+	resp, err := c.ListSecrets(ctx, partial)
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var suggestions []string
+	for _, item := range resp.Secrets {
+		suggestion := item.Path
+		if rest, ok := strings.CutPrefix(item.Path, partial); ok {
+			if i := strings.Index(rest, "/"); i >= 0 {
+				suggestion = partial + rest[:i+1]
+			}
+		}
+		if !seen[suggestion] {
+			seen[suggestion] = true
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	sort.Strings(suggestions)
+	return suggestions
+}
+
+const bashCompletion = `# bash completion for omnivault
+# Load with: source <(omnivault completion bash)
+
+_omnivault() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local cmd="" i
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        if [[ "${COMP_WORDS[i]}" != -* ]]; then
+            cmd="${COMP_WORDS[i]}"
+            break
+        fi
+    done
+
+    if [[ -z "$cmd" ]]; then
+        COMPREPLY=($(compgen -W "@COMMANDS@" -- "$cur"))
+        return
+    fi
+
+    case "$cmd" in
+        @PATH_COMMANDS@)
+            [[ "$cur" == -* ]] && return
+            local IFS=$'\n'
+            COMPREPLY=($(omnivault @COMPLETE@ "$cur" 2>/dev/null))
+            if [[ ${#COMPREPLY[@]} -eq 1 && "${COMPREPLY[0]}" == */ ]]; then
+                compopt -o nospace
+            fi
+            ;;
+        completion)
+            COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))
+            ;;
+    esac
+}
+
+complete -F _omnivault omnivault
+`
+
+const zshCompletion = `#compdef omnivault
+# zsh completion for omnivault
+# Load with: source <(omnivault completion zsh)
+
+_omnivault() {
+    local cmd=${${words[2,CURRENT-1]:#-*}[1]}
+
+    if [[ -z "$cmd" ]]; then
+        compadd -- @COMMANDS@
+        return
+    fi
+
+    case "$cmd" in
+        @PATH_COMMANDS@)
+            [[ "$PREFIX" == -* ]] && return
+            local p
+            for p in ${(f)"$(omnivault @COMPLETE@ "$PREFIX" 2>/dev/null)"}; do
+                if [[ "$p" == */ ]]; then
+                    compadd -S '' -- "$p"
+                else
+                    compadd -- "$p"
+                fi
+            done
+            ;;
+        completion)
+            compadd -- bash zsh fish
+            ;;
+    esac
+}
+
+if [[ "$funcstack[1]" == "_omnivault" ]]; then
+    _omnivault "$@"
+else
+    compdef _omnivault omnivault
+fi
+`
+
+const fishCompletion = `# fish completion for omnivault
+# Load with: omnivault completion fish | source
+
+complete -c omnivault -f
+complete -c omnivault -n __fish_use_subcommand -a "@COMMANDS@"
+complete -c omnivault -n "__fish_seen_subcommand_from @PATH_COMMANDS_LIST@" -a "(omnivault @COMPLETE@ (commandline -ct) 2>/dev/null)"
+complete -c omnivault -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+`
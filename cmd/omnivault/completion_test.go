@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/agentplexus/omnivault/internal/daemon"
+)
+
+func TestWriteCompletion(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{"bash", []string{"complete -F _omnivault omnivault", "get|delete|rm|mv|move|cp|copy)", "omnivault __complete-paths"}},
+		{"zsh", []string{"#compdef omnivault", "compdef _omnivault omnivault", "omnivault __complete-paths"}},
+		{"fish", []string{"complete -c omnivault", "__fish_seen_subcommand_from get delete rm mv move cp copy", "omnivault __complete-paths"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeCompletion(&buf, tt.shell); err != nil {
+				t.Fatalf("writeCompletion failed: %v", err)
+			}
+			script := buf.String()
+			for _, want := range append(tt.want, "migrate-paths") {
+				if !strings.Contains(script, want) {
+					t.Errorf("Script does not contain %q:\n%s", want, script)
+				}
+			}
+			if strings.Contains(script, "@COMMANDS@") || strings.Contains(script, "@COMPLETE@") {
+				t.Errorf("Script has unreplaced placeholders:\n%s", script)
+			}
+		})
+	}
+
+	if err := writeCompletion(&bytes.Buffer{}, "powershell"); err == nil {
+		t.Error("Expected error for unsupported shell")
+	}
+}
+
+// fakeLister is a pathLister over a fixed set of paths.
+type fakeLister struct {
+	paths  []string
+	locked bool
+	err    error
+}
+
+func (l *fakeLister) GetStatus(context.Context) (*daemon.StatusResponse, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	return &daemon.StatusResponse{Running: true, Locked: l.locked}, nil
+}
+
+func (l *fakeLister) ListSecrets(_ context.Context, prefix string) (*daemon.ListResponse, error) {
+	if l.locked {
+		return nil, errors.New("vault is locked")
+	}
+	resp := &daemon.ListResponse{}
+	for _, path := range l.paths {
+		if strings.HasPrefix(path, prefix) {
+			resp.Secrets = append(resp.Secrets, daemon.SecretListItem{Path: path})
+		}
+	}
+	resp.Count = len(resp.Secrets)
+	return resp, nil
+}
+
+func TestSuggestPaths(t *testing.T) {
+	lister := &fakeLister{paths: []string{
+		"api-key",
+		"database/password",
+		"database/replica/password",
+		"database/user",
+		"data",
+	}}
+	ctx := context.Background()
+
+	tests := []struct {
+		partial string
+		want    []string
+	}{
+		{"", []string{"api-key", "data", "database/"}},
+		{"dat", []string{"data", "database/"}},
+		{"database/", []string{"database/password", "database/replica/", "database/user"}},
+		{"database/r", []string{"database/replica/"}},
+		{"missing", nil},
+	}
+	for _, tt := range tests {
+		if got := suggestPaths(ctx, lister, tt.partial); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("suggestPaths(%q) = %v, want %v", tt.partial, got, tt.want)
+		}
+	}
+
+	lister.locked = true
+	if got := suggestPaths(ctx, lister, ""); got != nil {
+		t.Errorf("Expected no suggestions while locked, got %v", got)
+	}
+
+	lister.locked, lister.err = false, errors.New("connection refused")
+	if got := suggestPaths(ctx, lister, ""); got != nil {
+		t.Errorf("Expected no suggestions when the daemon fails, got %v", got)
+	}
+}
@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
 	"strconv"
+	"time"
 
 	"github.com/agentplexus/omnivault/internal/client"
 	"github.com/agentplexus/omnivault/internal/config"
@@ -22,19 +24,57 @@ func cmdDaemon(args []string) error {
 
 	switch subcmd {
 	case "start":
-		return daemonStart()
+		return daemonStart(args[1:])
 	case "stop":
 		return daemonStop()
 	case "status":
 		return daemonStatus()
 	case "run":
-		return daemonRun()
+		return daemonRun(args[1:])
 	default:
 		return fmt.Errorf("unknown daemon command: %s", subcmd)
 	}
 }
 
-func daemonStart() error {
+// daemonFlags parses the options shared by daemon start and daemon run.
+func daemonFlags(name string, args []string) (daemon.ServerConfig, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	requireToken := fs.Bool("require-token", false, "require a session token for secret requests")
+	normalizePaths := fs.Bool("normalize-paths", false, "normalize secret paths in vaults created before it was the default")
+	redactPaths := fs.Bool("redact-paths", false, "log secret paths only at debug level")
+	wal := fs.Bool("wal", false, "append writes to a write-ahead log instead of rewriting the vault file")
+	noSync := fs.Bool("no-sync", false, "do not sync the vault files after writes (for disposable vaults)")
+	trackAccess := fs.Bool("track-access", false, "record when each secret was last read, for list --by-access")
+	constantTime := fs.Bool("constant-time-lookups", false, "pad secret reads so their timing doesn't reveal which secrets exist")
+	trash := fs.Bool("trash", false, "move deleted secrets to the trash instead of removing them")
+	trashDays := fs.Int("trash-days", 0, "days to keep trashed secrets (default 30, negative to keep them until purged)")
+	maxSecretBytes := fs.Int64("max-secret-bytes", 0, "reject secrets larger than this many bytes (default unlimited)")
+	grpc := fs.Bool("grpc", false, "also serve the gRPC service")
+	grpcAddr := fs.String("grpc-addr", "", "gRPC socket path, or TCP address on Windows (default omnivaultd-grpc.sock in the config directory)")
+	if err := fs.Parse(args); err != nil {
+		return daemon.ServerConfig{}, err
+	}
+	return daemon.ServerConfig{
+		RequireToken:        *requireToken,
+		NormalizePaths:      *normalizePaths,
+		RedactPaths:         *redactPaths,
+		WAL:                 *wal,
+		NoSync:              *noSync,
+		TrackAccess:         *trackAccess,
+		ConstantTimeLookups: *constantTime,
+		Trash:               *trash,
+		TrashRetention:      time.Duration(*trashDays) * 24 * time.Hour,
+		MaxSecretBytes:      *maxSecretBytes,
+		GRPC:                *grpc || *grpcAddr != "",
+		GRPCAddr:            *grpcAddr,
+	}, nil
+}
+
+func daemonStart(args []string) error {
+	if _, err := daemonFlags("daemon start", args); err != nil {
+		return err
+	}
+
 	c := client.New()
 
 	if c.IsDaemonRunning() {
@@ -42,13 +82,24 @@ func daemonStart() error {
 		return nil
 	}
 
-	// Start daemon in background
+	pid, err := spawnDaemon(c, args)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Daemon started (PID: %d)\n", pid)
+	return nil
+}
+
+// spawnDaemon starts the daemon in the background with the daemon run
+// flags in args, and waits briefly for it to accept connections so that
+// commands run right after succeed. It returns the daemon's PID.
+func spawnDaemon(c *client.Client, args []string) (int, error) {
 	exe, err := os.Executable()
 	if err != nil {
-		return fmt.Errorf("failed to get executable path: %w", err)
+		return 0, fmt.Errorf("failed to get executable path: %w", err)
 	}
 
-	cmd := exec.Command(exe, "daemon", "run")
+	cmd := exec.Command(exe, append([]string{"daemon", "run"}, args...)...)
 	cmd.Stdout = nil
 	cmd.Stderr = nil
 	cmd.Stdin = nil
@@ -57,15 +108,70 @@ func daemonStart() error {
 	process.SetDetached(cmd)
 
 	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start daemon: %w", err)
+		return 0, fmt.Errorf("failed to start daemon: %w", err)
 	}
 
-	fmt.Printf("Daemon started (PID: %d)\n", cmd.Process.Pid)
+	// Wait briefly for the socket so commands run right after start succeed
+	deadline := time.Now().Add(client.DefaultRetryTimeout)
+	for !c.IsDaemonRunning() && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
 
 	// Don't wait for the child process - it's intentionally detached.
 	// The error from Wait() is not meaningful for a daemon we don't manage.
 	go func() { _ = cmd.Wait() }()
 
+	return cmd.Process.Pid, nil
+}
+
+// autoStart is set by the --auto-start global flag.
+var autoStart bool
+
+// envAutoStart enables auto-start without the flag: "1" or "true" in
+// interactive shells only, "always" everywhere.
+const envAutoStart = "OMNIVAULT_AUTOSTART"
+
+// daemonlessCommands don't talk to the daemon, so never auto-start it.
+var daemonlessCommands = map[string]bool{
+	"daemon": true, "bench-kdf": true, "completion": true, completeCommand: true,
+	"version": true, "help": true, "-h": true, "--help": true,
+}
+
+// autoStartEnabled reports whether a daemon that isn't running should be
+// started for cmd. The --auto-start flag always enables it. The
+// environment variable, which may be inherited from a shell profile,
+// only does so from a terminal outside CI unless set to "always", so
+// scripts and pipelines don't leave daemons behind by accident.
+func autoStartEnabled(cmd string, getenv func(string) string, interactive bool) bool {
+	if daemonlessCommands[cmd] {
+		return false
+	}
+	if autoStart {
+		return true
+	}
+	switch getenv(envAutoStart) {
+	case "always":
+		return true
+	case "1", "true":
+		return interactive && getenv("CI") == ""
+	}
+	return false
+}
+
+// ensureDaemon starts the daemon with its default flags if it isn't
+// running, reporting on stderr so command output stays clean.
+func ensureDaemon(c *client.Client) error {
+	if c.IsDaemonRunning() {
+		return nil
+	}
+	pid, err := spawnDaemon(c, nil)
+	if err != nil {
+		return err
+	}
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("started daemon (PID: %d) but it is not accepting connections", pid)
+	}
+	fmt.Fprintf(os.Stderr, "Daemon started (PID: %d)\n", pid)
 	return nil
 }
 
@@ -120,11 +226,16 @@ func daemonStatus() error {
 	return nil
 }
 
-func daemonRun() error {
+func daemonRun(args []string) error {
+	cfg, err := daemonFlags("daemon run", args)
+	if err != nil {
+		return err
+	}
+
 	// Run daemon in foreground
 	fmt.Println("Starting OmniVault daemon...")
 
-	server := daemon.NewServer(daemon.ServerConfig{})
+	server := daemon.NewServer(cfg)
 
 	ctx := context.Background()
 	return server.Run(ctx)
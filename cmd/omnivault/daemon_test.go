@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omnivault/internal/client"
+	"github.com/agentplexus/omnivault/internal/config"
+)
+
+// envRunMain makes the test binary run main instead of the tests, so
+// tests can run the CLI, and the daemon it spawns, as subprocesses.
+const envRunMain = "OMNIVAULT_TEST_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(envRunMain) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestAutoStartEnabled(t *testing.T) {
+	t.Cleanup(func() { autoStart = false })
+
+	tests := []struct {
+		name        string
+		flag        bool
+		env         map[string]string
+		interactive bool
+		cmd         string
+		want        bool
+	}{
+		{name: "off", interactive: true, cmd: "get"},
+		{name: "flag", flag: true, cmd: "get", want: true},
+		{name: "flag in CI", flag: true, env: map[string]string{"CI": "true"}, cmd: "get", want: true},
+		{name: "env interactive", env: map[string]string{envAutoStart: "1"}, interactive: true, cmd: "get", want: true},
+		{name: "env piped", env: map[string]string{envAutoStart: "1"}, cmd: "get"},
+		{name: "env in CI", env: map[string]string{envAutoStart: "true", "CI": "1"}, interactive: true, cmd: "get"},
+		{name: "env always", env: map[string]string{envAutoStart: "always", "CI": "1"}, cmd: "get", want: true},
+		{name: "daemon command", flag: true, cmd: "daemon"},
+		{name: "version", flag: true, cmd: "version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			autoStart = tt.flag
+			getenv := func(key string) string { return tt.env[key] }
+			if got := autoStartEnabled(tt.cmd, getenv, tt.interactive); got != tt.want {
+				t.Errorf("autoStartEnabled = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAutoStart(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a Unix socket")
+	}
+	if testing.Short() {
+		t.Skip("starts a daemon")
+	}
+
+	// t.TempDir can be too long for a Unix socket path on macOS
+	dir, err := os.MkdirTemp("", "ov")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	t.Setenv(config.EnvConfigDir, dir)
+	paths := config.GetPaths()
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(exe, args...)
+		cmd.Env = append(os.Environ(), envRunMain+"=1", envAutoStart+"=")
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+
+	c := client.NewWithPaths(paths.SocketPath, paths.TCPAddr)
+	t.Cleanup(func() { _ = c.Stop(context.Background()) })
+
+	if out, err := run("providers"); err == nil || !strings.Contains(out, "daemon is not running") {
+		t.Fatalf("Expected providers to fail without auto-start, got %v: %s", err, out)
+	}
+
+	out, err := run("--auto-start", "providers")
+	if err != nil {
+		t.Fatalf("providers with --auto-start failed: %v: %s", err, out)
+	}
+	if !strings.Contains(out, "Daemon started") {
+		t.Errorf("Expected the daemon to be started, got:\n%s", out)
+	}
+	if !c.IsDaemonRunning() {
+		t.Fatal("Expected the daemon to keep running")
+	}
+
+	// A running daemon is reused
+	out, err = run("--auto-start", "providers")
+	if err != nil || strings.Contains(out, "Daemon started") {
+		t.Errorf("Expected the running daemon to be used, got %v:\n%s", err, out)
+	}
+
+	if err := c.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for c.IsDaemonRunning() && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/agentplexus/omnivault/internal/client"
+	"github.com/agentplexus/omnivault/internal/daemon"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+func cmdDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	showValues := fs.Bool("show-values", false, "print the old and new values of changed secrets")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+
+	if len(args) < 1 {
+		return fmt.Errorf("usage: omnivault diff [--show-values] <file>")
+	}
+
+	incoming, err := readExportFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	c := client.New()
+	ctx := context.Background()
+
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
+
+	// Comparing values needs a full plaintext snapshot of the vault
+	fmt.Fprint(os.Stderr, "Enter master password: ")
+	password, err := readPassword()
+	if err != nil {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+
+	current, err := c.ExportPlain(ctx, password)
+	if err != nil {
+		return err
+	}
+
+	before := secretMap(current.Secrets)
+	after := secretMap(incoming)
+	printDiff(os.Stdout, vault.Diff(before, after), before, after, *showValues)
+	return nil
+}
+
+// secretMap converts daemon secrets into vault secrets keyed by path.
+func secretMap(secrets []daemon.SecretResponse) map[string]*vault.Secret {
+	m := make(map[string]*vault.Secret, len(secrets))
+	for _, s := range secrets {
+		m[s.Path] = &vault.Secret{Value: s.Value, Fields: s.Fields}
+	}
+	return m
+}
+
+// printDiff prints added (+), removed (-), and changed (~) paths. Values
+// are only printed when showValues is set.
+func printDiff(out io.Writer, d vault.DiffResult, before, after map[string]*vault.Secret, showValues bool) {
+	if d.Empty() {
+		fmt.Fprintln(out, "No differences")
+		return
+	}
+
+	for _, path := range d.Added {
+		fmt.Fprintf(out, "+ %s\n", path)
+	}
+	for _, path := range d.Removed {
+		fmt.Fprintf(out, "- %s\n", path)
+	}
+	for _, change := range d.Changed {
+		var what []string
+		if change.Value {
+			what = append(what, "value")
+		}
+		if len(change.Fields) > 0 {
+			what = append(what, "fields: "+strings.Join(change.Fields, ", "))
+		}
+		fmt.Fprintf(out, "~ %s (%s)\n", change.Path, strings.Join(what, "; "))
+
+		if !showValues {
+			continue
+		}
+		old, cur := before[change.Path], after[change.Path]
+		if change.Value {
+			fmt.Fprintf(out, "    value: %q -> %q\n", old.Value, cur.Value)
+		}
+		for _, name := range change.Fields {
+			fmt.Fprintf(out, "    %s: %q -> %q\n", name, old.Fields[name], cur.Fields[name])
+		}
+	}
+
+	fmt.Fprintf(out, "\n%d added, %d removed, %d changed\n", len(d.Added), len(d.Removed), len(d.Changed))
+}
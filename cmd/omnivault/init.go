@@ -1,17 +1,26 @@
 package main
 
 import (
-	"bufio"
 	"context"
+	"flag"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/agentplexus/omnivault/internal/client"
-	"golang.org/x/term"
+	"github.com/agentplexus/omnivault/vault"
 )
 
-func cmdInit(_ []string) error {
+// minPasswordScore is the strength score below which init warns about the
+// master password, or refuses it with --strict.
+const minPasswordScore = 3
+
+func cmdInit(args []string) error {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	strict := fs.Bool("strict", false, "refuse weak master passwords")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
 	c := client.New()
 	ctx := context.Background()
 
@@ -41,27 +50,53 @@ func cmdInit(_ []string) error {
 		return fmt.Errorf("password must be at least 8 characters")
 	}
 
-	fmt.Print("Confirm master password: ")
-	confirm, err := readPassword()
-	if err != nil {
-		return fmt.Errorf("failed to read password: %w", err)
+	if strength := vault.EstimateStrength(password); strength.Score < minPasswordScore {
+		fmt.Fprintf(os.Stderr, "Warning: weak master password (strength %d/4)\n", strength.Score)
+		for _, w := range strength.Warnings {
+			fmt.Fprintf(os.Stderr, "  - %s\n", w)
+		}
+		if *strict {
+			return fmt.Errorf("password is too weak")
+		}
 	}
 
-	if password != confirm {
-		return fmt.Errorf("passwords do not match")
+	if err := confirmInput(stdinInput, os.Stdout, "Confirm master password: ", password, "passwords"); err != nil {
+		return err
 	}
 
 	// Initialize vault
-	if err := c.Init(ctx, password); err != nil {
+	recoveryKey, err := c.InitWithRecovery(ctx, password)
+	if err != nil {
 		return fmt.Errorf("failed to initialize vault: %w", err)
 	}
 
 	fmt.Println("Vault initialized successfully!")
 	fmt.Println("Your vault is now unlocked and ready to use.")
+	printRecoveryKey(recoveryKey)
 	return nil
 }
 
-func cmdUnlock(_ []string) error {
+// printRecoveryKey shows a new recovery key, which is never shown again.
+func printRecoveryKey(recoveryKey string) {
+	if recoveryKey == "" {
+		return
+	}
+	fmt.Println()
+	fmt.Println("Recovery key (shown only once, store it offline):")
+	fmt.Printf("  %s\n", recoveryKey)
+	fmt.Println("It unlocks the vault if you forget the master password:")
+	fmt.Println("  omnivault unlock --recovery-key")
+}
+
+func cmdUnlock(args []string) error {
+	fs := flag.NewFlagSet("unlock", flag.ContinueOnError)
+	passwordCommand := fs.String("password-command", os.Getenv(client.EnvPasswordCommand),
+		"read the master password from this command's output (default $"+client.EnvPasswordCommand+")")
+	recovery := fs.Bool("recovery-key", false, "unlock with the recovery key instead of the master password")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
 	c := client.New()
 	ctx := context.Background()
 
@@ -83,10 +118,32 @@ func cmdUnlock(_ []string) error {
 		return nil
 	}
 
-	fmt.Print("Enter master password: ")
-	password, err := readPassword()
-	if err != nil {
-		return fmt.Errorf("failed to read password: %w", err)
+	if *recovery {
+		fmt.Print("Enter recovery key: ")
+		recoveryKey, err := readPassword()
+		if err != nil {
+			return fmt.Errorf("failed to read recovery key: %w", err)
+		}
+		if err := c.UnlockWithRecoveryKey(ctx, recoveryKey); err != nil {
+			return fmt.Errorf("failed to unlock: %w", err)
+		}
+		fmt.Println("Vault unlocked with the recovery key.")
+		fmt.Println("Set a new master password with: omnivault passwd")
+		return nil
+	}
+
+	var password string
+	if *passwordCommand != "" {
+		password, err = client.PasswordFromCommand(ctx, *passwordCommand)
+		if err != nil {
+			return err
+		}
+	} else {
+		fmt.Print("Enter master password: ")
+		password, err = readPassword()
+		if err != nil {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
 	}
 
 	if err := c.Unlock(ctx, password); err != nil {
@@ -97,6 +154,51 @@ func cmdUnlock(_ []string) error {
 	return nil
 }
 
+func cmdPasswd(args []string) error {
+	fs := flag.NewFlagSet("passwd", flag.ContinueOnError)
+	rotateRecovery := fs.Bool("rotate-recovery", false, "also replace the recovery key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	c := client.New()
+	ctx := context.Background()
+
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
+
+	fmt.Print("Enter current master password (empty after unlocking with the recovery key): ")
+	oldPassword, err := readPassword()
+	if err != nil {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+
+	fmt.Print("Enter new master password (min 8 chars): ")
+	newPassword, err := readPassword()
+	if err != nil {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+	if len(newPassword) < 8 {
+		return fmt.Errorf("password must be at least 8 characters")
+	}
+	if strength := vault.EstimateStrength(newPassword); strength.Score < minPasswordScore {
+		fmt.Fprintf(os.Stderr, "Warning: weak master password (strength %d/4)\n", strength.Score)
+	}
+	if err := confirmInput(stdinInput, os.Stdout, "Confirm new master password: ", newPassword, "passwords"); err != nil {
+		return err
+	}
+
+	recoveryKey, err := c.ChangePassword(ctx, oldPassword, newPassword, *rotateRecovery)
+	if err != nil {
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+
+	fmt.Println("Master password changed")
+	printRecoveryKey(recoveryKey)
+	return nil
+}
+
 func cmdLock(_ []string) error {
 	c := client.New()
 	ctx := context.Background()
@@ -148,25 +250,69 @@ func cmdStatus(_ []string) error {
 	return nil
 }
 
-// readPassword reads a password from the terminal without echo.
-func readPassword() (string, error) {
-	fd := int(os.Stdin.Fd())
+func cmdInfo(_ []string) error {
+	c := client.New()
+	ctx := context.Background()
 
-	// Try to read without echo
-	if term.IsTerminal(fd) {
-		password, err := term.ReadPassword(fd)
-		fmt.Println() // Print newline after password
-		if err != nil {
-			return "", err
-		}
-		return string(password), nil
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
+
+	info, err := c.VaultInfo(ctx)
+	if err != nil {
+		return err
 	}
 
-	// Fallback for non-terminal (e.g., piped input)
-	reader := bufio.NewReader(os.Stdin)
-	password, err := reader.ReadString('\n')
+	fmt.Printf("Format version: %d\n", info.Version)
+	fmt.Printf("Created at: %s\n", info.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Cipher: %s\n", info.Cipher)
+	fmt.Printf("KDF: %s (time=%d, memory=%d MiB, threads=%d, key=%d bytes)\n",
+		info.KDF, info.KDFParams.Time, info.KDFParams.MemoryKiB/1024, info.KDFParams.Threads, info.KDFParams.KeyLen)
+	fmt.Printf("Salt: %d bytes\n", info.SaltLength)
+	fmt.Printf("Path normalization: %t\n", info.NormalizePaths)
+	fmt.Printf("Recovery key: %t\n", info.RecoveryKey)
+	if info.MaxSecretBytes > 0 {
+		fmt.Printf("Max secret size: %d bytes\n", info.MaxSecretBytes)
+	}
+	return nil
+}
+
+func cmdSession(_ []string) error {
+	c := client.New()
+	ctx := context.Background()
+
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
+
+	session, err := c.Session(ctx)
 	if err != nil {
-		return "", err
+		return err
 	}
-	return strings.TrimSpace(password), nil
+
+	fmt.Printf("Vault: %s\n", session.Vault)
+	if session.Locked {
+		fmt.Println("Status: locked")
+		return nil
+	}
+
+	if session.UnlockedAt != nil {
+		fmt.Printf("Unlocked at: %s\n", session.UnlockedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	if session.Recovered {
+		fmt.Println("Unlocked with the recovery key; set a new password with: omnivault passwd")
+	}
+	if session.AutoLockAt != nil {
+		fmt.Printf("Auto-lock in: %s (at %s)\n", session.AutoLockIn, session.AutoLockAt.Local().Format("15:04:05"))
+	}
+
+	switch {
+	case !session.TokenRequired:
+		fmt.Println("Token: not required")
+	case session.TokenValid:
+		fmt.Println("Token: valid until auto-lock")
+	default:
+		fmt.Println("Token: missing or invalid, run: omnivault unlock")
+	}
+	return nil
 }
@@ -4,20 +4,30 @@ package main
 import (
 	"fmt"
 	"os"
+
+	"github.com/agentplexus/omnivault/internal/client"
 )
 
 const version = "0.1.0"
 
 func main() {
-	if len(os.Args) < 2 {
+	args := parseGlobalFlags(os.Args[1:])
+	if len(args) < 1 {
 		printUsage()
 		os.Exit(1)
 	}
 
-	cmd := os.Args[1]
-	args := os.Args[2:]
+	cmd := args[0]
+	args = args[1:]
 
 	var err error
+	if autoStartEnabled(cmd, os.Getenv, stdinInput.terminal) {
+		if err := ensureDaemon(client.New()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
 	switch cmd {
 	case "init":
 		err = cmdInit(args)
@@ -25,18 +35,60 @@ func main() {
 		err = cmdUnlock(args)
 	case "lock":
 		err = cmdLock(args)
+	case "passwd":
+		err = cmdPasswd(args)
 	case "status":
 		err = cmdStatus(args)
+	case "info":
+		err = cmdInfo(args)
+	case "session":
+		err = cmdSession(args)
 	case "get":
 		err = cmdGet(args)
 	case "set":
 		err = cmdSet(args)
 	case "list", "ls":
 		err = cmdList(args)
+	case "tree":
+		err = cmdTree(args)
 	case "delete", "rm":
 		err = cmdDelete(args)
+	case "mv", "move":
+		err = cmdMove(args)
+	case "cp", "copy":
+		err = cmdCopy(args)
+	case "rotate":
+		err = cmdRotate(args)
+	case "restore":
+		err = cmdRestore(args)
+	case "trash":
+		err = cmdTrash(args)
+	case "import":
+		err = cmdImport(args)
+	case "diff":
+		err = cmdDiff(args)
+	case "migrate-paths":
+		err = cmdMigratePaths(args)
+	case "alias":
+		err = cmdAlias(args)
+	case "tag":
+		err = cmdTag(args)
+	case "expire":
+		err = cmdExpire(args)
+	case "protect":
+		err = cmdProtect(args)
+	case "unprotect":
+		err = cmdUnprotect(args)
 	case "daemon":
 		err = cmdDaemon(args)
+	case "providers":
+		err = cmdProviders(args)
+	case "bench-kdf":
+		err = cmdBenchKDF(args)
+	case "completion":
+		err = cmdCompletion(args)
+	case completeCommand:
+		err = cmdCompletePaths(args)
 	case "version":
 		fmt.Printf("omnivault version %s\n", version)
 	case "help", "-h", "--help":
@@ -57,34 +109,112 @@ func printUsage() {
 	fmt.Println(`omnivault - Secure local secret management
 
 Usage:
-  omnivault <command> [arguments]
+  omnivault [--dry-run] [--auto-start] <command> [arguments]
+
+  --dry-run prints what delete, mv, and import would change without
+  changing anything.
+  --auto-start starts the daemon first if it isn't running. Setting
+  OMNIVAULT_AUTOSTART=1 does the same from a terminal outside CI, and
+  OMNIVAULT_AUTOSTART=always everywhere.
 
 Vault Commands:
   init              Initialize a new vault with a master password
+                    (--strict to refuse weak passwords)
   unlock            Unlock the vault
+                    --password-command cmd  read the password from cmd
+                    (default $OMNIVAULT_PASSWORD_COMMAND)
+                    --recovery-key  unlock with the recovery key
   lock              Lock the vault
+  passwd            Change the master password
+                    (--rotate-recovery to also replace the recovery key)
   status            Show vault and daemon status
+  info              Show vault format and key derivation parameters
+  session           Show the unlocked vault and the time left until
+                    it auto-locks
 
 Secret Commands:
-  get <path>        Get a secret value
+  get <path>        Get a secret, with values masked unless --reveal
+                    --field name  print a single field
+                    --output fmt  print the secret as json, yaml, or
+                    dotenv (KEY=value lines), unmasked
+                    --render      print the secret's template rendered
+                    --version n   print a previous version kept by rotate
   set <path> [val]  Set a secret (prompts for value if not provided)
+                    --field k=v, --tag k=v  set fields and tags
+                    --notes text  set free-form notes
+                    --template t  set a template over the fields, such as
+                    postgres://{{.username}}:{{.password}}@{{.host}}
+                    --lint    warn about likely mistakes (--strict to refuse)
+                    --merge   merge into the existing secret
+                    --replace with --merge, clear the value if empty
+                    --confirm prompt for the value twice
   list [prefix]     List secrets
-  delete <path>     Delete a secret
+                    --by-access  sort by last read, stalest first
+                    --output fmt print as json or yaml
+  tree [prefix]     List secrets as a tree of their paths
+  delete <path>     Delete a secret (--yes to skip confirmation)
+                    --recursive to delete everything under a prefix,
+                    with --all to allow an empty prefix
+                    --permanent to bypass the trash
+  rotate <path>     Replace a secret's value with a generated one,
+                    keeping the old value as a version
+  restore <path>    Restore a deleted secret from the trash
+  trash [list]      List the secrets in the trash
+                    --purge [path]  empty the trash, or remove one secret
+  mv <from> <to>    Move a secret to a new path
+                    --recursive  move every secret under a prefix
+                    --force      overwrite existing secrets at <to>
+  cp <from> <to>    Copy a secret to a new path
+                    --force      overwrite an existing secret at <to>
+  import <file>     Import secrets from a JSON export
+                    --on-conflict skip-existing|overwrite|newer-wins|error
+                    (--replace is the same as overwrite)
+  diff <file>       Compare the vault with a JSON export
+                    (--show-values to print changed values)
+  alias <from> <to> Make <from> an alias of the secret at <to>
+  tag add|remove <path> <key[=value]>...
+                    Add or remove tags without resending the value
+                    (--prefix p instead of a path to tag a whole subtree)
+  expire <path> <when>
+                    Set the expiry (date, RFC 3339 time, 30d, or never)
+  protect <path>    Require the master password to read a secret
+                    (--field name to hide a single field instead)
+  unprotect <path>  Remove protection from a secret or --field
 
 Daemon Commands:
   daemon start      Start the daemon in background
+                    (--require-token to require a session token,
+                    --normalize-paths to normalize paths in older vaults,
+                    --redact-paths to keep secret paths out of info logs,
+                    --wal to log writes instead of rewriting the vault,
+                    --no-sync to skip fsync for disposable vaults,
+                    --track-access to record when secrets are read,
+                    --constant-time-lookups to hide which secrets exist,
+                    --trash to move deleted secrets to the trash,
+                    --trash-days n to purge them after n days, default 30,
+                    --max-secret-bytes n to reject secrets over n bytes,
+                    --grpc to also serve the gRPC service,
+                    --grpc-addr addr to serve it at addr)
   daemon stop       Stop the daemon
   daemon status     Show daemon status
   daemon run        Run daemon in foreground (for debugging)
 
 Other Commands:
+  migrate-paths     Normalize the paths of existing secrets
+  providers         List the available providers and their capabilities
+  bench-kdf         Benchmark key derivation parameters (--target 500ms)
+  completion <shell>
+                    Print a bash, zsh, or fish completion script
   version           Show version
   help              Show this help
 
 Examples:
   omnivault init
   omnivault set database/password
-  omnivault get database/password
+  omnivault get --reveal database/password
+  omnivault set --merge --field port=5433 postgres/prod
   omnivault list database/
-  omnivault delete database/password`)
+  omnivault delete database/password
+  omnivault delete --recursive database/
+  omnivault --dry-run import --on-conflict newer-wins backup.json`)
 }
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agentplexus/omnivault/internal/client"
+	"github.com/agentplexus/omnivault/internal/daemon"
+)
+
+func cmdTag(args []string) error {
+	usage := fmt.Errorf("usage: omnivault tag <add|remove> <path|--prefix prefix> <key[=value]>...")
+	if len(args) < 3 || (args[0] != "add" && args[0] != "remove") {
+		return usage
+	}
+
+	action, path := args[0], args[1]
+	tags := args[2:]
+	byPrefix := false
+	if prefix, ok := strings.CutPrefix(path, "--prefix="); ok {
+		path, byPrefix = prefix, true
+	} else if path == "--prefix" {
+		if len(args) < 4 {
+			return usage
+		}
+		path, tags, byPrefix = args[2], args[3:], true
+	}
+
+	var req daemon.UpdateMetadataRequest
+	if action == "add" {
+		req.Tags = make(map[string]string)
+		for _, tag := range tags {
+			k, v, _ := strings.Cut(tag, "=")
+			if k == "" {
+				return fmt.Errorf("invalid tag %q", tag)
+			}
+			req.Tags[k] = v
+		}
+	} else {
+		req.RemoveTags = tags
+	}
+
+	if byPrefix {
+		return tagPrefix(path, req)
+	}
+	if err := updateMetadata(path, req); err != nil {
+		return err
+	}
+
+	fmt.Printf("Tags of '%s' updated\n", path)
+	return nil
+}
+
+// tagPrefix applies a tag update to every secret under prefix.
+func tagPrefix(prefix string, req daemon.UpdateMetadataRequest) error {
+	if prefix == "" {
+		return fmt.Errorf("prefix must not be empty")
+	}
+
+	c := client.New()
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
+
+	updated, err := c.UpdateMetadataPrefix(context.Background(), prefix, false, req)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Tags of %d secret(s) under '%s' updated\n", updated, prefix)
+	return nil
+}
+
+func cmdExpire(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: omnivault expire <path> <when|never>")
+	}
+
+	path := args[0]
+	expiresAt, err := parseExpiry(args[1], time.Now())
+	if err != nil {
+		return err
+	}
+
+	req := daemon.UpdateMetadataRequest{ExpiresAt: expiresAt, ClearExpiry: expiresAt == nil}
+	if err := updateMetadata(path, req); err != nil {
+		return err
+	}
+
+	if expiresAt == nil {
+		fmt.Printf("Secret '%s' no longer expires\n", path)
+	} else {
+		fmt.Printf("Secret '%s' expires at %s\n", path, expiresAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// updateMetadata sends a metadata-only update to the daemon.
+func updateMetadata(path string, req daemon.UpdateMetadataRequest) error {
+	c := client.New()
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
+	return c.UpdateMetadata(context.Background(), path, req)
+}
+
+// parseExpiry parses an expiry given as an RFC 3339 time, a date
+// (2006-01-02), a duration from now ("720h", "30d"), or "never", which
+// returns nil.
+func parseExpiry(when string, now time.Time) (*time.Time, error) {
+	if when == "never" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, when); err == nil {
+		return &t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, when, time.Local); err == nil {
+		return &t, nil
+	}
+
+	if days, ok := strings.CutSuffix(when, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			t := now.AddDate(0, 0, n)
+			return &t, nil
+		}
+	}
+	if d, err := time.ParseDuration(when); err == nil && d > 0 {
+		t := now.Add(d)
+		return &t, nil
+	}
+
+	return nil, fmt.Errorf("invalid expiry %q: use a date, RFC 3339 time, duration like 30d, or never", when)
+}
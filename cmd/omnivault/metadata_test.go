@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		when string
+		want time.Time
+	}{
+		{"2025-03-01T00:00:00Z", time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"2025-03-01", time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)},
+		{"30d", now.AddDate(0, 0, 30)},
+		{"36h", now.Add(36 * time.Hour)},
+	}
+	for _, tt := range tests {
+		got, err := parseExpiry(tt.when, now)
+		if err != nil {
+			t.Errorf("parseExpiry(%q) failed: %v", tt.when, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseExpiry(%q) = %v, want %v", tt.when, got, tt.want)
+		}
+	}
+
+	if got, err := parseExpiry("never", now); err != nil || got != nil {
+		t.Errorf("parseExpiry(never) = %v, %v; want nil, nil", got, err)
+	}
+	for _, when := range []string{"soon", "-5d", "0d"} {
+		if _, err := parseExpiry(when, now); err == nil {
+			t.Errorf("Expected error for %q", when)
+		}
+	}
+}
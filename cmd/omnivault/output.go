@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/agentplexus/omnivault/internal/daemon"
+	"github.com/agentplexus/omnivault/internal/yaml"
+)
+
+// Output formats accepted by --output. Text is the default, human
+// readable output; the others are meant for scripts and are never masked.
+const (
+	outputText   = "text"
+	outputJSON   = "json"
+	outputYAML   = "yaml"
+	outputDotenv = "dotenv"
+)
+
+// errDotenvList is returned for list --output dotenv.
+var errDotenvList = errors.New("dotenv output needs values, so it is only supported by get")
+
+// outputEncoder writes secrets and secret lists in a machine-readable
+// format.
+type outputEncoder interface {
+	encodeSecret(w io.Writer, secret *daemon.SecretResponse) error
+	encodeList(w io.Writer, items []daemon.SecretListItem) error
+}
+
+// newOutputEncoder returns the encoder for format, or nil for text output.
+func newOutputEncoder(format string) (outputEncoder, error) {
+	switch format {
+	case outputText:
+		return nil, nil
+	case outputJSON:
+		return jsonEncoder{}, nil
+	case outputYAML:
+		return yamlEncoder{}, nil
+	case outputDotenv:
+		return dotenvEncoder{}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q (want text, json, yaml, or dotenv)", format)
+}
+
+// jsonEncoder writes indented JSON.
+type jsonEncoder struct{}
+
+func (jsonEncoder) encodeSecret(w io.Writer, secret *daemon.SecretResponse) error {
+	return encodeJSON(w, secret)
+}
+
+func (jsonEncoder) encodeList(w io.Writer, items []daemon.SecretListItem) error {
+	if items == nil {
+		items = []daemon.SecretListItem{}
+	}
+	return encodeJSON(w, items)
+}
+
+func encodeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// yamlEncoder writes YAML with the same keys as the JSON output.
+type yamlEncoder struct{}
+
+func (yamlEncoder) encodeSecret(w io.Writer, secret *daemon.SecretResponse) error {
+	return encodeYAML(w, secret)
+}
+
+func (yamlEncoder) encodeList(w io.Writer, items []daemon.SecretListItem) error {
+	if len(items) == 0 {
+		_, err := io.WriteString(w, "[]\n")
+		return err
+	}
+	return encodeYAML(w, items)
+}
+
+func encodeYAML(w io.Writer, v any) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// dotenvEncoder writes KEY=value lines in the format of a .env file. The
+// value is named after the secret's path and each field after the path
+// and the field, so "db/prod" with a "user" field gives DB_PROD and
+// DB_PROD_USER.
+type dotenvEncoder struct{}
+
+func (dotenvEncoder) encodeSecret(w io.Writer, secret *daemon.SecretResponse) error {
+	name := envName(secret.Path)
+	if secret.Value != "" {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", name, quoteEnv(secret.Value)); err != nil {
+			return err
+		}
+	}
+
+	keys := make([]string, 0, len(secret.Fields))
+	for k := range secret.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, err := fmt.Fprintf(w, "%s_%s=%s\n", name, envName(k), quoteEnv(secret.Fields[k])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dotenvEncoder) encodeList(io.Writer, []daemon.SecretListItem) error {
+	return errDotenvList
+}
+
+// envName turns a path or field name into an environment variable name:
+// upper case, with every character other than letters, digits, and
+// underscores replaced by an underscore.
+func envName(s string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// quoteEnv double-quotes a value with Go escapes if it would not survive
+// a round trip through a dotenv file unquoted.
+func quoteEnv(v string) string {
+	if strings.TrimSpace(v) != v || strings.ContainsAny(v, " \"'#$`\\\n\r\t") {
+		return strconv.Quote(v)
+	}
+	return v
+}
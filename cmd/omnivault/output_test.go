@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omnivault/internal/daemon"
+)
+
+// multiFieldSecret has a value and fields that need quoting in dotenv.
+var multiFieldSecret = &daemon.SecretResponse{
+	Path:  "db/prod-eu",
+	Value: "hunter2",
+	Fields: map[string]string{
+		"user":    "admin",
+		"comment": "primary db",
+		"cert":    "line1\nline2",
+	},
+	Tags: map[string]string{"env": "prod"},
+}
+
+func TestEncodeSecret(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{outputJSON, `{
+  "path": "db/prod-eu",
+  "value": "hunter2",
+  "fields": {
+    "cert": "line1\nline2",
+    "comment": "primary db",
+    "user": "admin"
+  },
+  "tags": {
+    "env": "prod"
+  },
+  "created_at": "0001-01-01T00:00:00Z",
+  "updated_at": "0001-01-01T00:00:00Z"
+}
+`},
+		{outputYAML, `created_at: 0001-01-01T00:00:00Z
+fields:
+  cert: "line1\nline2"
+  comment: primary db
+  user: admin
+path: db/prod-eu
+tags:
+  env: prod
+updated_at: 0001-01-01T00:00:00Z
+value: hunter2
+`},
+		{outputDotenv, `DB_PROD_EU=hunter2
+DB_PROD_EU_CERT="line1\nline2"
+DB_PROD_EU_COMMENT="primary db"
+DB_PROD_EU_USER=admin
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			encoder, err := newOutputEncoder(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := encoder.encodeSecret(&buf, multiFieldSecret); err != nil {
+				t.Fatalf("encodeSecret failed: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Output =\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeList(t *testing.T) {
+	updated := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	items := []daemon.SecretListItem{
+		{Path: "api/key", HasValue: true, UpdatedAt: updated},
+		{Path: "db/prod-eu", HasValue: true, HasFields: true, Tags: []string{"env=prod"}, UpdatedAt: updated},
+	}
+
+	encoder, _ := newOutputEncoder(outputYAML)
+	var buf bytes.Buffer
+	if err := encoder.encodeList(&buf, items); err != nil {
+		t.Fatalf("encodeList failed: %v", err)
+	}
+	want := `- has_fields: false
+  has_value: true
+  path: api/key
+  updated_at: 2024-06-01T09:00:00Z
+- has_fields: true
+  has_value: true
+  path: db/prod-eu
+  tags:
+    - env=prod
+  updated_at: 2024-06-01T09:00:00Z
+`
+	if buf.String() != want {
+		t.Errorf("Output =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	for format, want := range map[string]string{outputYAML: "[]\n", outputJSON: "[]\n"} {
+		encoder, _ := newOutputEncoder(format)
+		buf.Reset()
+		if err := encoder.encodeList(&buf, nil); err != nil || buf.String() != want {
+			t.Errorf("%s: empty list = %q, %v; want %q", format, buf.String(), err, want)
+		}
+	}
+
+	encoder, _ = newOutputEncoder(outputDotenv)
+	if err := encoder.encodeList(&buf, items); !errors.Is(err, errDotenvList) {
+		t.Errorf("Expected errDotenvList, got %v", err)
+	}
+}
+
+func TestNewOutputEncoder(t *testing.T) {
+	if encoder, err := newOutputEncoder(outputText); encoder != nil || err != nil {
+		t.Errorf("Expected no encoder for text output, got %v, %v", encoder, err)
+	}
+	if _, err := newOutputEncoder("xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"db/prod-eu":  "DB_PROD_EU",
+		"api.key":     "API_KEY",
+		"Mixed_Case1": "MIXED_CASE1",
+		"1password":   "_1PASSWORD",
+	}
+	for in, want := range tests {
+		if got := envName(in); got != want {
+			t.Errorf("envName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// inputReader reads sensitive input: without echo from a terminal, or a
+// line at a time from piped input.
+type inputReader struct {
+	fd       int
+	terminal bool
+	buf      *bufio.Reader
+}
+
+// stdinInput reads from standard input. It is shared by all prompts so that
+// piped input buffered by one prompt is not lost to the next.
+var stdinInput = newInputReader(os.Stdin)
+
+// newInputReader returns an inputReader for r.
+func newInputReader(r io.Reader) *inputReader {
+	in := &inputReader{buf: bufio.NewReader(r)}
+	if f, ok := r.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		in.fd = int(f.Fd())
+		in.terminal = true
+	}
+	return in
+}
+
+// readLine reads one line of input, without its surrounding whitespace
+// when piped.
+func (in *inputReader) readLine(out io.Writer) (string, error) {
+	if in.terminal {
+		b, err := term.ReadPassword(in.fd)
+		fmt.Fprintln(out) // Print newline after the hidden input
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+
+	line, err := in.buf.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// readPassword reads a password from standard input without echo.
+func readPassword() (string, error) {
+	return stdinInput.readLine(os.Stdout)
+}
+
+// confirmInput asks for value to be entered again and fails if the two
+// entries differ. what names the values in the error, e.g. "passwords".
+func confirmInput(in *inputReader, out io.Writer, prompt, value, what string) error {
+	fmt.Fprint(out, prompt)
+	again, err := in.readLine(out)
+	if err != nil {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	if again != value {
+		return fmt.Errorf("%s do not match", what)
+	}
+	return nil
+}
+
+// promptValue prompts for a secret value, and again to confirm it when
+// confirm is set.
+func promptValue(in *inputReader, out io.Writer, confirm bool) (string, error) {
+	fmt.Fprint(out, "Enter secret value: ")
+	value, err := in.readLine(out)
+	if err != nil {
+		return "", fmt.Errorf("failed to read value: %w", err)
+	}
+
+	if confirm {
+		if err := confirmInput(in, out, "Confirm secret value: ", value, "values"); err != nil {
+			return "", err
+		}
+	}
+	return value, nil
+}
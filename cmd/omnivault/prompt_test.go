@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPromptValueConfirm(t *testing.T) {
+	var out bytes.Buffer
+	in := newInputReader(strings.NewReader("s3cret\ns3cret\n"))
+
+	value, err := promptValue(in, &out, true)
+	if err != nil {
+		t.Fatalf("promptValue failed: %v", err)
+	}
+	if value != "s3cret" {
+		t.Errorf("Value = %q, want %q", value, "s3cret")
+	}
+	if want := "Enter secret value: Confirm secret value: "; out.String() != want {
+		t.Errorf("Output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestPromptValueMismatch(t *testing.T) {
+	var out bytes.Buffer
+	in := newInputReader(strings.NewReader("s3cret\ns3cert\n"))
+
+	if _, err := promptValue(in, &out, true); err == nil || err.Error() != "values do not match" {
+		t.Errorf("Expected mismatch error, got %v", err)
+	}
+}
+
+func TestPromptValueSingle(t *testing.T) {
+	var out bytes.Buffer
+	in := newInputReader(strings.NewReader("s3cret"))
+
+	value, err := promptValue(in, &out, false)
+	if err != nil {
+		t.Fatalf("promptValue failed: %v", err)
+	}
+	if value != "s3cret" {
+		t.Errorf("Value = %q, want %q", value, "s3cret")
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/agentplexus/omnivault/internal/client"
+	"github.com/agentplexus/omnivault/internal/daemon"
+)
+
+func cmdProviders(_ []string) error {
+	c := client.New()
+	ctx := context.Background()
+
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
+
+	resp, err := c.ListProviders(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tREAD\tWRITE\tDELETE\tLIST\tVERSIONS\tBINARY\tFIELDS\tWATCH")
+	for _, p := range resp.Providers {
+		fmt.Fprintf(w, "%s\t%s\n", p.Name, strings.Join(capabilityColumns(p), "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println("\nCapabilities are for the default configuration; ? marks registered providers.")
+	return nil
+}
+
+// capabilityColumns returns the capability matrix cells for a provider.
+func capabilityColumns(p daemon.ProviderInfo) []string {
+	caps := p.Capabilities
+	flags := []bool{caps.Read, caps.Write, caps.Delete, caps.List, caps.Versioning, caps.Binary, caps.MultiField, caps.Watch}
+
+	cells := make([]string, len(flags))
+	for i, ok := range flags {
+		switch {
+		case !p.Builtin:
+			cells[i] = "?"
+		case ok:
+			cells[i] = "yes"
+		default:
+			cells[i] = "-"
+		}
+	}
+	return cells
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/agentplexus/omnivault/internal/client"
+)
+
+func cmdRotate(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: omnivault rotate <path>")
+	}
+
+	path := args[0]
+	c := client.New()
+	ctx := context.Background()
+
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
+
+	resp, err := c.RotateSecret(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Secret '%s' rotated to version %s\n", path, resp.Version)
+	fmt.Printf("Show the new value with: omnivault get --reveal %s\n", path)
+	return nil
+}
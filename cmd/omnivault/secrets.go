@@ -1,19 +1,59 @@
 package main
 
 import (
-	"bufio"
 	"context"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/agentplexus/omnivault/internal/client"
-	"golang.org/x/term"
+	"github.com/agentplexus/omnivault/internal/daemon"
+	"github.com/agentplexus/omnivault/vault"
 )
 
+// envReveal makes get print values without --reveal when set to a true
+// value such as "1".
+const envReveal = "OMNIVAULT_REVEAL"
+
+// maskedValue is printed in place of values that are not revealed.
+const maskedValue = "********"
+
+// revealByDefault reports whether get reveals values without --reveal.
+func revealByDefault() bool {
+	reveal, _ := strconv.ParseBool(os.Getenv(envReveal))
+	return reveal
+}
+
 func cmdGet(args []string) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	field := fs.String("field", "", "print only this field (reveals protected fields)")
+	reveal := fs.Bool("reveal", revealByDefault(), "print values instead of masking them")
+	fs.BoolVar(reveal, "show", *reveal, "same as --reveal")
+	output := fs.String("output", outputText, "output format: text, json, yaml, or dotenv (unmasked)")
+	asJSON := fs.Bool("json", false, "same as --output json")
+	render := fs.Bool("render", false, "print the secret's template rendered over its fields")
+	version := fs.String("version", "", "print a previous version kept by rotate")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+
 	if len(args) < 1 {
-		return fmt.Errorf("usage: omnivault get <path>")
+		return fmt.Errorf("usage: omnivault get [--reveal] [--output format] [--field name | --render | --version n] <path>")
+	}
+	if *asJSON {
+		*output = outputJSON
+	}
+	encoder, err := newOutputEncoder(*output)
+	if err != nil {
+		return err
+	}
+	if (*render && *field != "") || (*version != "" && (*render || *field != "")) {
+		return fmt.Errorf("only one of --field, --render, and --version can be given")
 	}
 
 	path := args[0]
@@ -24,29 +64,108 @@ func cmdGet(args []string) error {
 		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
 	}
 
-	secret, err := c.GetSecret(ctx, path)
+	get := func(password string) (*daemon.SecretResponse, error) {
+		if *render {
+			return c.RenderSecret(ctx, path, password)
+		}
+		if *version != "" {
+			return c.GetSecretVersion(ctx, path, *version, password)
+		}
+		return c.GetProtectedSecretField(ctx, path, *field, password)
+	}
+
+	secret, err := get("")
+	if de, ok := err.(*client.DaemonError); ok && de.IsConfirmationRequired() {
+		// Protected secrets and fields require the master password again
+		fmt.Fprint(os.Stderr, "Secret is protected. Enter master password: ")
+		password, perr := readPassword()
+		if perr != nil {
+			return fmt.Errorf("failed to read password: %w", perr)
+		}
+		secret, err = get(password)
+	}
 	if err != nil {
 		return err
 	}
 
-	// Print value
+	// Other formats are meant for scripts, so they are never masked
+	if encoder != nil {
+		return encoder.encodeSecret(os.Stdout, secret)
+	}
+
+	if printSecret(os.Stdout, secret, *field, *reveal) {
+		fmt.Fprintln(os.Stderr, "(values masked; use --reveal to show them)")
+	}
+
+	if n := len(secret.ProtectedFields); n > 0 {
+		fmt.Fprintf(os.Stderr, "(%d protected field(s) hidden: %s; use --field to reveal)\n", n, strings.Join(secret.ProtectedFields, ", "))
+	}
+
+	return nil
+}
+
+// printSecret prints a secret's value, fields sorted by name, and notes,
+// or only the given field. Unless reveal is set, values are replaced with
+// maskedValue; notes are always shown. It reports whether any value was
+// masked.
+func printSecret(w io.Writer, secret *daemon.SecretResponse, field string, reveal bool) bool {
+	masked := false
+	show := func(v string) string {
+		if reveal || v == "" {
+			return v
+		}
+		masked = true
+		return maskedValue
+	}
+
+	if field != "" {
+		fmt.Fprintln(w, show(secret.Fields[field]))
+		return masked
+	}
+
 	if secret.Value != "" {
-		fmt.Println(secret.Value)
+		fmt.Fprintln(w, show(secret.Value))
 	}
 
-	// Print fields if present
-	if len(secret.Fields) > 0 {
-		for k, v := range secret.Fields {
-			fmt.Printf("%s: %s\n", k, v)
+	names := make([]string, 0, len(secret.Fields))
+	for k := range secret.Fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		fmt.Fprintf(w, "%s: %s\n", k, show(secret.Fields[k]))
+	}
+
+	if secret.Notes != "" {
+		fmt.Fprintln(w, "notes:")
+		for _, line := range strings.Split(strings.TrimRight(secret.Notes, "\n"), "\n") {
+			fmt.Fprintf(w, "  %s\n", line)
 		}
 	}
 
-	return nil
+	return masked
 }
 
 func cmdSet(args []string) error {
+	fs := flag.NewFlagSet("set", flag.ContinueOnError)
+	merge := fs.Bool("merge", false, "merge fields and tags into the existing secret")
+	replace := fs.Bool("replace", false, "with --merge, overwrite the value even if empty")
+	confirm := fs.Bool("confirm", false, "prompt for the value twice to catch typos")
+	fields := keyValueFlag{}
+	fs.Var(fields, "field", "set a field (key=value, repeatable)")
+	tags := keyValueFlag{}
+	fs.Var(tags, "tag", "set a tag (key=value, repeatable)")
+	notes := fs.String("notes", "", "set free-form notes describing the secret")
+	tmpl := fs.String("template", "", "set a template over the fields, shown by get --render")
+	lint := fs.Bool("lint", false, "warn about likely mistakes in the value and fields")
+	strict := fs.Bool("strict", false, "like --lint, but do not save the secret if there are warnings")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+
 	if len(args) < 1 {
-		return fmt.Errorf("usage: omnivault set <path> [value]")
+		return fmt.Errorf("usage: omnivault set [--merge] [--replace] [--confirm] [--field k=v]... [--tag k=v]... [--notes text] [--template text] [--lint] [--strict] <path> [value]")
 	}
 
 	path := args[0]
@@ -54,26 +173,21 @@ func cmdSet(args []string) error {
 
 	if len(args) >= 2 {
 		value = args[1]
-	} else {
-		// Prompt for value
-		fmt.Print("Enter secret value: ")
+	} else if len(fields) == 0 && len(tags) == 0 && *notes == "" && *tmpl == "" && !*replace {
 		var err error
-		fd := int(os.Stdin.Fd())
-		if term.IsTerminal(fd) {
-			// Read without echo for sensitive data
-			bytes, err := term.ReadPassword(fd)
-			fmt.Println()
-			if err != nil {
-				return fmt.Errorf("failed to read value: %w", err)
-			}
-			value = string(bytes)
-		} else {
-			reader := bufio.NewReader(os.Stdin)
-			value, err = reader.ReadString('\n')
-			if err != nil {
-				return fmt.Errorf("failed to read value: %w", err)
-			}
-			value = strings.TrimSpace(value)
+		value, err = promptValue(stdinInput, os.Stdout, *confirm)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *lint || *strict {
+		warnings := vault.Lint(&vault.Secret{Value: value, Fields: fields})
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+		}
+		if *strict && len(warnings) > 0 {
+			return fmt.Errorf("secret not saved: %d lint warning(s)", len(warnings))
 		}
 	}
 
@@ -84,7 +198,16 @@ func cmdSet(args []string) error {
 		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
 	}
 
-	if err := c.SetSecret(ctx, path, value, nil, nil); err != nil {
+	req := daemon.SetSecretRequest{
+		Value:    value,
+		Fields:   fields,
+		Tags:     tags,
+		Notes:    *notes,
+		Template: *tmpl,
+		Merge:    *merge,
+		Replace:  *replace,
+	}
+	if err := c.PutSecret(ctx, path, req); err != nil {
 		return err
 	}
 
@@ -92,7 +215,26 @@ func cmdSet(args []string) error {
 	return nil
 }
 
+// listPageSize is the number of secrets fetched per request when listing.
+const listPageSize = 100
+
 func cmdList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	byAccess := fs.Bool("by-access", false, "sort by when secrets were last read, never-read and stalest first")
+	output := fs.String("output", outputText, "output format: text, json, or yaml")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+
+	encoder, err := newOutputEncoder(*output)
+	if err != nil {
+		return err
+	}
+	if _, ok := encoder.(dotenvEncoder); ok {
+		return errDotenvList
+	}
+
 	prefix := ""
 	if len(args) >= 1 {
 		prefix = args[0]
@@ -105,39 +247,246 @@ func cmdList(args []string) error {
 		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
 	}
 
-	resp, err := c.ListSecrets(ctx, prefix)
+	count := 0
+	var all []daemon.SecretListItem
+	err = c.WalkSecrets(ctx, prefix, listPageSize, func(items []daemon.SecretListItem) error {
+		count += len(items)
+		// Sorting and encoding need every item, so only stream text output
+		// when not sorting
+		if *byAccess || encoder != nil {
+			all = append(all, items...)
+			return nil
+		}
+		for _, item := range items {
+			fmt.Printf("%s%s\n", item.Path, itemIndicators(item))
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	if resp.Count == 0 {
+	if *byAccess {
+		sortByAccess(all)
+	}
+	if encoder != nil {
+		return encoder.encodeList(os.Stdout, all)
+	}
+
+	if *byAccess {
+		for _, item := range all {
+			fmt.Printf("%s%s (%s)\n", item.Path, itemIndicators(item), lastRead(item))
+		}
+	}
+
+	if count == 0 {
 		fmt.Println("No secrets found")
 		return nil
 	}
 
-	for _, item := range resp.Secrets {
-		typeIndicator := ""
-		if item.HasValue && item.HasFields {
-			typeIndicator = " (value+fields)"
-		} else if item.HasFields {
-			typeIndicator = " (fields)"
+	fmt.Printf("\n%d secret(s)\n", count)
+	return nil
+}
+
+// sortByAccess sorts items by when they were last read: never-read
+// secrets first, then the least recently read, then by path.
+func sortByAccess(items []daemon.SecretListItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].LastAccessedAt, items[j].LastAccessedAt
+		switch {
+		case a == nil && b == nil:
+			return items[i].Path < items[j].Path
+		case a == nil || b == nil:
+			return a == nil
+		case !a.Equal(*b):
+			return a.Before(*b)
+		default:
+			return items[i].Path < items[j].Path
 		}
+	})
+}
+
+// lastRead describes when a listed secret was last read.
+func lastRead(item daemon.SecretListItem) string {
+	if item.LastAccessedAt == nil {
+		return "never read"
+	}
+	return "read " + item.LastAccessedAt.Local().Format("2006-01-02 15:04")
+}
+
+// itemIndicators describes a listed secret: whether it has fields, is
+// protected or an alias, and its tags.
+func itemIndicators(item daemon.SecretListItem) string {
+	typeIndicator := ""
+	if item.HasValue && item.HasFields {
+		typeIndicator = " (value+fields)"
+	} else if item.HasFields {
+		typeIndicator = " (fields)"
+	}
 
-		tagStr := ""
-		if len(item.Tags) > 0 {
-			tagStr = fmt.Sprintf(" [%s]", strings.Join(item.Tags, ", "))
+	if item.Protected {
+		typeIndicator += " (protected)"
+	}
+
+	if item.AliasOf != "" {
+		typeIndicator += " -> " + item.AliasOf
+	}
+
+	tagStr := ""
+	if len(item.Tags) > 0 {
+		tagStr = fmt.Sprintf(" [%s]", strings.Join(item.Tags, ", "))
+	}
+
+	return typeIndicator + tagStr
+}
+
+func cmdDelete(args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+	fs.BoolVar(&dryRun, "dry-run", dryRun, "print the deletion without performing it")
+	recursive := fs.Bool("recursive", false, "delete every secret under the prefix")
+	all := fs.Bool("all", false, "with --recursive, allow an empty prefix to delete all secrets")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	permanent := fs.Bool("permanent", false, "delete for good instead of moving to the trash")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+
+	if *recursive {
+		prefix := ""
+		if len(args) > 0 {
+			prefix = args[0]
+		}
+		if prefix == "" && !*all {
+			return fmt.Errorf("refusing to delete all secrets without --all")
+		}
+
+		c := client.New()
+		if !c.IsDaemonRunning() {
+			return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+		}
+		return deletePrefix(context.Background(), c, os.Stdin, os.Stdout, prefix, *all, *yes, *permanent, dryRun)
+	}
+
+	if len(args) < 1 {
+		return fmt.Errorf("usage: omnivault delete [--dry-run] [--yes] [--permanent] [--recursive [--all]] <path>")
+	}
+
+	path := args[0]
+	c := client.New()
+	ctx := context.Background()
+
+	if dryRun {
+		return deleteSecret(ctx, c, os.Stdout, path, *permanent, true)
+	}
+
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
+
+	if !*yes {
+		ok, err := confirm(os.Stdin, os.Stdout, fmt.Sprintf("Delete secret '%s'?", path))
+		if err != nil || !ok {
+			return err
 		}
+	}
+
+	return deleteSecret(ctx, c, os.Stdout, path, *permanent, false)
+}
+
+func cmdAlias(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: omnivault alias <from> <to>")
+	}
+
+	from, to := args[0], args[1]
+	c := client.New()
+	ctx := context.Background()
+
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
 
-		fmt.Printf("%s%s%s\n", item.Path, typeIndicator, tagStr)
+	if err := c.SetAlias(ctx, from, to); err != nil {
+		return err
 	}
 
-	fmt.Printf("\n%d secret(s)\n", resp.Count)
+	fmt.Printf("Secret '%s' is now an alias of '%s'\n", from, to)
 	return nil
 }
 
-func cmdDelete(args []string) error {
+func cmdMigratePaths(args []string) error {
+	c := client.New()
+	ctx := context.Background()
+
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
+
+	renamed, err := c.MigratePaths(ctx)
+	if err != nil {
+		return err
+	}
+
+	olds := make([]string, 0, len(renamed))
+	for old := range renamed {
+		olds = append(olds, old)
+	}
+	sort.Strings(olds)
+	for _, old := range olds {
+		fmt.Printf("%s -> %s\n", old, renamed[old])
+	}
+
+	fmt.Printf("%d secret path(s) normalized\n", len(renamed))
+	return nil
+}
+
+func cmdProtect(args []string) error {
+	fs := flag.NewFlagSet("protect", flag.ContinueOnError)
+	field := fs.String("field", "", "protect only this field")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+
+	if len(args) < 1 {
+		return fmt.Errorf("usage: omnivault protect [--field name] <path>")
+	}
+
+	path := args[0]
+	c := client.New()
+	ctx := context.Background()
+
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
+
+	if *field != "" {
+		if err := c.ProtectField(ctx, path, *field); err != nil {
+			return err
+		}
+		fmt.Printf("Field '%s' of secret '%s' protected\n", *field, path)
+		return nil
+	}
+
+	if err := c.Protect(ctx, path); err != nil {
+		return err
+	}
+
+	fmt.Printf("Secret '%s' protected\n", path)
+	return nil
+}
+
+func cmdUnprotect(args []string) error {
+	fs := flag.NewFlagSet("unprotect", flag.ContinueOnError)
+	field := fs.String("field", "", "unprotect only this field")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+
 	if len(args) < 1 {
-		return fmt.Errorf("usage: omnivault delete <path>")
+		return fmt.Errorf("usage: omnivault unprotect [--field name] <path>")
 	}
 
 	path := args[0]
@@ -148,24 +497,24 @@ func cmdDelete(args []string) error {
 		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
 	}
 
-	// Confirm deletion
-	fmt.Printf("Delete secret '%s'? [y/N]: ", path)
-	reader := bufio.NewReader(os.Stdin)
-	response, err := reader.ReadString('\n')
+	fmt.Print("Enter master password: ")
+	password, err := readPassword()
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return fmt.Errorf("failed to read password: %w", err)
 	}
 
-	response = strings.ToLower(strings.TrimSpace(response))
-	if response != "y" && response != "yes" {
-		fmt.Println("Cancelled")
+	if *field != "" {
+		if err := c.UnprotectField(ctx, path, *field, password); err != nil {
+			return err
+		}
+		fmt.Printf("Field '%s' of secret '%s' unprotected\n", *field, path)
 		return nil
 	}
 
-	if err := c.DeleteSecret(ctx, path); err != nil {
+	if err := c.Unprotect(ctx, path, password); err != nil {
 		return err
 	}
 
-	fmt.Printf("Secret '%s' deleted\n", path)
+	fmt.Printf("Secret '%s' unprotected\n", path)
 	return nil
 }
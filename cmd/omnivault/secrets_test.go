@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omnivault/internal/daemon"
+)
+
+func TestPrintSecret(t *testing.T) {
+	secret := &daemon.SecretResponse{
+		Path:   "db/prod",
+		Value:  "hunter2",
+		Fields: map[string]string{"user": "admin", "host": "db.internal", "empty": ""},
+	}
+
+	tests := []struct {
+		name       string
+		field      string
+		reveal     bool
+		want       string
+		wantMasked bool
+	}{
+		{"masked", "", false, "********\nempty: \nhost: ********\nuser: ********\n", true},
+		{"revealed", "", true, "hunter2\nempty: \nhost: db.internal\nuser: admin\n", false},
+		{"masked field", "user", false, "********\n", true},
+		{"revealed field", "user", true, "admin\n", false},
+		{"empty field", "empty", false, "\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			masked := printSecret(&buf, secret, tt.field, tt.reveal)
+			if buf.String() != tt.want {
+				t.Errorf("Output = %q, want %q", buf.String(), tt.want)
+			}
+			if masked != tt.wantMasked {
+				t.Errorf("masked = %v, want %v", masked, tt.wantMasked)
+			}
+		})
+	}
+}
+
+func TestPrintSecretNotes(t *testing.T) {
+	secret := &daemon.SecretResponse{
+		Path:  "db/prod",
+		Value: "hunter2",
+		Notes: "Owned by the DBA team.\nRotate quarterly.\n",
+	}
+
+	var buf bytes.Buffer
+	printSecret(&buf, secret, "", false)
+	want := "********\nnotes:\n  Owned by the DBA team.\n  Rotate quarterly.\n"
+	if buf.String() != want {
+		t.Errorf("Output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRevealByDefault(t *testing.T) {
+	t.Setenv(envReveal, "")
+	if revealByDefault() {
+		t.Error("Expected values to be masked by default")
+	}
+
+	t.Setenv(envReveal, "1")
+	if !revealByDefault() {
+		t.Errorf("Expected %s=1 to reveal values", envReveal)
+	}
+
+	t.Setenv(envReveal, "false")
+	if revealByDefault() {
+		t.Errorf("Expected %s=false to mask values", envReveal)
+	}
+}
+
+func TestSortByAccess(t *testing.T) {
+	early := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+	items := []daemon.SecretListItem{
+		{Path: "c", LastAccessedAt: &late},
+		{Path: "b"},
+		{Path: "d", LastAccessedAt: &early},
+		{Path: "a", LastAccessedAt: &late},
+		{Path: "e"},
+	}
+
+	sortByAccess(items)
+
+	want := []string{"b", "e", "d", "a", "c"}
+	for i, item := range items {
+		if item.Path != want[i] {
+			t.Fatalf("Sorted order = %v, want %v", items, want)
+		}
+	}
+}
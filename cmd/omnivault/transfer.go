@@ -0,0 +1,520 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentplexus/omnivault/internal/client"
+	"github.com/agentplexus/omnivault/internal/daemon"
+)
+
+// dryRun makes destructive commands print what they would do instead of
+// doing it. It is set by the global --dry-run flag or the command's own.
+var dryRun bool
+
+// secretsClient is the part of the daemon client used by commands that
+// change secrets, so they can be tested without a daemon.
+type secretsClient interface {
+	GetSecret(ctx context.Context, path string) (*daemon.SecretResponse, error)
+	PutSecret(ctx context.Context, path string, req daemon.SetSecretRequest) error
+	DeleteSecret(ctx context.Context, path string) error
+	DeleteSecretPermanently(ctx context.Context, path string) error
+	DeleteSecrets(ctx context.Context, prefix string, all bool) (int, error)
+	DeleteSecretsPermanently(ctx context.Context, prefix string, all bool) (int, error)
+	MovePrefix(ctx context.Context, from, to string, force bool) (map[string]string, error)
+	MoveSecret(ctx context.Context, from, to string, force bool) error
+	CopySecret(ctx context.Context, from, to string, force bool) error
+	WalkSecrets(ctx context.Context, prefix string, pageSize int, fn func(items []daemon.SecretListItem) error) error
+}
+
+var _ secretsClient = (*client.Client)(nil)
+
+// parseGlobalFlags consumes global flags placed before the command name.
+func parseGlobalFlags(args []string) []string {
+	for len(args) > 0 {
+		switch args[0] {
+		case "--dry-run", "-dry-run", "-n":
+			dryRun = true
+			args = args[1:]
+		case "--auto-start", "-auto-start":
+			autoStart = true
+			args = args[1:]
+		default:
+			return args
+		}
+	}
+	return args
+}
+
+// deleteSecret deletes a secret, bypassing the trash if permanent is set,
+// or only reports it in dry-run mode.
+func deleteSecret(ctx context.Context, c secretsClient, out io.Writer, path string, permanent, dryRun bool) error {
+	if dryRun {
+		fmt.Fprintf(out, "Would delete secret '%s'\n", path)
+		return nil
+	}
+
+	remove := c.DeleteSecret
+	if permanent {
+		remove = c.DeleteSecretPermanently
+	}
+	if err := remove(ctx, path); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Secret '%s' deleted\n", path)
+	return nil
+}
+
+// deletePrefix deletes every secret under prefix after asking for
+// confirmation, unless yes is set, bypassing the trash if permanent is
+// set. In dry-run mode it only lists them.
+func deletePrefix(ctx context.Context, c secretsClient, in io.Reader, out io.Writer, prefix string, all, yes, permanent, dryRun bool) error {
+	var paths []string
+	err := c.WalkSecrets(ctx, prefix, listPageSize, func(items []daemon.SecretListItem) error {
+		for _, item := range items {
+			paths = append(paths, item.Path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		fmt.Fprintf(out, "No secrets under '%s'\n", prefix)
+		return nil
+	}
+
+	if dryRun {
+		fmt.Fprintf(out, "Would delete %d secret(s):\n", len(paths))
+		for _, path := range paths {
+			fmt.Fprintf(out, "  %s\n", path)
+		}
+		return nil
+	}
+
+	if !yes {
+		ok, err := confirm(in, out, fmt.Sprintf("Delete %d secret(s) under '%s'?", len(paths), prefix))
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	removeAll := c.DeleteSecrets
+	if permanent {
+		removeAll = c.DeleteSecretsPermanently
+	}
+	deleted, err := removeAll(ctx, prefix, all)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Deleted %d secret(s)\n", deleted)
+	return nil
+}
+
+// confirm asks a yes/no question and reports whether the answer was yes.
+func confirm(in io.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N]: ", question)
+	response, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && response == "" {
+		return false, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	response = strings.ToLower(strings.TrimSpace(response))
+	if response != "y" && response != "yes" {
+		fmt.Fprintln(out, "Cancelled")
+		return false, nil
+	}
+	return true, nil
+}
+
+func cmdMove(args []string) error {
+	fs := flag.NewFlagSet("mv", flag.ContinueOnError)
+	recursive := fs.Bool("recursive", false, "move every secret under the <from> prefix")
+	fs.BoolVar(recursive, "r", false, "shorthand for --recursive")
+	force := fs.Bool("force", false, "overwrite existing secrets at the destination")
+	fs.BoolVar(&dryRun, "dry-run", dryRun, "print the move without performing it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+
+	if len(args) < 2 {
+		return fmt.Errorf("usage: omnivault mv [--recursive] [--force] [--dry-run] <from> <to>")
+	}
+
+	c := client.New()
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
+
+	if *recursive {
+		return movePrefix(context.Background(), c, os.Stdout, args[0], args[1], *force, dryRun)
+	}
+	return moveSecret(context.Background(), c, os.Stdout, args[0], args[1], *force, dryRun)
+}
+
+func cmdCopy(args []string) error {
+	fs := flag.NewFlagSet("cp", flag.ContinueOnError)
+	force := fs.Bool("force", false, "overwrite an existing secret at <to>")
+	fs.BoolVar(&dryRun, "dry-run", dryRun, "print the copy without performing it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+
+	if len(args) < 2 {
+		return fmt.Errorf("usage: omnivault cp [--force] [--dry-run] <from> <to>")
+	}
+
+	c := client.New()
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
+
+	return copySecret(context.Background(), c, os.Stdout, args[0], args[1], *force, dryRun)
+}
+
+// copySecret has the daemon copy a secret to a new path, refusing to
+// overwrite an existing secret unless force is set. In dry-run mode it
+// only reports the copy and whether it would overwrite.
+func copySecret(ctx context.Context, c secretsClient, out io.Writer, from, to string, force, dryRun bool) error {
+	if from == to {
+		return fmt.Errorf("source and destination are the same")
+	}
+
+	if dryRun {
+		if _, err := c.GetSecret(ctx, from); err != nil {
+			return err
+		}
+		overwrite, err := secretExists(ctx, c, to)
+		if err != nil {
+			return err
+		}
+		if overwrite {
+			fmt.Fprintf(out, "Would copy secret '%s' to '%s' (overwrite)\n", from, to)
+		} else {
+			fmt.Fprintf(out, "Would copy secret '%s' to '%s'\n", from, to)
+		}
+		return nil
+	}
+
+	if err := c.CopySecret(ctx, from, to, force); err != nil {
+		var daemonErr *client.DaemonError
+		if errors.As(err, &daemonErr) && daemonErr.IsAlreadyExists() {
+			return fmt.Errorf("%w (use --force to overwrite)", err)
+		}
+		return err
+	}
+
+	fmt.Fprintf(out, "Secret '%s' copied to '%s'\n", from, to)
+	return nil
+}
+
+// moveSecret has the daemon move a secret to a new path, so it keeps its
+// protected fields, metadata and version history, refusing to overwrite
+// an existing secret unless force is set. In dry-run mode it only reports
+// the move and whether it would overwrite.
+func moveSecret(ctx context.Context, c secretsClient, out io.Writer, from, to string, force, dryRun bool) error {
+	if from == to {
+		return fmt.Errorf("source and destination are the same")
+	}
+
+	if dryRun {
+		exists, err := secretExists(ctx, c, from)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("secret '%s' not found", from)
+		}
+		overwrite, err := secretExists(ctx, c, to)
+		if err != nil {
+			return err
+		}
+		if overwrite {
+			fmt.Fprintf(out, "Would move secret '%s' to '%s' (overwrite)\n", from, to)
+		} else {
+			fmt.Fprintf(out, "Would move secret '%s' to '%s'\n", from, to)
+		}
+		return nil
+	}
+
+	if err := c.MoveSecret(ctx, from, to, force); err != nil {
+		var daemonErr *client.DaemonError
+		if errors.As(err, &daemonErr) && daemonErr.IsAlreadyExists() {
+			return fmt.Errorf("%w (use --force to overwrite)", err)
+		}
+		return err
+	}
+
+	fmt.Fprintf(out, "Secret '%s' moved to '%s'\n", from, to)
+	return nil
+}
+
+// movePrefix moves every secret under from to the same path under to, in
+// one request so the daemon applies it atomically. Existing destination
+// paths are refused unless force is set. In dry-run mode it only lists the
+// planned moves.
+func movePrefix(ctx context.Context, c secretsClient, out io.Writer, from, to string, force, dryRun bool) error {
+	if from == "" {
+		return fmt.Errorf("source prefix is required")
+	}
+	if from == to {
+		return fmt.Errorf("source and destination are the same")
+	}
+
+	if dryRun {
+		existing := make(map[string]bool)
+		err := c.WalkSecrets(ctx, "", listPageSize, func(items []daemon.SecretListItem) error {
+			for _, item := range items {
+				existing[item.Path] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		var lines []string
+		for path := range existing {
+			rest, ok := strings.CutPrefix(path, from)
+			if !ok {
+				continue
+			}
+			dest := to + rest
+			line := fmt.Sprintf("  %s -> %s", path, dest)
+			if existing[dest] && !strings.HasPrefix(dest, from) {
+				line += " (overwrite)"
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) == 0 {
+			fmt.Fprintf(out, "No secrets under '%s'\n", from)
+			return nil
+		}
+		sort.Strings(lines)
+		fmt.Fprintf(out, "Would move %d secret(s):\n", len(lines))
+		for _, line := range lines {
+			fmt.Fprintln(out, line)
+		}
+		return nil
+	}
+
+	moved, err := c.MovePrefix(ctx, from, to, force)
+	if err != nil {
+		var daemonErr *client.DaemonError
+		if errors.As(err, &daemonErr) && daemonErr.IsAlreadyExists() {
+			return fmt.Errorf("%w (use --force to overwrite)", err)
+		}
+		return err
+	}
+	if len(moved) == 0 {
+		fmt.Fprintf(out, "No secrets under '%s'\n", from)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Moved %d secret(s) from '%s' to '%s'\n", len(moved), from, to)
+	return nil
+}
+
+// secretExists reports whether a secret exists at path.
+func secretExists(ctx context.Context, c secretsClient, path string) (bool, error) {
+	found := false
+	err := c.WalkSecrets(ctx, path, listPageSize, func(items []daemon.SecretListItem) error {
+		for _, item := range items {
+			if item.Path == path {
+				found = true
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+// importAction is what import does with one secret.
+type importAction string
+
+const (
+	importCreate    importAction = "create"
+	importOverwrite importAction = "overwrite"
+	importSkip      importAction = "skip"
+)
+
+// importStep is a planned import of one secret.
+type importStep struct {
+	Secret daemon.SecretResponse
+	Action importAction
+}
+
+// importStrategy decides what import does with a secret whose path
+// already exists in the vault.
+type importStrategy string
+
+const (
+	strategySkip      importStrategy = "skip-existing"
+	strategyOverwrite importStrategy = "overwrite"
+	strategyNewer     importStrategy = "newer-wins"
+	strategyError     importStrategy = "error"
+)
+
+// parseImportStrategy parses an --on-conflict value.
+func parseImportStrategy(s string) (importStrategy, error) {
+	switch strategy := importStrategy(s); strategy {
+	case strategySkip, strategyOverwrite, strategyNewer, strategyError:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("invalid --on-conflict %q: use skip-existing, overwrite, newer-wins, or error", s)
+}
+
+func cmdImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	onConflict := fs.String("on-conflict", string(strategySkip), "what to do with existing secrets: skip-existing, overwrite, newer-wins, or error")
+	replace := fs.Bool("replace", false, "overwrite secrets that already exist (same as --on-conflict=overwrite)")
+	fs.BoolVar(&dryRun, "dry-run", dryRun, "print the planned changes without importing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+
+	if len(args) < 1 {
+		return fmt.Errorf("usage: omnivault import [--on-conflict <strategy>] [--replace] [--dry-run] <file>")
+	}
+
+	strategy, err := parseImportStrategy(*onConflict)
+	if err != nil {
+		return err
+	}
+	if *replace {
+		strategy = strategyOverwrite
+	}
+
+	secrets, err := readExportFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	c := client.New()
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
+
+	return importSecrets(context.Background(), c, os.Stdout, secrets, strategy, dryRun)
+}
+
+// readExportFile reads secrets from a JSON file in the format returned by
+// the daemon's plaintext export.
+func readExportFile(path string) ([]daemon.SecretResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var export daemon.ExportResponse
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("failed to parse file: %w", err)
+	}
+	return export.Secrets, nil
+}
+
+// importSecrets writes secrets to the vault, resolving collisions with
+// existing secrets by strategy. The plan is printed first; in dry-run mode
+// nothing else happens. With strategyError nothing is written if any
+// path already exists.
+func importSecrets(ctx context.Context, c secretsClient, out io.Writer, secrets []daemon.SecretResponse, strategy importStrategy, dryRun bool) error {
+	existing := make(map[string]time.Time)
+	err := c.WalkSecrets(ctx, "", listPageSize, func(items []daemon.SecretListItem) error {
+		for _, item := range items {
+			existing[item.Path] = item.UpdatedAt
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	plan, err := planImport(existing, secrets, strategy)
+	if err != nil {
+		return err
+	}
+
+	verb := "Importing"
+	if dryRun {
+		verb = "Would import"
+	}
+	fmt.Fprintf(out, "%s %d secret(s):\n", verb, len(plan))
+	counts := make(map[importAction]int)
+	for _, step := range plan {
+		fmt.Fprintf(out, "  %-9s %s\n", step.Action, step.Secret.Path)
+		counts[step.Action]++
+	}
+	summary := fmt.Sprintf("%d created, %d overwritten, %d skipped",
+		counts[importCreate], counts[importOverwrite], counts[importSkip])
+
+	if dryRun {
+		fmt.Fprintf(out, "Would be %s\n", summary)
+		return nil
+	}
+
+	for _, step := range plan {
+		if step.Action == importSkip {
+			continue
+		}
+		req := daemon.SetSecretRequest{
+			Value:  step.Secret.Value,
+			Fields: step.Secret.Fields,
+			Tags:   step.Secret.Tags,
+			Notes:  step.Secret.Notes,
+		}
+		if err := c.PutSecret(ctx, step.Secret.Path, req); err != nil {
+			return fmt.Errorf("failed to import '%s': %w", step.Secret.Path, err)
+		}
+	}
+
+	fmt.Fprintf(out, "Done: %s\n", summary)
+	return nil
+}
+
+// planImport decides what to do with each imported secret given the paths
+// that already exist and when they were last updated. With strategyNewer
+// an existing secret is overwritten only if the imported one was updated
+// later; an imported secret without a timestamp never wins.
+func planImport(existing map[string]time.Time, secrets []daemon.SecretResponse, strategy importStrategy) ([]importStep, error) {
+	plan := make([]importStep, 0, len(secrets))
+	var conflicts []string
+	for _, secret := range secrets {
+		action := importCreate
+		if updatedAt, ok := existing[secret.Path]; ok {
+			switch strategy {
+			case strategyOverwrite:
+				action = importOverwrite
+			case strategyNewer:
+				action = importSkip
+				if secret.UpdatedAt.After(updatedAt) {
+					action = importOverwrite
+				}
+			case strategyError:
+				conflicts = append(conflicts, secret.Path)
+			default:
+				action = importSkip
+			}
+		}
+		plan = append(plan, importStep{Secret: secret, Action: action})
+	}
+
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%d secret(s) already exist: %s", len(conflicts), strings.Join(conflicts, ", "))
+	}
+	return plan, nil
+}
@@ -0,0 +1,495 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omnivault/internal/client"
+	"github.com/agentplexus/omnivault/internal/daemon"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// fakeClient is an in-memory secretsClient that counts mutations.
+type fakeClient struct {
+	secrets   map[string]daemon.SecretResponse
+	mutations int
+}
+
+func newFakeClient(paths ...string) *fakeClient {
+	c := &fakeClient{secrets: make(map[string]daemon.SecretResponse)}
+	for _, path := range paths {
+		c.secrets[path] = daemon.SecretResponse{Path: path, Value: "old-" + path}
+	}
+	return c
+}
+
+func (c *fakeClient) GetSecret(_ context.Context, path string) (*daemon.SecretResponse, error) {
+	secret, ok := c.secrets[path]
+	if !ok {
+		return nil, vault.ErrSecretNotFound
+	}
+	return &secret, nil
+}
+
+func (c *fakeClient) PutSecret(_ context.Context, path string, req daemon.SetSecretRequest) error {
+	c.mutations++
+	c.secrets[path] = daemon.SecretResponse{Path: path, Value: req.Value, Fields: req.Fields, Tags: req.Tags, Notes: req.Notes}
+	return nil
+}
+
+func (c *fakeClient) DeleteSecret(_ context.Context, path string) error {
+	c.mutations++
+	delete(c.secrets, path)
+	return nil
+}
+
+func (c *fakeClient) DeleteSecretPermanently(ctx context.Context, path string) error {
+	return c.DeleteSecret(ctx, path)
+}
+
+func (c *fakeClient) DeleteSecretsPermanently(ctx context.Context, prefix string, all bool) (int, error) {
+	return c.DeleteSecrets(ctx, prefix, all)
+}
+
+func (c *fakeClient) DeleteSecrets(_ context.Context, prefix string, _ bool) (int, error) {
+	c.mutations++
+	deleted := 0
+	for path := range c.secrets {
+		if strings.HasPrefix(path, prefix) {
+			delete(c.secrets, path)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (c *fakeClient) MovePrefix(_ context.Context, from, to string, force bool) (map[string]string, error) {
+	moved := make(map[string]string)
+	for path := range c.secrets {
+		if rest, ok := strings.CutPrefix(path, from); ok {
+			moved[path] = to + rest
+		}
+	}
+	for _, dest := range moved {
+		if _, exists := c.secrets[dest]; exists && !force && moved[dest] == "" {
+			return nil, &client.DaemonError{StatusCode: 409, Code: daemon.ErrCodeAlreadyExists, Message: dest}
+		}
+	}
+	c.mutations++
+	secrets := make(map[string]daemon.SecretResponse)
+	for old := range moved {
+		secrets[old] = c.secrets[old]
+		delete(c.secrets, old)
+	}
+	for old, dest := range moved {
+		secret := secrets[old]
+		secret.Path = dest
+		c.secrets[dest] = secret
+	}
+	return moved, nil
+}
+
+func (c *fakeClient) MoveSecret(_ context.Context, from, to string, force bool) error {
+	secret, ok := c.secrets[from]
+	if !ok {
+		return vault.ErrSecretNotFound
+	}
+	if _, exists := c.secrets[to]; exists && !force {
+		return &client.DaemonError{StatusCode: 409, Code: daemon.ErrCodeAlreadyExists, Message: to}
+	}
+	c.mutations++
+	delete(c.secrets, from)
+	secret.Path = to
+	c.secrets[to] = secret
+	return nil
+}
+
+func (c *fakeClient) CopySecret(_ context.Context, from, to string, force bool) error {
+	secret, ok := c.secrets[from]
+	if !ok {
+		return vault.ErrSecretNotFound
+	}
+	if _, exists := c.secrets[to]; exists && !force {
+		return &client.DaemonError{StatusCode: 409, Code: daemon.ErrCodeAlreadyExists, Message: to}
+	}
+	c.mutations++
+	secret.Path = to
+	c.secrets[to] = secret
+	return nil
+}
+
+func (c *fakeClient) WalkSecrets(_ context.Context, prefix string, _ int, fn func(items []daemon.SecretListItem) error) error {
+	var items []daemon.SecretListItem
+	for path := range c.secrets {
+		if strings.HasPrefix(path, prefix) {
+			items = append(items, daemon.SecretListItem{Path: path, UpdatedAt: c.secrets[path].UpdatedAt})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Path < items[j].Path })
+	return fn(items)
+}
+
+func TestDeleteDryRun(t *testing.T) {
+	c := newFakeClient("db/password")
+	var out bytes.Buffer
+
+	if err := deleteSecret(context.Background(), c, &out, "db/password", false, true); err != nil {
+		t.Fatalf("deleteSecret failed: %v", err)
+	}
+
+	if c.mutations != 0 {
+		t.Errorf("Expected no mutations, got %d", c.mutations)
+	}
+	if _, ok := c.secrets["db/password"]; !ok {
+		t.Error("Expected secret to still exist")
+	}
+	if !strings.Contains(out.String(), "Would delete secret 'db/password'") {
+		t.Errorf("Expected planned deletion in output, got %q", out.String())
+	}
+}
+
+func TestMoveDryRun(t *testing.T) {
+	c := newFakeClient("a", "b")
+	var out bytes.Buffer
+
+	if err := moveSecret(context.Background(), c, &out, "a", "b", false, true); err != nil {
+		t.Fatalf("moveSecret failed: %v", err)
+	}
+
+	if c.mutations != 0 {
+		t.Errorf("Expected no mutations, got %d", c.mutations)
+	}
+	if want := "Would move secret 'a' to 'b' (overwrite)\n"; out.String() != want {
+		t.Errorf("Output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestMove(t *testing.T) {
+	c := newFakeClient("a")
+	var out bytes.Buffer
+
+	if err := moveSecret(context.Background(), c, &out, "a", "b", false, false); err != nil {
+		t.Fatalf("moveSecret failed: %v", err)
+	}
+
+	if _, ok := c.secrets["a"]; ok {
+		t.Error("Expected source to be deleted")
+	}
+	if c.secrets["b"].Value != "old-a" {
+		t.Errorf("Expected value to be moved, got %q", c.secrets["b"].Value)
+	}
+	if want := "Secret 'a' moved to 'b'\n"; out.String() != want {
+		t.Errorf("Output = %q, want %q", out.String(), want)
+	}
+
+	c = newFakeClient("a", "b")
+	err := moveSecret(context.Background(), c, &out, "a", "b", false, false)
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("Expected an existing destination to suggest --force, got %v", err)
+	}
+	if c.secrets["a"].Value != "old-a" || c.secrets["b"].Value != "old-b" {
+		t.Errorf("Expected both secrets to be kept, got %v", c.secrets)
+	}
+	if err := moveSecret(context.Background(), c, &out, "a", "b", true, false); err != nil || c.secrets["b"].Value != "old-a" {
+		t.Errorf("Expected --force to overwrite b, got %q, %v", c.secrets["b"].Value, err)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	c := newFakeClient("a", "b")
+	var out bytes.Buffer
+
+	if err := copySecret(context.Background(), c, &out, "a", "c", false, false); err != nil {
+		t.Fatalf("copySecret failed: %v", err)
+	}
+	if c.secrets["a"].Value != "old-a" || c.secrets["c"].Value != "old-a" {
+		t.Errorf("Expected a copy next to the source, got %v", c.secrets)
+	}
+	if want := "Secret 'a' copied to 'c'\n"; out.String() != want {
+		t.Errorf("Output = %q, want %q", out.String(), want)
+	}
+
+	err := copySecret(context.Background(), c, &out, "a", "b", false, false)
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("Expected an existing destination to suggest --force, got %v", err)
+	}
+	if c.secrets["b"].Value != "old-b" {
+		t.Errorf("Expected b to be kept, got %q", c.secrets["b"].Value)
+	}
+	if err := copySecret(context.Background(), c, &out, "a", "b", true, false); err != nil || c.secrets["b"].Value != "old-a" {
+		t.Errorf("Expected --force to overwrite b, got %q, %v", c.secrets["b"].Value, err)
+	}
+}
+
+func TestCopyDryRun(t *testing.T) {
+	c := newFakeClient("a", "b")
+	var out bytes.Buffer
+
+	if err := copySecret(context.Background(), c, &out, "a", "b", false, true); err != nil {
+		t.Fatalf("copySecret failed: %v", err)
+	}
+	if c.mutations != 0 {
+		t.Errorf("Expected no mutations, got %d", c.mutations)
+	}
+	if want := "Would copy secret 'a' to 'b' (overwrite)\n"; out.String() != want {
+		t.Errorf("Output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestMovePrefix(t *testing.T) {
+	c := newFakeClient("old-app/db", "old-app/api/key", "other")
+	var out bytes.Buffer
+
+	if err := movePrefix(context.Background(), c, &out, "old-app/", "new-app/", false, false); err != nil {
+		t.Fatalf("movePrefix failed: %v", err)
+	}
+
+	var paths []string
+	for path := range c.secrets {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	if want := []string{"new-app/api/key", "new-app/db", "other"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("Paths = %v, want %v", paths, want)
+	}
+	if c.secrets["new-app/db"].Value != "old-old-app/db" {
+		t.Errorf("Expected value to be moved, got %q", c.secrets["new-app/db"].Value)
+	}
+	if want := "Moved 2 secret(s) from 'old-app/' to 'new-app/'\n"; out.String() != want {
+		t.Errorf("Output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestMovePrefixCollision(t *testing.T) {
+	c := newFakeClient("a/one", "b/one")
+	var out bytes.Buffer
+
+	err := movePrefix(context.Background(), c, &out, "a/", "b/", false, false)
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("Expected collision error suggesting --force, got %v", err)
+	}
+	if c.mutations != 0 {
+		t.Errorf("Expected no mutations, got %d", c.mutations)
+	}
+
+	if err := movePrefix(context.Background(), c, &out, "a/", "b/", true, false); err != nil {
+		t.Fatalf("movePrefix with force failed: %v", err)
+	}
+	if c.secrets["b/one"].Value != "old-a/one" {
+		t.Errorf("Expected b/one to be overwritten, got %q", c.secrets["b/one"].Value)
+	}
+}
+
+func TestMovePrefixDryRun(t *testing.T) {
+	c := newFakeClient("a/one", "a/two", "b/two")
+	var out bytes.Buffer
+
+	if err := movePrefix(context.Background(), c, &out, "a/", "b/", false, true); err != nil {
+		t.Fatalf("movePrefix failed: %v", err)
+	}
+
+	if c.mutations != 0 {
+		t.Errorf("Expected no mutations, got %d", c.mutations)
+	}
+	want := "Would move 2 secret(s):\n  a/one -> b/one\n  a/two -> b/two (overwrite)\n"
+	if out.String() != want {
+		t.Errorf("Output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestImportDryRun(t *testing.T) {
+	c := newFakeClient("existing")
+	var out bytes.Buffer
+
+	secrets := []daemon.SecretResponse{
+		{Path: "existing", Value: "new"},
+		{Path: "fresh", Value: "new"},
+	}
+	if err := importSecrets(context.Background(), c, &out, secrets, strategyOverwrite, true); err != nil {
+		t.Fatalf("importSecrets failed: %v", err)
+	}
+
+	if c.mutations != 0 {
+		t.Errorf("Expected no mutations, got %d", c.mutations)
+	}
+	if c.secrets["existing"].Value != "old-existing" {
+		t.Error("Expected existing secret to be unchanged")
+	}
+
+	want := "Would import 2 secret(s):\n" +
+		"  overwrite existing\n" +
+		"  create    fresh\n" +
+		"Would be 1 created, 1 overwritten, 0 skipped\n"
+	if out.String() != want {
+		t.Errorf("Output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestImport(t *testing.T) {
+	c := newFakeClient("existing")
+	var out bytes.Buffer
+
+	secrets := []daemon.SecretResponse{
+		{Path: "existing", Value: "new"},
+		{Path: "fresh", Value: "new"},
+	}
+	if err := importSecrets(context.Background(), c, &out, secrets, strategySkip, false); err != nil {
+		t.Fatalf("importSecrets failed: %v", err)
+	}
+
+	// By default existing secrets are skipped
+	if c.secrets["existing"].Value != "old-existing" {
+		t.Error("Expected existing secret to be skipped")
+	}
+	if c.secrets["fresh"].Value != "new" {
+		t.Error("Expected new secret to be imported")
+	}
+	if !strings.HasSuffix(out.String(), "Done: 1 created, 0 overwritten, 1 skipped\n") {
+		t.Errorf("Expected counts in output, got %q", out.String())
+	}
+}
+
+func TestImportStrategies(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newClient := func() *fakeClient {
+		c := newFakeClient("older", "newer", "undated")
+		for path, hours := range map[string]int{"older": 0, "newer": 48, "undated": 0} {
+			secret := c.secrets[path]
+			secret.UpdatedAt = base.Add(time.Duration(hours) * time.Hour)
+			c.secrets[path] = secret
+		}
+		return c
+	}
+	// The imported "older" is newer than the vault's, and "newer" is older
+	secrets := []daemon.SecretResponse{
+		{Path: "older", Value: "new", UpdatedAt: base.Add(24 * time.Hour)},
+		{Path: "newer", Value: "new", UpdatedAt: base.Add(24 * time.Hour)},
+		{Path: "undated", Value: "new"},
+		{Path: "fresh", Value: "new"},
+	}
+
+	tests := []struct {
+		strategy importStrategy
+		updated  []string
+		counts   string
+	}{
+		{strategySkip, []string{"fresh"}, "1 created, 0 overwritten, 3 skipped"},
+		{strategyOverwrite, []string{"fresh", "newer", "older", "undated"}, "1 created, 3 overwritten, 0 skipped"},
+		{strategyNewer, []string{"fresh", "older"}, "1 created, 1 overwritten, 2 skipped"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			c := newClient()
+			var out bytes.Buffer
+			if err := importSecrets(context.Background(), c, &out, secrets, tt.strategy, false); err != nil {
+				t.Fatalf("importSecrets failed: %v", err)
+			}
+
+			var updated []string
+			for path, secret := range c.secrets {
+				if secret.Value == "new" {
+					updated = append(updated, path)
+				}
+			}
+			sort.Strings(updated)
+			if !reflect.DeepEqual(updated, tt.updated) {
+				t.Errorf("Updated %v, want %v", updated, tt.updated)
+			}
+			if !strings.Contains(out.String(), "Done: "+tt.counts+"\n") {
+				t.Errorf("Expected %q in output, got %q", tt.counts, out.String())
+			}
+		})
+	}
+
+	t.Run(string(strategyError), func(t *testing.T) {
+		c := newClient()
+		var out bytes.Buffer
+		err := importSecrets(context.Background(), c, &out, secrets, strategyError, false)
+		if err == nil || !strings.Contains(err.Error(), "3 secret(s) already exist: older, newer, undated") {
+			t.Errorf("Expected conflict error, got %v", err)
+		}
+		if c.mutations != 0 {
+			t.Errorf("Expected no mutations, got %d", c.mutations)
+		}
+
+		c = newFakeClient()
+		if err := importSecrets(context.Background(), c, &out, secrets, strategyError, false); err != nil {
+			t.Errorf("Expected import without conflicts to succeed, got %v", err)
+		}
+		if len(c.secrets) != len(secrets) {
+			t.Errorf("Expected %d secrets, got %d", len(secrets), len(c.secrets))
+		}
+	})
+}
+
+func TestParseImportStrategy(t *testing.T) {
+	if s, err := parseImportStrategy("newer-wins"); err != nil || s != strategyNewer {
+		t.Errorf("parseImportStrategy(newer-wins) = %q, %v", s, err)
+	}
+	if _, err := parseImportStrategy("newest"); err == nil {
+		t.Error("Expected error for an unknown strategy")
+	}
+}
+
+func TestParseGlobalFlags(t *testing.T) {
+	t.Cleanup(func() { dryRun = false })
+
+	args := parseGlobalFlags([]string{"--dry-run", "delete", "x"})
+	if !dryRun {
+		t.Error("Expected --dry-run to be set")
+	}
+	if want := []string{"delete", "x"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Args = %v, want %v", args, want)
+	}
+}
+
+func TestDeletePrefix(t *testing.T) {
+	c := newFakeClient("db/a", "db/b", "api/key")
+	var out bytes.Buffer
+
+	err := deletePrefix(context.Background(), c, strings.NewReader("y\n"), &out, "db/", false, false, false, false)
+	if err != nil {
+		t.Fatalf("deletePrefix failed: %v", err)
+	}
+
+	if len(c.secrets) != 1 {
+		t.Errorf("Expected only api/key to remain, got %v", c.secrets)
+	}
+	if !strings.Contains(out.String(), "Delete 2 secret(s) under 'db/'?") || !strings.Contains(out.String(), "Deleted 2 secret(s)") {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+}
+
+func TestDeletePrefixDeclined(t *testing.T) {
+	c := newFakeClient("db/a", "db/b")
+	var out bytes.Buffer
+
+	err := deletePrefix(context.Background(), c, strings.NewReader("n\n"), &out, "db/", false, false, false, false)
+	if err != nil {
+		t.Fatalf("deletePrefix failed: %v", err)
+	}
+	if c.mutations != 0 {
+		t.Errorf("Expected no mutations, got %d", c.mutations)
+	}
+}
+
+func TestDeletePrefixDryRun(t *testing.T) {
+	c := newFakeClient("db/a", "db/b")
+	var out bytes.Buffer
+
+	err := deletePrefix(context.Background(), c, strings.NewReader(""), &out, "db/", false, true, false, true)
+	if err != nil {
+		t.Fatalf("deletePrefix failed: %v", err)
+	}
+	if c.mutations != 0 {
+		t.Errorf("Expected no mutations, got %d", c.mutations)
+	}
+	if want := "Would delete 2 secret(s):\n  db/a\n  db/b\n"; out.String() != want {
+		t.Errorf("Output = %q, want %q", out.String(), want)
+	}
+}
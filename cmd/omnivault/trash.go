@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/agentplexus/omnivault/internal/client"
+)
+
+func cmdTrash(args []string) error {
+	if len(args) > 0 && args[0] == "list" {
+		args = args[1:]
+	}
+
+	fs := flag.NewFlagSet("trash", flag.ContinueOnError)
+	purge := fs.Bool("purge", false, "permanently delete the trashed secret at the given path, or the whole trash")
+	yes := fs.Bool("yes", false, "with --purge, do not ask for confirmation")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+
+	c := client.New()
+	ctx := context.Background()
+
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
+
+	if *purge {
+		path := ""
+		if len(args) > 0 {
+			path = args[0]
+		}
+		if path == "" && !*yes {
+			ok, err := confirm(os.Stdin, os.Stdout, "Permanently delete every secret in the trash?")
+			if err != nil || !ok {
+				return err
+			}
+		}
+
+		purged, err := c.PurgeTrash(ctx, path)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Purged %d secret(s) from the trash\n", purged)
+		return nil
+	}
+
+	resp, err := c.ListTrash(ctx)
+	if err != nil {
+		return err
+	}
+
+	if resp.Count == 0 {
+		fmt.Println("Trash is empty")
+		return nil
+	}
+
+	for _, item := range resp.Items {
+		fmt.Printf("%s (deleted %s)\n", item.Path, item.DeletedAt.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("\n%d secret(s) in trash\n", resp.Count)
+	return nil
+}
+
+func cmdRestore(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: omnivault restore <path>")
+	}
+
+	path := args[0]
+	c := client.New()
+	ctx := context.Background()
+
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
+
+	if err := c.RestoreSecret(ctx, path); err != nil {
+		var daemonErr *client.DaemonError
+		if errors.As(err, &daemonErr) && daemonErr.IsAlreadyExists() {
+			return fmt.Errorf("%w (delete or move the secret at '%s' first)", err, path)
+		}
+		return err
+	}
+
+	fmt.Printf("Secret '%s' restored\n", path)
+	return nil
+}
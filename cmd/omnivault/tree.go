@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/agentplexus/omnivault/internal/client"
+	"github.com/agentplexus/omnivault/internal/daemon"
+)
+
+// treeNode is a path segment in the secret tree. It is a secret if item
+// is set, and a directory if it has children; it can be both.
+type treeNode struct {
+	name     string
+	item     *daemon.SecretListItem
+	children map[string]*treeNode
+}
+
+func cmdTree(args []string) error {
+	prefix := ""
+	if len(args) >= 1 {
+		prefix = args[0]
+	}
+
+	c := client.New()
+	ctx := context.Background()
+
+	if !c.IsDaemonRunning() {
+		return fmt.Errorf("daemon is not running, start it with: omnivault daemon start")
+	}
+
+	var items []daemon.SecretListItem
+	err := c.WalkSecrets(ctx, prefix, listPageSize, func(page []daemon.SecretListItem) error {
+		items = append(items, page...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		fmt.Println("No secrets found")
+		return nil
+	}
+
+	renderTree(os.Stdout, buildTree(items))
+	fmt.Printf("\n%d secret(s)\n", len(items))
+	return nil
+}
+
+// buildTree arranges secrets in a tree by splitting their paths on "/".
+func buildTree(items []daemon.SecretListItem) *treeNode {
+	root := &treeNode{}
+	for i := range items {
+		node := root
+		for _, name := range strings.Split(strings.Trim(items[i].Path, "/"), "/") {
+			child, ok := node.children[name]
+			if !ok {
+				if node.children == nil {
+					node.children = make(map[string]*treeNode)
+				}
+				child = &treeNode{name: name}
+				node.children[name] = child
+			}
+			node = child
+		}
+		node.item = &items[i]
+	}
+	return root
+}
+
+// renderTree prints the tree below root, one node per line, with children
+// sorted by name. Directories end in "/", and secrets are followed by the
+// same indicators as in list.
+func renderTree(w io.Writer, root *treeNode) {
+	renderChildren(w, root, "")
+}
+
+// renderChildren prints the children of node, each line starting with
+// indent.
+func renderChildren(w io.Writer, node *treeNode, indent string) {
+	names := make([]string, 0, len(node.children))
+	for name := range node.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		child := node.children[name]
+		branch, next := "├── ", "│   "
+		if i == len(names)-1 {
+			branch, next = "└── ", "    "
+		}
+
+		label := name
+		if len(child.children) > 0 {
+			label += "/"
+		}
+		if child.item != nil {
+			label += itemIndicators(*child.item)
+		}
+		fmt.Fprintf(w, "%s%s%s\n", indent, branch, label)
+		renderChildren(w, child, indent+next)
+	}
+}
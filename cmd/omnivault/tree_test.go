@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/agentplexus/omnivault/internal/daemon"
+)
+
+func TestRenderTree(t *testing.T) {
+	items := []daemon.SecretListItem{
+		{Path: "api/key", HasValue: true},
+		{Path: "db/prod/password", HasValue: true, Protected: true},
+		{Path: "db/prod", HasFields: true},
+		{Path: "db/staging/creds", HasValue: true, HasFields: true, Tags: []string{"env=staging"}},
+		{Path: "db/dev", AliasOf: "db/staging/creds"},
+		{Path: "token", HasValue: true},
+	}
+
+	var out bytes.Buffer
+	renderTree(&out, buildTree(items))
+
+	want := `├── api/
+│   └── key
+├── db/
+│   ├── dev -> db/staging/creds
+│   ├── prod/ (fields)
+│   │   └── password (protected)
+│   └── staging/
+│       └── creds (value+fields) [env=staging]
+└── token
+`
+	if got := out.String(); got != want {
+		t.Errorf("renderTree =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBuildTree(t *testing.T) {
+	root := buildTree([]daemon.SecretListItem{{Path: "a/b/c"}, {Path: "a/d"}})
+
+	a := root.children["a"]
+	if a == nil || a.item != nil || len(a.children) != 2 {
+		t.Fatalf("Expected directory a with two children, got %+v", a)
+	}
+	if c := a.children["b"].children["c"]; c == nil || c.item == nil || c.item.Path != "a/b/c" {
+		t.Errorf("Expected leaf a/b/c, got %+v", c)
+	}
+}
@@ -0,0 +1,182 @@
+package omnivault
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/agentplexus/omnivault/internal/yaml"
+	"github.com/agentplexus/omnivault/providers/age"
+	"github.com/agentplexus/omnivault/providers/bitwarden"
+	"github.com/agentplexus/omnivault/providers/document"
+	"github.com/agentplexus/omnivault/providers/doppler"
+	"github.com/agentplexus/omnivault/providers/env"
+	"github.com/agentplexus/omnivault/providers/file"
+	"github.com/agentplexus/omnivault/providers/infisical"
+	"github.com/agentplexus/omnivault/providers/keepass"
+	"github.com/agentplexus/omnivault/providers/keychain"
+	"github.com/agentplexus/omnivault/providers/keyring"
+	"github.com/agentplexus/omnivault/providers/libsecret"
+	"github.com/agentplexus/omnivault/providers/wincred"
+)
+
+// ConfigFile is the on-disk representation of a client configuration.
+//
+// Example (YAML):
+//
+//	provider: file
+//	config:
+//	  directory: ${HOME}/.secrets
+//	  jsonFormat: true
+//
+// String values in Config may reference environment variables as $VAR or
+// ${VAR}; they are expanded when the file is loaded.
+type ConfigFile struct {
+	// Provider is the name of a built-in provider (e.g., "env", "file").
+	Provider ProviderName `json:"provider"`
+
+	// Config contains provider-specific settings. Keys match the field
+	// names of the provider's Config struct (case-insensitive).
+	Config map[string]any `json:"config,omitempty"`
+}
+
+// NewClientFromFile creates a Client from a JSON or YAML configuration file.
+// The format is chosen by file extension (.yaml/.yml for YAML, JSON otherwise).
+func NewClientFromFile(path string) (*Client, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return NewClientFromYAML(data)
+	default:
+		return NewClientFromJSON(data)
+	}
+}
+
+// NewClientFromJSON creates a Client from a JSON configuration document.
+func NewClientFromJSON(data []byte) (*Client, error) {
+	var cf ConfigFile
+	if err := json.Unmarshal(data, &cf); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return newClientFromConfigFile(cf)
+}
+
+// NewClientFromYAML creates a Client from a YAML configuration document.
+func NewClientFromYAML(data []byte) (*Client, error) {
+	var cf ConfigFile
+	if err := yaml.Unmarshal(data, &cf); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return newClientFromConfigFile(cf)
+}
+
+// newClientFromConfigFile builds a Client from a parsed configuration file.
+func newClientFromConfigFile(cf ConfigFile) (*Client, error) {
+	if cf.Provider == "" {
+		return nil, ErrNoProvider
+	}
+
+	settings, _ := expandEnv(cf.Config).(map[string]any)
+	providerConfig, err := decodeProviderConfig(cf.Provider, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(Config{
+		Provider:       cf.Provider,
+		ProviderConfig: providerConfig,
+	})
+}
+
+// decodeProviderConfig converts generic settings into the config type
+// expected by the given built-in provider.
+func decodeProviderConfig(provider ProviderName, settings map[string]any) (any, error) {
+	var target any
+	switch provider {
+	case ProviderEnv:
+		target = &env.Config{}
+	case ProviderFile:
+		target = &file.Config{}
+	case ProviderLibSecret:
+		target = &libsecret.Config{}
+	case ProviderKeychain:
+		target = &keychain.Config{}
+	case ProviderWinCred:
+		target = &wincred.Config{}
+	case ProviderKeyring:
+		target = &keyring.Config{}
+	case ProviderDoppler:
+		target = &doppler.Config{}
+	case ProviderBitwarden:
+		target = &bitwarden.Config{}
+	case ProviderInfisical:
+		target = &infisical.Config{}
+	case ProviderDocument:
+		target = &document.Config{}
+	case ProviderKeePass:
+		target = &keepass.Config{}
+	case ProviderAge:
+		target = &age.Config{}
+	case ProviderMemory:
+		var mc struct {
+			Secrets map[string]string `json:"secrets"`
+		}
+		if err := decodeSettings(settings, &mc); err != nil {
+			return nil, fmt.Errorf("invalid %s config: %w", provider, err)
+		}
+		if mc.Secrets == nil {
+			return nil, nil
+		}
+		return mc.Secrets, nil
+	default:
+		return settings, nil
+	}
+
+	if err := decodeSettings(settings, target); err != nil {
+		return nil, fmt.Errorf("invalid %s config: %w", provider, err)
+	}
+	return target, nil
+}
+
+// decodeSettings decodes generic settings into target, rejecting unknown keys.
+func decodeSettings(settings map[string]any, target any) error {
+	if settings == nil {
+		return nil
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(target)
+}
+
+// expandEnv expands environment variable references in all string values.
+func expandEnv(v any) any {
+	switch val := v.(type) {
+	case string:
+		return os.ExpandEnv(val)
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = expandEnv(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = expandEnv(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,68 @@
+package omnivault
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewClientFromFile(t *testing.T) {
+	dir := t.TempDir()
+	secretsDir := filepath.Join(dir, "secrets")
+	if err := os.MkdirAll(secretsDir, 0700); err != nil {
+		t.Fatalf("Failed to create secrets dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(secretsDir, "api-key.txt"), []byte("key123"), 0600); err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+
+	t.Setenv("OMNIVAULT_TEST_DIR", secretsDir)
+
+	configs := map[string]string{
+		"config.yaml": "provider: file\nconfig:\n  directory: ${OMNIVAULT_TEST_DIR}\n  extension: .txt\n",
+		"config.json": `{"provider": "file", "config": {"directory": "$OMNIVAULT_TEST_DIR", "extension": ".txt"}}`,
+	}
+
+	for name, content := range configs {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			client, err := NewClientFromFile(path)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			defer client.Close()
+
+			if client.Name() != "file" {
+				t.Errorf("Expected file provider, got %s", client.Name())
+			}
+
+			value, err := client.GetValue(context.Background(), "api-key")
+			if err != nil {
+				t.Fatalf("Failed to get secret: %v", err)
+			}
+			if value != "key123" {
+				t.Errorf("Expected value 'key123', got '%s'", value)
+			}
+		})
+	}
+}
+
+func TestNewClientFromYAMLErrors(t *testing.T) {
+	if _, err := NewClientFromYAML([]byte("config:\n  directory: /tmp\n")); err == nil {
+		t.Error("Expected error for missing provider")
+	}
+
+	if _, err := NewClientFromYAML([]byte("provider: file\nconfig:\n  directroy: /tmp\n")); err == nil {
+		t.Error("Expected error for unknown config key")
+	}
+
+	// Unsupported YAML is an error rather than a prefix of "&app APP_"
+	if _, err := NewClientFromYAML([]byte("provider: env\nconfig:\n  prefix: &app APP_\n")); err == nil {
+		t.Error("Expected error for an anchor")
+	}
+}
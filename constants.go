@@ -40,9 +40,11 @@ const (
 	ProviderFile   ProviderName = "file"   // File-based
 	ProviderMemory ProviderName = "memory" // In-memory (testing)
 	ProviderDotEnv ProviderName = "dotenv" // .env files
-	ProviderSOPS   ProviderName = "sops"   // Mozilla SOPS
+	ProviderSOPS   ProviderName = "sops"   // Mozilla SOPS; import providers/sops to register
 	ProviderAge    ProviderName = "age"    // age encryption
 
+	ProviderDocument ProviderName = "document" // YAML or JSON document
+
 	// Kubernetes
 	ProviderK8sSecrets ProviderName = "k8s" // Kubernetes Secrets
 )
@@ -33,4 +33,12 @@ var (
 
 	// ErrProviderNotRegistered is returned when a scheme has no registered provider.
 	ErrProviderNotRegistered = errors.New("provider not registered for scheme")
+
+	// ErrReferenceCycle is returned when a chain of secret references leads
+	// back to a reference already followed.
+	ErrReferenceCycle = errors.New("secret reference cycle")
+
+	// ErrReferenceDepth is returned when a chain of secret references is
+	// longer than MaxResolveDepth.
+	ErrReferenceDepth = errors.New("secret reference chain too deep")
 )
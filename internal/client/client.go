@@ -2,32 +2,150 @@
 package client
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/agentplexus/omnivault/internal/config"
 	"github.com/agentplexus/omnivault/internal/daemon"
 )
 
+// DefaultRetryTimeout is how long requests are retried by default while the
+// daemon is not accepting connections (e.g. right after it was started).
+const DefaultRetryTimeout = 2 * time.Second
+
 // Client is a client for the OmniVault daemon.
 type Client struct {
-	socketPath string // Unix socket path (Unix only)
-	tcpAddr    string // TCP address (Windows only)
-	httpClient *http.Client
+	socketPath   string // Unix socket path (Unix only)
+	tcpAddr      string // TCP address (Windows only)
+	httpClient   *http.Client
+	streamClient *http.Client // no overall timeout, for event streams
+	retryTimeout time.Duration
+
+	// Connection pool tuning; zero keeps the net/http defaults
+	maxIdleConns    int
+	idleConnTimeout time.Duration
+
+	// Session token authentication
+	tokenMu   sync.Mutex
+	token     string
+	tokenFile string
+
+	// Context values sent as request headers, by context key
+	contextHeaders map[any]string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithRetry sets how long requests are retried when the daemon cannot be
+// reached (connection refused or socket not found). Application errors are
+// never retried. A zero timeout disables retries.
+func WithRetry(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.retryTimeout = timeout
+	}
 }
 
-// New creates a new daemon client.
-func New() *Client {
+// WithMaxIdleConns sets how many idle daemon connections are kept open for
+// reuse. Clients embedded in long-running programs can raise it to avoid
+// dialing the socket for concurrent requests.
+func WithMaxIdleConns(n int) Option {
+	return func(c *Client) {
+		c.maxIdleConns = n
+	}
+}
+
+// WithIdleConnTimeout sets how long an idle daemon connection is kept open
+// before it is closed.
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.idleConnTimeout = d
+	}
+}
+
+// WithToken sets the session token sent with requests to a daemon that
+// requires token authentication.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithTokenFile sets the file the session token is read from when none has
+// been set with WithToken or obtained from Init or Unlock.
+func WithTokenFile(path string) Option {
+	return func(c *Client) {
+		c.tokenFile = path
+	}
+}
+
+// WithContextHeaders sends context values as request headers, so trace
+// ids propagated through a service's context reach the daemon, which logs
+// the headers in ServerConfig.LogHeaders with each request. headers maps
+// context keys to header names. Values that are strings or fmt.Stringers
+// are sent; other values are ignored.
+func WithContextHeaders(headers map[any]string) Option {
+	return func(c *Client) {
+		if c.contextHeaders == nil {
+			c.contextHeaders = make(map[any]string, len(headers))
+		}
+		for key, name := range headers {
+			c.contextHeaders[key] = name
+		}
+	}
+}
+
+// traceParentKey is the context key of a W3C trace context.
+type traceParentKey struct{}
+
+// ContextWithTraceParent returns a context whose requests carry the W3C
+// trace context traceparent (e.g. from an OpenTelemetry span) in the
+// traceparent header, which the daemon logs by default.
+func ContextWithTraceParent(ctx context.Context, traceparent string) context.Context {
+	return context.WithValue(ctx, traceParentKey{}, traceparent)
+}
+
+// setContextHeaders sets the headers carried by ctx on req.
+func (c *Client) setContextHeaders(ctx context.Context, req *http.Request) {
+	if traceparent, ok := ctx.Value(traceParentKey{}).(string); ok && traceparent != "" {
+		req.Header.Set(daemon.HeaderTraceParent, traceparent)
+	}
+	for key, name := range c.contextHeaders {
+		var value string
+		switch v := ctx.Value(key).(type) {
+		case string:
+			value = v
+		case fmt.Stringer:
+			value = v.String()
+		}
+		if value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+}
+
+// New creates a new daemon client. The session token, if any, is read from
+// the default token file.
+func New(opts ...Option) *Client {
 	paths := config.GetPaths()
-	return NewWithPaths(paths.SocketPath, paths.TCPAddr)
+	opts = append([]Option{WithTokenFile(paths.TokenFile)}, opts...)
+	return NewWithPaths(paths.SocketPath, paths.TCPAddr, opts...)
 }
 
 // NewWithSocket creates a new daemon client with a custom socket path (for testing).
@@ -37,30 +155,48 @@ func NewWithSocket(socketPath string) *Client {
 }
 
 // NewWithPaths creates a new daemon client with custom paths (for testing).
-func NewWithPaths(socketPath, tcpAddr string) *Client {
+func NewWithPaths(socketPath, tcpAddr string, opts ...Option) *Client {
 	c := &Client{
-		socketPath: socketPath,
-		tcpAddr:    tcpAddr,
+		socketPath:   socketPath,
+		tcpAddr:      tcpAddr,
+		retryTimeout: DefaultRetryTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	// Create HTTP client with appropriate transport
+	// Create HTTP client with appropriate transport. All requests go to the
+	// same host, so idle connections are pooled per host. The transport
+	// asks for gzip and decompresses responses, which the daemon compresses
+	// when they are large, e.g. lists and exports.
+	var dialer net.Dialer
 	transport := &http.Transport{
 		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
 			if runtime.GOOS == "windows" {
-				return net.Dial("tcp", c.tcpAddr)
+				return dialer.DialContext(ctx, "tcp", c.tcpAddr)
 			}
-			return net.Dial("unix", c.socketPath)
+			return dialer.DialContext(ctx, "unix", c.socketPath)
 		},
+		MaxIdleConns:        c.maxIdleConns,
+		MaxIdleConnsPerHost: c.maxIdleConns,
+		IdleConnTimeout:     c.idleConnTimeout,
 	}
 
 	c.httpClient = &http.Client{
 		Transport: transport,
 		Timeout:   30 * time.Second,
 	}
+	c.streamClient = &http.Client{Transport: transport}
 
 	return c
 }
 
+// CloseIdleConnections closes pooled connections to the daemon that are
+// not in use.
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
+}
+
 // IsDaemonRunning checks if the daemon is running.
 func (c *Client) IsDaemonRunning() bool {
 	if runtime.GOOS == "windows" {
@@ -97,31 +233,206 @@ func (c *Client) GetStatus(ctx context.Context) (*daemon.StatusResponse, error)
 	return &resp, nil
 }
 
+// ListProviders returns the providers available in the daemon's build and
+// their capabilities.
+func (c *Client) ListProviders(ctx context.Context) (*daemon.ProvidersResponse, error) {
+	var resp daemon.ProvidersResponse
+	if err := c.get(ctx, "/providers", &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Session describes the current session, including when the vault
+// auto-locks. The vault does not need to be unlocked.
+func (c *Client) Session(ctx context.Context) (*daemon.SessionResponse, error) {
+	var resp daemon.SessionResponse
+	if err := c.get(ctx, "/session", &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// VaultInfo returns the vault's format and crypto parameters. The vault
+// does not need to be unlocked.
+func (c *Client) VaultInfo(ctx context.Context) (*daemon.VaultInfoResponse, error) {
+	var resp daemon.VaultInfoResponse
+	if err := c.get(ctx, "/vault-info", &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
 // Init initializes a new vault.
 func (c *Client) Init(ctx context.Context, password string) error {
+	_, err := c.InitWithRecovery(ctx, password)
+	return err
+}
+
+// InitWithRecovery initializes a new vault like Init and returns its
+// recovery key, which can unlock the vault if the password is forgotten.
+// The daemon does not keep the key, so it must be shown to the user.
+func (c *Client) InitWithRecovery(ctx context.Context, password string) (string, error) {
 	req := daemon.InitRequest{Password: password}
-	var resp daemon.SuccessResponse
-	return c.post(ctx, "/init", req, &resp)
+	var resp daemon.UnlockResponse
+	if err := c.post(ctx, "/init", req, &resp); err != nil {
+		return "", err
+	}
+	c.setToken(resp.Token)
+	return resp.RecoveryKey, nil
 }
 
-// Unlock unlocks the vault.
+// Unlock unlocks the vault. If the daemon requires token authentication,
+// the returned session token is used for subsequent requests.
 func (c *Client) Unlock(ctx context.Context, password string) error {
 	req := daemon.UnlockRequest{Password: password}
-	var resp daemon.SuccessResponse
-	return c.post(ctx, "/unlock", req, &resp)
+	var resp daemon.UnlockResponse
+	if err := c.post(ctx, "/unlock", req, &resp); err != nil {
+		return err
+	}
+	c.setToken(resp.Token)
+	return nil
+}
+
+// UnlockWithRecoveryKey unlocks the vault with its recovery key instead of
+// the master password. Set a new password afterwards with ChangePassword
+// and an empty old password.
+func (c *Client) UnlockWithRecoveryKey(ctx context.Context, recoveryKey string) error {
+	req := daemon.UnlockRequest{RecoveryKey: recoveryKey}
+	var resp daemon.UnlockResponse
+	if err := c.post(ctx, "/unlock", req, &resp); err != nil {
+		return err
+	}
+	c.setToken(resp.Token)
+	return nil
+}
+
+// ChangePassword changes the master password. The old password may be
+// empty after unlocking with the recovery key. With rotateRecovery the
+// recovery key is replaced too, and the new one returned.
+func (c *Client) ChangePassword(ctx context.Context, oldPassword, newPassword string, rotateRecovery bool) (string, error) {
+	req := daemon.ChangePasswordRequest{
+		OldPassword:    oldPassword,
+		NewPassword:    newPassword,
+		RotateRecovery: rotateRecovery,
+	}
+	var resp daemon.ChangePasswordResponse
+	if err := c.post(ctx, "/password", req, &resp); err != nil {
+		return "", err
+	}
+	return resp.RecoveryKey, nil
+}
+
+// Token returns the session token sent with requests, if any.
+func (c *Client) Token() string {
+	c.tokenMu.Lock()
+	token := c.token
+	c.tokenMu.Unlock()
+
+	if token == "" && c.tokenFile != "" {
+		if data, err := os.ReadFile(c.tokenFile); err == nil {
+			token = strings.TrimSpace(string(data))
+		}
+	}
+	return token
+}
+
+// setToken records a session token returned by the daemon.
+func (c *Client) setToken(token string) {
+	if token == "" {
+		return
+	}
+	c.tokenMu.Lock()
+	c.token = token
+	c.tokenMu.Unlock()
 }
 
 // Lock locks the vault.
 func (c *Client) Lock(ctx context.Context) error {
 	var resp daemon.SuccessResponse
-	return c.post(ctx, "/lock", nil, &resp)
+	if err := c.post(ctx, "/lock", nil, &resp); err != nil {
+		return err
+	}
+
+	// Locking invalidates the session token
+	c.tokenMu.Lock()
+	c.token = ""
+	c.tokenMu.Unlock()
+	return nil
+}
+
+// SubscribeEvents subscribes to vault lock state transitions. Events are
+// delivered on the returned channel until ctx is cancelled or the daemon
+// closes the stream, after which the channel is closed.
+func (c *Client) SubscribeEvents(ctx context.Context) (<-chan daemon.Event, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/events", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	c.setContextHeaders(ctx, req)
+
+	resp, err := c.streamClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+
+	events := make(chan daemon.Event)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue // event names, comments and separators
+			}
+
+			var e daemon.Event
+			if err := json.Unmarshal([]byte(data), &e); err != nil {
+				continue
+			}
+
+			select {
+			case events <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
 }
 
 // ListSecrets returns all secrets.
 func (c *Client) ListSecrets(ctx context.Context, prefix string) (*daemon.ListResponse, error) {
-	path := "/secrets"
+	return c.ListSecretsPage(ctx, prefix, "", 0)
+}
+
+// ListSecretsPage returns up to limit secrets after the given cursor.
+// Pass an empty cursor for the first page and the response's NextCursor
+// for subsequent pages; an empty NextCursor means there are no more pages.
+// A limit of zero returns all remaining secrets.
+func (c *Client) ListSecretsPage(ctx context.Context, prefix, cursor string, limit int) (*daemon.ListResponse, error) {
+	query := url.Values{}
 	if prefix != "" {
-		path += "?prefix=" + prefix
+		query.Set("prefix", prefix)
+	}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	path := "/secrets"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
 	}
 
 	var resp daemon.ListResponse
@@ -131,6 +442,82 @@ func (c *Client) ListSecrets(ctx context.Context, prefix string) (*daemon.ListRe
 	return &resp, nil
 }
 
+// WalkSecrets calls fn for each page of secrets matching the prefix,
+// fetching pages of the given size until all secrets have been visited.
+func (c *Client) WalkSecrets(ctx context.Context, prefix string, pageSize int, fn func(items []daemon.SecretListItem) error) error {
+	cursor := ""
+	for {
+		resp, err := c.ListSecretsPage(ctx, prefix, cursor, pageSize)
+		if err != nil {
+			return err
+		}
+		if err := fn(resp.Secrets); err != nil {
+			return err
+		}
+		if resp.NextCursor == "" {
+			return nil
+		}
+		cursor = resp.NextCursor
+	}
+}
+
+// maxStreamLine is the longest line accepted in a streamed list.
+const maxStreamLine = 1 << 20
+
+// StreamSecrets lists the secrets matching the prefix as the daemon sends
+// them, sorted by path, so that neither side holds the whole list. The
+// sequence ends after the last secret, or with a single error, e.g. when
+// the vault locks part way. Stopping the iteration closes the stream.
+func (c *Client) StreamSecrets(ctx context.Context, prefix string) iter.Seq2[daemon.SecretListItem, error] {
+	return func(yield func(daemon.SecretListItem, error) bool) {
+		path := "/secrets/stream"
+		if prefix != "" {
+			path += "?" + url.Values{"prefix": {prefix}}.Encode()
+		}
+
+		resp, err := c.send(ctx, c.streamClient, http.MethodGet, path, nil, nil)
+		if err != nil {
+			yield(daemon.SecretListItem{}, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= 400 {
+			body, _ := io.ReadAll(resp.Body)
+			yield(daemon.SecretListItem{}, responseError(resp.StatusCode, body))
+			return
+		}
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(nil, maxStreamLine)
+		for scanner.Scan() {
+			var line daemon.ListStreamLine
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				yield(daemon.SecretListItem{}, fmt.Errorf("failed to unmarshal response: %w", err))
+				return
+			}
+
+			switch {
+			case line.Error != "":
+				yield(daemon.SecretListItem{}, &DaemonError{StatusCode: resp.StatusCode, Code: line.Code, Message: line.Error})
+				return
+			case line.Done:
+				return
+			case line.Secret != nil:
+				if !yield(*line.Secret, nil) {
+					return
+				}
+			}
+		}
+
+		err = scanner.Err()
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		yield(daemon.SecretListItem{}, fmt.Errorf("failed to read response: %w", err))
+	}
+}
+
 // GetSecret retrieves a secret.
 func (c *Client) GetSecret(ctx context.Context, path string) (*daemon.SecretResponse, error) {
 	var resp daemon.SecretResponse
@@ -140,6 +527,165 @@ func (c *Client) GetSecret(ctx context.Context, path string) (*daemon.SecretResp
 	return &resp, nil
 }
 
+// GetProtectedSecret retrieves a secret, confirming access with the master
+// password. This is required for secrets marked as protected.
+func (c *Client) GetProtectedSecret(ctx context.Context, path, password string) (*daemon.SecretResponse, error) {
+	return c.getSecret(ctx, path, nil, password)
+}
+
+// GetSecretField retrieves a single field of a secret. The response only
+// contains the requested field.
+func (c *Client) GetSecretField(ctx context.Context, path, field string) (*daemon.SecretResponse, error) {
+	return c.getSecret(ctx, path, fieldQuery(field), "")
+}
+
+// GetProtectedSecretField retrieves a single field of a secret, confirming
+// access with the master password. This is required for protected fields.
+func (c *Client) GetProtectedSecretField(ctx context.Context, path, field, password string) (*daemon.SecretResponse, error) {
+	return c.getSecret(ctx, path, fieldQuery(field), password)
+}
+
+// GetSecretVersion retrieves a version of a secret: its current version or
+// one of the previous versions kept when it was rotated. A password is
+// required for protected secrets, and may be empty otherwise.
+func (c *Client) GetSecretVersion(ctx context.Context, path, version, password string) (*daemon.SecretResponse, error) {
+	return c.getSecret(ctx, path, url.Values{"version": {version}}, password)
+}
+
+// RotateSecret replaces a secret's value with a generated one, keeping the
+// previous value as a version, and returns the new version without its
+// value.
+func (c *Client) RotateSecret(ctx context.Context, path string) (*daemon.SecretResponse, error) {
+	var resp daemon.SecretResponse
+	if err := c.post(ctx, "/rotate", daemon.RotateRequest{Path: path}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RenderSecret retrieves a secret with its template rendered over its
+// fields as the value. A password is required if the secret has protected
+// fields, and may be empty otherwise.
+func (c *Client) RenderSecret(ctx context.Context, path, password string) (*daemon.SecretResponse, error) {
+	return c.getSecret(ctx, path, url.Values{"render": {"true"}}, password)
+}
+
+// getSecret retrieves a secret with the given query parameters, such as
+// the field to return, with optional confirmation.
+func (c *Client) getSecret(ctx context.Context, path string, query url.Values, password string) (*daemon.SecretResponse, error) {
+	endpoint := "/secret/" + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var header http.Header
+	if password != "" {
+		header = http.Header{}
+		header.Set(daemon.HeaderConfirmPassword, password)
+	}
+
+	var resp daemon.SecretResponse
+	if err := c.do(ctx, http.MethodGet, endpoint, nil, &resp, header); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// fieldQuery returns the query parameters selecting a single field of a
+// secret, or none if field is empty.
+func fieldQuery(field string) url.Values {
+	if field == "" {
+		return nil
+	}
+	return url.Values{"field": {field}}
+}
+
+// ProtectField marks a field of a secret as protected so it is omitted from
+// normal reads.
+func (c *Client) ProtectField(ctx context.Context, path, field string) error {
+	req := daemon.ProtectRequest{Path: path, Field: field, Protected: true}
+	var resp daemon.SuccessResponse
+	return c.post(ctx, "/protect", req, &resp)
+}
+
+// UnprotectField removes protection from a field. The master password is required.
+func (c *Client) UnprotectField(ctx context.Context, path, field, password string) error {
+	req := daemon.ProtectRequest{Path: path, Field: field, Protected: false, Password: password}
+	var resp daemon.SuccessResponse
+	return c.post(ctx, "/protect", req, &resp)
+}
+
+// SetAlias makes path an alias of target, so reading path returns the
+// target secret and writing path updates the target.
+func (c *Client) SetAlias(ctx context.Context, path, target string) error {
+	req := daemon.AliasRequest{Path: path, Target: target}
+	var resp daemon.SuccessResponse
+	return c.post(ctx, "/alias", req, &resp)
+}
+
+// MigratePaths renames secrets to their normalized paths and enables path
+// normalization for the vault. It returns the renamed paths, old to new.
+func (c *Client) MigratePaths(ctx context.Context) (map[string]string, error) {
+	var resp daemon.MigratePathsResponse
+	if err := c.post(ctx, "/migrate-paths", struct{}{}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Renamed, nil
+}
+
+// MovePrefix moves every secret under from to the same path under to and
+// returns the moved paths, old to new. Existing destinations are refused
+// unless force is set.
+func (c *Client) MovePrefix(ctx context.Context, from, to string, force bool) (map[string]string, error) {
+	req := daemon.MoveRequest{From: from, To: to, Force: force}
+	var resp daemon.MoveResponse
+	if err := c.post(ctx, "/move", req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Moved, nil
+}
+
+// MoveSecret moves the secret at from to a new path in the daemon, keeping
+// its protected fields, metadata and version history. An existing secret
+// at to is refused unless force is set.
+func (c *Client) MoveSecret(ctx context.Context, from, to string, force bool) error {
+	req := daemon.MoveRequest{From: from, To: to, Path: true, Force: force}
+	return c.post(ctx, "/move", req, nil)
+}
+
+// CopySecret copies the secret at from to a new path in the daemon, so
+// its value is never sent to the client. An existing secret at to is
+// refused unless force is set.
+func (c *Client) CopySecret(ctx context.Context, from, to string, force bool) error {
+	req := daemon.CopyRequest{From: from, To: to, Force: force}
+	return c.post(ctx, "/copy", req, nil)
+}
+
+// Protect marks a secret as protected so reading it requires confirmation.
+func (c *Client) Protect(ctx context.Context, path string) error {
+	req := daemon.ProtectRequest{Path: path, Protected: true}
+	var resp daemon.SuccessResponse
+	return c.post(ctx, "/protect", req, &resp)
+}
+
+// Unprotect removes protection from a secret. The master password is required.
+func (c *Client) Unprotect(ctx context.Context, path, password string) error {
+	req := daemon.ProtectRequest{Path: path, Protected: false, Password: password}
+	var resp daemon.SuccessResponse
+	return c.post(ctx, "/protect", req, &resp)
+}
+
+// ExportPlain returns every secret decrypted, as a consistent point-in-time
+// snapshot. The master password is required and the export is audit logged.
+func (c *Client) ExportPlain(ctx context.Context, password string) (*daemon.ExportResponse, error) {
+	req := daemon.ExportRequest{Confirm: true, Password: password}
+	var resp daemon.ExportResponse
+	if err := c.post(ctx, "/export-plain", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
 // SetSecret stores a secret.
 func (c *Client) SetSecret(ctx context.Context, path, value string, fields, tags map[string]string) error {
 	req := daemon.SetSecretRequest{
@@ -147,16 +693,163 @@ func (c *Client) SetSecret(ctx context.Context, path, value string, fields, tags
 		Fields: fields,
 		Tags:   tags,
 	}
+	return c.PutSecret(ctx, path, req)
+}
+
+// SetSecretResult describes a secret as stored by SetSecretReturning: its
+// metadata and timestamps, without the value and fields.
+type SetSecretResult = daemon.SecretResponse
+
+// SetSecretReturning stores a secret like SetSecret and returns it as
+// stored, saving a Get to learn its timestamps.
+func (c *Client) SetSecretReturning(ctx context.Context, path, value string, fields, tags map[string]string) (*SetSecretResult, error) {
+	req := daemon.SetSecretRequest{
+		Value:  value,
+		Fields: fields,
+		Tags:   tags,
+	}
+	return c.PutSecretReturning(ctx, path, req)
+}
+
+// MergeSecret merges fields and tags into an existing secret, keeping
+// anything not provided. An empty value keeps the existing value.
+func (c *Client) MergeSecret(ctx context.Context, path, value string, fields, tags map[string]string) error {
+	req := daemon.SetSecretRequest{
+		Value:  value,
+		Fields: fields,
+		Tags:   tags,
+		Merge:  true,
+	}
+	return c.PutSecret(ctx, path, req)
+}
+
+// PutSecret stores a secret using a fully specified request.
+func (c *Client) PutSecret(ctx context.Context, path string, req daemon.SetSecretRequest) error {
+	_, err := c.PutSecretReturning(ctx, path, req)
+	return err
+}
+
+// PutSecretReturning stores a secret using a fully specified request and
+// returns it as stored.
+func (c *Client) PutSecretReturning(ctx context.Context, path string, req daemon.SetSecretRequest) (*SetSecretResult, error) {
+	var resp SetSecretResult
+	if err := c.request(ctx, http.MethodPut, "/secret/"+path, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteSecrets deletes every secret under prefix and returns the number
+// deleted. An empty prefix is refused unless all is set. If the daemon
+// has soft delete enabled, the secrets are moved into the trash.
+func (c *Client) DeleteSecrets(ctx context.Context, prefix string, all bool) (int, error) {
+	return c.deleteSecrets(ctx, prefix, all, false)
+}
+
+// DeleteSecretsPermanently deletes every secret under prefix like
+// DeleteSecrets, bypassing the trash.
+func (c *Client) DeleteSecretsPermanently(ctx context.Context, prefix string, all bool) (int, error) {
+	return c.deleteSecrets(ctx, prefix, all, true)
+}
+
+func (c *Client) deleteSecrets(ctx context.Context, prefix string, all, permanent bool) (int, error) {
+	query := url.Values{}
+	if prefix != "" {
+		query.Set("prefix", prefix)
+	}
+	if all {
+		query.Set("all", "true")
+	}
+	if permanent {
+		query.Set("permanent", "true")
+	}
+
+	path := "/secrets"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var resp daemon.DeleteSecretsResponse
+	if err := c.request(ctx, http.MethodDelete, path, nil, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Deleted, nil
+}
+
+// UpdateMetadata changes a secret's tags, labels or expiry without
+// resending its value.
+func (c *Client) UpdateMetadata(ctx context.Context, path string, req daemon.UpdateMetadataRequest) error {
 	var resp daemon.SuccessResponse
-	return c.request(ctx, http.MethodPut, "/secret/"+path, req, &resp)
+	return c.request(ctx, http.MethodPatch, "/secret/"+path+"/metadata", req, &resp)
 }
 
-// DeleteSecret removes a secret.
+// UpdateMetadataPrefix changes the tags, labels or expiry of every secret
+// under prefix and returns the number updated. An empty prefix is refused
+// unless all is set.
+func (c *Client) UpdateMetadataPrefix(ctx context.Context, prefix string, all bool, req daemon.UpdateMetadataRequest) (int, error) {
+	query := url.Values{}
+	if prefix != "" {
+		query.Set("prefix", prefix)
+	}
+	if all {
+		query.Set("all", "true")
+	}
+
+	path := "/secrets"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var resp daemon.UpdateSecretsResponse
+	if err := c.request(ctx, http.MethodPatch, path, req, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Updated, nil
+}
+
+// DeleteSecret removes a secret. If the daemon has soft delete enabled,
+// the secret is moved into the trash.
 func (c *Client) DeleteSecret(ctx context.Context, path string) error {
 	var resp daemon.SuccessResponse
 	return c.request(ctx, http.MethodDelete, "/secret/"+path, nil, &resp)
 }
 
+// DeleteSecretPermanently removes a secret, bypassing the trash.
+func (c *Client) DeleteSecretPermanently(ctx context.Context, path string) error {
+	var resp daemon.SuccessResponse
+	return c.request(ctx, http.MethodDelete, "/secret/"+path+"?permanent=true", nil, &resp)
+}
+
+// ListTrash lists the secrets in the trash.
+func (c *Client) ListTrash(ctx context.Context) (*daemon.TrashResponse, error) {
+	var resp daemon.TrashResponse
+	if err := c.get(ctx, "/trash", &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RestoreSecret moves a secret from the trash back to path.
+func (c *Client) RestoreSecret(ctx context.Context, path string) error {
+	var resp daemon.SuccessResponse
+	return c.post(ctx, "/restore", daemon.RestoreRequest{Path: path}, &resp)
+}
+
+// PurgeTrash permanently removes the secret trashed from path, or the
+// whole trash if path is empty, and returns the number removed.
+func (c *Client) PurgeTrash(ctx context.Context, path string) (int, error) {
+	endpoint := "/trash"
+	if path != "" {
+		endpoint += "?" + url.Values{"path": {path}}.Encode()
+	}
+
+	var resp daemon.DeleteSecretsResponse
+	if err := c.request(ctx, http.MethodDelete, endpoint, nil, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Deleted, nil
+}
+
 // Stop stops the daemon.
 func (c *Client) Stop(ctx context.Context) error {
 	var resp daemon.SuccessResponse
@@ -175,28 +868,23 @@ func (c *Client) post(ctx context.Context, path string, body, result any) error
 
 // request performs an HTTP request.
 func (c *Client) request(ctx context.Context, method, path string, body, result any) error {
-	var bodyReader io.Reader
+	return c.do(ctx, method, path, body, result, nil)
+}
+
+// do performs an HTTP request with optional extra headers.
+func (c *Client) do(ctx context.Context, method, path string, body, result any, header http.Header) error {
+	var data []byte
 	if body != nil {
-		data, err := json.Marshal(body)
+		var err error
+		data, err = json.Marshal(body)
 		if err != nil {
 			return fmt.Errorf("failed to marshal request: %w", err)
 		}
-		bodyReader = bytes.NewReader(data)
 	}
 
-	// Use "http://localhost" as the host; the transport will use the socket
-	req, err := http.NewRequestWithContext(ctx, method, "http://localhost"+path, bodyReader)
+	resp, err := c.send(ctx, c.httpClient, method, path, data, header)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
@@ -207,15 +895,7 @@ func (c *Client) request(ctx context.Context, method, path string, body, result
 
 	// Check for error response
 	if resp.StatusCode >= 400 {
-		var errResp daemon.ErrorResponse
-		if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.Error != "" {
-			return &DaemonError{
-				StatusCode: resp.StatusCode,
-				Code:       errResp.Code,
-				Message:    errResp.Error,
-			}
-		}
-		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(respBody))
+		return responseError(resp.StatusCode, respBody)
 	}
 
 	if result != nil {
@@ -227,6 +907,79 @@ func (c *Client) request(ctx context.Context, method, path string, body, result
 	return nil
 }
 
+// responseError converts an error response to a *DaemonError, if the
+// daemon described the error.
+func responseError(statusCode int, body []byte) error {
+	var errResp daemon.ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != "" {
+		return &DaemonError{
+			StatusCode: statusCode,
+			Code:       errResp.Code,
+			Message:    errResp.Error,
+		}
+	}
+	return fmt.Errorf("request failed with status %d: %s", statusCode, string(body))
+}
+
+// send sends a request with hc, retrying with backoff while the daemon is
+// unreachable.
+func (c *Client) send(ctx context.Context, hc *http.Client, method, path string, data []byte, header http.Header) (*http.Response, error) {
+	deadline := time.Now().Add(c.retryTimeout)
+	backoff := 50 * time.Millisecond
+
+	for {
+		var bodyReader io.Reader
+		if data != nil {
+			bodyReader = bytes.NewReader(data)
+		}
+
+		// Use "http://localhost" as the host; the transport will use the socket
+		req, err := http.NewRequestWithContext(ctx, method, "http://localhost"+path, bodyReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		if data != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if token := c.Token(); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		c.setContextHeaders(ctx, req)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+
+		resp, err := hc.Do(req)
+		if err == nil {
+			return resp, nil
+		}
+
+		if !isTransient(err) || time.Now().Add(backoff).After(deadline) {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("request failed: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > 500*time.Millisecond {
+			backoff = 500 * time.Millisecond
+		}
+	}
+}
+
+// isTransient reports whether err means the daemon is not (yet) accepting
+// connections, as opposed to an error from the daemon itself.
+func isTransient(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ENOENT) ||
+		errors.Is(err, os.ErrNotExist)
+}
+
 // DaemonError represents an error from the daemon.
 type DaemonError struct {
 	StatusCode int
@@ -248,7 +1001,26 @@ func (e *DaemonError) IsVaultLocked() bool {
 
 // IsNotFound returns true if the error indicates not found.
 func (e *DaemonError) IsNotFound() bool {
-	return e.Code == daemon.ErrCodeSecretNotFound || e.Code == daemon.ErrCodeVaultNotFound
+	return e.Code == daemon.ErrCodeSecretNotFound || e.Code == daemon.ErrCodeVaultNotFound ||
+		e.Code == daemon.ErrCodeFieldNotFound || e.Code == daemon.ErrCodeVersionNotFound
+}
+
+// IsAlreadyExists returns true if the error indicates the destination of
+// a write already exists.
+func (e *DaemonError) IsAlreadyExists() bool {
+	return e.Code == daemon.ErrCodeAlreadyExists
+}
+
+// IsConfirmationRequired returns true if the error indicates the secret is
+// protected and requires the master password to be read.
+func (e *DaemonError) IsConfirmationRequired() bool {
+	return e.Code == daemon.ErrCodeConfirmationRequired
+}
+
+// IsUnauthorized returns true if the error indicates a missing or invalid
+// session token.
+func (e *DaemonError) IsUnauthorized() bool {
+	return e.Code == daemon.ErrCodeUnauthorized
 }
 
 // IsInvalidPassword returns true if the error indicates invalid password.
@@ -0,0 +1,175 @@
+package client
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/agentplexus/omnivault/internal/daemon"
+)
+
+// countingListener counts accepted connections.
+type countingListener struct {
+	net.Listener
+	accepted atomic.Int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.accepted.Add(1)
+	}
+	return conn, err
+}
+
+func TestConnectionReuse(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a Unix socket")
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	counter := &countingListener{Listener: ln}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"running":true}`))
+	})
+	server := &http.Server{Handler: mux}
+	go func() { _ = server.Serve(counter) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	c := NewWithPaths(socketPath, "", WithMaxIdleConns(4))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		var status daemon.StatusResponse
+		if err := c.get(ctx, "/status", &status); err != nil {
+			t.Fatalf("Request %d failed: %v", i, err)
+		}
+	}
+
+	if n := counter.accepted.Load(); n != 1 {
+		t.Errorf("Expected sequential requests to share 1 connection, got %d", n)
+	}
+
+	// After closing idle connections the next request dials again
+	c.CloseIdleConnections()
+	var status daemon.StatusResponse
+	if err := c.get(ctx, "/status", &status); err != nil {
+		t.Fatalf("Request after close failed: %v", err)
+	}
+	if n := counter.accepted.Load(); n != 2 {
+		t.Errorf("Expected a new connection after CloseIdleConnections, got %d", n)
+	}
+}
+
+func TestCompressedResponse(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a Unix socket")
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	want := daemon.ListResponse{}
+	for i := 0; i < 500; i++ {
+		want.Secrets = append(want.Secrets, daemon.SecretListItem{Path: fmt.Sprintf("app/secret-%03d", i), HasValue: true})
+	}
+	want.Count = len(want.Secrets)
+
+	var gzipped atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/secrets", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			_ = json.NewEncoder(w).Encode(want)
+			return
+		}
+		gzipped.Store(true)
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		_ = json.NewEncoder(zw).Encode(want)
+		_ = zw.Close()
+	})
+	server := &http.Server{Handler: mux}
+	go func() { _ = server.Serve(ln) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	c := NewWithPaths(socketPath, "")
+	got, err := c.ListSecrets(context.Background(), "")
+	if err != nil {
+		t.Fatalf("ListSecrets failed: %v", err)
+	}
+	if !gzipped.Load() {
+		t.Error("Expected the client to accept gzip")
+	}
+	if !reflect.DeepEqual(got, &want) {
+		t.Errorf("Expected the decompressed list to match, got %d secrets", got.Count)
+	}
+}
+
+type requestIDKey struct{}
+
+func TestContextHeaders(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a Unix socket")
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	received := make(chan http.Header, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(daemon.StatusResponse{Running: true})
+	})
+	server := &http.Server{Handler: mux}
+	go func() { _ = server.Serve(ln) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	c := NewWithPaths(socketPath, "", WithContextHeaders(map[any]string{requestIDKey{}: "X-Request-Id"}))
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-42")
+	ctx = ContextWithTraceParent(ctx, traceparent)
+
+	if _, err := c.GetStatus(ctx); err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	header := <-received
+	if got := header.Get("X-Request-Id"); got != "req-42" {
+		t.Errorf("X-Request-Id = %q, want req-42", got)
+	}
+	if got := header.Get(daemon.HeaderTraceParent); got != traceparent {
+		t.Errorf("Traceparent = %q, want %s", got, traceparent)
+	}
+
+	if _, err := c.GetStatus(context.Background()); err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	header = <-received
+	if header.Get("X-Request-Id") != "" || header.Get(daemon.HeaderTraceParent) != "" {
+		t.Errorf("Expected no context headers without context values, got %v", header)
+	}
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// EnvPasswordCommand names the environment variable holding a command
+// that prints the master password, e.g. a helper that reads it from the
+// OS keychain.
+const EnvPasswordCommand = "OMNIVAULT_PASSWORD_COMMAND"
+
+// PasswordFromCommand runs command and returns the master password it
+// prints on standard output, without the trailing newline. Like git's
+// credential.helper, the command is run by the shell ("sh -c", or
+// "cmd /C" on Windows), so it may quote arguments and use pipes. Its
+// standard input and error are the terminal's, so it can prompt or report
+// errors.
+func PasswordFromCommand(ctx context.Context, command string) (string, error) {
+	if strings.TrimSpace(command) == "" {
+		return "", errors.New("password command is empty")
+	}
+
+	var stdout bytes.Buffer
+	cmd := shellCommand(ctx, command)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if runtime.GOOS != "windows" && errors.As(err, &exitErr) && exitErr.ExitCode() == 127 {
+			// The shell's status for a command it cannot find
+			return "", fmt.Errorf("password command not found: %w", err)
+		}
+		return "", fmt.Errorf("password command failed: %w", err)
+	}
+
+	password := strings.TrimSuffix(stdout.String(), "\n")
+	password = strings.TrimSuffix(password, "\r")
+	if password == "" {
+		return "", errors.New("password command printed no password")
+	}
+	return password, nil
+}
+
+// shellCommand returns a command running command with the system shell.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeScript writes an executable shell script to a temporary directory
+// and returns its path.
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	path := filepath.Join(t.TempDir(), "password-helper")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0700); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	return path
+}
+
+func TestPasswordFromCommand(t *testing.T) {
+	ctx := context.Background()
+
+	script := writeScript(t, `printf 'pass word\n'`)
+	password, err := PasswordFromCommand(ctx, script)
+	if err != nil {
+		t.Fatalf("PasswordFromCommand failed: %v", err)
+	}
+	if password != "pass word" {
+		t.Errorf("Expected 'pass word', got %q", password)
+	}
+
+	// Arguments are passed to the command
+	script = writeScript(t, `printf '%s' "$1"`)
+	if password, err := PasswordFromCommand(ctx, script+" s3cret"); err != nil || password != "s3cret" {
+		t.Errorf("Expected 's3cret', got %q, %v", password, err)
+	}
+
+	// The shell handles quoting, so an argument may contain spaces
+	if password, err := PasswordFromCommand(ctx, script+` "omni vault"`); err != nil || password != "omni vault" {
+		t.Errorf("Expected 'omni vault', got %q, %v", password, err)
+	}
+
+	// A path with spaces can be quoted too
+	dir := filepath.Join(t.TempDir(), "my helpers")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	spaced := filepath.Join(dir, "helper")
+	if err := os.Rename(script, spaced); err != nil {
+		t.Fatal(err)
+	}
+	if password, err := PasswordFromCommand(ctx, `'`+spaced+`' x | tr x y`); err != nil || password != "y" {
+		t.Errorf("Expected 'y' through a pipe, got %q, %v", password, err)
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		want    string
+	}{
+		{"empty", "  ", "empty"},
+		{"missing", filepath.Join(t.TempDir(), "missing"), "not found"},
+		{"failing", writeScript(t, "exit 3"), "failed"},
+		{"no output", writeScript(t, "true"), "no password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := PasswordFromCommand(ctx, tt.command)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Package clock abstracts the current time and timers so that expiry and
+// auto-lock can be tested without sleeping.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and schedules functions.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// AfterFunc calls f in its own goroutine after d has elapsed, like
+	// time.AfterFunc. Fake clocks call it when they are advanced past d.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a function scheduled with Clock.AfterFunc.
+type Timer interface {
+	// Stop prevents the function from being called. It reports whether
+	// the call was stopped, false if it already ran or was stopped.
+	Stop() bool
+}
+
+// Real is the Clock backed by the time package.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
+
+// Fake is a Clock whose time only moves when Advance or Set is called.
+// It is safe for concurrent use.
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFake returns a fake clock set to now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns the fake clock's time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// AfterFunc schedules fn to run when the clock is advanced by d or more.
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTimer{clock: f, when: f.now.Add(d), fn: fn}
+	f.timers = append(f.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d and runs the functions that became
+// due, in order, before returning. Unlike time.AfterFunc they run on the
+// calling goroutine, so tests can check their effects right away.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the clock to now and runs the functions that became due, as
+// Advance does. Moving the clock backwards runs nothing.
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	f.now = now
+	var due, pending []*fakeTimer
+	for _, t := range f.timers {
+		if t.when.After(now) {
+			pending = append(pending, t)
+		} else {
+			due = append(due, t)
+		}
+	}
+	f.timers = pending
+	f.mu.Unlock()
+
+	sort.SliceStable(due, func(i, j int) bool { return due[i].when.Before(due[j].when) })
+	for _, t := range due {
+		t.fn()
+	}
+}
+
+// Jump moves the clock forward by d without running the functions that
+// became due, as happens to real timers while the system sleeps: they run
+// on the monotonic clock, which stops during suspend. They run on the next
+// Advance or Set.
+func (f *Fake) Jump(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// Pending returns the number of scheduled functions that have not run.
+func (f *Fake) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+type fakeTimer struct {
+	clock *Fake
+	when  time.Time
+	fn    func()
+}
+
+func (t *fakeTimer) Stop() bool {
+	f := t.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, pending := range f.timers {
+		if pending == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
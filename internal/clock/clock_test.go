@@ -0,0 +1,75 @@
+package clock
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+
+	var ran []string
+	c.AfterFunc(2*time.Minute, func() { ran = append(ran, "two") })
+	c.AfterFunc(time.Minute, func() { ran = append(ran, "one") })
+	stopped := c.AfterFunc(time.Minute, func() { ran = append(ran, "stopped") })
+
+	if !stopped.Stop() {
+		t.Error("Expected Stop of a pending timer to return true")
+	}
+	if stopped.Stop() {
+		t.Error("Expected a second Stop to return false")
+	}
+
+	c.Advance(59 * time.Second)
+	if len(ran) != 0 {
+		t.Errorf("Expected nothing to run yet, got %v", ran)
+	}
+
+	c.Advance(2 * time.Minute)
+	if want := []string{"one", "two"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("Ran = %v, want %v", ran, want)
+	}
+	if got, want := c.Now(), start.Add(179*time.Second); !got.Equal(want) {
+		t.Errorf("Now = %v, want %v", got, want)
+	}
+	if c.Pending() != 0 {
+		t.Errorf("Expected no pending timers, got %d", c.Pending())
+	}
+}
+
+func TestFakeRescheduleFromCallback(t *testing.T) {
+	c := NewFake(time.Unix(0, 0))
+
+	count := 0
+	var tick func()
+	tick = func() {
+		count++
+		c.AfterFunc(time.Second, tick)
+	}
+	c.AfterFunc(time.Second, tick)
+
+	c.Advance(time.Second)
+	if count != 1 || c.Pending() != 1 {
+		t.Errorf("Expected one run and one pending timer, got %d and %d", count, c.Pending())
+	}
+}
+
+func TestFakeJump(t *testing.T) {
+	start := time.Unix(0, 0)
+	c := NewFake(start)
+
+	ran := false
+	c.AfterFunc(time.Minute, func() { ran = true })
+
+	c.Jump(time.Hour)
+	if ran || !c.Now().Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected Jump to move the clock without running timers, ran = %v, now = %v", ran, c.Now())
+	}
+
+	c.Advance(0)
+	if !ran {
+		t.Error("Expected the due timer to run on the next Advance")
+	}
+}
@@ -27,29 +27,66 @@ type Paths struct {
 	// PIDFile is the daemon PID file.
 	PIDFile string
 
+	// TokenFile holds the session token while the vault is unlocked,
+	// when the daemon requires token authentication.
+	TokenFile string
+
 	// LogFile is the daemon log file.
 	LogFile string
 }
 
-// GetPaths returns the appropriate paths for the current platform.
+// Environment variables that override the default paths.
+const (
+	// EnvConfigDir roots all paths in the given directory instead of the
+	// platform default.
+	EnvConfigDir = "OMNIVAULT_CONFIG_DIR"
+
+	// EnvSocket overrides the daemon address: the Unix socket path, or the
+	// TCP address on Windows.
+	EnvSocket = "OMNIVAULT_SOCKET"
+)
+
+// GetPaths returns the appropriate paths for the current platform. The
+// OMNIVAULT_CONFIG_DIR and OMNIVAULT_SOCKET environment variables override
+// the defaults, so isolated instances can run side by side.
 func GetPaths() *Paths {
+	configDir := os.Getenv(EnvConfigDir)
+
+	var paths *Paths
 	switch runtime.GOOS {
 	case "windows":
-		return windowsPaths()
+		if configDir == "" {
+			configDir = windowsConfigDir()
+		}
+		paths = windowsPaths(configDir)
 	default:
-		return unixPaths()
+		if configDir == "" {
+			configDir = unixConfigDir()
+		}
+		paths = unixPaths(configDir)
+	}
+
+	if socket := os.Getenv(EnvSocket); socket != "" {
+		if runtime.GOOS == "windows" {
+			paths.TCPAddr = socket
+		} else {
+			paths.SocketPath = socket
+		}
 	}
+	return paths
 }
 
-// unixPaths returns paths for macOS and Linux.
-func unixPaths() *Paths {
+// unixConfigDir returns the default configuration directory for macOS and Linux.
+func unixConfigDir() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		home = "."
 	}
+	return filepath.Join(home, ".omnivault")
+}
 
-	configDir := filepath.Join(home, ".omnivault")
-
+// unixPaths returns paths for macOS and Linux.
+func unixPaths(configDir string) *Paths {
 	return &Paths{
 		ConfigDir:  configDir,
 		VaultFile:  filepath.Join(configDir, "vault.enc"),
@@ -57,19 +94,22 @@ func unixPaths() *Paths {
 		SocketPath: filepath.Join(configDir, "omnivaultd.sock"),
 		PIDFile:    filepath.Join(configDir, "omnivaultd.pid"),
 		LogFile:    filepath.Join(configDir, "omnivaultd.log"),
+		TokenFile:  filepath.Join(configDir, "omnivaultd.token"),
 	}
 }
 
-// windowsPaths returns paths for Windows.
-func windowsPaths() *Paths {
+// windowsConfigDir returns the default configuration directory for Windows.
+func windowsConfigDir() string {
 	localAppData := os.Getenv("LOCALAPPDATA")
 	if localAppData == "" {
 		home, _ := os.UserHomeDir()
 		localAppData = filepath.Join(home, "AppData", "Local")
 	}
+	return filepath.Join(localAppData, "OmniVault")
+}
 
-	configDir := filepath.Join(localAppData, "OmniVault")
-
+// windowsPaths returns paths for Windows.
+func windowsPaths(configDir string) *Paths {
 	return &Paths{
 		ConfigDir:  configDir,
 		VaultFile:  filepath.Join(configDir, "vault.enc"),
@@ -78,6 +118,7 @@ func windowsPaths() *Paths {
 		TCPAddr:    "127.0.0.1:19839",
 		PIDFile:    filepath.Join(configDir, "omnivaultd.pid"),
 		LogFile:    filepath.Join(configDir, "omnivaultd.log"),
+		TokenFile:  filepath.Join(configDir, "omnivaultd.token"),
 	}
 }
 
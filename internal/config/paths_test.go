@@ -0,0 +1,69 @@
+package config
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestGetPathsConfigDirOverride(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvConfigDir, dir)
+	t.Setenv(EnvSocket, "")
+
+	paths := GetPaths()
+
+	if paths.ConfigDir != dir {
+		t.Errorf("ConfigDir = %q, want %q", paths.ConfigDir, dir)
+	}
+
+	files := map[string]string{
+		"VaultFile": paths.VaultFile,
+		"MetaFile":  paths.MetaFile,
+		"PIDFile":   paths.PIDFile,
+		"LogFile":   paths.LogFile,
+		"TokenFile": paths.TokenFile,
+	}
+	if runtime.GOOS != "windows" {
+		files["SocketPath"] = paths.SocketPath
+	}
+	for name, path := range files {
+		if filepath.Dir(path) != dir {
+			t.Errorf("%s = %q, want it under %q", name, path, dir)
+		}
+	}
+}
+
+func TestGetPathsSocketOverride(t *testing.T) {
+	t.Setenv(EnvConfigDir, t.TempDir())
+
+	if runtime.GOOS == "windows" {
+		t.Setenv(EnvSocket, "127.0.0.1:29839")
+		if paths := GetPaths(); paths.TCPAddr != "127.0.0.1:29839" {
+			t.Errorf("TCPAddr = %q, want override", paths.TCPAddr)
+		}
+		return
+	}
+
+	socket := filepath.Join(t.TempDir(), "custom.sock")
+	t.Setenv(EnvSocket, socket)
+	if paths := GetPaths(); paths.SocketPath != socket {
+		t.Errorf("SocketPath = %q, want %q", paths.SocketPath, socket)
+	}
+}
+
+func TestGetPathsDefault(t *testing.T) {
+	t.Setenv(EnvConfigDir, "")
+	t.Setenv(EnvSocket, "")
+
+	paths := GetPaths()
+
+	want := ".omnivault"
+	if runtime.GOOS == "windows" {
+		want = "OmniVault"
+	}
+	if !strings.HasSuffix(paths.ConfigDir, want) {
+		t.Errorf("ConfigDir = %q, want default ending in %q", paths.ConfigDir, want)
+	}
+}
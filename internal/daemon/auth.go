@@ -0,0 +1,81 @@
+package daemon
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// tokenBytes is the number of random bytes in a session token.
+const tokenBytes = 32
+
+// issueToken returns the current session token, creating one and writing it
+// to the token file if there is none. It returns an empty token when token
+// authentication is disabled. Callers must hold s.mu.
+func (s *Server) issueToken() (string, error) {
+	if !s.requireToken {
+		return "", nil
+	}
+	if s.token != "" {
+		return s.token, nil
+	}
+
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	if s.paths.TokenFile != "" {
+		// Remove first so a stale file can't keep looser permissions
+		_ = os.Remove(s.paths.TokenFile)
+		if err := os.WriteFile(s.paths.TokenFile, []byte(token), 0600); err != nil {
+			return "", err
+		}
+	}
+
+	s.token = token
+	return token, nil
+}
+
+// revokeToken invalidates the session token. Callers must hold s.mu.
+func (s *Server) revokeToken() {
+	if s.token == "" {
+		return
+	}
+	s.token = ""
+	if s.paths.TokenFile != "" {
+		_ = os.Remove(s.paths.TokenFile)
+	}
+}
+
+// authorized wraps a handler so that, when token authentication is enabled
+// and the vault is unlocked, requests must carry the session token.
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.requireToken {
+			s.mu.RLock()
+			token := s.token
+			s.mu.RUnlock()
+
+			// Without a token the vault is locked; let the handler say so
+			if token != "" && !validToken(r, token) {
+				s.writeError(w, http.StatusUnauthorized, "missing or invalid session token", ErrCodeUnauthorized)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// validToken reports whether the request carries the expected bearer token.
+func validToken(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
@@ -0,0 +1,137 @@
+package daemon
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// DefaultCompressMinBytes is the default response size from which responses
+// are gzip-compressed for clients that accept it.
+const DefaultCompressMinBytes = 8 << 10
+
+// compressResponses wraps a handler so responses of at least the
+// configured size are gzip-compressed when the request accepts gzip.
+// Smaller responses, and streams flushed before reaching the size, are
+// sent as is.
+func (s *Server) compressResponses(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.compressMinBytes <= 0 || !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+		cw := &compressWriter{ResponseWriter: w, minBytes: s.compressMinBytes}
+		defer cw.finish()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, header := range r.Header.Values("Accept-Encoding") {
+		for _, coding := range strings.Split(header, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(coding), ";")
+			if strings.EqualFold(strings.TrimSpace(name), "gzip") &&
+				strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compressWriter buffers a response until it reaches minBytes, then
+// switches to gzip. A response that ends or is flushed before that is
+// written uncompressed.
+type compressWriter struct {
+	http.ResponseWriter
+	minBytes int
+
+	status      int
+	buf         bytes.Buffer
+	gz          *gzip.Writer
+	passthrough bool
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	switch {
+	case w.gz != nil:
+		return w.gz.Write(b)
+	case w.passthrough:
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.buf.Write(b)
+	if w.buf.Len() < w.minBytes {
+		return len(b), nil
+	}
+
+	h := w.Header()
+	h.Set("Content-Encoding", "gzip")
+	h.Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+	if _, err := w.gz.Write(w.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	w.buf.Reset()
+	return len(b), nil
+}
+
+// FlushError sends what has been written so far. Before compression has
+// started, the response continues uncompressed.
+func (w *compressWriter) FlushError() error {
+	if w.gz != nil {
+		if err := w.gz.Flush(); err != nil {
+			return err
+		}
+	} else {
+		w.startPassthrough()
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// startPassthrough writes the header and buffered data uncompressed.
+func (w *compressWriter) startPassthrough() {
+	if w.passthrough {
+		return
+	}
+	w.passthrough = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
+
+// finish completes the response.
+func (w *compressWriter) finish() {
+	if w.gz != nil {
+		_ = w.gz.Close()
+		return
+	}
+	if w.status == 0 && w.buf.Len() == 0 {
+		return // nothing written; the server sends 200 with an empty body
+	}
+	w.startPassthrough()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,5 @@
+// Package daemonpb holds the daemon's gRPC service and its Go client,
+// generated from daemon.proto.
+package daemonpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative daemon.proto
@@ -0,0 +1,145 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// eventBufferSize is the number of events buffered per subscriber. Events
+// are dropped for subscribers that fall this far behind.
+const eventBufferSize = 16
+
+// eventBroker fans out events to subscribers of the /events stream.
+type eventBroker struct {
+	mu     sync.Mutex
+	subs   map[chan Event]struct{}
+	closed bool
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subs: make(map[chan Event]struct{})}
+}
+
+// subscribe registers a new subscriber. The channel is closed when the
+// subscriber is removed or the broker is closed.
+func (b *eventBroker) subscribe() chan Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, eventBufferSize)
+	if b.closed {
+		close(ch)
+		return ch
+	}
+	b.subs[ch] = struct{}{}
+	return ch
+}
+
+// unsubscribe removes a subscriber and closes its channel.
+func (b *eventBroker) unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// publish sends an event to all subscribers without blocking. It reports
+// the number of subscribers the event was dropped for.
+func (b *eventBroker) publish(e Event) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	dropped := 0
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// close closes all subscriber channels, ending their streams.
+func (b *eventBroker) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+	b.closed = true
+}
+
+// emit notifies callbacks and subscribers of a lock state transition.
+// Callers must hold s.mu.
+func (s *Server) emit(eventType, reason string) {
+	e := Event{Type: eventType, Reason: reason, Time: s.clock.Now()}
+
+	switch eventType {
+	case EventLocked:
+		if s.onLock != nil {
+			s.onLock(e)
+		}
+	case EventUnlocked:
+		if s.onUnlock != nil {
+			s.onUnlock(e)
+		}
+	}
+
+	if dropped := s.events.publish(e); dropped > 0 {
+		s.logger.Warn("dropped event for slow subscribers", "event", eventType, "subscribers", dropped)
+	}
+}
+
+// handleEvents streams lock state transitions as server-sent events, one
+// JSON-encoded Event per transition.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	ch := s.events.subscribe()
+	defer s.events.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				s.requestLogger(r).Error("failed to encode event", "error", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/agentplexus/omnivault/internal/daemon/daemonpb"
+)
+
+// ErrorDomain is the domain of the ErrorInfo detail of failed gRPC calls,
+// whose reason is the daemon error code.
+const ErrorDomain = "omnivault.daemon"
+
+// grpcService implements the gRPC service by passing each call to the
+// handler of the matching HTTP endpoint, so both transports behave the
+// same, including authorization, auto-lock and request logging.
+type grpcService struct {
+	daemonpb.UnimplementedVaultServer
+	handler http.Handler
+}
+
+// newGRPCServer returns a gRPC server for the daemon's service.
+func (s *Server) newGRPCServer() *grpc.Server {
+	gs := grpc.NewServer()
+	daemonpb.RegisterVaultServer(gs, &grpcService{handler: s.handler()})
+	return gs
+}
+
+// createGRPCListener creates the listener for the gRPC service.
+func (s *Server) createGRPCListener() (net.Listener, error) {
+	if runtime.GOOS == "windows" {
+		return net.Listen("tcp", s.grpcAddr)
+	}
+
+	_ = os.Remove(s.grpcAddr)
+	return net.Listen("unix", s.grpcAddr)
+}
+
+// grpcRoute is the HTTP request a gRPC call is served by.
+type grpcRoute struct {
+	method   string
+	path     string
+	query    url.Values
+	body     any
+	password string // confirms access to protected secrets
+}
+
+// call serves a gRPC call with the HTTP endpoint of route, and decodes the
+// response into resp unless it is nil.
+func (g *grpcService) call(ctx context.Context, route grpcRoute, resp any) error {
+	var reader io.Reader = http.NoBody
+	if route.body != nil {
+		data, err := json.Marshal(route.body)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		reader = bytes.NewReader(data)
+	}
+	r, err := http.NewRequestWithContext(ctx, route.method, "/", reader)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	r.URL = &url.URL{Path: route.path, RawQuery: route.query.Encode()}
+
+	// Metadata, such as the session token and request IDs, becomes headers
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, values := range md {
+		if strings.HasPrefix(key, ":") || strings.HasPrefix(key, "grpc-") {
+			continue
+		}
+		r.Header[http.CanonicalHeaderKey(key)] = values
+	}
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Del("Accept-Encoding")
+	r.Header.Del(HeaderConfirmPassword)
+	if route.password != "" {
+		r.Header.Set(HeaderConfirmPassword, route.password)
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		r.RemoteAddr = p.Addr.String()
+	}
+
+	rec := &grpcRecorder{header: make(http.Header)}
+	g.handler.ServeHTTP(rec, r)
+
+	if rec.status >= http.StatusBadRequest {
+		var errResp ErrorResponse
+		_ = json.Unmarshal(rec.body.Bytes(), &errResp)
+		return grpcError(rec.status, errResp)
+	}
+	if resp != nil {
+		if err := json.Unmarshal(rec.body.Bytes(), resp); err != nil {
+			return status.Error(codes.Internal, "failed to decode response: "+err.Error())
+		}
+	}
+	return nil
+}
+
+// grpcError converts an HTTP error response to a gRPC status error.
+func grpcError(httpStatus int, resp ErrorResponse) error {
+	code := codes.Internal
+	switch resp.Code {
+	case ErrCodeVaultLocked, ErrCodeVaultNotFound:
+		code = codes.FailedPrecondition
+	case ErrCodeSecretNotFound, ErrCodeFieldNotFound, ErrCodeVersionNotFound:
+		code = codes.NotFound
+	case ErrCodeInvalidPassword, ErrCodeUnauthorized:
+		code = codes.Unauthenticated
+	case ErrCodeConfirmationRequired:
+		code = codes.PermissionDenied
+	case ErrCodeAlreadyExists:
+		code = codes.AlreadyExists
+	case ErrCodeInvalidRequest:
+		code = codes.InvalidArgument
+	default:
+		switch httpStatus {
+		case http.StatusBadRequest:
+			code = codes.InvalidArgument
+		case http.StatusRequestEntityTooLarge:
+			code = codes.ResourceExhausted
+		case http.StatusMethodNotAllowed:
+			code = codes.Unimplemented
+		}
+	}
+
+	message := resp.Error
+	if message == "" {
+		message = http.StatusText(httpStatus)
+	}
+	st := status.New(code, message)
+	if resp.Code == "" {
+		return st.Err()
+	}
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: resp.Code, Domain: ErrorDomain})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// ErrorCode returns the daemon error code of a failed gRPC call, such as
+// ErrCodeSecretNotFound, or "" if it has none.
+func ErrorCode(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Domain == ErrorDomain {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+func (g *grpcService) Status(ctx context.Context, _ *daemonpb.StatusRequest) (*daemonpb.StatusResponse, error) {
+	var resp StatusResponse
+	if err := g.call(ctx, grpcRoute{method: http.MethodGet, path: "/status"}, &resp); err != nil {
+		return nil, err
+	}
+	return &daemonpb.StatusResponse{
+		Locked:      resp.Locked,
+		VaultExists: resp.VaultExists,
+		SecretCount: int64(resp.SecretCount),
+		UnlockedAt:  timestamp(resp.UnlockedAt),
+		Uptime:      resp.Uptime,
+	}, nil
+}
+
+func (g *grpcService) Unlock(ctx context.Context, req *daemonpb.UnlockRequest) (*daemonpb.UnlockResponse, error) {
+	var resp UnlockResponse
+	body := UnlockRequest{Password: req.GetPassword(), RecoveryKey: req.GetRecoveryKey()}
+	if err := g.call(ctx, grpcRoute{method: http.MethodPost, path: "/unlock", body: body}, &resp); err != nil {
+		return nil, err
+	}
+	return &daemonpb.UnlockResponse{Token: resp.Token}, nil
+}
+
+func (g *grpcService) Lock(ctx context.Context, _ *daemonpb.LockRequest) (*daemonpb.LockResponse, error) {
+	if err := g.call(ctx, grpcRoute{method: http.MethodPost, path: "/lock"}, nil); err != nil {
+		return nil, err
+	}
+	return &daemonpb.LockResponse{}, nil
+}
+
+func (g *grpcService) Get(ctx context.Context, req *daemonpb.GetRequest) (*daemonpb.Secret, error) {
+	if req.GetPath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "path is required")
+	}
+	query := url.Values{}
+	if req.GetField() != "" {
+		query.Set("field", req.GetField())
+	}
+	if req.GetVersion() != "" {
+		query.Set("version", req.GetVersion())
+	}
+
+	var resp SecretResponse
+	route := grpcRoute{method: http.MethodGet, path: "/secret/" + req.GetPath(), query: query, password: req.GetPassword()}
+	if err := g.call(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+	return &daemonpb.Secret{
+		Path:            resp.Path,
+		Value:           resp.Value,
+		Fields:          resp.Fields,
+		Notes:           resp.Notes,
+		Tags:            resp.Tags,
+		Labels:          resp.Labels,
+		Protected:       resp.Protected,
+		CreatedAt:       timestamp(resp.CreatedAt),
+		UpdatedAt:       timestamp(resp.UpdatedAt),
+		ExpiresAt:       optionalTimestamp(resp.ExpiresAt),
+		Template:        resp.Template,
+		Version:         resp.Version,
+		ProtectedFields: resp.ProtectedFields,
+	}, nil
+}
+
+func (g *grpcService) Set(ctx context.Context, req *daemonpb.SetRequest) (*daemonpb.SetResponse, error) {
+	if req.GetPath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "path is required")
+	}
+	body := SetSecretRequest{
+		Value:    req.GetValue(),
+		Fields:   req.GetFields(),
+		Tags:     req.GetTags(),
+		Notes:    req.GetNotes(),
+		Template: req.GetTemplate(),
+		Merge:    req.GetMerge(),
+	}
+	if err := g.call(ctx, grpcRoute{method: http.MethodPut, path: "/secret/" + req.GetPath(), body: body}, nil); err != nil {
+		return nil, err
+	}
+	return &daemonpb.SetResponse{}, nil
+}
+
+func (g *grpcService) List(ctx context.Context, req *daemonpb.ListRequest) (*daemonpb.ListResponse, error) {
+	var resp ListResponse
+	route := grpcRoute{method: http.MethodGet, path: "/secrets", query: url.Values{"prefix": {req.GetPrefix()}}}
+	if err := g.call(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+	list := &daemonpb.ListResponse{Secrets: make([]*daemonpb.SecretListItem, 0, len(resp.Secrets))}
+	for _, item := range resp.Secrets {
+		list.Secrets = append(list.Secrets, &daemonpb.SecretListItem{
+			Path:      item.Path,
+			HasValue:  item.HasValue,
+			HasFields: item.HasFields,
+			Tags:      item.Tags,
+			Protected: item.Protected,
+			UpdatedAt: timestamp(item.UpdatedAt),
+			AliasOf:   item.AliasOf,
+		})
+	}
+	return list, nil
+}
+
+func (g *grpcService) Delete(ctx context.Context, req *daemonpb.DeleteRequest) (*daemonpb.DeleteResponse, error) {
+	if req.GetPath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "path is required")
+	}
+	query := url.Values{}
+	if req.GetPermanent() {
+		query.Set("permanent", strconv.FormatBool(true))
+	}
+	if err := g.call(ctx, grpcRoute{method: http.MethodDelete, path: "/secret/" + req.GetPath(), query: query}, nil); err != nil {
+		return nil, err
+	}
+	return &daemonpb.DeleteResponse{}, nil
+}
+
+// timestamp converts t to a protobuf timestamp, nil if it is zero.
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// optionalTimestamp converts t to a protobuf timestamp, nil if t is nil.
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamp(*t)
+}
+
+// grpcRecorder is the http.ResponseWriter a gRPC call's endpoint writes
+// to.
+type grpcRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *grpcRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *grpcRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
+
+func (rec *grpcRecorder) Write(p []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return rec.body.Write(p)
+}
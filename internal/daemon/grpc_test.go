@@ -0,0 +1,127 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/agentplexus/omnivault/internal/daemon/daemonpb"
+)
+
+// testGRPCClient serves the gRPC service of s in memory and returns a
+// client for it.
+func testGRPCClient(t *testing.T, s *Server) daemonpb.VaultClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	gs := s.newGRPCServer()
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///omnivault",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create gRPC client: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return daemonpb.NewVaultClient(conn)
+}
+
+// wantGRPCError fails the test unless err has the gRPC code and daemon
+// error code.
+func wantGRPCError(t *testing.T, err error, code codes.Code, errCode string) {
+	t.Helper()
+	if status.Code(err) != code || ErrorCode(err) != errCode {
+		t.Errorf("Expected %s %s, got %v (%s)", code, errCode, err, ErrorCode(err))
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	s := NewServerWithPaths(ServerConfig{
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		GRPC:         true,
+		RequireToken: true,
+	}, testPaths(t))
+	c := testGRPCClient(t, s)
+	ctx := context.Background()
+
+	if err := s.store.Initialize("testpassword123"); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if err := s.store.Lock(); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+
+	_, err := c.Get(ctx, &daemonpb.GetRequest{Path: "db"})
+	wantGRPCError(t, err, codes.FailedPrecondition, ErrCodeVaultLocked)
+	_, err = c.Unlock(ctx, &daemonpb.UnlockRequest{Password: "wrong"})
+	wantGRPCError(t, err, codes.Unauthenticated, ErrCodeInvalidPassword)
+
+	unlocked, err := c.Unlock(ctx, &daemonpb.UnlockRequest{Password: "testpassword123"})
+	if err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if unlocked.GetToken() == "" {
+		t.Fatal("Expected a session token")
+	}
+	st, err := c.Status(ctx, &daemonpb.StatusRequest{})
+	if err != nil || st.GetLocked() || st.GetUnlockedAt() == nil {
+		t.Errorf("Expected an unlocked status, got %v, %v", st, err)
+	}
+
+	// Secret calls need the session token
+	set := &daemonpb.SetRequest{Path: "db/main", Value: "hunter2", Fields: map[string]string{"user": "admin"}}
+	_, err = c.Set(ctx, set)
+	wantGRPCError(t, err, codes.Unauthenticated, ErrCodeUnauthorized)
+
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+unlocked.GetToken())
+	if _, err := c.Set(authCtx, set); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	secret, err := c.Get(authCtx, &daemonpb.GetRequest{Path: "db/main"})
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if secret.GetValue() != "hunter2" || secret.GetFields()["user"] != "admin" || secret.GetUpdatedAt() == nil {
+		t.Errorf("Unexpected secret: %v", secret)
+	}
+	field, err := c.Get(authCtx, &daemonpb.GetRequest{Path: "db/main", Field: "user"})
+	if err != nil || field.GetValue() != "" || field.GetFields()["user"] != "admin" {
+		t.Errorf("Expected only the field, got %v, %v", field, err)
+	}
+	_, err = c.Get(authCtx, &daemonpb.GetRequest{Path: "db/main", Field: "missing"})
+	wantGRPCError(t, err, codes.NotFound, ErrCodeFieldNotFound)
+	if _, err := c.Get(authCtx, &daemonpb.GetRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without a path, got %v", err)
+	}
+
+	list, err := c.List(authCtx, &daemonpb.ListRequest{Prefix: "db/"})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list.GetSecrets()) != 1 || list.GetSecrets()[0].GetPath() != "db/main" || !list.GetSecrets()[0].GetHasFields() {
+		t.Errorf("Unexpected list: %v", list)
+	}
+
+	if _, err := c.Delete(authCtx, &daemonpb.DeleteRequest{Path: "db/main"}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	_, err = c.Get(authCtx, &daemonpb.GetRequest{Path: "db/main"})
+	wantGRPCError(t, err, codes.NotFound, ErrCodeSecretNotFound)
+
+	if _, err := c.Lock(ctx, &daemonpb.LockRequest{}); err != nil || !s.store.IsLocked() {
+		t.Errorf("Expected Lock to lock the vault, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+)
+
+const (
+	// DefaultMaxRequestBytes is the default limit on request body size.
+	DefaultMaxRequestBytes = 10 << 20
+
+	// DefaultVaultSizeWarning is the default vault file size above which
+	// the daemon logs a warning after each change.
+	DefaultVaultSizeWarning = 100 << 20
+)
+
+// limitRequests wraps a handler so request bodies larger than the
+// configured limit are rejected, and warns when a change leaves the vault
+// file larger than the warning size.
+func (s *Server) limitRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.maxRequestBytes > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, s.maxRequestBytes)
+		}
+
+		next.ServeHTTP(w, r)
+
+		if r.Method != http.MethodGet && s.vaultSizeWarning > 0 {
+			if info, err := os.Stat(s.paths.VaultFile); err == nil && info.Size() > s.vaultSizeWarning {
+				s.requestLogger(r).Warn("vault file exceeds size warning",
+					"size", info.Size(),
+					"warning_size", s.vaultSizeWarning,
+				)
+			}
+		}
+	})
+}
+
+// decodeRequest decodes a JSON request body into v. On failure it writes
+// the error response, 413 if the body is over the size limit, and returns
+// false.
+func (s *Server) decodeRequest(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		s.writeError(w, http.StatusRequestEntityTooLarge, "request body too large", ErrCodeInvalidRequest)
+	} else {
+		s.writeError(w, http.StatusBadRequest, "invalid request body", ErrCodeInvalidRequest)
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package daemon
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// requestIDBytes is the number of random bytes in a request id.
+const requestIDBytes = 4
+
+// maxLoggedHeaderBytes caps the length of a logged header value.
+const maxLoggedHeaderBytes = 256
+
+// DefaultLogHeaders are the request headers logged when
+// ServerConfig.LogHeaders is nil.
+var DefaultLogHeaders = []string{HeaderTraceParent}
+
+// loggerKey is the context key for the request-scoped logger.
+type loggerKey struct{}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// logRequests wraps a handler so that each request gets a short id and a
+// logger carrying it, and is logged with its status and duration once it
+// completes.
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		logger := s.logger.With(s.requestAttrs(r)...)
+		rec := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), loggerKey{}, logger)))
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		// Query strings are never logged; they may carry path prefixes
+		path := r.URL.Path
+		if s.redactPaths {
+			logger.Debug("request path", "path", path)
+			path = redactPath(path)
+		}
+
+		logger.Info("request",
+			"method", r.Method,
+			"path", path,
+			"status", status,
+			"duration", time.Since(start),
+		)
+	})
+}
+
+// requestAttrs returns the attributes every log line of a request
+// carries: a new request id and the values of the logged headers, keyed by
+// their lower-case names.
+func (s *Server) requestAttrs(r *http.Request) []any {
+	attrs := []any{"request_id", newRequestID()}
+	for _, name := range s.logHeaders {
+		value := r.Header.Get(name)
+		if value == "" {
+			continue
+		}
+		if len(value) > maxLoggedHeaderBytes {
+			value = value[:maxLoggedHeaderBytes]
+		}
+		attrs = append(attrs, strings.ToLower(name), value)
+	}
+	return attrs
+}
+
+// requestLogger returns the logger for a request, tagged with its id.
+func (s *Server) requestLogger(r *http.Request) *slog.Logger {
+	if logger, ok := r.Context().Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return s.logger
+}
+
+// redactPath removes the secret path from a request path.
+func redactPath(path string) string {
+	if strings.HasPrefix(path, "/secret/") {
+		return "/secret/[redacted]"
+	}
+	return path
+}
+
+// newRequestID returns a short random hex id.
+func newRequestID() string {
+	b := make([]byte, requestIDBytes)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
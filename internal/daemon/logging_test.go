@@ -0,0 +1,120 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// logRecords decodes JSON log lines into maps.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func newLoggingServer(buf *bytes.Buffer, redact bool) *Server {
+	handler := slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return &Server{logger: slog.New(handler), redactPaths: redact}
+}
+
+func TestLogRequests(t *testing.T) {
+	var buf bytes.Buffer
+	s := newLoggingServer(&buf, false)
+
+	handler := s.logRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requestLogger(r).Info("inside handler")
+		s.writeError(w, http.StatusNotFound, "secret not found", ErrCodeSecretNotFound)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/secret/db/password?prefix=x", nil))
+
+	records := logRecords(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d: %s", len(records), buf.String())
+	}
+	inner, req := records[0], records[1]
+
+	id, _ := req["request_id"].(string)
+	if id == "" {
+		t.Fatalf("Expected request id, got %v", req)
+	}
+	if inner["request_id"] != id {
+		t.Errorf("Handler log has request id %v, want %s", inner["request_id"], id)
+	}
+
+	if req["method"] != http.MethodGet || req["status"] != float64(http.StatusNotFound) {
+		t.Errorf("Unexpected request log: %v", req)
+	}
+	if req["path"] != "/secret/db/password" {
+		t.Errorf("Expected path without query, got %v", req["path"])
+	}
+	if _, ok := req["duration"]; !ok {
+		t.Errorf("Expected duration, got %v", req)
+	}
+}
+
+func TestLogRequestsRedactPaths(t *testing.T) {
+	var buf bytes.Buffer
+	s := newLoggingServer(&buf, true)
+
+	handler := s.logRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/secret/db/password", nil))
+
+	records := logRecords(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d: %s", len(records), buf.String())
+	}
+	debug, info := records[0], records[1]
+
+	if debug["level"] != "DEBUG" || debug["path"] != "/secret/db/password" {
+		t.Errorf("Expected the full path at debug level, got %v", debug)
+	}
+	if info["path"] != "/secret/[redacted]" {
+		t.Errorf("Expected redacted path at info level, got %v", info["path"])
+	}
+	if info["status"] != float64(http.StatusOK) {
+		t.Errorf("Expected implicit 200 status, got %v", info["status"])
+	}
+}
+
+func TestLogRequestsHeaders(t *testing.T) {
+	var buf bytes.Buffer
+	s := newLoggingServer(&buf, false)
+	s.logHeaders = []string{HeaderTraceParent, "X-Request-Id"}
+
+	handler := s.logRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requestLogger(r).Info("inside handler")
+	}))
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	req.Header.Set(HeaderTraceParent, traceparent)
+	req.Header.Set("X-Request-Id", strings.Repeat("x", maxLoggedHeaderBytes+10))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	records := logRecords(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d: %s", len(records), buf.String())
+	}
+	for _, record := range records {
+		if record["traceparent"] != traceparent {
+			t.Errorf("Expected traceparent on every line, got %v", record)
+		}
+		if id, _ := record["x-request-id"].(string); len(id) != maxLoggedHeaderBytes {
+			t.Errorf("Expected x-request-id truncated to %d bytes, got %d", maxLoggedHeaderBytes, len(id))
+		}
+		if record["request_id"] == nil {
+			t.Errorf("Expected request id, got %v", record)
+		}
+	}
+}
@@ -1,13 +1,19 @@
 // Package daemon provides the OmniVault daemon server.
 package daemon
 
-import "time"
+import (
+	"time"
+
+	"github.com/agentplexus/omnivault/vault"
+)
 
 // Request types for daemon IPC.
 
-// UnlockRequest is the request to unlock the vault.
+// UnlockRequest is the request to unlock the vault with the master
+// password, or with the recovery key when RecoveryKey is set.
 type UnlockRequest struct {
-	Password string `json:"password"`
+	Password    string `json:"password"`
+	RecoveryKey string `json:"recovery_key,omitempty"`
 }
 
 // SetSecretRequest is the request to set a secret.
@@ -15,12 +21,59 @@ type SetSecretRequest struct {
 	Value  string            `json:"value,omitempty"`
 	Fields map[string]string `json:"fields,omitempty"`
 	Tags   map[string]string `json:"tags,omitempty"`
+	Notes  string            `json:"notes,omitempty"`
+
+	// Template sets a Go template over the secret's fields that is
+	// rendered as its value when read with ?render=true. Overwriting a
+	// secret without one keeps the existing template.
+	Template string `json:"template,omitempty"`
+
+	// Merge merges fields and tags into an existing secret instead of
+	// replacing it. An empty Value or Notes keeps the existing one.
+	Merge bool `json:"merge,omitempty"`
+
+	// Replace, combined with Merge, overwrites the existing value even
+	// when Value is empty.
+	Replace bool `json:"replace,omitempty"`
+}
+
+// UpdateMetadataRequest changes a secret's metadata without touching its
+// value or fields. Tags are merged into the existing tags.
+type UpdateMetadataRequest struct {
+	Tags         map[string]string `json:"tags,omitempty"`
+	RemoveTags   []string          `json:"remove_tags,omitempty"`
+	Labels       []string          `json:"labels,omitempty"`
+	RemoveLabels []string          `json:"remove_labels,omitempty"`
+
+	// ExpiresAt sets the expiry; ClearExpiry removes it.
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	ClearExpiry bool       `json:"clear_expiry,omitempty"`
 }
 
 // ChangePasswordRequest is the request to change the master password.
+// OldPassword may be empty if the vault was unlocked with the recovery
+// key. RotateRecovery also replaces the recovery key.
 type ChangePasswordRequest struct {
-	OldPassword string `json:"old_password"`
-	NewPassword string `json:"new_password"`
+	OldPassword    string `json:"old_password"`
+	NewPassword    string `json:"new_password"`
+	RotateRecovery bool   `json:"rotate_recovery,omitempty"`
+}
+
+// ChangePasswordResponse is the response for a password change.
+// RecoveryKey is set to the new recovery key if it was rotated.
+type ChangePasswordResponse struct {
+	Success     bool   `json:"success"`
+	RecoveryKey string `json:"recovery_key,omitempty"`
+}
+
+// ProtectRequest is the request to protect or unprotect a secret, or a
+// single field of it when Field is set. Removing protection requires the
+// master password.
+type ProtectRequest struct {
+	Path      string `json:"path"`
+	Field     string `json:"field,omitempty"`
+	Protected bool   `json:"protected"`
+	Password  string `json:"password,omitempty"`
 }
 
 // InitRequest is the request to initialize a new vault.
@@ -40,14 +93,74 @@ type StatusResponse struct {
 	Uptime      string    `json:"uptime"`
 }
 
+// SessionResponse describes the current session: which vault is unlocked
+// and how long until it locks. Session tokens are revoked when the vault
+// locks, so they expire with it.
+type SessionResponse struct {
+	Vault      string     `json:"vault"` // path of the vault file
+	Locked     bool       `json:"locked"`
+	UnlockedAt *time.Time `json:"unlocked_at,omitempty"`
+	Recovered  bool       `json:"recovered,omitempty"` // unlocked with the recovery key
+
+	// TokenRequired is set if secret requests need a session token, and
+	// TokenValid if this request carried the current one.
+	TokenRequired  bool       `json:"token_required"`
+	TokenValid     bool       `json:"token_valid"`
+	TokenExpiresAt *time.Time `json:"token_expires_at,omitempty"`
+
+	// AutoLockAt is when the vault locks unless there is activity before
+	// then, and AutoLockIn the time left until then.
+	AutoLockAt *time.Time `json:"auto_lock_at,omitempty"`
+	AutoLockIn string     `json:"auto_lock_in,omitempty"`
+}
+
+// VaultInfoResponse describes the vault's format and crypto parameters. It
+// never includes the salt or the password verification blob.
+type VaultInfoResponse struct {
+	Version        int       `json:"version"`
+	CreatedAt      time.Time `json:"created_at"`
+	SaltLength     int       `json:"salt_length"`
+	KDF            string    `json:"kdf"`
+	KDFParams      KDFParams `json:"kdf_params"`
+	Cipher         string    `json:"cipher"`
+	NormalizePaths bool      `json:"normalize_paths"`
+	RecoveryKey    bool      `json:"recovery_key"`
+
+	// MaxSecretBytes is the daemon's limit on the size of a secret, or
+	// zero if there is none.
+	MaxSecretBytes int64 `json:"max_secret_bytes,omitempty"`
+}
+
+// KDFParams are the key derivation parameters of the vault.
+type KDFParams struct {
+	Time      uint32 `json:"time"`
+	MemoryKiB uint32 `json:"memory_kib"`
+	Threads   uint8  `json:"threads"`
+	KeyLen    uint32 `json:"key_len"`
+}
+
 // SecretResponse is the response for get secret requests.
 type SecretResponse struct {
 	Path      string            `json:"path"`
 	Value     string            `json:"value,omitempty"`
 	Fields    map[string]string `json:"fields,omitempty"`
+	Notes     string            `json:"notes,omitempty"`
 	Tags      map[string]string `json:"tags,omitempty"`
+	Labels    []string          `json:"labels,omitempty"`
+	Protected bool              `json:"protected,omitempty"`
 	CreatedAt time.Time         `json:"created_at,omitempty"`
 	UpdatedAt time.Time         `json:"updated_at,omitempty"`
+	ExpiresAt *time.Time        `json:"expires_at,omitempty"`
+	Template  string            `json:"template,omitempty"`
+	Version   string            `json:"version,omitempty"` // set once the secret has been rotated
+
+	// LastAccessedAt is when the secret was last read, if the daemon
+	// tracks access.
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+
+	// ProtectedFields names fields omitted from the response because they
+	// are protected. Request them individually to reveal them.
+	ProtectedFields []string `json:"protected_fields,omitempty"`
 }
 
 // SecretListItem is an item in the secret list (metadata only).
@@ -56,13 +169,130 @@ type SecretListItem struct {
 	HasValue  bool      `json:"has_value"`
 	HasFields bool      `json:"has_fields"`
 	Tags      []string  `json:"tags,omitempty"`
+	Protected bool      `json:"protected,omitempty"`
 	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	AliasOf   string    `json:"alias_of,omitempty"`
+
+	// LastAccessedAt is when the secret was last read, if the daemon
+	// tracks access.
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
 }
 
 // ListResponse is the response for list requests.
+// Secrets are sorted by path. When a limit was requested and more secrets
+// remain, NextCursor is set and can be passed back to fetch the next page.
 type ListResponse struct {
-	Secrets []SecretListItem `json:"secrets"`
-	Count   int              `json:"count"`
+	Secrets    []SecretListItem `json:"secrets"`
+	Count      int              `json:"count"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// ListStreamLine is one line of a streamed list (/secrets/stream), which
+// is newline-delimited JSON. Every line but the last holds a secret. The
+// last line has Done set with the number of secrets sent, or Error set if
+// the list failed part way; a stream without it was cut off.
+type ListStreamLine struct {
+	Secret *SecretListItem `json:"secret,omitempty"`
+	Done   bool            `json:"done,omitempty"`
+	Count  int             `json:"count,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	Code   string          `json:"code,omitempty"`
+}
+
+// AliasRequest is the request to make Path an alias of Target.
+type AliasRequest struct {
+	Path   string `json:"path"`
+	Target string `json:"target"`
+}
+
+// MoveRequest is the request to move every secret under From to To, or
+// with Path set the single secret at From to To. Existing destination
+// paths are refused unless Force is set.
+type MoveRequest struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Path  bool   `json:"path,omitempty"`
+	Force bool   `json:"force,omitempty"`
+}
+
+// MoveResponse lists the secrets moved by a prefix move.
+type MoveResponse struct {
+	Moved map[string]string `json:"moved"` // old path -> new path
+}
+
+// CopyRequest is the request to copy the secret at From to To. An existing
+// secret at To is refused unless Force is set.
+type CopyRequest struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Force bool   `json:"force,omitempty"`
+}
+
+// ExportRequest is the request body for exporting all secrets in plaintext.
+// Confirm must be set explicitly and the master password supplied.
+type ExportRequest struct {
+	Confirm  bool   `json:"confirm"`
+	Password string `json:"password"`
+}
+
+// DeleteSecretsResponse is the response for a recursive delete, or for
+// purging the trash.
+type DeleteSecretsResponse struct {
+	Deleted int `json:"deleted"`
+}
+
+// UpdateSecretsResponse is the response for a metadata update across a
+// prefix.
+type UpdateSecretsResponse struct {
+	Updated int `json:"updated"`
+}
+
+// TrashItem describes a secret in the trash.
+type TrashItem struct {
+	Path      string    `json:"path"` // path the secret was deleted from
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// TrashResponse lists the secrets in the trash.
+type TrashResponse struct {
+	Items []TrashItem `json:"items"`
+	Count int         `json:"count"`
+}
+
+// RestoreRequest is the request to restore a secret from the trash.
+type RestoreRequest struct {
+	Path string `json:"path"`
+}
+
+// RotateRequest is the request to rotate a secret: replace its value with
+// a generated one, keeping the previous value as a version.
+type RotateRequest struct {
+	Path string `json:"path"`
+}
+
+// ProviderInfo describes a provider available in the daemon's build.
+type ProviderInfo struct {
+	Name         string             `json:"name"`
+	Capabilities vault.Capabilities `json:"capabilities"` // with the default configuration
+	Builtin      bool               `json:"builtin"`
+}
+
+// ProvidersResponse lists the providers available in the daemon's build.
+type ProvidersResponse struct {
+	Providers []ProviderInfo `json:"providers"`
+}
+
+// MigratePathsResponse lists the secrets renamed to normalized paths.
+type MigratePathsResponse struct {
+	Renamed map[string]string `json:"renamed"` // old path -> new path
+}
+
+// ExportResponse is the response for a plaintext export. It is a consistent
+// point-in-time view of every secret, including protected values.
+type ExportResponse struct {
+	Secrets    []SecretResponse `json:"secrets"`
+	Count      int              `json:"count"`
+	ExportedAt time.Time        `json:"exported_at"`
 }
 
 // ErrorResponse is the response for errors.
@@ -78,13 +308,60 @@ type SuccessResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
+// UnlockResponse is the response for init and unlock requests. Token is set
+// when the daemon requires token authentication; it must be sent as a bearer
+// token in the Authorization header of secret requests until the vault locks.
+type UnlockResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+	Token   string `json:"token,omitempty"`
+
+	// RecoveryKey is the vault's recovery key, returned once by init
+	RecoveryKey string `json:"recovery_key,omitempty"`
+}
+
+// Event is a vault state transition, streamed by the /events endpoint as
+// server-sent events.
+type Event struct {
+	Type   string    `json:"type"`
+	Reason string    `json:"reason,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// Event types.
+const (
+	EventLocked   = "locked"
+	EventUnlocked = "unlocked"
+)
+
+// Event reasons.
+const (
+	ReasonManual   = "manual"
+	ReasonAutoLock = "auto_lock"
+	ReasonShutdown = "shutdown"
+	ReasonInit     = "init"
+)
+
 // Error codes.
 const (
-	ErrCodeVaultLocked     = "VAULT_LOCKED"
-	ErrCodeVaultNotFound   = "VAULT_NOT_FOUND"
-	ErrCodeSecretNotFound  = "SECRET_NOT_FOUND"
-	ErrCodeInvalidPassword = "INVALID_PASSWORD"
-	ErrCodeInvalidRequest  = "INVALID_REQUEST"
-	ErrCodeInternalError   = "INTERNAL_ERROR"
-	ErrCodeAlreadyExists   = "ALREADY_EXISTS"
+	ErrCodeVaultLocked          = "VAULT_LOCKED"
+	ErrCodeVaultNotFound        = "VAULT_NOT_FOUND"
+	ErrCodeSecretNotFound       = "SECRET_NOT_FOUND"
+	ErrCodeInvalidPassword      = "INVALID_PASSWORD"
+	ErrCodeInvalidRequest       = "INVALID_REQUEST"
+	ErrCodeInternalError        = "INTERNAL_ERROR"
+	ErrCodeAlreadyExists        = "ALREADY_EXISTS"
+	ErrCodeConfirmationRequired = "CONFIRMATION_REQUIRED"
+	ErrCodeVaultTampered        = "VAULT_TAMPERED"
+	ErrCodeFieldNotFound        = "FIELD_NOT_FOUND"
+	ErrCodeVersionNotFound      = "VERSION_NOT_FOUND"
+	ErrCodeUnauthorized         = "UNAUTHORIZED"
 )
+
+// HeaderConfirmPassword carries the master password used to confirm
+// access to protected secrets.
+const HeaderConfirmPassword = "X-OmniVault-Confirm-Password"
+
+// HeaderTraceParent carries a W3C trace context, logged by the daemon with
+// each request so its logs can be tied to distributed traces.
+const HeaderTraceParent = "Traceparent"
@@ -2,24 +2,45 @@ package daemon
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"google.golang.org/grpc"
+
+	"github.com/agentplexus/omnivault"
+	"github.com/agentplexus/omnivault/internal/clock"
 	"github.com/agentplexus/omnivault/internal/config"
 	"github.com/agentplexus/omnivault/internal/store"
 	"github.com/agentplexus/omnivault/vault"
 )
 
+// DefaultTrashRetention is how long trashed secrets are kept when soft
+// delete is enabled.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// LookupFloor is the least time a secret lookup takes when constant-time
+// lookups are enabled. It is well above the time to decrypt a secret.
+const LookupFloor = 20 * time.Millisecond
+
+// DefaultGRPCTCPAddr is the address of the gRPC service on Windows.
+const DefaultGRPCTCPAddr = "127.0.0.1:19840"
+
 // Server is the OmniVault daemon server.
 type Server struct {
 	mu        sync.RWMutex
@@ -27,18 +48,152 @@ type Server struct {
 	paths     *config.Paths
 	listener  net.Listener
 	server    *http.Server
+	grpc      *grpc.Server
 	logger    *slog.Logger
+	clock     clock.Clock
 	startTime time.Time
 
-	// Auto-lock settings
+	// Auto-lock settings. autoLockMu guards the timer and last activity,
+	// which read-only handlers reset while holding only s.mu.RLock.
 	autoLockDuration time.Duration
-	autoLockTimer    *time.Timer
+	autoLockMu       sync.Mutex
+	autoLockTimer    clock.Timer
+	lastActivity     time.Time // wall clock time of the last reset
+
+	// Lock state notifications
+	onLock   func(Event)
+	onUnlock func(Event)
+	events   *eventBroker
+
+	// stop is closed when a client asks the daemon to stop
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	// Session token authentication
+	requireToken bool
+	token        string
+
+	// Keep secret paths out of info-level request logs
+	redactPaths bool
+
+	// Request headers logged with each request
+	logHeaders []string
+
+	// Size limits; zero means unlimited
+	maxRequestBytes  int64
+	maxSecretBytes   int64
+	vaultSizeWarning int64
+
+	// Minimum size of gzip-compressed responses; zero or less disables compression
+	compressMinBytes int
+
+	// Least duration of secret lookups; zero disables padding
+	lookupFloor time.Duration
+
+	// Address of the gRPC service; empty disables it
+	grpcAddr string
 }
 
 // ServerConfig contains server configuration.
 type ServerConfig struct {
 	Logger           *slog.Logger
 	AutoLockDuration time.Duration
+
+	// OnLock and OnUnlock are called when the vault is locked (manually,
+	// by auto-lock, or on shutdown) or unlocked. They are called
+	// synchronously while the server is locked, so they must return quickly
+	// and must not call back into the server.
+	OnLock   func(Event)
+	OnUnlock func(Event)
+
+	// RequireToken enables session token authentication. Init and unlock
+	// return a random token, also written to the token file, which secret
+	// requests must present as a bearer token. The token is invalidated
+	// when the vault locks.
+	RequireToken bool
+
+	// RequiredFields maps path prefixes to fields that secrets under the
+	// prefix must have, e.g. {"db/": {"username", "password"}}. Saving a
+	// secret without them fails. Validation is off when empty.
+	RequiredFields map[string][]string
+
+	// NormalizePaths normalizes secret paths (see store.NormalizePath) in
+	// vaults created before normalization became the default. New vaults,
+	// and vaults migrated with migrate-paths, always normalize.
+	NormalizePaths bool
+
+	// RedactPaths keeps secret paths out of request logs at info level.
+	// They are still logged at debug level.
+	RedactPaths bool
+
+	// Trash enables soft delete: deleted secrets are moved into the trash,
+	// from which they can be restored, unless the delete is permanent.
+	Trash bool
+
+	// TrashRetention is how long trashed secrets are kept. Zero means
+	// DefaultTrashRetention; a negative value keeps them until purged.
+	TrashRetention time.Duration
+
+	// WAL enables the vault's write-ahead log, so writes append a record
+	// instead of rewriting the whole vault file (see store.SetWAL).
+	WAL bool
+
+	// NoSync turns off syncing vault files to stable storage after each
+	// write (see store.SetDurable). Writes are durable by default; turning
+	// syncing off is faster, but a power loss may lose writes that
+	// succeeded, so it is only suitable for disposable or test vaults.
+	NoSync bool
+
+	// TrackAccess records when each secret was last read (see
+	// store.SetTrackAccess). Reads are saved with the next write or when
+	// the vault locks, so tracking doesn't turn every read into a write.
+	TrackAccess bool
+
+	// GRPC also serves the gRPC service defined in daemonpb/daemon.proto,
+	// for clients in other languages that prefer a typed protocol. Each
+	// method (status, unlock, lock, get, set, list, delete) behaves like
+	// the matching HTTP endpoint.
+	GRPC bool
+
+	// GRPCAddr is where the gRPC service listens: a Unix socket path, or
+	// a TCP address on Windows. Empty means omnivaultd-grpc.sock in the
+	// config directory, or DefaultGRPCTCPAddr on Windows.
+	GRPCAddr string
+
+	// ConstantTimeLookups pads secret reads to at least LookupFloor, so
+	// that how long a read takes doesn't reveal whether the secret exists.
+	ConstantTimeLookups bool
+
+	// LogHeaders are request headers whose values are added to every log
+	// line of the request, under their lower-case names, to correlate
+	// daemon logs with the caller's traces. Nil means DefaultLogHeaders;
+	// an empty slice logs none.
+	LogHeaders []string
+
+	// MaxRequestBytes limits the size of request bodies. Larger requests
+	// are rejected with 413 Request Entity Too Large. Zero means
+	// DefaultMaxRequestBytes; a negative value disables the limit.
+	MaxRequestBytes int64
+
+	// MaxSecretBytes limits the size of a stored secret: its value, binary
+	// value, and field names and values together. Larger secrets are
+	// rejected with 413 Request Entity Too Large. Zero or less means no
+	// limit.
+	MaxSecretBytes int64
+
+	// VaultSizeWarning is the vault file size above which a warning is
+	// logged after each change. Zero means DefaultVaultSizeWarning; a
+	// negative value disables the warning.
+	VaultSizeWarning int64
+
+	// CompressMinBytes is the response size from which responses are
+	// gzip-compressed for clients that send Accept-Encoding: gzip. Zero
+	// means DefaultCompressMinBytes; a negative value disables compression.
+	CompressMinBytes int
+
+	// Clock supplies the time for the auto-lock timer, events and secret
+	// timestamps. It defaults to clock.Real; tests use a clock.Fake.
+	Clock clock.Clock
 }
 
 // NewServer creates a new daemon server.
@@ -58,11 +213,77 @@ func NewServerWithPaths(cfg ServerConfig, paths *config.Paths) *Server {
 		autoLock = 15 * time.Minute // Default auto-lock
 	}
 
+	maxRequestBytes := cfg.MaxRequestBytes
+	if maxRequestBytes == 0 {
+		maxRequestBytes = DefaultMaxRequestBytes
+	}
+	vaultSizeWarning := cfg.VaultSizeWarning
+	if vaultSizeWarning == 0 {
+		vaultSizeWarning = DefaultVaultSizeWarning
+	}
+	compressMinBytes := cfg.CompressMinBytes
+	if compressMinBytes == 0 {
+		compressMinBytes = DefaultCompressMinBytes
+	}
+	clk := cfg.Clock
+	if clk == nil {
+		clk = clock.Real
+	}
+	logHeaders := cfg.LogHeaders
+	if logHeaders == nil {
+		logHeaders = DefaultLogHeaders
+	}
+	var lookupFloor time.Duration
+	if cfg.ConstantTimeLookups {
+		lookupFloor = LookupFloor
+	}
+	var grpcAddr string
+	if cfg.GRPC {
+		grpcAddr = cfg.GRPCAddr
+		if grpcAddr == "" && runtime.GOOS == "windows" {
+			grpcAddr = DefaultGRPCTCPAddr
+		} else if grpcAddr == "" {
+			grpcAddr = filepath.Join(paths.ConfigDir, "omnivaultd-grpc.sock")
+		}
+	}
+
+	st := store.NewEncryptedStore(paths.VaultFile, paths.MetaFile)
+	st.SetRequiredFields(cfg.RequiredFields)
+	st.SetNormalizePaths(cfg.NormalizePaths)
+	st.SetClock(clk)
+	st.SetDurable(!cfg.NoSync)
+	st.SetTrackAccess(cfg.TrackAccess)
+	if cfg.Trash {
+		trashRetention := cfg.TrashRetention
+		if trashRetention == 0 {
+			trashRetention = DefaultTrashRetention
+		}
+		st.SetTrash(true, trashRetention)
+	}
+	if cfg.WAL {
+		// The file backend always supports the log
+		_ = st.SetWAL(store.DefaultWALCompactRecords)
+	}
+
 	return &Server{
-		store:            store.NewEncryptedStore(paths.VaultFile, paths.MetaFile),
+		store:            st,
 		paths:            paths,
 		logger:           logger,
+		clock:            clk,
 		autoLockDuration: autoLock,
+		onLock:           cfg.OnLock,
+		onUnlock:         cfg.OnUnlock,
+		events:           newEventBroker(),
+		stop:             make(chan struct{}),
+		requireToken:     cfg.RequireToken,
+		redactPaths:      cfg.RedactPaths,
+		logHeaders:       logHeaders,
+		maxRequestBytes:  maxRequestBytes,
+		maxSecretBytes:   max(cfg.MaxSecretBytes, 0),
+		vaultSizeWarning: vaultSizeWarning,
+		compressMinBytes: compressMinBytes,
+		lookupFloor:      lookupFloor,
+		grpcAddr:         grpcAddr,
 	}
 }
 
@@ -83,17 +304,22 @@ func (s *Server) Run(ctx context.Context) error {
 	}
 	s.listener = listener
 
-	// Create HTTP server with routes
-	mux := http.NewServeMux()
-	s.registerRoutes(mux)
+	var grpcListener net.Listener
+	if s.grpcAddr != "" {
+		if grpcListener, err = s.createGRPCListener(); err != nil {
+			_ = listener.Close()
+			return fmt.Errorf("failed to create gRPC listener: %w", err)
+		}
+		s.grpc = s.newGRPCServer()
+	}
 
 	s.server = &http.Server{
-		Handler:      mux,
+		Handler:      s.handler(),
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
 
-	s.startTime = time.Now()
+	s.startTime = s.clock.Now()
 
 	// Write PID file
 	if err := s.writePIDFile(); err != nil {
@@ -106,16 +332,24 @@ func (s *Server) Run(ctx context.Context) error {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
-	errCh := make(chan error, 1)
+	errCh := make(chan error, 2)
 	go func() {
 		errCh <- s.server.Serve(listener)
 	}()
+	if s.grpc != nil {
+		s.logger.Info("serving gRPC", "address", s.grpcAddr)
+		go func() {
+			errCh <- s.grpc.Serve(grpcListener)
+		}()
+	}
 
 	select {
 	case <-ctx.Done():
 		s.logger.Info("context cancelled, shutting down")
 	case sig := <-sigCh:
 		s.logger.Info("received signal, shutting down", "signal", sig)
+	case <-s.stop:
+		s.logger.Info("stop requested, shutting down")
 	case err := <-errCh:
 		if err != nil && err != http.ErrServerClosed {
 			return err
@@ -125,7 +359,8 @@ func (s *Server) Run(ctx context.Context) error {
 	return s.Shutdown()
 }
 
-// Shutdown gracefully shuts down the server.
+// Shutdown gracefully shuts down the server. In-flight requests are
+// allowed to finish before the vault is locked.
 func (s *Server) Shutdown() error {
 	s.logger.Info("shutting down daemon")
 
@@ -134,12 +369,11 @@ func (s *Server) Shutdown() error {
 		s.autoLockTimer.Stop()
 	}
 
-	// Lock the vault
-	if err := s.store.Lock(); err != nil {
-		s.logger.Warn("failed to lock vault on shutdown", "error", err)
-	}
+	// End event streams so they don't hold up draining
+	s.events.close()
 
-	// Shutdown HTTP server
+	// Stop accepting connections and wait for in-flight requests, so no
+	// secret write is cut short
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -148,9 +382,37 @@ func (s *Server) Shutdown() error {
 			s.logger.Warn("failed to shutdown server", "error", err)
 		}
 	}
+	if s.grpc != nil {
+		stopped := make(chan struct{})
+		go func() {
+			s.grpc.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			s.logger.Warn("failed to shutdown gRPC server", "error", ctx.Err())
+			s.grpc.Stop()
+		}
+	}
+
+	// Lock the vault. Handlers still running after the timeout hold s.mu,
+	// so this waits for them.
+	s.mu.Lock()
+	wasLocked := s.store.IsLocked()
+	if err := s.store.Lock(); err != nil {
+		s.logger.Warn("failed to lock vault on shutdown", "error", err)
+	} else if !wasLocked {
+		s.emit(EventLocked, ReasonShutdown)
+	}
+	s.revokeToken()
+	s.mu.Unlock()
 
-	// Cleanup socket and PID file
+	// Cleanup sockets and PID file
 	_ = s.paths.CleanupSocket()
+	if s.grpc != nil && runtime.GOOS != "windows" {
+		_ = os.Remove(s.grpcAddr)
+	}
 	_ = os.Remove(s.paths.PIDFile)
 
 	return nil
@@ -166,14 +428,36 @@ func (s *Server) createListener() (net.Listener, error) {
 	return net.Listen("unix", s.paths.SocketPath)
 }
 
+// handler returns the HTTP handler serving all routes.
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	s.registerRoutes(mux)
+	return s.logRequests(s.lockAfterSleep(s.limitRequests(s.compressResponses(mux))))
+}
+
 // registerRoutes registers HTTP routes.
 func (s *Server) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/session", s.handleSession)
+	mux.HandleFunc("/vault-info", s.handleVaultInfo)
+	mux.HandleFunc("/providers", s.handleProviders)
 	mux.HandleFunc("/init", s.handleInit)
 	mux.HandleFunc("/unlock", s.handleUnlock)
 	mux.HandleFunc("/lock", s.handleLock)
-	mux.HandleFunc("/secrets", s.handleSecrets)
-	mux.HandleFunc("/secret/", s.handleSecret)
+	mux.HandleFunc("/password", s.authorized(s.handlePassword))
+	mux.HandleFunc("/secrets", s.authorized(s.handleSecrets))
+	mux.HandleFunc("/secrets/stream", s.authorized(s.handleSecretsStream))
+	mux.HandleFunc("/secret/", s.authorized(s.handleSecret))
+	mux.HandleFunc("/protect", s.authorized(s.handleProtect))
+	mux.HandleFunc("/alias", s.authorized(s.handleAlias))
+	mux.HandleFunc("/move", s.authorized(s.handleMove))
+	mux.HandleFunc("/copy", s.authorized(s.handleCopy))
+	mux.HandleFunc("/trash", s.authorized(s.handleTrash))
+	mux.HandleFunc("/restore", s.authorized(s.handleRestore))
+	mux.HandleFunc("/rotate", s.authorized(s.handleRotate))
+	mux.HandleFunc("/migrate-paths", s.authorized(s.handleMigratePaths))
+	mux.HandleFunc("/export-plain", s.authorized(s.handleExportPlain))
+	mux.HandleFunc("/events", s.handleEvents)
 	mux.HandleFunc("/stop", s.handleStop)
 }
 
@@ -192,7 +476,7 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 		Locked:      s.store.IsLocked(),
 		VaultExists: s.store.VaultExists(),
 		SecretCount: s.store.SecretCount(),
-		Uptime:      time.Since(s.startTime).Round(time.Second).String(),
+		Uptime:      s.clock.Now().Sub(s.startTime).Round(time.Second).String(),
 	}
 
 	if !s.store.IsLocked() {
@@ -202,6 +486,102 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 	s.writeJSON(w, http.StatusOK, status)
 }
 
+// handleSession describes the current session. It works while locked
+// and, like status, does not reset the auto-lock timer.
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resp := SessionResponse{
+		Vault:         s.paths.VaultFile,
+		Locked:        s.store.IsLocked(),
+		TokenRequired: s.requireToken,
+		TokenValid:    s.token != "" && validToken(r, s.token),
+	}
+	if resp.Locked {
+		s.writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	unlockedAt := s.store.UnlockTime()
+	resp.UnlockedAt = &unlockedAt
+	resp.Recovered = s.store.Recovered()
+
+	if deadline := s.autoLockDeadline(); !deadline.IsZero() {
+		resp.AutoLockAt = &deadline
+		resp.AutoLockIn = max(deadline.Sub(s.clock.Now().Round(0)), 0).Round(time.Second).String()
+		if resp.TokenValid {
+			resp.TokenExpiresAt = &deadline
+		}
+	}
+
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// handleProviders lists the providers available in the daemon's build and
+// their capabilities. It works while locked.
+func (s *Server) handleProviders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	resp := ProvidersResponse{Providers: []ProviderInfo{}}
+	for _, info := range omnivault.AvailableProviders() {
+		resp.Providers = append(resp.Providers, ProviderInfo{
+			Name:         string(info.Name),
+			Capabilities: info.Capabilities,
+			Builtin:      info.Builtin,
+		})
+	}
+
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// handleVaultInfo returns the vault's metadata. It works while locked.
+func (s *Server) handleVaultInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.store.VaultExists() {
+		s.writeError(w, http.StatusNotFound, "vault does not exist, run init first", ErrCodeVaultNotFound)
+		return
+	}
+
+	info, err := s.store.Info()
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, VaultInfoResponse{
+		Version:    info.Version,
+		CreatedAt:  info.CreatedAt,
+		SaltLength: info.SaltLength,
+		KDF:        info.KDF,
+		KDFParams: KDFParams{
+			Time:      info.Argon2Params.Time,
+			MemoryKiB: info.Argon2Params.Memory,
+			Threads:   info.Argon2Params.Threads,
+			KeyLen:    info.Argon2Params.KeyLen,
+		},
+		Cipher:         info.Cipher,
+		NormalizePaths: info.NormalizePaths,
+		RecoveryKey:    info.Recovery,
+		MaxSecretBytes: s.maxSecretBytes,
+	})
+}
+
 // handleInit initializes a new vault.
 func (s *Server) handleInit(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -210,8 +590,7 @@ func (s *Server) handleInit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req InitRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.writeError(w, http.StatusBadRequest, "invalid request body", ErrCodeInvalidRequest)
+	if !s.decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -233,8 +612,21 @@ func (s *Server) handleInit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	recoveryKey, err := s.store.SetupRecovery(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		return
+	}
+
+	token, err := s.issueToken()
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "failed to issue session token", ErrCodeInternalError)
+		return
+	}
+
+	s.emit(EventUnlocked, ReasonInit)
 	s.resetAutoLock()
-	s.writeJSON(w, http.StatusOK, SuccessResponse{Success: true, Message: "vault initialized"})
+	s.writeJSON(w, http.StatusOK, UnlockResponse{Success: true, Message: "vault initialized", Token: token, RecoveryKey: recoveryKey})
 }
 
 // handleUnlock unlocks the vault.
@@ -245,8 +637,7 @@ func (s *Server) handleUnlock(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req UnlockRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.writeError(w, http.StatusBadRequest, "invalid request body", ErrCodeInvalidRequest)
+	if !s.decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -258,225 +649,1256 @@ func (s *Server) handleUnlock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.store.Unlock(req.Password); err != nil {
+	wasLocked := s.store.IsLocked()
+	var err error
+	if req.RecoveryKey != "" {
+		err = s.store.UnlockWithRecoveryKey(req.RecoveryKey)
+	} else {
+		err = s.store.Unlock(req.Password)
+	}
+	if err != nil {
 		if strings.Contains(err.Error(), "invalid password") {
 			s.writeError(w, http.StatusUnauthorized, "invalid password", ErrCodeInvalidPassword)
+		} else if errors.Is(err, store.ErrInvalidRecoveryKey) || errors.Is(err, store.ErrNoRecoveryKey) {
+			s.writeError(w, http.StatusUnauthorized, err.Error(), ErrCodeInvalidPassword)
+		} else if errors.Is(err, store.ErrTampered) {
+			s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeVaultTampered)
 		} else {
 			s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
 		}
 		return
 	}
 
+	token, err := s.issueToken()
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "failed to issue session token", ErrCodeInternalError)
+		return
+	}
+
+	if wasLocked {
+		s.emit(EventUnlocked, ReasonManual)
+	}
 	s.resetAutoLock()
-	s.writeJSON(w, http.StatusOK, SuccessResponse{Success: true, Message: "vault unlocked"})
+	s.writeJSON(w, http.StatusOK, UnlockResponse{Success: true, Message: "vault unlocked", Token: token})
 }
 
-// handleLock locks the vault.
-func (s *Server) handleLock(w http.ResponseWriter, r *http.Request) {
+// handlePassword changes the master password, and the recovery key if
+// asked. The current password is not needed after unlocking with the
+// recovery key.
+func (s *Server) handlePassword(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
 		return
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if s.autoLockTimer != nil {
-		s.autoLockTimer.Stop()
-	}
-
-	if err := s.store.Lock(); err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+	var req ChangePasswordRequest
+	if !s.decodeRequest(w, r, &req) {
 		return
 	}
 
-	s.writeJSON(w, http.StatusOK, SuccessResponse{Success: true, Message: "vault locked"})
-}
-
-// handleSecrets handles listing secrets.
-func (s *Server) handleSecrets(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+	if len(req.NewPassword) < 8 {
+		s.writeError(w, http.StatusBadRequest, "password must be at least 8 characters", ErrCodeInvalidRequest)
 		return
 	}
 
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	if s.store.IsLocked() {
 		s.writeError(w, http.StatusForbidden, "vault is locked", ErrCodeVaultLocked)
 		return
 	}
 
-	prefix := r.URL.Query().Get("prefix")
-	paths, err := s.store.List(r.Context(), prefix)
-	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+	var err error
+	switch {
+	case req.OldPassword != "":
+		err = s.store.ChangePassword(r.Context(), req.OldPassword, req.NewPassword)
+	case s.store.Recovered():
+		err = s.store.ResetPassword(r.Context(), req.NewPassword)
+	default:
+		s.writeError(w, http.StatusBadRequest, "current password is required", ErrCodeInvalidRequest)
 		return
 	}
-
-	// Build list response with metadata
-	items := make([]SecretListItem, 0, len(paths))
-	for _, path := range paths {
-		secret, err := s.store.Get(r.Context(), path)
-		if err != nil {
-			continue
-		}
-
-		var tags []string
-		if secret.Metadata.Tags != nil {
-			for k := range secret.Metadata.Tags {
-				tags = append(tags, k)
-			}
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid current password") {
+			s.writeError(w, http.StatusUnauthorized, "invalid password", ErrCodeInvalidPassword)
+		} else {
+			s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
 		}
+		return
+	}
 
-		item := SecretListItem{
-			Path:      path,
-			HasValue:  secret.Value != "" || len(secret.ValueBytes) > 0,
-			HasFields: len(secret.Fields) > 0,
-			Tags:      tags,
-		}
-		if secret.Metadata.ModifiedAt != nil {
-			item.UpdatedAt = secret.Metadata.ModifiedAt.Time
+	resp := ChangePasswordResponse{Success: true}
+	if req.RotateRecovery {
+		if resp.RecoveryKey, err = s.store.SetupRecovery(r.Context()); err != nil {
+			s.writeError(w, http.StatusInternalServerError, "password changed, but rotating the recovery key failed: "+err.Error(), ErrCodeInternalError)
+			return
 		}
-
-		items = append(items, item)
 	}
 
 	s.resetAutoLock()
-	s.writeJSON(w, http.StatusOK, ListResponse{Secrets: items, Count: len(items)})
+	s.writeJSON(w, http.StatusOK, resp)
 }
 
-// handleSecret handles single secret operations.
-func (s *Server) handleSecret(w http.ResponseWriter, r *http.Request) {
-	// Extract path from URL
-	path := strings.TrimPrefix(r.URL.Path, "/secret/")
-	if path == "" {
-		s.writeError(w, http.StatusBadRequest, "path is required", ErrCodeInvalidRequest)
+// handleLock locks the vault.
+func (s *Server) handleLock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
 		return
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.store.IsLocked() {
-		s.writeError(w, http.StatusForbidden, "vault is locked", ErrCodeVaultLocked)
+	if s.autoLockTimer != nil {
+		s.autoLockTimer.Stop()
+	}
+
+	wasLocked := s.store.IsLocked()
+	if err := s.store.Lock(); err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
 		return
 	}
 
+	s.revokeToken()
+	if !wasLocked {
+		s.emit(EventLocked, ReasonManual)
+	}
+	s.writeJSON(w, http.StatusOK, SuccessResponse{Success: true, Message: "vault locked"})
+}
+
+// handleSecrets handles listing secrets and deleting them by prefix.
+func (s *Server) handleSecrets(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		s.getSecret(w, r, path)
-	case http.MethodPut:
-		s.setSecret(w, r, path)
+		s.listSecrets(w, r)
 	case http.MethodDelete:
-		s.deleteSecret(w, r, path)
+		s.deleteSecrets(w, r)
+	case http.MethodPatch:
+		s.updateSecretsMetadata(w, r)
 	default:
 		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
 	}
 }
 
-func (s *Server) getSecret(w http.ResponseWriter, r *http.Request, path string) {
-	secret, err := s.store.Get(r.Context(), path)
-	if err != nil {
-		if err == vault.ErrSecretNotFound {
-			s.writeError(w, http.StatusNotFound, "secret not found", ErrCodeSecretNotFound)
-		} else {
-			s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
-		}
+// deleteSecrets deletes every secret under a prefix. An empty prefix is
+// refused unless all=true is given.
+func (s *Server) deleteSecrets(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	if prefix == "" && query.Get("all") != "true" {
+		s.writeError(w, http.StatusBadRequest, "prefix is required; pass all=true to delete all secrets", ErrCodeInvalidRequest)
 		return
 	}
 
-	resp := SecretResponse{
-		Path:   path,
-		Value:  secret.String(),
-		Fields: secret.Fields,
-	}
-	if secret.Metadata.Tags != nil {
-		resp.Tags = secret.Metadata.Tags
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.store.IsLocked() {
+		s.writeError(w, http.StatusForbidden, "vault is locked", ErrCodeVaultLocked)
+		return
 	}
-	if secret.Metadata.CreatedAt != nil {
-		resp.CreatedAt = secret.Metadata.CreatedAt.Time
+
+	deletePrefix := s.store.DeletePrefix
+	if query.Get("permanent") == "true" {
+		deletePrefix = s.store.DeletePrefixPermanently
 	}
-	if secret.Metadata.ModifiedAt != nil {
-		resp.UpdatedAt = secret.Metadata.ModifiedAt.Time
+	deleted, err := deletePrefix(r.Context(), prefix)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		return
 	}
 
 	s.resetAutoLock()
-	s.writeJSON(w, http.StatusOK, resp)
+	s.writeJSON(w, http.StatusOK, DeleteSecretsResponse{Deleted: deleted})
 }
 
-func (s *Server) setSecret(w http.ResponseWriter, r *http.Request, path string) {
-	var req SetSecretRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.writeError(w, http.StatusBadRequest, "invalid request body", ErrCodeInvalidRequest)
+// updateSecretsMetadata merges tags, labels and expiry into every secret
+// under a prefix. An empty prefix is refused unless all=true is given.
+func (s *Server) updateSecretsMetadata(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	if prefix == "" && query.Get("all") != "true" {
+		s.writeError(w, http.StatusBadRequest, "prefix is required; pass all=true to update all secrets", ErrCodeInvalidRequest)
 		return
 	}
 
-	secret := &vault.Secret{
-		Value:  req.Value,
-		Fields: req.Fields,
-		Metadata: vault.Metadata{
-			Tags: req.Tags,
-		},
+	var req UpdateMetadataRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
 	}
 
-	if err := s.store.Set(r.Context(), path, secret); err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.store.IsLocked() {
+		s.writeError(w, http.StatusForbidden, "vault is locked", ErrCodeVaultLocked)
 		return
 	}
 
-	s.resetAutoLock()
-	s.writeJSON(w, http.StatusOK, SuccessResponse{Success: true, Message: "secret saved"})
-}
-
-func (s *Server) deleteSecret(w http.ResponseWriter, r *http.Request, path string) {
-	if err := s.store.Delete(r.Context(), path); err != nil {
+	updated, err := s.store.UpdateMetadataPrefix(r.Context(), prefix, func(m *vault.Metadata) {
+		applyMetadataUpdate(m, &req)
+	})
+	if err != nil {
 		s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
 		return
 	}
 
 	s.resetAutoLock()
-	s.writeJSON(w, http.StatusOK, SuccessResponse{Success: true, Message: "secret deleted"})
+	s.writeJSON(w, http.StatusOK, UpdateSecretsResponse{Updated: updated})
 }
 
-// handleStop stops the daemon.
-func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+// listSecrets lists secrets, a page at a time when a limit is given.
+func (s *Server) listSecrets(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.store.IsLocked() {
+		s.writeError(w, http.StatusForbidden, "vault is locked", ErrCodeVaultLocked)
 		return
 	}
 
-	s.writeJSON(w, http.StatusOK, SuccessResponse{Success: true, Message: "daemon stopping"})
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
 
-	// Shutdown in background
-	go func() {
-		time.Sleep(100 * time.Millisecond)
-		if err := s.Shutdown(); err != nil {
-			s.logger.Error("shutdown error", "error", err)
+	limit := 0
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s.writeError(w, http.StatusBadRequest, "invalid limit", ErrCodeInvalidRequest)
+			return
 		}
-		os.Exit(0)
-	}()
-}
-
-// resetAutoLock resets the auto-lock timer.
-func (s *Server) resetAutoLock() {
-	if s.autoLockTimer != nil {
-		s.autoLockTimer.Stop()
+		limit = n
 	}
 
-	s.autoLockTimer = time.AfterFunc(s.autoLockDuration, func() {
-		s.mu.Lock()
-		defer s.mu.Unlock()
+	after, err := decodeCursor(query.Get("cursor"))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid cursor", ErrCodeInvalidRequest)
+		return
+	}
 
-		if err := s.store.Lock(); err != nil {
-			s.logger.Warn("auto-lock failed", "error", err)
+	// Only the page is decrypted; one extra secret shows whether there
+	// is another page
+	pageSize := 0
+	if limit > 0 {
+		pageSize = limit + 1
+	}
+	infos, err := s.store.ListInfoPage(r.Context(), prefix, after, pageSize)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		return
+	}
+
+	var nextCursor string
+	if limit > 0 && len(infos) > limit {
+		infos = infos[:limit]
+		nextCursor = encodeCursor(infos[limit-1].Path)
+	}
+
+	items := make([]SecretListItem, 0, len(infos))
+	for _, info := range infos {
+		items = append(items, listItem(info))
+	}
+
+	s.resetAutoLock()
+	s.writeJSON(w, http.StatusOK, ListResponse{Secrets: items, Count: len(items), NextCursor: nextCursor})
+}
+
+// listItem describes a secret in a list.
+func listItem(info vault.SecretInfo) SecretListItem {
+	var tags []string
+	if info.Metadata.Tags != nil {
+		for k := range info.Metadata.Tags {
+			tags = append(tags, k)
+		}
+	}
+
+	item := SecretListItem{
+		Path:      info.Path,
+		HasValue:  info.HasValue,
+		HasFields: info.HasFields,
+		Tags:      tags,
+		Protected: info.Metadata.Protected,
+	}
+	if info.Metadata.ModifiedAt != nil {
+		item.UpdatedAt = info.Metadata.ModifiedAt.Time
+	}
+	if info.Metadata.LastAccessedAt != nil {
+		item.LastAccessedAt = &info.Metadata.LastAccessedAt.Time
+	}
+	item.AliasOf, _ = info.Metadata.Extra[store.AliasKey].(string)
+	return item
+}
+
+// streamPageSize is how many secrets a streamed list reads from the store
+// at a time.
+const streamPageSize = 256
+
+// handleSecretsStream lists secrets as newline-delimited JSON, one
+// ListStreamLine per secret, sorted by path. Secrets are read and sent a
+// page at a time, so neither side holds the whole list, and the lock is
+// released between pages. Unlike a paged list, the stream is not a
+// consistent snapshot if secrets change while it is sent.
+func (s *Server) handleSecretsStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	s.mu.RLock()
+	locked := s.store.IsLocked()
+	s.mu.RUnlock()
+	if locked {
+		s.writeError(w, http.StatusForbidden, "vault is locked", ErrCodeVaultLocked)
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+
+	prefix := r.URL.Query().Get("prefix")
+	after := ""
+	count := 0
+	for {
+		s.mu.RLock()
+		locked := s.store.IsLocked()
+		var infos []vault.SecretInfo
+		var err error
+		if !locked {
+			infos, err = s.store.ListInfoPage(r.Context(), prefix, after, streamPageSize)
+		}
+		s.mu.RUnlock()
+
+		// The vault may lock between pages
+		if locked {
+			_ = enc.Encode(ListStreamLine{Error: "vault is locked", Code: ErrCodeVaultLocked})
+			return
+		}
+		if err != nil {
+			_ = enc.Encode(ListStreamLine{Error: err.Error(), Code: ErrCodeInternalError})
+			return
+		}
+
+		for _, info := range infos {
+			item := listItem(info)
+			if err := enc.Encode(ListStreamLine{Secret: &item}); err != nil {
+				return
+			}
+		}
+		count += len(infos)
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		if len(infos) < streamPageSize {
+			break
+		}
+		after = infos[len(infos)-1].Path
+	}
+
+	s.resetAutoLock()
+	_ = enc.Encode(ListStreamLine{Done: true, Count: count})
+}
+
+// encodeCursor encodes the last path of a page as an opaque cursor.
+func encodeCursor(path string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(path))
+}
+
+// decodeCursor decodes a cursor produced by encodeCursor.
+func decodeCursor(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+	path, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", err
+	}
+	return string(path), nil
+}
+
+// handleSecret handles single secret operations.
+func (s *Server) handleSecret(w http.ResponseWriter, r *http.Request) {
+	// Extract path from URL
+	path := strings.TrimPrefix(r.URL.Path, "/secret/")
+	if path == "" {
+		s.writeError(w, http.StatusBadRequest, "path is required", ErrCodeInvalidRequest)
+		return
+	}
+
+	if r.Method == http.MethodGet && s.lookupFloor > 0 {
+		// Deferred first so it runs after the unlock and doesn't hold up
+		// other requests; the response is sent when the handler returns.
+		defer waitUntil(r.Context(), time.Now().Add(s.lookupFloor))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.store.IsLocked() {
+		s.writeError(w, http.StatusForbidden, "vault is locked", ErrCodeVaultLocked)
+		return
+	}
+
+	// Metadata-only updates; other methods treat "/metadata" as part of the path
+	if base, ok := strings.CutSuffix(path, "/metadata"); ok && r.Method == http.MethodPatch {
+		s.updateMetadata(w, r, base)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.getSecret(w, r, path)
+	case http.MethodPut:
+		s.setSecret(w, r, path)
+	case http.MethodDelete:
+		s.deleteSecret(w, r, path)
+	default:
+		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+	}
+}
+
+// waitUntil sleeps until deadline or until ctx is done. It uses the real
+// clock, since it pads the latency callers observe.
+func waitUntil(ctx context.Context, deadline time.Time) {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+func (s *Server) getSecret(w http.ResponseWriter, r *http.Request, path string) {
+	var secret *vault.Secret
+	var err error
+	if version := r.URL.Query().Get("version"); version != "" {
+		secret, err = s.store.GetVersion(r.Context(), path, version)
+	} else {
+		secret, err = s.store.Get(r.Context(), path)
+	}
+	if err != nil {
+		if err == vault.ErrSecretNotFound {
+			s.writeError(w, http.StatusNotFound, "secret not found", ErrCodeSecretNotFound)
+		} else if err == vault.ErrVersionNotFound {
+			s.writeError(w, http.StatusNotFound, "version not found", ErrCodeVersionNotFound)
+		} else {
+			s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		}
+		return
+	}
+
+	field := r.URL.Query().Get("field")
+	render := r.URL.Query().Get("render") == "true"
+	needsConfirm := secret.Metadata.Protected || (field != "" && secret.Metadata.IsFieldProtected(field)) ||
+		(render && len(secret.Metadata.ProtectedFields) > 0)
+	if needsConfirm && !s.confirmed(r) {
+		s.writeError(w, http.StatusForbidden, "secret is protected, confirmation required", ErrCodeConfirmationRequired)
+		return
+	}
+
+	resp := secretMetadata(path, secret)
+	if render {
+		// Only the rendered value is returned; the template may use
+		// protected fields
+		value, err := secret.Render()
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, err.Error(), ErrCodeInvalidRequest)
+			return
+		}
+		resp.Value = value
+	} else if field != "" {
+		// Return only the requested field
+		value, ok := secret.Fields[field]
+		if !ok {
+			s.writeError(w, http.StatusNotFound, "field not found", ErrCodeFieldNotFound)
+			return
+		}
+		resp.Fields = map[string]string{field: value}
+	} else {
+		resp.Value = secret.String()
+		resp.Fields = redactFields(secret)
+		resp.ProtectedFields = secret.Metadata.ProtectedFields
+		resp.Notes = secret.Notes
+	}
+
+	s.resetAutoLock()
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// secretMetadata returns a response describing the secret at path without
+// its value, fields, or notes.
+func secretMetadata(path string, secret *vault.Secret) SecretResponse {
+	resp := SecretResponse{
+		Path:      path,
+		Protected: secret.Metadata.Protected,
+		Template:  secret.Template(),
+		Version:   secret.Metadata.Version,
+		Tags:      secret.Metadata.Tags,
+		Labels:    secret.Metadata.Labels,
+	}
+	if secret.Metadata.CreatedAt != nil {
+		resp.CreatedAt = secret.Metadata.CreatedAt.Time
+	}
+	if secret.Metadata.ModifiedAt != nil {
+		resp.UpdatedAt = secret.Metadata.ModifiedAt.Time
+	}
+	if secret.Metadata.ExpiresAt != nil {
+		resp.ExpiresAt = &secret.Metadata.ExpiresAt.Time
+	}
+	if secret.Metadata.LastAccessedAt != nil {
+		resp.LastAccessedAt = &secret.Metadata.LastAccessedAt.Time
+	}
+	return resp
+}
+
+// updateMetadata merges tags, labels and expiry into a secret without
+// touching its value or fields.
+func (s *Server) updateMetadata(w http.ResponseWriter, r *http.Request, path string) {
+	var req UpdateMetadataRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	err := s.store.UpdateMetadata(r.Context(), path, func(m *vault.Metadata) {
+		applyMetadataUpdate(m, &req)
+	})
+	if err != nil {
+		if errors.Is(err, vault.ErrSecretNotFound) {
+			s.writeError(w, http.StatusNotFound, "secret not found", ErrCodeSecretNotFound)
+		} else {
+			s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		}
+		return
+	}
+
+	s.resetAutoLock()
+	s.writeJSON(w, http.StatusOK, SuccessResponse{Success: true, Message: "metadata updated"})
+}
+
+// applyMetadataUpdate applies a metadata update request to m.
+func applyMetadataUpdate(m *vault.Metadata, req *UpdateMetadataRequest) {
+	if len(req.Tags) > 0 && m.Tags == nil {
+		m.Tags = make(map[string]string, len(req.Tags))
+	}
+	for k, v := range req.Tags {
+		m.Tags[k] = v
+	}
+	for _, k := range req.RemoveTags {
+		delete(m.Tags, k)
+	}
+
+	for _, label := range req.Labels {
+		if !slices.Contains(m.Labels, label) {
+			m.Labels = append(m.Labels, label)
+		}
+	}
+	if len(req.RemoveLabels) > 0 {
+		m.Labels = slices.DeleteFunc(m.Labels, func(label string) bool {
+			return slices.Contains(req.RemoveLabels, label)
+		})
+	}
+
+	switch {
+	case req.ClearExpiry:
+		m.ExpiresAt = nil
+	case req.ExpiresAt != nil:
+		m.ExpiresAt = vault.NewTimestamp(*req.ExpiresAt)
+	}
+}
+
+func (s *Server) setSecret(w http.ResponseWriter, r *http.Request, path string) {
+	var req SetSecretRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+	if err := vault.ValidateTemplate(req.Template); req.Template != "" && err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error(), ErrCodeInvalidRequest)
+		return
+	}
+
+	secret := &vault.Secret{
+		Value:  req.Value,
+		Fields: req.Fields,
+		Notes:  req.Notes,
+		Metadata: vault.Metadata{
+			Tags: req.Tags,
+		},
+	}
+
+	// Overwriting a secret is not a read
+	ctx := store.WithoutAccessTracking(r.Context())
+	if existing, err := s.store.Get(ctx, path); err == nil {
+		if req.Merge {
+			secret = mergeSecret(existing, &req)
+		}
+		// Overwriting a secret must not silently drop its protection,
+		// template, or creation time
+		secret.Metadata.Protected = existing.Metadata.Protected
+		secret.Metadata.ProtectedFields = existing.Metadata.ProtectedFields
+		secret.Metadata.CreatedAt = existing.Metadata.CreatedAt
+		secret.Metadata.LastAccessedAt = existing.Metadata.LastAccessedAt
+		secret.SetTemplate(existing.Template())
+	}
+	if req.Template != "" {
+		secret.SetTemplate(req.Template)
+	}
+	if size := secretSize(secret); s.maxSecretBytes > 0 && size > s.maxSecretBytes {
+		s.writeError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("secret is %d bytes, over the limit of %d bytes", size, s.maxSecretBytes), ErrCodeInvalidRequest)
+		return
+	}
+
+	if err := s.store.Set(r.Context(), path, secret); err != nil {
+		var verr *store.ValidationError
+		if errors.As(err, &verr) {
+			s.writeError(w, http.StatusBadRequest, err.Error(), ErrCodeInvalidRequest)
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		return
+	}
+
+	// Respond with the secret as stored, so clients learn its timestamps
+	// without another request. The value and fields, which the client
+	// sent, are left out.
+	stored, err := s.store.Get(ctx, path)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		return
+	}
+
+	s.resetAutoLock()
+	s.writeJSON(w, http.StatusOK, secretMetadata(path, stored))
+}
+
+// secretSize returns the size counted against ServerConfig.MaxSecretBytes.
+func secretSize(secret *vault.Secret) int64 {
+	size := len(secret.Value) + len(secret.ValueBytes)
+	for k, v := range secret.Fields {
+		size += len(k) + len(v)
+	}
+	return int64(size)
+}
+
+// redactFields returns the secret's fields without its protected fields.
+func redactFields(secret *vault.Secret) map[string]string {
+	if len(secret.Metadata.ProtectedFields) == 0 {
+		return secret.Fields
+	}
+	fields := make(map[string]string, len(secret.Fields))
+	for k, v := range secret.Fields {
+		if !secret.Metadata.IsFieldProtected(k) {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// mergeSecret applies a set request on top of an existing secret. Provided
+// fields and tags are merged into the existing ones; an empty value keeps
+// the existing value unless the request asks to replace it, and empty
+// notes keep the existing notes.
+func mergeSecret(existing *vault.Secret, req *SetSecretRequest) *vault.Secret {
+	merged := existing
+	if req.Value != "" || req.Replace {
+		merged.Value = req.Value
+		merged.ValueBytes = nil
+	}
+	if req.Notes != "" {
+		merged.Notes = req.Notes
+	}
+
+	if len(req.Fields) > 0 {
+		if merged.Fields == nil {
+			merged.Fields = make(map[string]string, len(req.Fields))
+		}
+		for k, v := range req.Fields {
+			merged.Fields[k] = v
+		}
+	}
+
+	if len(req.Tags) > 0 {
+		if merged.Metadata.Tags == nil {
+			merged.Metadata.Tags = make(map[string]string, len(req.Tags))
+		}
+		for k, v := range req.Tags {
+			merged.Metadata.Tags[k] = v
+		}
+	}
+
+	return merged
+}
+
+func (s *Server) deleteSecret(w http.ResponseWriter, r *http.Request, path string) {
+	remove := s.store.Delete
+	if r.URL.Query().Get("permanent") == "true" {
+		remove = s.store.DeletePermanently
+	}
+	if err := remove(r.Context(), path); err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		return
+	}
+
+	s.resetAutoLock()
+	s.writeJSON(w, http.StatusOK, SuccessResponse{Success: true, Message: "secret deleted"})
+}
+
+// handleProtect marks a secret as protected or removes its protection.
+func (s *Server) handleProtect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	var req ProtectRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	if req.Path == "" {
+		s.writeError(w, http.StatusBadRequest, "path is required", ErrCodeInvalidRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.store.IsLocked() {
+		s.writeError(w, http.StatusForbidden, "vault is locked", ErrCodeVaultLocked)
+		return
+	}
+
+	// Removing protection requires the same confirmation as reading
+	if !req.Protected && !s.store.VerifyPassword(req.Password) {
+		s.writeError(w, http.StatusUnauthorized, "invalid password", ErrCodeInvalidPassword)
+		return
+	}
+
+	secret, err := s.store.Get(store.WithoutAccessTracking(r.Context()), req.Path)
+	if err != nil {
+		if err == vault.ErrSecretNotFound {
+			s.writeError(w, http.StatusNotFound, "secret not found", ErrCodeSecretNotFound)
+		} else {
+			s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		}
+		return
+	}
+
+	message := "secret protected"
+	if req.Field != "" {
+		if _, ok := secret.Fields[req.Field]; !ok {
+			s.writeError(w, http.StatusNotFound, "field not found", ErrCodeFieldNotFound)
+			return
+		}
+		secret.Metadata.ProtectedFields = setFieldProtected(secret.Metadata.ProtectedFields, req.Field, req.Protected)
+		message = "field protected"
+		if !req.Protected {
+			message = "field unprotected"
+		}
+	} else {
+		secret.Metadata.Protected = req.Protected
+		if !req.Protected {
+			message = "secret unprotected"
+		}
+	}
+
+	if err := s.store.Set(r.Context(), req.Path, secret); err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		return
+	}
+
+	s.resetAutoLock()
+	s.writeJSON(w, http.StatusOK, SuccessResponse{Success: true, Message: message})
+}
+
+// setFieldProtected adds or removes a field from a protected field list.
+func setFieldProtected(fields []string, field string, protected bool) []string {
+	result := make([]string, 0, len(fields)+1)
+	for _, f := range fields {
+		if f != field {
+			result = append(result, f)
+		}
+	}
+	if protected {
+		result = append(result, field)
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// handleMigratePaths renames secrets to their normalized paths.
+func (s *Server) handleMigratePaths(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.store.IsLocked() {
+		s.writeError(w, http.StatusForbidden, "vault is locked", ErrCodeVaultLocked)
+		return
+	}
+
+	renamed, err := s.store.MigratePaths(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		return
+	}
+
+	s.resetAutoLock()
+	s.writeJSON(w, http.StatusOK, MigratePathsResponse{Renamed: renamed})
+}
+
+// handleMove moves every secret under a prefix to another prefix.
+func (s *Server) handleMove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	var req MoveRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	if req.From == "" {
+		s.writeError(w, http.StatusBadRequest, "from is required", ErrCodeInvalidRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.store.IsLocked() {
+		s.writeError(w, http.StatusForbidden, "vault is locked", ErrCodeVaultLocked)
+		return
+	}
+
+	var moved map[string]string
+	var err error
+	if req.Path {
+		err = s.store.Move(r.Context(), req.From, req.To, req.Force)
+		moved = map[string]string{req.From: req.To}
+	} else {
+		moved, err = s.store.MovePrefix(r.Context(), req.From, req.To, req.Force)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, vault.ErrSecretNotFound):
+			s.writeError(w, http.StatusNotFound, "secret not found", ErrCodeSecretNotFound)
+		case errors.Is(err, vault.ErrAlreadyExists):
+			s.writeError(w, http.StatusConflict, err.Error(), ErrCodeAlreadyExists)
+		default:
+			s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		}
+		return
+	}
+
+	s.resetAutoLock()
+	s.writeJSON(w, http.StatusOK, MoveResponse{Moved: moved})
+}
+
+// handleCopy copies a secret to a new path. The response describes the
+// copy without its value, which never leaves the daemon.
+func (s *Server) handleCopy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	var req CopyRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	if req.From == "" || req.To == "" {
+		s.writeError(w, http.StatusBadRequest, "from and to are required", ErrCodeInvalidRequest)
+		return
+	}
+	if req.From == req.To {
+		s.writeError(w, http.StatusBadRequest, "source and destination are the same", ErrCodeInvalidRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.store.IsLocked() {
+		s.writeError(w, http.StatusForbidden, "vault is locked", ErrCodeVaultLocked)
+		return
+	}
+
+	if err := s.store.Copy(r.Context(), req.From, req.To, req.Force); err != nil {
+		var verr *store.ValidationError
+		switch {
+		case errors.Is(err, vault.ErrSecretNotFound):
+			s.writeError(w, http.StatusNotFound, "secret not found", ErrCodeSecretNotFound)
+		case errors.Is(err, vault.ErrAlreadyExists):
+			s.writeError(w, http.StatusConflict, err.Error(), ErrCodeAlreadyExists)
+		case errors.As(err, &verr):
+			s.writeError(w, http.StatusBadRequest, err.Error(), ErrCodeInvalidRequest)
+		default:
+			s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		}
+		return
+	}
+
+	stored, err := s.store.Get(store.WithoutAccessTracking(r.Context()), req.To)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		return
+	}
+
+	s.resetAutoLock()
+	s.writeJSON(w, http.StatusOK, secretMetadata(req.To, stored))
+}
+
+// handleTrash lists the secrets in the trash, or purges it. A path query
+// parameter purges only the secret trashed from that path.
+func (s *Server) handleTrash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.store.IsLocked() {
+		s.writeError(w, http.StatusForbidden, "vault is locked", ErrCodeVaultLocked)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		purged, err := s.store.PurgeTrash(r.Context(), r.URL.Query().Get("path"))
+		if err != nil {
+			s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+			return
+		}
+
+		s.resetAutoLock()
+		s.writeJSON(w, http.StatusOK, DeleteSecretsResponse{Deleted: purged})
+		return
+	}
+
+	items, err := s.store.ListTrash(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		return
+	}
+
+	resp := TrashResponse{Items: make([]TrashItem, 0, len(items)), Count: len(items)}
+	for _, item := range items {
+		resp.Items = append(resp.Items, TrashItem{Path: item.Path, DeletedAt: item.DeletedAt})
+	}
+
+	s.resetAutoLock()
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// handleRestore moves a secret from the trash back to its path.
+func (s *Server) handleRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	var req RestoreRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	if req.Path == "" {
+		s.writeError(w, http.StatusBadRequest, "path is required", ErrCodeInvalidRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.store.IsLocked() {
+		s.writeError(w, http.StatusForbidden, "vault is locked", ErrCodeVaultLocked)
+		return
+	}
+
+	if err := s.store.Restore(r.Context(), req.Path); err != nil {
+		switch {
+		case errors.Is(err, vault.ErrSecretNotFound):
+			s.writeError(w, http.StatusNotFound, "secret not found in trash", ErrCodeSecretNotFound)
+		case errors.Is(err, vault.ErrAlreadyExists):
+			s.writeError(w, http.StatusConflict, err.Error(), ErrCodeAlreadyExists)
+		default:
+			s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		}
+		return
+	}
+
+	s.resetAutoLock()
+	s.writeJSON(w, http.StatusOK, SuccessResponse{Success: true, Message: "secret restored"})
+}
+
+// handleRotate replaces a secret's value with a generated one and returns
+// the new version without its value.
+func (s *Server) handleRotate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	var req RotateRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	if req.Path == "" {
+		s.writeError(w, http.StatusBadRequest, "path is required", ErrCodeInvalidRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.store.IsLocked() {
+		s.writeError(w, http.StatusForbidden, "vault is locked", ErrCodeVaultLocked)
+		return
+	}
+
+	secret, err := s.store.Rotate(r.Context(), req.Path)
+	if err != nil {
+		if errors.Is(err, vault.ErrSecretNotFound) {
+			s.writeError(w, http.StatusNotFound, "secret not found", ErrCodeSecretNotFound)
 		} else {
-			s.logger.Info("vault auto-locked due to inactivity")
+			s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		}
+		return
+	}
+
+	s.resetAutoLock()
+	s.writeJSON(w, http.StatusOK, secretMetadata(req.Path, secret))
+}
+
+// handleAlias makes a path an alias of another secret.
+func (s *Server) handleAlias(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	var req AliasRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	if req.Path == "" || req.Target == "" {
+		s.writeError(w, http.StatusBadRequest, "path and target are required", ErrCodeInvalidRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.store.IsLocked() {
+		s.writeError(w, http.StatusForbidden, "vault is locked", ErrCodeVaultLocked)
+		return
+	}
+
+	if err := s.store.SetAlias(r.Context(), req.Path, req.Target); err != nil {
+		switch {
+		case errors.Is(err, vault.ErrSecretNotFound):
+			s.writeError(w, http.StatusNotFound, "alias target not found", ErrCodeSecretNotFound)
+		case errors.Is(err, store.ErrAliasLoop):
+			s.writeError(w, http.StatusBadRequest, err.Error(), ErrCodeInvalidRequest)
+		default:
+			s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		}
+		return
+	}
+
+	s.resetAutoLock()
+	s.writeJSON(w, http.StatusOK, SuccessResponse{Success: true, Message: "alias created"})
+}
+
+// handleExportPlain returns every secret decrypted, as a single consistent
+// snapshot. It requires an explicit confirmation flag and the master password,
+// and every attempt is recorded in the audit log.
+func (s *Server) handleExportPlain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	var req ExportRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	if !req.Confirm {
+		s.writeError(w, http.StatusBadRequest, "export must be explicitly confirmed", ErrCodeConfirmationRequired)
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.store.IsLocked() {
+		s.writeError(w, http.StatusForbidden, "vault is locked", ErrCodeVaultLocked)
+		return
+	}
+
+	if !s.store.VerifyPassword(req.Password) {
+		s.requestLogger(r).Warn("audit: plaintext export denied", "reason", "invalid password")
+		s.writeError(w, http.StatusUnauthorized, "invalid password", ErrCodeInvalidPassword)
+		return
+	}
+
+	secrets, err := s.store.Snapshot(r.Context())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err.Error(), ErrCodeInternalError)
+		return
+	}
+
+	paths := make([]string, 0, len(secrets))
+	for path := range secrets {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	items := make([]SecretResponse, 0, len(paths))
+	for _, path := range paths {
+		secret := secrets[path]
+		item := SecretResponse{
+			Path:      path,
+			Value:     secret.String(),
+			Fields:    secret.Fields,
+			Notes:     secret.Notes,
+			Tags:      secret.Metadata.Tags,
+			Protected: secret.Metadata.Protected,
+		}
+		if secret.Metadata.CreatedAt != nil {
+			item.CreatedAt = secret.Metadata.CreatedAt.Time
+		}
+		if secret.Metadata.ModifiedAt != nil {
+			item.UpdatedAt = secret.Metadata.ModifiedAt.Time
+		}
+		items = append(items, item)
+	}
+
+	s.requestLogger(r).Warn("audit: plaintext export", "count", len(items))
+
+	s.resetAutoLock()
+	s.writeJSON(w, http.StatusOK, ExportResponse{Secrets: items, Count: len(items), ExportedAt: s.clock.Now()})
+}
+
+// confirmed reports whether the request carries a valid master password
+// confirmation for accessing protected secrets.
+func (s *Server) confirmed(r *http.Request) bool {
+	password := r.Header.Get(HeaderConfirmPassword)
+	if password == "" {
+		return false
+	}
+	return s.store.VerifyPassword(password)
+}
+
+// handleStop stops the daemon.
+func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, SuccessResponse{Success: true, Message: "daemon stopping"})
+
+	// Send the response before Run starts draining connections
+	_ = http.NewResponseController(w).Flush()
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// resetAutoLock resets the auto-lock timer.
+func (s *Server) resetAutoLock() {
+	s.autoLockMu.Lock()
+	defer s.autoLockMu.Unlock()
+
+	if s.autoLockTimer != nil {
+		s.autoLockTimer.Stop()
+	}
+
+	// Round strips the monotonic reading, which stops while the system
+	// sleeps, so the idle time compared in lockAfterSleep includes sleep
+	s.lastActivity = s.clock.Now().Round(0)
+	s.autoLockTimer = s.clock.AfterFunc(s.autoLockDuration, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.autoLockUnsafe("vault auto-locked due to inactivity")
+	})
+}
+
+// autoLockDeadline returns when the vault auto-locks without further
+// activity, or the zero time if no timer is running.
+func (s *Server) autoLockDeadline() time.Time {
+	s.autoLockMu.Lock()
+	defer s.autoLockMu.Unlock()
+
+	if s.lastActivity.IsZero() {
+		return time.Time{}
+	}
+	return s.lastActivity.Add(s.autoLockDuration)
+}
+
+// lockAfterSleep wraps a handler so the vault is locked before a request
+// is served if it has been idle for longer than the auto-lock duration by
+// the wall clock. Timers run on the monotonic clock, which stops while
+// the system is suspended, so after a laptop wakes up the auto-lock timer
+// would otherwise only fire once the remaining time has passed.
+func (s *Server) lockAfterSleep(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.autoLockMu.Lock()
+		idle := s.clock.Now().Round(0).Sub(s.lastActivity)
+		slept := !s.lastActivity.IsZero() && idle >= s.autoLockDuration
+		s.autoLockMu.Unlock()
+
+		if slept {
+			s.mu.Lock()
+			s.autoLockUnsafe("vault auto-locked after the system slept")
+			s.mu.Unlock()
 		}
+
+		next.ServeHTTP(w, r)
 	})
 }
 
+// autoLockUnsafe locks the vault for inactivity, if it is unlocked.
+// Callers must hold s.mu.
+func (s *Server) autoLockUnsafe(message string) {
+	if s.store.IsLocked() {
+		return
+	}
+
+	s.autoLockMu.Lock()
+	if s.autoLockTimer != nil {
+		s.autoLockTimer.Stop()
+	}
+	s.lastActivity = time.Time{}
+	s.autoLockMu.Unlock()
+
+	if err := s.store.Lock(); err != nil {
+		s.logger.Warn("auto-lock failed", "error", err)
+	} else {
+		s.logger.Info(message)
+		s.revokeToken()
+		s.emit(EventLocked, ReasonAutoLock)
+	}
+}
+
 // writePIDFile writes the daemon PID to a file.
 func (s *Server) writePIDFile() error {
 	pid := os.Getpid()
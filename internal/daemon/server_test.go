@@ -0,0 +1,633 @@
+package daemon
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omnivault/internal/clock"
+	"github.com/agentplexus/omnivault/internal/config"
+	"github.com/agentplexus/omnivault/internal/store"
+)
+
+// slowBackend blocks data writes while armed until release is closed.
+type slowBackend struct {
+	store.Backend
+	armed   atomic.Bool
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (b *slowBackend) WriteData(data []byte) error {
+	if b.armed.CompareAndSwap(true, false) {
+		close(b.writing)
+		<-b.release
+	}
+	return b.Backend.WriteData(data)
+}
+
+func testPaths(t *testing.T) *config.Paths {
+	t.Helper()
+	dir := t.TempDir()
+	return &config.Paths{
+		ConfigDir:  dir,
+		VaultFile:  filepath.Join(dir, "vault.enc"),
+		MetaFile:   filepath.Join(dir, "vault.meta"),
+		SocketPath: filepath.Join(dir, "omnivaultd.sock"),
+		TCPAddr:    "127.0.0.1:19950",
+		PIDFile:    filepath.Join(dir, "omnivaultd.pid"),
+		LogFile:    filepath.Join(dir, "omnivaultd.log"),
+		TokenFile:  filepath.Join(dir, "omnivaultd.token"),
+	}
+}
+
+// testHTTPClient returns a client that connects to the daemon at paths.
+func testHTTPClient(paths *config.Paths) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				if runtime.GOOS == "windows" {
+					return d.DialContext(ctx, "tcp", paths.TCPAddr)
+				}
+				return d.DialContext(ctx, "unix", paths.SocketPath)
+			},
+		},
+	}
+}
+
+func doRequest(c *http.Client, method, path string, body any) (int, error) {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, "http://localhost"+path, r)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+func TestStopDrainsInFlightRequests(t *testing.T) {
+	paths := testPaths(t)
+	backend := &slowBackend{
+		Backend: store.NewFileBackend(paths.VaultFile, paths.MetaFile),
+		writing: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+
+	s := NewServerWithPaths(ServerConfig{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}, paths)
+	s.store = store.NewEncryptedStoreWithBackend(backend)
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- s.Run(context.Background()) }()
+
+	c := testHTTPClient(paths)
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		if _, err := doRequest(c, http.MethodGet, "/status", nil); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Daemon did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if status, err := doRequest(c, http.MethodPost, "/init", InitRequest{Password: "testpassword123"}); err != nil || status != http.StatusOK {
+		t.Fatalf("Init failed: %d, %v", status, err)
+	}
+
+	// Start a Set that blocks while writing the vault
+	backend.armed.Store(true)
+	setStatus := make(chan int, 1)
+	go func() {
+		status, err := doRequest(c, http.MethodPut, "/secret/db/password", SetSecretRequest{Value: "hunter2"})
+		if err != nil {
+			t.Errorf("Set failed: %v", err)
+		}
+		setStatus <- status
+	}()
+	<-backend.writing
+
+	// The stop response arrives while the Set is still writing
+	if status, err := doRequest(testHTTPClient(paths), http.MethodPost, "/stop", nil); err != nil || status != http.StatusOK {
+		t.Fatalf("Stop failed: %d, %v", status, err)
+	}
+
+	select {
+	case err := <-runErr:
+		t.Fatalf("Daemon exited before the Set finished: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(backend.release)
+	if status := <-setStatus; status != http.StatusOK {
+		t.Errorf("Expected Set to succeed, got status %d", status)
+	}
+
+	select {
+	case err := <-runErr:
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Daemon did not shut down")
+	}
+	if !s.store.IsLocked() {
+		t.Error("Expected vault to be locked after shutdown")
+	}
+
+	st := store.NewEncryptedStore(paths.VaultFile, paths.MetaFile)
+	if err := st.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	secret, err := st.Get(context.Background(), "db/password")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if secret.Value != "hunter2" {
+		t.Errorf("Expected persisted value 'hunter2', got %q", secret.Value)
+	}
+}
+
+func TestRequestSizeLimit(t *testing.T) {
+	var logs bytes.Buffer
+	s := NewServerWithPaths(ServerConfig{
+		Logger:           slog.New(slog.NewTextHandler(&logs, nil)),
+		MaxRequestBytes:  1024,
+		VaultSizeWarning: 1,
+	}, testPaths(t))
+	h := s.handler()
+
+	serve := func(method, path string, body any) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, bytes.NewReader(data)))
+		return rec
+	}
+
+	if rec := serve(http.MethodPost, "/init", InitRequest{Password: "testpassword123"}); rec.Code != http.StatusOK {
+		t.Fatalf("Init failed: %d %s", rec.Code, rec.Body)
+	}
+
+	rec := serve(http.MethodPut, "/secret/big", SetSecretRequest{Value: strings.Repeat("x", 2048)})
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected 413, got %d %s", rec.Code, rec.Body)
+	}
+	var errResp ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &errResp); err != nil || errResp.Code != ErrCodeInvalidRequest {
+		t.Errorf("Expected %s error, got %s", ErrCodeInvalidRequest, rec.Body)
+	}
+	if _, err := s.store.Get(context.Background(), "big"); err == nil {
+		t.Error("Expected oversized secret not to be stored")
+	}
+
+	if rec := serve(http.MethodPut, "/secret/small", SetSecretRequest{Value: "hunter2"}); rec.Code != http.StatusOK {
+		t.Errorf("Expected a normal request to succeed, got %d %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(logs.String(), "vault file exceeds size warning") {
+		t.Errorf("Expected a vault size warning, got logs:\n%s", logs.String())
+	}
+}
+
+func TestAutoLockFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	var locked []Event
+	s := NewServerWithPaths(ServerConfig{
+		Logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+		AutoLockDuration: 10 * time.Minute,
+		OnLock:           func(e Event) { locked = append(locked, e) },
+		Clock:            clk,
+	}, testPaths(t))
+	h := s.handler()
+
+	serve := func(method, path string, body any) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, bytes.NewReader(data)))
+		return rec
+	}
+
+	if rec := serve(http.MethodPost, "/init", InitRequest{Password: "testpassword123"}); rec.Code != http.StatusOK {
+		t.Fatalf("Init failed: %d %s", rec.Code, rec.Body)
+	}
+	if !s.store.UnlockTime().Equal(start) {
+		t.Errorf("UnlockTime = %v, want %v", s.store.UnlockTime(), start)
+	}
+
+	// Activity resets the timer
+	clk.Advance(9 * time.Minute)
+	if rec := serve(http.MethodPut, "/secret/db/password", SetSecretRequest{Value: "hunter2"}); rec.Code != http.StatusOK {
+		t.Fatalf("Set failed: %d %s", rec.Code, rec.Body)
+	}
+	clk.Advance(9 * time.Minute)
+	if s.store.IsLocked() {
+		t.Fatal("Expected the vault to stay unlocked after activity")
+	}
+
+	secret, err := s.store.Get(context.Background(), "db/password")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if want := start.Add(9 * time.Minute); !secret.Metadata.CreatedAt.Equal(want) {
+		t.Errorf("CreatedAt = %v, want %v", secret.Metadata.CreatedAt, want)
+	}
+
+	clk.Advance(time.Minute)
+	if !s.store.IsLocked() {
+		t.Fatal("Expected the vault to auto-lock")
+	}
+	if len(locked) != 1 || locked[0].Reason != ReasonAutoLock || !locked[0].Time.Equal(start.Add(19*time.Minute)) {
+		t.Errorf("Expected one auto-lock event at the fake time, got %+v", locked)
+	}
+}
+
+func TestAutoLockAfterSleep(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	var locked []Event
+	s := NewServerWithPaths(ServerConfig{
+		Logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+		AutoLockDuration: 10 * time.Minute,
+		OnLock:           func(e Event) { locked = append(locked, e) },
+		Clock:            clk,
+	}, testPaths(t))
+	h := s.handler()
+
+	serve := func(method, path string, body any) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, bytes.NewReader(data)))
+		return rec
+	}
+
+	if rec := serve(http.MethodPost, "/init", InitRequest{Password: "testpassword123"}); rec.Code != http.StatusOK {
+		t.Fatalf("Init failed: %d %s", rec.Code, rec.Body)
+	}
+	if rec := serve(http.MethodPut, "/secret/db/password", SetSecretRequest{Value: "hunter2"}); rec.Code != http.StatusOK {
+		t.Fatalf("Set failed: %d %s", rec.Code, rec.Body)
+	}
+
+	// A short sleep leaves the vault unlocked
+	clk.Jump(5 * time.Minute)
+	if rec := serve(http.MethodGet, "/secret/db/password", nil); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the vault to stay unlocked after a short sleep, got %d %s", rec.Code, rec.Body)
+	}
+
+	// The system sleeps past the auto-lock duration without the timer firing
+	clk.Jump(2 * time.Hour)
+	if s.store.IsLocked() {
+		t.Fatal("Expected the timer not to fire while the system slept")
+	}
+
+	rec := serve(http.MethodGet, "/secret/db/password", nil)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("Expected the first request after waking to find the vault locked, got %d %s", rec.Code, rec.Body)
+	}
+	if len(locked) != 1 || locked[0].Reason != ReasonAutoLock {
+		t.Errorf("Expected one auto-lock event, got %+v", locked)
+	}
+
+	// The stale timer does not lock the vault again once it is unlocked
+	if rec := serve(http.MethodPost, "/unlock", UnlockRequest{Password: "testpassword123"}); rec.Code != http.StatusOK {
+		t.Fatalf("Unlock failed: %d %s", rec.Code, rec.Body)
+	}
+	clk.Advance(time.Minute)
+	if s.store.IsLocked() || len(locked) != 1 {
+		t.Errorf("Expected the vault to stay unlocked, got %d lock events", len(locked))
+	}
+}
+
+func TestSession(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	paths := testPaths(t)
+	s := NewServerWithPaths(ServerConfig{
+		Logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+		AutoLockDuration: 10 * time.Minute,
+		RequireToken:     true,
+		Clock:            clk,
+	}, paths)
+	h := s.handler()
+
+	serve := func(method, path, token string, body any) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(data))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	session := func(token string) SessionResponse {
+		t.Helper()
+		rec := serve(http.MethodGet, "/session", token, nil)
+		var resp SessionResponse
+		if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &resp) != nil {
+			t.Fatalf("Session failed: %d %s", rec.Code, rec.Body)
+		}
+		return resp
+	}
+
+	rec := serve(http.MethodPost, "/init", "", InitRequest{Password: "testpassword123"})
+	var unlocked UnlockResponse
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &unlocked) != nil {
+		t.Fatalf("Init failed: %d %s", rec.Code, rec.Body)
+	}
+	token := unlocked.Token
+
+	got := session(token)
+	if want := start.Add(10 * time.Minute); got.AutoLockAt == nil || !got.AutoLockAt.Equal(want) {
+		t.Errorf("AutoLockAt = %v, want %v", got.AutoLockAt, want)
+	}
+	if got.Vault != paths.VaultFile || got.Locked || !got.TokenRequired || !got.TokenValid {
+		t.Errorf("Unexpected session: %+v", got)
+	}
+	if got.TokenExpiresAt == nil || !got.TokenExpiresAt.Equal(*got.AutoLockAt) {
+		t.Errorf("Expected the token to expire at auto-lock, got %v", got.TokenExpiresAt)
+	}
+
+	// Checking the session is not activity
+	clk.Advance(4 * time.Minute)
+	if got := session(token); got.AutoLockIn != "6m0s" || !got.AutoLockAt.Equal(start.Add(10*time.Minute)) {
+		t.Errorf("Expected 6m0s left before auto-lock, got %s at %v", got.AutoLockIn, got.AutoLockAt)
+	}
+
+	// Activity pushes the deadline back
+	if rec := serve(http.MethodPut, "/secret/db/password", token, SetSecretRequest{Value: "hunter2"}); rec.Code != http.StatusOK {
+		t.Fatalf("Set failed: %d %s", rec.Code, rec.Body)
+	}
+	got = session(token)
+	if want := start.Add(14 * time.Minute); got.AutoLockAt == nil || !got.AutoLockAt.Equal(want) || got.AutoLockIn != "10m0s" {
+		t.Errorf("Expected the deadline to move to %v after activity, got %v (%s)", want, got.AutoLockAt, got.AutoLockIn)
+	}
+
+	if got := session("wrong"); got.TokenValid || got.TokenExpiresAt != nil {
+		t.Errorf("Expected an invalid token to be reported, got %+v", got)
+	}
+
+	clk.Advance(10 * time.Minute)
+	if got := session(token); !got.Locked || got.AutoLockAt != nil || got.TokenValid {
+		t.Errorf("Expected a locked session without a deadline, got %+v", got)
+	}
+}
+
+func TestConstantTimeLookups(t *testing.T) {
+	s := NewServerWithPaths(ServerConfig{
+		Logger:              slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ConstantTimeLookups: true,
+	}, testPaths(t))
+	h := s.handler()
+
+	serve := func(method, path string, body any) (*httptest.ResponseRecorder, time.Duration) {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		start := time.Now()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, bytes.NewReader(data)))
+		return rec, time.Since(start)
+	}
+
+	if rec, _ := serve(http.MethodPost, "/init", InitRequest{Password: "testpassword123"}); rec.Code != http.StatusOK {
+		t.Fatalf("Init failed: %d %s", rec.Code, rec.Body)
+	}
+	if rec, _ := serve(http.MethodPut, "/secret/db/password", SetSecretRequest{Value: "hunter2"}); rec.Code != http.StatusOK {
+		t.Fatalf("Set failed: %d %s", rec.Code, rec.Body)
+	}
+
+	present, presentTime := serve(http.MethodGet, "/secret/db/password", nil)
+	absent, absentTime := serve(http.MethodGet, "/secret/db/missing", nil)
+	if present.Code != http.StatusOK || absent.Code != http.StatusNotFound {
+		t.Fatalf("Unexpected status codes %d and %d", present.Code, absent.Code)
+	}
+
+	for _, elapsed := range []time.Duration{presentTime, absentTime} {
+		if elapsed < LookupFloor {
+			t.Errorf("Expected lookups to take at least %v, took %v", LookupFloor, elapsed)
+		}
+	}
+	if diff := presentTime - absentTime; diff > LookupFloor || diff < -LookupFloor {
+		t.Errorf("Expected comparable lookup times, got %v (present) and %v (absent)", presentTime, absentTime)
+	}
+}
+
+func TestResponseCompression(t *testing.T) {
+	s := NewServerWithPaths(ServerConfig{
+		Logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+		CompressMinBytes: 1024,
+	}, testPaths(t))
+	h := s.handler()
+
+	serve := func(method, path string, body any, acceptGzip bool) *httptest.ResponseRecorder {
+		var r io.Reader
+		if body != nil {
+			data, err := json.Marshal(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r = bytes.NewReader(data)
+		}
+		req := httptest.NewRequest(method, path, r)
+		if acceptGzip {
+			req.Header.Set("Accept-Encoding", "gzip")
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(http.MethodPost, "/init", InitRequest{Password: "testpassword123"}, false); rec.Code != http.StatusOK {
+		t.Fatalf("Init failed: %d %s", rec.Code, rec.Body)
+	}
+	for i := 0; i < 50; i++ {
+		path := fmt.Sprintf("/secret/app/secret-%02d", i)
+		if rec := serve(http.MethodPut, path, SetSecretRequest{Value: "value"}, false); rec.Code != http.StatusOK {
+			t.Fatalf("Set failed: %d %s", rec.Code, rec.Body)
+		}
+	}
+
+	rec := serve(http.MethodGet, "/secrets", nil, true)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected a gzip-encoded list, got %d %v", rec.Code, rec.Header())
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("Invalid gzip response: %v", err)
+	}
+	var list ListResponse
+	if err := json.NewDecoder(zr).Decode(&list); err != nil {
+		t.Fatalf("Failed to decode list: %v", err)
+	}
+	if list.Count != 50 || list.Secrets[0].Path != "app/secret-00" {
+		t.Errorf("Unexpected list: count %d, first %+v", list.Count, list.Secrets[0])
+	}
+
+	// Small responses and clients that don't accept gzip get plain JSON
+	if rec := serve(http.MethodGet, "/status", nil, true); rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("Expected a small response not to be compressed, got %v", rec.Header())
+	}
+	rec = serve(http.MethodGet, "/secrets", nil, false)
+	if rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("Expected no compression without Accept-Encoding, got %v", rec.Header())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || list.Count != 50 {
+		t.Errorf("Expected a plain list, got %v", err)
+	}
+}
+
+func TestMaxSecretBytes(t *testing.T) {
+	s := NewServerWithPaths(ServerConfig{
+		Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+		MaxSecretBytes: 100,
+	}, testPaths(t))
+	h := s.handler()
+
+	serve := func(method, path string, body any) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, bytes.NewReader(data)))
+		return rec
+	}
+
+	if rec := serve(http.MethodPost, "/init", InitRequest{Password: "testpassword123"}); rec.Code != http.StatusOK {
+		t.Fatalf("Init failed: %d %s", rec.Code, rec.Body)
+	}
+
+	// 60 + 4 + 36 bytes, with the field name counted
+	under := SetSecretRequest{Value: strings.Repeat("x", 60), Fields: map[string]string{"user": strings.Repeat("y", 36)}}
+	if rec := serve(http.MethodPut, "/secret/under", under); rec.Code != http.StatusOK {
+		t.Errorf("Expected a secret at the limit to be stored, got %d %s", rec.Code, rec.Body)
+	}
+
+	over := SetSecretRequest{Value: strings.Repeat("x", 60), Fields: map[string]string{"user": strings.Repeat("y", 37)}}
+	rec := serve(http.MethodPut, "/secret/over", over)
+	var errResp ErrorResponse
+	if rec.Code != http.StatusRequestEntityTooLarge || json.Unmarshal(rec.Body.Bytes(), &errResp) != nil ||
+		errResp.Error != "secret is 101 bytes, over the limit of 100 bytes" {
+		t.Errorf("Expected 413 naming the limit, got %d %s", rec.Code, rec.Body)
+	}
+	if _, err := s.store.Get(context.Background(), "over"); err == nil {
+		t.Error("Expected the oversized secret not to be stored")
+	}
+
+	// Merging counts the merged secret
+	merge := SetSecretRequest{Fields: map[string]string{"x": "z"}, Merge: true}
+	if rec := serve(http.MethodPut, "/secret/under", merge); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected a merge over the limit to be rejected, got %d %s", rec.Code, rec.Body)
+	}
+
+	rec = serve(http.MethodGet, "/vault-info", nil)
+	var info VaultInfoResponse
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &info) != nil || info.MaxSecretBytes != 100 {
+		t.Errorf("Expected vault-info to report the limit, got %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestGetSecretField(t *testing.T) {
+	s := NewServerWithPaths(ServerConfig{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}, testPaths(t))
+	h := s.handler()
+
+	serve := func(method, path string, body any) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, bytes.NewReader(data)))
+		return rec
+	}
+
+	if rec := serve(http.MethodPost, "/init", InitRequest{Password: "testpassword123"}); rec.Code != http.StatusOK {
+		t.Fatalf("Init failed: %d %s", rec.Code, rec.Body)
+	}
+	secret := SetSecretRequest{
+		Value:  "main-value",
+		Fields: map[string]string{"username": "admin", "password": "hunter2"},
+		Tags:   map[string]string{"env": "prod"},
+		Notes:  "rotate quarterly",
+	}
+	if rec := serve(http.MethodPut, "/secret/db", secret); rec.Code != http.StatusOK {
+		t.Fatalf("Set failed: %d %s", rec.Code, rec.Body)
+	}
+
+	rec := serve(http.MethodGet, "/secret/db?field=username", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Get failed: %d %s", rec.Code, rec.Body)
+	}
+	for _, leaked := range []string{"main-value", "hunter2", "password", "rotate quarterly"} {
+		if strings.Contains(rec.Body.String(), leaked) {
+			t.Errorf("Expected %q to be left out of the response, got %s", leaked, rec.Body)
+		}
+	}
+	var resp SecretResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Path != "db" || resp.Value != "" || len(resp.Fields) != 1 || resp.Fields["username"] != "admin" {
+		t.Errorf("Expected only the username field, got %+v", resp)
+	}
+	if resp.Tags["env"] != "prod" {
+		t.Errorf("Expected the secret's metadata, got %+v", resp)
+	}
+
+	// Without the field parameter, the whole secret is returned
+	rec = serve(http.MethodGet, "/secret/db", nil)
+	resp = SecretResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Value != "main-value" || len(resp.Fields) != 2 || resp.Notes != "rotate quarterly" {
+		t.Errorf("Expected the whole secret, got %+v", resp)
+	}
+
+	rec = serve(http.MethodGet, "/secret/db?field=missing", nil)
+	var errResp ErrorResponse
+	if rec.Code != http.StatusNotFound || json.Unmarshal(rec.Body.Bytes(), &errResp) != nil || errResp.Code != ErrCodeFieldNotFound {
+		t.Errorf("Expected 404 field not found, got %d %s", rec.Code, rec.Body)
+	}
+}
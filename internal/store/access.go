@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// noAccessKey marks contexts whose reads are not tracked.
+type noAccessKey struct{}
+
+// WithoutAccessTracking returns a context whose Get calls are not recorded
+// as accesses, for reads that only inspect a secret, such as before
+// overwriting it.
+func WithoutAccessTracking(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noAccessKey{}, true)
+}
+
+// SetTrackAccess enables recording when each secret was last read with Get,
+// in Metadata.LastAccessedAt. To avoid a write for every read, accesses are
+// kept in memory and saved with the next write, on Lock, or by
+// FlushAccess; accesses not saved yet are lost if the process exits
+// without locking the vault.
+func (s *EncryptedStore) SetTrackAccess(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trackAccess = enabled
+}
+
+// recordAccess records that the secret at path was read and sets its
+// LastAccessedAt. Callers must hold s.mu, at least for reading.
+func (s *EncryptedStore) recordAccess(ctx context.Context, path string, secret *vault.Secret) {
+	if !s.trackAccess || ctx.Value(noAccessKey{}) != nil {
+		return
+	}
+
+	now := s.clock.Now()
+	s.accessMu.Lock()
+	if s.accessed == nil {
+		s.accessed = make(map[string]time.Time)
+	}
+	s.accessed[path] = now
+	s.accessMu.Unlock()
+
+	secret.Metadata.LastAccessedAt = vault.NewTimestamp(now)
+}
+
+// pendingAccess returns when the secret at path was read, if that has not
+// been saved yet. Callers must hold s.mu, at least for reading.
+func (s *EncryptedStore) pendingAccess(path string) (time.Time, bool) {
+	s.accessMu.Lock()
+	defer s.accessMu.Unlock()
+	t, ok := s.accessed[path]
+	return t, ok
+}
+
+// foldAccess writes the recorded accesses into the secrets' metadata,
+// marking the data dirty if there were any. Callers must hold s.mu.
+func (s *EncryptedStore) foldAccess() error {
+	s.accessMu.Lock()
+	accessed := s.accessed
+	s.accessed = nil
+	s.accessMu.Unlock()
+
+	for path, t := range accessed {
+		secret, err := s.decrypt(path)
+		if errors.Is(err, vault.ErrSecretNotFound) {
+			continue // Deleted since it was read
+		}
+		if err != nil {
+			return err
+		}
+		secret.Metadata.LastAccessedAt = vault.NewTimestamp(t)
+		if err := s.encrypt(path, secret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FlushAccess saves the accesses recorded since the last write.
+func (s *EncryptedStore) FlushAccess(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isLockedUnsafe() {
+		return errors.New("vault is locked")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if len(s.accessed) == 0 {
+		return nil
+	}
+	if err := s.foldAccess(); err != nil {
+		return err
+	}
+
+	if s.autoSave {
+		return s.commit(ctx)
+	}
+
+	return nil
+}
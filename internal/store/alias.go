@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// AliasKey is the Metadata.Extra key holding the target path of an alias.
+const AliasKey = "alias"
+
+// MaxAliasDepth is the maximum number of aliases followed to reach a secret.
+const MaxAliasDepth = 8
+
+var (
+	// ErrAliasLoop is returned when an alias chain loops back on itself or
+	// is longer than MaxAliasDepth.
+	ErrAliasLoop = errors.New("alias loop detected")
+
+	// ErrAliasWrite is returned when setting a value on an alias while
+	// write-through is disabled.
+	ErrAliasWrite = errors.New("cannot set a value on an alias")
+)
+
+// aliasOf returns the target path if the secret is an alias.
+func aliasOf(secret *vault.Secret) string {
+	target, _ := secret.Metadata.Extra[AliasKey].(string)
+	return target
+}
+
+// SetAliasWriteThrough controls what Set does when the path is an alias.
+// When enabled (the default) the secret is written to the alias target;
+// otherwise Set returns ErrAliasWrite.
+func (s *EncryptedStore) SetAliasWriteThrough(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aliasReadOnly = !enabled
+}
+
+// SetAlias makes path an alias of target, so that Get on path returns the
+// target secret. Any existing secret at path is replaced. The target must
+// exist and must not resolve back to path.
+func (s *EncryptedStore) SetAlias(ctx context.Context, path, target string) error {
+	secret := &vault.Secret{
+		Metadata: vault.Metadata{
+			Extra: map[string]any{AliasKey: target},
+		},
+	}
+	return s.Set(ctx, path, secret)
+}
+
+// AliasTarget returns the path that the secret at path is an alias of, or
+// an empty string if it is not an alias.
+func (s *EncryptedStore) AliasTarget(ctx context.Context, path string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.isLockedUnsafe() {
+		return "", errors.New("vault is locked")
+	}
+
+	secret, err := s.decrypt(s.cleanPath(path))
+	if err != nil {
+		return "", err
+	}
+	return aliasOf(secret), nil
+}
+
+// resolvePath follows aliases from path and returns the path of the secret
+// they point to, which may not exist. Callers must hold s.mu.
+func (s *EncryptedStore) resolvePath(path string) (string, error) {
+	for depth := 0; ; depth++ {
+		if _, ok := s.data.Secrets[path]; !ok {
+			return path, nil
+		}
+
+		secret, err := s.decrypt(path)
+		if err != nil {
+			return "", err
+		}
+
+		target := aliasOf(secret)
+		if target == "" {
+			return path, nil
+		}
+		if depth >= MaxAliasDepth {
+			return "", ErrAliasLoop
+		}
+		path = target
+	}
+}
+
+// checkAlias verifies that making path an alias of target creates no loop
+// and that the target exists. Callers must hold s.mu.
+func (s *EncryptedStore) checkAlias(path, target string) error {
+	current := target
+	for depth := 1; ; depth++ {
+		if current == path || depth > MaxAliasDepth {
+			return ErrAliasLoop
+		}
+		if _, ok := s.data.Secrets[current]; !ok {
+			return vault.ErrSecretNotFound
+		}
+
+		secret, err := s.decrypt(current)
+		if err != nil {
+			return err
+		}
+
+		next := aliasOf(secret)
+		if next == "" {
+			return nil
+		}
+		current = next
+	}
+}
@@ -0,0 +1,279 @@
+package store
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// Backend persists the vault metadata and encrypted data blobs.
+// Implementations must return an error matching fs.ErrNotExist when
+// the requested blob has not been written yet.
+type Backend interface {
+	// ReadMeta reads the unencrypted vault metadata.
+	ReadMeta() ([]byte, error)
+
+	// WriteMeta writes the unencrypted vault metadata.
+	WriteMeta(data []byte) error
+
+	// ReadData reads the encrypted vault data.
+	ReadData() ([]byte, error)
+
+	// WriteData writes the encrypted vault data.
+	WriteData(data []byte) error
+}
+
+// DurableBackend is implemented by backends that can sync writes to
+// stable storage before returning, so a power loss can't lose a write that
+// succeeded.
+type DurableBackend interface {
+	// SetDurable turns syncing on or off.
+	SetDurable(durable bool)
+}
+
+// fileBackend stores the vault in two files on the local filesystem, and
+// its write-ahead log next to the data file. Files are replaced atomically
+// by renaming a temporary file over them.
+type fileBackend struct {
+	vaultPath string
+	metaPath  string
+
+	// sync flushes a written file to stable storage; nil if writes are
+	// not durable
+	sync func(*os.File) error
+}
+
+// NewFileBackend creates a backend storing data and metadata at the given
+// paths. Writes are durable unless turned off with SetDurable.
+func NewFileBackend(vaultPath, metaPath string) Backend {
+	return &fileBackend{
+		vaultPath: vaultPath,
+		metaPath:  metaPath,
+		sync:      (*os.File).Sync,
+	}
+}
+
+// SetDurable turns syncing files and their directory after writes on or
+// off. Turning it off is faster but only suitable for disposable vaults.
+func (b *fileBackend) SetDurable(durable bool) {
+	b.sync = nil
+	if durable {
+		b.sync = (*os.File).Sync
+	}
+}
+
+// ReadMeta reads the metadata file.
+func (b *fileBackend) ReadMeta() ([]byte, error) {
+	return os.ReadFile(b.metaPath)
+}
+
+// WriteMeta writes the metadata file, creating its directory if needed.
+func (b *fileBackend) WriteMeta(data []byte) error {
+	return b.writeFile(b.metaPath, data)
+}
+
+// ReadData reads the vault data file.
+func (b *fileBackend) ReadData() ([]byte, error) {
+	return os.ReadFile(b.vaultPath)
+}
+
+// WriteData writes the vault data file, creating its directory if needed.
+func (b *fileBackend) WriteData(data []byte) error {
+	return b.writeFile(b.vaultPath, data)
+}
+
+// walPath returns the path of the write-ahead log.
+func (b *fileBackend) walPath() string {
+	return b.vaultPath + ".wal"
+}
+
+// AppendWAL appends a record to the write-ahead log file and syncs it.
+func (b *fileBackend) AppendWAL(record []byte) error {
+	if err := os.MkdirAll(filepath.Dir(b.vaultPath), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(b.walPath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(record); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if b.sync != nil {
+		if err := b.sync(f); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// ReadWAL reads the write-ahead log file.
+func (b *fileBackend) ReadWAL() ([]byte, error) {
+	return os.ReadFile(b.walPath())
+}
+
+// TruncateWAL removes the write-ahead log file.
+func (b *fileBackend) TruncateWAL() error {
+	if err := os.Remove(b.walPath()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// writeFile replaces the file at path with data, with owner-only
+// permissions. The data is written to a temporary file that is renamed
+// over path, so a crash leaves either the old or the new file. If writes
+// are durable, the file is synced before the rename and its directory
+// after it.
+func (b *fileBackend) writeFile(path string, data []byte) error {
+	// Ensure directory exists
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if err := b.writeTemp(f, data); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	if b.sync == nil {
+		return nil
+	}
+	return b.syncDir(dir)
+}
+
+// writeTemp writes data to the temporary file f, syncs it if writes are
+// durable, and closes it.
+func (b *fileBackend) writeTemp(f *os.File, data []byte) error {
+	if err := f.Chmod(0600); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if b.sync != nil {
+		if err := b.sync(f); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// syncDir syncs a directory so a rename in it is durable.
+func (b *fileBackend) syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		// Directories can't be opened for syncing on Windows
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := b.sync(d); err != nil {
+		_ = d.Close()
+		return err
+	}
+	return d.Close()
+}
+
+// MemBackend stores the vault in memory. It is primarily useful for tests.
+type MemBackend struct {
+	mu   sync.RWMutex
+	meta []byte
+	data []byte
+	wal  []byte
+}
+
+// NewMemBackend creates an empty in-memory backend.
+func NewMemBackend() *MemBackend {
+	return &MemBackend{}
+}
+
+// ReadMeta returns a copy of the stored metadata.
+func (b *MemBackend) ReadMeta() ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.meta == nil {
+		return nil, fs.ErrNotExist
+	}
+	return append([]byte(nil), b.meta...), nil
+}
+
+// WriteMeta stores a copy of the metadata.
+func (b *MemBackend) WriteMeta(data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.meta = append([]byte(nil), data...)
+	return nil
+}
+
+// ReadData returns a copy of the stored vault data.
+func (b *MemBackend) ReadData() ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.data == nil {
+		return nil, fs.ErrNotExist
+	}
+	return append([]byte(nil), b.data...), nil
+}
+
+// WriteData stores a copy of the vault data.
+func (b *MemBackend) WriteData(data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append([]byte(nil), data...)
+	return nil
+}
+
+// AppendWAL appends a copy of record to the write-ahead log.
+func (b *MemBackend) AppendWAL(record []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.wal = append(b.wal, record...)
+	return nil
+}
+
+// ReadWAL returns a copy of the write-ahead log.
+func (b *MemBackend) ReadWAL() ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.wal == nil {
+		return nil, fs.ErrNotExist
+	}
+	return append([]byte(nil), b.wal...), nil
+}
+
+// TruncateWAL removes the write-ahead log.
+func (b *MemBackend) TruncateWAL() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.wal = nil
+	return nil
+}
+
+// Ensure backends implement Backend, WALBackend, and DurableBackend.
+var (
+	_ Backend        = (*fileBackend)(nil)
+	_ Backend        = (*MemBackend)(nil)
+	_ WALBackend     = (*fileBackend)(nil)
+	_ WALBackend     = (*MemBackend)(nil)
+	_ DurableBackend = (*fileBackend)(nil)
+)
@@ -4,11 +4,14 @@ package store
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"time"
 
 	"golang.org/x/crypto/argon2"
 )
@@ -21,6 +24,12 @@ type Argon2Params struct {
 	KeyLen  uint32 `json:"key_len"`
 }
 
+// Algorithm identifiers reported by EncryptedStore.Info.
+const (
+	KDFArgon2id     = "argon2id"
+	CipherAES256GCM = "aes-256-gcm"
+)
+
 // DefaultArgon2Params returns secure default parameters for Argon2id.
 // These are based on OWASP recommendations for password hashing.
 func DefaultArgon2Params() Argon2Params {
@@ -32,11 +41,30 @@ func DefaultArgon2Params() Argon2Params {
 	}
 }
 
+// BenchmarkParams measures how long deriving a key with the given parameters
+// takes on the current machine. It uses a fixed salt and has no side effects.
+func BenchmarkParams(params Argon2Params, password string) time.Duration {
+	salt := make([]byte, 32)
+	start := time.Now()
+	key := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, params.KeyLen)
+	elapsed := time.Since(start)
+
+	for i := range key {
+		key[i] = 0
+	}
+	return elapsed
+}
+
 // Crypto handles encryption and key derivation for the vault.
+//
+// Secrets are encrypted with a data encryption key, which is stored in the
+// vault metadata wrapped (encrypted) with a key derived from the password.
+// Changing the password only re-wraps the data key.
 type Crypto struct {
 	params Argon2Params
 	salt   []byte
-	key    []byte // Derived key (only set when unlocked)
+	kek    []byte // Password-derived key encryption key (only set when unlocked)
+	key    []byte // Data encryption key (only set when unlocked)
 }
 
 // NewCrypto creates a new Crypto instance with the given salt.
@@ -82,18 +110,69 @@ func (c *Crypto) DeriveKey(password string) []byte {
 }
 
 // Unlock derives the key from the password and stores it for encryption/decryption.
+// Until UnwrapDataKey or NewDataKey is called, the derived key is also used
+// as the data encryption key, as in vaults created before data keys were
+// introduced.
 func (c *Crypto) Unlock(password string) {
-	c.key = c.DeriveKey(password)
+	c.kek = c.DeriveKey(password)
+	c.key = append([]byte(nil), c.kek...)
 }
 
-// Lock clears the derived key from memory.
+// Lock clears the keys from memory.
 func (c *Crypto) Lock() {
-	if c.key != nil {
-		// Zero out the key before releasing
-		for i := range c.key {
-			c.key[i] = 0
-		}
-		c.key = nil
+	// Zero out the keys before releasing
+	zero(c.kek)
+	zero(c.key)
+	c.kek = nil
+	c.key = nil
+}
+
+// NewDataKey replaces the data encryption key with a new random key.
+func (c *Crypto) NewDataKey() error {
+	if c.key == nil {
+		return errors.New("vault is locked")
+	}
+	key, err := GenerateRandomBytes(len(c.key))
+	if err != nil {
+		return fmt.Errorf("failed to generate data key: %w", err)
+	}
+	zero(c.key)
+	c.key = key
+	return nil
+}
+
+// WrapDataKey encrypts the data encryption key with the password-derived
+// key, for storing in the vault metadata.
+func (c *Crypto) WrapDataKey() (string, error) {
+	if c.kek == nil {
+		return "", errors.New("vault is locked")
+	}
+	return sealer(c.kek, c.key)
+}
+
+// UnwrapDataKey decrypts a data encryption key wrapped by WrapDataKey and
+// uses it from then on.
+func (c *Crypto) UnwrapDataKey(wrapped string) error {
+	if c.kek == nil {
+		return errors.New("vault is locked")
+	}
+	key, err := open(c.kek, wrapped)
+	if err != nil {
+		return fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	zero(c.key)
+	c.key = key
+	return nil
+}
+
+// withDataKey returns an unlocked copy of c that uses the data key of
+// other.
+func (c *Crypto) withDataKey(other *Crypto) *Crypto {
+	return &Crypto{
+		params: c.params,
+		salt:   c.salt,
+		kek:    append([]byte(nil), c.kek...),
+		key:    append([]byte(nil), other.key...),
 	}
 }
 
@@ -108,8 +187,16 @@ func (c *Crypto) Encrypt(plaintext []byte) (string, error) {
 	if c.key == nil {
 		return "", errors.New("vault is locked")
 	}
+	return sealer(c.key, plaintext)
+}
 
-	block, err := aes.NewCipher(c.key)
+// sealer is the cipher used by Encrypt, WrapDataKey, and
+// CreateVerificationBlob. Tests replace it to simulate a faulty cipher.
+var sealer = seal
+
+// seal encrypts plaintext with key using AES-256-GCM.
+func seal(key, plaintext []byte) (string, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", fmt.Errorf("failed to create cipher: %w", err)
 	}
@@ -136,13 +223,17 @@ func (c *Crypto) Decrypt(encoded string) ([]byte, error) {
 	if c.key == nil {
 		return nil, errors.New("vault is locked")
 	}
+	return open(c.key, encoded)
+}
 
+// open decrypts ciphertext encrypted by seal with key.
+func open(key []byte, encoded string) ([]byte, error) {
 	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode ciphertext: %w", err)
 	}
 
-	block, err := aes.NewCipher(c.key)
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cipher: %w", err)
 	}
@@ -180,54 +271,96 @@ func (c *Crypto) DecryptString(encoded string) (string, error) {
 	return string(plaintext), nil
 }
 
+// MAC computes a keyed HMAC-SHA256 over data using a subkey derived from
+// the master key. Returns the base64-encoded MAC.
+func (c *Crypto) MAC(data []byte) (string, error) {
+	if c.key == nil {
+		return "", errors.New("vault is locked")
+	}
+
+	// Derive a separate key so the encryption key is never used for MACs
+	sub := hmac.New(sha256.New, c.key)
+	sub.Write([]byte(macKeyContext))
+	macKey := sub.Sum(nil)
+	defer zero(macKey)
+
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(data)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// VerifyMAC reports whether mac is a valid MAC for data.
+func (c *Crypto) VerifyMAC(data []byte, mac string) bool {
+	expected, err := c.MAC(data)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(expected), []byte(mac))
+}
+
 // VerifyPassword checks if the given password matches by attempting to decrypt
 // a verification blob. Returns true if password is correct.
 func (c *Crypto) VerifyPassword(password string, verificationBlob string) bool {
 	// Temporarily derive key from password
 	key := c.DeriveKey(password)
-	defer func() {
-		for i := range key {
-			key[i] = 0
-		}
-	}()
+	defer zero(key)
 
 	// Try to decrypt verification blob
-	ciphertext, err := base64.StdEncoding.DecodeString(verificationBlob)
+	plaintext, err := open(key, verificationBlob)
 	if err != nil {
 		return false
 	}
 
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return false
+	// Verify the magic bytes
+	return subtle.ConstantTimeCompare(plaintext, []byte(verificationMagic)) == 1
+}
+
+// CreateVerificationBlob creates an encrypted blob that can be used to verify passwords.
+func (c *Crypto) CreateVerificationBlob() (string, error) {
+	if c.kek == nil {
+		return "", errors.New("vault is locked")
 	}
+	return sealer(c.kek, []byte(verificationMagic))
+}
 
-	gcm, err := cipher.NewGCM(block)
+// verifyBlob checks that a verification blob decrypts with c's password-
+// derived key.
+func (c *Crypto) verifyBlob(verification string) error {
+	plaintext, err := open(c.kek, verification)
 	if err != nil {
-		return false
+		return fmt.Errorf("%w: verification blob: %v", ErrVerificationFailed, err)
 	}
-
-	nonceSize := gcm.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return false
+	if subtle.ConstantTimeCompare(plaintext, []byte(verificationMagic)) != 1 {
+		return fmt.Errorf("%w: verification blob does not match", ErrVerificationFailed)
 	}
+	return nil
+}
 
-	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+// verifyWrappedKey checks that a data key wrapped by WrapDataKey unwraps
+// with c's password-derived key to c's data key.
+func (c *Crypto) verifyWrappedKey(wrapped string) error {
+	key, err := open(c.kek, wrapped)
 	if err != nil {
-		return false
+		return fmt.Errorf("%w: wrapped data key: %v", ErrVerificationFailed, err)
 	}
-
-	// Verify the magic bytes
-	return subtle.ConstantTimeCompare(plaintext, []byte(verificationMagic)) == 1
+	defer zero(key)
+	if subtle.ConstantTimeCompare(key, c.key) != 1 {
+		return fmt.Errorf("%w: wrapped data key does not match", ErrVerificationFailed)
+	}
+	return nil
 }
 
-// CreateVerificationBlob creates an encrypted blob that can be used to verify passwords.
-func (c *Crypto) CreateVerificationBlob() (string, error) {
-	return c.EncryptString(verificationMagic)
-}
+const (
+	verificationMagic = "omnivault-v1"
+	macKeyContext     = "omnivault-integrity-v1"
+)
 
-const verificationMagic = "omnivault-v1"
+// zero overwrites key material.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
 
 // GenerateRandomBytes generates cryptographically secure random bytes.
 func GenerateRandomBytes(n int) ([]byte, error) {
@@ -3,6 +3,7 @@ package store
 import (
 	"bytes"
 	"testing"
+	"time"
 )
 
 func TestCryptoNew(t *testing.T) {
@@ -262,3 +263,65 @@ func TestGenerateRandomBytes(t *testing.T) {
 		t.Errorf("Expected length 32, got %d", len(b1))
 	}
 }
+
+func TestBenchmarkParams(t *testing.T) {
+	low := Argon2Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32}
+	high := Argon2Params{Time: 1, Memory: 32 * 1024, Threads: 1, KeyLen: 32}
+
+	// Take the fastest of a few runs to reduce scheduling noise
+	fastest := func(params Argon2Params) time.Duration {
+		best := BenchmarkParams(params, "password123")
+		for i := 0; i < 2; i++ {
+			if d := BenchmarkParams(params, "password123"); d < best {
+				best = d
+			}
+		}
+		return best
+	}
+
+	lowTime := fastest(low)
+	highTime := fastest(high)
+
+	if lowTime <= 0 || highTime <= 0 {
+		t.Fatalf("Expected positive durations, got %v and %v", lowTime, highTime)
+	}
+
+	// Loosely assert that 32x the memory is not faster
+	if highTime*2 < lowTime {
+		t.Errorf("Expected higher memory to take longer: 1MB=%v, 32MB=%v", lowTime, highTime)
+	}
+}
+
+func TestCryptoDataKey(t *testing.T) {
+	crypto, _ := NewCrypto(nil, DefaultArgon2Params())
+	crypto.Unlock("password123")
+
+	if err := crypto.NewDataKey(); err != nil {
+		t.Fatalf("NewDataKey failed: %v", err)
+	}
+	encrypted, _ := crypto.EncryptString("secret")
+	wrapped, err := crypto.WrapDataKey()
+	if err != nil {
+		t.Fatalf("WrapDataKey failed: %v", err)
+	}
+	crypto.Lock()
+
+	// The password alone does not decrypt data encrypted with the data key
+	crypto.Unlock("password123")
+	if _, err := crypto.DecryptString(encrypted); err == nil {
+		t.Error("Expected decryption with the password-derived key to fail")
+	}
+
+	if err := crypto.UnwrapDataKey(wrapped); err != nil {
+		t.Fatalf("UnwrapDataKey failed: %v", err)
+	}
+	if decrypted, err := crypto.DecryptString(encrypted); err != nil || decrypted != "secret" {
+		t.Errorf("Expected 'secret', got %q, %v", decrypted, err)
+	}
+
+	other, _ := NewCrypto(nil, DefaultArgon2Params())
+	other.Unlock("password123")
+	if err := other.UnwrapDataKey(wrapped); err == nil {
+		t.Error("Expected unwrapping with a different salt to fail")
+	}
+}
@@ -1,27 +1,76 @@
 package store
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io/fs"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/agentplexus/omnivault/internal/clock"
 	"github.com/agentplexus/omnivault/vault"
 )
 
+// ErrTampered is returned when the vault data fails its integrity check,
+// e.g. because entries were removed or the file was truncated.
+var ErrTampered = errors.New("vault data integrity check failed")
+
+// ErrVerificationFailed is returned by ChangePassword and RotateDEK when
+// the newly encrypted data does not decrypt back to the original. The
+// vault is left unchanged.
+var ErrVerificationFailed = errors.New("re-encryption verification failed")
+
+// Vault metadata versions.
+const (
+	// metaVersionDirect vaults encrypt secrets with the password-derived key
+	metaVersionDirect = 1
+
+	// metaVersionDataKey vaults encrypt secrets with a data key stored
+	// wrapped in the metadata
+	metaVersionDataKey = 2
+)
+
 // VaultMeta contains unencrypted vault metadata.
 type VaultMeta struct {
 	Version      int          `json:"version"`
 	CreatedAt    time.Time    `json:"created_at"`
 	Salt         []byte       `json:"salt"`
 	Argon2Params Argon2Params `json:"argon2_params"`
-	Verification string       `json:"verification"` // Encrypted verification blob
+	Verification string       `json:"verification"`          // Encrypted verification blob
+	WrappedKey   string       `json:"wrapped_key,omitempty"` // Data key encrypted with the password-derived key
+	DataMAC      string       `json:"data_mac,omitempty"`    // MAC over the vault data file, before it was appended to the file
+
+	// DataMACAppended is set once the vault data file ends with its MAC,
+	// so removing the file or stripping the MAC is detected
+	DataMACAppended bool `json:"data_mac_appended,omitempty"`
+
+	// NormalizePaths is set for vaults whose secret paths are normalized,
+	// which is the default for new vaults (see NormalizePath)
+	NormalizePaths bool `json:"normalize_paths,omitempty"`
+
+	// RecoveryWrappedKey is the data key encrypted with the key derived
+	// from the recovery key, and RecoveryKEK that key encrypted with the
+	// data key, so the data key can be re-wrapped when it is rotated
+	RecoveryWrappedKey string `json:"recovery_wrapped_key,omitempty"`
+	RecoveryKEK        string `json:"recovery_kek,omitempty"`
+
+	// PendingKey is set while RotateDEK writes the data with a new data
+	// key (see settleDataKey)
+	PendingKey *PendingKey `json:"pending_key,omitempty"`
+}
+
+// PendingKey holds the wrapped forms of a data key that RotateDEK is
+// switching to, until the data encrypted with it has been written.
+type PendingKey struct {
+	WrappedKey         string `json:"wrapped_key"`
+	RecoveryWrappedKey string `json:"recovery_wrapped_key,omitempty"`
+	RecoveryKEK        string `json:"recovery_kek,omitempty"`
 }
 
 // VaultData contains encrypted vault data.
@@ -32,25 +81,92 @@ type VaultData struct {
 // EncryptedStore implements vault.Vault with encrypted file storage.
 type EncryptedStore struct {
 	mu         sync.RWMutex
-	vaultPath  string
-	metaPath   string
+	backend    Backend
 	crypto     *Crypto
 	meta       *VaultMeta
 	data       *VaultData
 	dirty      bool
 	autoSave   bool
 	unlockTime time.Time
+
+	// clock supplies unlock times and secret timestamps
+	clock clock.Clock
+
+	// aliasReadOnly makes Set on an alias fail instead of writing through
+	aliasReadOnly bool
+
+	// requiredFields maps path prefixes to the fields secrets must have
+	requiredFields map[string][]string
+
+	// normalizePaths enables path normalization for older vaults
+	normalizePaths bool
+
+	// recovered is set while the vault is unlocked with its recovery key
+	// and the password has not been reset
+	recovered bool
+
+	// Soft delete moves deleted secrets into the trash, where they are
+	// kept for trashRetention, or until purged if it is zero
+	trash          bool
+	trashRetention time.Duration
+
+	// rotators generate new values for Rotate, by path
+	rotators map[string]RotatorFunc
+
+	// Access tracking (see SetTrackAccess): when secrets were read since
+	// the data was last written, by path. Reads only hold s.mu for
+	// reading, so accessed has its own lock.
+	trackAccess bool
+	accessMu    sync.Mutex
+	accessed    map[string]time.Time
+
+	// Write-ahead log state (see SetWAL): the number of records after
+	// which it is compacted, zero when disabled, the records logged since
+	// the data was saved, the MAC the next record chains to, and the paths
+	// changed since the last commit
+	walCompact int
+	walRecords int
+	walMAC     string
+	changed    map[string]bool
 }
 
-// NewEncryptedStore creates a new encrypted store.
+// NewEncryptedStore creates a new encrypted store backed by local files.
 func NewEncryptedStore(vaultPath, metaPath string) *EncryptedStore {
+	return NewEncryptedStoreWithBackend(NewFileBackend(vaultPath, metaPath))
+}
+
+// NewEncryptedStoreWithBackend creates a new encrypted store that persists
+// to the given backend.
+func NewEncryptedStoreWithBackend(backend Backend) *EncryptedStore {
 	return &EncryptedStore{
-		vaultPath: vaultPath,
-		metaPath:  metaPath,
-		autoSave:  true,
+		backend:  backend,
+		autoSave: true,
+		clock:    clock.Real,
+	}
+}
+
+// SetDurable controls whether writes are synced to stable storage before
+// they return. Writes are durable by default; turning it off is faster but
+// a power loss may then lose recent writes, so it is only suitable for
+// disposable vaults. It has no effect on backends that do not implement
+// DurableBackend.
+func (s *EncryptedStore) SetDurable(durable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b, ok := s.backend.(DurableBackend); ok {
+		b.SetDurable(durable)
 	}
 }
 
+// SetClock sets the clock used for unlock times and secret timestamps.
+// It is meant for tests; stores use the real clock by default.
+func (s *EncryptedStore) SetClock(c clock.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = c
+}
+
 // Initialize creates a new vault with the given master password.
 func (s *EncryptedStore) Initialize(password string) error {
 	s.mu.Lock()
@@ -74,13 +190,27 @@ func (s *EncryptedStore) Initialize(password string) error {
 		return fmt.Errorf("failed to create verification: %w", err)
 	}
 
+	// Generate the data key that encrypts the secrets
+	if err := crypto.NewDataKey(); err != nil {
+		crypto.Lock()
+		return err
+	}
+	wrapped, err := crypto.WrapDataKey()
+	if err != nil {
+		crypto.Lock()
+		return fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
 	// Create metadata
 	s.meta = &VaultMeta{
-		Version:      1,
-		CreatedAt:    time.Now(),
+		Version:      metaVersionDataKey,
+		CreatedAt:    s.clock.Now(),
 		Salt:         crypto.Salt(),
 		Argon2Params: crypto.Params(),
 		Verification: verification,
+		WrappedKey:   wrapped,
+
+		NormalizePaths: true,
 	}
 
 	// Create empty vault data
@@ -89,23 +219,88 @@ func (s *EncryptedStore) Initialize(password string) error {
 	}
 
 	s.crypto = crypto
-	s.unlockTime = time.Now()
+	s.unlockTime = s.clock.Now()
 
 	// Save to disk
 	if err := s.saveMeta(); err != nil {
 		return fmt.Errorf("failed to save metadata: %w", err)
 	}
 
-	if err := s.saveData(); err != nil {
+	if err := s.saveData(context.Background()); err != nil {
 		return fmt.Errorf("failed to save data: %w", err)
 	}
 
 	return nil
 }
 
-// VaultExists returns true if the vault exists on disk.
+// verifyReencrypted checks that the wrapped data key unwraps to
+// newCrypto's key and that every secret in newSecrets decrypts with it
+// to the plaintext of the same secret in s.data. Callers must hold s.mu.
+func (s *EncryptedStore) verifyReencrypted(newCrypto *Crypto, wrapped string, newSecrets map[string]string) error {
+	if err := newCrypto.verifyWrappedKey(wrapped); err != nil {
+		return err
+	}
+	if len(newSecrets) != len(s.data.Secrets) {
+		return fmt.Errorf("%w: expected %d secrets, got %d", ErrVerificationFailed, len(s.data.Secrets), len(newSecrets))
+	}
+
+	for path, encrypted := range s.data.Secrets {
+		want, err := s.crypto.Decrypt(encrypted)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt secret %s: %w", path, err)
+		}
+		got, err := newCrypto.Decrypt(newSecrets[path])
+		equal := err == nil && subtle.ConstantTimeCompare(got, want) == 1
+		zero(want)
+		zero(got)
+		if !equal {
+			return fmt.Errorf("%w: secret %s", ErrVerificationFailed, path)
+		}
+	}
+	return nil
+}
+
+// VaultInfo is the vault metadata that is safe to reveal without
+// unlocking: everything but the salt itself and the verification blob.
+type VaultInfo struct {
+	Version        int
+	CreatedAt      time.Time
+	SaltLength     int
+	KDF            string
+	Argon2Params   Argon2Params
+	Cipher         string
+	NormalizePaths bool
+	Recovery       bool // the vault has a recovery key
+}
+
+// Info returns the vault's metadata. It reads the metadata from the
+// backend, so it works while the vault is locked.
+func (s *EncryptedStore) Info() (*VaultInfo, error) {
+	data, err := s.backend.ReadMeta()
+	if err != nil {
+		return nil, err
+	}
+
+	var meta VaultMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+
+	return &VaultInfo{
+		Version:        meta.Version,
+		CreatedAt:      meta.CreatedAt,
+		SaltLength:     len(meta.Salt),
+		KDF:            KDFArgon2id,
+		Argon2Params:   meta.Argon2Params,
+		Cipher:         CipherAES256GCM,
+		NormalizePaths: meta.NormalizePaths,
+		Recovery:       meta.RecoveryWrappedKey != "",
+	}, nil
+}
+
+// VaultExists returns true if the vault exists in the backend.
 func (s *EncryptedStore) VaultExists() bool {
-	_, err := os.Stat(s.metaPath)
+	_, err := s.backend.ReadMeta()
 	return err == nil
 }
 
@@ -136,16 +331,58 @@ func (s *EncryptedStore) Unlock(password string) error {
 
 	// Unlock
 	crypto.Unlock(password)
+	if s.meta.WrappedKey != "" {
+		if err := crypto.UnwrapDataKey(s.meta.WrappedKey); err != nil {
+			crypto.Lock()
+			return err
+		}
+	}
 	s.crypto = crypto
-	s.unlockTime = time.Now()
+	s.recovered = false
+	s.unlockTime = s.clock.Now()
 
 	// Load vault data
-	if err := s.loadData(); err != nil {
+	unwrapPending := func(p *PendingKey) ([]byte, error) {
+		return open(crypto.kek, p.WrappedKey)
+	}
+	if err := s.loadData(context.Background(), unwrapPending); err != nil {
 		s.crypto.Lock()
 		s.crypto = nil
 		return fmt.Errorf("failed to load vault data: %w", err)
 	}
 
+	if s.meta.WrappedKey == "" {
+		if err := s.migrateDataKey(); err != nil {
+			s.crypto.Lock()
+			s.crypto = nil
+			s.data = nil
+			return fmt.Errorf("failed to migrate vault: %w", err)
+		}
+	}
+
+	// Expired trash is dropped now and saved with the next write
+	_, _ = s.purgeExpiredTrash()
+
+	return nil
+}
+
+// migrateDataKey upgrades a vault whose secrets are encrypted with the
+// password-derived key by storing that key as its wrapped data key. No
+// secret is re-encrypted; RotateDEK replaces the key with a random one.
+// Callers must hold s.mu.
+func (s *EncryptedStore) migrateDataKey() error {
+	wrapped, err := s.crypto.WrapDataKey()
+	if err != nil {
+		return err
+	}
+
+	s.meta.WrappedKey = wrapped
+	s.meta.Version = metaVersionDataKey
+	if err := s.saveMeta(); err != nil {
+		s.meta.WrappedKey = ""
+		s.meta.Version = metaVersionDirect
+		return err
+	}
 	return nil
 }
 
@@ -159,14 +396,18 @@ func (s *EncryptedStore) Lock() error {
 	}
 
 	// Save any dirty data first
+	if err := s.foldAccess(); err != nil {
+		return fmt.Errorf("failed to save access times: %w", err)
+	}
 	if s.dirty {
-		if err := s.saveData(); err != nil {
+		if err := s.saveData(context.Background()); err != nil {
 			return fmt.Errorf("failed to save data: %w", err)
 		}
 	}
 
 	s.crypto.Lock()
 	s.crypto = nil
+	s.recovered = false
 	s.data = nil
 	s.dirty = false
 
@@ -185,6 +426,18 @@ func (s *EncryptedStore) isLockedUnsafe() bool {
 	return s.crypto == nil || !s.crypto.IsUnlocked()
 }
 
+// VerifyPassword reports whether password is the vault's master password.
+// It does not change the lock state.
+func (s *EncryptedStore) VerifyPassword(password string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.crypto == nil || s.meta == nil {
+		return false
+	}
+	return s.crypto.VerifyPassword(password, s.meta.Verification)
+}
+
 // UnlockTime returns when the vault was unlocked.
 func (s *EncryptedStore) UnlockTime() time.Time {
 	s.mu.RLock()
@@ -192,7 +445,8 @@ func (s *EncryptedStore) UnlockTime() time.Time {
 	return s.unlockTime
 }
 
-// Get retrieves a secret from the vault.
+// Get retrieves a secret from the vault. Aliases are resolved, so the
+// secret they point to is returned.
 func (s *EncryptedStore) Get(ctx context.Context, path string) (*vault.Secret, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -201,6 +455,45 @@ func (s *EncryptedStore) Get(ctx context.Context, path string) (*vault.Secret, e
 		return nil, errors.New("vault is locked")
 	}
 
+	return s.get(ctx, path)
+}
+
+// get implements Get. Callers must hold s.mu, at least for reading.
+func (s *EncryptedStore) get(ctx context.Context, path string) (*vault.Secret, error) {
+	resolved, err := s.resolvePath(s.cleanPath(path))
+	if err != nil {
+		return nil, err
+	}
+	secret, err := s.decrypt(resolved)
+	if err != nil {
+		return nil, err
+	}
+	s.recordAccess(ctx, resolved, secret)
+	return secret, nil
+}
+
+// encrypt serializes and encrypts a secret and stores it at path.
+// Callers must hold s.mu.
+func (s *EncryptedStore) encrypt(path string, secret *vault.Secret) error {
+	data, err := json.Marshal(secret)
+	if err != nil {
+		return fmt.Errorf("failed to marshal secret: %w", err)
+	}
+
+	encrypted, err := s.crypto.EncryptString(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+
+	s.data.Secrets[path] = encrypted
+	s.markChanged(path)
+	s.dirty = true
+	return nil
+}
+
+// decrypt decrypts the secret stored at path without resolving aliases.
+// Callers must hold s.mu.
+func (s *EncryptedStore) decrypt(path string) (*vault.Secret, error) {
 	encrypted, ok := s.data.Secrets[path]
 	if !ok {
 		return nil, vault.ErrSecretNotFound
@@ -219,7 +512,10 @@ func (s *EncryptedStore) Get(ctx context.Context, path string) (*vault.Secret, e
 	return &secret, nil
 }
 
-// Set stores a secret in the vault.
+// Set stores a secret in the vault. If path is an alias, the secret is
+// written to the alias target, unless write-through has been disabled with
+// SetAliasWriteThrough. A secret that is itself an alias (see AliasKey)
+// replaces whatever is stored at path.
 func (s *EncryptedStore) Set(ctx context.Context, path string, secret *vault.Secret) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -228,37 +524,160 @@ func (s *EncryptedStore) Set(ctx context.Context, path string, secret *vault.Sec
 		return errors.New("vault is locked")
 	}
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := s.set(path, secret); err != nil {
+		return err
+	}
+
+	if s.autoSave {
+		return s.commit(ctx)
+	}
+
+	return nil
+}
+
+// set implements Set without saving. Callers must hold s.mu.
+func (s *EncryptedStore) set(path string, secret *vault.Secret) error {
+	path = s.cleanPath(path)
+	if target := aliasOf(secret); target != "" {
+		target = s.cleanPath(target)
+		secret.Metadata.Extra[AliasKey] = target
+		if err := s.checkAlias(path, target); err != nil {
+			return err
+		}
+	} else {
+		resolved, err := s.resolvePath(path)
+		if err != nil {
+			return err
+		}
+		if resolved != path && s.aliasReadOnly {
+			return ErrAliasWrite
+		}
+		path = resolved
+
+		if err := s.validate(path, secret); err != nil {
+			return err
+		}
+	}
+
 	// Set metadata timestamps
-	now := vault.Now()
+	now := vault.NewTimestamp(s.clock.Now())
 	if secret.Metadata.CreatedAt == nil {
 		secret.Metadata.CreatedAt = now
 	}
 	secret.Metadata.ModifiedAt = now
 
-	// Serialize secret
-	data, err := json.Marshal(secret)
-	if err != nil {
-		return fmt.Errorf("failed to marshal secret: %w", err)
+	return s.encrypt(path, secret)
+}
+
+// UpdateMetadata applies fn to the metadata of the secret at path, leaving
+// its value and fields untouched. Aliases are resolved, so the target
+// secret is updated.
+func (s *EncryptedStore) UpdateMetadata(ctx context.Context, path string, fn func(*vault.Metadata)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isLockedUnsafe() {
+		return errors.New("vault is locked")
 	}
 
-	// Encrypt
-	encrypted, err := s.crypto.EncryptString(string(data))
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	resolved, err := s.resolvePath(s.cleanPath(path))
 	if err != nil {
-		return fmt.Errorf("failed to encrypt secret: %w", err)
+		return err
+	}
+	secret, err := s.decrypt(resolved)
+	if err != nil {
+		return err
 	}
 
-	s.data.Secrets[path] = encrypted
-	s.dirty = true
+	fn(&secret.Metadata)
+	secret.Metadata.ModifiedAt = vault.NewTimestamp(s.clock.Now())
+
+	if err := s.encrypt(resolved, secret); err != nil {
+		return err
+	}
 
 	if s.autoSave {
-		return s.saveData()
+		return s.commit(ctx)
 	}
 
 	return nil
 }
 
-// Delete removes a secret from the vault.
+// UpdateMetadataPrefix applies fn to the metadata of every secret whose
+// path starts with prefix, under a single lock, and returns the number
+// updated. Aliases are skipped, as are secrets in the trash unless prefix
+// is in it. An empty prefix updates all secrets.
+func (s *EncryptedStore) UpdateMetadataPrefix(ctx context.Context, prefix string, fn func(*vault.Metadata)) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isLockedUnsafe() {
+		return 0, errors.New("vault is locked")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if s.normalizing() {
+		prefix = normalizePrefix(prefix)
+	}
+
+	now := vault.NewTimestamp(s.clock.Now())
+	updated := 0
+	for path := range s.data.Secrets {
+		if !strings.HasPrefix(path, prefix) || (inTrash(path) && !inTrash(prefix)) {
+			continue
+		}
+		secret, err := s.decrypt(path)
+		if err != nil {
+			return updated, err
+		}
+		if aliasOf(secret) != "" {
+			continue
+		}
+
+		fn(&secret.Metadata)
+		secret.Metadata.ModifiedAt = now
+		if err := s.encrypt(path, secret); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	if updated == 0 {
+		return 0, nil
+	}
+
+	if s.autoSave {
+		return updated, s.commit(ctx)
+	}
+
+	return updated, nil
+}
+
+// Delete removes a secret from the vault. With soft delete enabled (see
+// SetTrash) the secret is moved into the trash instead, unless it already
+// is in the trash.
 func (s *EncryptedStore) Delete(ctx context.Context, path string) error {
+	return s.remove(ctx, path, false)
+}
+
+// DeletePermanently removes a secret from the vault, bypassing the trash.
+func (s *EncryptedStore) DeletePermanently(ctx context.Context, path string) error {
+	return s.remove(ctx, path, true)
+}
+
+// remove deletes or, with soft delete enabled and unless permanent is
+// set, trashes the secret at path.
+func (s *EncryptedStore) remove(ctx context.Context, path string, permanent bool) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -266,11 +685,311 @@ func (s *EncryptedStore) Delete(ctx context.Context, path string) error {
 		return errors.New("vault is locked")
 	}
 
-	delete(s.data.Secrets, path)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := s.removeSecret(path, permanent); err != nil {
+		return err
+	}
+
+	if s.autoSave {
+		return s.commit(ctx)
+	}
+
+	return nil
+}
+
+// removeSecret implements remove without saving. Callers must hold s.mu.
+func (s *EncryptedStore) removeSecret(path string, permanent bool) error {
+	path = s.cleanPath(path)
+	if s.trash && !permanent && !inTrash(path) {
+		if err := s.trashSecret(path); err != nil {
+			return err
+		}
+		if _, err := s.purgeExpiredTrash(); err != nil {
+			return err
+		}
+	} else {
+		delete(s.data.Secrets, path)
+		s.markChanged(path)
+	}
+	s.dirty = true
+	return nil
+}
+
+// DeletePrefix removes every secret whose path starts with prefix, under a
+// single lock, and returns the number removed. An empty prefix removes all
+// secrets. With soft delete enabled the secrets are moved into the trash
+// instead, and the trash itself is left alone unless prefix is in it.
+func (s *EncryptedStore) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	return s.deletePrefix(ctx, prefix, false)
+}
+
+// DeletePrefixPermanently removes every secret whose path starts with
+// prefix, like DeletePrefix, bypassing the trash.
+func (s *EncryptedStore) DeletePrefixPermanently(ctx context.Context, prefix string) (int, error) {
+	return s.deletePrefix(ctx, prefix, true)
+}
+
+// deletePrefix deletes or trashes the secrets under prefix.
+func (s *EncryptedStore) deletePrefix(ctx context.Context, prefix string, permanent bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isLockedUnsafe() {
+		return 0, errors.New("vault is locked")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if s.normalizing() {
+		prefix = normalizePrefix(prefix)
+	}
+
+	soft := s.trash && !permanent && !inTrash(prefix)
+	deleted := 0
+	for path := range s.data.Secrets {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		if !soft {
+			delete(s.data.Secrets, path)
+			s.markChanged(path)
+			deleted++
+		} else if !inTrash(path) {
+			if err := s.trashSecret(path); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+	}
+	if deleted == 0 {
+		return 0, nil
+	}
+	if soft {
+		if _, err := s.purgeExpiredTrash(); err != nil {
+			return deleted, err
+		}
+	}
+	s.dirty = true
+
+	if s.autoSave {
+		return deleted, s.commit(ctx)
+	}
+
+	return deleted, nil
+}
+
+// MovePrefix renames every secret whose path starts with fromPrefix so it
+// starts with toPrefix instead, under a single lock, and returns the
+// renamed paths, old to new. Secrets keep their metadata, and aliases
+// pointing into the subtree are updated. If a destination path already
+// exists nothing is changed and an error wrapping vault.ErrAlreadyExists
+// lists the collisions, unless force is set, in which case they are
+// overwritten.
+func (s *EncryptedStore) MovePrefix(ctx context.Context, fromPrefix, toPrefix string, force bool) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isLockedUnsafe() {
+		return nil, errors.New("vault is locked")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if s.normalizing() {
+		fromPrefix = normalizePrefix(fromPrefix)
+		toPrefix = normalizePrefix(toPrefix)
+	}
+	if fromPrefix == "" {
+		return nil, errors.New("source prefix is required")
+	}
+	if fromPrefix == toPrefix {
+		return nil, errors.New("source and destination are the same")
+	}
+
+	renamed := make(map[string]string)
+	for path := range s.data.Secrets {
+		if rest, ok := strings.CutPrefix(path, fromPrefix); ok {
+			renamed[path] = toPrefix + rest
+		}
+	}
+	if len(renamed) == 0 {
+		return renamed, nil
+	}
+
+	// Aliases into the subtree must follow it
+	retarget := func(target string) (string, bool) {
+		rest, ok := strings.CutPrefix(target, fromPrefix)
+		return toPrefix + rest, ok
+	}
+	if err := s.rename(ctx, renamed, retarget, force); err != nil {
+		return nil, err
+	}
+	return renamed, nil
+}
+
+// Move renames the secret at from to to, under a single lock. Unlike
+// Copy, the secret keeps everything, including its creation time and
+// version history, and aliases pointing at it are updated. An alias at
+// from is moved itself, not its target. If to already exists an error
+// wrapping vault.ErrAlreadyExists is returned, unless force is set, in
+// which case it is overwritten.
+func (s *EncryptedStore) Move(ctx context.Context, from, to string, force bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isLockedUnsafe() {
+		return errors.New("vault is locked")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	from, to = s.cleanPath(from), s.cleanPath(to)
+	if to == "" {
+		return errors.New("destination is required")
+	}
+	if from == to {
+		return errors.New("source and destination are the same")
+	}
+	if _, exists := s.data.Secrets[from]; !exists {
+		return vault.ErrSecretNotFound
+	}
+
+	retarget := func(target string) (string, bool) {
+		return to, target == from
+	}
+	return s.rename(ctx, map[string]string{from: to}, retarget, force)
+}
+
+// rename moves the secrets in renamed, old path to new, and points
+// aliases whose target retarget maps elsewhere at the new target. Existing
+// destinations are refused unless force is set. Callers must hold s.mu.
+func (s *EncryptedStore) rename(ctx context.Context, renamed map[string]string, retarget func(target string) (string, bool), force bool) error {
+	if !force {
+		var conflicts []string
+		for _, dest := range renamed {
+			_, exists := s.data.Secrets[dest]
+			_, moving := renamed[dest]
+			if exists && !moving {
+				conflicts = append(conflicts, dest)
+			}
+		}
+		if len(conflicts) > 0 {
+			sort.Strings(conflicts)
+			return fmt.Errorf("%w: %s", vault.ErrAlreadyExists, strings.Join(conflicts, ", "))
+		}
+	}
+
+	moved := make(map[string]string, len(renamed))
+	for old := range renamed {
+		moved[old] = s.data.Secrets[old]
+		delete(s.data.Secrets, old)
+		s.markChanged(old)
+	}
+	for old, dest := range renamed {
+		s.data.Secrets[dest] = moved[old]
+		s.markChanged(dest)
+	}
+
+	for path := range s.data.Secrets {
+		secret, err := s.decrypt(path)
+		if err != nil {
+			return err
+		}
+		alias := aliasOf(secret)
+		if alias == "" {
+			continue
+		}
+		target, ok := retarget(alias)
+		if !ok {
+			continue
+		}
+		secret.Metadata.Extra[AliasKey] = target
+		if err := s.encrypt(path, secret); err != nil {
+			return err
+		}
+	}
+
 	s.dirty = true
+	if s.autoSave {
+		return s.commit(ctx)
+	}
+
+	return nil
+}
+
+// Copy stores a copy of the secret at from at to, under a single lock. The
+// copy has the source's value, fields, notes, tags, labels, expiry,
+// protection and template, and its own creation time and no version
+// history. An alias at from is resolved, so its target is copied. If to
+// already exists an error wrapping vault.ErrAlreadyExists is returned,
+// unless force is set, in which case it is overwritten.
+func (s *EncryptedStore) Copy(ctx context.Context, from, to string, force bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isLockedUnsafe() {
+		return errors.New("vault is locked")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	from, to = s.cleanPath(from), s.cleanPath(to)
+	if to == "" {
+		return errors.New("destination is required")
+	}
+	resolved, err := s.resolvePath(from)
+	if err != nil {
+		return err
+	}
+	if resolved == to || from == to {
+		return errors.New("source and destination are the same")
+	}
+	source, err := s.decrypt(resolved)
+	if err != nil {
+		return err
+	}
+	if _, exists := s.data.Secrets[to]; exists && !force {
+		return fmt.Errorf("%w: %s", vault.ErrAlreadyExists, to)
+	}
+
+	now := vault.NewTimestamp(s.clock.Now())
+	copied := &vault.Secret{
+		Value:      source.Value,
+		ValueBytes: source.ValueBytes,
+		Fields:     source.Fields,
+		Notes:      source.Notes,
+		Metadata: vault.Metadata{
+			CreatedAt:       now,
+			ModifiedAt:      now,
+			ExpiresAt:       source.Metadata.ExpiresAt,
+			Tags:            source.Metadata.Tags,
+			Labels:          source.Metadata.Labels,
+			Protected:       source.Metadata.Protected,
+			ProtectedFields: source.Metadata.ProtectedFields,
+		},
+	}
+	copied.SetTemplate(source.Template())
+
+	if err := s.validate(to, copied); err != nil {
+		return err
+	}
+	if err := s.encrypt(to, copied); err != nil {
+		return err
+	}
 
 	if s.autoSave {
-		return s.saveData()
+		return s.commit(ctx)
 	}
 
 	return nil
@@ -285,11 +1004,12 @@ func (s *EncryptedStore) Exists(ctx context.Context, path string) (bool, error)
 		return false, errors.New("vault is locked")
 	}
 
-	_, ok := s.data.Secrets[path]
+	_, ok := s.data.Secrets[s.cleanPath(path)]
 	return ok, nil
 }
 
-// List returns all secret paths matching the given prefix.
+// List returns all secret paths matching the given prefix. Trashed
+// secrets are only listed when the prefix is in the trash.
 func (s *EncryptedStore) List(ctx context.Context, prefix string) ([]string, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -298,9 +1018,13 @@ func (s *EncryptedStore) List(ctx context.Context, prefix string) ([]string, err
 		return nil, errors.New("vault is locked")
 	}
 
+	if s.normalizing() {
+		prefix = normalizePrefix(prefix)
+	}
+
 	var paths []string
 	for path := range s.data.Secrets {
-		if prefix == "" || strings.HasPrefix(path, prefix) {
+		if listed(path, prefix) {
 			paths = append(paths, path)
 		}
 	}
@@ -309,6 +1033,121 @@ func (s *EncryptedStore) List(ctx context.Context, prefix string) ([]string, err
 	return paths, nil
 }
 
+// ListInfo returns the metadata of the secrets whose path starts with
+// prefix, sorted by path. Aliases are described by the secret they
+// resolve to, with Metadata.Extra[AliasKey] naming their target, and are
+// left out when they can't be resolved. Like List, it leaves out trashed
+// secrets. All secrets are read under a single read lock.
+func (s *EncryptedStore) ListInfo(ctx context.Context, prefix string) ([]vault.SecretInfo, error) {
+	return s.ListInfoPage(ctx, prefix, "", 0)
+}
+
+// ListInfoPage returns the metadata of up to limit secrets whose path
+// starts with prefix and sorts after the path after, like ListInfo. Only
+// the secrets returned are decrypted, so a list can be read a page at a
+// time without holding all of it. Dangling aliases are left out without
+// counting toward limit, so a short page is always the last one. A limit
+// of zero returns all of them.
+func (s *EncryptedStore) ListInfoPage(ctx context.Context, prefix, after string, limit int) ([]vault.SecretInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.isLockedUnsafe() {
+		return nil, errors.New("vault is locked")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if s.normalizing() {
+		prefix = normalizePrefix(prefix)
+	}
+
+	var paths []string
+	for path := range s.data.Secrets {
+		if listed(path, prefix) && path > after {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	size := len(paths)
+	if limit > 0 && size > limit {
+		size = limit
+	}
+	infos := make([]vault.SecretInfo, 0, size)
+	for _, path := range paths {
+		if len(infos) == size {
+			break
+		}
+		secret, err := s.decrypt(path)
+		if err != nil {
+			return nil, err
+		}
+
+		resolved := path
+		target := aliasOf(secret)
+		if target != "" {
+			resolved, err = s.resolvePath(path)
+			if err == nil {
+				secret, err = s.decrypt(resolved)
+			}
+			if errors.Is(err, vault.ErrSecretNotFound) || errors.Is(err, ErrAliasLoop) {
+				continue // Get fails on dangling and looping aliases
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		info := vault.InfoOf(path, secret)
+		if t, ok := s.pendingAccess(resolved); ok {
+			info.Metadata.LastAccessedAt = vault.NewTimestamp(t)
+		}
+		if target != "" {
+			if info.Metadata.Extra == nil {
+				info.Metadata.Extra = make(map[string]any, 1)
+			}
+			info.Metadata.Extra[AliasKey] = target
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// Snapshot returns all decrypted secrets keyed by path. The secrets are read
+// under a single read lock, so the result is a consistent point-in-time view
+// that no concurrent write can partially update.
+func (s *EncryptedStore) Snapshot(ctx context.Context) (map[string]*vault.Secret, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.isLockedUnsafe() {
+		return nil, errors.New("vault is locked")
+	}
+
+	secrets := make(map[string]*vault.Secret, len(s.data.Secrets))
+	for path, encrypted := range s.data.Secrets {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		decrypted, err := s.crypto.DecryptString(encrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt secret %s: %w", path, err)
+		}
+
+		var secret vault.Secret
+		if err := json.Unmarshal([]byte(decrypted), &secret); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal secret %s: %w", path, err)
+		}
+		secrets[path] = &secret
+	}
+
+	return secrets, nil
+}
+
 // Name returns the provider name.
 func (s *EncryptedStore) Name() string {
 	return "encrypted"
@@ -323,6 +1162,7 @@ func (s *EncryptedStore) Capabilities() vault.Capabilities {
 		List:       true,
 		Binary:     true,
 		MultiField: true,
+		Rotation:   true,
 	}
 }
 
@@ -342,24 +1182,19 @@ func (s *EncryptedStore) SecretCount() int {
 	return len(s.data.Secrets)
 }
 
-// saveMeta saves the vault metadata to disk.
+// saveMeta saves the vault metadata to the backend.
 func (s *EncryptedStore) saveMeta() error {
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(s.metaPath), 0700); err != nil {
-		return err
-	}
-
 	data, err := json.MarshalIndent(s.meta, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(s.metaPath, data, 0600)
+	return s.backend.WriteMeta(data)
 }
 
-// loadMeta loads the vault metadata from disk.
+// loadMeta loads the vault metadata from the backend.
 func (s *EncryptedStore) loadMeta() error {
-	data, err := os.ReadFile(s.metaPath)
+	data, err := s.backend.ReadMeta()
 	if err != nil {
 		return err
 	}
@@ -373,19 +1208,30 @@ func (s *EncryptedStore) loadMeta() error {
 	return nil
 }
 
-// saveData saves the encrypted vault data to disk.
-func (s *EncryptedStore) saveData() error {
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(s.vaultPath), 0700); err != nil {
+// saveData saves the encrypted vault data to the backend.
+// A cancelled context aborts the save before anything is written.
+func (s *EncryptedStore) saveData(ctx context.Context) error {
+	mac, err := s.writeData(ctx)
+	if err != nil {
 		return err
 	}
 
-	data, err := json.Marshal(s.data)
-	if err != nil {
-		return err
+	// Vaults that kept the MAC in the metadata are upgraded once
+	if !s.meta.DataMACAppended || s.meta.DataMAC != "" {
+		meta := *s.meta
+		meta.DataMACAppended = true
+		meta.DataMAC = ""
+
+		old := s.meta
+		s.meta = &meta
+		if err := s.saveMeta(); err != nil {
+			s.meta = old
+			return err
+		}
 	}
 
-	if err := os.WriteFile(s.vaultPath, data, 0600); err != nil {
+	// The log's records are now part of the data
+	if err := s.truncateWAL(mac); err != nil {
 		return err
 	}
 
@@ -393,19 +1239,91 @@ func (s *EncryptedStore) saveData() error {
 	return nil
 }
 
-// loadData loads the encrypted vault data from disk.
-func (s *EncryptedStore) loadData() error {
-	data, err := os.ReadFile(s.vaultPath)
+// writeData writes the encrypted vault data to the backend with its MAC
+// appended, so both are replaced in one atomic write, and returns the MAC.
+// Callers must hold s.mu.
+func (s *EncryptedStore) writeData(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(s.data)
+	if err != nil {
+		return "", err
+	}
+
+	mac, err := s.crypto.MAC(data)
+	if err != nil {
+		return "", err
+	}
+
+	// Last chance to abort
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	// Compact JSON has no newlines, so the last line is the MAC
+	data = append(data, '\n')
+	data = append(data, mac...)
+	if err := s.backend.WriteData(data); err != nil {
+		return "", err
+	}
+	return mac, nil
+}
+
+// splitDataMAC splits a vault data file into the data and its appended
+// MAC, which is empty for files written before it was appended.
+func splitDataMAC(raw []byte) ([]byte, string) {
+	i := bytes.LastIndexByte(raw, '\n')
+	if i < 0 {
+		return raw, ""
+	}
+	return raw[:i], string(raw[i+1:])
+}
+
+// loadData loads the encrypted vault data from the backend.
+// unwrapPending unwraps the metadata's pending data key, if it has one,
+// with the key the vault was unlocked with.
+func (s *EncryptedStore) loadData(ctx context.Context, unwrapPending func(*PendingKey) ([]byte, error)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	raw, err := s.backend.ReadData()
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, fs.ErrNotExist) {
+			if s.meta.DataMAC != "" || s.meta.DataMACAppended {
+				// Data was written before, so it has been removed
+				return ErrTampered
+			}
 			// New vault, no data yet
 			s.data = &VaultData{
 				Secrets: make(map[string]string),
 			}
-			return nil
+			return s.recoverWAL(ctx, "")
+		}
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, mac := splitDataMAC(raw)
+	if mac == "" {
+		if s.meta.DataMACAppended {
+			return ErrTampered
 		}
+		// Older vaults keep the MAC in the metadata, and those written
+		// before integrity MACs were introduced have none
+		mac = s.meta.DataMAC
+	}
+	if err := s.settleDataKey(data, mac, unwrapPending); err != nil {
 		return err
 	}
+	if mac != "" && !s.crypto.VerifyMAC(data, mac) {
+		return ErrTampered
+	}
 
 	var vaultData VaultData
 	if err := json.Unmarshal(data, &vaultData); err != nil {
@@ -417,19 +1335,91 @@ func (s *EncryptedStore) loadData() error {
 	}
 
 	s.data = &vaultData
+	return s.recoverWAL(ctx, mac)
+}
+
+// settleDataKey finishes a RotateDEK that stopped after recording the new
+// data key as pending. The data MAC shows which key the data was last
+// written with: if it is the new one, that key replaces the current one,
+// and otherwise the pending key is dropped. Callers must hold s.mu.
+func (s *EncryptedStore) settleDataKey(data []byte, mac string, unwrapPending func(*PendingKey) ([]byte, error)) error {
+	pending := s.meta.PendingKey
+	if pending == nil {
+		return nil
+	}
+
+	meta := *s.meta
+	meta.PendingKey = nil
+	if !s.crypto.VerifyMAC(data, mac) {
+		key, err := unwrapPending(pending)
+		if err != nil {
+			return ErrTampered
+		}
+		crypto := s.crypto.withDataKey(s.crypto)
+		zero(crypto.key)
+		crypto.key = key
+		if !crypto.VerifyMAC(data, mac) {
+			crypto.Lock()
+			return ErrTampered
+		}
+
+		meta.WrappedKey = pending.WrappedKey
+		meta.RecoveryWrappedKey = pending.RecoveryWrappedKey
+		meta.RecoveryKEK = pending.RecoveryKEK
+		meta.Version = metaVersionDataKey
+		s.crypto.Lock()
+		s.crypto = crypto
+	}
+
+	old := s.meta
+	s.meta = &meta
+	if err := s.saveMeta(); err != nil {
+		s.meta = old
+		return fmt.Errorf("failed to save metadata: %w", err)
+	}
 	return nil
 }
 
-// ChangePassword changes the master password.
-func (s *EncryptedStore) ChangePassword(oldPassword, newPassword string) error {
+// recoverWAL replays the write-ahead log left by a process that stopped
+// before folding it into the vault data, whose MAC is mac, and folds it
+// in. Callers must hold s.mu.
+func (s *EncryptedStore) recoverWAL(ctx context.Context, mac string) error {
+	lines, err := s.replayWAL(mac)
+	if err != nil || lines == 0 {
+		return err
+	}
+
+	s.walRecords = lines
+	s.dirty = true
+	return s.saveData(ctx)
+}
+
+// ChangePassword changes the master password. Only the wrapped data key
+// in the metadata is rewritten; the secrets stay encrypted with the same
+// data key, so the recovery key stays valid. Use RotateDEK to also replace
+// the data key, e.g. after the old password was exposed. A cancelled
+// context returns ctx.Err() and leaves the vault unchanged, as does
+// ErrVerificationFailed if the new metadata does not open with the new
+// password.
+func (s *EncryptedStore) ChangePassword(ctx context.Context, oldPassword, newPassword string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.isLockedUnsafe() {
+		return errors.New("vault is locked")
+	}
+
 	// Verify old password
 	if !s.crypto.VerifyPassword(oldPassword, s.meta.Verification) {
 		return errors.New("invalid current password")
 	}
 
+	return s.replacePassword(ctx, newPassword)
+}
+
+// replacePassword re-wraps the data key with a new password.
+// Callers must hold s.mu.
+func (s *EncryptedStore) replacePassword(ctx context.Context, newPassword string) error {
 	// Create new crypto with new salt
 	newCrypto, err := NewCrypto(nil, DefaultArgon2Params())
 	if err != nil {
@@ -437,6 +1427,7 @@ func (s *EncryptedStore) ChangePassword(oldPassword, newPassword string) error {
 	}
 
 	newCrypto.Unlock(newPassword)
+	newCrypto = newCrypto.withDataKey(s.crypto)
 
 	// Create new verification blob
 	verification, err := newCrypto.CreateVerificationBlob()
@@ -445,9 +1436,86 @@ func (s *EncryptedStore) ChangePassword(oldPassword, newPassword string) error {
 		return fmt.Errorf("failed to create verification: %w", err)
 	}
 
-	// Re-encrypt all secrets with new key
+	// Re-wrap the data key with the new password
+	wrapped, err := newCrypto.WrapDataKey()
+	if err != nil {
+		newCrypto.Lock()
+		return fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	// Make sure the new password opens the new metadata before saving it
+	if err := newCrypto.verifyBlob(verification); err != nil {
+		newCrypto.Lock()
+		return err
+	}
+	if err := newCrypto.verifyWrappedKey(wrapped); err != nil {
+		newCrypto.Lock()
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		newCrypto.Lock()
+		return err
+	}
+
+	// Update metadata
+	meta := *s.meta
+	meta.Salt = newCrypto.Salt()
+	meta.Argon2Params = newCrypto.Params()
+	meta.Verification = verification
+	meta.WrappedKey = wrapped
+
+	old := s.meta
+	s.meta = &meta
+	if err := s.saveMeta(); err != nil {
+		s.meta = old
+		newCrypto.Lock()
+		return fmt.Errorf("failed to save metadata: %w", err)
+	}
+
+	// Replace crypto
+	s.crypto.Lock()
+	s.crypto = newCrypto
+	s.recovered = false
+
+	return nil
+}
+
+// RotateDEK replaces the data encryption key with a new random key,
+// re-encrypting every secret. The password is unchanged. A cancelled
+// context aborts the re-encryption between secrets and returns ctx.Err(),
+// leaving the vault unchanged. Every re-encrypted secret is then decrypted
+// with the new key and compared with the original; on any mismatch
+// RotateDEK returns ErrVerificationFailed and keeps the old key. Once
+// verified the result is always saved, since a partial save would corrupt
+// the vault.
+//
+// The new key is recorded in the metadata as pending before the data is
+// written with it, and replaces the old key once the data is written. If
+// the process stops in between, the next unlock uses whichever key the
+// data was written with. If writing the data fails, the old key is kept.
+func (s *EncryptedStore) RotateDEK(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isLockedUnsafe() {
+		return errors.New("vault is locked")
+	}
+
+	newCrypto := s.crypto.withDataKey(s.crypto)
+	if err := newCrypto.NewDataKey(); err != nil {
+		newCrypto.Lock()
+		return err
+	}
+
+	// Re-encrypt all secrets with the new key
 	newSecrets := make(map[string]string)
 	for path, encrypted := range s.data.Secrets {
+		if err := ctx.Err(); err != nil {
+			newCrypto.Lock()
+			return err
+		}
+
 		// Decrypt with old key
 		decrypted, err := s.crypto.DecryptString(encrypted)
 		if err != nil {
@@ -465,26 +1533,67 @@ func (s *EncryptedStore) ChangePassword(oldPassword, newPassword string) error {
 		newSecrets[path] = reEncrypted
 	}
 
-	// Update metadata
-	s.meta.Salt = newCrypto.Salt()
-	s.meta.Argon2Params = newCrypto.Params()
-	s.meta.Verification = verification
-
-	// Update data
-	s.data.Secrets = newSecrets
+	wrapped, err := newCrypto.WrapDataKey()
+	if err != nil {
+		newCrypto.Lock()
+		return fmt.Errorf("failed to wrap data key: %w", err)
+	}
 
-	// Replace crypto
-	s.crypto.Lock()
-	s.crypto = newCrypto
+	// Make sure everything decrypts with the new key before replacing the
+	// old one, since a bad re-encryption would lose every secret
+	if err := s.verifyReencrypted(newCrypto, wrapped, newSecrets); err != nil {
+		newCrypto.Lock()
+		return err
+	}
 
-	// Save to disk
+	rotated := *s.meta
+	if err := rewrapRecovery(&rotated, s.crypto, newCrypto); err != nil {
+		newCrypto.Lock()
+		return err
+	}
+	rotated.WrappedKey = wrapped
+	rotated.Version = metaVersionDataKey
+
+	// Record the new key before any data is written with it
+	oldMeta, oldData, oldCrypto := s.meta, s.data, s.crypto
+	pending := *s.meta
+	pending.PendingKey = &PendingKey{
+		WrappedKey:         rotated.WrappedKey,
+		RecoveryWrappedKey: rotated.RecoveryWrappedKey,
+		RecoveryKEK:        rotated.RecoveryKEK,
+	}
+	s.meta = &pending
 	if err := s.saveMeta(); err != nil {
+		s.meta = oldMeta
+		newCrypto.Lock()
 		return fmt.Errorf("failed to save metadata: %w", err)
 	}
 
-	if err := s.saveData(); err != nil {
+	s.data = &VaultData{Secrets: newSecrets}
+	s.crypto = newCrypto
+	mac, err := s.writeData(context.WithoutCancel(ctx))
+	if err != nil {
+		// The pending key is dropped on the next unlock or metadata save
+		s.meta, s.data, s.crypto = oldMeta, oldData, oldCrypto
+		newCrypto.Lock()
 		return fmt.Errorf("failed to save data: %w", err)
 	}
+	oldCrypto.Lock()
+
+	// The data is now encrypted with the new key, so it is kept even if
+	// the metadata can't be saved; the next unlock then finishes the
+	// rotation
+	rotated.DataMACAppended = true
+	rotated.DataMAC = ""
+	s.meta = &rotated
+	if err := s.saveMeta(); err != nil {
+		return fmt.Errorf("failed to save metadata: %w", err)
+	}
 
+	// The log's records are now part of the data
+	if err := s.truncateWAL(mac); err != nil {
+		return err
+	}
+	s.dirty = false
 	return nil
 }
@@ -0,0 +1,2180 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omnivault/internal/clock"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// newTestStore creates an initialized, unlocked store on an in-memory backend.
+func newTestStore(t *testing.T) (*EncryptedStore, *MemBackend) {
+	t.Helper()
+
+	backend := NewMemBackend()
+	s := NewEncryptedStoreWithBackend(backend)
+	if err := s.Initialize("password123"); err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
+	return s, backend
+}
+
+func TestEncryptedStoreSetGet(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "api/key", &vault.Secret{Value: "secret123"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+
+	secret, err := s.Get(ctx, "api/key")
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+
+	if secret.Value != "secret123" {
+		t.Errorf("Expected value 'secret123', got '%s'", secret.Value)
+	}
+
+	if _, err := s.Get(ctx, "missing"); err != vault.ErrSecretNotFound {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+}
+
+func TestEncryptedStorePersistence(t *testing.T) {
+	s, backend := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "db/password", &vault.Secret{Value: "hunter2"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	if err := s.Lock(); err != nil {
+		t.Fatalf("Failed to lock store: %v", err)
+	}
+
+	// A new store on the same backend sees the persisted vault
+	reopened := NewEncryptedStoreWithBackend(backend)
+	if !reopened.VaultExists() {
+		t.Fatal("Expected vault to exist in backend")
+	}
+
+	if err := reopened.Unlock("wrongpassword"); err == nil {
+		t.Error("Expected error unlocking with wrong password")
+	}
+
+	if err := reopened.Unlock("password123"); err != nil {
+		t.Fatalf("Failed to unlock store: %v", err)
+	}
+
+	secret, err := reopened.Get(ctx, "db/password")
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+
+	if secret.Value != "hunter2" {
+		t.Errorf("Expected value 'hunter2', got '%s'", secret.Value)
+	}
+}
+
+func TestEncryptedStoreNotes(t *testing.T) {
+	s, backend := newTestStore(t)
+	ctx := context.Background()
+
+	notes := "rotate-me-quarterly"
+	if err := s.Set(ctx, "db/password", &vault.Secret{Value: "hunter2", Notes: notes}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+
+	data, err := backend.ReadData()
+	if err != nil {
+		t.Fatalf("Failed to read vault data: %v", err)
+	}
+	if strings.Contains(string(data), notes) {
+		t.Error("Expected notes to be encrypted at rest")
+	}
+
+	if err := s.Lock(); err != nil {
+		t.Fatalf("Failed to lock store: %v", err)
+	}
+	if err := s.Unlock("password123"); err != nil {
+		t.Fatalf("Failed to unlock store: %v", err)
+	}
+
+	secret, err := s.Get(ctx, "db/password")
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if secret.Notes != notes {
+		t.Errorf("Expected notes %q, got %q", notes, secret.Notes)
+	}
+}
+
+func TestEncryptedStoreLocked(t *testing.T) {
+	s := NewEncryptedStoreWithBackend(NewMemBackend())
+
+	if s.VaultExists() {
+		t.Error("Expected empty backend to have no vault")
+	}
+
+	if !s.IsLocked() {
+		t.Error("Expected new store to be locked")
+	}
+
+	if _, err := s.Get(context.Background(), "any"); err == nil {
+		t.Error("Expected error reading from locked store")
+	}
+}
+
+func TestEncryptedStoreTamperDetection(t *testing.T) {
+	s, backend := newTestStore(t)
+	ctx := context.Background()
+
+	_ = s.Set(ctx, "a", &vault.Secret{Value: "1"})
+	_ = s.Set(ctx, "b", &vault.Secret{Value: "2"})
+	if err := s.Lock(); err != nil {
+		t.Fatalf("Failed to lock store: %v", err)
+	}
+
+	// Remove one entry from the stored data file, keeping its MAC
+	raw, _ := backend.ReadData()
+	body, mac := splitDataMAC(raw)
+	var data VaultData
+	if err := json.Unmarshal(body, &data); err != nil {
+		t.Fatalf("Failed to parse vault data: %v", err)
+	}
+	delete(data.Secrets, "b")
+	body, _ = json.Marshal(data)
+	_ = backend.WriteData([]byte(string(body) + "\n" + mac))
+
+	err := s.Unlock("password123")
+	if !errors.Is(err, ErrTampered) {
+		t.Errorf("Expected ErrTampered, got %v", err)
+	}
+
+	// Stripping the MAC is detected too
+	_ = backend.WriteData(body)
+	if err := s.Unlock("password123"); !errors.Is(err, ErrTampered) {
+		t.Errorf("Expected ErrTampered without the MAC, got %v", err)
+	}
+}
+
+func TestEncryptedStoreMACWithData(t *testing.T) {
+	s, backend := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "a", &vault.Secret{Value: "1"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	metaBefore, _ := backend.ReadMeta()
+
+	// Writes replace only the data file, which carries its own MAC
+	if err := s.Set(ctx, "b", &vault.Secret{Value: "2"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	if metaAfter, _ := backend.ReadMeta(); string(metaAfter) != string(metaBefore) {
+		t.Errorf("Expected a write to leave the metadata unchanged")
+	}
+	var meta VaultMeta
+	if err := json.Unmarshal(metaBefore, &meta); err != nil {
+		t.Fatalf("Failed to parse metadata: %v", err)
+	}
+	if !meta.DataMACAppended || meta.DataMAC != "" {
+		t.Errorf("Expected the MAC in the data file only, got %+v", meta)
+	}
+
+	// A vault that kept the MAC in its metadata is upgraded by the next
+	// write, and still opens if the process stops before the metadata is
+	meta.DataMACAppended = false
+	meta.DataMAC = "stale"
+	raw, _ := json.Marshal(meta)
+	_ = backend.WriteMeta(raw)
+	if err := s.Lock(); err != nil {
+		t.Fatalf("Failed to lock: %v", err)
+	}
+	if err := s.Unlock("password123"); err != nil {
+		t.Fatalf("Expected the MAC in the data file to be used, got %v", err)
+	}
+	if count := s.SecretCount(); count != 2 {
+		t.Errorf("Expected 2 secrets, got %d", count)
+	}
+}
+
+func TestEncryptedStoreLegacyWithoutMAC(t *testing.T) {
+	s, backend := newTestStore(t)
+	ctx := context.Background()
+
+	_ = s.Set(ctx, "a", &vault.Secret{Value: "1"})
+	_ = s.Lock()
+
+	// Strip the MAC to simulate a vault created by an older version
+	raw, _ := backend.ReadMeta()
+	var meta VaultMeta
+	_ = json.Unmarshal(raw, &meta)
+	meta.DataMACAppended = false
+	raw, _ = json.Marshal(meta)
+	_ = backend.WriteMeta(raw)
+	raw, _ = backend.ReadData()
+	data, _ := splitDataMAC(raw)
+	_ = backend.WriteData(data)
+
+	if err := s.Unlock("password123"); err != nil {
+		t.Fatalf("Expected legacy vault to unlock, got %v", err)
+	}
+}
+
+func TestEncryptedStoreSnapshotConsistent(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	const keys = 8
+	const generations = 50
+	path := func(i int) string { return fmt.Sprintf("counter/%d", i) }
+
+	for i := 0; i < keys; i++ {
+		if err := s.Set(ctx, path(i), &vault.Secret{Value: "0"}); err != nil {
+			t.Fatalf("Failed to set secret: %v", err)
+		}
+	}
+
+	// The writer advances every key to the next generation in order, so any
+	// point-in-time view has non-increasing generations across the keys that
+	// differ by at most one.
+	done := make(chan error, 1)
+	go func() {
+		for g := 1; g <= generations; g++ {
+			for i := 0; i < keys; i++ {
+				if err := s.Set(ctx, path(i), &vault.Secret{Value: strconv.Itoa(g)}); err != nil {
+					done <- err
+					return
+				}
+			}
+		}
+		done <- nil
+	}()
+
+	for running := true; running; {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Writer failed: %v", err)
+			}
+			running = false
+		default:
+		}
+
+		snap, err := s.Snapshot(ctx)
+		if err != nil {
+			t.Fatalf("Snapshot failed: %v", err)
+		}
+		if len(snap) != keys {
+			t.Fatalf("Expected %d secrets, got %d", keys, len(snap))
+		}
+
+		first, _ := strconv.Atoi(snap[path(0)].Value)
+		prev := first
+		for i := 1; i < keys; i++ {
+			g, _ := strconv.Atoi(snap[path(i)].Value)
+			if g > prev || first-g > 1 {
+				t.Fatalf("Snapshot is not point-in-time consistent: key %d has generation %d after %d (first %d)", i, g, prev, first)
+			}
+			prev = g
+		}
+	}
+}
+
+func TestEncryptedStoreSnapshotLocked(t *testing.T) {
+	s, _ := newTestStore(t)
+
+	if err := s.Lock(); err != nil {
+		t.Fatalf("Failed to lock store: %v", err)
+	}
+	if _, err := s.Snapshot(context.Background()); err == nil {
+		t.Error("Expected error when snapshotting a locked store")
+	}
+}
+
+// cancelAfterContext reports cancellation once Err has been called n times.
+type cancelAfterContext struct {
+	context.Context
+	n     int
+	calls int
+}
+
+func (c *cancelAfterContext) Err() error {
+	c.calls++
+	if c.calls > c.n {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestEncryptedStoreRotateDEKCancelled(t *testing.T) {
+	s, backend := newTestStore(t)
+	ctx := context.Background()
+
+	const count = 100
+	for i := 0; i < count; i++ {
+		if err := s.Set(ctx, fmt.Sprintf("secret/%03d", i), &vault.Secret{Value: strconv.Itoa(i)}); err != nil {
+			t.Fatalf("Failed to set secret: %v", err)
+		}
+	}
+
+	// Cancel halfway through re-encryption
+	cancelCtx := &cancelAfterContext{Context: ctx, n: count / 2}
+	err := s.RotateDEK(cancelCtx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if cancelCtx.calls != count/2+1 {
+		t.Errorf("Expected cancellation to stop the loop after %d checks, got %d", count/2+1, cancelCtx.calls)
+	}
+
+	// The open store still works
+	if secret, err := s.Get(ctx, "secret/099"); err != nil || secret.Value != "99" {
+		t.Errorf("Expected secret/099 = 99, got %v, %v", secret, err)
+	}
+
+	// The persisted vault is intact
+	reopened := NewEncryptedStoreWithBackend(backend)
+	if err := reopened.Unlock("password123"); err != nil {
+		t.Fatalf("Failed to unlock with old password: %v", err)
+	}
+	if n := reopened.SecretCount(); n != count {
+		t.Errorf("Expected %d secrets, got %d", count, n)
+	}
+	for i := 0; i < count; i++ {
+		secret, err := reopened.Get(ctx, fmt.Sprintf("secret/%03d", i))
+		if err != nil || secret.Value != strconv.Itoa(i) {
+			t.Fatalf("Secret %d corrupted: %v, %v", i, secret, err)
+		}
+	}
+}
+
+func TestEncryptedStoreChangePassword(t *testing.T) {
+	s, backend := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "api/key", &vault.Secret{Value: "secret123"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	if err := s.ChangePassword(ctx, "password123", "newpassword456"); err != nil {
+		t.Fatalf("Failed to change password: %v", err)
+	}
+
+	reopened := NewEncryptedStoreWithBackend(backend)
+	if err := reopened.Unlock("newpassword456"); err != nil {
+		t.Fatalf("Failed to unlock with new password: %v", err)
+	}
+	if secret, err := reopened.Get(ctx, "api/key"); err != nil || secret.Value != "secret123" {
+		t.Errorf("Expected api/key = secret123, got %v, %v", secret, err)
+	}
+}
+
+// countingBackend counts data writes.
+type countingBackend struct {
+	*MemBackend
+	dataWrites int
+}
+
+func (b *countingBackend) WriteData(data []byte) error {
+	b.dataWrites++
+	return b.MemBackend.WriteData(data)
+}
+
+func TestEncryptedStoreChangePasswordKeepsData(t *testing.T) {
+	backend := &countingBackend{MemBackend: NewMemBackend()}
+	s := NewEncryptedStoreWithBackend(backend)
+	if err := s.Initialize("password123"); err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		if err := s.Set(ctx, fmt.Sprintf("secret/%d", i), &vault.Secret{Value: strconv.Itoa(i)}); err != nil {
+			t.Fatalf("Failed to set secret: %v", err)
+		}
+	}
+	before, _ := backend.ReadData()
+	writes := backend.dataWrites
+
+	if err := s.ChangePassword(ctx, "password123", "newpassword456"); err != nil {
+		t.Fatalf("Failed to change password: %v", err)
+	}
+
+	// Only the wrapped data key changes, so no secret is re-encrypted
+	if backend.dataWrites != writes {
+		t.Errorf("Expected no data writes, got %d", backend.dataWrites-writes)
+	}
+	if after, _ := backend.ReadData(); string(after) != string(before) {
+		t.Error("Expected vault data to be unchanged")
+	}
+
+	reopened := NewEncryptedStoreWithBackend(backend)
+	if err := reopened.Unlock("password123"); err == nil {
+		t.Error("Expected old password to be rejected")
+	}
+	if err := reopened.Unlock("newpassword456"); err != nil {
+		t.Fatalf("Failed to unlock with new password: %v", err)
+	}
+	if secret, err := reopened.Get(ctx, "secret/9"); err != nil || secret.Value != "9" {
+		t.Errorf("Expected secret/9 = 9, got %v, %v", secret, err)
+	}
+}
+
+func TestEncryptedStoreChangePasswordCancelled(t *testing.T) {
+	s, backend := newTestStore(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := s.ChangePassword(ctx, "password123", "newpassword456"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if !s.VerifyPassword("password123") {
+		t.Error("Expected old password to remain valid")
+	}
+
+	reopened := NewEncryptedStoreWithBackend(backend)
+	if err := reopened.Unlock("password123"); err != nil {
+		t.Errorf("Failed to unlock with old password: %v", err)
+	}
+}
+
+// faultySealer makes the cipher encrypt the wrong plaintext whenever
+// corrupt returns true, until the test ends, as a subtly broken cipher
+// would.
+func faultySealer(t *testing.T, corrupt func(plaintext []byte) bool) {
+	t.Helper()
+	sealer = func(key, plaintext []byte) (string, error) {
+		if corrupt(plaintext) {
+			plaintext = append([]byte("x"), plaintext...)
+		}
+		return seal(key, plaintext)
+	}
+	t.Cleanup(func() { sealer = seal })
+}
+
+func TestEncryptedStoreChangePasswordVerifies(t *testing.T) {
+	s, backend := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "api/key", &vault.Secret{Value: "secret123"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	metaBefore, _ := backend.ReadMeta()
+
+	faultySealer(t, func([]byte) bool { return true })
+	if err := s.ChangePassword(ctx, "password123", "newpassword456"); !errors.Is(err, ErrVerificationFailed) {
+		t.Fatalf("Expected ErrVerificationFailed, got %v", err)
+	}
+	sealer = seal
+
+	if after, _ := backend.ReadMeta(); string(after) != string(metaBefore) {
+		t.Error("Expected the metadata to be unchanged")
+	}
+	if !s.VerifyPassword("password123") {
+		t.Error("Expected the old password to remain valid")
+	}
+	if secret, err := s.Get(ctx, "api/key"); err != nil || secret.Value != "secret123" {
+		t.Errorf("Expected the open store to still work, got %v, %v", secret, err)
+	}
+
+	reopened := NewEncryptedStoreWithBackend(backend)
+	if err := reopened.Unlock("newpassword456"); err == nil {
+		t.Error("Expected the new password to be rejected")
+	}
+	if err := reopened.Unlock("password123"); err != nil {
+		t.Fatalf("Failed to unlock with old password: %v", err)
+	}
+	if secret, err := reopened.Get(ctx, "api/key"); err != nil || secret.Value != "secret123" {
+		t.Errorf("Expected api/key = secret123, got %v, %v", secret, err)
+	}
+}
+
+func TestEncryptedStoreRotateDEKVerifies(t *testing.T) {
+	s, backend := newTestStore(t)
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		if err := s.Set(ctx, fmt.Sprintf("secret/%d", i), &vault.Secret{Value: strconv.Itoa(i)}); err != nil {
+			t.Fatalf("Failed to set secret: %v", err)
+		}
+	}
+	wrappedBefore := s.meta.WrappedKey
+	dataBefore, _ := backend.ReadData()
+
+	// Only secrets are corrupted, not the data key
+	faultySealer(t, func(plaintext []byte) bool { return bytes.Contains(plaintext, []byte(`"7"`)) })
+	err := s.RotateDEK(ctx)
+	if !errors.Is(err, ErrVerificationFailed) || !strings.Contains(err.Error(), "secret/7") {
+		t.Fatalf("Expected ErrVerificationFailed for secret/7, got %v", err)
+	}
+	sealer = seal
+
+	if s.meta.WrappedKey != wrappedBefore {
+		t.Error("Expected the old data key to be kept")
+	}
+	if after, _ := backend.ReadData(); string(after) != string(dataBefore) {
+		t.Error("Expected the vault data to be unchanged")
+	}
+	if secret, err := s.Get(ctx, "secret/9"); err != nil || secret.Value != "9" {
+		t.Errorf("Expected the open store to still work, got %v, %v", secret, err)
+	}
+
+	reopened := NewEncryptedStoreWithBackend(backend)
+	if err := reopened.Unlock("password123"); err != nil {
+		t.Fatalf("Failed to unlock: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		secret, err := reopened.Get(ctx, fmt.Sprintf("secret/%d", i))
+		if err != nil || secret.Value != strconv.Itoa(i) {
+			t.Fatalf("Secret %d corrupted: %v, %v", i, secret, err)
+		}
+	}
+}
+
+func TestEncryptedStoreRotateDEK(t *testing.T) {
+	s, backend := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "api/key", &vault.Secret{Value: "secret123"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	wrappedBefore := s.meta.WrappedKey
+	cipherBefore := s.data.Secrets["api/key"]
+
+	if err := s.RotateDEK(ctx); err != nil {
+		t.Fatalf("Failed to rotate data key: %v", err)
+	}
+	if s.meta.WrappedKey == wrappedBefore {
+		t.Error("Expected a new wrapped data key")
+	}
+	if s.data.Secrets["api/key"] == cipherBefore {
+		t.Error("Expected the secret to be re-encrypted")
+	}
+
+	reopened := NewEncryptedStoreWithBackend(backend)
+	if err := reopened.Unlock("password123"); err != nil {
+		t.Fatalf("Failed to unlock after rotation: %v", err)
+	}
+	if secret, err := reopened.Get(ctx, "api/key"); err != nil || secret.Value != "secret123" {
+		t.Errorf("Expected api/key = secret123, got %v, %v", secret, err)
+	}
+
+	_ = s.Lock()
+	if err := s.RotateDEK(ctx); err == nil {
+		t.Error("Expected RotateDEK to fail while locked")
+	}
+}
+
+// crashingBackend stops persisting writes after writesLeft of them, as if
+// the process had crashed; later writes fail without changing anything.
+type crashingBackend struct {
+	*MemBackend
+	writesLeft int
+}
+
+var errCrashed = errors.New("crashed")
+
+func (b *crashingBackend) WriteMeta(data []byte) error {
+	if b.writesLeft <= 0 {
+		return errCrashed
+	}
+	b.writesLeft--
+	return b.MemBackend.WriteMeta(data)
+}
+
+func (b *crashingBackend) WriteData(data []byte) error {
+	if b.writesLeft <= 0 {
+		return errCrashed
+	}
+	b.writesLeft--
+	return b.MemBackend.WriteData(data)
+}
+
+func TestEncryptedStoreRotateDEKInterrupted(t *testing.T) {
+	ctx := context.Background()
+
+	// RotateDEK writes the metadata with the pending key, the data, and
+	// the metadata with the new key
+	for writes, rotated := range []bool{false, false, true} {
+		backend := &crashingBackend{MemBackend: NewMemBackend(), writesLeft: 100}
+		s := NewEncryptedStoreWithBackend(backend)
+		if err := s.Initialize("password123"); err != nil {
+			t.Fatalf("Failed to initialize store: %v", err)
+		}
+		if err := s.Set(ctx, "api/key", &vault.Secret{Value: "secret123"}); err != nil {
+			t.Fatalf("Failed to set secret: %v", err)
+		}
+		recoveryKey, err := s.SetupRecovery(ctx)
+		if err != nil {
+			t.Fatalf("SetupRecovery failed: %v", err)
+		}
+		wrappedBefore := s.meta.WrappedKey
+
+		backend.writesLeft = writes
+		if err := s.RotateDEK(ctx); !errors.Is(err, errCrashed) {
+			t.Fatalf("%d writes: expected the rotation to fail, got %v", writes, err)
+		}
+		backend.writesLeft = 100
+
+		if !rotated {
+			// The open store keeps the old key and still saves
+			if s.meta.WrappedKey != wrappedBefore || s.meta.PendingKey != nil {
+				t.Errorf("%d writes: expected the old key to be kept", writes)
+			}
+			if err := s.Set(ctx, "db/password", &vault.Secret{Value: "hunter2"}); err != nil {
+				t.Fatalf("%d writes: failed to set secret: %v", writes, err)
+			}
+		}
+
+		// A new process opens the vault with either key; each gets its own
+		// copy, since unlocking settles the pending key
+		meta, _ := backend.ReadMeta()
+		data, _ := backend.ReadData()
+		copyBackend := func() *MemBackend {
+			b := NewMemBackend()
+			_ = b.WriteMeta(meta)
+			_ = b.WriteData(data)
+			return b
+		}
+		recovered := NewEncryptedStoreWithBackend(copyBackend())
+		if err := recovered.UnlockWithRecoveryKey(recoveryKey); err != nil {
+			t.Fatalf("%d writes: failed to unlock with the recovery key: %v", writes, err)
+		}
+		reopened := NewEncryptedStoreWithBackend(copyBackend())
+		if err := reopened.Unlock("password123"); err != nil {
+			t.Fatalf("%d writes: failed to unlock: %v", writes, err)
+		}
+		for _, store := range []*EncryptedStore{recovered, reopened} {
+			if secret, err := store.Get(ctx, "api/key"); err != nil || secret.Value != "secret123" {
+				t.Errorf("%d writes: expected api/key = secret123, got %v, %v", writes, secret, err)
+			}
+			if store.meta.PendingKey != nil {
+				t.Errorf("%d writes: expected the pending key to be settled", writes)
+			}
+			if got := store.meta.WrappedKey != wrappedBefore; got != rotated {
+				t.Errorf("%d writes: expected the key to be rotated = %v, got %v", writes, rotated, got)
+			}
+		}
+	}
+}
+
+// writeDirectKeyVault writes a vault in the format used before data keys,
+// with secrets encrypted by the password-derived key.
+func writeDirectKeyVault(t *testing.T, backend Backend, password string, secrets map[string]string) {
+	t.Helper()
+
+	crypto, err := NewCrypto(nil, DefaultArgon2Params())
+	if err != nil {
+		t.Fatal(err)
+	}
+	crypto.Unlock(password)
+	defer crypto.Lock()
+
+	verification, err := crypto.CreateVerificationBlob()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := VaultData{Secrets: make(map[string]string)}
+	for path, value := range secrets {
+		plain, _ := json.Marshal(&vault.Secret{Value: value})
+		if data.Secrets[path], err = crypto.Encrypt(plain); err != nil {
+			t.Fatal(err)
+		}
+	}
+	raw, _ := json.Marshal(data)
+	mac, err := crypto.MAC(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta, _ := json.Marshal(VaultMeta{
+		Version:      metaVersionDirect,
+		CreatedAt:    time.Now(),
+		Salt:         crypto.Salt(),
+		Argon2Params: crypto.Params(),
+		Verification: verification,
+		DataMAC:      mac,
+	})
+	if err := backend.WriteMeta(meta); err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.WriteData(raw); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEncryptedStoreMigrateDirectKey(t *testing.T) {
+	backend := NewMemBackend()
+	writeDirectKeyVault(t, backend, "password123", map[string]string{"api/key": "secret123"})
+	dataBefore, _ := backend.ReadData()
+	ctx := context.Background()
+
+	s := NewEncryptedStoreWithBackend(backend)
+	if err := s.Unlock("password123"); err != nil {
+		t.Fatalf("Failed to unlock direct-key vault: %v", err)
+	}
+	if secret, err := s.Get(ctx, "api/key"); err != nil || secret.Value != "secret123" {
+		t.Errorf("Expected api/key = secret123, got %v, %v", secret, err)
+	}
+
+	// Unlocking stores the key as a wrapped data key without touching the data
+	raw, _ := backend.ReadMeta()
+	var meta VaultMeta
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		t.Fatalf("Failed to parse metadata: %v", err)
+	}
+	if meta.Version != metaVersionDataKey || meta.WrappedKey == "" {
+		t.Errorf("Expected migrated metadata, got version %d, wrapped key %q", meta.Version, meta.WrappedKey)
+	}
+	if dataAfter, _ := backend.ReadData(); string(dataAfter) != string(dataBefore) {
+		t.Error("Expected migration to leave the vault data unchanged")
+	}
+
+	// The migrated vault supports password changes and key rotation
+	if err := s.ChangePassword(ctx, "password123", "newpassword456"); err != nil {
+		t.Fatalf("Failed to change password: %v", err)
+	}
+	if err := s.RotateDEK(ctx); err != nil {
+		t.Fatalf("Failed to rotate data key: %v", err)
+	}
+
+	reopened := NewEncryptedStoreWithBackend(backend)
+	if err := reopened.Unlock("newpassword456"); err != nil {
+		t.Fatalf("Failed to unlock migrated vault: %v", err)
+	}
+	if secret, err := reopened.Get(ctx, "api/key"); err != nil || secret.Value != "secret123" {
+		t.Errorf("Expected api/key = secret123, got %v, %v", secret, err)
+	}
+}
+
+func TestEncryptedStoreSetCancelled(t *testing.T) {
+	s, _ := newTestStore(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := s.Set(ctx, "api/key", &vault.Secret{Value: "x"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if exists, _ := s.Exists(context.Background(), "api/key"); exists {
+		t.Error("Expected cancelled Set to leave the store unchanged")
+	}
+}
+
+func TestEncryptedStoreAliasResolution(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "db/prod", &vault.Secret{Value: "hunter2"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	if err := s.SetAlias(ctx, "db/current", "db/prod"); err != nil {
+		t.Fatalf("Failed to set alias: %v", err)
+	}
+	if err := s.SetAlias(ctx, "db/default", "db/current"); err != nil {
+		t.Fatalf("Failed to set chained alias: %v", err)
+	}
+
+	secret, err := s.Get(ctx, "db/default")
+	if err != nil {
+		t.Fatalf("Failed to get alias: %v", err)
+	}
+	if secret.Value != "hunter2" {
+		t.Errorf("Expected alias to resolve to 'hunter2', got %q", secret.Value)
+	}
+
+	if target, err := s.AliasTarget(ctx, "db/current"); err != nil || target != "db/prod" {
+		t.Errorf("Expected alias target 'db/prod', got %q, %v", target, err)
+	}
+
+	// Writes through an alias update the target
+	if err := s.Set(ctx, "db/current", &vault.Secret{Value: "rotated"}); err != nil {
+		t.Fatalf("Failed to set through alias: %v", err)
+	}
+	secret, err = s.Get(ctx, "db/prod")
+	if err != nil {
+		t.Fatalf("Failed to get target: %v", err)
+	}
+	if secret.Value != "rotated" {
+		t.Errorf("Expected target to be updated, got %q", secret.Value)
+	}
+	if target, _ := s.AliasTarget(ctx, "db/current"); target != "db/prod" {
+		t.Errorf("Expected alias to be kept, got target %q", target)
+	}
+
+	s.SetAliasWriteThrough(false)
+	if err := s.Set(ctx, "db/current", &vault.Secret{Value: "x"}); !errors.Is(err, ErrAliasWrite) {
+		t.Errorf("Expected ErrAliasWrite, got %v", err)
+	}
+
+	if err := s.SetAlias(ctx, "db/dangling", "db/missing"); err != vault.ErrSecretNotFound {
+		t.Errorf("Expected ErrSecretNotFound for missing target, got %v", err)
+	}
+}
+
+func TestEncryptedStoreAliasLoop(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "a", &vault.Secret{Value: "value"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	if err := s.SetAlias(ctx, "b", "a"); err != nil {
+		t.Fatalf("Failed to set alias: %v", err)
+	}
+
+	// Turning the target into an alias of its alias would loop
+	if err := s.SetAlias(ctx, "a", "b"); !errors.Is(err, ErrAliasLoop) {
+		t.Errorf("Expected ErrAliasLoop, got %v", err)
+	}
+	if err := s.SetAlias(ctx, "b", "b"); !errors.Is(err, ErrAliasLoop) {
+		t.Errorf("Expected ErrAliasLoop for self alias, got %v", err)
+	}
+
+	// Chains longer than MaxAliasDepth are rejected
+	prev := "a"
+	for i := 0; i < MaxAliasDepth; i++ {
+		next := "chain" + strconv.Itoa(i)
+		if err := s.SetAlias(ctx, next, prev); err != nil {
+			t.Fatalf("Failed to set alias %d: %v", i, err)
+		}
+		prev = next
+	}
+	if err := s.SetAlias(ctx, "too-deep", prev); !errors.Is(err, ErrAliasLoop) {
+		t.Errorf("Expected ErrAliasLoop for deep chain, got %v", err)
+	}
+}
+
+func TestEncryptedStoreAliasDelete(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "target", &vault.Secret{Value: "value"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	if err := s.SetAlias(ctx, "alias", "target"); err != nil {
+		t.Fatalf("Failed to set alias: %v", err)
+	}
+
+	if err := s.Delete(ctx, "alias"); err != nil {
+		t.Fatalf("Failed to delete alias: %v", err)
+	}
+	if _, err := s.Get(ctx, "alias"); err != vault.ErrSecretNotFound {
+		t.Errorf("Expected alias to be gone, got %v", err)
+	}
+
+	secret, err := s.Get(ctx, "target")
+	if err != nil {
+		t.Fatalf("Expected target to survive alias deletion: %v", err)
+	}
+	if secret.Value != "value" {
+		t.Errorf("Expected 'value', got %q", secret.Value)
+	}
+}
+
+func TestEncryptedStoreRequiredFields(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	s.SetRequiredFields(map[string][]string{
+		"db/": {"username", "password"},
+	})
+
+	valid := &vault.Secret{Fields: map[string]string{"username": "admin", "password": "hunter2"}}
+	if err := s.Set(ctx, "db/prod", valid); err != nil {
+		t.Fatalf("Expected valid secret to be saved, got %v", err)
+	}
+
+	// Paths outside the prefix are not validated
+	if err := s.Set(ctx, "api/key", &vault.Secret{Value: "x"}); err != nil {
+		t.Fatalf("Expected unrelated path to be saved, got %v", err)
+	}
+
+	invalid := &vault.Secret{Fields: map[string]string{"username": "admin"}}
+	err := s.Set(ctx, "db/staging", invalid)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+	if len(verr.Missing) != 1 || verr.Missing[0] != "password" {
+		t.Errorf("Expected missing 'password', got %v", verr.Missing)
+	}
+	if exists, _ := s.Exists(ctx, "db/staging"); exists {
+		t.Error("Expected rejected secret not to be stored")
+	}
+
+	// Disabling validation accepts the secret again
+	s.SetRequiredFields(nil)
+	if err := s.Set(ctx, "db/staging", invalid); err != nil {
+		t.Errorf("Expected secret to be saved with validation off, got %v", err)
+	}
+}
+
+func TestEncryptedStoreNormalizePaths(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "database/password", &vault.Secret{Value: "hunter2"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+
+	for _, path := range []string{"database/password", "/database/password", "database//password", "database/password/"} {
+		secret, err := s.Get(ctx, path)
+		if err != nil {
+			t.Errorf("Get(%q) failed: %v", path, err)
+			continue
+		}
+		if secret.Value != "hunter2" {
+			t.Errorf("Get(%q) = %q, want 'hunter2'", path, secret.Value)
+		}
+		if exists, _ := s.Exists(ctx, path); !exists {
+			t.Errorf("Exists(%q) = false", path)
+		}
+	}
+
+	// Writing a variant updates the same secret
+	if err := s.Set(ctx, "/database//password", &vault.Secret{Value: "rotated"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	paths, err := s.List(ctx, "/database/")
+	if err != nil {
+		t.Fatalf("Failed to list: %v", err)
+	}
+	if len(paths) != 1 || paths[0] != "database/password" {
+		t.Errorf("Expected single normalized path, got %v", paths)
+	}
+
+	if err := s.Delete(ctx, "database//password/"); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if s.SecretCount() != 0 {
+		t.Errorf("Expected secret to be deleted, %d left", s.SecretCount())
+	}
+}
+
+func TestEncryptedStoreMigratePaths(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	// Simulate a vault created before normalization was the default
+	s.meta.NormalizePaths = false
+	for _, path := range []string{"/a/b", "c//d", "e"} {
+		if err := s.Set(ctx, path, &vault.Secret{Value: path}); err != nil {
+			t.Fatalf("Failed to set %q: %v", path, err)
+		}
+	}
+	if _, err := s.Get(ctx, "a/b"); err != vault.ErrSecretNotFound {
+		t.Fatalf("Expected paths not to be normalized yet, got %v", err)
+	}
+
+	renamed, err := s.MigratePaths(ctx)
+	if err != nil {
+		t.Fatalf("MigratePaths failed: %v", err)
+	}
+	want := map[string]string{"/a/b": "a/b", "c//d": "c/d"}
+	if fmt.Sprint(renamed) != fmt.Sprint(want) {
+		t.Errorf("Renamed = %v, want %v", renamed, want)
+	}
+
+	secret, err := s.Get(ctx, "/a//b")
+	if err != nil {
+		t.Fatalf("Failed to get migrated secret: %v", err)
+	}
+	if secret.Value != "/a/b" {
+		t.Errorf("Expected migrated value '/a/b', got %q", secret.Value)
+	}
+}
+
+func TestEncryptedStoreMigratePathsConflict(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	s.meta.NormalizePaths = false
+	for _, path := range []string{"a/b", "/a/b"} {
+		if err := s.Set(ctx, path, &vault.Secret{Value: path}); err != nil {
+			t.Fatalf("Failed to set %q: %v", path, err)
+		}
+	}
+
+	if _, err := s.MigratePaths(ctx); err == nil {
+		t.Fatal("Expected conflict error")
+	}
+	if exists, _ := s.Exists(ctx, "/a/b"); !exists {
+		t.Error("Expected conflicting secrets to be left alone")
+	}
+}
+
+func TestEncryptedStoreUpdateMetadata(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	secret := &vault.Secret{
+		Value:    "hunter2",
+		Fields:   map[string]string{"username": "admin"},
+		Metadata: vault.Metadata{Tags: map[string]string{"env": "dev"}},
+	}
+	if err := s.Set(ctx, "db/prod", secret); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	expires := vault.NewTimestamp(time.Now().Add(time.Hour).Truncate(time.Second))
+	err := s.UpdateMetadata(ctx, "db/prod", func(m *vault.Metadata) {
+		m.Tags["env"] = "prod"
+		m.Tags["team"] = "core"
+		m.ExpiresAt = expires
+	})
+	if err != nil {
+		t.Fatalf("UpdateMetadata failed: %v", err)
+	}
+
+	got, err := s.Get(ctx, "db/prod")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Value != "hunter2" || got.Fields["username"] != "admin" {
+		t.Errorf("Expected value and fields to be preserved, got %+v", got)
+	}
+	if got.Metadata.Tags["env"] != "prod" || got.Metadata.Tags["team"] != "core" {
+		t.Errorf("Expected updated tags, got %v", got.Metadata.Tags)
+	}
+	if got.Metadata.ExpiresAt == nil || !got.Metadata.ExpiresAt.Equal(expires.Time) {
+		t.Errorf("Expected expiry %v, got %v", expires, got.Metadata.ExpiresAt)
+	}
+
+	err = s.UpdateMetadata(ctx, "missing", func(*vault.Metadata) {})
+	if !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+}
+
+func TestEncryptedStoreDeletePrefix(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	for _, path := range []string{"db/prod", "db/staging", "dbx", "api/key"} {
+		if err := s.Set(ctx, path, &vault.Secret{Value: "x"}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	deleted, err := s.DeletePrefix(ctx, "db/")
+	if err != nil {
+		t.Fatalf("DeletePrefix failed: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Expected 2 deleted, got %d", deleted)
+	}
+
+	paths, _ := s.List(ctx, "")
+	if want := []string{"api/key", "dbx"}; fmt.Sprint(paths) != fmt.Sprint(want) {
+		t.Errorf("Remaining = %v, want %v", paths, want)
+	}
+
+	if deleted, err := s.DeletePrefix(ctx, "missing/"); err != nil || deleted != 0 {
+		t.Errorf("Expected 0, nil for missing prefix; got %d, %v", deleted, err)
+	}
+}
+
+func TestEncryptedStoreUpdateMetadataPrefix(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	for _, path := range []string{"db/prod", "db/staging", "dbx"} {
+		if err := s.Set(ctx, path, &vault.Secret{Value: "x"}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	if err := s.SetAlias(ctx, "db/current", "dbx"); err != nil {
+		t.Fatalf("SetAlias failed: %v", err)
+	}
+
+	tag := func(m *vault.Metadata) {
+		if m.Tags == nil {
+			m.Tags = make(map[string]string)
+		}
+		m.Tags["env"] = "db"
+	}
+	updated, err := s.UpdateMetadataPrefix(ctx, "db/", tag)
+	if err != nil {
+		t.Fatalf("UpdateMetadataPrefix failed: %v", err)
+	}
+	if updated != 2 {
+		t.Errorf("Expected 2 updated, got %d", updated)
+	}
+
+	for path, want := range map[string]string{"db/prod": "db", "db/staging": "db", "dbx": ""} {
+		secret, err := s.Get(ctx, path)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if secret.Metadata.Tags["env"] != want || secret.Value != "x" {
+			t.Errorf("%s: expected env tag %q, got %+v", path, want, secret)
+		}
+	}
+	if target, _ := s.AliasTarget(ctx, "db/current"); target != "dbx" {
+		t.Errorf("Expected the alias to be left alone, got target %q", target)
+	}
+}
+
+func TestEncryptedStoreRecoveryKey(t *testing.T) {
+	s, backend := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "api/key", &vault.Secret{Value: "secret123"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	recoveryKey, err := s.SetupRecovery(ctx)
+	if err != nil {
+		t.Fatalf("SetupRecovery failed: %v", err)
+	}
+	if err := s.Lock(); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+
+	// A fresh store, as after a daemon restart
+	s = NewEncryptedStoreWithBackend(backend)
+	if err := s.UnlockWithRecoveryKey("AAAA-BBBB"); !errors.Is(err, ErrInvalidRecoveryKey) {
+		t.Errorf("Expected ErrInvalidRecoveryKey for a malformed key, got %v", err)
+	}
+	other, _ := NewRecoveryKey()
+	if err := s.UnlockWithRecoveryKey(other); !errors.Is(err, ErrInvalidRecoveryKey) {
+		t.Errorf("Expected ErrInvalidRecoveryKey for the wrong key, got %v", err)
+	}
+
+	// Case and dashes don't matter
+	if err := s.UnlockWithRecoveryKey(strings.ToLower(strings.ReplaceAll(recoveryKey, "-", ""))); err != nil {
+		t.Fatalf("UnlockWithRecoveryKey failed: %v", err)
+	}
+	if got, err := s.Get(ctx, "api/key"); err != nil || got.Value != "secret123" {
+		t.Fatalf("Expected to read secrets after recovery, got %v, %v", got, err)
+	}
+	if !s.Recovered() {
+		t.Error("Expected Recovered to be true")
+	}
+
+	if err := s.ResetPassword(ctx, "newpassword456"); err != nil {
+		t.Fatalf("ResetPassword failed: %v", err)
+	}
+	if err := s.ResetPassword(ctx, "again789012"); err == nil {
+		t.Error("Expected a second ResetPassword to require the current password")
+	}
+
+	if err := s.Lock(); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if err := s.Unlock("newpassword456"); err != nil {
+		t.Fatalf("Unlock with the reset password failed: %v", err)
+	}
+	if info, err := s.Info(); err != nil || !info.Recovery {
+		t.Errorf("Expected Info to report a recovery key, got %+v, %v", info, err)
+	}
+}
+
+func TestEncryptedStoreRecoveryKeySurvivesPasswordChange(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "api/key", &vault.Secret{Value: "secret123"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	recoveryKey, err := s.SetupRecovery(ctx)
+	if err != nil {
+		t.Fatalf("SetupRecovery failed: %v", err)
+	}
+
+	if err := s.ChangePassword(ctx, "password123", "newpassword456"); err != nil {
+		t.Fatalf("ChangePassword failed: %v", err)
+	}
+	if err := s.RotateDEK(ctx); err != nil {
+		t.Fatalf("RotateDEK failed: %v", err)
+	}
+
+	if err := s.Lock(); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if err := s.UnlockWithRecoveryKey(recoveryKey); err != nil {
+		t.Fatalf("Expected the recovery key to survive password and key changes: %v", err)
+	}
+	if got, err := s.Get(ctx, "api/key"); err != nil || got.Value != "secret123" {
+		t.Errorf("Expected to read secrets after recovery, got %v, %v", got, err)
+	}
+
+	// Rotating the recovery key invalidates the old one
+	if _, err := s.SetupRecovery(ctx); err != nil {
+		t.Fatalf("SetupRecovery failed: %v", err)
+	}
+	if err := s.Lock(); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if err := s.UnlockWithRecoveryKey(recoveryKey); !errors.Is(err, ErrInvalidRecoveryKey) {
+		t.Errorf("Expected the old recovery key to be rejected, got %v", err)
+	}
+}
+
+func TestEncryptedStoreClock(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	start := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	s.SetClock(clk)
+
+	if err := s.Set(ctx, "api/key", &vault.Secret{Value: "v1"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	clk.Advance(48 * time.Hour)
+	if err := s.UpdateMetadata(ctx, "api/key", func(m *vault.Metadata) { m.Tags = map[string]string{"env": "prod"} }); err != nil {
+		t.Fatalf("UpdateMetadata failed: %v", err)
+	}
+
+	got, err := s.Get(ctx, "api/key")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !got.Metadata.CreatedAt.Equal(start) {
+		t.Errorf("CreatedAt = %v, want %v", got.Metadata.CreatedAt, start)
+	}
+	if want := start.Add(48 * time.Hour); !got.Metadata.ModifiedAt.Equal(want) {
+		t.Errorf("ModifiedAt = %v, want %v", got.Metadata.ModifiedAt, want)
+	}
+
+	clk.Advance(time.Hour)
+	if err := s.Lock(); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if err := s.Unlock("password123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if !s.UnlockTime().Equal(clk.Now()) {
+		t.Errorf("UnlockTime = %v, want %v", s.UnlockTime(), clk.Now())
+	}
+}
+
+func TestEncryptedStoreMovePrefix(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	secret := &vault.Secret{Value: "dsn", Metadata: vault.Metadata{Tags: map[string]string{"env": "prod"}}}
+	if err := s.Set(ctx, "old-app/db", secret); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	for _, path := range []string{"old-app/api/key", "old-appx", "other/key"} {
+		if err := s.Set(ctx, path, &vault.Secret{Value: "x"}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	if err := s.SetAlias(ctx, "current-db", "old-app/db"); err != nil {
+		t.Fatalf("SetAlias failed: %v", err)
+	}
+	before, _ := s.Get(ctx, "old-app/db")
+
+	moved, err := s.MovePrefix(ctx, "old-app/", "new-app/", false)
+	if err != nil {
+		t.Fatalf("MovePrefix failed: %v", err)
+	}
+	want := map[string]string{"old-app/db": "new-app/db", "old-app/api/key": "new-app/api/key"}
+	if fmt.Sprint(moved) != fmt.Sprint(want) {
+		t.Errorf("Moved = %v, want %v", moved, want)
+	}
+
+	paths, _ := s.List(ctx, "")
+	if want := []string{"current-db", "new-app/api/key", "new-app/db", "old-appx", "other/key"}; fmt.Sprint(paths) != fmt.Sprint(want) {
+		t.Errorf("Paths = %v, want %v", paths, want)
+	}
+
+	got, err := s.Get(ctx, "new-app/db")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Value != "dsn" || got.Metadata.Tags["env"] != "prod" || !got.Metadata.ModifiedAt.Equal(before.Metadata.ModifiedAt.Time) {
+		t.Errorf("Expected value and metadata to be preserved, got %+v", got)
+	}
+
+	if target, err := s.AliasTarget(ctx, "current-db"); err != nil || target != "new-app/db" {
+		t.Errorf("Expected alias to follow the move, got %q, %v", target, err)
+	}
+}
+
+func TestEncryptedStoreCopy(t *testing.T) {
+	s, _ := newTestStore(t)
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	s.SetClock(clk)
+	ctx := context.Background()
+
+	source := &vault.Secret{
+		Value:  "dsn",
+		Fields: map[string]string{"user": "admin"},
+		Notes:  "primary",
+		Metadata: vault.Metadata{
+			Tags:            map[string]string{"env": "prod"},
+			ProtectedFields: []string{"user"},
+		},
+	}
+	if err := s.Set(ctx, "prod/db", source); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := s.SetAlias(ctx, "current-db", "prod/db"); err != nil {
+		t.Fatalf("SetAlias failed: %v", err)
+	}
+	before, _ := s.Get(ctx, "prod/db")
+
+	clk.Advance(time.Hour)
+	if err := s.Copy(ctx, "current-db", "staging/db", false); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	got, err := s.Get(ctx, "staging/db")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Value != "dsn" || got.Fields["user"] != "admin" || got.Notes != "primary" ||
+		got.Metadata.Tags["env"] != "prod" || !got.Metadata.IsFieldProtected("user") {
+		t.Errorf("Expected the value, fields and tags to be copied, got %+v", got)
+	}
+	if !got.Metadata.CreatedAt.Equal(clk.Now()) || got.Metadata.CreatedAt.Equal(before.Metadata.CreatedAt.Time) {
+		t.Errorf("Expected a new creation time, got %v", got.Metadata.CreatedAt)
+	}
+
+	after, _ := s.Get(ctx, "prod/db")
+	if !reflect.DeepEqual(after, before) {
+		t.Errorf("Expected the source to be unchanged, got %+v, want %+v", after, before)
+	}
+
+	// An existing destination is kept unless forced
+	if err := s.Set(ctx, "dev/db", &vault.Secret{Value: "local"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := s.Copy(ctx, "prod/db", "dev/db", false); !errors.Is(err, vault.ErrAlreadyExists) {
+		t.Errorf("Expected ErrAlreadyExists, got %v", err)
+	}
+	if got, _ := s.Get(ctx, "dev/db"); got.Value != "local" {
+		t.Errorf("Expected dev/db to be kept, got %q", got.Value)
+	}
+	if err := s.Copy(ctx, "prod/db", "dev/db", true); err != nil {
+		t.Fatalf("Copy with force failed: %v", err)
+	}
+	if got, _ := s.Get(ctx, "dev/db"); got.Value != "dsn" {
+		t.Errorf("Expected dev/db to be overwritten, got %q", got.Value)
+	}
+
+	if err := s.Copy(ctx, "missing", "x", false); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+	if err := s.Copy(ctx, "current-db", "prod/db", true); err == nil {
+		t.Error("Expected copying an alias onto its target to fail")
+	}
+}
+
+func TestEncryptedStoreMove(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	source := &vault.Secret{
+		Value:  "dsn",
+		Fields: map[string]string{"user": "admin", "password": "hunter2"},
+		Notes:  "primary",
+		Metadata: vault.Metadata{
+			Tags:            map[string]string{"env": "prod"},
+			Labels:          []string{"db"},
+			ProtectedFields: []string{"password"},
+		},
+	}
+	if err := s.Set(ctx, "prod/db", source); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := s.Rotate(ctx, "prod/db"); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	for _, path := range []string{"prod/dbx", "dev/db"} {
+		if err := s.Set(ctx, path, &vault.Secret{Value: "x"}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	if err := s.SetAlias(ctx, "current-db", "prod/db"); err != nil {
+		t.Fatalf("SetAlias failed: %v", err)
+	}
+	before, _ := s.Get(ctx, "prod/db")
+
+	if err := s.Move(ctx, "prod/db", "dev/db", false); !errors.Is(err, vault.ErrAlreadyExists) {
+		t.Errorf("Expected ErrAlreadyExists, got %v", err)
+	}
+	if err := s.Move(ctx, "prod/db", "staging/db", false); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+
+	paths, _ := s.List(ctx, "")
+	if want := []string{"current-db", "dev/db", "prod/dbx", "staging/db"}; fmt.Sprint(paths) != fmt.Sprint(want) {
+		t.Errorf("Paths = %v, want %v", paths, want)
+	}
+	got, err := s.Get(ctx, "staging/db")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, before) {
+		t.Errorf("Expected the secret to be moved unchanged, got %+v, want %+v", got, before)
+	}
+	if _, err := s.GetVersion(ctx, "staging/db", "1"); err != nil {
+		t.Errorf("Expected the version history to move, got %v", err)
+	}
+	if target, err := s.AliasTarget(ctx, "current-db"); err != nil || target != "staging/db" {
+		t.Errorf("Expected alias to follow the move, got %q, %v", target, err)
+	}
+
+	if err := s.Move(ctx, "staging/db", "dev/db", true); err != nil {
+		t.Fatalf("Move with force failed: %v", err)
+	}
+	if got, _ := s.Get(ctx, "dev/db"); got == nil || got.Value != before.Value {
+		t.Errorf("Expected dev/db to be overwritten, got %+v", got)
+	}
+	if err := s.Move(ctx, "missing", "x", false); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+}
+
+func TestEncryptedStoreMovePrefixCollision(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	for path, value := range map[string]string{"a/one": "a1", "a/two": "a2", "b/two": "b2"} {
+		if err := s.Set(ctx, path, &vault.Secret{Value: value}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	_, err := s.MovePrefix(ctx, "a/", "b/", false)
+	if !errors.Is(err, vault.ErrAlreadyExists) || !strings.Contains(err.Error(), "b/two") {
+		t.Fatalf("Expected ErrAlreadyExists naming b/two, got %v", err)
+	}
+	paths, _ := s.List(ctx, "")
+	if want := []string{"a/one", "a/two", "b/two"}; fmt.Sprint(paths) != fmt.Sprint(want) {
+		t.Errorf("Expected nothing to change, got %v", paths)
+	}
+
+	if _, err := s.MovePrefix(ctx, "a/", "b/", true); err != nil {
+		t.Fatalf("MovePrefix with force failed: %v", err)
+	}
+	if got, _ := s.Get(ctx, "b/two"); got == nil || got.Value != "a2" {
+		t.Errorf("Expected b/two to be overwritten, got %+v", got)
+	}
+
+	// A destination that is itself moved is not a collision
+	if err := s.Set(ctx, "b/b/one", &vault.Secret{Value: "bb1"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := s.MovePrefix(ctx, "b/", "b/b/", false); err != nil {
+		t.Errorf("Expected nested move to succeed, got %v", err)
+	}
+	if got, _ := s.Get(ctx, "b/b/b/one"); got == nil || got.Value != "bb1" {
+		t.Errorf("Expected b/b/one to move to b/b/b/one, got %+v", got)
+	}
+}
+
+func TestEncryptedStoreListInfo(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	secrets := map[string]*vault.Secret{
+		"db/prod": {Value: "dsn", Fields: map[string]string{"user": "admin"}, Metadata: vault.Metadata{Tags: map[string]string{"env": "prod"}}},
+		"db/dev":  {Fields: map[string]string{"user": "dev"}},
+		"api/key": {Value: "abc"},
+	}
+	for path, secret := range secrets {
+		if err := s.Set(ctx, path, secret); err != nil {
+			t.Fatalf("Failed to set secret: %v", err)
+		}
+	}
+	if err := s.SetAlias(ctx, "db/current", "db/prod"); err != nil {
+		t.Fatalf("Failed to set alias: %v", err)
+	}
+
+	infos, err := s.ListInfo(ctx, "db/")
+	if err != nil {
+		t.Fatalf("ListInfo failed: %v", err)
+	}
+
+	paths, err := s.List(ctx, "db/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(infos) != len(paths) {
+		t.Fatalf("Expected %d infos, got %+v", len(paths), infos)
+	}
+	for i, path := range paths {
+		secret, err := s.Get(ctx, path)
+		if err != nil {
+			t.Fatalf("Failed to get secret: %v", err)
+		}
+		want := vault.InfoOf(path, secret)
+		if target, _ := s.AliasTarget(ctx, path); target != "" {
+			want.Metadata.Extra = map[string]any{AliasKey: target}
+		}
+		if !reflect.DeepEqual(infos[i], want) {
+			t.Errorf("ListInfo[%d] = %+v, want %+v", i, infos[i], want)
+		}
+	}
+
+	// Pages start after the given path and skip dangling aliases without
+	// coming up short
+	page, err := s.ListInfoPage(ctx, "db/", "db/current", 1)
+	if err != nil || len(page) != 1 || page[0].Path != "db/dev" {
+		t.Errorf("Expected a page of db/dev, got %+v, %v", page, err)
+	}
+	if err := s.Delete(ctx, "db/prod"); err != nil {
+		t.Fatalf("Failed to delete secret: %v", err)
+	}
+	page, err = s.ListInfoPage(ctx, "db/", "", 1)
+	if err != nil || len(page) != 1 || page[0].Path != "db/dev" {
+		t.Errorf("Expected the dangling alias to be skipped, got %+v, %v", page, err)
+	}
+
+	if err := s.Lock(); err != nil {
+		t.Fatalf("Failed to lock store: %v", err)
+	}
+	if _, err := s.ListInfo(ctx, ""); err == nil {
+		t.Error("Expected ListInfo to fail on a locked store")
+	}
+}
+
+func TestWALReplayAfterCrash(t *testing.T) {
+	backend := &countingBackend{MemBackend: NewMemBackend()}
+	s := NewEncryptedStoreWithBackend(backend)
+	if err := s.Initialize("password123"); err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
+	if err := s.SetWAL(DefaultWALCompactRecords); err != nil {
+		t.Fatalf("SetWAL failed: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, path := range []string{"db/password", "api/key", "old/token"} {
+		if err := s.Set(ctx, path, &vault.Secret{Value: path}); err != nil {
+			t.Fatalf("Failed to set %s: %v", path, err)
+		}
+	}
+	if err := s.Delete(ctx, "old/token"); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if _, err := s.MovePrefix(ctx, "api/", "svc/", false); err != nil {
+		t.Fatalf("Failed to move: %v", err)
+	}
+	if backend.dataWrites != 1 {
+		t.Errorf("Expected writes to go to the log only, got %d data writes", backend.dataWrites)
+	}
+
+	// Simulate a crash: the store is dropped without locking, leaving a
+	// torn record from an interrupted append
+	if err := backend.AppendWAL([]byte(`{"change":{"set":`)); err != nil {
+		t.Fatalf("AppendWAL failed: %v", err)
+	}
+
+	reopened := NewEncryptedStoreWithBackend(backend)
+	if err := reopened.Unlock("password123"); err != nil {
+		t.Fatalf("Failed to unlock: %v", err)
+	}
+	paths, _ := reopened.List(ctx, "")
+	if want := []string{"db/password", "svc/key"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("Paths after replay = %v, want %v", paths, want)
+	}
+	if secret, err := reopened.Get(ctx, "svc/key"); err != nil || secret.Value != "api/key" {
+		t.Errorf("Expected svc/key to be replayed, got %v, %v", secret, err)
+	}
+
+	// The log was folded into the data
+	if _, err := backend.ReadWAL(); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected the log to be truncated, got %v", err)
+	}
+	again := NewEncryptedStoreWithBackend(backend)
+	if err := again.Unlock("password123"); err != nil {
+		t.Fatalf("Failed to unlock: %v", err)
+	}
+	if count := again.SecretCount(); count != 2 {
+		t.Errorf("Expected 2 secrets in the data, got %d", count)
+	}
+}
+
+func TestWALCompaction(t *testing.T) {
+	s, backend := newTestStore(t)
+	if err := s.SetWAL(3); err != nil {
+		t.Fatalf("SetWAL failed: %v", err)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := s.Set(ctx, fmt.Sprintf("secret/%d", i), &vault.Secret{Value: strconv.Itoa(i)}); err != nil {
+			t.Fatalf("Failed to set secret: %v", err)
+		}
+	}
+	if _, err := backend.ReadWAL(); err != nil {
+		t.Fatalf("Expected a log, got %v", err)
+	}
+
+	// The third write folds the log into the data
+	if err := s.Set(ctx, "secret/2", &vault.Secret{Value: "2"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	if _, err := backend.ReadWAL(); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected the log to be compacted, got %v", err)
+	}
+
+	// Locking folds it in too
+	if err := s.Set(ctx, "secret/3", &vault.Secret{Value: "3"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	if err := s.Lock(); err != nil {
+		t.Fatalf("Failed to lock: %v", err)
+	}
+	if _, err := backend.ReadWAL(); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected the log to be compacted on lock, got %v", err)
+	}
+	if err := s.Unlock("password123"); err != nil {
+		t.Fatalf("Failed to unlock: %v", err)
+	}
+	if count := s.SecretCount(); count != 4 {
+		t.Errorf("Expected 4 secrets, got %d", count)
+	}
+}
+
+func TestWALTampered(t *testing.T) {
+	s, backend := newTestStore(t)
+	if err := s.SetWAL(DefaultWALCompactRecords); err != nil {
+		t.Fatalf("SetWAL failed: %v", err)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := s.Set(ctx, fmt.Sprintf("secret/%d", i), &vault.Secret{Value: strconv.Itoa(i)}); err != nil {
+			t.Fatalf("Failed to set secret: %v", err)
+		}
+	}
+
+	// Dropping a record from the middle breaks the MAC chain
+	log, _ := backend.ReadWAL()
+	lines := strings.SplitAfter(string(log), "\n")
+	_ = backend.TruncateWAL()
+	_ = backend.AppendWAL([]byte(lines[0] + lines[2]))
+
+	reopened := NewEncryptedStoreWithBackend(backend)
+	if err := reopened.Unlock("password123"); !errors.Is(err, ErrTampered) {
+		t.Errorf("Expected ErrTampered, got %v", err)
+	}
+}
+
+func TestTrashDeleteRestore(t *testing.T) {
+	s, _ := newTestStore(t)
+	s.SetTrash(true, 0)
+	ctx := context.Background()
+
+	start := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	s.SetClock(clock.NewFake(start))
+
+	if err := s.Set(ctx, "db/password", &vault.Secret{Value: "hunter2", Metadata: vault.Metadata{Tags: map[string]string{"env": "prod"}}}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := s.Delete(ctx, "db/password"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	if _, err := s.Get(ctx, "db/password"); err != vault.ErrSecretNotFound {
+		t.Errorf("Expected the secret to be gone, got %v", err)
+	}
+	if paths, _ := s.List(ctx, ""); len(paths) != 0 {
+		t.Errorf("Expected trashed secrets to be hidden from List, got %v", paths)
+	}
+	items, err := s.ListTrash(ctx)
+	if err != nil {
+		t.Fatalf("ListTrash failed: %v", err)
+	}
+	if want := []TrashItem{{Path: "db/password", DeletedAt: start}}; !reflect.DeepEqual(items, want) {
+		t.Errorf("ListTrash = %v, want %v", items, want)
+	}
+
+	if err := s.Set(ctx, "db/password", &vault.Secret{Value: "new"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := s.Restore(ctx, "db/password"); !errors.Is(err, vault.ErrAlreadyExists) {
+		t.Errorf("Expected ErrAlreadyExists restoring over a new secret, got %v", err)
+	}
+	if err := s.DeletePermanently(ctx, "db/password"); err != nil {
+		t.Fatalf("DeletePermanently failed: %v", err)
+	}
+
+	if err := s.Restore(ctx, "db/password"); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	secret, err := s.Get(ctx, "db/password")
+	if err != nil || secret.Value != "hunter2" || secret.Metadata.Tags["env"] != "prod" {
+		t.Fatalf("Expected the original secret back, got %+v, %v", secret, err)
+	}
+	if _, ok := secret.Metadata.Extra[DeletedAtKey]; ok {
+		t.Error("Expected the deletion time to be removed on restore")
+	}
+	if items, _ := s.ListTrash(ctx); len(items) != 0 {
+		t.Errorf("Expected an empty trash, got %v", items)
+	}
+	if err := s.Restore(ctx, "db/password"); err != vault.ErrSecretNotFound {
+		t.Errorf("Expected ErrSecretNotFound restoring twice, got %v", err)
+	}
+}
+
+func TestTrashPermanentDelete(t *testing.T) {
+	s, _ := newTestStore(t)
+	s.SetTrash(true, 0)
+	ctx := context.Background()
+
+	for _, path := range []string{"db/password", "db/user", "api/key"} {
+		if err := s.Set(ctx, path, &vault.Secret{Value: path}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	if err := s.DeletePermanently(ctx, "api/key"); err != nil {
+		t.Fatalf("DeletePermanently failed: %v", err)
+	}
+	if deleted, err := s.DeletePrefix(ctx, "db/"); err != nil || deleted != 2 {
+		t.Fatalf("DeletePrefix = %d, %v", deleted, err)
+	}
+	items, _ := s.ListTrash(ctx)
+	if len(items) != 2 || items[0].Path != "db/password" || items[1].Path != "db/user" {
+		t.Errorf("Expected only the db secrets in the trash, got %v", items)
+	}
+
+	// Deleting from the trash is permanent
+	if err := s.Delete(ctx, TrashPrefix+"db/user"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if purged, err := s.PurgeTrash(ctx, ""); err != nil || purged != 1 {
+		t.Errorf("PurgeTrash = %d, %v", purged, err)
+	}
+	if count := s.SecretCount(); count != 0 {
+		t.Errorf("Expected an empty vault, got %d secrets", count)
+	}
+}
+
+func TestTrashRetention(t *testing.T) {
+	s, _ := newTestStore(t)
+	s.SetTrash(true, 30*24*time.Hour)
+	ctx := context.Background()
+
+	clk := clock.NewFake(time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC))
+	s.SetClock(clk)
+
+	for _, path := range []string{"old", "recent"} {
+		if err := s.Set(ctx, path, &vault.Secret{Value: path}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	if err := s.Delete(ctx, "old"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	clk.Advance(20 * 24 * time.Hour)
+	if err := s.Delete(ctx, "recent"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	clk.Advance(10 * 24 * time.Hour)
+	items, _ := s.ListTrash(ctx)
+	if len(items) != 1 || items[0].Path != "recent" {
+		t.Errorf("Expected only the recent secret in the trash, got %v", items)
+	}
+	if err := s.Restore(ctx, "old"); err != vault.ErrSecretNotFound {
+		t.Errorf("Expected an expired secret not to be restorable, got %v", err)
+	}
+
+	// Expired secrets are purged on unlock
+	if err := s.Lock(); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if err := s.Unlock("password123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if count := s.SecretCount(); count != 1 {
+		t.Errorf("Expected the expired secret to be purged, got %d secrets", count)
+	}
+	if purged, err := s.PurgeExpiredTrash(ctx); err != nil || purged != 0 {
+		t.Errorf("PurgeExpiredTrash = %d, %v", purged, err)
+	}
+
+	clk.Advance(20 * 24 * time.Hour)
+	if purged, err := s.PurgeExpiredTrash(ctx); err != nil || purged != 1 {
+		t.Errorf("PurgeExpiredTrash = %d, %v", purged, err)
+	}
+}
+
+func TestFileBackendDurable(t *testing.T) {
+	dir := t.TempDir()
+	backend := NewFileBackend(filepath.Join(dir, "vault.enc"), filepath.Join(dir, "vault.meta")).(*fileBackend)
+	syncs := 0
+	backend.sync = func(f *os.File) error {
+		syncs++
+		return f.Sync()
+	}
+
+	s := NewEncryptedStoreWithBackend(backend)
+	if err := s.Initialize("password123"); err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
+	ctx := context.Background()
+
+	syncs = 0
+	if err := s.Set(ctx, "api/key", &vault.Secret{Value: "v1"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	// The data file and its directory; the metadata is unchanged
+	if syncs != 2 {
+		t.Errorf("Expected the data file and its directory to be synced, got %d syncs", syncs)
+	}
+
+	s.SetDurable(false)
+	syncs = 0
+	if err := s.Set(ctx, "api/key", &vault.Secret{Value: "v2"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	if syncs != 0 || backend.sync != nil {
+		t.Errorf("Expected no syncs with durability off, got %d", syncs)
+	}
+
+	// Writes replace the files atomically and leave no temporary files
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read dir: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected only the vault files, got %v", entries)
+	}
+
+	reopened := NewEncryptedStore(filepath.Join(dir, "vault.enc"), filepath.Join(dir, "vault.meta"))
+	if err := reopened.Unlock("password123"); err != nil {
+		t.Fatalf("Failed to unlock: %v", err)
+	}
+	if secret, err := reopened.Get(ctx, "api/key"); err != nil || secret.Value != "v2" {
+		t.Errorf("Expected the last write to persist, got %v, %v", secret, err)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "api/key", &vault.Secret{Value: "v1", Fields: map[string]string{"user": "app"}}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+
+	rotated, err := s.Rotate(ctx, "api/key")
+	if err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+	if rotated.Value == "v1" || len(rotated.Value) < 40 || rotated.Metadata.Version != "2" {
+		t.Errorf("Expected a new random value as version 2, got %q version %q", rotated.Value, rotated.Metadata.Version)
+	}
+
+	current, err := s.Get(ctx, "api/key")
+	if err != nil || current.Value != rotated.Value || current.Fields["user"] != "app" {
+		t.Errorf("Expected the rotated value with fields kept, got %v, %v", current, err)
+	}
+
+	previous, err := s.GetVersion(ctx, "api/key", "1")
+	if err != nil {
+		t.Fatalf("Failed to get version 1: %v", err)
+	}
+	if previous.Value != "v1" || previous.Metadata.Version != "1" {
+		t.Errorf("Expected version 1 to hold the old value, got %q version %q", previous.Value, previous.Metadata.Version)
+	}
+	if _, err := s.GetVersion(ctx, "api/key", "3"); !errors.Is(err, vault.ErrVersionNotFound) {
+		t.Errorf("Expected ErrVersionNotFound, got %v", err)
+	}
+
+	versions, err := s.ListVersions(ctx, "api/key")
+	if err != nil {
+		t.Fatalf("Failed to list versions: %v", err)
+	}
+	if len(versions) != 2 || versions[0].ID != "1" || versions[0].Current || versions[1].ID != "2" || !versions[1].Current {
+		t.Errorf("Unexpected versions: %+v", versions)
+	}
+}
+
+func TestRegisterRotator(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "db/password", &vault.Secret{Value: "pw-1"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	s.RegisterRotator("db/password", func(_ context.Context, _ string, current *vault.Secret) (string, error) {
+		n, _ := strconv.Atoi(strings.TrimPrefix(current.Value, "pw-"))
+		return fmt.Sprintf("pw-%d", n+1), nil
+	})
+
+	for i := 0; i < MaxPreviousVersions+2; i++ {
+		if _, err := s.Rotate(ctx, "db/password"); err != nil {
+			t.Fatalf("Failed to rotate: %v", err)
+		}
+	}
+
+	current, _ := s.Get(ctx, "db/password")
+	if want := fmt.Sprintf("pw-%d", MaxPreviousVersions+3); current.Value != want {
+		t.Errorf("Expected %s, got %s", want, current.Value)
+	}
+	versions, _ := s.ListVersions(ctx, "db/password")
+	if len(versions) != MaxPreviousVersions+1 {
+		t.Errorf("Expected %d versions to be kept, got %d", MaxPreviousVersions+1, len(versions))
+	}
+	if _, err := s.GetVersion(ctx, "db/password", "1"); !errors.Is(err, vault.ErrVersionNotFound) {
+		t.Errorf("Expected the oldest version to be dropped, got %v", err)
+	}
+
+	failing := errors.New("generator failed")
+	s.RegisterRotator("db/password", func(context.Context, string, *vault.Secret) (string, error) {
+		return "", failing
+	})
+	if _, err := s.Rotate(ctx, "db/password"); !errors.Is(err, failing) {
+		t.Errorf("Expected the generator error, got %v", err)
+	}
+	if after, _ := s.Get(ctx, "db/password"); after.Value != current.Value {
+		t.Error("Expected a failed rotation to keep the value")
+	}
+}
+
+func TestTrackAccess(t *testing.T) {
+	s, backend := newTestStore(t)
+	s.SetTrackAccess(true)
+	ctx := context.Background()
+
+	readAt := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(readAt)
+	s.SetClock(clk)
+
+	if err := s.Set(ctx, "api/key", &vault.Secret{Value: "v"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := s.Set(ctx, "api/unread", &vault.Secret{Value: "v"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	secret, err := s.Get(ctx, "api/key")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if secret.Metadata.LastAccessedAt == nil || !secret.Metadata.LastAccessedAt.Equal(readAt) {
+		t.Errorf("Expected LastAccessedAt %v, got %v", readAt, secret.Metadata.LastAccessedAt)
+	}
+
+	// Inspecting a secret is not a read
+	clk.Advance(time.Hour)
+	if _, err := s.Get(WithoutAccessTracking(ctx), "api/key"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	infos, err := s.ListInfo(ctx, "api/")
+	if err != nil {
+		t.Fatalf("ListInfo failed: %v", err)
+	}
+	for _, info := range infos {
+		got := info.Metadata.LastAccessedAt
+		switch info.Path {
+		case "api/key":
+			if got == nil || !got.Equal(readAt) {
+				t.Errorf("Expected the unsaved read to be listed at %v, got %v", readAt, got)
+			}
+		case "api/unread":
+			if got != nil {
+				t.Errorf("Expected an unread secret to have no LastAccessedAt, got %v", got)
+			}
+		}
+	}
+
+	// Reads are saved when the vault locks
+	if err := s.Lock(); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	reopened := NewEncryptedStoreWithBackend(backend)
+	if err := reopened.Unlock("password123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	secret, err = reopened.Get(ctx, "api/key")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if secret.Metadata.LastAccessedAt == nil || !secret.Metadata.LastAccessedAt.Equal(readAt) {
+		t.Errorf("Expected the read to be saved, got %v", secret.Metadata.LastAccessedAt)
+	}
+}
+
+func TestTrackAccessDisabled(t *testing.T) {
+	s, backend := newTestStore(t)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "api/key", &vault.Secret{Value: "v"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	saved := backend.data
+
+	secret, err := s.Get(ctx, "api/key")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if secret.Metadata.LastAccessedAt != nil {
+		t.Errorf("Expected no LastAccessedAt, got %v", secret.Metadata.LastAccessedAt)
+	}
+
+	if err := s.FlushAccess(ctx); err != nil {
+		t.Fatalf("FlushAccess failed: %v", err)
+	}
+	if !bytes.Equal(backend.data, saved) {
+		t.Error("Expected a read not to rewrite the vault")
+	}
+}
+
+func TestEncryptedStoreWithLockSerializes(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+	if err := s.Set(ctx, "counter", &vault.Secret{Value: "0"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	increment := func() error {
+		return s.WithLock(ctx, func(tx Tx) error {
+			secret, err := tx.Get(ctx, "counter")
+			if err != nil {
+				return err
+			}
+			n, err := strconv.Atoi(secret.Value)
+			if err != nil {
+				return err
+			}
+			// Give the other transaction a chance to interleave
+			time.Sleep(10 * time.Millisecond)
+			return tx.Set(ctx, "counter", &vault.Secret{Value: strconv.Itoa(n + 1)})
+		})
+	}
+
+	const workers = 5
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		go func() { errs <- increment() }()
+	}
+	for i := 0; i < workers; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("WithLock failed: %v", err)
+		}
+	}
+
+	if got, _ := s.Get(ctx, "counter"); got == nil || got.Value != strconv.Itoa(workers) {
+		t.Errorf("Expected every increment to be kept, got %+v", got)
+	}
+}
+
+func TestEncryptedStoreWithLockCommitsOnce(t *testing.T) {
+	backend := &countingBackend{MemBackend: NewMemBackend()}
+	s := NewEncryptedStoreWithBackend(backend)
+	if err := s.Initialize("password123"); err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
+	ctx := context.Background()
+	if err := s.Set(ctx, "old", &vault.Secret{Value: "x"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	writes := backend.dataWrites
+
+	err := s.WithLock(ctx, func(tx Tx) error {
+		for i := 0; i < 3; i++ {
+			if err := tx.Set(ctx, fmt.Sprintf("new/%d", i), &vault.Secret{Value: strconv.Itoa(i)}); err != nil {
+				return err
+			}
+		}
+		if got, err := tx.Get(ctx, "new/2"); err != nil || got.Value != "2" {
+			t.Errorf("Expected the transaction to see its own write, got %v, %v", got, err)
+		}
+		return tx.Delete(ctx, "old")
+	})
+	if err != nil {
+		t.Fatalf("WithLock failed: %v", err)
+	}
+	if n := backend.dataWrites - writes; n != 1 {
+		t.Errorf("Expected 1 save, got %d", n)
+	}
+
+	reopened := NewEncryptedStoreWithBackend(backend.MemBackend)
+	if err := reopened.Unlock("password123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	paths, _ := reopened.List(ctx, "")
+	if want := []string{"new/0", "new/1", "new/2"}; fmt.Sprint(paths) != fmt.Sprint(want) {
+		t.Errorf("Expected the committed changes to be saved, got %v", paths)
+	}
+}
+
+func TestEncryptedStoreWithLockRollsBack(t *testing.T) {
+	backend := &countingBackend{MemBackend: NewMemBackend()}
+	s := NewEncryptedStoreWithBackend(backend)
+	if err := s.Initialize("password123"); err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
+	ctx := context.Background()
+	for path, value := range map[string]string{"a": "1", "b": "2"} {
+		if err := s.Set(ctx, path, &vault.Secret{Value: value}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	writes := backend.dataWrites
+
+	errFailed := errors.New("failed")
+	var leaked Tx
+	err := s.WithLock(ctx, func(tx Tx) error {
+		leaked = tx
+		if err := tx.Set(ctx, "a", &vault.Secret{Value: "changed"}); err != nil {
+			return err
+		}
+		if err := tx.Set(ctx, "c", &vault.Secret{Value: "3"}); err != nil {
+			return err
+		}
+		if err := tx.Delete(ctx, "b"); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("Expected the transaction's error, got %v", err)
+	}
+	if backend.dataWrites != writes {
+		t.Errorf("Expected nothing to be saved, got %d saves", backend.dataWrites-writes)
+	}
+
+	paths, _ := s.List(ctx, "")
+	if want := []string{"a", "b"}; fmt.Sprint(paths) != fmt.Sprint(want) {
+		t.Errorf("Expected the paths to be rolled back, got %v", paths)
+	}
+	if got, _ := s.Get(ctx, "a"); got == nil || got.Value != "1" {
+		t.Errorf("Expected a to be rolled back, got %+v", got)
+	}
+
+	if err := leaked.Set(ctx, "a", &vault.Secret{Value: "late"}); !errors.Is(err, ErrTxDone) {
+		t.Errorf("Expected ErrTxDone after the transaction, got %v", err)
+	}
+
+	// A panic rolls back too
+	func() {
+		defer func() { _ = recover() }()
+		_ = s.WithLock(ctx, func(tx Tx) error {
+			_ = tx.Delete(ctx, "a")
+			panic("boom")
+		})
+	}()
+	if exists, _ := s.Exists(ctx, "a"); !exists {
+		t.Error("Expected a panicking transaction to be rolled back")
+	}
+}
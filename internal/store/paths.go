@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NormalizePath returns path without leading or trailing slashes and with
+// repeated slashes collapsed, so "/db//password/" becomes "db/password".
+func NormalizePath(path string) string {
+	parts := strings.Split(path, "/")
+	kept := parts[:0]
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, "/")
+}
+
+// normalizePrefix normalizes a List prefix, keeping a trailing slash so
+// that "db/" still only matches paths under db.
+func normalizePrefix(prefix string) string {
+	normalized := NormalizePath(prefix)
+	if normalized != "" && strings.HasSuffix(prefix, "/") {
+		normalized += "/"
+	}
+	return normalized
+}
+
+// SetNormalizePaths enables path normalization for vaults created before
+// it became the default. Vaults created with Initialize, or migrated with
+// MigratePaths, always normalize paths.
+func (s *EncryptedStore) SetNormalizePaths(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.normalizePaths = enabled
+}
+
+// normalizing reports whether paths are normalized. Callers must hold s.mu.
+func (s *EncryptedStore) normalizing() bool {
+	return s.normalizePaths || (s.meta != nil && s.meta.NormalizePaths)
+}
+
+// cleanPath normalizes path if normalization is enabled. Callers must hold s.mu.
+func (s *EncryptedStore) cleanPath(path string) string {
+	if s.normalizing() {
+		return NormalizePath(path)
+	}
+	return path
+}
+
+// MigratePaths renames every secret to its normalized path and enables
+// normalization for the vault. It returns the renamed paths, old to new.
+// If several secrets normalize to the same path nothing is changed and an
+// error lists the conflicting paths, which must be resolved by hand.
+func (s *EncryptedStore) MigratePaths(ctx context.Context) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isLockedUnsafe() {
+		return nil, errors.New("vault is locked")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sources := make(map[string][]string)
+	for path := range s.data.Secrets {
+		normalized := NormalizePath(path)
+		sources[normalized] = append(sources[normalized], path)
+	}
+
+	var conflicts []string
+	for normalized, paths := range sources {
+		if len(paths) > 1 {
+			sort.Strings(paths)
+			conflicts = append(conflicts, fmt.Sprintf("%s (%s)", normalized, strings.Join(paths, ", ")))
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return nil, fmt.Errorf("paths normalize to the same secret: %s", strings.Join(conflicts, "; "))
+	}
+
+	renamed := make(map[string]string)
+	for normalized, paths := range sources {
+		if paths[0] != normalized {
+			renamed[paths[0]] = normalized
+		}
+	}
+	for old, normalized := range renamed {
+		s.data.Secrets[normalized] = s.data.Secrets[old]
+		delete(s.data.Secrets, old)
+	}
+
+	// Aliases must point at the normalized paths too
+	for path := range s.data.Secrets {
+		secret, err := s.decrypt(path)
+		if err != nil {
+			return nil, err
+		}
+		target := aliasOf(secret)
+		if target == "" || target == NormalizePath(target) {
+			continue
+		}
+		secret.Metadata.Extra[AliasKey] = NormalizePath(target)
+		if err := s.encrypt(path, secret); err != nil {
+			return nil, err
+		}
+	}
+
+	s.meta.NormalizePaths = true
+	s.dirty = true
+	if err := s.saveData(context.WithoutCancel(ctx)); err != nil {
+		return nil, err
+	}
+
+	return renamed, nil
+}
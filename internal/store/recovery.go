@@ -0,0 +1,216 @@
+package store
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Recovery key errors.
+var (
+	// ErrInvalidRecoveryKey is returned when a recovery key is malformed
+	// or does not unlock the vault
+	ErrInvalidRecoveryKey = errors.New("invalid recovery key")
+
+	// ErrNoRecoveryKey is returned when unlocking a vault that has no
+	// recovery key with one
+	ErrNoRecoveryKey = errors.New("vault has no recovery key")
+)
+
+const (
+	// recoveryKeyBytes is the entropy of a recovery key
+	recoveryKeyBytes = 20
+
+	recoveryKeyContext = "omnivault-recovery-v1"
+)
+
+// NewRecoveryKey generates a random recovery key, formatted as dash
+// separated groups of four base32 characters for writing down.
+func NewRecoveryKey() (string, error) {
+	raw, err := GenerateRandomBytes(recoveryKeyBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate recovery key: %w", err)
+	}
+
+	encoded := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+	groups := make([]string, 0, len(encoded)/4)
+	for i := 0; i < len(encoded); i += 4 {
+		groups = append(groups, encoded[i:i+4])
+	}
+	return strings.Join(groups, "-"), nil
+}
+
+// recoveryKEK derives the key that wraps the data key from a recovery
+// key. Case, dashes, and spaces are ignored. Recovery keys are random, so
+// unlike passwords they need no slow key derivation.
+func recoveryKEK(recoveryKey string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(recoveryKey)))
+	raw, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(normalized)
+	if err != nil || len(raw) != recoveryKeyBytes {
+		return nil, ErrInvalidRecoveryKey
+	}
+	defer zero(raw)
+
+	mac := hmac.New(sha256.New, raw)
+	mac.Write([]byte(recoveryKeyContext))
+	return mac.Sum(nil), nil
+}
+
+// SetupRecovery generates a new recovery key that can unlock the vault
+// instead of the master password, replacing any previous one, and returns
+// it. The key is not stored; it must be shown to the user to keep offline.
+// Changing the password keeps the recovery key valid.
+func (s *EncryptedStore) SetupRecovery(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isLockedUnsafe() {
+		return "", errors.New("vault is locked")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	recoveryKey, err := NewRecoveryKey()
+	if err != nil {
+		return "", err
+	}
+	kek, err := recoveryKEK(recoveryKey)
+	if err != nil {
+		return "", err
+	}
+	defer zero(kek)
+
+	meta := *s.meta
+	if err := wrapForRecovery(&meta, kek, s.crypto); err != nil {
+		return "", err
+	}
+
+	old := s.meta
+	s.meta = &meta
+	if err := s.saveMeta(); err != nil {
+		s.meta = old
+		return "", fmt.Errorf("failed to save metadata: %w", err)
+	}
+
+	return recoveryKey, nil
+}
+
+// wrapForRecovery stores the data key of c wrapped with the recovery
+// key's kek in meta, and kek itself encrypted with the data key so the
+// data key can be re-wrapped when it is rotated.
+func wrapForRecovery(meta *VaultMeta, kek []byte, c *Crypto) error {
+	wrapped, err := seal(kek, c.key)
+	if err != nil {
+		return fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	sealedKEK, err := seal(c.key, kek)
+	if err != nil {
+		return fmt.Errorf("failed to wrap recovery key: %w", err)
+	}
+
+	meta.RecoveryWrappedKey = wrapped
+	meta.RecoveryKEK = sealedKEK
+	return nil
+}
+
+// UnlockWithRecoveryKey unlocks the vault with its recovery key instead of
+// the master password. The password is not recovered, so until it is
+// replaced with ResetPassword, operations that need it fail.
+func (s *EncryptedStore) UnlockWithRecoveryKey(recoveryKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.VaultExists() {
+		return errors.New("vault does not exist, run init first")
+	}
+
+	if err := s.loadMeta(); err != nil {
+		return fmt.Errorf("failed to load metadata: %w", err)
+	}
+	if s.meta.RecoveryWrappedKey == "" {
+		return ErrNoRecoveryKey
+	}
+
+	kek, err := recoveryKEK(recoveryKey)
+	if err != nil {
+		return err
+	}
+	defer zero(kek)
+
+	dataKey, err := open(kek, s.meta.RecoveryWrappedKey)
+	if err != nil {
+		return ErrInvalidRecoveryKey
+	}
+
+	crypto, err := NewCrypto(s.meta.Salt, s.meta.Argon2Params)
+	if err != nil {
+		zero(dataKey)
+		return fmt.Errorf("failed to create crypto: %w", err)
+	}
+	crypto.key = dataKey
+	s.crypto = crypto
+	s.recovered = true
+	s.unlockTime = s.clock.Now()
+
+	unwrapPending := func(p *PendingKey) ([]byte, error) {
+		return open(kek, p.RecoveryWrappedKey)
+	}
+	if err := s.loadData(context.Background(), unwrapPending); err != nil {
+		s.crypto.Lock()
+		s.crypto = nil
+		s.recovered = false
+		return fmt.Errorf("failed to load vault data: %w", err)
+	}
+
+	// Expired trash is dropped now and saved with the next write
+	_, _ = s.purgeExpiredTrash()
+
+	return nil
+}
+
+// Recovered reports whether the vault was unlocked with its recovery key
+// and the password has not been reset since.
+func (s *EncryptedStore) Recovered() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.recovered && !s.isLockedUnsafe()
+}
+
+// ResetPassword sets a new master password without the current one. It
+// only works after UnlockWithRecoveryKey; otherwise use ChangePassword.
+// The recovery key stays valid.
+func (s *EncryptedStore) ResetPassword(ctx context.Context, newPassword string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isLockedUnsafe() {
+		return errors.New("vault is locked")
+	}
+	if !s.recovered {
+		return errors.New("current password is required")
+	}
+
+	return s.replacePassword(ctx, newPassword)
+}
+
+// rewrapRecovery re-wraps a rotated data key for the recovery key, if the
+// vault has one, by recovering the recovery kek with the old data key.
+func rewrapRecovery(meta *VaultMeta, oldCrypto, newCrypto *Crypto) error {
+	if meta.RecoveryKEK == "" {
+		return nil
+	}
+
+	kek, err := open(oldCrypto.key, meta.RecoveryKEK)
+	if err != nil {
+		return fmt.Errorf("failed to unwrap recovery key: %w", err)
+	}
+	defer zero(kek)
+
+	return wrapForRecovery(meta, kek, newCrypto)
+}
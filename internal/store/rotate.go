@@ -0,0 +1,236 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// VersionsKey is the Metadata.Extra key holding the previous versions of a
+// rotated secret, oldest first.
+const VersionsKey = "versions"
+
+// MaxPreviousVersions is the number of previous versions kept for a
+// rotated secret. Older versions are dropped.
+const MaxPreviousVersions = 10
+
+// rotatedValueBytes is the entropy of values generated by DefaultRotator.
+const rotatedValueBytes = 32
+
+// RotatorFunc generates the new value of the secret at path when it is
+// rotated. current is a copy of the secret being replaced. It is called
+// while the store is locked, so it must not call back into the store.
+type RotatorFunc func(ctx context.Context, path string, current *vault.Secret) (string, error)
+
+// DefaultRotator generates a random, URL-safe value with 256 bits of
+// entropy. It is used for paths without a registered rotator.
+func DefaultRotator(_ context.Context, _ string, _ *vault.Secret) (string, error) {
+	raw, err := GenerateRandomBytes(rotatedValueBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// previousVersion is a previous value of a rotated secret.
+type previousVersion struct {
+	ID        string           `json:"id"`
+	Value     string           `json:"value"`
+	CreatedAt *vault.Timestamp `json:"created_at,omitempty"`
+}
+
+// RegisterRotator sets the function generating new values for the secret
+// at path when it is rotated. A nil fn restores DefaultRotator.
+func (s *EncryptedStore) RegisterRotator(path string, fn RotatorFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path = s.cleanPath(path)
+	if fn == nil {
+		delete(s.rotators, path)
+		return
+	}
+	if s.rotators == nil {
+		s.rotators = make(map[string]RotatorFunc)
+	}
+	s.rotators[path] = fn
+}
+
+// Rotate replaces the value of the secret at path with one generated by
+// its rotator and returns the new version. The previous value is kept and
+// can be read with GetVersion. Versions are numbered from 1, the version
+// of a secret that was never rotated.
+func (s *EncryptedStore) Rotate(ctx context.Context, path string) (*vault.Secret, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isLockedUnsafe() {
+		return nil, errors.New("vault is locked")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	path, err := s.resolvePath(s.cleanPath(path))
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.decrypt(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rotate := s.rotators[path]
+	if rotate == nil {
+		rotate = DefaultRotator
+	}
+	value, err := rotate(ctx, path, current.Clone())
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate %s: %w", path, err)
+	}
+
+	versions, err := previousVersions(current)
+	if err != nil {
+		return nil, err
+	}
+	id := currentVersion(current)
+	versions = append(versions, previousVersion{ID: id, Value: current.String(), CreatedAt: current.Metadata.ModifiedAt})
+	if len(versions) > MaxPreviousVersions {
+		versions = versions[len(versions)-MaxPreviousVersions:]
+	}
+	n, _ := strconv.Atoi(id)
+
+	rotated := current.Clone()
+	rotated.Value = value
+	rotated.ValueBytes = nil
+	rotated.Metadata.Version = strconv.Itoa(n + 1)
+	rotated.Metadata.ModifiedAt = vault.NewTimestamp(s.clock.Now())
+	if rotated.Metadata.Extra == nil {
+		rotated.Metadata.Extra = make(map[string]any, 1)
+	}
+	rotated.Metadata.Extra[VersionsKey] = versions
+
+	if err := s.encrypt(path, rotated); err != nil {
+		return nil, err
+	}
+
+	if s.autoSave {
+		if err := s.commit(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return rotated, nil
+}
+
+// GetVersion returns the given version of the secret at path: its current
+// version or one of the previous versions kept by Rotate. It returns
+// vault.ErrVersionNotFound if the version is not kept.
+func (s *EncryptedStore) GetVersion(ctx context.Context, path, version string) (*vault.Secret, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.isLockedUnsafe() {
+		return nil, errors.New("vault is locked")
+	}
+
+	resolved, err := s.resolvePath(s.cleanPath(path))
+	if err != nil {
+		return nil, err
+	}
+	secret, err := s.decrypt(resolved)
+	if err != nil {
+		return nil, err
+	}
+	if version == currentVersion(secret) {
+		return secret, nil
+	}
+
+	versions, err := previousVersions(secret)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v.ID == version {
+			previous := secret.Clone()
+			previous.Value = v.Value
+			previous.ValueBytes = nil
+			previous.Metadata.Version = v.ID
+			previous.Metadata.ModifiedAt = v.CreatedAt
+			delete(previous.Metadata.Extra, VersionsKey)
+			return previous, nil
+		}
+	}
+	return nil, vault.ErrVersionNotFound
+}
+
+// ListVersions returns the versions of the secret at path kept by Rotate,
+// oldest first. The last one is the current version.
+func (s *EncryptedStore) ListVersions(ctx context.Context, path string) ([]vault.Version, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.isLockedUnsafe() {
+		return nil, errors.New("vault is locked")
+	}
+
+	resolved, err := s.resolvePath(s.cleanPath(path))
+	if err != nil {
+		return nil, err
+	}
+	secret, err := s.decrypt(resolved)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := previousVersions(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]vault.Version, 0, len(previous)+1)
+	for _, v := range previous {
+		versions = append(versions, vault.Version{ID: v.ID, CreatedAt: v.CreatedAt})
+	}
+	versions = append(versions, vault.Version{
+		ID:        currentVersion(secret),
+		CreatedAt: secret.Metadata.ModifiedAt,
+		Current:   true,
+	})
+	return versions, nil
+}
+
+// currentVersion returns the version of a secret, "1" if it was never
+// rotated.
+func currentVersion(secret *vault.Secret) string {
+	if secret.Metadata.Version == "" {
+		return "1"
+	}
+	return secret.Metadata.Version
+}
+
+// previousVersions returns the previous versions kept in a secret's
+// metadata, which are decoded as generic JSON when the secret is loaded.
+func previousVersions(secret *vault.Secret) ([]previousVersion, error) {
+	raw, ok := secret.Metadata.Extra[VersionsKey]
+	if !ok {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var versions []previousVersion
+	if err := json.Unmarshal(data, &versions); err != nil {
+		return nil, fmt.Errorf("invalid versions of %s: %w", secret.Metadata.Path, err)
+	}
+	return versions, nil
+}
+
+// Ensure EncryptedStore implements vault.ExtendedVault.
+var _ vault.ExtendedVault = (*EncryptedStore)(nil)
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// ValidationError is returned by Set when a secret lacks fields required
+// for its path prefix.
+type ValidationError struct {
+	Path    string
+	Missing []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("secret %q is missing required fields: %s", e.Path, strings.Join(e.Missing, ", "))
+}
+
+// SetRequiredFields configures the fields that secrets must have, keyed by
+// path prefix. A secret must have every field required by each prefix that
+// matches its path. A nil or empty map disables validation, which is the
+// default.
+func (s *EncryptedStore) SetRequiredFields(required map[string][]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requiredFields = make(map[string][]string, len(required))
+	for prefix, fields := range required {
+		s.requiredFields[prefix] = append([]string(nil), fields...)
+	}
+}
+
+// validate checks the secret against the required fields for path.
+// Callers must hold s.mu.
+func (s *EncryptedStore) validate(path string, secret *vault.Secret) error {
+	var missing []string
+	seen := make(map[string]bool)
+	for prefix, fields := range s.requiredFields {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		for _, field := range fields {
+			if seen[field] {
+				continue
+			}
+			seen[field] = true
+			if secret.Fields[field] == "" {
+				missing = append(missing, field)
+			}
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return &ValidationError{Path: path, Missing: missing}
+}
@@ -0,0 +1,259 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// TrashPrefix is the namespace deleted secrets are moved to when soft
+// delete is enabled (see SetTrash). A trashed secret keeps its path below
+// it, so "db/password" is trashed as ".trash/db/password".
+const TrashPrefix = ".trash/"
+
+// DeletedAtKey is the Metadata.Extra key holding when a trashed secret was
+// deleted, in RFC 3339 format.
+const DeletedAtKey = "deleted_at"
+
+// TrashItem describes a secret in the trash.
+type TrashItem struct {
+	Path      string // path the secret was deleted from
+	DeletedAt time.Time
+}
+
+// SetTrash enables soft delete: Delete and DeletePrefix move secrets into
+// the trash instead of removing them, and Restore brings them back.
+// Trashed secrets are purged once they have been in the trash for
+// retention, when the vault is unlocked or another secret is deleted. A
+// retention of zero or less keeps them until PurgeTrash is called.
+func (s *EncryptedStore) SetTrash(enabled bool, retention time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trash = enabled
+	s.trashRetention = retention
+}
+
+// inTrash reports whether path is in the trash.
+func inTrash(path string) bool {
+	return strings.HasPrefix(path, TrashPrefix)
+}
+
+// listed reports whether path is listed for prefix. Trashed secrets are
+// only listed when prefix is in the trash.
+func listed(path, prefix string) bool {
+	if inTrash(path) && !inTrash(prefix) {
+		return false
+	}
+	return prefix == "" || strings.HasPrefix(path, prefix)
+}
+
+// deletedAt returns when a trashed secret was deleted.
+func deletedAt(secret *vault.Secret) (time.Time, bool) {
+	value, _ := secret.Metadata.Extra[DeletedAtKey].(string)
+	t, err := time.Parse(time.RFC3339Nano, value)
+	return t, err == nil
+}
+
+// trashExpired reports whether a secret deleted at t is past the trash
+// retention. Callers must hold s.mu.
+func (s *EncryptedStore) trashExpired(t time.Time) bool {
+	return s.trashRetention > 0 && !s.clock.Now().Before(t.Add(s.trashRetention))
+}
+
+// trashSecret moves the secret at path into the trash, replacing any
+// earlier version trashed from the same path. Callers must hold s.mu.
+func (s *EncryptedStore) trashSecret(path string) error {
+	secret, err := s.decrypt(path)
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if secret.Metadata.Extra == nil {
+		secret.Metadata.Extra = make(map[string]any, 1)
+	}
+	secret.Metadata.Extra[DeletedAtKey] = s.clock.Now().UTC().Format(time.RFC3339Nano)
+	if err := s.encrypt(TrashPrefix+path, secret); err != nil {
+		return err
+	}
+
+	delete(s.data.Secrets, path)
+	s.markChanged(path)
+	return nil
+}
+
+// purgeExpiredTrash removes the trashed secrets that are past the trash
+// retention and returns the number removed. Callers must hold s.mu.
+func (s *EncryptedStore) purgeExpiredTrash() (int, error) {
+	if s.trashRetention <= 0 {
+		return 0, nil
+	}
+
+	purged := 0
+	for path := range s.data.Secrets {
+		if !inTrash(path) {
+			continue
+		}
+		secret, err := s.decrypt(path)
+		if err != nil {
+			return purged, err
+		}
+		if t, ok := deletedAt(secret); ok && s.trashExpired(t) {
+			delete(s.data.Secrets, path)
+			s.markChanged(path)
+			purged++
+		}
+	}
+	if purged > 0 {
+		s.dirty = true
+	}
+	return purged, nil
+}
+
+// PurgeExpiredTrash removes the trashed secrets that are past the trash
+// retention and returns the number removed.
+func (s *EncryptedStore) PurgeExpiredTrash(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isLockedUnsafe() {
+		return 0, errors.New("vault is locked")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	purged, err := s.purgeExpiredTrash()
+	if err != nil || purged == 0 {
+		return purged, err
+	}
+
+	if s.autoSave {
+		return purged, s.commit(ctx)
+	}
+
+	return purged, nil
+}
+
+// ListTrash returns the secrets in the trash, sorted by the path they were
+// deleted from. Secrets past the trash retention are left out.
+func (s *EncryptedStore) ListTrash(ctx context.Context) ([]TrashItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.isLockedUnsafe() {
+		return nil, errors.New("vault is locked")
+	}
+
+	items := []TrashItem{}
+	for path := range s.data.Secrets {
+		if !inTrash(path) {
+			continue
+		}
+		secret, err := s.decrypt(path)
+		if err != nil {
+			return nil, err
+		}
+		t, _ := deletedAt(secret)
+		if s.trashExpired(t) {
+			continue
+		}
+		items = append(items, TrashItem{Path: strings.TrimPrefix(path, TrashPrefix), DeletedAt: t})
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Path < items[j].Path })
+	return items, nil
+}
+
+// Restore moves a trashed secret back to the path it was deleted from. It
+// returns vault.ErrSecretNotFound if no secret from path is in the trash,
+// and an error wrapping vault.ErrAlreadyExists if path has been reused.
+func (s *EncryptedStore) Restore(ctx context.Context, path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isLockedUnsafe() {
+		return errors.New("vault is locked")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	path = s.cleanPath(path)
+	trashPath := TrashPrefix + path
+	secret, err := s.decrypt(trashPath)
+	if err != nil {
+		return err
+	}
+	if t, _ := deletedAt(secret); s.trashExpired(t) {
+		return vault.ErrSecretNotFound
+	}
+	if _, exists := s.data.Secrets[path]; exists {
+		return fmt.Errorf("%w: %s", vault.ErrAlreadyExists, path)
+	}
+
+	delete(secret.Metadata.Extra, DeletedAtKey)
+	if err := s.encrypt(path, secret); err != nil {
+		return err
+	}
+	delete(s.data.Secrets, trashPath)
+	s.markChanged(trashPath)
+
+	if s.autoSave {
+		return s.commit(ctx)
+	}
+
+	return nil
+}
+
+// PurgeTrash permanently removes the secret trashed from path, or every
+// trashed secret if path is empty, and returns the number removed.
+func (s *EncryptedStore) PurgeTrash(ctx context.Context, path string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isLockedUnsafe() {
+		return 0, errors.New("vault is locked")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	if path != "" {
+		trashPath := TrashPrefix + s.cleanPath(path)
+		if _, ok := s.data.Secrets[trashPath]; ok {
+			delete(s.data.Secrets, trashPath)
+			s.markChanged(trashPath)
+			purged++
+		}
+	} else {
+		for trashPath := range s.data.Secrets {
+			if inTrash(trashPath) {
+				delete(s.data.Secrets, trashPath)
+				s.markChanged(trashPath)
+				purged++
+			}
+		}
+	}
+	if purged == 0 {
+		return 0, nil
+	}
+	s.dirty = true
+
+	if s.autoSave {
+		return purged, s.commit(ctx)
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"maps"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// ErrTxDone is returned by the operations of a Tx used after its WithLock
+// call has returned.
+var ErrTxDone = errors.New("transaction has already finished")
+
+// Tx is a transaction started by WithLock. Its operations behave like the
+// store's, see the transaction's own writes, and are saved together when
+// the transaction commits. A Tx must not be used after WithLock returns or
+// from several goroutines at once.
+type Tx interface {
+	Get(ctx context.Context, path string) (*vault.Secret, error)
+	Set(ctx context.Context, path string, secret *vault.Secret) error
+	Delete(ctx context.Context, path string) error
+}
+
+// WithLock runs fn with a transaction that holds the store's lock for its
+// whole duration, so read-modify-write sequences can't interleave with
+// other writers. If fn returns nil the changes are saved once; if fn
+// returns an error or panics, or saving fails, every change is rolled
+// back. fn must not call the store's own methods, which would deadlock.
+func (s *EncryptedStore) WithLock(ctx context.Context, fn func(tx Tx) error) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isLockedUnsafe() {
+		return errors.New("vault is locked")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	secrets, changed, dirty := maps.Clone(s.data.Secrets), maps.Clone(s.changed), s.dirty
+	t := &tx{s: s}
+	defer func() {
+		t.done = true
+		p := recover()
+		if err != nil || p != nil {
+			s.data.Secrets, s.changed, s.dirty = secrets, changed, dirty
+		}
+		if p != nil {
+			panic(p)
+		}
+	}()
+
+	if err := fn(t); err != nil {
+		return err
+	}
+	if !t.wrote || !s.autoSave {
+		return nil
+	}
+	return s.commit(ctx)
+}
+
+// tx implements Tx. Its store's lock is held while it is in use.
+type tx struct {
+	s     *EncryptedStore
+	done  bool
+	wrote bool
+}
+
+func (t *tx) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	if t.done {
+		return nil, ErrTxDone
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return t.s.get(ctx, path)
+}
+
+func (t *tx) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	if t.done {
+		return ErrTxDone
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t.wrote = true
+	return t.s.set(path, secret)
+}
+
+func (t *tx) Delete(ctx context.Context, path string) error {
+	if t.done {
+		return ErrTxDone
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t.wrote = true
+	return t.s.removeSecret(path, false)
+}
@@ -0,0 +1,203 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+// WALBackend is implemented by backends that can keep a write-ahead log
+// next to the vault data. Implementations must return an error matching
+// fs.ErrNotExist when no log has been written.
+type WALBackend interface {
+	// AppendWAL appends a record to the log and syncs it to storage
+	// before returning.
+	AppendWAL(record []byte) error
+
+	// ReadWAL reads the whole log.
+	ReadWAL() ([]byte, error)
+
+	// TruncateWAL removes every record from the log.
+	TruncateWAL() error
+}
+
+// DefaultWALCompactRecords is the number of write-ahead log records after
+// which the log is folded into the vault data.
+const DefaultWALCompactRecords = 100
+
+// walRecord is one line of the write-ahead log: the encrypted secrets a
+// write changed and a MAC chaining it to the previous record, or to the
+// vault data for the first one, so records can't be dropped, reordered,
+// or replayed onto other data.
+type walRecord struct {
+	Change json.RawMessage `json:"change"`
+	MAC    string          `json:"mac"`
+}
+
+// walChange is the content of a write-ahead log record.
+type walChange struct {
+	Set    map[string]string `json:"set,omitempty"`    // path -> encrypted secret JSON
+	Delete []string          `json:"delete,omitempty"` // removed paths
+}
+
+// SetWAL enables the write-ahead log. Writes then append a record of the
+// secrets they changed to the log instead of rewriting the whole vault
+// data, which is faster for large vaults. The log is folded into the vault
+// data after compactAfter records, when the vault is locked, and, if the
+// process crashed before that, on the next unlock. A compactAfter of zero
+// or less disables the log. It fails if the backend does not implement
+// WALBackend.
+func (s *EncryptedStore) SetWAL(compactAfter int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.backend.(WALBackend); !ok && compactAfter > 0 {
+		return errors.New("backend does not support a write-ahead log")
+	}
+	s.walCompact = compactAfter
+	return nil
+}
+
+// markChanged records that the secret at path was written or deleted, so
+// the next commit logs it. Callers must hold s.mu.
+func (s *EncryptedStore) markChanged(path string) {
+	if s.walCompact <= 0 {
+		return
+	}
+	if s.changed == nil {
+		s.changed = make(map[string]bool)
+	}
+	s.changed[path] = true
+}
+
+// commit persists a write, along with any recorded accesses: by appending
+// the changed secrets to the write-ahead log if it is enabled, or else by
+// saving the vault data. Callers must hold s.mu.
+func (s *EncryptedStore) commit(ctx context.Context) error {
+	// Accesses are saved along with writes
+	if err := s.foldAccess(); err != nil {
+		return err
+	}
+
+	wal, ok := s.backend.(WALBackend)
+	if !ok || s.walCompact <= 0 || s.walRecords+1 >= s.walCompact {
+		return s.saveData(ctx)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var change walChange
+	for path := range s.changed {
+		if encrypted, ok := s.data.Secrets[path]; ok {
+			if change.Set == nil {
+				change.Set = make(map[string]string)
+			}
+			change.Set[path] = encrypted
+		} else {
+			change.Delete = append(change.Delete, path)
+		}
+	}
+	sort.Strings(change.Delete)
+
+	body, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	mac, err := s.crypto.MAC(walChain(s.walMAC, body))
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(walRecord{Change: body, MAC: mac})
+	if err != nil {
+		return err
+	}
+
+	if err := wal.AppendWAL(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to append to write-ahead log: %w", err)
+	}
+
+	// The vault data stays dirty until the log is folded into it
+	s.walMAC = mac
+	s.walRecords++
+	s.changed = nil
+	return nil
+}
+
+// replayWAL applies the write-ahead log to the loaded vault data, whose
+// MAC is dataMAC, and returns the number of lines the log holds, which
+// must be folded into the data and truncated. A torn last line, left by a
+// crash while appending, is ignored. Callers must hold s.mu.
+func (s *EncryptedStore) replayWAL(dataMAC string) (int, error) {
+	s.walMAC = dataMAC
+	s.walRecords = 0
+	s.changed = nil
+
+	wal, ok := s.backend.(WALBackend)
+	if !ok {
+		return 0, nil
+	}
+	log, err := wal.ReadWAL()
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && len(log) == 0) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read write-ahead log: %w", err)
+	}
+
+	lines := bytes.Split(log, []byte("\n"))
+	mac := dataMAC
+	for i, line := range lines[:len(lines)-1] {
+		var record walRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return 0, ErrTampered
+		}
+		if !s.crypto.VerifyMAC(walChain(mac, record.Change), record.MAC) {
+			if i == 0 {
+				// Left over by a crash after the log was folded into the
+				// data but before it was truncated
+				break
+			}
+			return 0, ErrTampered
+		}
+
+		var change walChange
+		if err := json.Unmarshal(record.Change, &change); err != nil {
+			return 0, ErrTampered
+		}
+		for path, encrypted := range change.Set {
+			s.data.Secrets[path] = encrypted
+		}
+		for _, path := range change.Delete {
+			delete(s.data.Secrets, path)
+		}
+		mac = record.MAC
+	}
+
+	return len(lines), nil
+}
+
+// truncateWAL empties the write-ahead log after the vault data was saved
+// with mac. Callers must hold s.mu.
+func (s *EncryptedStore) truncateWAL(mac string) error {
+	if wal, ok := s.backend.(WALBackend); ok && s.walRecords > 0 {
+		if err := wal.TruncateWAL(); err != nil {
+			return fmt.Errorf("failed to truncate write-ahead log: %w", err)
+		}
+	}
+	s.walMAC = mac
+	s.walRecords = 0
+	s.changed = nil
+	return nil
+}
+
+// walChain returns the data a record's MAC covers: the previous MAC and
+// the record's change.
+func walChain(prevMAC string, change []byte) []byte {
+	return append([]byte(prevMAC+"\n"), change...)
+}
@@ -0,0 +1,182 @@
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Marshal returns the YAML encoding of v. Map keys are sorted so the
+// output is deterministic.
+func Marshal(v any) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	return Encode(generic), nil
+}
+
+// Encode returns the YAML encoding of generic values as returned by Parse
+// or ParseOrdered. Unlike Marshal, []MapItem mappings are written in their
+// order, so a document parsed with ParseOrdered keeps its key order.
+func Encode(v any) []byte {
+	var buf bytes.Buffer
+	encodeNode(&buf, v, 0)
+	return buf.Bytes()
+}
+
+// encodeNode writes a top-level or nested block node.
+func encodeNode(buf *bytes.Buffer, v any, indent int) {
+	if items, ok := mappingItems(v); ok {
+		if len(items) == 0 {
+			writeLine(buf, indent, "{}")
+			return
+		}
+		encodeMapping(buf, items, indent)
+		return
+	}
+	switch val := v.(type) {
+	case []any:
+		if len(val) == 0 {
+			writeLine(buf, indent, "[]")
+			return
+		}
+		encodeSequence(buf, val, indent)
+	default:
+		writeLine(buf, indent, encodeScalar(val))
+	}
+}
+
+// mappingItems returns the items of a mapping: a []MapItem as is, or a
+// map[string]any with sorted keys.
+func mappingItems(v any) ([]MapItem, bool) {
+	switch val := v.(type) {
+	case []MapItem:
+		return val, true
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		items := make([]MapItem, len(keys))
+		for i, k := range keys {
+			items[i] = MapItem{Key: k, Value: val[k]}
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+// encodeMapping writes a block mapping.
+func encodeMapping(buf *bytes.Buffer, items []MapItem, indent int) {
+	for _, item := range items {
+		key := encodeString(item.Key)
+		if nested, ok := mappingItems(item.Value); ok {
+			if len(nested) == 0 {
+				writeLine(buf, indent, key+": {}")
+				continue
+			}
+			writeLine(buf, indent, key+":")
+			encodeMapping(buf, nested, indent+2)
+			continue
+		}
+		switch val := item.Value.(type) {
+		case []any:
+			if len(val) == 0 {
+				writeLine(buf, indent, key+": []")
+				continue
+			}
+			writeLine(buf, indent, key+":")
+			encodeSequence(buf, val, indent+2)
+		default:
+			writeLine(buf, indent, key+": "+encodeScalar(val))
+		}
+	}
+}
+
+// encodeSequence writes a block sequence.
+func encodeSequence(buf *bytes.Buffer, s []any, indent int) {
+	for _, item := range s {
+		if nested, ok := mappingItems(item); ok {
+			if len(nested) == 0 {
+				writeLine(buf, indent, "- {}")
+				continue
+			}
+			// Write the mapping indented, then turn its first indent into the dash
+			var mapping bytes.Buffer
+			encodeMapping(&mapping, nested, indent+2)
+			out := mapping.Bytes()
+			out[indent] = '-'
+			buf.Write(out)
+			continue
+		}
+		switch val := item.(type) {
+		case []any:
+			writeLine(buf, indent, "-")
+			encodeNode(buf, val, indent+2)
+		default:
+			writeLine(buf, indent, "- "+encodeScalar(val))
+		}
+	}
+}
+
+// encodeScalar encodes a scalar value from encoding/json's generic types.
+func encodeScalar(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(val)
+	case json.Number:
+		return val.String()
+	case string:
+		return encodeString(val)
+	default:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+}
+
+// encodeString returns s as a plain scalar if that round-trips as the same
+// string, and double-quoted otherwise.
+func encodeString(s string) string {
+	if needsQuoting(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// needsQuoting reports whether s must be quoted to be read back as a string.
+func needsQuoting(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+	if strings.ContainsAny(s, "\n\r\t\"'#") || strings.Contains(s, ": ") || strings.HasSuffix(s, ":") {
+		return true
+	}
+	switch s[0] {
+	case '-', '[', ']', '{', '}', '&', '*', '!', '|', '>', '%', '@', '`', '?', ',':
+		return true
+	}
+	parsed, err := parseScalar(s)
+	return err != nil || parsed != s
+}
+
+// writeLine writes an indented line.
+func writeLine(buf *bytes.Buffer, indent int, text string) {
+	buf.WriteString(strings.Repeat(" ", indent))
+	buf.WriteString(text)
+	buf.WriteByte('\n')
+}
@@ -0,0 +1,523 @@
+// Package yaml implements the subset of YAML used by OmniVault for
+// configuration files and output formatting, without external dependencies.
+//
+// Supported: block mappings and sequences, plain, single- and double-quoted
+// scalars, literal block scalars (|), flow sequences of scalars ([a, b]),
+// empty flow collections ([] and {}), comments, and a leading "---".
+// Anchors, aliases, tags, flow mappings, folded block scalars and
+// multi-document streams are not supported; they are parse errors rather
+// than being read as plain strings.
+//
+// Values are decoded into the same generic types as encoding/json
+// (map[string]any, []any, string, float64, bool, nil), and Unmarshal and
+// Marshal round-trip through encoding/json so that struct tags work as usual.
+package yaml
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Unmarshal parses YAML data and stores the result in the value pointed to by v.
+func Unmarshal(data []byte, v any) error {
+	generic, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// Parse parses YAML data into generic values.
+func Parse(data []byte) (any, error) {
+	return parse(data, false)
+}
+
+// MapItem is a key and value of a mapping parsed by ParseOrdered.
+type MapItem struct {
+	Key   string
+	Value any
+}
+
+// ParseOrdered is like Parse, but decodes block mappings into []MapItem
+// in document order instead of map[string]any.
+func ParseOrdered(data []byte) (any, error) {
+	return parse(data, true)
+}
+
+func parse(data []byte, ordered bool) (any, error) {
+	p := &parser{lines: splitLines(string(data)), ordered: ordered}
+	if len(p.lines) == 0 {
+		return nil, nil
+	}
+	value, err := p.parseNode(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, p.errorf("unexpected content %q", p.lines[p.pos].text)
+	}
+	return value, nil
+}
+
+// line is a significant (non-blank, non-comment) source line.
+type line struct {
+	num    int
+	indent int
+	text   string // content without indentation or trailing comment
+	raw    string // original line, used for block scalars
+}
+
+// splitLines splits source into significant lines.
+func splitLines(src string) []line {
+	var lines []line
+	for i, raw := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimLeft(raw, " ")
+		text := strings.TrimSpace(stripComment(trimmed))
+		if text == "" || (len(lines) == 0 && text == "---") {
+			// Keep blank lines so block scalars can preserve them
+			lines = append(lines, line{num: i + 1, indent: -1, raw: raw})
+			continue
+		}
+		lines = append(lines, line{
+			num:    i + 1,
+			indent: len(raw) - len(trimmed),
+			text:   text,
+			raw:    raw,
+		})
+	}
+
+	// Drop leading and trailing blank lines
+	for len(lines) > 0 && lines[0].indent < 0 {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1].indent < 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// stripComment removes a trailing "# comment" that is not inside quotes.
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+type parser struct {
+	lines   []line
+	pos     int
+	ordered bool
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	num := 0
+	if p.pos < len(p.lines) {
+		num = p.lines[p.pos].num
+	}
+	return fmt.Errorf("yaml: line %d: %s", num, fmt.Sprintf(format, args...))
+}
+
+// skipBlank advances past blank lines.
+func (p *parser) skipBlank() {
+	for p.pos < len(p.lines) && p.lines[p.pos].indent < 0 {
+		p.pos++
+	}
+}
+
+// parseNode parses the block node starting at the current line.
+func (p *parser) parseNode(indent int) (any, error) {
+	p.skipBlank()
+	if p.pos >= len(p.lines) {
+		return nil, nil
+	}
+	l := p.lines[p.pos]
+	if isSequenceItem(l.text) {
+		return p.parseSequence(indent)
+	}
+	if _, _, ok := splitKey(l.text); ok {
+		return p.parseMapping(indent)
+	}
+	p.pos++
+	return parseScalar(l.text)
+}
+
+// parseMapping parses a block mapping whose keys are at the given indent,
+// as a map[string]any or, if the parser is ordered, a []MapItem.
+func (p *parser) parseMapping(indent int) (any, error) {
+	result := make(map[string]any)
+	items := []MapItem{}
+	done := func() (any, error) {
+		if p.ordered {
+			return items, nil
+		}
+		return result, nil
+	}
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.lines) {
+			return done()
+		}
+		l := p.lines[p.pos]
+		if l.indent < indent {
+			return done()
+		}
+		if l.indent > indent || isSequenceItem(l.text) {
+			return nil, p.errorf("bad indentation")
+		}
+
+		key, rest, ok := splitKey(l.text)
+		if !ok {
+			return nil, p.errorf("expected key: value, got %q", l.text)
+		}
+		if l.text[0] != '"' && l.text[0] != '\'' {
+			if err := checkPlain(key); err != nil {
+				return nil, p.errorf("%v", err)
+			}
+		}
+		p.pos++
+
+		value, err := p.parseValue(indent, rest, true)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = value
+		items = append(items, MapItem{Key: key, Value: value})
+	}
+}
+
+// parseSequence parses a block sequence whose dashes are at the given indent.
+func (p *parser) parseSequence(indent int) ([]any, error) {
+	result := []any{}
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.lines) {
+			return result, nil
+		}
+		l := p.lines[p.pos]
+		if l.indent < indent || !isSequenceItem(l.text) {
+			return result, nil
+		}
+		if l.indent > indent {
+			return nil, p.errorf("bad indentation")
+		}
+
+		rest := strings.TrimSpace(strings.TrimPrefix(l.text, "-"))
+		if _, _, ok := splitKey(rest); ok && !isQuoted(rest) {
+			// "- key: value" starts a mapping indented past the dash
+			offset := len(l.text) - len(strings.TrimLeft(l.text[1:], " "))
+			p.lines[p.pos].indent = indent + offset
+			p.lines[p.pos].text = rest
+			value, err := p.parseMapping(indent + offset)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+			continue
+		}
+
+		p.pos++
+		value, err := p.parseValue(indent, rest, false)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+}
+
+// parseValue parses the value following a key or dash. An empty rest means
+// the value is a nested block on the following lines.
+func (p *parser) parseValue(indent int, rest string, inMapping bool) (any, error) {
+	switch {
+	case rest == "|" || rest == "|-" || rest == "|+":
+		return p.parseLiteral(indent, rest), nil
+	case rest != "":
+		return parseScalar(rest)
+	}
+
+	p.skipBlank()
+	if p.pos >= len(p.lines) {
+		return nil, nil
+	}
+	next := p.lines[p.pos]
+	if next.indent > indent {
+		return p.parseNode(next.indent)
+	}
+	if inMapping && next.indent == indent && isSequenceItem(next.text) {
+		// Sequences may sit at the same indent as their parent key
+		return p.parseSequence(indent)
+	}
+	return nil, nil
+}
+
+// parseLiteral parses a literal block scalar indented past the given indent.
+// Literal content is taken from the raw source lines, so "#" is not a comment.
+func (p *parser) parseLiteral(indent int, header string) string {
+	var buf []string
+	blockIndent := -1
+	end := p.pos
+	for i := p.pos; i < len(p.lines); i++ {
+		raw := p.lines[i].raw
+		content := strings.TrimLeft(raw, " ")
+		if strings.TrimSpace(raw) == "" {
+			buf = append(buf, "")
+			continue
+		}
+		rawIndent := len(raw) - len(content)
+		if rawIndent <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = rawIndent
+		}
+		if rawIndent < blockIndent {
+			break
+		}
+		buf = append(buf, raw[blockIndent:])
+		end = i + 1
+	}
+	p.pos = end
+
+	// Trailing blank lines are only kept with the "+" indicator
+	trailing := 0
+	for len(buf) > 0 && buf[len(buf)-1] == "" {
+		buf = buf[:len(buf)-1]
+		trailing++
+	}
+
+	text := strings.Join(buf, "\n")
+	switch header {
+	case "|-":
+		return text
+	case "|+":
+		return text + strings.Repeat("\n", trailing+1)
+	default:
+		return text + "\n"
+	}
+}
+
+// isSequenceItem reports whether text is a block sequence entry.
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// isQuoted reports whether text is a single quoted scalar.
+func isQuoted(text string) bool {
+	if len(text) < 2 {
+		return false
+	}
+	q := text[0]
+	return (q == '"' || q == '\'') && text[len(text)-1] == q && !strings.Contains(text[1:len(text)-1], string(q)+":")
+}
+
+// splitKey splits "key: value" into key and value.
+func splitKey(text string) (key, rest string, ok bool) {
+	if text == "" || text[0] == '[' || text[0] == '{' {
+		return "", "", false
+	}
+
+	if text[0] == '"' || text[0] == '\'' {
+		end := closingQuote(text)
+		if end < 0 || end+1 >= len(text) || text[end+1] != ':' {
+			return "", "", false
+		}
+		unquoted, err := parseScalar(text[:end+1])
+		if err != nil {
+			return "", "", false
+		}
+		after := text[end+2:]
+		if after != "" && after[0] != ' ' {
+			return "", "", false
+		}
+		return fmt.Sprint(unquoted), strings.TrimSpace(after), true
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// closingQuote returns the index of the quote closing the one at text[0].
+func closingQuote(text string) int {
+	q := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case q == '"' && text[i] == '\\':
+			i++
+		case q == '\'' && text[i] == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == q:
+			return i
+		}
+	}
+	return -1
+}
+
+// parseScalar parses a flow scalar.
+func parseScalar(text string) (any, error) {
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case "[]":
+		return []any{}, nil
+	case "{}":
+		return map[string]any{}, nil
+	}
+
+	if err := checkPlain(text); err != nil {
+		return nil, fmt.Errorf("yaml: %w", err)
+	}
+
+	switch text[0] {
+	case '"':
+		s, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("yaml: invalid double-quoted string %s", text)
+		}
+		return s, nil
+	case '\'':
+		if len(text) < 2 || text[len(text)-1] != '\'' {
+			return nil, fmt.Errorf("yaml: invalid single-quoted string %s", text)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	case '[':
+		if text[len(text)-1] != ']' {
+			return nil, fmt.Errorf("yaml: unterminated flow sequence %s", text)
+		}
+		entries, err := splitFlow(text[1 : len(text)-1])
+		if err != nil {
+			return nil, fmt.Errorf("yaml: %w in %s", err, text)
+		}
+		items := []any{}
+		for _, entry := range entries {
+			value, err := parseScalar(entry)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		return items, nil
+	}
+
+	// Leading zeros are octal, as in YAML 1.1 (e.g. file modes like 0600)
+	if len(text) > 1 && text[0] == '0' && isDigits(text[1:]) {
+		if n, err := strconv.ParseInt(text[1:], 8, 64); err == nil {
+			return float64(n), nil
+		}
+	}
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return float64(n), nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil && !strings.ContainsAny(text, "xXnN") {
+		return f, nil
+	}
+	return text, nil
+}
+
+// checkPlain returns an error if text starts with an indicator of YAML
+// syntax this package doesn't support, so that it isn't misread as a plain
+// string.
+func checkPlain(text string) error {
+	if text == "" {
+		return nil
+	}
+	switch text[0] {
+	case '{':
+		return fmt.Errorf("flow mappings are not supported: %s", text)
+	case '&':
+		return fmt.Errorf("anchors are not supported: %s", text)
+	case '*':
+		return fmt.Errorf("aliases are not supported: %s", text)
+	case '!':
+		return fmt.Errorf("tags are not supported: %s", text)
+	case '>':
+		return fmt.Errorf("folded block scalars are not supported: %s", text)
+	case '|':
+		return fmt.Errorf("block scalar indicators other than |, |- and |+ are not supported: %s", text)
+	case '%':
+		return fmt.Errorf("directives are not supported: %s", text)
+	case '@', '`':
+		return fmt.Errorf("reserved indicator %q starts %s", text[0], text)
+	}
+	return nil
+}
+
+// splitFlow splits the content of a flow sequence into its trimmed
+// entries, ignoring commas inside quotes and nested sequences. A trailing
+// comma is allowed.
+func splitFlow(content string) ([]string, error) {
+	var entries []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == ',' && depth == 0:
+			entries = append(entries, strings.TrimSpace(content[start:i]))
+			start = i + 1
+		}
+	}
+	if quote != 0 || depth != 0 {
+		return nil, fmt.Errorf("unterminated quote or sequence")
+	}
+	if last := strings.TrimSpace(content[start:]); last != "" || len(entries) > 0 {
+		entries = append(entries, last)
+	}
+	for i, entry := range entries {
+		if entry == "" && i < len(entries)-1 {
+			return nil, fmt.Errorf("empty entry")
+		}
+	}
+	if len(entries) > 0 && entries[len(entries)-1] == "" {
+		entries = entries[:len(entries)-1]
+	}
+	return entries, nil
+}
+
+// isDigits reports whether s consists only of ASCII digits.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
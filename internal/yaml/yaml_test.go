@@ -0,0 +1,199 @@
+package yaml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	src := `---
+# provider settings
+provider: file
+config:
+  directory: /tmp/secrets   # trailing comment
+  mode: 0600
+  readOnly: true
+  ratio: 1.5
+  empty:
+  quoted: "a: b # not a comment"
+  single: 'it''s'
+  list:
+  - one
+  - "two"
+  flow: [a, 2]
+  flowQuoted: ["a, b", 'c', [1, 2], ]
+  nested:
+    - name: first
+      value: 1
+    - name: second
+  note: |
+    line one
+    # not a comment
+
+    line three
+other: ~
+`
+
+	got, err := Parse([]byte(src))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	want := map[string]any{
+		"provider": "file",
+		"config": map[string]any{
+			"directory":  "/tmp/secrets",
+			"mode":       float64(0600),
+			"readOnly":   true,
+			"ratio":      1.5,
+			"empty":      nil,
+			"quoted":     "a: b # not a comment",
+			"single":     "it's",
+			"list":       []any{"one", "two"},
+			"flow":       []any{"a", float64(2)},
+			"flowQuoted": []any{"a, b", "c", []any{float64(1), float64(2)}},
+			"nested": []any{
+				map[string]any{"name": "first", "value": float64(1)},
+				map[string]any{"name": "second"},
+			},
+			"note": "line one\n# not a comment\n\nline three\n",
+		},
+		"other": nil,
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse mismatch\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{
+		"a: 1\n  b: 2\n",
+		"a: \"unterminated\n",
+		"- a\nb: 1\n",
+		"a: [1, 2\n",
+		"a: [1,, 2]\n",
+		"a: [\"b, c]\n",
+	} {
+		if _, err := Parse([]byte(src)); err == nil {
+			t.Errorf("Expected error parsing %q", src)
+		}
+	}
+}
+
+func TestParseUnsupported(t *testing.T) {
+	for src, want := range map[string]string{
+		"a: {b: 1}\n":                          "flow mappings",
+		"a: [1, {b: 1}]\n":                     "flow mappings",
+		"base: &x 1\n":                         "anchors",
+		"a: *x\n":                              "aliases",
+		"- *x\n":                               "aliases",
+		"a: !!str 1\n":                         "tags",
+		"&x a: 1\n":                            "anchors",
+		"a: >\n  folded\n":                     "folded block scalars",
+		"a: |2\n   text\n":                     "block scalar indicators",
+		"a: @b\n":                              "reserved indicator",
+		"base:\n  a: 1\nother:\n  <<: *base\n": "aliases",
+	} {
+		_, err := Parse([]byte(src))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q) = %v, want an error about %s", src, err, want)
+		}
+	}
+
+	// Quoted, the indicators are ordinary characters
+	got, err := Parse([]byte("a: \"*x\"\n'&b': '{c: 1}'\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse quoted indicators: %v", err)
+	}
+	if want := map[string]any{"a": "*x", "&b": "{c: 1}"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse = %#v, want %#v", got, want)
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	in := map[string]any{
+		"name":    "api",
+		"value":   "multi\nline",
+		"number":  "123",
+		"bool":    "true",
+		"colon":   "a: b",
+		"empty":   "",
+		"count":   3,
+		"enabled": false,
+		"tags":    map[string]any{"env": "prod"},
+		"items":   []any{"x", map[string]any{"k": "v", "n": 1}},
+	}
+
+	out, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	var back map[string]any
+	if err := Unmarshal(out, &back); err != nil {
+		t.Fatalf("Failed to unmarshal:\n%s\n%v", out, err)
+	}
+
+	want := map[string]any{
+		"name":    "api",
+		"value":   "multi\nline",
+		"number":  "123",
+		"bool":    "true",
+		"colon":   "a: b",
+		"empty":   "",
+		"count":   float64(3),
+		"enabled": false,
+		"tags":    map[string]any{"env": "prod"},
+		"items":   []any{"x", map[string]any{"k": "v", "n": float64(1)}},
+	}
+	if !reflect.DeepEqual(back, want) {
+		t.Errorf("Round trip mismatch\nyaml:\n%s\ngot:  %#v\nwant: %#v", out, back, want)
+	}
+}
+
+func TestParseOrdered(t *testing.T) {
+	src := `b: 1
+a:
+  z: x
+  y:
+    - k: v
+c: [1, 2]
+`
+	got, err := ParseOrdered([]byte(src))
+	if err != nil {
+		t.Fatalf("ParseOrdered failed: %v", err)
+	}
+	want := []MapItem{
+		{Key: "b", Value: float64(1)},
+		{Key: "a", Value: []MapItem{
+			{Key: "z", Value: "x"},
+			{Key: "y", Value: []any{[]MapItem{{Key: "k", Value: "v"}}}},
+		}},
+		{Key: "c", Value: []any{float64(1), float64(2)}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseOrdered = %#v, want %#v", got, want)
+	}
+}
+
+func TestEncodeOrdered(t *testing.T) {
+	src := `b: 1
+a:
+  z: x
+  y:
+    - k: v
+      j: "2"
+c:
+  - 1
+  - 2
+`
+	doc, err := ParseOrdered([]byte(src))
+	if err != nil {
+		t.Fatalf("ParseOrdered failed: %v", err)
+	}
+	if got := string(Encode(doc)); got != src {
+		t.Errorf("Encode = %q, want %q", got, src)
+	}
+}
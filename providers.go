@@ -2,16 +2,148 @@ package omnivault
 
 import (
 	"fmt"
+	"runtime"
+	"sort"
+	"sync"
 
+	"github.com/agentplexus/omnivault/providers/age"
+	"github.com/agentplexus/omnivault/providers/awsssm"
+	"github.com/agentplexus/omnivault/providers/azurekv"
+	"github.com/agentplexus/omnivault/providers/bitwarden"
+	"github.com/agentplexus/omnivault/providers/document"
+	"github.com/agentplexus/omnivault/providers/doppler"
 	"github.com/agentplexus/omnivault/providers/env"
 	"github.com/agentplexus/omnivault/providers/file"
+	"github.com/agentplexus/omnivault/providers/infisical"
+	"github.com/agentplexus/omnivault/providers/keepass"
+	"github.com/agentplexus/omnivault/providers/keychain"
+	"github.com/agentplexus/omnivault/providers/keyring"
+	"github.com/agentplexus/omnivault/providers/libsecret"
 	"github.com/agentplexus/omnivault/providers/memory"
+	"github.com/agentplexus/omnivault/providers/wincred"
 	"github.com/agentplexus/omnivault/vault"
 )
 
+// ProviderFactory creates a vault from a client configuration.
+// Provider-specific settings are passed in Config.ProviderConfig; when the
+// client is built from a config file this is the raw map[string]any.
+type ProviderFactory func(Config) (vault.Vault, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[ProviderName]ProviderFactory)
+)
+
+// RegisterProvider makes an external provider available by name, so it can be
+// selected via Config.Provider or a config file. It is typically called from
+// the init function of the provider's package. Built-in providers take
+// precedence over registered ones with the same name.
+//
+// RegisterProvider panics if the name is empty, the factory is nil, or a
+// provider is already registered under the name.
+func RegisterProvider(name ProviderName, factory ProviderFactory) {
+	if name == "" {
+		panic("omnivault: RegisterProvider with empty name")
+	}
+	if factory == nil {
+		panic("omnivault: RegisterProvider factory is nil for " + string(name))
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, dup := registry[name]; dup {
+		panic("omnivault: RegisterProvider called twice for " + string(name))
+	}
+	registry[name] = factory
+}
+
+// registeredProvider returns the factory registered under name, if any.
+func registeredProvider(name ProviderName) (ProviderFactory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	factory, ok := registry[name]
+	return factory, ok
+}
+
+// ProviderInfo describes a provider that can be selected via
+// Config.Provider in this build.
+type ProviderInfo struct {
+	Name ProviderName
+
+	// Capabilities are those of the provider with its default
+	// configuration. They are unknown, and zero, for registered providers.
+	Capabilities vault.Capabilities
+
+	// Builtin is false for providers added with RegisterProvider.
+	Builtin bool
+}
+
+// builtinCapabilities returns the capabilities of the built-in providers
+// with their default configuration. Platform providers report no
+// capabilities when built for another platform.
+var builtinCapabilities = map[ProviderName]func() vault.Capabilities{
+	ProviderEnv:    (&env.Provider{}).Capabilities,
+	ProviderMemory: (&memory.Provider{}).Capabilities,
+	ProviderFile: func() vault.Capabilities {
+		// Plain text files hold a single value
+		return vault.Capabilities{Read: true, Write: true, Delete: true, List: true, Binary: true, Watch: true}
+	},
+	ProviderLibSecret:         (&libsecret.Provider{}).Capabilities,
+	ProviderKeychain:          (&keychain.Provider{}).Capabilities,
+	ProviderWinCred:           (&wincred.Provider{}).Capabilities,
+	ProviderKeyring:           keyringCapabilities,
+	ProviderDoppler:           (&doppler.Provider{}).Capabilities,
+	ProviderBitwarden:         (&bitwarden.Provider{}).Capabilities,
+	ProviderInfisical:         (&infisical.Provider{}).Capabilities,
+	ProviderAzureKeyVault:     (&azurekv.Provider{}).Capabilities,
+	ProviderAWSParameterStore: (&awsssm.Provider{}).Capabilities,
+	ProviderDocument:          (&document.Provider{}).Capabilities,
+	ProviderKeePass:           (&keepass.Provider{}).Capabilities,
+	ProviderAge:               (&age.Provider{}).Capabilities,
+}
+
+// keyringCapabilities returns the capabilities of the provider the keyring
+// delegates to on this platform.
+func keyringCapabilities() vault.Capabilities {
+	switch runtime.GOOS {
+	case "darwin":
+		return (&keychain.Provider{}).Capabilities()
+	case "windows":
+		return (&wincred.Provider{}).Capabilities()
+	case "linux":
+		return (&libsecret.Provider{}).Capabilities()
+	}
+	return vault.Capabilities{}
+}
+
+// AvailableProviders returns the providers that can be selected in this
+// build, sorted by name: the built-in providers supported on this platform
+// and those added with RegisterProvider.
+func AvailableProviders() []ProviderInfo {
+	var infos []ProviderInfo
+	for name, capabilities := range builtinCapabilities {
+		if caps := capabilities(); caps.Read {
+			infos = append(infos, ProviderInfo{Name: name, Capabilities: caps, Builtin: true})
+		}
+	}
+
+	registryMu.RLock()
+	for name := range registry {
+		if _, ok := builtinCapabilities[name]; !ok {
+			infos = append(infos, ProviderInfo{Name: name})
+		}
+	}
+	registryMu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
 // newProvider creates a vault provider based on the configuration.
-// This function handles built-in providers only. External providers
-// should be passed via Config.CustomVault.
+// Built-in providers are handled directly; other names are looked up among
+// providers added with RegisterProvider.
 func newProvider(config Config) (vault.Vault, error) {
 	switch config.Provider {
 	case ProviderEnv:
@@ -20,10 +152,37 @@ func newProvider(config Config) (vault.Vault, error) {
 		return newMemoryProvider(config)
 	case ProviderFile:
 		return newFileProvider(config)
+	case ProviderLibSecret:
+		return newLibSecretProvider(config)
+	case ProviderKeychain:
+		return newKeychainProvider(config)
+	case ProviderWinCred:
+		return newWinCredProvider(config)
+	case ProviderKeyring:
+		return newKeyringProvider(config)
+	case ProviderDoppler:
+		return newDopplerProvider(config)
+	case ProviderBitwarden:
+		return newBitwardenProvider(config)
+	case ProviderInfisical:
+		return newInfisicalProvider(config)
+	case ProviderAzureKeyVault:
+		return newAzureKVProvider(config)
+	case ProviderAWSParameterStore:
+		return newAWSSSMProvider(config)
+	case ProviderDocument:
+		return newDocumentProvider(config)
+	case ProviderKeePass:
+		return newKeePassProvider(config)
+	case ProviderAge:
+		return newAgeProvider(config)
 	case "":
 		return nil, ErrNoProvider
 	default:
-		return nil, fmt.Errorf("%w: %s (use CustomVault for external providers)", ErrUnknownScheme, config.Provider)
+		if factory, ok := registeredProvider(config.Provider); ok {
+			return factory(config)
+		}
+		return nil, fmt.Errorf("%w: %s (use RegisterProvider or CustomVault for external providers)", ErrUnknownScheme, config.Provider)
 	}
 }
 
@@ -63,8 +222,276 @@ func newFileProvider(config Config) (vault.Vault, error) {
 	return file.New(fileConfig)
 }
 
+// newLibSecretProvider creates a Linux Secret Service provider.
+func newLibSecretProvider(config Config) (vault.Vault, error) {
+	var lsConfig libsecret.Config
+
+	if pc, ok := config.ProviderConfig.(libsecret.Config); ok {
+		lsConfig = pc
+	} else if pc, ok := config.ProviderConfig.(*libsecret.Config); ok && pc != nil {
+		lsConfig = *pc
+	}
+
+	p, err := libsecret.New(lsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newKeychainProvider creates a macOS Keychain provider.
+func newKeychainProvider(config Config) (vault.Vault, error) {
+	var kcConfig keychain.Config
+
+	if pc, ok := config.ProviderConfig.(keychain.Config); ok {
+		kcConfig = pc
+	} else if pc, ok := config.ProviderConfig.(*keychain.Config); ok && pc != nil {
+		kcConfig = *pc
+	}
+
+	p, err := keychain.New(kcConfig)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newWinCredProvider creates a Windows Credential Manager provider.
+func newWinCredProvider(config Config) (vault.Vault, error) {
+	var wcConfig wincred.Config
+
+	if pc, ok := config.ProviderConfig.(wincred.Config); ok {
+		wcConfig = pc
+	} else if pc, ok := config.ProviderConfig.(*wincred.Config); ok && pc != nil {
+		wcConfig = *pc
+	}
+
+	p, err := wincred.New(wcConfig)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newKeyringProvider creates a provider for the current platform's keyring.
+func newKeyringProvider(config Config) (vault.Vault, error) {
+	var krConfig keyring.Config
+
+	if pc, ok := config.ProviderConfig.(keyring.Config); ok {
+		krConfig = pc
+	} else if pc, ok := config.ProviderConfig.(*keyring.Config); ok && pc != nil {
+		krConfig = *pc
+	}
+
+	p, err := keyring.New(krConfig)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newDopplerProvider creates a Doppler provider.
+func newDopplerProvider(config Config) (vault.Vault, error) {
+	var dopplerConfig doppler.Config
+
+	if pc, ok := config.ProviderConfig.(doppler.Config); ok {
+		dopplerConfig = pc
+	} else if pc, ok := config.ProviderConfig.(*doppler.Config); ok && pc != nil {
+		dopplerConfig = *pc
+	} else {
+		return nil, fmt.Errorf("doppler provider requires doppler.Config in ProviderConfig")
+	}
+
+	if dopplerConfig.HTTPClient == nil {
+		dopplerConfig.HTTPClient = config.HTTPClient
+	}
+
+	p, err := doppler.New(dopplerConfig)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newBitwardenProvider creates a Bitwarden CLI provider.
+func newBitwardenProvider(config Config) (vault.Vault, error) {
+	var bwConfig bitwarden.Config
+
+	if pc, ok := config.ProviderConfig.(bitwarden.Config); ok {
+		bwConfig = pc
+	} else if pc, ok := config.ProviderConfig.(*bitwarden.Config); ok && pc != nil {
+		bwConfig = *pc
+	}
+
+	p, err := bitwarden.New(bwConfig)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newInfisicalProvider creates an Infisical provider.
+func newInfisicalProvider(config Config) (vault.Vault, error) {
+	var infisicalConfig infisical.Config
+
+	if pc, ok := config.ProviderConfig.(infisical.Config); ok {
+		infisicalConfig = pc
+	} else if pc, ok := config.ProviderConfig.(*infisical.Config); ok && pc != nil {
+		infisicalConfig = *pc
+	} else {
+		return nil, fmt.Errorf("infisical provider requires infisical.Config in ProviderConfig")
+	}
+
+	if infisicalConfig.HTTPClient == nil {
+		infisicalConfig.HTTPClient = config.HTTPClient
+	}
+
+	p, err := infisical.New(infisicalConfig)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newAzureKVProvider creates an Azure Key Vault provider.
+func newAzureKVProvider(config Config) (vault.Vault, error) {
+	var kvConfig azurekv.Config
+
+	if pc, ok := config.ProviderConfig.(azurekv.Config); ok {
+		kvConfig = pc
+	} else if pc, ok := config.ProviderConfig.(*azurekv.Config); ok && pc != nil {
+		kvConfig = *pc
+	} else {
+		return nil, fmt.Errorf("azure-kv provider requires azurekv.Config in ProviderConfig")
+	}
+
+	if kvConfig.HTTPClient == nil {
+		kvConfig.HTTPClient = config.HTTPClient
+	}
+
+	p, err := azurekv.New(kvConfig)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newAWSSSMProvider creates an AWS Parameter Store provider.
+func newAWSSSMProvider(config Config) (vault.Vault, error) {
+	var ssmConfig awsssm.Config
+
+	if pc, ok := config.ProviderConfig.(awsssm.Config); ok {
+		ssmConfig = pc
+	} else if pc, ok := config.ProviderConfig.(*awsssm.Config); ok && pc != nil {
+		ssmConfig = *pc
+	}
+
+	if ssmConfig.HTTPClient == nil {
+		ssmConfig.HTTPClient = config.HTTPClient
+	}
+
+	p, err := awsssm.New(ssmConfig)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newDocumentProvider creates a YAML or JSON document provider.
+func newDocumentProvider(config Config) (vault.Vault, error) {
+	var docConfig document.Config
+
+	if pc, ok := config.ProviderConfig.(document.Config); ok {
+		docConfig = pc
+	} else if pc, ok := config.ProviderConfig.(*document.Config); ok && pc != nil {
+		docConfig = *pc
+	} else {
+		return nil, fmt.Errorf("document provider requires document.Config in ProviderConfig")
+	}
+
+	p, err := document.New(docConfig)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newKeePassProvider creates a KeePass provider.
+func newKeePassProvider(config Config) (vault.Vault, error) {
+	var kpConfig keepass.Config
+
+	if pc, ok := config.ProviderConfig.(keepass.Config); ok {
+		kpConfig = pc
+	} else if pc, ok := config.ProviderConfig.(*keepass.Config); ok && pc != nil {
+		kpConfig = *pc
+	} else {
+		return nil, fmt.Errorf("keepass provider requires keepass.Config in ProviderConfig")
+	}
+
+	p, err := keepass.New(kpConfig)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newAgeProvider creates an age provider.
+func newAgeProvider(config Config) (vault.Vault, error) {
+	var ageConfig age.Config
+
+	if pc, ok := config.ProviderConfig.(age.Config); ok {
+		ageConfig = pc
+	} else if pc, ok := config.ProviderConfig.(*age.Config); ok && pc != nil {
+		ageConfig = *pc
+	} else {
+		return nil, fmt.Errorf("age provider requires age.Config in ProviderConfig")
+	}
+
+	p, err := age.New(ageConfig)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
 // EnvConfig is an alias for env.Config for convenience.
 type EnvConfig = env.Config
 
 // FileConfig is an alias for file.Config for convenience.
 type FileConfig = file.Config
+
+// LibSecretConfig is an alias for libsecret.Config for convenience.
+type LibSecretConfig = libsecret.Config
+
+// KeychainConfig is an alias for keychain.Config for convenience.
+type KeychainConfig = keychain.Config
+
+// WinCredConfig is an alias for wincred.Config for convenience.
+type WinCredConfig = wincred.Config
+
+// KeyringConfig is an alias for keyring.Config for convenience.
+type KeyringConfig = keyring.Config
+
+// DopplerConfig is an alias for doppler.Config for convenience.
+type DopplerConfig = doppler.Config
+
+// BitwardenConfig is an alias for bitwarden.Config for convenience.
+type BitwardenConfig = bitwarden.Config
+
+// InfisicalConfig is an alias for infisical.Config for convenience.
+type InfisicalConfig = infisical.Config
+
+// AzureKeyVaultConfig is an alias for azurekv.Config for convenience.
+type AzureKeyVaultConfig = azurekv.Config
+
+// AWSParameterStoreConfig is an alias for awsssm.Config for convenience.
+type AWSParameterStoreConfig = awsssm.Config
+
+// DocumentConfig is an alias for document.Config for convenience.
+type DocumentConfig = document.Config
+
+// KeePassConfig is an alias for keepass.Config for convenience.
+type KeePassConfig = keepass.Config
+
+// AgeConfig is an alias for age.Config for convenience.
+type AgeConfig = age.Config
@@ -0,0 +1,325 @@
+// Package age provides a vault implementation that stores each secret as
+// an age-encrypted file, encrypted to one or more recipients so that a
+// team can share a directory of secrets, e.g. in a git repository, and
+// each member decrypts with their own identity.
+//
+// Usage:
+//
+//	v, err := age.New(age.Config{
+//	    Directory:    "secrets",
+//	    IdentityFile: os.ExpandEnv("$HOME/.config/age/keys.txt"),
+//	    Recipients:   []string{"age1alice...", "age1bob..."},
+//	})
+//	secret, err := v.Get(ctx, "database/password") // reads secrets/database/password.age
+//
+// Files hold the secret as JSON, so they can also be read with
+// "age -d -i keys.txt secrets/database/password.age". After adding or
+// removing a team member, call Reencrypt with the new recipients so that
+// existing secrets can be read only by the current team.
+package age
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+
+	"github.com/agentplexus/omnivault/providers/file"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// Extension is the file extension of secret files.
+const Extension = ".age"
+
+// Config holds configuration for the age provider.
+type Config struct {
+	// Directory is the base directory for storing secrets.
+	Directory string
+
+	// Identities are age identities ("AGE-SECRET-KEY-1...") used to
+	// decrypt secrets.
+	Identities []string
+
+	// IdentityFile is a file of age identities, one per line, such as the
+	// keys.txt written by age-keygen.
+	IdentityFile string
+
+	// Recipients are the "age1..." public keys every secret is encrypted
+	// to. It defaults to the recipients of the identities.
+	Recipients []string
+}
+
+// Provider implements vault.Vault with age-encrypted files.
+type Provider struct {
+	files *file.Provider
+	ids   []age.Identity
+
+	mu         sync.RWMutex
+	recipients []*age.X25519Recipient
+}
+
+// New creates a new age provider. At least one identity or recipient is
+// required; without an identity secrets can be written but not read.
+func New(config Config) (*Provider, error) {
+	if config.Directory == "" {
+		return nil, errors.New("age: directory is required")
+	}
+
+	keys := strings.Join(config.Identities, "\n")
+	if config.IdentityFile != "" {
+		data, err := os.ReadFile(config.IdentityFile)
+		if err != nil {
+			return nil, fmt.Errorf("age: failed to read identity file: %w", err)
+		}
+		keys += "\n" + string(data)
+	}
+	var ids []age.Identity
+	if strings.TrimSpace(keys) != "" {
+		parsed, err := age.ParseIdentities(strings.NewReader(keys))
+		if err != nil {
+			return nil, fmt.Errorf("age: %w", err)
+		}
+		ids = parsed
+	}
+
+	recipients, err := parseRecipients(config.Recipients)
+	if err != nil {
+		return nil, err
+	}
+	if len(recipients) == 0 {
+		for _, id := range ids {
+			if id, ok := id.(*age.X25519Identity); ok {
+				recipients = append(recipients, id.Recipient())
+			}
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("age: no identities or recipients configured")
+	}
+
+	files, err := file.New(file.Config{Directory: config.Directory, Extension: Extension})
+	if err != nil {
+		return nil, fmt.Errorf("age: %w", err)
+	}
+
+	return &Provider{files: files, ids: ids, recipients: recipients}, nil
+}
+
+// parseRecipients parses "age1..." recipients.
+func parseRecipients(recipients []string) ([]*age.X25519Recipient, error) {
+	parsed := make([]*age.X25519Recipient, 0, len(recipients))
+	for _, s := range recipients {
+		r, err := age.ParseX25519Recipient(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("age: %w", err)
+		}
+		parsed = append(parsed, r)
+	}
+	return parsed, nil
+}
+
+// Get decrypts a secret with the configured identities.
+func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	secret, err := p.read(ctx, path)
+	if err != nil {
+		return nil, p.error("Get", path, err)
+	}
+	return secret, nil
+}
+
+// Set encrypts a secret to every recipient.
+func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.error("Set", path, p.write(ctx, path, secret, p.recipients))
+}
+
+// Delete removes a secret file.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	return p.error("Delete", path, p.files.Delete(ctx, path))
+}
+
+// Exists checks if a secret file exists, without decrypting it.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	exists, err := p.files.Exists(ctx, path)
+	return exists, p.error("Exists", path, err)
+}
+
+// List returns the paths of the secret files matching the prefix.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	paths, err := p.files.List(ctx, prefix)
+	if err != nil {
+		return nil, p.error("List", prefix, err)
+	}
+	return paths, nil
+}
+
+// Recipients returns the recipients secrets are encrypted to.
+func (p *Provider) Recipients() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	recipients := make([]string, len(p.recipients))
+	for i, r := range p.recipients {
+		recipients[i] = r.String()
+	}
+	return recipients
+}
+
+// Reencrypt encrypts every secret to a new set of recipients, which are
+// used for later writes too. Every secret is decrypted before any is
+// rewritten, so a secret that cannot be read leaves all of them
+// unchanged. Note that a removed recipient may still hold copies of the
+// old files, so rotate the secrets themselves if they must not know them.
+func (p *Provider) Reencrypt(ctx context.Context, recipients []string) error {
+	parsed, err := parseRecipients(recipients)
+	if err != nil {
+		return err
+	}
+	if len(parsed) == 0 {
+		return p.error("Reencrypt", "", errors.New("no age recipients"))
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	paths, err := p.files.List(ctx, "")
+	if err != nil {
+		return p.error("Reencrypt", "", err)
+	}
+	secrets := make(map[string]*vault.Secret, len(paths))
+	for _, path := range paths {
+		secret, err := p.read(ctx, path)
+		if err != nil {
+			return p.error("Reencrypt", path, err)
+		}
+		secrets[path] = secret
+	}
+
+	for _, path := range paths {
+		if err := p.write(ctx, path, secrets[path], parsed); err != nil {
+			return p.error("Reencrypt", path, err)
+		}
+	}
+	p.recipients = parsed
+	return nil
+}
+
+// read decrypts the secret at path.
+func (p *Provider) read(ctx context.Context, path string) (*vault.Secret, error) {
+	if len(p.ids) == 0 {
+		return nil, fmt.Errorf("%w: no age identities configured", vault.ErrAccessDenied)
+	}
+
+	stored, err := p.files.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := decrypt([]byte(stored.Value), p.ids)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", vault.ErrAccessDenied, err)
+	}
+
+	secret := &vault.Secret{}
+	if err := json.Unmarshal(plaintext, secret); err != nil {
+		return nil, fmt.Errorf("failed to decode secret: %w", err)
+	}
+	secret.Metadata.Provider = p.Name()
+	secret.Metadata.Path = path
+	secret.Metadata.ModifiedAt = stored.Metadata.ModifiedAt
+	return secret, nil
+}
+
+// write encrypts secret to recipients and stores it at path.
+func (p *Provider) write(ctx context.Context, path string, secret *vault.Secret, recipients []*age.X25519Recipient) error {
+	plaintext, err := json.Marshal(secret)
+	if err != nil {
+		return err
+	}
+	encrypted, err := encrypt(plaintext, recipients)
+	if err != nil {
+		return err
+	}
+	return p.files.Set(ctx, path, &vault.Secret{ValueBytes: encrypted})
+}
+
+// encrypt encrypts plaintext to recipients as a binary age file.
+func encrypt(plaintext []byte, recipients []*age.X25519Recipient) ([]byte, error) {
+	to := make([]age.Recipient, len(recipients))
+	for i, r := range recipients {
+		to[i] = r
+	}
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, to...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decrypt decrypts an age file, armored or binary, with the first
+// identity that matches one of its recipients.
+func decrypt(data []byte, ids []age.Identity) ([]byte, error) {
+	var r io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(data, []byte(armor.Header)) {
+		r = armor.NewReader(r)
+	}
+
+	plaintext, err := age.Decrypt(r, ids...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(plaintext)
+}
+
+// error wraps err for this provider, unwrapping errors from the file
+// provider so they are not reported twice.
+func (p *Provider) error(op, path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var ve *vault.VaultError
+	if errors.As(err, &ve) {
+		err = ve.Err
+	}
+	return vault.NewVaultError(op, path, p.Name(), err)
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "age"
+}
+
+// Capabilities returns the provider capabilities.
+func (p *Provider) Capabilities() vault.Capabilities {
+	return vault.Capabilities{
+		Read:       true,
+		Write:      true,
+		Delete:     true,
+		List:       true,
+		Binary:     true,
+		MultiField: true,
+	}
+}
+
+// Close closes the provider. Later calls return vault.ErrClosed.
+func (p *Provider) Close() error {
+	return p.files.Close()
+}
+
+// Ensure Provider implements vault.Vault.
+var _ vault.Vault = (*Provider)(nil)
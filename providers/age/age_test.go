@@ -0,0 +1,208 @@
+package age
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"filippo.io/age"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// newIdentity returns a random identity and its recipient.
+func newIdentity(t *testing.T) (identity, recipient string) {
+	t.Helper()
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id.String(), id.Recipient().String()
+}
+
+// open returns a provider over dir decrypting with identity.
+func open(t *testing.T, dir, identity string, recipients ...string) *Provider {
+	t.Helper()
+	p, err := New(Config{Directory: dir, Identities: []string{identity}, Recipients: recipients})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return p
+}
+
+func TestTwoRecipients(t *testing.T) {
+	dir := t.TempDir()
+	alice, aliceR := newIdentity(t)
+	bob, bobR := newIdentity(t)
+	eve, _ := newIdentity(t)
+	ctx := context.Background()
+
+	secret := &vault.Secret{Value: "hunter2", Fields: map[string]string{"username": "admin"}}
+	if err := open(t, dir, alice, aliceR, bobR).Set(ctx, "db/prod", secret); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "db", "prod.age")); err != nil {
+		t.Errorf("Expected db/prod.age to be written: %v", err)
+	}
+
+	for name, identity := range map[string]string{"alice": alice, "bob": bob} {
+		got, err := open(t, dir, identity, aliceR, bobR).Get(ctx, "db/prod")
+		if err != nil {
+			t.Fatalf("%s: Get failed: %v", name, err)
+		}
+		if got.Value != "hunter2" || !reflect.DeepEqual(got.Fields, secret.Fields) {
+			t.Errorf("%s: Get = %+v", name, got)
+		}
+		if got.Metadata.Provider != "age" || got.Metadata.Path != "db/prod" {
+			t.Errorf("%s: Unexpected metadata %+v", name, got.Metadata)
+		}
+	}
+
+	if _, err := open(t, dir, eve).Get(ctx, "db/prod"); !errors.Is(err, vault.ErrAccessDenied) {
+		t.Errorf("Expected ErrAccessDenied for a non-recipient, got %v", err)
+	}
+}
+
+// The files under testdata/secrets were encrypted by the age 1.3.1
+// command to the identity in testdata/keys.txt: db/prod.age armored
+// ("age -a -r ...") and api-key.age binary.
+func TestAgeCommandFiles(t *testing.T) {
+	p, err := New(Config{Directory: filepath.Join("testdata", "secrets"), IdentityFile: filepath.Join("testdata", "keys.txt")})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+
+	got, err := p.Get(ctx, "db/prod")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Value != "hunter2" || got.Fields["username"] != "admin" {
+		t.Errorf("Get(db/prod) = %+v", got)
+	}
+	if got, err := p.Get(ctx, "api-key"); err != nil || got.Value != "abc" {
+		t.Errorf("Get(api-key) = %v, %v", got, err)
+	}
+
+	eve, _ := newIdentity(t)
+	if _, err := open(t, filepath.Join("testdata", "secrets"), eve).Get(ctx, "db/prod"); !errors.Is(err, vault.ErrAccessDenied) {
+		t.Errorf("Expected ErrAccessDenied for a non-recipient, got %v", err)
+	}
+}
+
+func TestDefaultRecipients(t *testing.T) {
+	dir := t.TempDir()
+	alice, aliceR := newIdentity(t)
+
+	p := open(t, dir, alice)
+	if got := p.Recipients(); !reflect.DeepEqual(got, []string{aliceR}) {
+		t.Errorf("Recipients = %v, want the identity's recipient", got)
+	}
+	ctx := context.Background()
+	if err := p.Set(ctx, "api-key", &vault.Secret{Value: "abc"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got, err := p.Get(ctx, "api-key"); err != nil || got.Value != "abc" {
+		t.Errorf("Get = %v, %v", got, err)
+	}
+
+	if _, err := New(Config{Directory: dir}); err == nil {
+		t.Error("Expected an error without identities or recipients")
+	}
+}
+
+func TestReencrypt(t *testing.T) {
+	dir := t.TempDir()
+	alice, aliceR := newIdentity(t)
+	bob, bobR := newIdentity(t)
+	carol, carolR := newIdentity(t)
+	ctx := context.Background()
+
+	p := open(t, dir, alice, aliceR, bobR)
+	for _, path := range []string{"api-key", "db/prod"} {
+		if err := p.Set(ctx, path, &vault.Secret{Value: path + "-value"}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	// Bob leaves, Carol joins
+	if err := p.Reencrypt(ctx, []string{aliceR, carolR}); err != nil {
+		t.Fatalf("Reencrypt failed: %v", err)
+	}
+	if got := p.Recipients(); !reflect.DeepEqual(got, []string{aliceR, carolR}) {
+		t.Errorf("Recipients = %v", got)
+	}
+	if err := p.Set(ctx, "new", &vault.Secret{Value: "new-value"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	for _, path := range []string{"api-key", "db/prod", "new"} {
+		for name, identity := range map[string]string{"alice": alice, "carol": carol} {
+			if got, err := open(t, dir, identity).Get(ctx, path); err != nil || got.Value != path+"-value" {
+				t.Errorf("%s: Get(%s) = %v, %v", name, path, got, err)
+			}
+		}
+		if _, err := open(t, dir, bob).Get(ctx, path); !errors.Is(err, vault.ErrAccessDenied) {
+			t.Errorf("Expected bob to lose access to %s, got %v", path, err)
+		}
+	}
+}
+
+func TestReencryptNeedsEverySecret(t *testing.T) {
+	dir := t.TempDir()
+	alice, aliceR := newIdentity(t)
+	_, bobR := newIdentity(t)
+	ctx := context.Background()
+
+	p := open(t, dir, alice)
+	if err := p.Set(ctx, "mine", &vault.Secret{Value: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	// A secret only bob can read
+	if err := open(t, dir, alice, bobR).Set(ctx, "bobs", &vault.Secret{Value: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(filepath.Join(dir, "mine.age"))
+
+	if err := p.Reencrypt(ctx, []string{aliceR, bobR}); !errors.Is(err, vault.ErrAccessDenied) {
+		t.Fatalf("Expected ErrAccessDenied, got %v", err)
+	}
+	if after, _ := os.ReadFile(filepath.Join(dir, "mine.age")); string(after) != string(before) {
+		t.Error("Expected no secret to be rewritten")
+	}
+	if got := p.Recipients(); !reflect.DeepEqual(got, []string{aliceR}) {
+		t.Errorf("Expected the recipients to be unchanged, got %v", got)
+	}
+}
+
+func TestListAndDelete(t *testing.T) {
+	alice, _ := newIdentity(t)
+	p := open(t, t.TempDir(), alice)
+	ctx := context.Background()
+
+	for _, path := range []string{"a", "dir/b"} {
+		if err := p.Set(ctx, path, &vault.Secret{Value: "x"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	paths, err := p.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"a", "dir/b"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("List = %v, want %v", paths, want)
+	}
+
+	if err := p.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := p.Get(ctx, "a"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+	if exists, err := p.Exists(ctx, "dir/b"); err != nil || !exists {
+		t.Errorf("Exists = %v, %v", exists, err)
+	}
+}
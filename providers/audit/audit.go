@@ -0,0 +1,141 @@
+// Package audit wraps a vault so every operation is reported to a sink,
+// without secret values, e.g. to find out which secrets an application
+// actually reads.
+//
+// Usage:
+//
+//	reads := make(map[string]int)
+//	v := audit.New(inner, func(e audit.Event) {
+//	    if e.Op == audit.OpGet && e.Err == nil {
+//	        reads[e.Path]++
+//	    }
+//	})
+//	secret, err := v.Get(ctx, "database/password")
+//
+// The sink is called synchronously after each operation, from the
+// goroutine that made it, so it must be safe for concurrent use if the
+// vault is shared, and should not block.
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// Operations reported in Event.Op.
+const (
+	OpGet    = "Get"
+	OpSet    = "Set"
+	OpDelete = "Delete"
+	OpExists = "Exists"
+	OpList   = "List"
+	OpWatch  = "Watch"
+)
+
+// Event describes one operation on the wrapped vault.
+type Event struct {
+	Op   string    // one of the Op constants
+	Path string    // secret path, or the prefix for List
+	Time time.Time // when the operation finished
+	Err  error     // the error returned, if any
+}
+
+// Provider wraps a vault.Vault and reports each operation to a sink.
+type Provider struct {
+	inner vault.Vault
+	sink  func(Event)
+	now   func() time.Time
+}
+
+// New wraps inner so that every Get, Set, Delete, Exists, List and Watch
+// is reported to sink. A nil sink discards the events.
+func New(inner vault.Vault, sink func(Event)) *Provider {
+	if sink == nil {
+		sink = func(Event) {}
+	}
+	return &Provider{inner: inner, sink: sink, now: time.Now}
+}
+
+// Middleware returns a vault.Middleware that wraps a vault with New, for
+// use with vault.Chain.
+func Middleware(sink func(Event)) vault.Middleware {
+	return func(inner vault.Vault) vault.Vault {
+		return New(inner, sink)
+	}
+}
+
+// Get retrieves a secret and reports the read.
+func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	secret, err := p.inner.Get(ctx, path)
+	p.emit(OpGet, path, err)
+	return secret, err
+}
+
+// Set stores a secret and reports the write.
+func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	err := p.inner.Set(ctx, path, secret)
+	p.emit(OpSet, path, err)
+	return err
+}
+
+// Delete removes a secret and reports the deletion.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	err := p.inner.Delete(ctx, path)
+	p.emit(OpDelete, path, err)
+	return err
+}
+
+// Exists checks if a secret exists and reports the check.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	exists, err := p.inner.Exists(ctx, path)
+	p.emit(OpExists, path, err)
+	return exists, err
+}
+
+// List returns secret paths matching the prefix and reports the listing.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	paths, err := p.inner.List(ctx, prefix)
+	p.emit(OpList, prefix, err)
+	return paths, err
+}
+
+// Watch reports changes to secrets under prefix if the wrapped vault
+// supports watching, and returns vault.ErrNotSupported otherwise. Starting
+// the watch is reported, the changes it delivers are not.
+func (p *Provider) Watch(ctx context.Context, prefix string) (<-chan vault.WatchEvent, error) {
+	var events <-chan vault.WatchEvent
+	var err error = vault.NewVaultError("Watch", prefix, p.Name(), vault.ErrNotSupported)
+	if w, ok := vault.AsWatchable(p.inner); ok {
+		events, err = w.Watch(ctx, prefix)
+	}
+	p.emit(OpWatch, prefix, err)
+	return events, err
+}
+
+// Name returns the name of the wrapped provider.
+func (p *Provider) Name() string {
+	return p.inner.Name()
+}
+
+// Capabilities returns the capabilities of the wrapped provider.
+func (p *Provider) Capabilities() vault.Capabilities {
+	return p.inner.Capabilities()
+}
+
+// Close closes the wrapped provider.
+func (p *Provider) Close() error {
+	return p.inner.Close()
+}
+
+// emit sends an event to the sink.
+func (p *Provider) emit(op, path string, err error) {
+	p.sink(Event{Op: op, Path: path, Time: p.now(), Err: err})
+}
+
+// Ensure Provider implements vault.Vault and vault.WatchableVault.
+var (
+	_ vault.Vault          = (*Provider)(nil)
+	_ vault.WatchableVault = (*Provider)(nil)
+)
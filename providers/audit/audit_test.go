@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omnivault/providers/memory"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+func newRecorded(secrets map[string]string) (*Provider, *[]Event) {
+	var events []Event
+	p := New(memory.NewWithSecrets(secrets), func(e Event) { events = append(events, e) })
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	return p, &events
+}
+
+func TestGetEmitsOneEvent(t *testing.T) {
+	p, events := newRecorded(map[string]string{"db/password": "hunter2"})
+
+	secret, err := p.Get(context.Background(), "db/password")
+	if err != nil || secret.Value != "hunter2" {
+		t.Fatalf("Get = %v, %v", secret, err)
+	}
+
+	if len(*events) != 1 {
+		t.Fatalf("Expected one event, got %v", *events)
+	}
+	want := Event{Op: OpGet, Path: "db/password", Time: time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)}
+	if got := (*events)[0]; got != want {
+		t.Errorf("Event = %+v, want %+v", got, want)
+	}
+}
+
+func TestNotFoundEmitsErrorEvent(t *testing.T) {
+	p, events := newRecorded(nil)
+
+	if _, err := p.Get(context.Background(), "missing"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Fatalf("Expected ErrSecretNotFound, got %v", err)
+	}
+
+	if len(*events) != 1 {
+		t.Fatalf("Expected one event, got %v", *events)
+	}
+	if e := (*events)[0]; e.Op != OpGet || e.Path != "missing" || !errors.Is(e.Err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected a not-found Get event, got %+v", e)
+	}
+}
+
+func TestEveryOperationEmits(t *testing.T) {
+	p, events := newRecorded(nil)
+	ctx := context.Background()
+
+	_ = p.Set(ctx, "api/key", &vault.Secret{Value: "v"})
+	_, _ = p.Exists(ctx, "api/key")
+	_, _ = p.List(ctx, "api/")
+	_ = p.Delete(ctx, "api/key")
+
+	want := []struct{ op, path string }{
+		{OpSet, "api/key"},
+		{OpExists, "api/key"},
+		{OpList, "api/"},
+		{OpDelete, "api/key"},
+	}
+	if len(*events) != len(want) {
+		t.Fatalf("Expected %d events, got %v", len(want), *events)
+	}
+	for i, w := range want {
+		if e := (*events)[i]; e.Op != w.op || e.Path != w.path || e.Err != nil {
+			t.Errorf("Event %d = %+v, want %s %s", i, e, w.op, w.path)
+		}
+	}
+}
@@ -0,0 +1,398 @@
+// Package awsssm provides a vault implementation backed by AWS Systems
+// Manager Parameter Store (https://docs.aws.amazon.com/systems-manager/latest/userguide/systems-manager-parameter-store.html).
+//
+// Usage:
+//
+//	v, err := awsssm.New(awsssm.Config{
+//	    Region:         "us-east-1",
+//	    Path:           "/myapp/prod",
+//	    WithDecryption: true,
+//	})
+//	secret, err := v.Get(ctx, "db/password") // reads /myapp/prod/db/password
+//
+// Paths are parameter names relative to Path. Without a Path they are
+// full parameter names, and List only finds hierarchical names, those
+// starting with "/".
+package awsssm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// Parameter types.
+const (
+	TypeString       = "String"
+	TypeStringList   = "StringList"
+	TypeSecureString = "SecureString"
+)
+
+// Config holds configuration for the Parameter Store provider.
+type Config struct {
+	// Region is the AWS region (default: $AWS_REGION, then
+	// $AWS_DEFAULT_REGION, then the region of the shared config profile).
+	Region string
+
+	// Path is the hierarchy the provider's paths are relative to, e.g.
+	// "/myapp/prod".
+	Path string
+
+	// WithDecryption returns SecureString values decrypted. Without it
+	// they are returned encrypted.
+	WithDecryption bool
+
+	// Type is the type of parameters created by Set (default
+	// SecureString).
+	Type string
+
+	// Credentials sign API requests (default: the AWS SDK's default
+	// credential chain, i.e. the environment, the shared config and
+	// credentials files including SSO profiles, web identity tokens, and
+	// ECS or EC2 instance roles).
+	Credentials CredentialsProvider
+
+	// Endpoint overrides the Parameter Store endpoint, e.g. for
+	// LocalStack (default: https://ssm.<region>.amazonaws.com).
+	Endpoint string
+
+	// Client overrides the Parameter Store API client, mainly for tests.
+	Client Client
+
+	// HTTPClient is the HTTP client used for API requests (default: 30s
+	// timeout). $AWS_CA_BUNDLE can't be used with it.
+	HTTPClient *http.Client
+}
+
+// Client is the part of the Parameter Store API used by the provider. The
+// default implementation uses the AWS SDK. Errors for failed requests
+// should be *ResponseError.
+type Client interface {
+	// GetParameter returns a parameter.
+	GetParameter(ctx context.Context, input GetParameterInput) (*Parameter, error)
+
+	// GetParametersByPath returns one page of the parameters under a path.
+	GetParametersByPath(ctx context.Context, input GetParametersByPathInput) (*GetParametersByPathOutput, error)
+
+	// PutParameter creates or overwrites a parameter.
+	PutParameter(ctx context.Context, input PutParameterInput) error
+
+	// DeleteParameter deletes a parameter.
+	DeleteParameter(ctx context.Context, name string) error
+}
+
+// Parameter is a Parameter Store parameter.
+type Parameter struct {
+	Name             string  `json:"Name"`
+	Type             string  `json:"Type"`
+	Value            string  `json:"Value"`
+	Version          int64   `json:"Version"`
+	LastModifiedDate float64 `json:"LastModifiedDate,omitempty"` // Unix seconds
+	ARN              string  `json:"ARN,omitempty"`
+}
+
+// GetParameterInput are the parameters for GetParameter.
+type GetParameterInput struct {
+	Name           string `json:"Name"`
+	WithDecryption bool   `json:"WithDecryption"`
+}
+
+// GetParametersByPathInput are the parameters for GetParametersByPath.
+type GetParametersByPathInput struct {
+	Path           string `json:"Path"`
+	Recursive      bool   `json:"Recursive"`
+	WithDecryption bool   `json:"WithDecryption"`
+	MaxResults     int    `json:"MaxResults,omitempty"`
+	NextToken      string `json:"NextToken,omitempty"`
+}
+
+// GetParametersByPathOutput is a page of parameters.
+type GetParametersByPathOutput struct {
+	Parameters []Parameter `json:"Parameters"`
+	NextToken  string      `json:"NextToken,omitempty"`
+}
+
+// PutParameterInput are the parameters for PutParameter.
+type PutParameterInput struct {
+	Name      string `json:"Name"`
+	Value     string `json:"Value"`
+	Type      string `json:"Type"`
+	Overwrite bool   `json:"Overwrite"`
+}
+
+// ResponseError is a failed Parameter Store API request.
+type ResponseError struct {
+	StatusCode int
+	Code       string // e.g. "ParameterNotFound"
+	Message    string
+}
+
+func (e *ResponseError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s (status %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// parameterName matches the characters allowed in parameter names.
+var parameterName = regexp.MustCompile(`^[0-9A-Za-z_.\-/]+$`)
+
+// maxNameLength is the longest parameter name, including its hierarchy.
+const maxNameLength = 2048
+
+// pageSize is the largest page GetParametersByPath returns.
+const pageSize = 10
+
+// Provider implements vault.Vault for AWS Parameter Store.
+type Provider struct {
+	client         Client
+	root           string
+	withDecryption bool
+	paramType      string
+}
+
+// New creates a new Parameter Store provider.
+func New(config Config) (*Provider, error) {
+	p := &Provider{
+		root:           strings.TrimSuffix(config.Path, "/"),
+		withDecryption: config.WithDecryption,
+		paramType:      config.Type,
+	}
+	if p.root != "" && !strings.HasPrefix(p.root, "/") {
+		p.root = "/" + p.root
+	}
+	if p.paramType == "" {
+		p.paramType = TypeSecureString
+	}
+
+	if config.Client != nil {
+		p.client = config.Client
+		return p, nil
+	}
+
+	region := config.Region
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+
+	var httpClient aws.HTTPClient = awshttp.NewBuildableClient().WithTimeout(30 * time.Second)
+	if config.HTTPClient != nil {
+		httpClient = config.HTTPClient
+	}
+
+	options := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithRegion(region),
+		awsconfig.WithHTTPClient(httpClient),
+	}
+	if config.Credentials != nil {
+		options = append(options, awsconfig.WithCredentialsProvider(awsCredentials{provider: config.Credentials}))
+	}
+	awsConfig, err := awsconfig.LoadDefaultConfig(context.Background(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if awsConfig.Region == "" {
+		return nil, errors.New("region is required")
+	}
+
+	p.client = &sdkClient{ssm: ssm.NewFromConfig(awsConfig, func(o *ssm.Options) {
+		if config.Endpoint != "" {
+			o.BaseEndpoint = aws.String(config.Endpoint)
+		}
+	})}
+	return p, nil
+}
+
+// name returns the parameter name for path.
+func (p *Provider) name(path string) (string, error) {
+	name := path
+	if p.root != "" {
+		name = p.root + "/" + strings.TrimPrefix(path, "/")
+	}
+	if path == "" || len(name) > maxNameLength || !parameterName.MatchString(name) {
+		return "", vault.ErrInvalidPath
+	}
+	return name, nil
+}
+
+// Get retrieves a parameter.
+func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	name, err := p.name(path)
+	if err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), err)
+	}
+
+	param, err := p.client.GetParameter(ctx, GetParameterInput{Name: name, WithDecryption: p.withDecryption})
+	if err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), mapError(err))
+	}
+
+	extra := map[string]any{"type": param.Type}
+	if param.ARN != "" {
+		extra["arn"] = param.ARN
+	}
+
+	secret := &vault.Secret{
+		Value: param.Value,
+		Metadata: vault.Metadata{
+			Provider: p.Name(),
+			Path:     path,
+			Version:  strconv.FormatInt(param.Version, 10),
+			Extra:    extra,
+		},
+	}
+	if param.LastModifiedDate != 0 {
+		secret.Metadata.ModifiedAt = vault.NewTimestamp(unixTime(param.LastModifiedDate))
+	}
+	return secret, nil
+}
+
+// Set creates or overwrites a parameter, of the configured type.
+func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	name, err := p.name(path)
+	if err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+
+	input := PutParameterInput{Name: name, Value: secret.String(), Type: p.paramType, Overwrite: true}
+	if err := p.client.PutParameter(ctx, input); err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), mapError(err))
+	}
+	return nil
+}
+
+// Delete deletes a parameter.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	name, err := p.name(path)
+	if err != nil {
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+
+	err = mapError(p.client.DeleteParameter(ctx, name))
+	if err != nil && !errors.Is(err, vault.ErrSecretNotFound) {
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+	return nil
+}
+
+// Exists checks if a parameter exists.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	_, err := p.Get(ctx, path)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return false, nil
+	}
+	return false, err
+}
+
+// List returns the paths of all parameters matching the prefix. Only the
+// hierarchy level holding the prefix is searched.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	search := "/"
+	full := p.root + "/" + strings.TrimPrefix(prefix, "/")
+	if p.root == "" {
+		full = prefix
+	}
+	if i := strings.LastIndex(full, "/"); i > 0 && strings.HasPrefix(full, "/") {
+		search = full[:i]
+	}
+
+	input := GetParametersByPathInput{Path: search, Recursive: true, MaxResults: pageSize}
+	var results []string
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, vault.NewVaultError("List", prefix, p.Name(), err)
+		}
+
+		page, err := p.client.GetParametersByPath(ctx, input)
+		if err != nil {
+			return nil, vault.NewVaultError("List", prefix, p.Name(), mapError(err))
+		}
+		for _, param := range page.Parameters {
+			if path, ok := p.pathOf(param.Name); ok && strings.HasPrefix(path, prefix) {
+				results = append(results, path)
+			}
+		}
+
+		if page.NextToken == "" {
+			break
+		}
+		input.NextToken = page.NextToken
+	}
+
+	sort.Strings(results)
+	return results, nil
+}
+
+// pathOf returns the path of a parameter name, and false if the
+// parameter is outside the provider's hierarchy.
+func (p *Provider) pathOf(name string) (string, bool) {
+	if p.root == "" {
+		return name, true
+	}
+	return strings.CutPrefix(name, p.root+"/")
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "aws-ssm"
+}
+
+// Capabilities returns the provider capabilities.
+func (p *Provider) Capabilities() vault.Capabilities {
+	return vault.Capabilities{
+		Read:   true,
+		Write:  true,
+		Delete: true,
+		List:   true,
+	}
+}
+
+// Close is a no-op for the Parameter Store provider.
+func (p *Provider) Close() error {
+	return nil
+}
+
+// mapError converts Parameter Store API errors to standard vault errors.
+func mapError(err error) error {
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) {
+		return err
+	}
+	switch respErr.Code {
+	case "ParameterNotFound", "ParameterVersionNotFound":
+		return vault.ErrSecretNotFound
+	case "AccessDeniedException":
+		return vault.ErrAccessDenied
+	case "UnrecognizedClientException", "InvalidSignatureException", "ExpiredTokenException":
+		return vault.ErrAuthenticationFailed
+	}
+	return err
+}
+
+// unixTime converts fractional Unix seconds to a time.
+func unixTime(sec float64) time.Time {
+	return time.UnixMilli(int64(sec * 1000))
+}
+
+// Ensure Provider implements vault.Vault.
+var _ vault.Vault = (*Provider)(nil)
@@ -0,0 +1,285 @@
+package awsssm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// fakeClient is an in-memory Client that returns GetParametersByPath
+// results two at a time.
+type fakeClient struct {
+	params map[string]Parameter
+	pages  int
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{params: make(map[string]Parameter)}
+}
+
+func notFound(name string) error {
+	return &ResponseError{StatusCode: http.StatusBadRequest, Code: "ParameterNotFound", Message: name}
+}
+
+func (c *fakeClient) GetParameter(_ context.Context, input GetParameterInput) (*Parameter, error) {
+	param, ok := c.params[input.Name]
+	if !ok {
+		return nil, notFound(input.Name)
+	}
+	if param.Type == TypeSecureString && !input.WithDecryption {
+		param.Value = "encrypted:" + param.Value
+	}
+	return &param, nil
+}
+
+func (c *fakeClient) GetParametersByPath(_ context.Context, input GetParametersByPathInput) (*GetParametersByPathOutput, error) {
+	var names []string
+	for name := range c.params {
+		if strings.HasPrefix(name, strings.TrimSuffix(input.Path, "/")+"/") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	start := 0
+	if input.NextToken != "" {
+		start = len(names)
+		for i, name := range names {
+			if name == input.NextToken {
+				start = i
+			}
+		}
+	}
+	c.pages++
+
+	output := &GetParametersByPathOutput{}
+	for i := start; i < len(names); i++ {
+		if len(output.Parameters) == 2 {
+			output.NextToken = names[i]
+			break
+		}
+		output.Parameters = append(output.Parameters, Parameter{Name: names[i], Type: c.params[names[i]].Type})
+	}
+	return output, nil
+}
+
+func (c *fakeClient) PutParameter(_ context.Context, input PutParameterInput) error {
+	param := c.params[input.Name]
+	if param.Name != "" && !input.Overwrite {
+		return &ResponseError{StatusCode: http.StatusBadRequest, Code: "ParameterAlreadyExists"}
+	}
+	c.params[input.Name] = Parameter{
+		Name:             input.Name,
+		Type:             input.Type,
+		Value:            input.Value,
+		Version:          param.Version + 1,
+		LastModifiedDate: 1717232400.5,
+	}
+	return nil
+}
+
+func (c *fakeClient) DeleteParameter(_ context.Context, name string) error {
+	if _, ok := c.params[name]; !ok {
+		return notFound(name)
+	}
+	delete(c.params, name)
+	return nil
+}
+
+func newTestProvider(t *testing.T, config Config) (*Provider, *fakeClient) {
+	t.Helper()
+	client := newFakeClient()
+	config.Client = client
+	p, err := New(config)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return p, client
+}
+
+func TestGetSetDelete(t *testing.T) {
+	p, client := newTestProvider(t, Config{Path: "/myapp/prod", WithDecryption: true})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := p.Set(ctx, "db/password", &vault.Secret{Value: "hunter2"}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	param, ok := client.params["/myapp/prod/db/password"]
+	if !ok || param.Type != TypeSecureString {
+		t.Fatalf("Expected a SecureString parameter under Path, got %+v", client.params)
+	}
+
+	secret, err := p.Get(ctx, "db/password")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if secret.Value != "hunter2" || secret.Metadata.Version != "2" || secret.Metadata.Extra["type"] != TypeSecureString {
+		t.Errorf("Unexpected secret: %+v", secret)
+	}
+	if want := time.UnixMilli(1717232400500); secret.Metadata.ModifiedAt == nil || !secret.Metadata.ModifiedAt.Equal(want) {
+		t.Errorf("Expected ModifiedAt %v, got %v", want, secret.Metadata.ModifiedAt)
+	}
+
+	if err := p.Delete(ctx, "db/password"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := p.Get(ctx, "db/password"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+	if exists, err := p.Exists(ctx, "db/password"); err != nil || exists {
+		t.Errorf("Expected Exists = false, nil; got %v, %v", exists, err)
+	}
+	if err := p.Delete(ctx, "db/password"); err != nil {
+		t.Errorf("Expected Delete of a missing parameter to succeed, got %v", err)
+	}
+
+	if err := p.Set(ctx, "bad name", &vault.Secret{Value: "x"}); !errors.Is(err, vault.ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath for an invalid name, got %v", err)
+	}
+}
+
+func TestWithoutDecryption(t *testing.T) {
+	p, _ := newTestProvider(t, Config{Type: TypeString})
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "/plain", &vault.Secret{Value: "visible"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got, err := p.Get(ctx, "/plain"); err != nil || got.Value != "visible" {
+		t.Errorf("Expected a String parameter to be read as is, got %v, %v", got, err)
+	}
+}
+
+func TestList(t *testing.T) {
+	p, client := newTestProvider(t, Config{Path: "/myapp/prod"})
+	ctx := context.Background()
+
+	for _, name := range []string{
+		"/myapp/prod/api-key",
+		"/myapp/prod/db/password",
+		"/myapp/prod/db/username",
+		"/myapp/prod/db/replica/password",
+		"/myapp/staging/db/password",
+	} {
+		client.params[name] = Parameter{Name: name, Type: TypeSecureString, Value: "x"}
+	}
+
+	got, err := p.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	want := []string{"api-key", "db/password", "db/replica/password", "db/username"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("List = %v, want %v", got, want)
+	}
+	if client.pages != 2 {
+		t.Errorf("Expected List to read 2 pages, read %d", client.pages)
+	}
+
+	got, err = p.List(ctx, "db/p")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"db/password"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List(db/p) = %v, want %v", got, want)
+	}
+}
+
+func TestSDKClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "Credential=AKIDVALID/") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"UnrecognizedClientException","message":"bad key"}`))
+			return
+		}
+
+		var input map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		switch r.Header.Get("X-Amz-Target") {
+		case "AmazonSSM.GetParameter":
+			if input["Name"] != "/app/db/password" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"__type":"com.amazonaws.ssm#ParameterNotFound","message":"not found"}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"Parameter": Parameter{Name: "/app/db/password", Type: TypeSecureString, Value: "hunter2", Version: 3},
+			})
+		case "AmazonSSM.GetParametersByPath":
+			if input["NextToken"] == nil {
+				_ = json.NewEncoder(w).Encode(GetParametersByPathOutput{
+					Parameters: []Parameter{{Name: "/app/db/password"}},
+					NextToken:  "page2",
+				})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(GetParametersByPathOutput{Parameters: []Parameter{{Name: "/app/api-key"}}})
+		default:
+			http.Error(w, "unexpected target", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("AWS_CA_BUNDLE", "") // can't be combined with server.Client()
+
+	newProvider := func(accessKey string) *Provider {
+		p, err := New(Config{
+			Region:         "us-east-1",
+			Path:           "/app",
+			WithDecryption: true,
+			Credentials:    Credentials{AccessKeyID: accessKey, SecretAccessKey: "secret"},
+			Endpoint:       server.URL,
+			HTTPClient:     server.Client(),
+		})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		return p
+	}
+	p := newProvider("AKIDVALID")
+	ctx := context.Background()
+
+	secret, err := p.Get(ctx, "db/password")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if secret.Value != "hunter2" || secret.Metadata.Version != "3" {
+		t.Errorf("Unexpected secret: %+v", secret)
+	}
+
+	names, err := p.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"api-key", "db/password"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List = %v, want %v", names, want)
+	}
+
+	if _, err := p.Get(ctx, "missing"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+
+	if _, err := newProvider("AKIDINVALID").Get(ctx, "db/password"); !errors.Is(err, vault.ErrAuthenticationFailed) {
+		t.Errorf("Expected ErrAuthenticationFailed, got %v", err)
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	noCreds, err := New(Config{Region: "us-east-1", Credentials: EnvCredentials{}, Endpoint: server.URL})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := noCreds.Get(ctx, "db/password"); !errors.Is(err, vault.ErrAuthenticationFailed) {
+		t.Errorf("Expected ErrAuthenticationFailed without credentials, got %v", err)
+	}
+}
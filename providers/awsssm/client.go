@@ -0,0 +1,162 @@
+package awsssm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// Credentials are AWS access keys.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // for temporary credentials
+}
+
+// Retrieve returns the credentials, so static keys can be used as a
+// CredentialsProvider.
+func (c Credentials) Retrieve(context.Context) (Credentials, error) {
+	return c, nil
+}
+
+// CredentialsProvider provides the credentials that sign each request.
+// Providers of temporary credentials should refresh them before they
+// expire.
+type CredentialsProvider interface {
+	Retrieve(ctx context.Context) (Credentials, error)
+}
+
+// EnvCredentials reads credentials from $AWS_ACCESS_KEY_ID,
+// $AWS_SECRET_ACCESS_KEY, and $AWS_SESSION_TOKEN only, unlike the default
+// credential chain.
+type EnvCredentials struct{}
+
+// Retrieve reads the credentials from the environment.
+func (EnvCredentials) Retrieve(context.Context) (Credentials, error) {
+	creds := Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are not set")
+	}
+	return creds, nil
+}
+
+// awsCredentials adapts a CredentialsProvider to the AWS SDK.
+type awsCredentials struct {
+	provider CredentialsProvider
+}
+
+func (c awsCredentials) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	creds, err := c.provider.Retrieve(ctx)
+	if err != nil {
+		return aws.Credentials{}, fmt.Errorf("%w: %v", vault.ErrAuthenticationFailed, err)
+	}
+	return aws.Credentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		Source:          "omnivault",
+	}, nil
+}
+
+// sdkClient implements Client with the AWS SDK's Parameter Store client.
+type sdkClient struct {
+	ssm *ssm.Client
+}
+
+func (c *sdkClient) GetParameter(ctx context.Context, input GetParameterInput) (*Parameter, error) {
+	output, err := c.ssm.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(input.Name),
+		WithDecryption: aws.Bool(input.WithDecryption),
+	})
+	if err != nil {
+		return nil, responseError(err)
+	}
+	param := parameter(output.Parameter)
+	return &param, nil
+}
+
+func (c *sdkClient) GetParametersByPath(ctx context.Context, input GetParametersByPathInput) (*GetParametersByPathOutput, error) {
+	sdkInput := &ssm.GetParametersByPathInput{
+		Path:           aws.String(input.Path),
+		Recursive:      aws.Bool(input.Recursive),
+		WithDecryption: aws.Bool(input.WithDecryption),
+	}
+	if input.MaxResults > 0 {
+		sdkInput.MaxResults = aws.Int32(int32(input.MaxResults))
+	}
+	if input.NextToken != "" {
+		sdkInput.NextToken = aws.String(input.NextToken)
+	}
+
+	output, err := c.ssm.GetParametersByPath(ctx, sdkInput)
+	if err != nil {
+		return nil, responseError(err)
+	}
+	page := &GetParametersByPathOutput{NextToken: aws.ToString(output.NextToken)}
+	for i := range output.Parameters {
+		page.Parameters = append(page.Parameters, parameter(&output.Parameters[i]))
+	}
+	return page, nil
+}
+
+func (c *sdkClient) PutParameter(ctx context.Context, input PutParameterInput) error {
+	_, err := c.ssm.PutParameter(ctx, &ssm.PutParameterInput{
+		Name:      aws.String(input.Name),
+		Value:     aws.String(input.Value),
+		Type:      types.ParameterType(input.Type),
+		Overwrite: aws.Bool(input.Overwrite),
+	})
+	return responseError(err)
+}
+
+func (c *sdkClient) DeleteParameter(ctx context.Context, name string) error {
+	_, err := c.ssm.DeleteParameter(ctx, &ssm.DeleteParameterInput{Name: aws.String(name)})
+	return responseError(err)
+}
+
+// parameter converts a parameter returned by the SDK.
+func parameter(p *types.Parameter) Parameter {
+	if p == nil {
+		return Parameter{}
+	}
+	param := Parameter{
+		Name:    aws.ToString(p.Name),
+		Type:    string(p.Type),
+		Value:   aws.ToString(p.Value),
+		Version: p.Version,
+		ARN:     aws.ToString(p.ARN),
+	}
+	if p.LastModifiedDate != nil {
+		param.LastModifiedDate = float64(p.LastModifiedDate.UnixMilli()) / 1000
+	}
+	return param
+}
+
+// responseError converts an API error returned by the SDK to a
+// *ResponseError. Other errors, such as failing to connect, are returned
+// unchanged.
+func responseError(err error) error {
+	var apiErr smithy.APIError
+	if err == nil || !errors.As(err, &apiErr) {
+		return err
+	}
+	respErr := &ResponseError{Code: apiErr.ErrorCode(), Message: apiErr.ErrorMessage()}
+	var httpErr *awshttp.ResponseError
+	if errors.As(err, &httpErr) {
+		respErr.StatusCode = httpErr.HTTPStatusCode()
+	}
+	return respErr
+}
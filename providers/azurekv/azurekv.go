@@ -0,0 +1,379 @@
+// Package azurekv provides a vault implementation backed by Azure Key Vault
+// secrets (https://learn.microsoft.com/azure/key-vault/secrets/).
+//
+// Usage:
+//
+//	v, err := azurekv.New(azurekv.Config{
+//	    VaultURL:   "https://myvault.vault.azure.net",
+//	    Credential: cred,
+//	})
+//	secret, err := v.Get(ctx, "database-password")
+//
+// Paths are Key Vault secret names, which may only contain letters, digits,
+// and dashes. Append "#version" to a path to read a specific version.
+package azurekv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// Config holds configuration for the Azure Key Vault provider.
+type Config struct {
+	// VaultURL is the vault's URL, e.g. "https://myvault.vault.azure.net".
+	VaultURL string
+
+	// Credential provides access tokens for Key Vault. Required unless
+	// Client is set.
+	Credential Credential
+
+	// Client overrides the Key Vault API client, mainly for tests.
+	Client Client
+
+	// HTTPClient is the HTTP client used for API requests (default: 30s timeout).
+	HTTPClient *http.Client
+}
+
+// Credential provides OAuth access tokens for the Key Vault resource
+// (https://vault.azure.net). An azidentity credential can be adapted by
+// calling its GetToken method with the Key Vault scope.
+type Credential interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a Credential that always returns the same access token.
+type StaticToken string
+
+// Token returns the token.
+func (t StaticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+// Client is the part of the Key Vault secrets API used by the provider.
+// Errors for failed requests should be *ResponseError.
+type Client interface {
+	// GetSecret returns a version of a secret, or the latest if version
+	// is empty.
+	GetSecret(ctx context.Context, name, version string) (*SecretBundle, error)
+
+	// SetSecret stores a new version of a secret.
+	SetSecret(ctx context.Context, name string, params SetSecretParams) (*SecretBundle, error)
+
+	// DeleteSecret deletes a secret and all of its versions. On vaults
+	// with soft-delete the secret can be recovered until it is purged.
+	DeleteSecret(ctx context.Context, name string) error
+
+	// ListSecrets returns every secret in the vault, without values.
+	ListSecrets(ctx context.Context) ([]SecretItem, error)
+
+	// ListSecretVersions returns every version of a secret, without values.
+	ListSecretVersions(ctx context.Context, name string) ([]SecretItem, error)
+}
+
+// Attributes are the management attributes of a secret version.
+type Attributes struct {
+	Enabled *bool `json:"enabled,omitempty"`
+	Created int64 `json:"created,omitempty"` // Unix seconds
+	Updated int64 `json:"updated,omitempty"` // Unix seconds
+	Expires int64 `json:"exp,omitempty"`     // Unix seconds
+}
+
+// SecretBundle is a secret version including its value.
+type SecretBundle struct {
+	ID          string            `json:"id"`
+	Value       string            `json:"value"`
+	ContentType string            `json:"contentType,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Attributes  Attributes        `json:"attributes"`
+}
+
+// SecretItem is a secret or secret version without its value.
+type SecretItem struct {
+	ID          string            `json:"id"`
+	ContentType string            `json:"contentType,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Attributes  Attributes        `json:"attributes"`
+}
+
+// SetSecretParams are the parameters for setting a secret.
+type SetSecretParams struct {
+	Value       string            `json:"value"`
+	ContentType string            `json:"contentType,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+}
+
+// ResponseError is a failed Key Vault API request.
+type ResponseError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *ResponseError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s (status %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// secretName matches valid Key Vault secret names.
+var secretName = regexp.MustCompile(`^[0-9A-Za-z-]{1,127}$`)
+
+// Provider implements vault.ExtendedVault for Azure Key Vault.
+type Provider struct {
+	client Client
+}
+
+// New creates a new Azure Key Vault provider.
+func New(config Config) (*Provider, error) {
+	if config.Client != nil {
+		return &Provider{client: config.Client}, nil
+	}
+
+	if config.VaultURL == "" {
+		return nil, errors.New("vault URL is required")
+	}
+	if config.Credential == nil {
+		return nil, errors.New("credential is required")
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Provider{client: &restClient{
+		vaultURL:   strings.TrimSuffix(config.VaultURL, "/"),
+		credential: config.Credential,
+		http:       httpClient,
+	}}, nil
+}
+
+// splitVersion splits a "name#version" path.
+func splitVersion(path string) (name, version string, err error) {
+	name, version, _ = strings.Cut(path, "#")
+	if !secretName.MatchString(name) {
+		return "", "", vault.ErrInvalidPath
+	}
+	return name, version, nil
+}
+
+// Get retrieves the latest version of a secret, or the version given as
+// "name#version".
+func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	name, version, err := splitVersion(path)
+	if err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), err)
+	}
+	return p.get(ctx, "Get", path, name, version)
+}
+
+// GetVersion retrieves a specific version of a secret.
+func (p *Provider) GetVersion(ctx context.Context, path, version string) (*vault.Secret, error) {
+	name, _, err := splitVersion(path)
+	if err != nil {
+		return nil, vault.NewVaultError("GetVersion", path, p.Name(), err)
+	}
+	return p.get(ctx, "GetVersion", path, name, version)
+}
+
+// get fetches a secret version and converts it.
+func (p *Provider) get(ctx context.Context, op, path, name, version string) (*vault.Secret, error) {
+	bundle, err := p.client.GetSecret(ctx, name, version)
+	if err != nil {
+		err = mapError(err)
+		if version != "" && errors.Is(err, vault.ErrSecretNotFound) {
+			err = vault.ErrVersionNotFound
+		}
+		return nil, vault.NewVaultError(op, path, p.Name(), err)
+	}
+
+	extra := map[string]any{"id": bundle.ID}
+	if bundle.ContentType != "" {
+		extra["contentType"] = bundle.ContentType
+	}
+
+	return &vault.Secret{
+		Value: bundle.Value,
+		Metadata: vault.Metadata{
+			Provider:   p.Name(),
+			Path:       path,
+			Version:    versionOf(bundle.ID),
+			Tags:       bundle.Tags,
+			CreatedAt:  unixTimestamp(bundle.Attributes.Created),
+			ModifiedAt: unixTimestamp(bundle.Attributes.Updated),
+			ExpiresAt:  unixTimestamp(bundle.Attributes.Expires),
+			Extra:      extra,
+		},
+	}, nil
+}
+
+// Set stores a new version of a secret.
+func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	name, version, err := splitVersion(path)
+	if err == nil && version != "" {
+		err = vault.ErrInvalidPath
+	}
+	if err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+
+	params := SetSecretParams{Value: secret.String(), Tags: secret.Metadata.Tags}
+	if _, err := p.client.SetSecret(ctx, name, params); err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), mapError(err))
+	}
+	return nil
+}
+
+// Delete deletes a secret and all of its versions. On vaults with
+// soft-delete enabled the secret can be recovered until it is purged.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	name, _, err := splitVersion(path)
+	if err != nil {
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+
+	err = mapError(p.client.DeleteSecret(ctx, name))
+	if err != nil && !errors.Is(err, vault.ErrSecretNotFound) {
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+	return nil
+}
+
+// Exists checks if a secret exists.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	_, err := p.Get(ctx, path)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, vault.ErrSecretNotFound) || errors.Is(err, vault.ErrVersionNotFound) {
+		return false, nil
+	}
+	return false, err
+}
+
+// List returns the names of all secrets matching the prefix.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	items, err := p.client.ListSecrets(ctx)
+	if err != nil {
+		return nil, vault.NewVaultError("List", prefix, p.Name(), mapError(err))
+	}
+
+	var results []string
+	for _, item := range items {
+		if name := path.Base(item.ID); strings.HasPrefix(name, prefix) {
+			results = append(results, name)
+		}
+	}
+	sort.Strings(results)
+	return results, nil
+}
+
+// ListVersions returns all versions of a secret, oldest first.
+func (p *Provider) ListVersions(ctx context.Context, path string) ([]vault.Version, error) {
+	name, _, err := splitVersion(path)
+	if err != nil {
+		return nil, vault.NewVaultError("ListVersions", path, p.Name(), err)
+	}
+
+	items, err := p.client.ListSecretVersions(ctx, name)
+	if err != nil {
+		return nil, vault.NewVaultError("ListVersions", path, p.Name(), mapError(err))
+	}
+	if len(items) == 0 {
+		return nil, vault.NewVaultError("ListVersions", path, p.Name(), vault.ErrSecretNotFound)
+	}
+
+	latest, err := p.client.GetSecret(ctx, name, "")
+	if err != nil {
+		return nil, vault.NewVaultError("ListVersions", path, p.Name(), mapError(err))
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Attributes.Created < items[j].Attributes.Created
+	})
+
+	versions := make([]vault.Version, 0, len(items))
+	for _, item := range items {
+		id := versionOf(item.ID)
+		versions = append(versions, vault.Version{
+			ID:        id,
+			CreatedAt: unixTimestamp(item.Attributes.Created),
+			Current:   id == versionOf(latest.ID),
+		})
+	}
+	return versions, nil
+}
+
+// Rotate is not supported; Key Vault does not generate secret values.
+func (p *Provider) Rotate(_ context.Context, path string) (*vault.Secret, error) {
+	return nil, vault.NewVaultError("Rotate", path, p.Name(), vault.ErrNotSupported)
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "azure-kv"
+}
+
+// Capabilities returns the provider capabilities.
+func (p *Provider) Capabilities() vault.Capabilities {
+	return vault.Capabilities{
+		Read:       true,
+		Write:      true,
+		Delete:     true,
+		List:       true,
+		Versioning: true,
+	}
+}
+
+// Close is a no-op for the Azure Key Vault provider.
+func (p *Provider) Close() error {
+	return nil
+}
+
+// mapError converts Key Vault API errors to standard vault errors.
+func mapError(err error) error {
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) {
+		return err
+	}
+	switch respErr.StatusCode {
+	case http.StatusNotFound:
+		return vault.ErrSecretNotFound
+	case http.StatusUnauthorized:
+		return vault.ErrAuthenticationFailed
+	case http.StatusForbidden:
+		return vault.ErrAccessDenied
+	}
+	return err
+}
+
+// versionOf returns the version from a secret version ID such as
+// "https://myvault.vault.azure.net/secrets/name/version".
+func versionOf(id string) string {
+	parts := strings.Split(strings.TrimSuffix(id, "/"), "/")
+	if len(parts) >= 3 && parts[len(parts)-3] == "secrets" {
+		return parts[len(parts)-1]
+	}
+	return ""
+}
+
+// unixTimestamp converts Unix seconds to a timestamp, or nil if unset.
+func unixTimestamp(sec int64) *vault.Timestamp {
+	if sec == 0 {
+		return nil
+	}
+	return vault.NewTimestamp(time.Unix(sec, 0))
+}
+
+// Ensure Provider implements vault.ExtendedVault.
+var _ vault.ExtendedVault = (*Provider)(nil)
@@ -0,0 +1,272 @@
+package azurekv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+const testVaultURL = "https://test.vault.azure.net"
+
+// fakeClient is an in-memory Client keeping every version of each secret.
+type fakeClient struct {
+	versions map[string][]SecretBundle // name -> versions, oldest first
+	deleted  []string
+	clock    int64
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{versions: make(map[string][]SecretBundle)}
+}
+
+func notFound(name string) error {
+	return &ResponseError{StatusCode: http.StatusNotFound, Code: "SecretNotFound", Message: name}
+}
+
+func (c *fakeClient) GetSecret(_ context.Context, name, version string) (*SecretBundle, error) {
+	versions := c.versions[name]
+	if len(versions) == 0 {
+		return nil, notFound(name)
+	}
+	if version == "" {
+		return &versions[len(versions)-1], nil
+	}
+	for i := range versions {
+		if versionOf(versions[i].ID) == version {
+			return &versions[i], nil
+		}
+	}
+	return nil, notFound(name + "/" + version)
+}
+
+func (c *fakeClient) SetSecret(_ context.Context, name string, params SetSecretParams) (*SecretBundle, error) {
+	c.clock++
+	bundle := SecretBundle{
+		ID:         fmt.Sprintf("%s/secrets/%s/v%d", testVaultURL, name, c.clock),
+		Value:      params.Value,
+		Tags:       params.Tags,
+		Attributes: Attributes{Created: c.clock, Updated: c.clock},
+	}
+	c.versions[name] = append(c.versions[name], bundle)
+	return &bundle, nil
+}
+
+func (c *fakeClient) DeleteSecret(_ context.Context, name string) error {
+	if _, ok := c.versions[name]; !ok {
+		return notFound(name)
+	}
+	delete(c.versions, name)
+	c.deleted = append(c.deleted, name)
+	return nil
+}
+
+func (c *fakeClient) ListSecrets(context.Context) ([]SecretItem, error) {
+	var items []SecretItem
+	for name := range c.versions {
+		items = append(items, SecretItem{ID: testVaultURL + "/secrets/" + name})
+	}
+	return items, nil
+}
+
+func (c *fakeClient) ListSecretVersions(_ context.Context, name string) ([]SecretItem, error) {
+	var items []SecretItem
+	// Key Vault does not order versions
+	versions := c.versions[name]
+	for i := len(versions) - 1; i >= 0; i-- {
+		items = append(items, SecretItem{ID: versions[i].ID, Attributes: versions[i].Attributes})
+	}
+	return items, nil
+}
+
+func newTestProvider(t *testing.T) (*Provider, *fakeClient) {
+	t.Helper()
+	client := newFakeClient()
+	p, err := New(Config{Client: client})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return p, client
+}
+
+func TestGetSetList(t *testing.T) {
+	p, _ := newTestProvider(t)
+	ctx := context.Background()
+
+	secret := &vault.Secret{Value: "hunter2", Metadata: vault.Metadata{Tags: map[string]string{"env": "prod"}}}
+	if err := p.Set(ctx, "db-password", secret); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Set(ctx, "api-key", &vault.Secret{Value: "abc"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	got, err := p.Get(ctx, "db-password")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Value != "hunter2" || got.Metadata.Tags["env"] != "prod" || got.Metadata.Version != "v1" {
+		t.Errorf("Unexpected secret: %+v", got)
+	}
+
+	names, err := p.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"api-key", "db-password"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List = %v, want %v", names, want)
+	}
+
+	names, err = p.List(ctx, "db-")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"db-password"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List(db-) = %v, want %v", names, want)
+	}
+
+	if err := p.Set(ctx, "db/password", secret); !errors.Is(err, vault.ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath for an invalid name, got %v", err)
+	}
+}
+
+func TestVersions(t *testing.T) {
+	p, _ := newTestProvider(t)
+	ctx := context.Background()
+
+	for _, value := range []string{"one", "two", "three"} {
+		if err := p.Set(ctx, "token", &vault.Secret{Value: value}); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	if got, err := p.Get(ctx, "token"); err != nil || got.Value != "three" {
+		t.Errorf("Expected latest value 'three', got %v, %v", got, err)
+	}
+	if got, err := p.Get(ctx, "token#v1"); err != nil || got.Value != "one" {
+		t.Errorf("Expected value 'one' for token#v1, got %v, %v", got, err)
+	}
+	if got, err := p.GetVersion(ctx, "token", "v2"); err != nil || got.Value != "two" {
+		t.Errorf("Expected value 'two' for v2, got %v, %v", got, err)
+	}
+	if _, err := p.GetVersion(ctx, "token", "v9"); !errors.Is(err, vault.ErrVersionNotFound) {
+		t.Errorf("Expected ErrVersionNotFound, got %v", err)
+	}
+
+	versions, err := p.ListVersions(ctx, "token")
+	if err != nil {
+		t.Fatalf("ListVersions failed: %v", err)
+	}
+	var ids []string
+	for _, v := range versions {
+		ids = append(ids, v.ID)
+	}
+	if want := []string{"v1", "v2", "v3"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ListVersions = %v, want %v", ids, want)
+	}
+	if !versions[2].Current || versions[0].Current {
+		t.Errorf("Expected only the latest version to be current, got %+v", versions)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	p, client := newTestProvider(t)
+	ctx := context.Background()
+
+	if _, err := p.Get(ctx, "missing"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+	if exists, err := p.Exists(ctx, "missing"); err != nil || exists {
+		t.Errorf("Expected Exists = false, nil; got %v, %v", exists, err)
+	}
+	if err := p.Delete(ctx, "missing"); err != nil {
+		t.Errorf("Expected Delete of missing secret to succeed, got %v", err)
+	}
+
+	if err := p.Set(ctx, "gone", &vault.Secret{Value: "x"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Delete(ctx, "gone"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(client.deleted) != 1 || client.deleted[0] != "gone" {
+		t.Errorf("Expected secret to be deleted, got %v", client.deleted)
+	}
+}
+
+func TestRESTClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"code":"Unauthorized","message":"bad token"}}`))
+			return
+		}
+		if r.URL.Query().Get("api-version") != APIVersion {
+			http.Error(w, "missing api-version", http.StatusBadRequest)
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/secrets/db-password":
+			_ = json.NewEncoder(w).Encode(SecretBundle{
+				ID:    "https://test.vault.azure.net/secrets/db-password/abc123",
+				Value: "hunter2",
+			})
+		case r.Method == http.MethodGet && r.URL.Path == "/secrets" && r.URL.Query().Get("page") == "":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"value":    []SecretItem{{ID: "https://test.vault.azure.net/secrets/db-password"}},
+				"nextLink": "http://" + r.Host + "/secrets?api-version=" + APIVersion + "&page=2",
+			})
+		case r.Method == http.MethodGet && r.URL.Path == "/secrets":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"value": []SecretItem{{ID: "https://test.vault.azure.net/secrets/api-key"}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":"SecretNotFound","message":"not found"}}`))
+		}
+	}))
+	defer server.Close()
+
+	newProvider := func(token string) *Provider {
+		p, err := New(Config{VaultURL: server.URL, Credential: StaticToken(token), HTTPClient: server.Client()})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		return p
+	}
+	p := newProvider("valid")
+	ctx := context.Background()
+
+	secret, err := p.Get(ctx, "db-password")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if secret.Value != "hunter2" || secret.Metadata.Version != "abc123" {
+		t.Errorf("Unexpected secret: %+v", secret)
+	}
+
+	names, err := p.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"api-key", "db-password"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List = %v, want %v", names, want)
+	}
+
+	_, err = p.Get(ctx, "missing")
+	if !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+
+	_, err = newProvider("invalid").Get(ctx, "db-password")
+	if !errors.Is(err, vault.ErrAuthenticationFailed) {
+		t.Errorf("Expected ErrAuthenticationFailed, got %v", err)
+	}
+}
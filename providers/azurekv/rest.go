@@ -0,0 +1,145 @@
+package azurekv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// APIVersion is the Key Vault REST API version used.
+const APIVersion = "7.4"
+
+// restClient implements Client over the Key Vault REST API.
+type restClient struct {
+	vaultURL   string
+	credential Credential
+	http       *http.Client
+}
+
+// errorResponse is the error body returned by Key Vault.
+type errorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// listResponse is a page of secrets or secret versions.
+type listResponse struct {
+	Value    []SecretItem `json:"value"`
+	NextLink string       `json:"nextLink"`
+}
+
+func (c *restClient) GetSecret(ctx context.Context, name, version string) (*SecretBundle, error) {
+	endpoint := "/secrets/" + url.PathEscape(name)
+	if version != "" {
+		endpoint += "/" + url.PathEscape(version)
+	}
+
+	var bundle SecretBundle
+	if err := c.do(ctx, http.MethodGet, c.url(endpoint), nil, &bundle); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+func (c *restClient) SetSecret(ctx context.Context, name string, params SetSecretParams) (*SecretBundle, error) {
+	var bundle SecretBundle
+	if err := c.do(ctx, http.MethodPut, c.url("/secrets/"+url.PathEscape(name)), params, &bundle); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+func (c *restClient) DeleteSecret(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, c.url("/secrets/"+url.PathEscape(name)), nil, nil)
+}
+
+func (c *restClient) ListSecrets(ctx context.Context) ([]SecretItem, error) {
+	return c.list(ctx, c.url("/secrets"))
+}
+
+func (c *restClient) ListSecretVersions(ctx context.Context, name string) ([]SecretItem, error) {
+	return c.list(ctx, c.url("/secrets/"+url.PathEscape(name)+"/versions"))
+}
+
+// list follows nextLink until every page has been read.
+func (c *restClient) list(ctx context.Context, u string) ([]SecretItem, error) {
+	var items []SecretItem
+	for u != "" {
+		var page listResponse
+		if err := c.do(ctx, http.MethodGet, u, nil, &page); err != nil {
+			return nil, err
+		}
+		items = append(items, page.Value...)
+		u = page.NextLink
+	}
+	return items, nil
+}
+
+// url returns the URL of an API endpoint.
+func (c *restClient) url(endpoint string) string {
+	return c.vaultURL + endpoint + "?api-version=" + APIVersion
+}
+
+// do performs an authenticated API request and decodes the JSON response.
+func (c *restClient) do(ctx context.Context, method, u string, body, result any) error {
+	token, err := c.credential.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %v", vault.ErrAuthenticationFailed, err)
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		bodyReader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, bodyReader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", vault.ErrConnectionFailed, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		respErr := &ResponseError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(respBody))}
+		var errResp errorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error.Code != "" {
+			respErr.Code = errResp.Error.Code
+			respErr.Message = errResp.Error.Message
+		}
+		return respErr
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(respBody, result); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+	}
+	return nil
+}
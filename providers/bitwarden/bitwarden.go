@@ -0,0 +1,444 @@
+// Package bitwarden provides a vault implementation backed by Bitwarden
+// using the official bw command-line client.
+//
+// Usage:
+//
+//	v, err := bitwarden.New(bitwarden.Config{
+//	    Session: os.Getenv("BW_SESSION"),
+//	})
+//	secret, err := v.Get(ctx, "GitHub")
+//
+// Paths are item names or IDs. For login items the secret value is the
+// password; for other items it is the notes. The login username, password,
+// first URI and notes, plus any custom fields, are available in Fields.
+//
+// The vault must already be unlocked, e.g. with "bw unlock --raw", and the
+// session key passed in Config.Session or the BW_SESSION environment variable.
+package bitwarden
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// Default configuration values.
+const (
+	DefaultCommand = "bw"
+
+	// SessionEnv is the environment variable holding the bw session key.
+	SessionEnv = "BW_SESSION"
+)
+
+// Well-known field names mapped from Bitwarden login items.
+const (
+	FieldUsername = "username"
+	FieldPassword = "password"
+	FieldURI      = "uri"
+	FieldNotes    = "notes"
+	FieldTOTP     = "totp"
+)
+
+// itemTypeLogin is the Bitwarden item type for logins.
+const itemTypeLogin = 1
+
+// Runner executes the bw command with the given arguments and returns its
+// standard output. It is replaceable for testing.
+type Runner func(ctx context.Context, args ...string) ([]byte, error)
+
+// Config holds configuration for the Bitwarden provider.
+type Config struct {
+	// Session is the bw session key (default: $BW_SESSION).
+	Session string
+
+	// Command is the bw executable to invoke (default: "bw").
+	Command string
+
+	// Runner overrides how bw is executed. When nil, Command is run with
+	// the session key in its environment.
+	Runner Runner
+}
+
+// Provider implements vault.Vault for Bitwarden.
+type Provider struct {
+	run Runner
+}
+
+// New creates a new Bitwarden provider.
+func New(config Config) (*Provider, error) {
+	if config.Runner != nil {
+		return &Provider{run: config.Runner}, nil
+	}
+
+	if config.Command == "" {
+		config.Command = DefaultCommand
+	}
+	if config.Session == "" {
+		config.Session = os.Getenv(SessionEnv)
+	}
+	if _, err := exec.LookPath(config.Command); err != nil {
+		return nil, vault.NewVaultError("New", "", "bitwarden", vault.ErrNotSupported)
+	}
+
+	return &Provider{run: execRunner(config.Command, config.Session)}, nil
+}
+
+// execRunner returns a Runner that invokes the bw executable. The session
+// key is passed through the environment rather than the command line so it
+// doesn't show up in process listings.
+func execRunner(command, session string) Runner {
+	return func(ctx context.Context, args ...string) ([]byte, error) {
+		cmd := exec.CommandContext(ctx, command, args...)
+		cmd.Env = os.Environ()
+		if session != "" {
+			cmd.Env = append(cmd.Env, SessionEnv+"="+session)
+		}
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		if err := cmd.Run(); err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, errors.New(msg)
+			}
+			return nil, err
+		}
+		return stdout.Bytes(), nil
+	}
+}
+
+// item is the subset of a Bitwarden item used by the provider.
+type item struct {
+	ID           string  `json:"id"`
+	Type         int     `json:"type"`
+	Name         string  `json:"name"`
+	Notes        *string `json:"notes"`
+	Login        *login  `json:"login"`
+	Fields       []field `json:"fields"`
+	FolderID     *string `json:"folderId"`
+	RevisionDate string  `json:"revisionDate"`
+}
+
+type login struct {
+	Username *string `json:"username"`
+	Password *string `json:"password"`
+	TOTP     *string `json:"totp"`
+	URIs     []uri   `json:"uris"`
+}
+
+type uri struct {
+	URI string `json:"uri"`
+}
+
+type field struct {
+	Name  string  `json:"name"`
+	Value *string `json:"value"`
+	Type  int     `json:"type"`
+}
+
+// Get retrieves an item from Bitwarden.
+func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	it, _, err := p.getItem(ctx, path)
+	if err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), err)
+	}
+	return toSecret(it, path, p.Name()), nil
+}
+
+// Set creates or updates a login item in Bitwarden. The secret value is
+// stored as the password, and well-known fields as the corresponding login
+// properties; any other fields become custom fields. Properties of an
+// existing item that the secret doesn't set are preserved.
+func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	_, raw, err := p.getItem(ctx, path)
+	if err != nil && !errors.Is(err, vault.ErrSecretNotFound) {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+
+	exists := err == nil
+	if !exists {
+		raw = map[string]any{
+			"type":  itemTypeLogin,
+			"name":  path,
+			"login": map[string]any{},
+		}
+	}
+	applySecret(raw, secret)
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	if exists {
+		id, _ := raw["id"].(string)
+		_, err = p.exec(ctx, "edit", "item", id, encoded)
+	} else {
+		_, err = p.exec(ctx, "create", "item", encoded)
+	}
+	if err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+	return nil
+}
+
+// Delete moves an item to the Bitwarden trash.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	it, _, err := p.getItem(ctx, path)
+	if err != nil {
+		if errors.Is(err, vault.ErrSecretNotFound) {
+			return nil // Already deleted, not an error
+		}
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+
+	if _, err := p.exec(ctx, "delete", "item", it.ID); err != nil {
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+	return nil
+}
+
+// Exists checks if an item exists in Bitwarden.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	_, _, err := p.getItem(ctx, path)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return false, nil
+	}
+	return false, vault.NewVaultError("Exists", path, p.Name(), err)
+}
+
+// List returns the names of all items matching the prefix.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	args := []string{"list", "items"}
+	if prefix != "" {
+		args = append(args, "--search", prefix)
+	}
+
+	out, err := p.exec(ctx, args...)
+	if err != nil {
+		return nil, vault.NewVaultError("List", prefix, p.Name(), err)
+	}
+
+	var items []item
+	if err := json.Unmarshal(out, &items); err != nil {
+		return nil, vault.NewVaultError("List", prefix, p.Name(), fmt.Errorf("failed to parse items: %w", err))
+	}
+
+	// Search is fuzzy, so filter to a strict prefix match
+	var results []string
+	for _, it := range items {
+		if strings.HasPrefix(it.Name, prefix) {
+			results = append(results, it.Name)
+		}
+	}
+	sort.Strings(results)
+	return results, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "bitwarden"
+}
+
+// Capabilities returns the provider capabilities.
+func (p *Provider) Capabilities() vault.Capabilities {
+	return vault.Capabilities{
+		Read:       true,
+		Write:      true,
+		Delete:     true,
+		List:       true,
+		MultiField: true,
+	}
+}
+
+// Close is a no-op for the Bitwarden provider.
+func (p *Provider) Close() error {
+	return nil
+}
+
+// getItem fetches an item by name or ID, returning both the decoded item
+// and its raw JSON object so updates can preserve unknown properties.
+func (p *Provider) getItem(ctx context.Context, path string) (*item, map[string]any, error) {
+	if path == "" {
+		return nil, nil, vault.ErrInvalidPath
+	}
+
+	out, err := p.exec(ctx, "get", "item", path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var it item
+	if err := json.Unmarshal(out, &it); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse item: %w", err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse item: %w", err)
+	}
+	return &it, raw, nil
+}
+
+// exec runs bw and maps its error messages to standard vault errors.
+func (p *Provider) exec(ctx context.Context, args ...string) ([]byte, error) {
+	out, err := p.run(ctx, args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return out, nil
+}
+
+// mapError converts bw error output into standard vault errors.
+func mapError(err error) error {
+	msg := strings.TrimSuffix(strings.TrimSpace(err.Error()), ".")
+	switch {
+	case msg == "Not found":
+		return vault.ErrSecretNotFound
+	case msg == "You are not logged in", msg == "Vault is locked",
+		strings.Contains(msg, "Invalid master password"):
+		return fmt.Errorf("%w: %s", vault.ErrAuthenticationFailed, msg)
+	default:
+		return err
+	}
+}
+
+// toSecret converts a Bitwarden item to a secret.
+func toSecret(it *item, path, provider string) *vault.Secret {
+	secret := &vault.Secret{
+		Fields: make(map[string]string),
+		Metadata: vault.Metadata{
+			Provider: provider,
+			Path:     path,
+			Extra: map[string]any{
+				"id":   it.ID,
+				"name": it.Name,
+			},
+		},
+	}
+
+	if it.Login != nil {
+		setIfPresent(secret.Fields, FieldUsername, it.Login.Username)
+		setIfPresent(secret.Fields, FieldPassword, it.Login.Password)
+		setIfPresent(secret.Fields, FieldTOTP, it.Login.TOTP)
+		if len(it.Login.URIs) > 0 && it.Login.URIs[0].URI != "" {
+			secret.Fields[FieldURI] = it.Login.URIs[0].URI
+		}
+	}
+	setIfPresent(secret.Fields, FieldNotes, it.Notes)
+
+	for _, f := range it.Fields {
+		if f.Name != "" && f.Value != nil {
+			secret.Fields[f.Name] = *f.Value
+		}
+	}
+
+	if it.Type == itemTypeLogin && it.Login != nil && it.Login.Password != nil {
+		secret.Value = *it.Login.Password
+	} else if it.Notes != nil {
+		secret.Value = *it.Notes
+	}
+
+	if it.FolderID != nil {
+		secret.Metadata.Extra["folderId"] = *it.FolderID
+	}
+	if t, err := time.Parse(time.RFC3339, it.RevisionDate); err == nil {
+		secret.Metadata.ModifiedAt = &vault.Timestamp{Time: t}
+	}
+
+	return secret
+}
+
+// setIfPresent copies a non-empty optional value into fields.
+func setIfPresent(fields map[string]string, name string, value *string) {
+	if value != nil && *value != "" {
+		fields[name] = *value
+	}
+}
+
+// applySecret writes a secret's value and fields into a raw item object.
+// The value is stored as the password of login items and as the notes of
+// any other item type.
+func applySecret(raw map[string]any, secret *vault.Secret) {
+	// JSON numbers decode as float64
+	isLogin := raw["type"] == float64(itemTypeLogin) || raw["type"] == itemTypeLogin
+
+	loginObj, _ := raw["login"].(map[string]any)
+	if loginObj == nil {
+		loginObj = map[string]any{}
+	}
+
+	value := secret.String()
+	if !isLogin {
+		if value != "" {
+			raw["notes"] = value
+		}
+	} else if value != "" {
+		loginObj["password"] = value
+	} else if password, ok := secret.Fields[FieldPassword]; ok {
+		loginObj["password"] = password
+	}
+
+	var custom []any
+	if existing, ok := raw["fields"].([]any); ok {
+		custom = existing
+	}
+
+	names := make([]string, 0, len(secret.Fields))
+	for name := range secret.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := secret.Fields[name]
+		switch name {
+		case FieldPassword:
+			// Stored above
+		case FieldUsername:
+			loginObj["username"] = value
+		case FieldTOTP:
+			loginObj["totp"] = value
+		case FieldURI:
+			loginObj["uris"] = []any{map[string]any{"uri": value}}
+		case FieldNotes:
+			raw["notes"] = value
+		default:
+			custom = setCustomField(custom, name, value)
+		}
+	}
+
+	if isLogin {
+		raw["login"] = loginObj
+	}
+	if len(custom) > 0 {
+		raw["fields"] = custom
+	}
+}
+
+// setCustomField updates or appends a text custom field.
+func setCustomField(fields []any, name, value string) []any {
+	for _, f := range fields {
+		if m, ok := f.(map[string]any); ok && m["name"] == name {
+			m["value"] = value
+			return fields
+		}
+	}
+	return append(fields, map[string]any{"name": name, "value": value, "type": 0})
+}
+
+// Ensure Provider implements vault.Vault.
+var _ vault.Vault = (*Provider)(nil)
@@ -0,0 +1,266 @@
+package bitwarden
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// fakeBW replays recorded bw output keyed by command line and records calls.
+type fakeBW struct {
+	t         *testing.T
+	responses map[string]string // args joined by spaces -> fixture file or error
+	calls     [][]string
+}
+
+func newFakeBW(t *testing.T) *fakeBW {
+	return &fakeBW{t: t, responses: make(map[string]string)}
+}
+
+// on registers a fixture file to return for the given command line.
+func (f *fakeBW) on(cmdline, fixture string) {
+	f.responses[cmdline] = fixture
+}
+
+// fail registers an error message, as printed by bw, for the command line.
+func (f *fakeBW) fail(cmdline, message string) {
+	f.responses[cmdline] = "error:" + message
+}
+
+func (f *fakeBW) run(_ context.Context, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, args)
+
+	key := strings.Join(args, " ")
+	if args[0] == "create" || args[0] == "edit" {
+		key = strings.Join(args[:len(args)-1], " ")
+	}
+
+	resp, ok := f.responses[key]
+	if !ok {
+		return nil, errors.New("Not found.")
+	}
+	if msg, isErr := strings.CutPrefix(resp, "error:"); isErr {
+		return nil, errors.New(msg)
+	}
+	if resp == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join("testdata", resp))
+	if err != nil {
+		f.t.Fatalf("Failed to read fixture: %v", err)
+	}
+	return data, nil
+}
+
+// lastPayload decodes the base64 item JSON passed to the last create or edit.
+func (f *fakeBW) lastPayload() map[string]any {
+	f.t.Helper()
+	last := f.calls[len(f.calls)-1]
+	data, err := base64.StdEncoding.DecodeString(last[len(last)-1])
+	if err != nil {
+		f.t.Fatalf("Payload is not base64: %v", err)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(data, &payload); err != nil {
+		f.t.Fatalf("Payload is not JSON: %v", err)
+	}
+	return payload
+}
+
+func newTestProvider(t *testing.T) (*Provider, *fakeBW) {
+	t.Helper()
+	bw := newFakeBW(t)
+	p, err := New(Config{Runner: bw.run})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return p, bw
+}
+
+func TestGetLogin(t *testing.T) {
+	p, bw := newTestProvider(t)
+	bw.on("get item GitHub", "item_github.json")
+
+	secret, err := p.Get(context.Background(), "GitHub")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	if secret.Value != "hunter2" {
+		t.Errorf("Expected value 'hunter2', got %q", secret.Value)
+	}
+
+	want := map[string]string{
+		FieldUsername: "octocat",
+		FieldPassword: "hunter2",
+		FieldURI:      "https://github.com/login",
+		FieldNotes:    "Recovery codes in the safe",
+		"api_token":   "ghp_abc123",
+		"org":         "agentplexus",
+	}
+	if !reflect.DeepEqual(secret.Fields, want) {
+		t.Errorf("Fields = %v, want %v", secret.Fields, want)
+	}
+
+	if secret.Metadata.Extra["id"] != "2b1c7a3e-9f4d-4c2a-8e61-b0f3a9d15e27" {
+		t.Errorf("Expected item id in metadata, got %v", secret.Metadata.Extra["id"])
+	}
+	if secret.Metadata.ModifiedAt == nil || secret.Metadata.ModifiedAt.Year() != 2024 {
+		t.Errorf("Expected revision date in metadata, got %v", secret.Metadata.ModifiedAt)
+	}
+}
+
+func TestGetSecureNote(t *testing.T) {
+	p, bw := newTestProvider(t)
+	bw.on("get item ssh/config", "item_note.json")
+
+	secret, err := p.Get(context.Background(), "ssh/config")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if secret.Value != "Host *\n  IdentitiesOnly yes" {
+		t.Errorf("Expected notes as value, got %q", secret.Value)
+	}
+}
+
+func TestErrorMapping(t *testing.T) {
+	p, bw := newTestProvider(t)
+	bw.fail("get item missing", "Not found.")
+	bw.fail("get item locked", "Vault is locked.")
+	bw.fail("get item ambiguous", "More than one result was found. Try getting a specific object by `id` instead.")
+
+	ctx := context.Background()
+
+	if _, err := p.Get(ctx, "missing"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+	if exists, err := p.Exists(ctx, "missing"); err != nil || exists {
+		t.Errorf("Expected Exists = false, nil; got %v, %v", exists, err)
+	}
+	if err := p.Delete(ctx, "missing"); err != nil {
+		t.Errorf("Expected Delete of missing item to succeed, got %v", err)
+	}
+	if _, err := p.Get(ctx, "locked"); !errors.Is(err, vault.ErrAuthenticationFailed) {
+		t.Errorf("Expected ErrAuthenticationFailed, got %v", err)
+	}
+	if _, err := p.Get(ctx, "ambiguous"); err == nil || errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ambiguous match error, got %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	p, bw := newTestProvider(t)
+	bw.on("list items", "list_items.json")
+	bw.on("list items --search Git", "list_items.json")
+
+	ctx := context.Background()
+
+	all, err := p.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"GitHub", "GitLab", "ssh/config"}; !reflect.DeepEqual(all, want) {
+		t.Errorf("List = %v, want %v", all, want)
+	}
+
+	// The fuzzy search result is narrowed to a strict prefix
+	git, err := p.List(ctx, "Git")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"GitHub", "GitLab"}; !reflect.DeepEqual(git, want) {
+		t.Errorf("List(Git) = %v, want %v", git, want)
+	}
+}
+
+func TestSetCreate(t *testing.T) {
+	p, bw := newTestProvider(t)
+	bw.fail("get item new/login", "Not found.")
+	bw.on("create item", "")
+
+	secret := &vault.Secret{
+		Value: "s3cret",
+		Fields: map[string]string{
+			FieldUsername: "admin",
+			FieldURI:      "https://example.com",
+			"region":      "eu",
+		},
+	}
+	if err := p.Set(context.Background(), "new/login", secret); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	payload := bw.lastPayload()
+	if payload["name"] != "new/login" || payload["type"] != float64(itemTypeLogin) {
+		t.Errorf("Unexpected item: %v", payload)
+	}
+	login := payload["login"].(map[string]any)
+	if login["password"] != "s3cret" || login["username"] != "admin" {
+		t.Errorf("Unexpected login: %v", login)
+	}
+	uris := login["uris"].([]any)
+	if uris[0].(map[string]any)["uri"] != "https://example.com" {
+		t.Errorf("Unexpected uris: %v", uris)
+	}
+	fields := payload["fields"].([]any)
+	if len(fields) != 1 || fields[0].(map[string]any)["name"] != "region" {
+		t.Errorf("Unexpected custom fields: %v", fields)
+	}
+}
+
+func TestSetEdit(t *testing.T) {
+	p, bw := newTestProvider(t)
+	bw.on("get item GitHub", "item_github.json")
+	bw.on("edit item 2b1c7a3e-9f4d-4c2a-8e61-b0f3a9d15e27", "")
+
+	secret := &vault.Secret{
+		Value:  "newpass",
+		Fields: map[string]string{"org": "other"},
+	}
+	if err := p.Set(context.Background(), "GitHub", secret); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	payload := bw.lastPayload()
+	login := payload["login"].(map[string]any)
+	if login["password"] != "newpass" {
+		t.Errorf("Expected updated password, got %v", login["password"])
+	}
+	if login["username"] != "octocat" {
+		t.Errorf("Expected username to be preserved, got %v", login["username"])
+	}
+	if payload["favorite"] != false || payload["creationDate"] == nil {
+		t.Errorf("Expected unknown properties to be preserved, got %v", payload)
+	}
+
+	fields := payload["fields"].([]any)
+	if len(fields) != 2 {
+		t.Fatalf("Expected 2 custom fields, got %v", fields)
+	}
+	if org := fields[1].(map[string]any); org["name"] != "org" || org["value"] != "other" {
+		t.Errorf("Expected org field to be updated in place, got %v", org)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	p, bw := newTestProvider(t)
+	bw.on("get item GitHub", "item_github.json")
+	bw.on("delete item 2b1c7a3e-9f4d-4c2a-8e61-b0f3a9d15e27", "")
+
+	if err := p.Delete(context.Background(), "GitHub"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	last := bw.calls[len(bw.calls)-1]
+	if strings.Join(last, " ") != "delete item 2b1c7a3e-9f4d-4c2a-8e61-b0f3a9d15e27" {
+		t.Errorf("Expected delete by id, got %v", last)
+	}
+}
@@ -0,0 +1,182 @@
+// Package cache wraps a vault so secrets read from it are kept in memory
+// for a while, e.g. to avoid a round trip to a remote service on every
+// read.
+//
+// Usage:
+//
+//	v := cache.New(remote, 5*time.Minute)
+//	secret, err := v.Get(ctx, "api-key") // later reads within 5m are served from memory
+//
+// Only successful Gets are cached. Set and Delete go to the wrapped vault
+// and drop the path from the cache, and List is never cached. Changes made
+// to the wrapped vault by anyone else are not seen until the entry
+// expires, unless the wrapped vault implements vault.WatchableVault and
+// Watch is running, e.g. through Resolver.EnableAutoInvalidate: changed
+// paths are then dropped from the cache as they are reported.
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// Provider wraps a vault.Vault with a TTL cache of secrets by path.
+type Provider struct {
+	inner vault.Vault
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+// entry is a cached secret and its expiry time.
+type entry struct {
+	secret    *vault.Secret
+	expiresAt time.Time
+}
+
+// New wraps inner so that secrets read from it are cached for ttl.
+func New(inner vault.Vault, ttl time.Duration) *Provider {
+	return &Provider{
+		inner:   inner,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]entry),
+	}
+}
+
+// Middleware returns a vault.Middleware that wraps a vault with New, for
+// use with vault.Chain.
+func Middleware(ttl time.Duration) vault.Middleware {
+	return func(inner vault.Vault) vault.Vault {
+		return New(inner, ttl)
+	}
+}
+
+// Get returns the cached secret if present and not expired, and reads it
+// from the wrapped vault otherwise.
+func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	if secret, ok := p.cached(path); ok {
+		return secret, nil
+	}
+
+	secret, err := p.inner.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.entries[path] = entry{secret: secret.Clone(), expiresAt: p.now().Add(p.ttl)}
+	p.mu.Unlock()
+	return secret, nil
+}
+
+// Set stores a secret in the wrapped vault and drops it from the cache.
+func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	defer p.Invalidate(path)
+	return p.inner.Set(ctx, path, secret)
+}
+
+// Delete removes a secret from the wrapped vault and from the cache.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	defer p.Invalidate(path)
+	return p.inner.Delete(ctx, path)
+}
+
+// Exists reports true for a cached secret, and asks the wrapped vault
+// otherwise.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	if _, ok := p.cached(path); ok {
+		return true, nil
+	}
+	return p.inner.Exists(ctx, path)
+}
+
+// List returns secret paths matching the prefix from the wrapped vault.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	return p.inner.List(ctx, prefix)
+}
+
+// Name returns the name of the wrapped provider.
+func (p *Provider) Name() string {
+	return p.inner.Name()
+}
+
+// Capabilities returns the capabilities of the wrapped provider.
+func (p *Provider) Capabilities() vault.Capabilities {
+	return p.inner.Capabilities()
+}
+
+// Watch reports changes to secrets under prefix if the wrapped vault
+// supports watching, and returns vault.ErrNotSupported otherwise. Changed
+// paths are dropped from the cache before the event is delivered.
+func (p *Provider) Watch(ctx context.Context, prefix string) (<-chan vault.WatchEvent, error) {
+	w, ok := vault.AsWatchable(p.inner)
+	if !ok {
+		return nil, vault.NewVaultError("Watch", prefix, p.Name(), vault.ErrNotSupported)
+	}
+
+	events, err := w.Watch(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan vault.WatchEvent)
+	go func() {
+		defer close(out)
+		for event := range events {
+			p.Invalidate(event.Path)
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Close drops every cached secret and closes the wrapped provider.
+func (p *Provider) Close() error {
+	p.Clear()
+	return p.inner.Close()
+}
+
+// Invalidate drops a path from the cache.
+func (p *Provider) Invalidate(path string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.entries, path)
+}
+
+// Clear drops every cached secret.
+func (p *Provider) Clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.entries = make(map[string]entry)
+}
+
+// cached returns a copy of the cached secret if present and not expired.
+func (p *Provider) cached(path string) (*vault.Secret, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.entries[path]
+	if !ok {
+		return nil, false
+	}
+	if !p.now().Before(e.expiresAt) {
+		delete(p.entries, path)
+		return nil, false
+	}
+	return e.secret.Clone(), true
+}
+
+// Ensure Provider implements vault.Vault and vault.WatchableVault.
+var (
+	_ vault.Vault          = (*Provider)(nil)
+	_ vault.WatchableVault = (*Provider)(nil)
+)
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omnivault/providers/memory"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+func TestGetCachesUntilExpiry(t *testing.T) {
+	inner := memory.NewWithSecrets(map[string]string{"api-key": "one"})
+	p := New(inner, time.Minute)
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if secret, err := p.Get(ctx, "api-key"); err != nil || secret.Value != "one" {
+		t.Fatalf("Get = %v, %v", secret, err)
+	}
+
+	// Changed behind the cache's back
+	if err := inner.Set(ctx, "api-key", &vault.Secret{Value: "two"}); err != nil {
+		t.Fatal(err)
+	}
+	if secret, _ := p.Get(ctx, "api-key"); secret.Value != "one" {
+		t.Errorf("Expected the cached value, got %q", secret.Value)
+	}
+
+	now = now.Add(time.Minute)
+	if secret, _ := p.Get(ctx, "api-key"); secret.Value != "two" {
+		t.Errorf("Expected the expired entry to be read again, got %q", secret.Value)
+	}
+}
+
+func TestCachedSecretIsCopied(t *testing.T) {
+	p := New(memory.NewWithSecrets(map[string]string{"api-key": "one"}), time.Minute)
+	ctx := context.Background()
+
+	secret, err := p.Get(ctx, "api-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret.Value = "changed"
+
+	if secret, _ := p.Get(ctx, "api-key"); secret.Value != "one" {
+		t.Errorf("Expected the cache to be unaffected by the caller, got %q", secret.Value)
+	}
+}
+
+func TestWritesInvalidate(t *testing.T) {
+	p := New(memory.NewWithSecrets(map[string]string{"api-key": "one"}), time.Minute)
+	ctx := context.Background()
+
+	if _, err := p.Get(ctx, "api-key"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Set(ctx, "api-key", &vault.Secret{Value: "two"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if secret, _ := p.Get(ctx, "api-key"); secret.Value != "two" {
+		t.Errorf("Expected Set to invalidate the cache, got %q", secret.Value)
+	}
+
+	if err := p.Delete(ctx, "api-key"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := p.Get(ctx, "api-key"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound after Delete, got %v", err)
+	}
+	if exists, err := p.Exists(ctx, "api-key"); err != nil || exists {
+		t.Errorf("Expected Exists = false, nil; got %v, %v", exists, err)
+	}
+}
+
+func TestWatchNotSupported(t *testing.T) {
+	p := New(memory.New(), time.Minute)
+
+	if _, err := p.Watch(context.Background(), ""); !errors.Is(err, vault.ErrNotSupported) {
+		t.Errorf("Expected ErrNotSupported for an unwatchable vault, got %v", err)
+	}
+}
+
+func TestCloseClosesInner(t *testing.T) {
+	inner := memory.New()
+	p := New(inner, time.Minute)
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !inner.Closed() {
+		t.Error("Expected Close to close the wrapped provider")
+	}
+}
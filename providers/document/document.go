@@ -0,0 +1,522 @@
+// Package document provides a vault implementation that serves the leaf
+// values of a single YAML or JSON document as secrets, for small projects
+// that keep all their secrets in one file.
+//
+// Usage:
+//
+//	v, err := document.New(document.Config{
+//	    Path: "secrets.yaml",
+//	})
+//	secret, err := v.Get(ctx, "database/password")
+//
+// Paths are the keys leading to a value joined with "/" or ".", with
+// list elements addressed by index ("hosts/0"). Keys containing "/" or
+// "." can't be addressed. Mappings and lists are not secrets; only their
+// leaf values are.
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agentplexus/omnivault/internal/yaml"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// Config holds configuration for the document provider.
+type Config struct {
+	// Path is the document file.
+	Path string
+
+	// Format is the document format, FormatYAML or FormatJSON (default:
+	// from the file extension, YAML unless it is ".json").
+	Format string
+
+	// Writable enables Set and Delete, which write the whole document
+	// back, keeping its key order and nesting. Comments in YAML documents
+	// are not kept. A missing file is created by the first Set.
+	Writable bool
+
+	// Reload reads the document again when the file changes. Without it
+	// the document is read once, on first use.
+	Reload bool
+}
+
+// Provider implements vault.Vault over a YAML or JSON document.
+type Provider struct {
+	config Config
+
+	mu      sync.Mutex
+	root    []yaml.MapItem
+	loaded  bool
+	modTime time.Time
+	size    int64
+}
+
+// New creates a new document provider.
+func New(config Config) (*Provider, error) {
+	if config.Path == "" {
+		return nil, errors.New("path is required")
+	}
+	if config.Format == "" {
+		config.Format = formatOf(config.Path)
+	}
+	if config.Format != FormatYAML && config.Format != FormatJSON {
+		return nil, fmt.Errorf("unsupported format %q", config.Format)
+	}
+
+	return &Provider{config: config}, nil
+}
+
+// load reads the document if it has not been read, or, with Reload, if
+// the file changed. Callers must hold p.mu.
+func (p *Provider) load() error {
+	if p.loaded && !p.config.Reload {
+		return nil
+	}
+
+	info, err := os.Stat(p.config.Path)
+	if errors.Is(err, fs.ErrNotExist) && p.config.Writable {
+		p.root, p.loaded, p.modTime, p.size = []yaml.MapItem{}, true, time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if p.loaded && info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return nil
+	}
+
+	data, err := os.ReadFile(p.config.Path)
+	if err != nil {
+		return err
+	}
+	root, err := parse(data, p.config.Format)
+	if err != nil {
+		return err
+	}
+
+	p.root, p.loaded, p.modTime, p.size = root, true, info.ModTime(), info.Size()
+	return nil
+}
+
+// save writes the document back to the file, replacing it atomically.
+// Callers must hold p.mu.
+func (p *Provider) save() error {
+	data, err := encode(p.root, p.config.Format)
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(p.config.Path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p.config.Path), "."+filepath.Base(p.config.Path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), p.config.Path); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(p.config.Path); err == nil {
+		p.modTime, p.size = info.ModTime(), info.Size()
+	}
+	return nil
+}
+
+// splitPath splits a path into its keys.
+func splitPath(path string) ([]string, error) {
+	parts := strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '.' })
+	if len(parts) == 0 {
+		return nil, vault.ErrInvalidPath
+	}
+	return parts, nil
+}
+
+// Get retrieves the leaf value at a path.
+func (p *Provider) Get(_ context.Context, path string) (*vault.Secret, error) {
+	parts, err := splitPath(path)
+	if err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.load(); err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), err)
+	}
+
+	node, ok := lookup(p.root, parts)
+	if !ok || !isLeaf(node) {
+		return nil, vault.NewVaultError("Get", path, p.Name(), vault.ErrSecretNotFound)
+	}
+
+	secret := &vault.Secret{
+		Value: leafString(node),
+		Metadata: vault.Metadata{
+			Provider: p.Name(),
+			Path:     path,
+		},
+	}
+	if !p.modTime.IsZero() {
+		secret.Metadata.ModifiedAt = vault.NewTimestamp(p.modTime)
+	}
+	return secret, nil
+}
+
+// Set stores a value at a path and writes the document back. Missing
+// mappings along the path are created. A value replacing a number or
+// boolean keeps its type if it parses as one.
+func (p *Provider) Set(_ context.Context, path string, secret *vault.Secret) error {
+	if !p.config.Writable {
+		return vault.NewVaultError("Set", path, p.Name(), vault.ErrReadOnly)
+	}
+	parts, err := splitPath(path)
+	if err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.load(); err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+
+	root, err := setPath(p.root, parts, secret.String())
+	if err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+	p.root = root.([]yaml.MapItem)
+
+	if err := p.save(); err != nil {
+		// Read the file again rather than keep a change that wasn't saved
+		p.loaded = false
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+	return nil
+}
+
+// Delete removes the leaf value at a path and writes the document back.
+// The mappings that held it are kept, even if empty.
+func (p *Provider) Delete(_ context.Context, path string) error {
+	if !p.config.Writable {
+		return vault.NewVaultError("Delete", path, p.Name(), vault.ErrReadOnly)
+	}
+	parts, err := splitPath(path)
+	if err != nil {
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.load(); err != nil {
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+
+	root, found := deletePath(p.root, parts)
+	if !found {
+		return nil // Already deleted, not an error
+	}
+	p.root = root.([]yaml.MapItem)
+
+	if err := p.save(); err != nil {
+		p.loaded = false
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+	return nil
+}
+
+// Exists checks if a leaf value exists at a path.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	_, err := p.Get(ctx, path)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, vault.ErrSecretNotFound) || errors.Is(err, vault.ErrInvalidPath) {
+		return false, nil
+	}
+	return false, err
+}
+
+// List returns the paths of all leaf values matching the prefix, joined
+// with "/", in document order.
+func (p *Provider) List(_ context.Context, prefix string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.load(); err != nil {
+		return nil, vault.NewVaultError("List", prefix, p.Name(), err)
+	}
+
+	var results []string
+	walkLeaves(p.root, "", func(path string) {
+		if strings.HasPrefix(path, prefix) {
+			results = append(results, path)
+		}
+	})
+	return results, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "document"
+}
+
+// Capabilities returns the provider capabilities.
+func (p *Provider) Capabilities() vault.Capabilities {
+	return vault.Capabilities{
+		Read:   true,
+		Write:  p.config.Writable,
+		Delete: p.config.Writable,
+		List:   true,
+	}
+}
+
+// Close is a no-op for the document provider.
+func (p *Provider) Close() error {
+	return nil
+}
+
+// isLeaf reports whether a document node is a leaf value rather than a
+// mapping or list.
+func isLeaf(node any) bool {
+	switch node.(type) {
+	case []yaml.MapItem, map[string]any, []any:
+		return false
+	}
+	return true
+}
+
+// mapping returns the items of a mapping node. Flow mappings such as "{}"
+// are parsed as map[string]any rather than in order.
+func mapping(node any) ([]yaml.MapItem, bool) {
+	switch v := node.(type) {
+	case []yaml.MapItem:
+		return v, true
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		items := make([]yaml.MapItem, len(keys))
+		for i, k := range keys {
+			items[i] = yaml.MapItem{Key: k, Value: v[k]}
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+// leafString returns a leaf value as a string.
+func leafString(node any) string {
+	switch v := node.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(node)
+}
+
+// leafValue returns the node storing value in place of old, keeping the
+// type of a number or boolean if value parses as one.
+func leafValue(old any, value string) any {
+	switch old.(type) {
+	case float64:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case json.Number:
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return json.Number(value)
+		}
+	case bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// index returns the list index a key addresses in a list of length n.
+func index(key string, n int) (int, bool) {
+	i, err := strconv.Atoi(key)
+	return i, err == nil && i >= 0 && i < n
+}
+
+// lookup returns the node at the keys parts below node.
+func lookup(node any, parts []string) (any, bool) {
+	for _, key := range parts {
+		if items, ok := mapping(node); ok {
+			found := false
+			for _, item := range items {
+				if item.Key == key {
+					node, found = item.Value, true
+					break
+				}
+			}
+			if !found {
+				return nil, false
+			}
+			continue
+		}
+
+		list, ok := node.([]any)
+		if !ok {
+			return nil, false
+		}
+		i, ok := index(key, len(list))
+		if !ok {
+			return nil, false
+		}
+		node = list[i]
+	}
+	return node, true
+}
+
+// setPath stores value at the keys parts below node and returns the
+// updated node. A missing node is created as a mapping.
+func setPath(node any, parts []string, value string) (any, error) {
+	if len(parts) == 0 {
+		if !isLeaf(node) {
+			return nil, fmt.Errorf("%w: path holds a mapping or list", vault.ErrInvalidPath)
+		}
+		return leafValue(node, value), nil
+	}
+
+	if node == nil {
+		node = []yaml.MapItem{}
+	}
+	key := parts[0]
+	if v, ok := mapping(node); ok {
+		for i := range v {
+			if v[i].Key == key {
+				child, err := setPath(v[i].Value, parts[1:], value)
+				if err != nil {
+					return nil, err
+				}
+				v[i].Value = child
+				return v, nil
+			}
+		}
+		child, err := setPath(nil, parts[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		return append(v, yaml.MapItem{Key: key, Value: child}), nil
+	}
+	if v, ok := node.([]any); ok {
+		i, ok := index(key, len(v))
+		if !ok {
+			return nil, fmt.Errorf("%w: no list element %s", vault.ErrInvalidPath, key)
+		}
+		child, err := setPath(v[i], parts[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		v[i] = child
+		return v, nil
+	}
+	return nil, fmt.Errorf("%w: %s is below a value", vault.ErrInvalidPath, key)
+}
+
+// deletePath removes the leaf at the keys parts below node and returns
+// the updated node, and whether there was a leaf to remove.
+func deletePath(node any, parts []string) (any, bool) {
+	key := parts[0]
+	last := len(parts) == 1
+
+	if v, ok := mapping(node); ok {
+		for i := range v {
+			if v[i].Key != key {
+				continue
+			}
+			if last {
+				if !isLeaf(v[i].Value) {
+					return node, false
+				}
+				return append(v[:i:i], v[i+1:]...), true
+			}
+			child, found := deletePath(v[i].Value, parts[1:])
+			v[i].Value = child
+			return v, found
+		}
+		return node, false
+	}
+	if v, ok := node.([]any); ok {
+		i, ok := index(key, len(v))
+		if !ok {
+			return node, false
+		}
+		if last {
+			if !isLeaf(v[i]) {
+				return node, false
+			}
+			return append(v[:i:i], v[i+1:]...), true
+		}
+		child, found := deletePath(v[i], parts[1:])
+		v[i] = child
+		return v, found
+	}
+	return node, false
+}
+
+// walkLeaves calls fn with the path of every leaf below node.
+func walkLeaves(node any, path string, fn func(path string)) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "/" + key
+	}
+
+	if items, ok := mapping(node); ok {
+		for _, item := range items {
+			walkLeaves(item.Value, join(item.Key), fn)
+		}
+		return
+	}
+	if list, ok := node.([]any); ok {
+		for i, item := range list {
+			walkLeaves(item, join(strconv.Itoa(i)), fn)
+		}
+		return
+	}
+	fn(path)
+}
+
+// Ensure Provider implements vault.Vault.
+var _ vault.Vault = (*Provider)(nil)
@@ -0,0 +1,222 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+const testYAML = `# Secrets for the demo app
+database:
+  host: db.internal
+  port: 5432
+  password: hunter2
+api:
+  keys:
+    - abc
+    - def
+  enabled: true
+empty: {}
+`
+
+func writeDocument(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNestedLookup(t *testing.T) {
+	p, err := New(Config{Path: writeDocument(t, "secrets.yaml", testYAML)})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+
+	tests := map[string]string{
+		"database/password": "hunter2",
+		"database.host":     "db.internal",
+		"database/port":     "5432",
+		"api/keys/1":        "def",
+		"api.keys.0":        "abc",
+		"api/enabled":       "true",
+	}
+	for path, want := range tests {
+		secret, err := p.Get(ctx, path)
+		if err != nil {
+			t.Errorf("Get(%s) failed: %v", path, err)
+			continue
+		}
+		if secret.Value != want {
+			t.Errorf("Get(%s) = %q, want %q", path, secret.Value, want)
+		}
+	}
+
+	for _, path := range []string{"database/user", "database", "api/keys/2", "database/host/extra", "empty"} {
+		if _, err := p.Get(ctx, path); !errors.Is(err, vault.ErrSecretNotFound) {
+			t.Errorf("Get(%s): expected ErrSecretNotFound, got %v", path, err)
+		}
+	}
+
+	if err := p.Set(ctx, "database/password", &vault.Secret{Value: "x"}); !errors.Is(err, vault.ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly without Writable, got %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	p, err := New(Config{Path: writeDocument(t, "secrets.yaml", testYAML)})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+
+	got, err := p.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	want := []string{"database/host", "database/port", "database/password", "api/keys/0", "api/keys/1", "api/enabled"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("List = %v, want %v", got, want)
+	}
+
+	got, err = p.List(ctx, "api/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"api/keys/0", "api/keys/1", "api/enabled"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List(api/) = %v, want %v", got, want)
+	}
+}
+
+func TestWriteBackYAML(t *testing.T) {
+	path := writeDocument(t, "secrets.yaml", testYAML)
+	p, err := New(Config{Path: path, Writable: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+
+	for path, value := range map[string]string{
+		"database/password": "s3cret: new",
+		"database/port":     "5433",
+		"api/keys/0":        "xyz",
+		"cache/redis/url":   "redis://localhost",
+	} {
+		if err := p.Set(ctx, path, &vault.Secret{Value: value}); err != nil {
+			t.Fatalf("Set(%s) failed: %v", path, err)
+		}
+	}
+	if err := p.Delete(ctx, "api/enabled"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := p.Delete(ctx, "api/enabled"); err != nil {
+		t.Errorf("Expected Delete of a missing value to succeed, got %v", err)
+	}
+	if err := p.Set(ctx, "database", &vault.Secret{Value: "x"}); !errors.Is(err, vault.ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath when replacing a mapping, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `database:
+  host: db.internal
+  port: 5433
+  password: "s3cret: new"
+api:
+  keys:
+    - xyz
+    - def
+empty: {}
+cache:
+  redis:
+    url: redis://localhost
+`
+	if string(data) != want {
+		t.Errorf("Document =\n%s\nwant\n%s", data, want)
+	}
+
+	// A fresh provider reads the values back
+	reread, err := New(Config{Path: path})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if secret, err := reread.Get(ctx, "database/password"); err != nil || secret.Value != "s3cret: new" {
+		t.Errorf("Expected the written value to be read back, got %v, %v", secret, err)
+	}
+}
+
+func TestWriteBackJSON(t *testing.T) {
+	path := writeDocument(t, "secrets.json", `{"zeta": {"token": "a", "retries": 3}, "alpha": ["x"]}`)
+	p, err := New(Config{Path: path, Writable: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "zeta/token", &vault.Secret{Value: "b"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "zeta": {
+    "token": "b",
+    "retries": 3
+  },
+  "alpha": [
+    "x"
+  ]
+}
+`
+	if string(data) != want {
+		t.Errorf("Document =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := writeDocument(t, "secrets.yaml", "token: one\n")
+	cached, err := New(Config{Path: path})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	reloading, err := New(Config{Path: path, Reload: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, p := range []*Provider{cached, reloading} {
+		if secret, err := p.Get(ctx, "token"); err != nil || secret.Value != "one" {
+			t.Fatalf("Get failed: %v, %v", secret, err)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("token: two\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Make the change visible even on file systems with coarse timestamps
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	if secret, _ := cached.Get(ctx, "token"); secret.Value != "one" {
+		t.Errorf("Expected the document to be read once without Reload, got %q", secret.Value)
+	}
+	if secret, _ := reloading.Get(ctx, "token"); secret.Value != "two" {
+		t.Errorf("Expected Reload to read the changed document, got %q", secret.Value)
+	}
+}
@@ -0,0 +1,171 @@
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/agentplexus/omnivault/internal/yaml"
+)
+
+// Supported document formats.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// formatOf returns the format of a file from its extension.
+func formatOf(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// parse decodes a document into its top-level mapping, with mappings as
+// []yaml.MapItem in document order.
+func parse(data []byte, format string) ([]yaml.MapItem, error) {
+	var doc any
+	var err error
+	switch format {
+	case FormatYAML:
+		doc, err = yaml.ParseOrdered(data)
+	case FormatJSON:
+		doc, err = parseJSON(data)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+
+	switch doc := doc.(type) {
+	case []yaml.MapItem:
+		return doc, nil
+	case nil:
+		return []yaml.MapItem{}, nil // empty file
+	}
+	return nil, errors.New("document is not a mapping")
+}
+
+// encode encodes a document in format, keeping its key order.
+func encode(root []yaml.MapItem, format string) ([]byte, error) {
+	if format == FormatYAML {
+		return yaml.Encode(root), nil
+	}
+
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, root, ""); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// parseJSON decodes JSON, keeping the order of object keys and the text
+// of numbers.
+func parseJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := parseJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err == nil {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return v, nil
+}
+
+func parseJSONValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		items := []yaml.MapItem{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := parseJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, yaml.MapItem{Key: key.(string), Value: value})
+		}
+		_, err := dec.Token()
+		return items, err
+	case json.Delim('['):
+		list := []any{}
+		for dec.More() {
+			value, err := parseJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := dec.Token()
+		return list, err
+	}
+	return tok, nil
+}
+
+// encodeJSON writes v as indented JSON, with []yaml.MapItem objects in
+// order.
+func encodeJSON(buf *bytes.Buffer, v any, indent string) error {
+	switch val := v.(type) {
+	case []yaml.MapItem:
+		if len(val) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for i, item := range val {
+			key, err := json.Marshal(item.Key)
+			if err != nil {
+				return err
+			}
+			buf.WriteString(indent + "  ")
+			buf.Write(key)
+			buf.WriteString(": ")
+			if err := encodeJSON(buf, item.Value, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(val)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "}")
+	case []any:
+		if len(val) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for i, item := range val {
+			buf.WriteString(indent + "  ")
+			if err := encodeJSON(buf, item, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(val)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "]")
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+// Package doppler provides a vault implementation backed by Doppler
+// (https://www.doppler.com) using its REST API.
+//
+// Usage:
+//
+//	v, err := doppler.New(doppler.Config{
+//	    Token:   os.Getenv("DOPPLER_TOKEN"),
+//	    Project: "backend",
+//	    Config:  "prd",
+//	})
+//	secret, err := v.Get(ctx, "DATABASE_URL")
+//
+// Paths may also be fully qualified as "project/config/NAME", matching
+// doppler://project/config/NAME references, in which case the configured
+// project and config are ignored.
+package doppler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// DefaultBaseURL is the Doppler API base URL.
+const DefaultBaseURL = "https://api.doppler.com"
+
+// Config holds configuration for the Doppler provider.
+type Config struct {
+	// Token is a Doppler service or personal token.
+	Token string
+
+	// Project is the default Doppler project.
+	Project string
+
+	// Config is the default Doppler config (environment), e.g. "dev" or "prd".
+	Config string
+
+	// BaseURL overrides the API base URL (default: https://api.doppler.com).
+	BaseURL string
+
+	// HTTPClient is the HTTP client used for API requests (default: 30s timeout).
+	HTTPClient *http.Client
+}
+
+// Provider implements vault.Vault for Doppler.
+type Provider struct {
+	config Config
+	client *http.Client
+}
+
+// New creates a new Doppler provider.
+func New(config Config) (*Provider, error) {
+	if config.Token == "" {
+		return nil, errors.New("token is required")
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Provider{config: config, client: client}, nil
+}
+
+// location identifies a secret within Doppler.
+type location struct {
+	project string
+	config  string
+	name    string
+}
+
+// locate resolves a path to a project, config and secret name.
+func (p *Provider) locate(path string) (location, error) {
+	loc := location{project: p.config.Project, config: p.config.Config, name: path}
+	if parts := strings.Split(path, "/"); len(parts) == 3 {
+		loc = location{project: parts[0], config: parts[1], name: parts[2]}
+	}
+	if loc.project == "" || loc.config == "" || loc.name == "" {
+		return loc, vault.ErrInvalidPath
+	}
+	return loc, nil
+}
+
+// locateScope resolves a list prefix to a project, config and name prefix.
+func (p *Provider) locateScope(prefix string) location {
+	if parts := strings.SplitN(prefix, "/", 3); len(parts) == 3 {
+		return location{project: parts[0], config: parts[1], name: parts[2]}
+	}
+	return location{project: p.config.Project, config: p.config.Config, name: prefix}
+}
+
+// secretResponse is the response from the single secret endpoint.
+type secretResponse struct {
+	Name  string `json:"name"`
+	Value struct {
+		Raw      string `json:"raw"`
+		Computed string `json:"computed"`
+	} `json:"value"`
+}
+
+// namesResponse is the response from the secret names endpoint.
+type namesResponse struct {
+	Names []string `json:"names"`
+}
+
+// Get retrieves a secret from Doppler.
+func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	loc, err := p.locate(path)
+	if err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), err)
+	}
+
+	query := url.Values{"project": {loc.project}, "config": {loc.config}, "name": {loc.name}}
+	var resp secretResponse
+	if err := p.do(ctx, http.MethodGet, "/v3/configs/config/secret", query, nil, &resp); err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), err)
+	}
+
+	return &vault.Secret{
+		Value: resp.Value.Computed,
+		Metadata: vault.Metadata{
+			Provider: p.Name(),
+			Path:     path,
+			Extra: map[string]any{
+				"project": loc.project,
+				"config":  loc.config,
+			},
+		},
+	}, nil
+}
+
+// Set creates or updates a secret in Doppler.
+func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	loc, err := p.locate(path)
+	if err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+
+	body := map[string]any{
+		"project": loc.project,
+		"config":  loc.config,
+		"secrets": map[string]string{loc.name: secret.String()},
+	}
+	if err := p.do(ctx, http.MethodPost, "/v3/configs/config/secrets", nil, body, nil); err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+	return nil
+}
+
+// Delete removes a secret from Doppler.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	loc, err := p.locate(path)
+	if err != nil {
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+
+	query := url.Values{"project": {loc.project}, "config": {loc.config}, "name": {loc.name}}
+	err = p.do(ctx, http.MethodDelete, "/v3/configs/config/secret", query, nil, nil)
+	if err != nil && !errors.Is(err, vault.ErrSecretNotFound) {
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+	return nil
+}
+
+// Exists checks if a secret exists in Doppler.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	_, err := p.Get(ctx, path)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return false, nil
+	}
+	return false, err
+}
+
+// List returns the names of all secrets in the config matching the prefix.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	loc := p.locateScope(prefix)
+	if loc.project == "" || loc.config == "" {
+		return nil, vault.NewVaultError("List", prefix, p.Name(), vault.ErrInvalidPath)
+	}
+
+	query := url.Values{"project": {loc.project}, "config": {loc.config}}
+	var resp namesResponse
+	if err := p.do(ctx, http.MethodGet, "/v3/configs/config/secrets/names", query, nil, &resp); err != nil {
+		return nil, vault.NewVaultError("List", prefix, p.Name(), err)
+	}
+
+	// Keep results in the same form as the prefix that was given
+	qualifier := strings.TrimSuffix(prefix, loc.name)
+
+	var results []string
+	for _, name := range resp.Names {
+		if strings.HasPrefix(name, loc.name) {
+			results = append(results, qualifier+name)
+		}
+	}
+	sort.Strings(results)
+	return results, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "doppler"
+}
+
+// Capabilities returns the provider capabilities.
+func (p *Provider) Capabilities() vault.Capabilities {
+	return vault.Capabilities{
+		Read:   true,
+		Write:  true,
+		Delete: true,
+		List:   true,
+	}
+}
+
+// Close is a no-op for the Doppler provider.
+func (p *Provider) Close() error {
+	return nil
+}
+
+// do performs an authenticated API request and decodes the JSON response.
+func (p *Provider) do(ctx context.Context, method, endpoint string, query url.Values, body, result any) error {
+	u := p.config.BaseURL + endpoint
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		bodyReader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, bodyReader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.config.Token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", vault.ErrConnectionFailed, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return vault.ErrAuthenticationFailed
+	case resp.StatusCode == http.StatusForbidden:
+		return vault.ErrAccessDenied
+	case resp.StatusCode == http.StatusNotFound:
+		return vault.ErrSecretNotFound
+	case resp.StatusCode >= 400:
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(respBody, result); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ensure Provider implements vault.Vault.
+var _ vault.Vault = (*Provider)(nil)
@@ -0,0 +1,168 @@
+package doppler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// newMockServer returns a minimal Doppler API mock holding secrets for
+// project "backend", config "dev".
+func newMockServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var mu sync.Mutex
+	secrets := map[string]string{"API_KEY": "key123", "DB_URL": "postgres://"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/configs/config/secret", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		name := r.URL.Query().Get("name")
+		value, ok := secrets[name]
+		if !ok {
+			http.Error(w, `{"messages":["Could not find secret"]}`, http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(secrets, name)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"name":  name,
+			"value": map[string]string{"raw": value, "computed": value},
+		})
+	})
+	mux.HandleFunc("/v3/configs/config/secrets", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var body struct {
+			Secrets map[string]string `json:"secrets"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for k, v := range body.Secrets {
+			secrets[k] = v
+		}
+		_, _ = w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/v3/configs/config/secrets/names", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		names := make([]string, 0, len(secrets))
+		for k := range secrets {
+			names = append(names, k)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"names": names})
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer dp.st.valid" {
+			http.Error(w, `{"messages":["Invalid Auth token"]}`, http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("project") != "backend" && r.Method != http.MethodPost {
+			http.Error(w, `{"messages":["Could not find project"]}`, http.StatusNotFound)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestProvider(t *testing.T, server *httptest.Server, token string) *Provider {
+	t.Helper()
+
+	p, err := New(Config{
+		Token:      token,
+		Project:    "backend",
+		Config:     "dev",
+		BaseURL:    server.URL,
+		HTTPClient: server.Client(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	return p
+}
+
+func TestDopplerGetSetList(t *testing.T) {
+	server := newMockServer(t)
+	p := newTestProvider(t, server, "dp.st.valid")
+	ctx := context.Background()
+
+	secret, err := p.Get(ctx, "API_KEY")
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if secret.Value != "key123" {
+		t.Errorf("Expected value 'key123', got '%s'", secret.Value)
+	}
+
+	// Fully qualified path form
+	secret, err = p.Get(ctx, "backend/dev/DB_URL")
+	if err != nil {
+		t.Fatalf("Failed to get qualified secret: %v", err)
+	}
+	if secret.Value != "postgres://" {
+		t.Errorf("Expected value 'postgres://', got '%s'", secret.Value)
+	}
+
+	if err := p.Set(ctx, "NEW_SECRET", &vault.Secret{Value: "new"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+
+	names, err := p.List(ctx, "")
+	if err != nil {
+		t.Fatalf("Failed to list secrets: %v", err)
+	}
+	if len(names) != 3 || names[2] != "NEW_SECRET" {
+		t.Errorf("Expected 3 sorted names ending in NEW_SECRET, got %v", names)
+	}
+
+	names, err = p.List(ctx, "backend/dev/DB")
+	if err != nil {
+		t.Fatalf("Failed to list qualified secrets: %v", err)
+	}
+	if len(names) != 1 || names[0] != "backend/dev/DB_URL" {
+		t.Errorf("Expected [backend/dev/DB_URL], got %v", names)
+	}
+}
+
+func TestDopplerNotFound(t *testing.T) {
+	server := newMockServer(t)
+	p := newTestProvider(t, server, "dp.st.valid")
+	ctx := context.Background()
+
+	_, err := p.Get(ctx, "MISSING")
+	if !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+
+	exists, err := p.Exists(ctx, "MISSING")
+	if err != nil || exists {
+		t.Errorf("Expected Exists to return false, nil; got %v, %v", exists, err)
+	}
+}
+
+func TestDopplerAuthFailure(t *testing.T) {
+	server := newMockServer(t)
+	p := newTestProvider(t, server, "dp.st.invalid")
+
+	_, err := p.Get(context.Background(), "API_KEY")
+	if !errors.Is(err, vault.ErrAuthenticationFailed) {
+		t.Errorf("Expected ErrAuthenticationFailed, got %v", err)
+	}
+}
@@ -7,12 +7,17 @@
 //
 // This provider is read-only by default. Writing to environment variables
 // is possible but only affects the current process.
+//
+// Set Config.Allow and Config.Deny to limit which variables are exposed,
+// for example before registering the provider with a resolver.
 package env
 
 import (
 	"context"
 	"os"
+	"path"
 	"strings"
+	"sync/atomic"
 
 	"github.com/agentplexus/omnivault/vault"
 )
@@ -26,11 +31,22 @@ type Config struct {
 	// AllowWrite enables writing to environment variables.
 	// Note: This only affects the current process.
 	AllowWrite bool
+
+	// Allow lists glob patterns (see path.Match) of the variable names to
+	// expose, including Prefix, e.g. "MYAPP_*". When empty, every variable
+	// not denied is exposed.
+	Allow []string
+
+	// Deny lists glob patterns of variable names to hide, e.g. "AWS_*".
+	// Deny overrides Allow. Hidden variables are not listed, and reading
+	// them returns vault.ErrSecretNotFound.
+	Deny []string
 }
 
 // Provider implements vault.Vault for environment variables.
 type Provider struct {
 	config Config
+	closed atomic.Bool
 }
 
 // New creates a new environment variable provider.
@@ -45,9 +61,13 @@ func NewWithConfig(config Config) *Provider {
 
 // Get retrieves an environment variable value.
 func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	if p.closed.Load() {
+		return nil, vault.NewVaultError("Get", path, p.Name(), vault.ErrClosed)
+	}
+
 	name := p.config.Prefix + path
 	value, ok := os.LookupEnv(name)
-	if !ok {
+	if !ok || !p.permitted(name) {
 		return nil, vault.NewVaultError("Get", path, p.Name(), vault.ErrSecretNotFound)
 	}
 	return &vault.Secret{
@@ -61,38 +81,60 @@ func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error)
 
 // Set sets an environment variable.
 func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	if p.closed.Load() {
+		return vault.NewVaultError("Set", path, p.Name(), vault.ErrClosed)
+	}
+
 	if !p.config.AllowWrite {
 		return vault.NewVaultError("Set", path, p.Name(), vault.ErrReadOnly)
 	}
 	name := p.config.Prefix + path
+	if !p.permitted(name) {
+		return vault.NewVaultError("Set", path, p.Name(), vault.ErrAccessDenied)
+	}
 	return os.Setenv(name, secret.String())
 }
 
 // Delete unsets an environment variable.
 func (p *Provider) Delete(ctx context.Context, path string) error {
+	if p.closed.Load() {
+		return vault.NewVaultError("Delete", path, p.Name(), vault.ErrClosed)
+	}
+
 	if !p.config.AllowWrite {
 		return vault.NewVaultError("Delete", path, p.Name(), vault.ErrReadOnly)
 	}
 	name := p.config.Prefix + path
+	if !p.permitted(name) {
+		return vault.NewVaultError("Delete", path, p.Name(), vault.ErrAccessDenied)
+	}
 	return os.Unsetenv(name)
 }
 
 // Exists checks if an environment variable is set.
 func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	if p.closed.Load() {
+		return false, vault.NewVaultError("Exists", path, p.Name(), vault.ErrClosed)
+	}
+
 	name := p.config.Prefix + path
 	_, ok := os.LookupEnv(name)
-	return ok, nil
+	return ok && p.permitted(name), nil
 }
 
 // List returns all environment variable names matching the prefix.
 func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	if p.closed.Load() {
+		return nil, vault.NewVaultError("List", prefix, p.Name(), vault.ErrClosed)
+	}
+
 	fullPrefix := p.config.Prefix + prefix
 	var results []string
 	for _, env := range os.Environ() {
 		parts := strings.SplitN(env, "=", 2)
 		if len(parts) >= 1 {
 			name := parts[0]
-			if strings.HasPrefix(name, fullPrefix) {
+			if strings.HasPrefix(name, fullPrefix) && p.permitted(name) {
 				// Remove the config prefix from the result
 				result := strings.TrimPrefix(name, p.config.Prefix)
 				results = append(results, result)
@@ -102,6 +144,26 @@ func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
 	return results, nil
 }
 
+// permitted reports whether the variable name is exposed by the Allow and
+// Deny patterns.
+func (p *Provider) permitted(name string) bool {
+	if matchAny(p.config.Deny, name) {
+		return false
+	}
+	return len(p.config.Allow) == 0 || matchAny(p.config.Allow, name)
+}
+
+// matchAny reports whether name matches any of the patterns. Malformed
+// patterns match nothing.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // Name returns the provider name.
 func (p *Provider) Name() string {
 	return "env"
@@ -117,10 +179,17 @@ func (p *Provider) Capabilities() vault.Capabilities {
 	}
 }
 
-// Close is a no-op for environment variables.
+// Close marks the provider as closed. Later operations return
+// vault.ErrClosed. Closing more than once is safe.
 func (p *Provider) Close() error {
+	p.closed.Store(true)
 	return nil
 }
 
+// Closed returns true if the provider has been closed.
+func (p *Provider) Closed() bool {
+	return p.closed.Load()
+}
+
 // Ensure Provider implements vault.Vault.
 var _ vault.Vault = (*Provider)(nil)
@@ -0,0 +1,113 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+func TestCloseTwice(t *testing.T) {
+	t.Setenv("OMNIVAULT_TEST_SECRET", "value")
+	p := New()
+	ctx := context.Background()
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("Second Close failed: %v", err)
+	}
+	if !p.Closed() {
+		t.Error("Expected Closed() to be true")
+	}
+
+	if _, err := p.Get(ctx, "OMNIVAULT_TEST_SECRET"); !errors.Is(err, vault.ErrClosed) {
+		t.Errorf("Get: expected ErrClosed, got %v", err)
+	}
+	if _, err := p.Exists(ctx, "OMNIVAULT_TEST_SECRET"); !errors.Is(err, vault.ErrClosed) {
+		t.Errorf("Exists: expected ErrClosed, got %v", err)
+	}
+	if _, err := p.List(ctx, ""); !errors.Is(err, vault.ErrClosed) {
+		t.Errorf("List: expected ErrClosed, got %v", err)
+	}
+}
+
+func TestAllowDeny(t *testing.T) {
+	t.Setenv("OVTEST_APP_TOKEN", "token")
+	t.Setenv("OVTEST_APP_DEBUG", "1")
+	t.Setenv("OVTEST_AWS_SECRET_ACCESS_KEY", "aws")
+	t.Setenv("OVTEST_PATH", "/bin")
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		allow   []string
+		deny    []string
+		visible []string
+	}{
+		{"no patterns", nil, nil, []string{"OVTEST_APP_DEBUG", "OVTEST_APP_TOKEN", "OVTEST_AWS_SECRET_ACCESS_KEY", "OVTEST_PATH"}},
+		{"allow", []string{"OVTEST_APP_*"}, nil, []string{"OVTEST_APP_DEBUG", "OVTEST_APP_TOKEN"}},
+		{"deny only", nil, []string{"OVTEST_AWS_*", "OVTEST_PATH"}, []string{"OVTEST_APP_DEBUG", "OVTEST_APP_TOKEN"}},
+		{"deny overrides allow", []string{"OVTEST_*"}, []string{"*_SECRET_*", "OVTEST_APP_DEBU?"}, []string{"OVTEST_APP_TOKEN", "OVTEST_PATH"}},
+		{"malformed pattern", []string{"OVTEST_[", "OVTEST_PATH"}, nil, []string{"OVTEST_PATH"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewWithConfig(Config{Allow: tt.allow, Deny: tt.deny})
+
+			names, err := p.List(ctx, "OVTEST_")
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.visible) {
+				t.Errorf("List = %v, want %v", names, tt.visible)
+			}
+
+			for _, name := range []string{"OVTEST_APP_TOKEN", "OVTEST_APP_DEBUG", "OVTEST_AWS_SECRET_ACCESS_KEY", "OVTEST_PATH"} {
+				want := slices.Contains(tt.visible, name)
+				_, err := p.Get(ctx, name)
+				if want && err != nil {
+					t.Errorf("Get(%s) failed: %v", name, err)
+				}
+				if !want && !errors.Is(err, vault.ErrSecretNotFound) {
+					t.Errorf("Get(%s): expected ErrSecretNotFound, got %v", name, err)
+				}
+				if exists, _ := p.Exists(ctx, name); exists != want {
+					t.Errorf("Exists(%s) = %v, want %v", name, exists, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAllowDenyWithPrefix(t *testing.T) {
+	t.Setenv("OVTEST_DB_PASSWORD", "hunter2")
+	t.Setenv("OVTEST_DB_ROOT_PASSWORD", "root")
+	ctx := context.Background()
+
+	// Patterns match the full variable name, including the prefix
+	p := NewWithConfig(Config{Prefix: "OVTEST_", Deny: []string{"OVTEST_*ROOT*"}, AllowWrite: true})
+
+	names, err := p.List(ctx, "DB_")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"DB_PASSWORD"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List = %v, want %v", names, want)
+	}
+	if _, err := p.Get(ctx, "DB_ROOT_PASSWORD"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+	if err := p.Set(ctx, "DB_ROOT_PASSWORD", &vault.Secret{Value: "x"}); !errors.Is(err, vault.ErrAccessDenied) {
+		t.Errorf("Set: expected ErrAccessDenied, got %v", err)
+	}
+	if err := p.Delete(ctx, "DB_ROOT_PASSWORD"); !errors.Is(err, vault.ErrAccessDenied) {
+		t.Errorf("Delete: expected ErrAccessDenied, got %v", err)
+	}
+}
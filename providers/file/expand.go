@@ -0,0 +1,74 @@
+package file
+
+import (
+	"os"
+	"strings"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// expandSecret expands environment variable references in the value and
+// field values of secret, for Config.ExpandEnv.
+func expandSecret(secret *vault.Secret) {
+	secret.Value = expandEnv(secret.Value)
+	for k, v := range secret.Fields {
+		secret.Fields[k] = expandEnv(v)
+	}
+}
+
+// expandEnv replaces ${VAR} with the value of the environment variable VAR,
+// or the empty string if it is unset, and ${VAR:-default} with default if
+// VAR is unset or empty. Other uses of "$", including $VAR without braces
+// and unterminated or malformed references, are left as they are.
+func expandEnv(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		name, fallback, hasDefault := strings.Cut(s[start+2:end], ":-")
+		if !validEnvName(name) {
+			b.WriteString(s[:start+2])
+			s = s[start+2:]
+			continue
+		}
+
+		b.WriteString(s[:start])
+		value := os.Getenv(name)
+		if value == "" && hasDefault {
+			value = fallback
+		}
+		b.WriteString(value)
+		s = s[end+1:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// validEnvName reports whether name is a letter or underscore followed by
+// letters, digits and underscores.
+func validEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case i > 0 && c >= '0' && c <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
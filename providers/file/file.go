@@ -7,16 +7,27 @@
 //	    Directory: "/path/to/secrets",
 //	})
 //	secret, err := v.Get(ctx, "api-key")  // reads /path/to/secrets/api-key
+//
+// Files hold the plain secret value by default. Set Config.Format to store
+// multi-field secrets as JSON, as a flat YAML mapping, or as env-style
+// KEY=VALUE lines, which keeps the directory easy to edit by hand.
+//
+// Set Config.GroupByDir to read directories laid out like Kubernetes CSI
+// secret mounts, where each directory is a secret and each file in it is a
+// field.
 package file
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/agentplexus/omnivault/vault"
 )
@@ -29,7 +40,12 @@ type Config struct {
 	// Extension is the file extension for secret files (default: none).
 	Extension string
 
-	// JSONFormat stores secrets as JSON with metadata (default: false, plain text).
+	// Format is the serialization format of secret files: FormatText
+	// (default), FormatJSON, FormatYAML, or FormatEnv.
+	Format Format
+
+	// JSONFormat stores secrets as JSON with metadata. It is equivalent to
+	// Format: FormatJSON and is kept for compatibility.
 	JSONFormat bool
 
 	// FileMode is the permission mode for secret files (default: 0600).
@@ -40,11 +56,30 @@ type Config struct {
 
 	// ReadOnly prevents write and delete operations.
 	ReadOnly bool
+
+	// WatchInterval is how often Watch polls the directory for changes
+	// (default: 1s).
+	WatchInterval time.Duration
+
+	// GroupByDir reads each directory as a multi-field secret, with one
+	// file per field, as Kubernetes CSI secret drivers mount them: the
+	// files db/username and db/password make up the secret "db". Hidden
+	// files and directories, such as the "..data" links Kubernetes
+	// creates, are ignored, and Extension applies to the field files.
+	// Secrets are read-only in this layout and Format must be FormatText.
+	GroupByDir bool
+
+	// ExpandEnv expands ${VAR} and ${VAR:-default} in secret values and
+	// field values on Get, using the process environment, for templated
+	// config snippets. An unset variable expands to the empty string. The
+	// files themselves are left unexpanded.
+	ExpandEnv bool
 }
 
 // Provider implements vault.Vault with file-based storage.
 type Provider struct {
 	config Config
+	closed atomic.Bool
 }
 
 // New creates a new file provider with the given configuration.
@@ -60,6 +95,23 @@ func New(config Config) (*Provider, error) {
 	if config.DirMode == 0 {
 		config.DirMode = 0700
 	}
+	if config.WatchInterval <= 0 {
+		config.WatchInterval = time.Second
+	}
+	if config.Format == "" {
+		config.Format = FormatText
+		if config.JSONFormat {
+			config.Format = FormatJSON
+		}
+	}
+	switch config.Format {
+	case FormatText, FormatJSON, FormatYAML, FormatEnv:
+	default:
+		return nil, fmt.Errorf("unsupported format %q", config.Format)
+	}
+	if config.GroupByDir && config.Format != FormatText {
+		return nil, fmt.Errorf("format %q is not supported with GroupByDir", config.Format)
+	}
 
 	// Create directory if it doesn't exist
 	if !config.ReadOnly {
@@ -72,38 +124,112 @@ func New(config Config) (*Provider, error) {
 }
 
 // filepath returns the full path for a secret.
-func (p *Provider) filepath(path string) string {
+// It rejects absolute paths and ".." traversal, and resolves symlinks to
+// ensure the final target stays within the configured directory.
+func (p *Provider) filepath(path string) (string, error) {
+	if path == "" || filepath.IsAbs(path) || filepath.VolumeName(path) != "" ||
+		strings.HasPrefix(path, "/") || strings.HasPrefix(path, `\`) {
+		return "", vault.ErrInvalidPath
+	}
+	for _, part := range strings.FieldsFunc(path, isSeparator) {
+		if part == ".." {
+			return "", vault.ErrInvalidPath
+		}
+	}
+
 	filename := path
-	if p.config.Extension != "" {
+	if p.config.Extension != "" && !p.config.GroupByDir {
 		filename = path + p.config.Extension
 	}
-	return filepath.Join(p.config.Directory, filename)
+	fp := filepath.Join(p.config.Directory, filename)
+
+	if err := p.checkContained(fp); err != nil {
+		return "", err
+	}
+	return fp, nil
+}
+
+// checkContained resolves symlinks in fp and verifies the result is inside
+// the configured directory. Missing trailing components are allowed so that
+// new secrets can be created.
+func (p *Provider) checkContained(fp string) error {
+	base, err := filepath.EvalSymlinks(p.config.Directory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Nothing can escape a directory that doesn't exist
+		}
+		return err
+	}
+
+	existing, rest := fp, ""
+	var target string
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			target = filepath.Join(resolved, rest)
+			break
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		// A dangling symlink would be followed on write; refuse it
+		if _, lerr := os.Lstat(existing); lerr == nil {
+			return vault.ErrInvalidPath
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return nil
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+
+	rel, err := filepath.Rel(base, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return vault.ErrInvalidPath
+	}
+	return nil
+}
+
+// isSeparator reports whether r separates path components.
+// Both slash styles are rejected regardless of platform.
+func isSeparator(r rune) bool {
+	return r == '/' || r == '\\'
 }
 
 // Get retrieves a secret from a file.
 func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
-	fp := p.filepath(path)
+	if p.closed.Load() {
+		return nil, vault.NewVaultError("Get", path, p.Name(), vault.ErrClosed)
+	}
 
-	data, err := os.ReadFile(fp)
+	fp, err := p.filepath(path)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, vault.NewVaultError("Get", path, p.Name(), vault.ErrSecretNotFound)
-		}
 		return nil, vault.NewVaultError("Get", path, p.Name(), err)
 	}
 
-	var secret *vault.Secret
+	if p.config.GroupByDir {
+		secret, err := p.readGroup(fp)
+		if err != nil {
+			return nil, vault.NewVaultError("Get", path, p.Name(), err)
+		}
+		secret.Metadata.Provider = p.Name()
+		secret.Metadata.Path = path
+		if p.config.ExpandEnv {
+			expandSecret(secret)
+		}
+		return secret, nil
+	}
 
-	if p.config.JSONFormat {
-		secret = &vault.Secret{}
-		if err := json.Unmarshal(data, secret); err != nil {
-			// Fall back to treating as plain text if JSON parsing fails
-			secret = &vault.Secret{Value: string(data)}
+	data, err := os.ReadFile(fp)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, vault.NewVaultError("Get", path, p.Name(), vault.ErrSecretNotFound)
 		}
-	} else {
-		secret = &vault.Secret{Value: string(data)}
+		return nil, vault.NewVaultError("Get", path, p.Name(), err)
 	}
 
+	secret := p.decode(data)
 	secret.Metadata.Provider = p.Name()
 	secret.Metadata.Path = path
 
@@ -112,17 +238,30 @@ func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error)
 		modTime := info.ModTime()
 		secret.Metadata.ModifiedAt = &vault.Timestamp{Time: modTime}
 	}
+	if p.config.ExpandEnv {
+		expandSecret(secret)
+	}
 
 	return secret, nil
 }
 
 // Set stores a secret to a file.
 func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	if p.closed.Load() {
+		return vault.NewVaultError("Set", path, p.Name(), vault.ErrClosed)
+	}
+
 	if p.config.ReadOnly {
 		return vault.NewVaultError("Set", path, p.Name(), vault.ErrReadOnly)
 	}
+	if p.config.GroupByDir {
+		return vault.NewVaultError("Set", path, p.Name(), vault.ErrNotSupported)
+	}
 
-	fp := p.filepath(path)
+	fp, err := p.filepath(path)
+	if err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
 
 	// Ensure parent directory exists
 	dir := filepath.Dir(fp)
@@ -130,16 +269,9 @@ func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) e
 		return vault.NewVaultError("Set", path, p.Name(), err)
 	}
 
-	var data []byte
-	var err error
-
-	if p.config.JSONFormat {
-		data, err = json.MarshalIndent(secret, "", "  ")
-		if err != nil {
-			return vault.NewVaultError("Set", path, p.Name(), err)
-		}
-	} else {
-		data = secret.Bytes()
+	data, err := p.encode(secret)
+	if err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), err)
 	}
 
 	if err := os.WriteFile(fp, data, p.config.FileMode); err != nil {
@@ -151,11 +283,21 @@ func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) e
 
 // Delete removes a secret file.
 func (p *Provider) Delete(ctx context.Context, path string) error {
+	if p.closed.Load() {
+		return vault.NewVaultError("Delete", path, p.Name(), vault.ErrClosed)
+	}
+
 	if p.config.ReadOnly {
 		return vault.NewVaultError("Delete", path, p.Name(), vault.ErrReadOnly)
 	}
+	if p.config.GroupByDir {
+		return vault.NewVaultError("Delete", path, p.Name(), vault.ErrNotSupported)
+	}
 
-	fp := p.filepath(path)
+	fp, err := p.filepath(path)
+	if err != nil {
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
 
 	if err := os.Remove(fp); err != nil {
 		if os.IsNotExist(err) {
@@ -169,8 +311,27 @@ func (p *Provider) Delete(ctx context.Context, path string) error {
 
 // Exists checks if a secret file exists.
 func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
-	fp := p.filepath(path)
-	_, err := os.Stat(fp)
+	if p.closed.Load() {
+		return false, vault.NewVaultError("Exists", path, p.Name(), vault.ErrClosed)
+	}
+
+	fp, err := p.filepath(path)
+	if err != nil {
+		return false, vault.NewVaultError("Exists", path, p.Name(), err)
+	}
+
+	if p.config.GroupByDir {
+		_, err := p.readGroup(fp)
+		if err == nil {
+			return true, nil
+		}
+		if errors.Is(err, vault.ErrSecretNotFound) {
+			return false, nil
+		}
+		return false, vault.NewVaultError("Exists", path, p.Name(), err)
+	}
+
+	_, err = os.Stat(fp)
 	if err == nil {
 		return true, nil
 	}
@@ -182,12 +343,112 @@ func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
 
 // List returns all secret paths matching the prefix.
 func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	if p.closed.Load() {
+		return nil, vault.NewVaultError("List", prefix, p.Name(), vault.ErrClosed)
+	}
+
 	var results []string
+	seen := make(map[string]bool)
+
+	// With GroupByDir, each field of a secret is visited
+	err := p.walk(ctx, prefix, func(rel, _ string) error {
+		if !seen[rel] {
+			seen[rel] = true
+			results = append(results, rel)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, vault.NewVaultError("List", prefix, p.Name(), err)
+	}
+
+	return results, nil
+}
+
+// ListInfo returns the metadata of all secrets matching the prefix. Plain
+// text secrets are described from their file attributes without being
+// read; other formats and GroupByDir secrets are read to find their fields.
+func (p *Provider) ListInfo(ctx context.Context, prefix string) ([]vault.SecretInfo, error) {
+	if p.closed.Load() {
+		return nil, vault.NewVaultError("ListInfo", prefix, p.Name(), vault.ErrClosed)
+	}
+
+	stat := p.config.Format == FormatText && !p.config.GroupByDir
+	var infos []vault.SecretInfo
+	seen := make(map[string]bool)
+
+	err := p.walk(ctx, prefix, func(rel, fp string) error {
+		if seen[rel] {
+			return nil
+		}
+		seen[rel] = true
+
+		if !stat {
+			secret, err := p.Get(ctx, rel)
+			if err != nil {
+				return err
+			}
+			infos = append(infos, vault.InfoOf(rel, secret))
+			return nil
+		}
+
+		fi, err := os.Stat(fp)
+		if err != nil {
+			return err
+		}
+		infos = append(infos, vault.SecretInfo{
+			Path:     rel,
+			HasValue: fi.Size() > 0,
+			Metadata: vault.Metadata{
+				Provider:   p.Name(),
+				Path:       rel,
+				ModifiedAt: &vault.Timestamp{Time: fi.ModTime()},
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, vault.NewVaultError("ListInfo", prefix, p.Name(), err)
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Path < infos[j].Path })
+	return infos, nil
+}
 
-	err := filepath.WalkDir(p.config.Directory, func(path string, d fs.DirEntry, err error) error {
+// walk calls fn with the secret path and file path of each secret file
+// whose secret path starts with prefix. With GroupByDir, it is called for
+// each field file, with the path of the secret holding it. It stops with
+// ctx.Err() as soon as ctx is done.
+func (p *Provider) walk(ctx context.Context, prefix string, fn func(rel, fp string) error) error {
+	return filepath.WalkDir(p.config.Directory, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
 		if err != nil {
 			return err
 		}
+
+		if p.config.GroupByDir {
+			if d.IsDir() {
+				if path != p.config.Directory && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if _, ok := p.fieldName(d.Name()); !ok {
+				return nil
+			}
+			rel, err := filepath.Rel(p.config.Directory, filepath.Dir(path))
+			if err != nil {
+				return err
+			}
+			if rel != "." && strings.HasPrefix(rel, prefix) {
+				return fn(rel, path)
+			}
+			return nil
+		}
+
 		if d.IsDir() {
 			return nil
 		}
@@ -205,17 +466,76 @@ func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
 
 		// Filter by prefix
 		if strings.HasPrefix(rel, prefix) {
-			results = append(results, rel)
+			return fn(rel, path)
 		}
 
 		return nil
 	})
+}
+
+// fieldName returns the field held by a file in the GroupByDir layout.
+// Hidden files and files without the configured extension hold no field.
+func (p *Provider) fieldName(name string) (string, bool) {
+	if strings.HasPrefix(name, ".") {
+		return "", false
+	}
+	if p.config.Extension == "" {
+		return name, true
+	}
+	field, ok := strings.CutSuffix(name, p.config.Extension)
+	return field, ok && field != ""
+}
 
+// readGroup reads the secret in directory dir in the GroupByDir layout.
+// A directory without field files is not a secret.
+func (p *Provider) readGroup(dir string) (*vault.Secret, error) {
+	info, err := os.Stat(dir)
 	if err != nil {
-		return nil, vault.NewVaultError("List", prefix, p.Name(), err)
+		if os.IsNotExist(err) {
+			return nil, vault.ErrSecretNotFound
+		}
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, vault.ErrSecretNotFound
 	}
 
-	return results, nil
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &vault.Secret{Fields: make(map[string]string)}
+	for _, e := range entries {
+		field, ok := p.fieldName(e.Name())
+		if !ok {
+			continue
+		}
+
+		// Fields are usually symlinks into a hidden data directory
+		fp := filepath.Join(dir, e.Name())
+		if err := p.checkContained(fp); err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(fp)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(fp)
+		if err != nil {
+			return nil, err
+		}
+
+		secret.Fields[field] = string(data)
+		if m := secret.Metadata.ModifiedAt; m == nil || info.ModTime().After(m.Time) {
+			secret.Metadata.ModifiedAt = &vault.Timestamp{Time: info.ModTime()}
+		}
+	}
+
+	if len(secret.Fields) == 0 {
+		return nil, vault.ErrSecretNotFound
+	}
+	return secret, nil
 }
 
 // Name returns the provider name.
@@ -227,18 +547,29 @@ func (p *Provider) Name() string {
 func (p *Provider) Capabilities() vault.Capabilities {
 	return vault.Capabilities{
 		Read:       true,
-		Write:      !p.config.ReadOnly,
-		Delete:     !p.config.ReadOnly,
+		Write:      !p.config.ReadOnly && !p.config.GroupByDir,
+		Delete:     !p.config.ReadOnly && !p.config.GroupByDir,
 		List:       true,
 		Binary:     true,
-		MultiField: p.config.JSONFormat,
+		MultiField: p.config.Format != FormatText || p.config.GroupByDir,
+		Watch:      true,
 	}
 }
 
-// Close is a no-op for the file provider.
+// Close marks the provider as closed. Later operations return
+// vault.ErrClosed. Closing more than once is safe.
 func (p *Provider) Close() error {
+	p.closed.Store(true)
 	return nil
 }
 
-// Ensure Provider implements vault.Vault.
-var _ vault.Vault = (*Provider)(nil)
+// Closed returns true if the provider has been closed.
+func (p *Provider) Closed() bool {
+	return p.closed.Load()
+}
+
+// Ensure Provider implements vault.Vault and vault.MetadataLister.
+var (
+	_ vault.Vault          = (*Provider)(nil)
+	_ vault.MetadataLister = (*Provider)(nil)
+)
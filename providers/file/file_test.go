@@ -0,0 +1,538 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+func newTestProvider(t *testing.T) (*Provider, string) {
+	t.Helper()
+	dir := t.TempDir()
+	p, err := New(Config{Directory: dir})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return p, dir
+}
+
+func TestNestedPaths(t *testing.T) {
+	p, dir := newTestProvider(t)
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "database/password", &vault.Secret{Value: "secret"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "database", "password")); err != nil {
+		t.Errorf("Expected secret file in subdirectory: %v", err)
+	}
+
+	secret, err := p.Get(ctx, "database/password")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if secret.Value != "secret" {
+		t.Errorf("Expected 'secret', got %q", secret.Value)
+	}
+}
+
+func TestTraversalRejected(t *testing.T) {
+	p, _ := newTestProvider(t)
+	ctx := context.Background()
+
+	paths := []string{
+		"../outside",
+		"../../etc/passwd",
+		"a/../../outside",
+		`..\outside`,
+		"..",
+	}
+	for _, path := range paths {
+		if _, err := p.Get(ctx, path); !errors.Is(err, vault.ErrInvalidPath) {
+			t.Errorf("Get(%q): expected ErrInvalidPath, got %v", path, err)
+		}
+		if err := p.Set(ctx, path, &vault.Secret{Value: "x"}); !errors.Is(err, vault.ErrInvalidPath) {
+			t.Errorf("Set(%q): expected ErrInvalidPath, got %v", path, err)
+		}
+		if err := p.Delete(ctx, path); !errors.Is(err, vault.ErrInvalidPath) {
+			t.Errorf("Delete(%q): expected ErrInvalidPath, got %v", path, err)
+		}
+		if _, err := p.Exists(ctx, path); !errors.Is(err, vault.ErrInvalidPath) {
+			t.Errorf("Exists(%q): expected ErrInvalidPath, got %v", path, err)
+		}
+	}
+}
+
+func TestAbsolutePathRejected(t *testing.T) {
+	p, _ := newTestProvider(t)
+	ctx := context.Background()
+
+	abs := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(abs, []byte("outside"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{abs, "/etc/passwd"} {
+		if _, err := p.Get(ctx, path); !errors.Is(err, vault.ErrInvalidPath) {
+			t.Errorf("Get(%q): expected ErrInvalidPath, got %v", path, err)
+		}
+	}
+}
+
+func TestSymlinkOutsideRejected(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require elevated privileges on Windows")
+	}
+
+	p, dir := newTestProvider(t)
+	ctx := context.Background()
+
+	outside := t.TempDir()
+	target := filepath.Join(outside, "secret")
+	if err := os.WriteFile(target, []byte("outside"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Symlinked file
+	if err := os.Symlink(target, filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Get(ctx, "link"); !errors.Is(err, vault.ErrInvalidPath) {
+		t.Errorf("Get via file symlink: expected ErrInvalidPath, got %v", err)
+	}
+
+	// Symlinked directory, including creating a new file through it
+	if err := os.Symlink(outside, filepath.Join(dir, "linkdir")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Get(ctx, "linkdir/secret"); !errors.Is(err, vault.ErrInvalidPath) {
+		t.Errorf("Get via dir symlink: expected ErrInvalidPath, got %v", err)
+	}
+	if err := p.Set(ctx, "linkdir/new", &vault.Secret{Value: "x"}); !errors.Is(err, vault.ErrInvalidPath) {
+		t.Errorf("Set via dir symlink: expected ErrInvalidPath, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new")); !os.IsNotExist(err) {
+		t.Error("Expected no file to be written outside the directory")
+	}
+
+	// Dangling symlink that would be created on write
+	if err := os.Symlink(filepath.Join(outside, "missing"), filepath.Join(dir, "dangling")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Set(ctx, "dangling", &vault.Secret{Value: "x"}); !errors.Is(err, vault.ErrInvalidPath) {
+		t.Errorf("Set via dangling symlink: expected ErrInvalidPath, got %v", err)
+	}
+}
+
+func TestSymlinkInsideAllowed(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require elevated privileges on Windows")
+	}
+
+	p, dir := newTestProvider(t)
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "real", &vault.Secret{Value: "inside"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "real"), filepath.Join(dir, "alias")); err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := p.Get(ctx, "alias")
+	if err != nil {
+		t.Fatalf("Get via inside symlink failed: %v", err)
+	}
+	if secret.Value != "inside" {
+		t.Errorf("Expected 'inside', got %q", secret.Value)
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	p, _ := newTestProvider(t)
+	ctx := context.Background()
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("Second Close failed: %v", err)
+	}
+	if !p.Closed() {
+		t.Error("Expected Closed() to be true")
+	}
+
+	if _, err := p.Get(ctx, "secret"); !errors.Is(err, vault.ErrClosed) {
+		t.Errorf("Get: expected ErrClosed, got %v", err)
+	}
+	if err := p.Set(ctx, "secret", &vault.Secret{Value: "x"}); !errors.Is(err, vault.ErrClosed) {
+		t.Errorf("Set: expected ErrClosed, got %v", err)
+	}
+	if _, err := p.List(ctx, ""); !errors.Is(err, vault.ErrClosed) {
+		t.Errorf("List: expected ErrClosed, got %v", err)
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	secret := &vault.Secret{
+		Value: "s3cret",
+		Fields: map[string]string{
+			"username": "admin",
+			"host":     "db.example.com",
+			"port":     "5432",
+			"note":     "line one\nline \"two\" # not a comment",
+			"padded":   "  spaces  ",
+			"empty":    "",
+		},
+	}
+
+	for _, format := range []Format{FormatJSON, FormatYAML, FormatEnv} {
+		t.Run(string(format), func(t *testing.T) {
+			p, err := New(Config{Directory: t.TempDir(), Format: format})
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			ctx := context.Background()
+
+			if err := p.Set(ctx, "db", secret); err != nil {
+				t.Fatalf("Set failed: %v", err)
+			}
+			got, err := p.Get(ctx, "db")
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+
+			if got.Value != secret.Value {
+				t.Errorf("Value = %q, want %q", got.Value, secret.Value)
+			}
+			if !reflect.DeepEqual(got.Fields, secret.Fields) {
+				t.Errorf("Fields = %q, want %q", got.Fields, secret.Fields)
+			}
+		})
+	}
+}
+
+func TestFormatParsesHandwrittenFiles(t *testing.T) {
+	tests := []struct {
+		format Format
+		data   string
+	}{
+		{FormatYAML, "# database\nusername: admin\nport: 5432\n"},
+		{FormatEnv, "# database\nexport username=admin\nport='5432'\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "db"), []byte(tt.data), 0600); err != nil {
+				t.Fatal(err)
+			}
+			p, err := New(Config{Directory: dir, Format: tt.format})
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+
+			got, err := p.Get(context.Background(), "db")
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			want := map[string]string{"username": "admin", "port": "5432"}
+			if !reflect.DeepEqual(got.Fields, want) {
+				t.Errorf("Fields = %v, want %v", got.Fields, want)
+			}
+		})
+	}
+}
+
+func TestJSONFormatCompatibility(t *testing.T) {
+	p, err := New(Config{Directory: t.TempDir(), JSONFormat: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if p.config.Format != FormatJSON {
+		t.Errorf("Expected JSONFormat to select FormatJSON, got %q", p.config.Format)
+	}
+
+	if _, err := New(Config{Directory: t.TempDir(), Format: "toml"}); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		fp := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fp), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fp, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGroupByDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"db/username":          "admin",
+		"db/password":          "hunter2",
+		"services/api/token":   "abc",
+		"services/.cache/junk": "x",
+		"services/.hidden":     "x",
+		"README":               "not a secret",
+	})
+
+	p, err := New(Config{Directory: dir, GroupByDir: true, ReadOnly: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+
+	secret, err := p.Get(ctx, "db")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	want := map[string]string{"username": "admin", "password": "hunter2"}
+	if !reflect.DeepEqual(secret.Fields, want) {
+		t.Errorf("Fields = %v, want %v", secret.Fields, want)
+	}
+	if secret.Metadata.Path != "db" || secret.Metadata.ModifiedAt == nil {
+		t.Errorf("Unexpected metadata: %+v", secret.Metadata)
+	}
+
+	// Directories holding only other secrets, and field files, are not secrets
+	for _, path := range []string{"services", "db/username", "missing"} {
+		if _, err := p.Get(ctx, path); !errors.Is(err, vault.ErrSecretNotFound) {
+			t.Errorf("Get(%q): expected ErrSecretNotFound, got %v", path, err)
+		}
+		if exists, err := p.Exists(ctx, path); err != nil || exists {
+			t.Errorf("Exists(%q) = %v, %v; want false, nil", path, exists, err)
+		}
+	}
+
+	names, err := p.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"db", filepath.Join("services", "api")}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List = %v, want %v", names, want)
+	}
+	names, err = p.List(ctx, "serv")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{filepath.Join("services", "api")}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List(serv) = %v, want %v", names, want)
+	}
+
+	if err := p.Set(ctx, "db", &vault.Secret{Value: "x"}); err == nil {
+		t.Error("Expected Set to fail with GroupByDir")
+	}
+	if caps := p.Capabilities(); !caps.MultiField || caps.Write {
+		t.Errorf("Unexpected capabilities: %+v", caps)
+	}
+
+	if _, err := New(Config{Directory: dir, GroupByDir: true, Format: FormatJSON}); err == nil {
+		t.Error("Expected error for GroupByDir with JSON format")
+	}
+}
+
+func TestGroupByDirKubernetesMount(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require elevated privileges on Windows")
+	}
+
+	// Kubernetes mounts files as links into a timestamped directory,
+	// through a "..data" link that it swaps on update
+	dir := t.TempDir()
+	db := filepath.Join(dir, "db")
+	writeFiles(t, db, map[string]string{
+		"..2025_01_01_00_00_00.000000001/username": "admin",
+		"..2025_01_01_00_00_00.000000001/password": "hunter2",
+	})
+	if err := os.Symlink("..2025_01_01_00_00_00.000000001", filepath.Join(db, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"username", "password"} {
+		if err := os.Symlink(filepath.Join("..data", field), filepath.Join(db, field)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := New(Config{Directory: dir, GroupByDir: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+
+	secret, err := p.Get(ctx, "db")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	want := map[string]string{"username": "admin", "password": "hunter2"}
+	if !reflect.DeepEqual(secret.Fields, want) {
+		t.Errorf("Fields = %v, want %v", secret.Fields, want)
+	}
+
+	names, err := p.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"db"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List = %v, want %v", names, want)
+	}
+}
+
+func TestListInfoMatchesGet(t *testing.T) {
+	for _, format := range []Format{FormatText, FormatJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			p, err := New(Config{Directory: t.TempDir(), Format: format})
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			ctx := context.Background()
+
+			secrets := map[string]*vault.Secret{
+				"db/password": {Value: "hunter2"},
+				"api/key":     {Value: "abc"},
+				"empty":       {},
+			}
+			if format == FormatJSON {
+				secrets["db/conn"] = &vault.Secret{Fields: map[string]string{"user": "admin"}}
+			}
+			for path, secret := range secrets {
+				if err := p.Set(ctx, path, secret); err != nil {
+					t.Fatalf("Set failed: %v", err)
+				}
+			}
+
+			infos, err := p.ListInfo(ctx, "")
+			if err != nil {
+				t.Fatalf("ListInfo failed: %v", err)
+			}
+
+			paths, err := p.List(ctx, "")
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			var want []vault.SecretInfo
+			for _, path := range paths {
+				secret, err := p.Get(ctx, path)
+				if err != nil {
+					t.Fatalf("Get failed: %v", err)
+				}
+				want = append(want, vault.InfoOf(path, secret))
+			}
+
+			if !reflect.DeepEqual(infos, want) {
+				t.Errorf("ListInfo = %+v, want %+v", infos, want)
+			}
+		})
+	}
+}
+
+func TestListCancelled(t *testing.T) {
+	p, dir := newTestProvider(t)
+	for i := 0; i < 500; i++ {
+		sub := filepath.Join(dir, fmt.Sprintf("dir%02d", i%20))
+		if err := os.MkdirAll(sub, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(sub, fmt.Sprintf("secret%03d", i)), []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Cancelled mid-walk, the walk stops at the next entry
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	visited := 0
+	err := p.walk(ctx, "", func(string, string) error {
+		visited++
+		if visited == 10 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if visited != 10 {
+		t.Errorf("Expected the walk to stop after 10 secrets, visited %d", visited)
+	}
+
+	if _, err := p.List(ctx, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected List to fail with context.Canceled, got %v", err)
+	}
+	if paths, err := p.List(context.Background(), "dir01/"); err != nil || len(paths) != 25 {
+		t.Errorf("Expected 25 secrets, got %d, %v", len(paths), err)
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("OMNIVAULT_TEST_HOME", "/home/alice")
+	t.Setenv("OMNIVAULT_TEST_EMPTY", "")
+
+	tests := map[string]string{
+		"${OMNIVAULT_TEST_HOME}/.config":                    "/home/alice/.config",
+		"a=${OMNIVAULT_TEST_HOME} b=${OMNIVAULT_TEST_HOME}": "a=/home/alice b=/home/alice",
+		"${OMNIVAULT_TEST_UNSET:-/tmp}/cache":               "/tmp/cache",
+		"${OMNIVAULT_TEST_EMPTY:-fallback}":                 "fallback",
+		"${OMNIVAULT_TEST_HOME:-fallback}":                  "/home/alice",
+		"${OMNIVAULT_TEST_UNSET:-}":                         "",
+		"x${OMNIVAULT_TEST_UNSET}y":                         "xy",
+		"${OMNIVAULT_TEST_EMPTY}":                           "",
+		"$OMNIVAULT_TEST_HOME and $5":                       "$OMNIVAULT_TEST_HOME and $5",
+		"${not valid} ${1X} ${}":                            "${not valid} ${1X} ${}",
+		"unterminated ${OMNIVAULT_TEST_HOME":                "unterminated ${OMNIVAULT_TEST_HOME",
+		"${${OMNIVAULT_TEST_HOME}}":                         "${/home/alice}",
+	}
+	for in, want := range tests {
+		if got := expandEnv(in); got != want {
+			t.Errorf("expandEnv(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	dir := t.TempDir()
+	value := "root=${OMNIVAULT_TEST_HOME}"
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte(value), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	expanding, err := New(Config{Directory: dir, ExpandEnv: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if secret, err := expanding.Get(ctx, "config"); err != nil || secret.Value != "root=/home/alice" {
+		t.Errorf("Expected the value to be expanded, got %v, %v", secret, err)
+	}
+
+	plain, err := New(Config{Directory: dir})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if secret, err := plain.Get(ctx, "config"); err != nil || secret.Value != value {
+		t.Errorf("Expected the value to be untouched without ExpandEnv, got %v, %v", secret, err)
+	}
+
+	// Fields are expanded too
+	jsonDir := t.TempDir()
+	jsonProvider, err := New(Config{Directory: jsonDir, Format: FormatJSON, ExpandEnv: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := jsonProvider.Set(ctx, "db", &vault.Secret{Fields: map[string]string{"socket": "${OMNIVAULT_TEST_HOME}/db.sock"}}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if secret, err := jsonProvider.Get(ctx, "db"); err != nil || secret.Fields["socket"] != "/home/alice/db.sock" {
+		t.Errorf("Expected the field to be expanded, got %v, %v", secret, err)
+	}
+}
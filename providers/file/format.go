@@ -0,0 +1,190 @@
+package file
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/agentplexus/omnivault/internal/yaml"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// Format is the serialization format of secret files.
+type Format string
+
+// Supported formats.
+const (
+	// FormatText stores the secret value as-is (the default).
+	FormatText Format = "text"
+
+	// FormatJSON stores the whole secret, including metadata, as JSON.
+	FormatJSON Format = "json"
+
+	// FormatYAML stores a flat YAML mapping of field names to values.
+	FormatYAML Format = "yaml"
+
+	// FormatEnv stores KEY=VALUE lines, one per field.
+	FormatEnv Format = "env"
+)
+
+// valueKey is the key holding the primary value in YAML and env files.
+const valueKey = "value"
+
+// encode serializes a secret in the configured format.
+func (p *Provider) encode(secret *vault.Secret) ([]byte, error) {
+	switch p.config.Format {
+	case FormatJSON:
+		return json.MarshalIndent(secret, "", "  ")
+	case FormatYAML:
+		return yaml.Marshal(flatten(secret))
+	case FormatEnv:
+		return encodeEnv(flatten(secret)), nil
+	default:
+		return secret.Bytes(), nil
+	}
+}
+
+// decode parses a secret in the configured format. Files that fail to parse
+// are treated as plain text.
+func (p *Provider) decode(data []byte) *vault.Secret {
+	switch p.config.Format {
+	case FormatJSON:
+		secret := &vault.Secret{}
+		if err := json.Unmarshal(data, secret); err == nil {
+			return secret
+		}
+	case FormatYAML:
+		if fields, err := decodeYAML(data); err == nil {
+			return unflatten(fields)
+		}
+	case FormatEnv:
+		if fields, err := decodeEnv(data); err == nil {
+			return unflatten(fields)
+		}
+	}
+	return &vault.Secret{Value: string(data)}
+}
+
+// flatten returns the secret's fields with the value under valueKey.
+func flatten(secret *vault.Secret) map[string]string {
+	m := make(map[string]string, len(secret.Fields)+1)
+	for k, v := range secret.Fields {
+		m[k] = v
+	}
+	if value := secret.String(); value != "" {
+		m[valueKey] = value
+	}
+	return m
+}
+
+// unflatten is the inverse of flatten.
+func unflatten(m map[string]string) *vault.Secret {
+	secret := &vault.Secret{Value: m[valueKey]}
+	for k, v := range m {
+		if k != valueKey {
+			secret.SetField(k, v)
+		}
+	}
+	return secret
+}
+
+// decodeYAML parses a flat YAML mapping. Scalars are converted to strings;
+// nested mappings and sequences are rejected.
+func decodeYAML(data []byte) (map[string]string, error) {
+	parsed, err := yaml.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if parsed == nil {
+		return map[string]string{}, nil
+	}
+
+	doc, ok := parsed.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a mapping")
+	}
+
+	fields := make(map[string]string, len(doc))
+	for k, v := range doc {
+		switch val := v.(type) {
+		case string:
+			fields[k] = val
+		case nil:
+			fields[k] = ""
+		case map[string]any, []any:
+			return nil, fmt.Errorf("field %s: nested values are not supported", k)
+		default:
+			fields[k] = fmt.Sprint(val)
+		}
+	}
+	return fields, nil
+}
+
+// encodeEnv writes sorted KEY=VALUE lines, quoting values that would not
+// survive a round trip unquoted.
+func encodeEnv(fields map[string]string) []byte {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		v := fields[k]
+		if needsQuoting(v) {
+			v = strconv.Quote(v)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", k, v)
+	}
+	return []byte(b.String())
+}
+
+// needsQuoting reports whether an env value must be double-quoted.
+func needsQuoting(v string) bool {
+	if v == "" {
+		return false
+	}
+	if strings.TrimSpace(v) != v {
+		return true
+	}
+	return strings.ContainsAny(v, "\"'#\\\n\r\t")
+}
+
+// decodeEnv parses KEY=VALUE lines. Blank lines, comments, and a leading
+// "export " are ignored. Values may be double-quoted with Go escapes or
+// single-quoted literally.
+func decodeEnv(data []byte) (map[string]string, error) {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		k, v, ok := strings.Cut(line, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", n)
+		}
+		v = strings.TrimSpace(v)
+
+		switch {
+		case len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"':
+			unquoted, err := strconv.Unquote(v)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			v = unquoted
+		case len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'':
+			v = v[1 : len(v)-1]
+		}
+		fields[k] = v
+	}
+	return fields, scanner.Err()
+}
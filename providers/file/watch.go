@@ -0,0 +1,107 @@
+package file
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// fileState identifies a version of a secret file.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watch reports secrets under prefix that are created, changed, or
+// deleted, including by other processes. The directory is polled every
+// Config.WatchInterval, so changes are reported up to one interval late.
+func (p *Provider) Watch(ctx context.Context, prefix string) (<-chan vault.WatchEvent, error) {
+	if p.closed.Load() {
+		return nil, vault.NewVaultError("Watch", prefix, p.Name(), vault.ErrClosed)
+	}
+
+	prev, err := p.scan(ctx, prefix)
+	if err != nil {
+		return nil, vault.NewVaultError("Watch", prefix, p.Name(), err)
+	}
+
+	events := make(chan vault.WatchEvent)
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(p.config.WatchInterval)
+		defer ticker.Stop()
+
+		send := func(event vault.WatchEvent) bool {
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if p.closed.Load() {
+				return
+			}
+
+			cur, err := p.scan(ctx, prefix)
+			if err != nil {
+				continue // Try again on the next tick
+			}
+
+			for path, state := range cur {
+				if old, ok := prev[path]; !ok || old != state {
+					if !send(vault.WatchEvent{Type: vault.WatchEventSet, Path: path}) {
+						return
+					}
+				}
+			}
+			for path := range prev {
+				if _, ok := cur[path]; !ok {
+					if !send(vault.WatchEvent{Type: vault.WatchEventDelete, Path: path}) {
+						return
+					}
+				}
+			}
+			prev = cur
+		}
+	}()
+
+	return events, nil
+}
+
+// scan returns the state of every secret under prefix. The state of a
+// secret made up of several files combines theirs.
+func (p *Provider) scan(ctx context.Context, prefix string) (map[string]fileState, error) {
+	states := make(map[string]fileState)
+	err := p.walk(ctx, prefix, func(rel, fp string) error {
+		// Follow symlinks, which Kubernetes swaps to update mounted secrets
+		info, err := os.Stat(fp)
+		if err != nil {
+			return nil // Removed while walking
+		}
+		state := states[rel]
+		if info.ModTime().After(state.modTime) {
+			state.modTime = info.ModTime()
+		}
+		state.size += info.Size()
+		states[rel] = state
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return states, nil
+}
+
+// Ensure Provider implements vault.WatchableVault.
+var _ vault.WatchableVault = (*Provider)(nil)
@@ -0,0 +1,348 @@
+// Package infisical provides a vault implementation backed by Infisical
+// (https://infisical.com) using its REST API.
+//
+// Usage:
+//
+//	v, err := infisical.New(infisical.Config{
+//	    Token:       os.Getenv("INFISICAL_TOKEN"),
+//	    ProjectID:   "6523...",
+//	    Environment: "prod",
+//	})
+//	secret, err := v.Get(ctx, "backend/DATABASE_URL")
+//
+// The last path segment is the secret name and the rest is the Infisical
+// folder, so "backend/DATABASE_URL" is DATABASE_URL in folder /backend.
+package infisical
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// DefaultSiteURL is the Infisical Cloud URL.
+const DefaultSiteURL = "https://app.infisical.com"
+
+// secretType is the type of secrets read and written. Personal overrides
+// are not supported.
+const secretType = "shared"
+
+// Config holds configuration for the Infisical provider.
+type Config struct {
+	// SiteURL is the Infisical instance URL (default: https://app.infisical.com).
+	SiteURL string
+
+	// Token is a service token or machine identity access token.
+	Token string
+
+	// ProjectID is the Infisical project (workspace) ID.
+	ProjectID string
+
+	// Environment is the environment slug, e.g. "dev" or "prod".
+	Environment string
+
+	// HTTPClient is the HTTP client used for API requests (default: 30s timeout).
+	HTTPClient *http.Client
+}
+
+// Provider implements vault.Vault for Infisical.
+type Provider struct {
+	config Config
+	client *http.Client
+}
+
+// New creates a new Infisical provider.
+func New(config Config) (*Provider, error) {
+	if config.Token == "" {
+		return nil, errors.New("token is required")
+	}
+	if config.ProjectID == "" {
+		return nil, errors.New("project ID is required")
+	}
+	if config.Environment == "" {
+		return nil, errors.New("environment is required")
+	}
+	if config.SiteURL == "" {
+		config.SiteURL = DefaultSiteURL
+	}
+	config.SiteURL = strings.TrimSuffix(config.SiteURL, "/")
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Provider{config: config, client: client}, nil
+}
+
+// splitPath splits a path into an Infisical folder and secret name.
+func splitPath(path string) (folder, name string, err error) {
+	path = strings.Trim(path, "/")
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		folder, name = path[:i], path[i+1:]
+	} else {
+		name = path
+	}
+	if name == "" {
+		return "", "", vault.ErrInvalidPath
+	}
+	return "/" + folder, name, nil
+}
+
+// joinPath returns the vault path of a secret in an Infisical folder.
+func joinPath(folder, name string) string {
+	folder = strings.Trim(folder, "/")
+	if folder == "" {
+		return name
+	}
+	return folder + "/" + name
+}
+
+// rawSecret is a secret as returned by the raw secrets endpoints.
+type rawSecret struct {
+	ID            string `json:"id"`
+	Version       int    `json:"version"`
+	SecretKey     string `json:"secretKey"`
+	SecretValue   string `json:"secretValue"`
+	SecretComment string `json:"secretComment"`
+	SecretPath    string `json:"secretPath"`
+}
+
+// secretResponse is the response from the single secret endpoint.
+type secretResponse struct {
+	Secret rawSecret `json:"secret"`
+}
+
+// secretsResponse is the response from the list secrets endpoint.
+type secretsResponse struct {
+	Secrets []rawSecret `json:"secrets"`
+}
+
+// Get retrieves a secret from Infisical.
+func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	folder, name, err := splitPath(path)
+	if err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), err)
+	}
+
+	query := p.scope(folder)
+	query.Set("type", secretType)
+	var resp secretResponse
+	if err := p.do(ctx, http.MethodGet, secretEndpoint(name), query, nil, &resp); err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), err)
+	}
+
+	extra := map[string]any{
+		"environment": p.config.Environment,
+		"folder":      folder,
+	}
+	if resp.Secret.SecretComment != "" {
+		extra["comment"] = resp.Secret.SecretComment
+	}
+
+	return &vault.Secret{
+		Value: resp.Secret.SecretValue,
+		Metadata: vault.Metadata{
+			Provider: p.Name(),
+			Path:     path,
+			Version:  strconv.Itoa(resp.Secret.Version),
+			Extra:    extra,
+		},
+	}, nil
+}
+
+// Set creates or updates a secret in Infisical.
+func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	folder, name, err := splitPath(path)
+	if err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+
+	body := map[string]any{
+		"workspaceId": p.config.ProjectID,
+		"environment": p.config.Environment,
+		"secretPath":  folder,
+		"secretValue": secret.String(),
+		"type":        secretType,
+	}
+
+	// Update in place, creating the secret if it does not exist yet
+	err = p.do(ctx, http.MethodPatch, secretEndpoint(name), nil, body, nil)
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		err = p.do(ctx, http.MethodPost, secretEndpoint(name), nil, body, nil)
+	}
+	if err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+	return nil
+}
+
+// Delete removes a secret from Infisical.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	folder, name, err := splitPath(path)
+	if err != nil {
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+
+	body := map[string]any{
+		"workspaceId": p.config.ProjectID,
+		"environment": p.config.Environment,
+		"secretPath":  folder,
+		"type":        secretType,
+	}
+	err = p.do(ctx, http.MethodDelete, secretEndpoint(name), nil, body, nil)
+	if err != nil && !errors.Is(err, vault.ErrSecretNotFound) {
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+	return nil
+}
+
+// Exists checks if a secret exists in Infisical.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	_, err := p.Get(ctx, path)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return false, nil
+	}
+	return false, err
+}
+
+// List returns the paths of all secrets in the environment matching the
+// prefix, including those in nested folders.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	// Only fetch the folder the prefix points into
+	folder := "/"
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		folder = "/" + strings.Trim(prefix[:i], "/")
+	}
+
+	query := p.scope(folder)
+	query.Set("recursive", "true")
+	var resp secretsResponse
+	if err := p.do(ctx, http.MethodGet, "/api/v3/secrets/raw", query, nil, &resp); err != nil {
+		if errors.Is(err, vault.ErrSecretNotFound) {
+			return nil, nil
+		}
+		return nil, vault.NewVaultError("List", prefix, p.Name(), err)
+	}
+
+	var results []string
+	for _, s := range resp.Secrets {
+		secretFolder := s.SecretPath
+		if secretFolder == "" {
+			secretFolder = folder
+		}
+		if path := joinPath(secretFolder, s.SecretKey); strings.HasPrefix(path, prefix) {
+			results = append(results, path)
+		}
+	}
+	sort.Strings(results)
+	return results, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "infisical"
+}
+
+// Capabilities returns the provider capabilities.
+func (p *Provider) Capabilities() vault.Capabilities {
+	return vault.Capabilities{
+		Read:   true,
+		Write:  true,
+		Delete: true,
+		List:   true,
+	}
+}
+
+// Close is a no-op for the Infisical provider.
+func (p *Provider) Close() error {
+	return nil
+}
+
+// scope returns the query parameters selecting a folder of the configured
+// project and environment.
+func (p *Provider) scope(folder string) url.Values {
+	return url.Values{
+		"workspaceId": {p.config.ProjectID},
+		"environment": {p.config.Environment},
+		"secretPath":  {folder},
+	}
+}
+
+// secretEndpoint returns the raw secret endpoint for a secret name.
+func secretEndpoint(name string) string {
+	return "/api/v3/secrets/raw/" + url.PathEscape(name)
+}
+
+// do performs an authenticated API request and decodes the JSON response.
+func (p *Provider) do(ctx context.Context, method, endpoint string, query url.Values, body, result any) error {
+	u := p.config.SiteURL + endpoint
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		bodyReader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, bodyReader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.config.Token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", vault.ErrConnectionFailed, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return vault.ErrAuthenticationFailed
+	case resp.StatusCode == http.StatusForbidden:
+		return vault.ErrAccessDenied
+	case resp.StatusCode == http.StatusNotFound:
+		return vault.ErrSecretNotFound
+	case resp.StatusCode >= 400:
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(respBody, result); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ensure Provider implements vault.Vault.
+var _ vault.Vault = (*Provider)(nil)
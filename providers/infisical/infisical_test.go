@@ -0,0 +1,205 @@
+package infisical
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// newMockServer returns a minimal Infisical API mock holding secrets for
+// project "proj", environment "dev", keyed by folder and name.
+func newMockServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var mu sync.Mutex
+	secrets := map[string]map[string]string{
+		"/":        {"API_KEY": "key123"},
+		"/backend": {"DB_URL": "postgres://"},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/secrets/raw/{name}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		name := r.PathValue("name")
+		folder := r.URL.Query().Get("secretPath")
+		var body struct {
+			SecretPath  string `json:"secretPath"`
+			SecretValue string `json:"secretValue"`
+		}
+		if r.Method != http.MethodGet {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			folder = body.SecretPath
+		}
+
+		value, ok := secrets[folder][name]
+		switch r.Method {
+		case http.MethodGet:
+			if !ok {
+				http.Error(w, `{"message":"Secret not found"}`, http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"secret": map[string]any{"secretKey": name, "secretValue": value, "version": 2},
+			})
+		case http.MethodPatch:
+			if !ok {
+				http.Error(w, `{"message":"Secret not found"}`, http.StatusNotFound)
+				return
+			}
+			secrets[folder][name] = body.SecretValue
+		case http.MethodPost:
+			if ok {
+				http.Error(w, `{"message":"Secret already exists"}`, http.StatusBadRequest)
+				return
+			}
+			if secrets[folder] == nil {
+				secrets[folder] = make(map[string]string)
+			}
+			secrets[folder][name] = body.SecretValue
+		case http.MethodDelete:
+			delete(secrets[folder], name)
+		}
+	})
+	mux.HandleFunc("GET /api/v3/secrets/raw", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		root := r.URL.Query().Get("secretPath")
+		var list []map[string]any
+		for folder, names := range secrets {
+			if !strings.HasPrefix(folder, root) {
+				continue
+			}
+			for name, value := range names {
+				list = append(list, map[string]any{"secretKey": name, "secretValue": value, "secretPath": folder})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"secrets": list})
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer st.valid" {
+			http.Error(w, `{"message":"Invalid token"}`, http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodGet && r.URL.Query().Get("workspaceId") != "proj" {
+			http.Error(w, `{"message":"Project not found"}`, http.StatusNotFound)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestProvider(t *testing.T, server *httptest.Server, token string) *Provider {
+	t.Helper()
+
+	p, err := New(Config{
+		SiteURL:     server.URL,
+		Token:       token,
+		ProjectID:   "proj",
+		Environment: "dev",
+		HTTPClient:  server.Client(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	return p
+}
+
+func TestInfisicalGetSetList(t *testing.T) {
+	server := newMockServer(t)
+	p := newTestProvider(t, server, "st.valid")
+	ctx := context.Background()
+
+	secret, err := p.Get(ctx, "API_KEY")
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if secret.Value != "key123" || secret.Metadata.Version != "2" {
+		t.Errorf("Unexpected secret: %+v", secret)
+	}
+
+	// Folders map to the secret path
+	secret, err = p.Get(ctx, "backend/DB_URL")
+	if err != nil {
+		t.Fatalf("Failed to get secret in folder: %v", err)
+	}
+	if secret.Value != "postgres://" {
+		t.Errorf("Expected value 'postgres://', got '%s'", secret.Value)
+	}
+
+	// Create, then update in place
+	if err := p.Set(ctx, "backend/NEW_SECRET", &vault.Secret{Value: "new"}); err != nil {
+		t.Fatalf("Failed to create secret: %v", err)
+	}
+	if err := p.Set(ctx, "API_KEY", &vault.Secret{Value: "key456"}); err != nil {
+		t.Fatalf("Failed to update secret: %v", err)
+	}
+	if secret, _ := p.Get(ctx, "API_KEY"); secret == nil || secret.Value != "key456" {
+		t.Errorf("Expected updated value, got %+v", secret)
+	}
+
+	names, err := p.List(ctx, "")
+	if err != nil {
+		t.Fatalf("Failed to list secrets: %v", err)
+	}
+	if want := []string{"API_KEY", "backend/DB_URL", "backend/NEW_SECRET"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List = %v, want %v", names, want)
+	}
+
+	names, err = p.List(ctx, "backend/N")
+	if err != nil {
+		t.Fatalf("Failed to list folder: %v", err)
+	}
+	if want := []string{"backend/NEW_SECRET"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List(backend/N) = %v, want %v", names, want)
+	}
+
+	if err := p.Delete(ctx, "backend/NEW_SECRET"); err != nil {
+		t.Fatalf("Failed to delete secret: %v", err)
+	}
+	if exists, err := p.Exists(ctx, "backend/NEW_SECRET"); err != nil || exists {
+		t.Errorf("Expected Exists = false, nil; got %v, %v", exists, err)
+	}
+}
+
+func TestInfisicalNotFound(t *testing.T) {
+	server := newMockServer(t)
+	p := newTestProvider(t, server, "st.valid")
+
+	_, err := p.Get(context.Background(), "backend/MISSING")
+	if !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+}
+
+func TestInfisicalAuthFailure(t *testing.T) {
+	server := newMockServer(t)
+	p := newTestProvider(t, server, "st.invalid")
+	ctx := context.Background()
+
+	if _, err := p.Get(ctx, "API_KEY"); !errors.Is(err, vault.ErrAuthenticationFailed) {
+		t.Errorf("Expected ErrAuthenticationFailed from Get, got %v", err)
+	}
+	if _, err := p.List(ctx, ""); !errors.Is(err, vault.ErrAuthenticationFailed) {
+		t.Errorf("Expected ErrAuthenticationFailed from List, got %v", err)
+	}
+	if err := p.Set(ctx, "API_KEY", &vault.Secret{Value: "x"}); !errors.Is(err, vault.ErrAuthenticationFailed) {
+		t.Errorf("Expected ErrAuthenticationFailed from Set, got %v", err)
+	}
+}
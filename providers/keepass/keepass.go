@@ -0,0 +1,494 @@
+// Package keepass provides a vault implementation backed by a KeePass
+// database (.kdbx), as used by KeePass, KeePassXC, and compatible apps.
+//
+// Usage:
+//
+//	v, err := keepass.New(keepass.Config{
+//	    Path:     "passwords.kdbx",
+//	    Password: os.Getenv("KEEPASS_PASSWORD"),
+//	})
+//	secret, err := v.Get(ctx, "Servers/Production/db") // group/subgroup/title
+//
+// A path is the names of the groups leading to an entry, below the root
+// group, followed by the entry's title. The entry's password is the
+// secret's value; its user name, URL, and notes are the fields
+// "username", "url", and "notes", next to its custom fields. Entries in
+// the recycle bin are ignored.
+package keepass
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tobischo/gokeepasslib/v3"
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// Config holds configuration for the KeePass provider.
+type Config struct {
+	// Path is the .kdbx database file.
+	Path string
+
+	// Password is the database's master password.
+	Password string
+
+	// KeyFile is the database's key file. A database may be locked with a
+	// password, a key file, or both.
+	KeyFile string
+}
+
+// Standard entry keys and the fields they are returned as.
+const (
+	keyTitle    = "Title"
+	keyPassword = "Password"
+)
+
+var standardFields = []struct{ key, field string }{
+	{"UserName", "username"},
+	{"URL", "url"},
+	{"Notes", "notes"},
+}
+
+// Provider implements vault.Vault for a KeePass database.
+type Provider struct {
+	config      Config
+	credentials *gokeepasslib.DBCredentials
+
+	mu      sync.Mutex
+	db      *gokeepasslib.Database
+	modTime time.Time
+	size    int64
+}
+
+// New creates a new KeePass provider. The database is opened on first use,
+// and opened again when the file changes.
+func New(config Config) (*Provider, error) {
+	if config.Path == "" {
+		return nil, errors.New("path is required")
+	}
+
+	var credentials *gokeepasslib.DBCredentials
+	var err error
+	switch {
+	case config.KeyFile != "" && config.Password != "":
+		credentials, err = gokeepasslib.NewPasswordAndKeyCredentials(config.Password, config.KeyFile)
+	case config.KeyFile != "":
+		credentials, err = gokeepasslib.NewKeyCredentials(config.KeyFile)
+	case config.Password != "":
+		credentials = gokeepasslib.NewPasswordCredentials(config.Password)
+	default:
+		return nil, errors.New("password or key file is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	return &Provider{config: config, credentials: credentials}, nil
+}
+
+// load opens the database if it has not been opened or the file changed.
+// Callers must hold p.mu.
+func (p *Provider) load() error {
+	info, err := os.Stat(p.config.Path)
+	if err != nil {
+		return err
+	}
+	if p.db != nil && info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return nil
+	}
+
+	file, err := os.Open(p.config.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	db := gokeepasslib.NewDatabase()
+	db.Credentials = p.credentials
+	if err := gokeepasslib.NewDecoder(file).Decode(db); err != nil {
+		// The library doesn't export its wrong password errors
+		if strings.Contains(err.Error(), "Wrong password") {
+			return fmt.Errorf("%w: %v", vault.ErrAuthenticationFailed, err)
+		}
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	if db.Content == nil || db.Content.Root == nil || len(db.Content.Root.Groups) == 0 {
+		return errors.New("database has no root group")
+	}
+	if err := db.UnlockProtectedEntries(); err != nil {
+		return fmt.Errorf("failed to unlock entries: %w", err)
+	}
+
+	p.db, p.modTime, p.size = db, info.ModTime(), info.Size()
+	return nil
+}
+
+// save writes the database to a temporary file and renames it over the
+// original, keeping its mode. Callers must hold p.mu.
+func (p *Provider) save() error {
+	if err := p.db.LockProtectedEntries(); err != nil {
+		return fmt.Errorf("failed to lock entries: %w", err)
+	}
+	var buf bytes.Buffer
+	err := gokeepasslib.NewEncoder(&buf).Encode(p.db)
+	if unlockErr := p.db.UnlockProtectedEntries(); err == nil {
+		err = unlockErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode database: %w", err)
+	}
+
+	mode := fs.FileMode(0600)
+	if info, err := os.Stat(p.config.Path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p.config.Path), ".keepass-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), p.config.Path); err != nil {
+		return err
+	}
+
+	// The written file is what the next load would read
+	if info, err := os.Stat(p.config.Path); err == nil {
+		p.modTime, p.size = info.ModTime(), info.Size()
+	}
+	return nil
+}
+
+// root returns the database's root group. Callers must hold p.mu.
+func (p *Provider) root() *gokeepasslib.Group {
+	return &p.db.Content.Root.Groups[0]
+}
+
+// recycleBin reports whether g is the database's recycle bin.
+func (p *Provider) recycleBin(g *gokeepasslib.Group) bool {
+	meta := p.db.Content.Meta
+	return meta != nil && meta.RecycleBinEnabled.Bool && g.UUID.Compare(meta.RecycleBinUUID)
+}
+
+// splitPath splits a path into its group names and entry title.
+func splitPath(path string) ([]string, string, error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for _, part := range parts {
+		if part == "" {
+			return nil, "", vault.ErrInvalidPath
+		}
+	}
+	return parts[:len(parts)-1], parts[len(parts)-1], nil
+}
+
+// group returns the group reached through names from the root group,
+// creating missing groups if create is set. Callers must hold p.mu.
+func (p *Provider) group(names []string, create bool) *gokeepasslib.Group {
+	g := p.root()
+	for _, name := range names {
+		i := p.childGroup(g, name)
+		if i < 0 {
+			if !create {
+				return nil
+			}
+			child := gokeepasslib.NewGroup()
+			child.Name = name
+			g.Groups = append(g.Groups, child)
+			i = len(g.Groups) - 1
+		}
+		g = &g.Groups[i]
+	}
+	return g
+}
+
+// childGroup returns the index of the subgroup of g named name, other than
+// the recycle bin, or -1.
+func (p *Provider) childGroup(g *gokeepasslib.Group, name string) int {
+	for i := range g.Groups {
+		if g.Groups[i].Name == name && !p.recycleBin(&g.Groups[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// entryIndex returns the index of the first entry of g titled title, or -1.
+func entryIndex(g *gokeepasslib.Group, title string) int {
+	for i := range g.Entries {
+		if g.Entries[i].GetTitle() == title {
+			return i
+		}
+	}
+	return -1
+}
+
+// find returns the entry at path. Callers must hold p.mu.
+func (p *Provider) find(path string) (*gokeepasslib.Entry, error) {
+	names, title, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+	g := p.group(names, false)
+	if g == nil {
+		return nil, vault.ErrSecretNotFound
+	}
+	i := entryIndex(g, title)
+	if i < 0 {
+		return nil, vault.ErrSecretNotFound
+	}
+	return &g.Entries[i], nil
+}
+
+// Get retrieves the entry at path.
+func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.load(); err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), err)
+	}
+	entry, err := p.find(path)
+	if err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), err)
+	}
+
+	secret := &vault.Secret{
+		Value: entry.GetPassword(),
+		Metadata: vault.Metadata{
+			Provider: p.Name(),
+			Path:     path,
+			Extra:    map[string]any{"uuid": fmt.Sprintf("%x", entry.UUID[:])},
+		},
+	}
+	for _, v := range entry.Values {
+		switch {
+		case v.Key == keyTitle || v.Key == keyPassword:
+		case fieldOf(v.Key) != "":
+			if v.Value.Content != "" {
+				secret.SetField(fieldOf(v.Key), v.Value.Content)
+			}
+		default:
+			secret.SetField(v.Key, v.Value.Content)
+		}
+	}
+	if entry.Tags != "" {
+		secret.Metadata.Labels = strings.FieldsFunc(entry.Tags, func(r rune) bool { return r == ';' || r == ',' })
+	}
+
+	times := entry.Times
+	if times.CreationTime != nil {
+		secret.Metadata.CreatedAt = vault.NewTimestamp(times.CreationTime.Time)
+	}
+	if times.LastModificationTime != nil {
+		secret.Metadata.ModifiedAt = vault.NewTimestamp(times.LastModificationTime.Time)
+	}
+	if times.Expires.Bool && times.ExpiryTime != nil {
+		secret.Metadata.ExpiresAt = vault.NewTimestamp(times.ExpiryTime.Time)
+	}
+	return secret, nil
+}
+
+// Set adds an entry at path, creating missing groups, or replaces the
+// password and fields of the existing entry, keeping its previous state in
+// the entry's history. The database is saved before Set returns.
+func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.load(); err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+	names, title, err := splitPath(path)
+	if err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+
+	g := p.group(names, true)
+	var entry *gokeepasslib.Entry
+	if i := entryIndex(g, title); i >= 0 {
+		entry = &g.Entries[i]
+		previous := *entry
+		previous.Values = slices.Clone(entry.Values)
+		previous.Histories = nil
+		if len(entry.Histories) == 0 {
+			entry.Histories = []gokeepasslib.History{{}}
+		}
+		entry.Histories[0].Entries = append(entry.Histories[0].Entries, previous)
+	} else {
+		g.Entries = append(g.Entries, gokeepasslib.NewEntry())
+		entry = &g.Entries[len(g.Entries)-1]
+		setValue(entry, keyTitle, title, false)
+	}
+
+	entry.Values = slices.DeleteFunc(entry.Values, func(v gokeepasslib.ValueData) bool {
+		return v.Key != keyTitle && fieldOf(v.Key) == ""
+	})
+	setValue(entry, keyPassword, secret.Value, true)
+	for _, std := range standardFields {
+		setValue(entry, std.key, secret.Fields[std.field], false)
+	}
+	for _, name := range slices.Sorted(maps.Keys(secret.Fields)) {
+		if !isStandardField(name) {
+			setValue(entry, name, secret.Fields[name], true)
+		}
+	}
+	now := w.Now()
+	entry.Times.LastModificationTime = &now
+
+	if err := p.save(); err != nil {
+		// Drop the change so the next call reads the file again
+		p.db = nil
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+	return nil
+}
+
+// setValue sets the value of key on entry.
+func setValue(entry *gokeepasslib.Entry, key, value string, protected bool) {
+	v := gokeepasslib.V{Content: value, Protected: w.NewBoolWrapper(protected)}
+	if i := entry.GetIndex(key); i >= 0 {
+		entry.Values[i].Value = v
+		return
+	}
+	entry.Values = append(entry.Values, gokeepasslib.ValueData{Key: key, Value: v})
+}
+
+// fieldOf returns the field a standard key is returned as, or "" for
+// other keys.
+func fieldOf(key string) string {
+	for _, std := range standardFields {
+		if std.key == key {
+			return std.field
+		}
+	}
+	return ""
+}
+
+// isStandardField reports whether name is the field of a standard key.
+func isStandardField(name string) bool {
+	for _, std := range standardFields {
+		if std.field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Delete removes the entry at path and saves the database. Deleting a
+// missing entry is not an error.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.load(); err != nil {
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+	names, title, err := splitPath(path)
+	if err != nil {
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+
+	g := p.group(names, false)
+	if g == nil {
+		return nil
+	}
+	i := entryIndex(g, title)
+	if i < 0 {
+		return nil
+	}
+	g.Entries = append(g.Entries[:i], g.Entries[i+1:]...)
+
+	if err := p.save(); err != nil {
+		p.db = nil
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+	return nil
+}
+
+// Exists checks if an entry exists.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	_, err := p.Get(ctx, path)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return false, nil
+	}
+	return false, err
+}
+
+// List returns the paths of all entries matching the prefix, in database
+// order.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.load(); err != nil {
+		return nil, vault.NewVaultError("List", prefix, p.Name(), err)
+	}
+
+	var results []string
+	var walk func(g *gokeepasslib.Group, dir string)
+	walk = func(g *gokeepasslib.Group, dir string) {
+		for i := range g.Entries {
+			if path := dir + g.Entries[i].GetTitle(); strings.HasPrefix(path, prefix) {
+				results = append(results, path)
+			}
+		}
+		for i := range g.Groups {
+			if !p.recycleBin(&g.Groups[i]) {
+				walk(&g.Groups[i], dir+g.Groups[i].Name+"/")
+			}
+		}
+	}
+	walk(p.root(), "")
+	return results, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "keepass"
+}
+
+// Capabilities returns the provider capabilities.
+func (p *Provider) Capabilities() vault.Capabilities {
+	return vault.Capabilities{
+		Read:       true,
+		Write:      true,
+		Delete:     true,
+		List:       true,
+		MultiField: true,
+	}
+}
+
+// Close is a no-op for the KeePass provider.
+func (p *Provider) Close() error {
+	return nil
+}
+
+// Ensure Provider implements vault.Vault.
+var _ vault.Vault = (*Provider)(nil)
@@ -0,0 +1,177 @@
+package keepass
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// testdata/test.kdbx is a KDBX 3.1 database with the password "omnivault":
+//
+//	Passwords (root)
+//	├── Email
+//	├── Servers/Production/db   (fields, a custom "port" field, tags)
+//	└── Recycle Bin/old
+const testPassword = "omnivault"
+
+// copyFixture copies the fixture database so tests can write to it.
+func copyFixture(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "test.kdbx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "test.kdbx")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGet(t *testing.T) {
+	p, err := New(Config{Path: filepath.Join("testdata", "test.kdbx"), Password: testPassword})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+
+	secret, err := p.Get(ctx, "Servers/Production/db")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if secret.Value != "hunter2" {
+		t.Errorf("Value = %q, want hunter2", secret.Value)
+	}
+	want := map[string]string{
+		"username": "admin",
+		"url":      "postgres://db.internal",
+		"notes":    "primary",
+		"port":     "5432",
+	}
+	if !reflect.DeepEqual(secret.Fields, want) {
+		t.Errorf("Fields = %v, want %v", secret.Fields, want)
+	}
+	if want := []string{"prod", "db"}; !reflect.DeepEqual(secret.Metadata.Labels, want) {
+		t.Errorf("Labels = %v, want %v", secret.Metadata.Labels, want)
+	}
+	if secret.Metadata.ModifiedAt == nil {
+		t.Error("Expected ModifiedAt to be set")
+	}
+
+	email, err := p.Get(ctx, "Email")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if email.Value != "mail-pass" || email.Fields["username"] != "alice@example.com" {
+		t.Errorf("Unexpected secret: %+v", email)
+	}
+	if _, ok := email.Fields["notes"]; ok {
+		t.Error("Expected empty notes to be left out")
+	}
+
+	for _, path := range []string{"Servers/db", "Servers/Production/missing", "Recycle Bin/old", "Servers/Production"} {
+		if _, err := p.Get(ctx, path); !errors.Is(err, vault.ErrSecretNotFound) {
+			t.Errorf("Get(%s): expected ErrSecretNotFound, got %v", path, err)
+		}
+	}
+	if exists, err := p.Exists(ctx, "Email"); err != nil || !exists {
+		t.Errorf("Expected Exists = true, nil; got %v, %v", exists, err)
+	}
+}
+
+func TestWrongPassword(t *testing.T) {
+	p, err := New(Config{Path: filepath.Join("testdata", "test.kdbx"), Password: "wrong"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := p.Get(context.Background(), "Email"); !errors.Is(err, vault.ErrAuthenticationFailed) {
+		t.Errorf("Expected ErrAuthenticationFailed, got %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	p, err := New(Config{Path: filepath.Join("testdata", "test.kdbx"), Password: testPassword})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+
+	got, err := p.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"Email", "Servers/Production/db"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List = %v, want %v", got, want)
+	}
+
+	got, err = p.List(ctx, "Servers/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"Servers/Production/db"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List(Servers/) = %v, want %v", got, want)
+	}
+}
+
+func TestSetAndDelete(t *testing.T) {
+	path := copyFixture(t)
+	p, err := New(Config{Path: path, Password: testPassword})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+
+	created := &vault.Secret{Value: "s3cret", Fields: map[string]string{"username": "bob", "region": "eu"}}
+	if err := p.Set(ctx, "Cloud/AWS/bob", created); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	updated := &vault.Secret{Value: "hunter3", Fields: map[string]string{"username": "admin"}}
+	if err := p.Set(ctx, "Servers/Production/db", updated); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := p.Delete(ctx, "Email"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := p.Delete(ctx, "Email"); err != nil {
+		t.Errorf("Expected Delete of a missing entry to succeed, got %v", err)
+	}
+
+	// A fresh provider reads the saved database
+	reread, err := New(Config{Path: path, Password: testPassword})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	got, err := reread.Get(ctx, "Cloud/AWS/bob")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Value != "s3cret" || !reflect.DeepEqual(got.Fields, created.Fields) {
+		t.Errorf("Expected the created entry to be read back, got %+v", got)
+	}
+
+	got, err = reread.Get(ctx, "Servers/Production/db")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Value != "hunter3" || !reflect.DeepEqual(got.Fields, updated.Fields) {
+		t.Errorf("Expected the entry's password and fields to be replaced, got %+v", got)
+	}
+	entry, _ := reread.find("Servers/Production/db")
+	if len(entry.Histories) != 1 || len(entry.Histories[0].Entries) != 1 || entry.Histories[0].Entries[0].GetPassword() != "hunter2" {
+		t.Errorf("Expected the previous password in the entry's history, got %+v", entry.Histories)
+	}
+
+	paths, err := reread.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"Servers/Production/db", "Cloud/AWS/bob"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("List = %v, want %v", paths, want)
+	}
+}
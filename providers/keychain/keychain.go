@@ -0,0 +1,106 @@
+// Package keychain provides a vault implementation backed by the macOS
+// Keychain.
+//
+// Secrets are stored as generic password items whose service is the
+// configured service name and whose account is the secret path, so that:
+//
+//	v, err := keychain.New(keychain.Config{Service: "myapp"})
+//	secret, err := v.Get(ctx, "database/password")
+//
+// reads the item with service "myapp" and account "database/password".
+//
+// The provider uses the security command shipped with macOS. It is only
+// available on macOS; on other platforms New returns vault.ErrNotSupported.
+package keychain
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+)
+
+// Default configuration values.
+const (
+	DefaultService = "omnivault"
+	DefaultCommand = "/usr/bin/security"
+)
+
+// Config holds configuration for the Keychain provider.
+type Config struct {
+	// Service namespaces all items created by this provider (default: "omnivault").
+	Service string
+
+	// Keychain is the keychain file to use (default: the user's default
+	// keychain, usually the login keychain).
+	Keychain string
+
+	// Command is the security executable to invoke (default: "/usr/bin/security").
+	Command string
+}
+
+// withDefaults returns a copy of the config with defaults applied.
+func (c Config) withDefaults() Config {
+	if c.Service == "" {
+		c.Service = DefaultService
+	}
+	if c.Command == "" {
+		c.Command = DefaultCommand
+	}
+	return c
+}
+
+// label returns the human-readable item label shown in Keychain Access.
+func (c Config) label(path string) string {
+	return c.Service + ": " + path
+}
+
+// quote quotes s for the command line read by "security -i".
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// parseDumpAccounts extracts the accounts of the generic password items of
+// service from `security dump-keychain` output, keeping only those that
+// match the prefix.
+func parseDumpAccounts(output, service, prefix string) []string {
+	var paths []string
+	seen := make(map[string]bool)
+
+	var class, svce, acct string
+	flush := func() {
+		if class == "genp" && svce == service && strings.HasPrefix(acct, prefix) && !seen[acct] {
+			seen[acct] = true
+			paths = append(paths, acct)
+		}
+		class, svce, acct = "", "", ""
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "keychain: "):
+			flush()
+		case strings.HasPrefix(line, "class: "):
+			class = unquote(strings.TrimPrefix(line, "class: "))
+		case strings.HasPrefix(line, `"svce"<blob>=`):
+			svce = unquote(strings.TrimPrefix(line, `"svce"<blob>=`))
+		case strings.HasPrefix(line, `"acct"<blob>=`):
+			acct = unquote(strings.TrimPrefix(line, `"acct"<blob>=`))
+		}
+	}
+	flush()
+	return paths
+}
+
+// unquote returns the text of a quoted dump-keychain value, or "" for
+// values shown in hex or as <NULL>.
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return ""
+	}
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+	return s[1 : len(s)-1]
+}
@@ -0,0 +1,153 @@
+//go:build darwin
+
+package keychain
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// errItemNotFound is the exit status of security when no item matches
+// (errSecItemNotFound).
+const errItemNotFound = 44
+
+// Provider implements vault.Vault for the macOS Keychain.
+type Provider struct {
+	config Config
+}
+
+// New creates a new Keychain provider.
+func New(config Config) (*Provider, error) {
+	config = config.withDefaults()
+	if _, err := exec.LookPath(config.Command); err != nil {
+		return nil, vault.NewVaultError("New", "", "keychain", vault.ErrNotSupported)
+	}
+	return &Provider{config: config}, nil
+}
+
+// run invokes security with the given arguments and optional stdin.
+func (p *Provider) run(ctx context.Context, stdin string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, p.config.Command, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == errItemNotFound {
+			return "", vault.ErrSecretNotFound
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// keychainArgs returns the keychain argument, if one is configured.
+func (p *Provider) keychainArgs() []string {
+	if p.config.Keychain == "" {
+		return nil
+	}
+	return []string{p.config.Keychain}
+}
+
+// Get retrieves a secret from the Keychain.
+func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	args := append([]string{"find-generic-password", "-s", p.config.Service, "-a", path, "-w"}, p.keychainArgs()...)
+	out, err := p.run(ctx, "", args...)
+	if err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), err)
+	}
+	return &vault.Secret{
+		Value: strings.TrimSuffix(out, "\n"),
+		Metadata: vault.Metadata{
+			Provider: p.Name(),
+			Path:     path,
+		},
+	}, nil
+}
+
+// Set stores a secret in the Keychain, replacing any existing item. The
+// command is passed on stdin with the value hex-encoded, so the value
+// never appears in the process list.
+func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -l %s -X %s",
+		quote(p.config.Service), quote(path), quote(p.config.label(path)), hex.EncodeToString(secret.Bytes()))
+	if p.config.Keychain != "" {
+		command += " " + quote(p.config.Keychain)
+	}
+
+	if _, err := p.run(ctx, command+"\n", "-i"); err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+	return nil
+}
+
+// Delete removes a secret from the Keychain.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	args := append([]string{"delete-generic-password", "-s", p.config.Service, "-a", path}, p.keychainArgs()...)
+	if _, err := p.run(ctx, "", args...); err != nil && !errors.Is(err, vault.ErrSecretNotFound) {
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+	return nil
+}
+
+// Exists checks if a secret exists in the Keychain.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	_, err := p.Get(ctx, path)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return false, nil
+	}
+	return false, err
+}
+
+// List returns all secret paths matching the prefix.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	out, err := p.run(ctx, "", append([]string{"dump-keychain"}, p.keychainArgs()...)...)
+	if err != nil {
+		return nil, vault.NewVaultError("List", prefix, p.Name(), err)
+	}
+
+	paths := parseDumpAccounts(out, p.config.Service, prefix)
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "keychain"
+}
+
+// Capabilities returns the provider capabilities.
+func (p *Provider) Capabilities() vault.Capabilities {
+	return vault.Capabilities{
+		Read:   true,
+		Write:  true,
+		Delete: true,
+		List:   true,
+	}
+}
+
+// Close is a no-op for the Keychain provider.
+func (p *Provider) Close() error {
+	return nil
+}
+
+// Ensure Provider implements vault.Vault.
+var _ vault.Vault = (*Provider)(nil)
@@ -0,0 +1,103 @@
+package keychain
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+func TestConfigDefaults(t *testing.T) {
+	config := Config{}.withDefaults()
+	if config.Service != DefaultService || config.Command != DefaultCommand {
+		t.Errorf("Unexpected defaults: %+v", config)
+	}
+	if got := (Config{Service: "myapp"}).label("x"); got != "myapp: x" {
+		t.Errorf("Unexpected label: %s", got)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if got, want := quote("it's"), `'it'"'"'s'`; got != want {
+		t.Errorf("quote = %s, want %s", got, want)
+	}
+}
+
+func TestParseDumpAccounts(t *testing.T) {
+	output := `keychain: "/Users/me/Library/Keychains/login.keychain-db"
+version: 512
+class: "genp"
+attributes:
+    0x00000007 <blob>="omnivault: database/password"
+    "acct"<blob>="database/password"
+    "cdat"<timedate>=0x32303234303130313030303030305A00  "20240101000000Z\000"
+    "svce"<blob>="omnivault"
+keychain: "/Users/me/Library/Keychains/login.keychain-db"
+version: 512
+class: "genp"
+attributes:
+    "acct"<blob>="api/key"
+    "svce"<blob>="omnivault"
+keychain: "/Users/me/Library/Keychains/login.keychain-db"
+version: 512
+class: "genp"
+attributes:
+    "acct"<blob>="database/password"
+    "svce"<blob>="other"
+keychain: "/Users/me/Library/Keychains/login.keychain-db"
+version: 512
+class: "inet"
+attributes:
+    "acct"<blob>="database/user"
+    "svce"<blob>="omnivault"
+`
+
+	got := parseDumpAccounts(output, "omnivault", "")
+	want := []string{"database/password", "api/key"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected paths %v, got %v", want, got)
+	}
+
+	got = parseDumpAccounts(output, "omnivault", "database/")
+	if len(got) != 1 || got[0] != "database/password" {
+		t.Errorf("Expected only database/password, got %v", got)
+	}
+}
+
+// TestLive exercises the real login keychain. It is only run when
+// OMNIVAULT_KEYCHAIN_LIVE=1.
+func TestLive(t *testing.T) {
+	if os.Getenv("OMNIVAULT_KEYCHAIN_LIVE") != "1" {
+		t.Skip("set OMNIVAULT_KEYCHAIN_LIVE=1 to run against the login keychain")
+	}
+
+	ctx := context.Background()
+	p, err := New(Config{Service: "omnivault-test"})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	defer p.Close()
+
+	if err := p.Set(ctx, "live/secret", &vault.Secret{Value: "value123"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	defer func() { _ = p.Delete(ctx, "live/secret") }()
+
+	secret, err := p.Get(ctx, "live/secret")
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if secret.Value != "value123" {
+		t.Errorf("Expected value 'value123', got '%s'", secret.Value)
+	}
+
+	paths, err := p.List(ctx, "live/")
+	if err != nil {
+		t.Fatalf("Failed to list secrets: %v", err)
+	}
+	if len(paths) != 1 {
+		t.Errorf("Expected 1 path, got %v", paths)
+	}
+}
@@ -0,0 +1,117 @@
+// Package keyring provides a vault implementation backed by the operating
+// system's keyring, chosen at runtime:
+//
+//   - macOS: the Keychain (see package keychain)
+//   - Windows: the Credential Manager (see package wincred)
+//   - Linux: the Secret Service (see package libsecret)
+//
+// All operations are delegated to the platform provider, so secrets written
+// through keyring can also be read with that provider directly, using the
+// same service name.
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+
+	"github.com/agentplexus/omnivault/providers/keychain"
+	"github.com/agentplexus/omnivault/providers/libsecret"
+	"github.com/agentplexus/omnivault/providers/wincred"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// Config holds configuration for the keyring provider.
+type Config struct {
+	// Service namespaces all secrets created by this provider (default: "omnivault").
+	Service string
+
+	// Keychain is the macOS keychain file to use (default: the login keychain).
+	Keychain string
+
+	// Collection is the Secret Service collection to store new items in on
+	// Linux (default: the user's default collection).
+	Collection string
+}
+
+// backend is a platform keyring provider.
+type backend struct {
+	// name is the name of the delegate provider.
+	name string
+
+	// requires describes what the backend needs to be available.
+	requires string
+
+	// open creates the delegate provider.
+	open func(Config) (vault.Vault, error)
+}
+
+// backends maps GOOS values to their keyring backend.
+var backends = map[string]backend{
+	"darwin": {
+		name:     "keychain",
+		requires: "the security command",
+		open: func(c Config) (vault.Vault, error) {
+			return keychain.New(keychain.Config{Service: c.Service, Keychain: c.Keychain})
+		},
+	},
+	"windows": {
+		name:     "wincred",
+		requires: "advapi32.dll",
+		open: func(c Config) (vault.Vault, error) {
+			return wincred.New(wincred.Config{Service: c.Service})
+		},
+	},
+	"linux": {
+		name:     "libsecret",
+		requires: "a Secret Service on the D-Bus session bus",
+		open: func(c Config) (vault.Vault, error) {
+			return libsecret.New(libsecret.Config{Service: c.Service, Collection: c.Collection})
+		},
+	},
+}
+
+// Provider implements vault.Vault by delegating to the platform keyring.
+type Provider struct {
+	vault.Vault
+	backend string
+}
+
+// New creates a keyring provider for the current platform. It fails with an
+// error wrapping vault.ErrNotSupported if the platform has no supported
+// keyring or its backend is unavailable.
+func New(config Config) (*Provider, error) {
+	return newForOS(runtime.GOOS, config)
+}
+
+// newForOS creates a keyring provider using the backend for goos.
+func newForOS(goos string, config Config) (*Provider, error) {
+	b, ok := backends[goos]
+	if !ok {
+		return nil, vault.NewVaultError("New", "", "keyring",
+			fmt.Errorf("no keyring backend for %s: %w", goos, vault.ErrNotSupported))
+	}
+
+	v, err := b.open(config)
+	if err != nil {
+		if errors.Is(err, vault.ErrNotSupported) {
+			err = fmt.Errorf("%s backend is unavailable on %s (requires %s): %w", b.name, goos, b.requires, vault.ErrNotSupported)
+		}
+		return nil, vault.NewVaultError("New", "", "keyring", err)
+	}
+	return &Provider{Vault: v, backend: b.name}, nil
+}
+
+// Backend returns the name of the provider the keyring delegates to, e.g.
+// "keychain".
+func (p *Provider) Backend() string {
+	return p.backend
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "keyring"
+}
+
+// Ensure Provider implements vault.Vault.
+var _ vault.Vault = (*Provider)(nil)
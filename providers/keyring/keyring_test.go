@@ -0,0 +1,114 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/agentplexus/omnivault/providers/memory"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// fakeBackends replaces the platform backends for the duration of a test.
+// Backends listed in unavailable fail as their real providers do on a
+// platform without the keyring.
+func fakeBackends(t *testing.T, unavailable ...string) map[string]Config {
+	t.Helper()
+	opened := make(map[string]Config)
+	fakes := make(map[string]backend, len(backends))
+	for goos, b := range backends {
+		open := func(c Config) (vault.Vault, error) {
+			opened[b.name] = c
+			return memory.New(), nil
+		}
+		for _, name := range unavailable {
+			if name == b.name {
+				open = func(Config) (vault.Vault, error) {
+					return nil, vault.NewVaultError("New", "", b.name, vault.ErrNotSupported)
+				}
+			}
+		}
+		fakes[goos] = backend{name: b.name, requires: b.requires, open: open}
+	}
+
+	saved := backends
+	backends = fakes
+	t.Cleanup(func() { backends = saved })
+	return opened
+}
+
+func TestBackendSelection(t *testing.T) {
+	tests := []struct {
+		goos string
+		want string
+	}{
+		{"darwin", "keychain"},
+		{"windows", "wincred"},
+		{"linux", "libsecret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			opened := fakeBackends(t)
+
+			p, err := newForOS(tt.goos, Config{Service: "myapp"})
+			if err != nil {
+				t.Fatalf("newForOS failed: %v", err)
+			}
+			if p.Backend() != tt.want || p.Name() != "keyring" {
+				t.Errorf("Backend = %s, Name = %s; want %s, keyring", p.Backend(), p.Name(), tt.want)
+			}
+			if len(opened) != 1 || opened[tt.want].Service != "myapp" {
+				t.Errorf("Expected only %s to be opened with the config, got %v", tt.want, opened)
+			}
+
+			ctx := context.Background()
+			if err := p.Set(ctx, "api/key", &vault.Secret{Value: "abc"}); err != nil {
+				t.Fatalf("Set failed: %v", err)
+			}
+			if got, err := p.Get(ctx, "api/key"); err != nil || got.Value != "abc" {
+				t.Errorf("Get = %v, %v; want abc", got, err)
+			}
+		})
+	}
+}
+
+func TestBackendUnavailable(t *testing.T) {
+	fakeBackends(t, "libsecret")
+
+	_, err := newForOS("linux", Config{})
+	if !errors.Is(err, vault.ErrNotSupported) {
+		t.Fatalf("Expected ErrNotSupported, got %v", err)
+	}
+	for _, want := range []string{"libsecret", "linux", "Secret Service"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got %v", want, err)
+		}
+	}
+
+	_, err = newForOS("plan9", Config{})
+	if !errors.Is(err, vault.ErrNotSupported) || !strings.Contains(err.Error(), "plan9") {
+		t.Errorf("Expected ErrNotSupported naming plan9, got %v", err)
+	}
+}
+
+func TestNewUsesCurrentPlatform(t *testing.T) {
+	b, ok := backends[runtime.GOOS]
+	if !ok {
+		t.Skipf("no keyring backend for %s", runtime.GOOS)
+	}
+
+	p, err := New(Config{})
+	if err != nil {
+		// The real backend may be missing, e.g. the Secret Service in CI
+		if !errors.Is(err, vault.ErrNotSupported) || !strings.Contains(err.Error(), b.requires) {
+			t.Errorf("Expected a clear ErrNotSupported error, got %v", err)
+		}
+		return
+	}
+	if p.Backend() != b.name {
+		t.Errorf("Expected backend %s on %s, got %s", b.name, runtime.GOOS, p.Backend())
+	}
+}
@@ -0,0 +1,64 @@
+// Package libsecret provides a vault implementation backed by the Linux
+// Secret Service (GNOME Keyring, KWallet, KeePassXC), the D-Bus API that
+// libsecret uses.
+//
+// Secrets are stored as items in a Secret Service collection. Each secret
+// path is mapped to a pair of item attributes, so that:
+//
+//	v, err := libsecret.New(libsecret.Config{Service: "myapp"})
+//	secret, err := v.Get(ctx, "database/password")
+//
+// looks up the item with attributes service=myapp and path=database/password.
+//
+// The provider talks to the Secret Service on the D-Bus session bus, so it
+// needs no libsecret library or secret-tool command at runtime. List reads
+// only the item attributes, never the secret values. It is only available
+// on Linux; on other platforms, or without a session bus or Secret
+// Service, New returns vault.ErrNotSupported.
+package libsecret
+
+// Default configuration values.
+const (
+	DefaultService = "omnivault"
+
+	// DefaultCollection is the alias of the user's default collection.
+	DefaultCollection = "default"
+
+	// AttrService is the item attribute holding the configured service name.
+	AttrService = "service"
+
+	// AttrPath is the item attribute holding the secret path.
+	AttrPath = "path"
+)
+
+// Config holds configuration for the libsecret provider.
+type Config struct {
+	// Service namespaces all items created by this provider (default: "omnivault").
+	Service string
+
+	// Collection is the Secret Service collection to store new items in,
+	// as an alias such as "session" or a D-Bus object path (default: the
+	// user's default collection, usually "login").
+	Collection string
+}
+
+// withDefaults returns a copy of the config with defaults applied.
+func (c Config) withDefaults() Config {
+	if c.Service == "" {
+		c.Service = DefaultService
+	}
+	if c.Collection == "" {
+		c.Collection = DefaultCollection
+	}
+	return c
+}
+
+// attributes returns the item attributes identifying a secret path.
+func (c Config) attributes(path string) map[string]string {
+	return map[string]string{AttrService: c.Service, AttrPath: path}
+}
+
+// label returns the human-readable item label shown in keyring UIs.
+func (c Config) label(path string) string {
+	return c.Service + ": " + path
+}
@@ -0,0 +1,344 @@
+//go:build linux
+
+package libsecret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// Secret Service D-Bus names, from
+// https://specifications.freedesktop.org/secret-service/.
+const (
+	busName       = "org.freedesktop.secrets"
+	servicePath   = dbus.ObjectPath("/org/freedesktop/secrets")
+	serviceIface  = "org.freedesktop.Secret.Service"
+	sessionIface  = "org.freedesktop.Secret.Session"
+	itemIface     = "org.freedesktop.Secret.Item"
+	promptIface   = "org.freedesktop.Secret.Prompt"
+	noPrompt      = dbus.ObjectPath("/")
+	noCollection  = dbus.ObjectPath("/")
+	contentType   = "text/plain; charset=utf8"
+	algorithmNone = "plain"
+)
+
+// secret is the Secret Service's (oayays) secret struct.
+type secret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// Provider implements vault.Vault for the Linux Secret Service.
+type Provider struct {
+	config Config
+	conn   *dbus.Conn
+
+	// object returns the Secret Service object at a path.
+	object func(path dbus.ObjectPath) dbus.BusObject
+}
+
+// New creates a new libsecret provider connected to the session bus.
+func New(config Config) (*Provider, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, vault.NewVaultError("New", "", "libsecret", vault.ErrNotSupported)
+	}
+	if !serviceAvailable(conn) {
+		_ = conn.Close()
+		return nil, vault.NewVaultError("New", "", "libsecret", vault.ErrNotSupported)
+	}
+
+	return &Provider{
+		config: config.withDefaults(),
+		conn:   conn,
+		object: func(path dbus.ObjectPath) dbus.BusObject {
+			return conn.Object(busName, path)
+		},
+	}, nil
+}
+
+// serviceAvailable reports whether a Secret Service is running on the bus
+// or can be started by it.
+func serviceAvailable(conn *dbus.Conn) bool {
+	var running bool
+	if err := conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, busName).Store(&running); err == nil && running {
+		return true
+	}
+	var activatable []string
+	if err := conn.BusObject().Call("org.freedesktop.DBus.ListActivatableNames", 0).Store(&activatable); err != nil {
+		return false
+	}
+	for _, name := range activatable {
+		if name == busName {
+			return true
+		}
+	}
+	return false
+}
+
+// call invokes a method on the Secret Service object at path.
+func (p *Provider) call(ctx context.Context, path dbus.ObjectPath, method string, args ...any) *dbus.Call {
+	return p.object(path).CallWithContext(ctx, method, 0, args...)
+}
+
+// search returns the items with the given attributes, unlocked first.
+func (p *Provider) search(ctx context.Context, attributes map[string]string) ([]dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	if err := p.call(ctx, servicePath, serviceIface+".SearchItems", attributes).Store(&unlocked, &locked); err != nil {
+		return nil, err
+	}
+	if err := p.unlock(ctx, locked); err != nil {
+		return nil, err
+	}
+	return append(unlocked, locked...), nil
+}
+
+// unlock unlocks items or collections, prompting the user if the Secret
+// Service asks to.
+func (p *Provider) unlock(ctx context.Context, objects []dbus.ObjectPath) error {
+	if len(objects) == 0 {
+		return nil
+	}
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	if err := p.call(ctx, servicePath, serviceIface+".Unlock", objects).Store(&unlocked, &prompt); err != nil {
+		return err
+	}
+	return p.prompt(ctx, prompt)
+}
+
+// prompt shows a Secret Service prompt, such as a password dialog to
+// unlock a collection, and waits for the user to complete it.
+func (p *Provider) prompt(ctx context.Context, prompt dbus.ObjectPath) error {
+	if prompt == noPrompt || prompt == "" {
+		return nil
+	}
+	if p.conn == nil {
+		return errors.New("the Secret Service requires a prompt")
+	}
+
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(prompt),
+		dbus.WithMatchInterface(promptIface),
+		dbus.WithMatchMember("Completed"),
+	}
+	if err := p.conn.AddMatchSignalContext(ctx, match...); err != nil {
+		return err
+	}
+	defer func() { _ = p.conn.RemoveMatchSignal(match...) }()
+
+	signals := make(chan *dbus.Signal, 1)
+	p.conn.Signal(signals)
+	defer p.conn.RemoveSignal(signals)
+
+	if err := p.call(ctx, prompt, promptIface+".Prompt", "").Err; err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			_ = p.call(context.Background(), prompt, promptIface+".Dismiss").Err
+			return ctx.Err()
+		case sig := <-signals:
+			if sig.Path != prompt || sig.Name != promptIface+".Completed" {
+				continue
+			}
+			var dismissed bool
+			var result dbus.Variant
+			if err := dbus.Store(sig.Body, &dismissed, &result); err != nil {
+				return err
+			}
+			if dismissed {
+				return fmt.Errorf("%w: prompt dismissed", vault.ErrAccessDenied)
+			}
+			return nil
+		}
+	}
+}
+
+// openSession opens a session for transferring secrets. Secrets are sent
+// unencrypted, which is safe on the user's private session bus.
+func (p *Provider) openSession(ctx context.Context) (dbus.ObjectPath, func(), error) {
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	if err := p.call(ctx, servicePath, serviceIface+".OpenSession", algorithmNone, dbus.MakeVariant("")).Store(&output, &session); err != nil {
+		return "", nil, err
+	}
+	return session, func() { _ = p.call(context.Background(), session, sessionIface+".Close").Err }, nil
+}
+
+// collection returns the path of the collection new items are stored in.
+func (p *Provider) collection(ctx context.Context) (dbus.ObjectPath, error) {
+	if strings.HasPrefix(p.config.Collection, "/") {
+		return dbus.ObjectPath(p.config.Collection), nil
+	}
+	var path dbus.ObjectPath
+	if err := p.call(ctx, servicePath, serviceIface+".ReadAlias", p.config.Collection).Store(&path); err != nil {
+		return "", err
+	}
+	if path == noCollection {
+		return "", fmt.Errorf("no Secret Service collection %q", p.config.Collection)
+	}
+	return path, nil
+}
+
+// Get retrieves a secret from the Secret Service.
+func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	items, err := p.search(ctx, p.config.attributes(path))
+	if err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), err)
+	}
+	if len(items) == 0 {
+		return nil, vault.NewVaultError("Get", path, p.Name(), vault.ErrSecretNotFound)
+	}
+
+	session, closeSession, err := p.openSession(ctx)
+	if err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), err)
+	}
+	defer closeSession()
+
+	var s secret
+	if err := p.call(ctx, items[0], itemIface+".GetSecret", session).Store(&s); err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), err)
+	}
+	return &vault.Secret{
+		Value: string(s.Value),
+		Metadata: vault.Metadata{
+			Provider: p.Name(),
+			Path:     path,
+		},
+	}, nil
+}
+
+// Set stores a secret in the Secret Service, replacing any existing item.
+func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	if err := p.set(ctx, path, secret); err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+	return nil
+}
+
+func (p *Provider) set(ctx context.Context, path string, value *vault.Secret) error {
+	collection, err := p.collection(ctx)
+	if err != nil {
+		return err
+	}
+	if err := p.unlock(ctx, []dbus.ObjectPath{collection}); err != nil {
+		return err
+	}
+
+	session, closeSession, err := p.openSession(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeSession()
+
+	properties := map[string]dbus.Variant{
+		itemIface + ".Label":      dbus.MakeVariant(p.config.label(path)),
+		itemIface + ".Attributes": dbus.MakeVariant(p.config.attributes(path)),
+	}
+	s := secret{Session: session, Parameters: []byte{}, Value: []byte(value.String()), ContentType: contentType}
+
+	var item, prompt dbus.ObjectPath
+	if err := p.call(ctx, collection, "org.freedesktop.Secret.Collection.CreateItem", properties, s, true).Store(&item, &prompt); err != nil {
+		return err
+	}
+	return p.prompt(ctx, prompt)
+}
+
+// Delete removes a secret from the Secret Service.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	items, err := p.search(ctx, p.config.attributes(path))
+	if err != nil {
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+	for _, item := range items {
+		var prompt dbus.ObjectPath
+		if err := p.call(ctx, item, itemIface+".Delete").Store(&prompt); err != nil {
+			return vault.NewVaultError("Delete", path, p.Name(), err)
+		}
+		if err := p.prompt(ctx, prompt); err != nil {
+			return vault.NewVaultError("Delete", path, p.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Exists checks if a secret exists in the Secret Service, without reading
+// its value.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	var unlocked, locked []dbus.ObjectPath
+	err := p.call(ctx, servicePath, serviceIface+".SearchItems", p.config.attributes(path)).Store(&unlocked, &locked)
+	if err != nil {
+		return false, vault.NewVaultError("Exists", path, p.Name(), err)
+	}
+	return len(unlocked)+len(locked) > 0, nil
+}
+
+// List returns all secret paths matching the prefix. Only the attributes
+// of the items are read, so no secret value is transferred and locked
+// items are not unlocked.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	var unlocked, locked []dbus.ObjectPath
+	err := p.call(ctx, servicePath, serviceIface+".SearchItems", map[string]string{AttrService: p.config.Service}).Store(&unlocked, &locked)
+	if err != nil {
+		return nil, vault.NewVaultError("List", prefix, p.Name(), err)
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	for _, item := range append(unlocked, locked...) {
+		v, err := p.object(item).GetProperty(itemIface + ".Attributes")
+		if err != nil {
+			return nil, vault.NewVaultError("List", prefix, p.Name(), err)
+		}
+		var attributes map[string]string
+		if err := v.Store(&attributes); err != nil {
+			return nil, vault.NewVaultError("List", prefix, p.Name(), err)
+		}
+		path, ok := attributes[AttrPath]
+		if !ok || !strings.HasPrefix(path, prefix) || seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "libsecret"
+}
+
+// Capabilities returns the provider capabilities.
+func (p *Provider) Capabilities() vault.Capabilities {
+	return vault.Capabilities{
+		Read:   true,
+		Write:  true,
+		Delete: true,
+		List:   true,
+	}
+}
+
+// Close closes the connection to the session bus.
+func (p *Provider) Close() error {
+	if p.conn == nil {
+		return nil
+	}
+	return p.conn.Close()
+}
+
+// Ensure Provider implements vault.Vault.
+var _ vault.Vault = (*Provider)(nil)
@@ -0,0 +1,217 @@
+//go:build linux
+
+package libsecret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+const fakeCollection = dbus.ObjectPath("/org/freedesktop/secrets/collection/login")
+
+// fakeService is an in-memory Secret Service.
+type fakeService struct {
+	items      map[dbus.ObjectPath]*fakeItem
+	next       int
+	secretsOut int
+}
+
+type fakeItem struct {
+	label      string
+	attributes map[string]string
+	value      []byte
+	locked     bool
+}
+
+func newFakeProvider(config Config) (*Provider, *fakeService) {
+	s := &fakeService{items: make(map[dbus.ObjectPath]*fakeItem)}
+	p := &Provider{
+		config: config.withDefaults(),
+		object: func(path dbus.ObjectPath) dbus.BusObject {
+			return &fakeObject{service: s, path: path}
+		},
+	}
+	return p, s
+}
+
+// fakeObject implements the methods of dbus.BusObject the provider uses.
+type fakeObject struct {
+	dbus.BusObject
+	service *fakeService
+	path    dbus.ObjectPath
+}
+
+func (o *fakeObject) CallWithContext(_ context.Context, method string, _ dbus.Flags, args ...any) *dbus.Call {
+	s := o.service
+	reply := func(body ...any) *dbus.Call { return &dbus.Call{Body: body} }
+
+	switch method {
+	case serviceIface + ".SearchItems":
+		var unlocked, locked []dbus.ObjectPath
+		for _, path := range s.search(args[0].(map[string]string)) {
+			if s.items[path].locked {
+				locked = append(locked, path)
+			} else {
+				unlocked = append(unlocked, path)
+			}
+		}
+		return reply(unlocked, locked)
+	case serviceIface + ".Unlock":
+		for _, path := range args[0].([]dbus.ObjectPath) {
+			if item, ok := s.items[path]; ok {
+				item.locked = false
+			}
+		}
+		return reply(args[0], noPrompt)
+	case serviceIface + ".OpenSession":
+		return reply(dbus.MakeVariant(""), dbus.ObjectPath("/org/freedesktop/secrets/session/1"))
+	case serviceIface + ".ReadAlias":
+		if args[0] == "default" {
+			return reply(fakeCollection)
+		}
+		return reply(noCollection)
+	case sessionIface + ".Close":
+		return reply()
+	case "org.freedesktop.Secret.Collection.CreateItem":
+		if o.path != fakeCollection {
+			return &dbus.Call{Err: fmt.Errorf("no collection %s", o.path)}
+		}
+		properties, sec := args[0].(map[string]dbus.Variant), args[1].(secret)
+		attributes := properties[itemIface+".Attributes"].Value().(map[string]string)
+		item := &fakeItem{label: properties[itemIface+".Label"].Value().(string), attributes: attributes, value: sec.Value}
+		path := dbus.ObjectPath("")
+		if existing := s.search(attributes); len(existing) > 0 && args[2].(bool) {
+			path = existing[0]
+		} else {
+			s.next++
+			path = dbus.ObjectPath(fmt.Sprintf("%s/%d", fakeCollection, s.next))
+		}
+		s.items[path] = item
+		return reply(path, noPrompt)
+	case itemIface + ".GetSecret":
+		item, ok := s.items[o.path]
+		if !ok || item.locked {
+			return &dbus.Call{Err: errors.New("item is locked or missing")}
+		}
+		s.secretsOut++
+		return reply(secret{Session: args[0].(dbus.ObjectPath), Value: item.value, ContentType: contentType})
+	case itemIface + ".Delete":
+		delete(s.items, o.path)
+		return reply(noPrompt)
+	}
+	return &dbus.Call{Err: fmt.Errorf("unexpected call %s", method)}
+}
+
+func (o *fakeObject) GetProperty(name string) (dbus.Variant, error) {
+	item, ok := o.service.items[o.path]
+	if !ok || name != itemIface+".Attributes" {
+		return dbus.Variant{}, fmt.Errorf("no property %s on %s", name, o.path)
+	}
+	return dbus.MakeVariant(item.attributes), nil
+}
+
+// search returns the items having all the attributes.
+func (s *fakeService) search(attributes map[string]string) []dbus.ObjectPath {
+	var paths []dbus.ObjectPath
+	for path, item := range s.items {
+		match := true
+		for k, v := range attributes {
+			match = match && item.attributes[k] == v
+		}
+		if match {
+			paths = append(paths, path)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i] < paths[j] })
+	return paths
+}
+
+func TestProviderSecretService(t *testing.T) {
+	p, s := newFakeProvider(Config{Service: "myapp"})
+	ctx := context.Background()
+
+	for path, value := range map[string]string{"db/password": "old", "api/key": "abc"} {
+		if err := p.Set(ctx, path, &vault.Secret{Value: value}); err != nil {
+			t.Fatalf("Set(%s) failed: %v", path, err)
+		}
+	}
+	if err := p.Set(ctx, "db/password", &vault.Secret{Value: "hunter2"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if len(s.items) != 2 {
+		t.Errorf("Expected Set to replace the existing item, got %d items", len(s.items))
+	}
+	for _, item := range s.items {
+		if item.label != "myapp: "+item.attributes[AttrPath] {
+			t.Errorf("Unexpected label %q", item.label)
+		}
+	}
+
+	secret, err := p.Get(ctx, "db/password")
+	if err != nil || secret.Value != "hunter2" {
+		t.Fatalf("Get = %v, %v; want hunter2", secret, err)
+	}
+	if _, err := p.Get(ctx, "missing"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+
+	// Locked items are listed from their attributes and unlocked to be read
+	for _, item := range s.items {
+		item.locked = true
+	}
+	s.secretsOut = 0
+	paths, err := p.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if !reflect.DeepEqual(paths, []string{"api/key", "db/password"}) {
+		t.Errorf("List = %v", paths)
+	}
+	if paths, _ := p.List(ctx, "db/"); !reflect.DeepEqual(paths, []string{"db/password"}) {
+		t.Errorf("List(db/) = %v", paths)
+	}
+	if exists, err := p.Exists(ctx, "api/key"); err != nil || !exists {
+		t.Errorf("Exists = %v, %v; want true", exists, err)
+	}
+	if s.secretsOut != 0 {
+		t.Errorf("Expected List and Exists not to read secret values, read %d", s.secretsOut)
+	}
+	if secret, err := p.Get(ctx, "api/key"); err != nil || secret.Value != "abc" {
+		t.Errorf("Get of a locked item = %v, %v; want abc", secret, err)
+	}
+
+	if err := p.Delete(ctx, "api/key"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if exists, _ := p.Exists(ctx, "api/key"); exists {
+		t.Error("Expected api/key to be deleted")
+	}
+	if err := p.Delete(ctx, "api/key"); err != nil {
+		t.Errorf("Expected deleting a missing secret to succeed, got %v", err)
+	}
+}
+
+func TestProviderCollection(t *testing.T) {
+	ctx := context.Background()
+
+	p, _ := newFakeProvider(Config{Collection: "work"})
+	if err := p.Set(ctx, "x", &vault.Secret{Value: "v"}); err == nil {
+		t.Error("Expected an error for an unknown collection alias")
+	}
+
+	p, s := newFakeProvider(Config{Collection: string(fakeCollection)})
+	if err := p.Set(ctx, "x", &vault.Secret{Value: "v"}); err != nil {
+		t.Fatalf("Set with a collection path failed: %v", err)
+	}
+	if len(s.items) != 1 {
+		t.Errorf("Expected 1 item, got %d", len(s.items))
+	}
+}
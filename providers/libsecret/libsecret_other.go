@@ -0,0 +1,60 @@
+//go:build !linux
+
+package libsecret
+
+import (
+	"context"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// Provider is unavailable on this platform.
+type Provider struct{}
+
+// New returns vault.ErrNotSupported on non-Linux platforms.
+func New(config Config) (*Provider, error) {
+	return nil, vault.NewVaultError("New", "", "libsecret", vault.ErrNotSupported)
+}
+
+// Get returns vault.ErrNotSupported.
+func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	return nil, vault.NewVaultError("Get", path, p.Name(), vault.ErrNotSupported)
+}
+
+// Set returns vault.ErrNotSupported.
+func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	return vault.NewVaultError("Set", path, p.Name(), vault.ErrNotSupported)
+}
+
+// Delete returns vault.ErrNotSupported.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	return vault.NewVaultError("Delete", path, p.Name(), vault.ErrNotSupported)
+}
+
+// Exists returns vault.ErrNotSupported.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	return false, vault.NewVaultError("Exists", path, p.Name(), vault.ErrNotSupported)
+}
+
+// List returns vault.ErrNotSupported.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	return nil, vault.NewVaultError("List", prefix, p.Name(), vault.ErrNotSupported)
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "libsecret"
+}
+
+// Capabilities reports no capabilities on unsupported platforms.
+func (p *Provider) Capabilities() vault.Capabilities {
+	return vault.Capabilities{}
+}
+
+// Close is a no-op.
+func (p *Provider) Close() error {
+	return nil
+}
+
+// Ensure Provider implements vault.Vault.
+var _ vault.Vault = (*Provider)(nil)
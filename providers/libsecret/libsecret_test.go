@@ -0,0 +1,67 @@
+package libsecret
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+func TestConfigAttributes(t *testing.T) {
+	config := Config{}.withDefaults()
+
+	got := config.attributes("database/password")
+	want := map[string]string{"service": "omnivault", "path": "database/password"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected attributes %v, got %v", want, got)
+	}
+	if config.Collection != "default" {
+		t.Errorf("Expected the default collection, got '%s'", config.Collection)
+	}
+
+	config = Config{Service: "myapp"}.withDefaults()
+	if got := config.attributes("x")["service"]; got != "myapp" {
+		t.Errorf("Expected service 'myapp', got '%s'", got)
+	}
+	if config.label("x") != "myapp: x" {
+		t.Errorf("Unexpected label: %s", config.label("x"))
+	}
+}
+
+// TestLive exercises a real Secret Service. It requires a session bus and
+// is only run when OMNIVAULT_LIBSECRET_LIVE=1.
+func TestLive(t *testing.T) {
+	if os.Getenv("OMNIVAULT_LIBSECRET_LIVE") != "1" {
+		t.Skip("set OMNIVAULT_LIBSECRET_LIVE=1 to run against a live Secret Service")
+	}
+
+	ctx := context.Background()
+	p, err := New(Config{Service: "omnivault-test"})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	defer p.Close()
+
+	if err := p.Set(ctx, "live/secret", &vault.Secret{Value: "value123"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	defer func() { _ = p.Delete(ctx, "live/secret") }()
+
+	secret, err := p.Get(ctx, "live/secret")
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if secret.Value != "value123" {
+		t.Errorf("Expected value 'value123', got '%s'", secret.Value)
+	}
+
+	paths, err := p.List(ctx, "live/")
+	if err != nil {
+		t.Fatalf("Failed to list secrets: %v", err)
+	}
+	if len(paths) != 1 {
+		t.Errorf("Expected 1 path, got %v", paths)
+	}
+}
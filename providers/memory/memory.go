@@ -6,10 +6,15 @@
 //	v := memory.New()
 //	v.Set(ctx, "my-secret", &vault.Secret{Value: "secret-value"})
 //	secret, err := v.Get(ctx, "my-secret")
+//
+// NewBounded creates a provider that holds at most a fixed number of
+// secrets, evicting the least recently used, for use as a cache backend.
 package memory
 
 import (
+	"container/list"
 	"context"
+	"sort"
 	"strings"
 	"sync"
 
@@ -21,6 +26,12 @@ type Provider struct {
 	mu      sync.RWMutex
 	secrets map[string]*vault.Secret
 	closed  bool
+
+	// LRU state, only used when maxEntries > 0
+	maxEntries int
+	recency    *list.List               // front is most recently used
+	elements   map[string]*list.Element // path -> element holding the path
+	onEvict    func(path string, secret *vault.Secret)
 }
 
 // New creates a new in-memory provider.
@@ -30,6 +41,29 @@ func New() *Provider {
 	}
 }
 
+// NewBounded creates a new in-memory provider that holds at most maxEntries
+// secrets. When a Set would exceed the limit, the least recently used secret
+// is evicted. Both Get and Set count as a use. A maxEntries of zero or less
+// means no limit, like New.
+func NewBounded(maxEntries int) *Provider {
+	p := New()
+	if maxEntries > 0 {
+		p.maxEntries = maxEntries
+		p.recency = list.New()
+		p.elements = make(map[string]*list.Element)
+	}
+	return p
+}
+
+// OnEvict sets a function called with each secret evicted from a bounded
+// provider. It is called after the provider's lock is released, so it may
+// call back into the provider.
+func (p *Provider) OnEvict(fn func(path string, secret *vault.Secret)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onEvict = fn
+}
+
 // NewWithSecrets creates a new in-memory provider pre-populated with secrets.
 func NewWithSecrets(secrets map[string]string) *Provider {
 	p := New()
@@ -48,8 +82,14 @@ func NewWithSecrets(secrets map[string]string) *Provider {
 
 // Get retrieves a secret from memory.
 func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
+	// Bounded providers update recency on reads
+	if p.maxEntries > 0 {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+	} else {
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+	}
 
 	if p.closed {
 		return nil, vault.NewVaultError("Get", path, p.Name(), vault.ErrClosed)
@@ -59,6 +99,7 @@ func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error)
 	if !ok {
 		return nil, vault.NewVaultError("Get", path, p.Name(), vault.ErrSecretNotFound)
 	}
+	p.touch(path)
 
 	// Return a copy to prevent mutation
 	return p.copySecret(secret), nil
@@ -67,9 +108,9 @@ func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error)
 // Set stores a secret in memory.
 func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
 	p.mu.Lock()
-	defer p.mu.Unlock()
 
 	if p.closed {
+		p.mu.Unlock()
 		return vault.NewVaultError("Set", path, p.Name(), vault.ErrClosed)
 	}
 
@@ -83,6 +124,16 @@ func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) e
 	stored.Metadata.Path = path
 
 	p.secrets[path] = stored
+	p.touch(path)
+	evicted := p.evict()
+	onEvict := p.onEvict
+	p.mu.Unlock()
+
+	if onEvict != nil {
+		for _, e := range evicted {
+			onEvict(e.path, e.secret)
+		}
+	}
 	return nil
 }
 
@@ -96,6 +147,10 @@ func (p *Provider) Delete(ctx context.Context, path string) error {
 	}
 
 	delete(p.secrets, path)
+	if elem, ok := p.elements[path]; ok {
+		p.recency.Remove(elem)
+		delete(p.elements, path)
+	}
 	return nil
 }
 
@@ -130,6 +185,26 @@ func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
 	return results, nil
 }
 
+// ListInfo returns the metadata of all secrets matching the prefix. Like
+// List, it does not count as a use of the secrets.
+func (p *Provider) ListInfo(ctx context.Context, prefix string) ([]vault.SecretInfo, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return nil, vault.NewVaultError("ListInfo", prefix, p.Name(), vault.ErrClosed)
+	}
+
+	var infos []vault.SecretInfo
+	for path, secret := range p.secrets {
+		if strings.HasPrefix(path, prefix) {
+			infos = append(infos, vault.InfoOf(path, secret))
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Path < infos[j].Path })
+	return infos, nil
+}
+
 // Name returns the provider name.
 func (p *Provider) Name() string {
 	return "memory"
@@ -147,66 +222,149 @@ func (p *Provider) Capabilities() vault.Capabilities {
 	}
 }
 
-// Close marks the provider as closed.
+// Close marks the provider as closed and drops its secrets. Later
+// operations return vault.ErrClosed. Closing more than once is safe.
 func (p *Provider) Close() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
 	p.closed = true
 	p.secrets = nil
+	if p.maxEntries > 0 {
+		p.recency.Init()
+		p.elements = make(map[string]*list.Element)
+	}
 	return nil
 }
 
-// Clear removes all secrets from memory.
+// Closed returns true if the provider has been closed.
+func (p *Provider) Closed() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.closed
+}
+
+// Clear removes all secrets from memory. It does nothing once the provider
+// is closed.
 func (p *Provider) Clear() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
 	p.secrets = make(map[string]*vault.Secret)
+	if p.maxEntries > 0 {
+		p.recency.Init()
+		p.elements = make(map[string]*list.Element)
+	}
 }
 
-// Count returns the number of secrets stored.
-func (p *Provider) Count() int {
+// Snapshot returns a deep copy of the stored secrets, by path, for Restore
+// to roll back to later. Changes to the provider don't affect the snapshot.
+func (p *Provider) Snapshot() map[string]*vault.Secret {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	return len(p.secrets)
+	snap := make(map[string]*vault.Secret, len(p.secrets))
+	for path, secret := range p.secrets {
+		snap[path] = p.copySecret(secret)
+	}
+	return snap
 }
 
-// copySecret creates a deep copy of a secret.
-func (p *Provider) copySecret(secret *vault.Secret) *vault.Secret {
-	if secret == nil {
-		return nil
+// Restore replaces the stored secrets with deep copies of those in snap,
+// usually taken by Snapshot, so that later changes to either leave the
+// other alone. A bounded provider counts the restored secrets as used in
+// path order, evicting any beyond its limit. Restore does nothing once
+// the provider is closed.
+func (p *Provider) Restore(snap map[string]*vault.Secret) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
 	}
-
-	copied := &vault.Secret{
-		Value:    secret.Value,
-		Metadata: secret.Metadata,
+	p.secrets = make(map[string]*vault.Secret, len(snap))
+	for path, secret := range snap {
+		p.secrets[path] = p.copySecret(secret)
 	}
 
-	if len(secret.ValueBytes) > 0 {
-		copied.ValueBytes = make([]byte, len(secret.ValueBytes))
-		copy(copied.ValueBytes, secret.ValueBytes)
+	var removed []evicted
+	if p.maxEntries > 0 {
+		p.recency.Init()
+		p.elements = make(map[string]*list.Element)
+		paths := make([]string, 0, len(p.secrets))
+		for path := range p.secrets {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			p.touch(path)
+		}
+		removed = p.evict()
 	}
+	onEvict := p.onEvict
+	p.mu.Unlock()
 
-	if secret.Fields != nil {
-		copied.Fields = make(map[string]string, len(secret.Fields))
-		for k, v := range secret.Fields {
-			copied.Fields[k] = v
+	if onEvict != nil {
+		for _, e := range removed {
+			onEvict(e.path, e.secret)
 		}
 	}
+}
 
-	if secret.Metadata.Tags != nil {
-		copied.Metadata.Tags = make(map[string]string, len(secret.Metadata.Tags))
-		for k, v := range secret.Metadata.Tags {
-			copied.Metadata.Tags[k] = v
-		}
+// Count returns the number of secrets stored.
+func (p *Provider) Count() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.secrets)
+}
+
+// evicted is a secret removed to stay within the size limit.
+type evicted struct {
+	path   string
+	secret *vault.Secret
+}
+
+// touch marks path as the most recently used. Callers must hold p.mu
+// exclusively for bounded providers.
+func (p *Provider) touch(path string) {
+	if p.maxEntries <= 0 {
+		return
 	}
+	if elem, ok := p.elements[path]; ok {
+		p.recency.MoveToFront(elem)
+		return
+	}
+	p.elements[path] = p.recency.PushFront(path)
+}
 
-	if secret.Metadata.Labels != nil {
-		copied.Metadata.Labels = make([]string, len(secret.Metadata.Labels))
-		copy(copied.Metadata.Labels, secret.Metadata.Labels)
+// evict removes least recently used secrets until the provider is within
+// its size limit. Callers must hold p.mu.
+func (p *Provider) evict() []evicted {
+	if p.maxEntries <= 0 {
+		return nil
 	}
 
-	return copied
+	var removed []evicted
+	for p.recency.Len() > p.maxEntries {
+		elem := p.recency.Back()
+		path := elem.Value.(string)
+		p.recency.Remove(elem)
+		delete(p.elements, path)
+		removed = append(removed, evicted{path: path, secret: p.secrets[path]})
+		delete(p.secrets, path)
+	}
+	return removed
 }
 
-// Ensure Provider implements vault.Vault.
-var _ vault.Vault = (*Provider)(nil)
+// copySecret creates a deep copy of a secret.
+func (p *Provider) copySecret(secret *vault.Secret) *vault.Secret {
+	return secret.Clone()
+}
+
+// Ensure Provider implements vault.Vault and vault.MetadataLister.
+var (
+	_ vault.Vault          = (*Provider)(nil)
+	_ vault.MetadataLister = (*Provider)(nil)
+)
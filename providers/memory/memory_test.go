@@ -0,0 +1,286 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+func setValues(t *testing.T, p *Provider, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		if err := p.Set(context.Background(), path, &vault.Secret{Value: path}); err != nil {
+			t.Fatalf("Set(%q) failed: %v", path, err)
+		}
+	}
+}
+
+func listPaths(t *testing.T, p *Provider) []string {
+	t.Helper()
+	list, err := p.List(context.Background(), "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func TestBoundedEvictionOrder(t *testing.T) {
+	p := NewBounded(2)
+
+	var evictedPaths []string
+	p.OnEvict(func(path string, secret *vault.Secret) {
+		if secret.Value != path {
+			t.Errorf("Evicted secret %q has value %q", path, secret.Value)
+		}
+		evictedPaths = append(evictedPaths, path)
+	})
+
+	setValues(t, p, "a", "b", "c", "d")
+
+	if p.Count() != 2 {
+		t.Errorf("Expected 2 secrets, got %d", p.Count())
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(evictedPaths, want) {
+		t.Errorf("Evicted %v, want %v", evictedPaths, want)
+	}
+	if want := []string{"c", "d"}; !reflect.DeepEqual(listPaths(t, p), want) {
+		t.Errorf("Remaining %v, want %v", listPaths(t, p), want)
+	}
+
+	// Overwriting an existing path refreshes it without evicting
+	setValues(t, p, "c", "e")
+	if want := []string{"c", "e"}; !reflect.DeepEqual(listPaths(t, p), want) {
+		t.Errorf("Remaining %v, want %v", listPaths(t, p), want)
+	}
+}
+
+func TestBoundedGetRefreshesRecency(t *testing.T) {
+	p := NewBounded(2)
+	ctx := context.Background()
+
+	setValues(t, p, "a", "b")
+	if _, err := p.Get(ctx, "a"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	setValues(t, p, "c")
+
+	if _, err := p.Get(ctx, "b"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected least recently used 'b' to be evicted, got %v", err)
+	}
+	if _, err := p.Get(ctx, "a"); err != nil {
+		t.Errorf("Expected recently read 'a' to be kept, got %v", err)
+	}
+}
+
+func TestBoundedDelete(t *testing.T) {
+	p := NewBounded(2)
+
+	setValues(t, p, "a", "b")
+	if err := p.Delete(context.Background(), "a"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	// The freed slot is reused without evicting 'b'
+	setValues(t, p, "c")
+	if want := []string{"b", "c"}; !reflect.DeepEqual(listPaths(t, p), want) {
+		t.Errorf("Remaining %v, want %v", listPaths(t, p), want)
+	}
+}
+
+func TestUnboundedKeepsEverything(t *testing.T) {
+	p := New()
+	setValues(t, p, "a", "b", "c")
+	if p.Count() != 3 {
+		t.Errorf("Expected 3 secrets, got %d", p.Count())
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	p := NewBounded(2)
+	setValues(t, p, "a")
+	ctx := context.Background()
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("Second Close failed: %v", err)
+	}
+	if !p.Closed() {
+		t.Error("Expected Closed() to be true")
+	}
+
+	p.Clear()
+	if _, err := p.Get(ctx, "a"); !errors.Is(err, vault.ErrClosed) {
+		t.Errorf("Get: expected ErrClosed, got %v", err)
+	}
+	if err := p.Set(ctx, "a", &vault.Secret{Value: "x"}); !errors.Is(err, vault.ErrClosed) {
+		t.Errorf("Set: expected ErrClosed, got %v", err)
+	}
+	if _, err := p.List(ctx, ""); !errors.Is(err, vault.ErrClosed) {
+		t.Errorf("List: expected ErrClosed, got %v", err)
+	}
+}
+
+func TestListInfoMatchesGet(t *testing.T) {
+	p := New()
+	ctx := context.Background()
+	secrets := map[string]*vault.Secret{
+		"db/prod":  {Value: "dsn", Fields: map[string]string{"user": "admin"}, Metadata: vault.Metadata{Tags: map[string]string{"env": "prod"}}},
+		"db/dev":   {Fields: map[string]string{"user": "dev"}},
+		"api/key":  {ValueBytes: []byte{1, 2}},
+		"api/none": {},
+	}
+	for path, secret := range secrets {
+		if err := p.Set(ctx, path, secret); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	for _, prefix := range []string{"", "db/"} {
+		infos, err := p.ListInfo(ctx, prefix)
+		if err != nil {
+			t.Fatalf("ListInfo failed: %v", err)
+		}
+
+		paths, err := p.List(ctx, prefix)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		sort.Strings(paths)
+		var want []vault.SecretInfo
+		for _, path := range paths {
+			secret, err := p.Get(ctx, path)
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			want = append(want, vault.InfoOf(path, secret))
+		}
+
+		if !reflect.DeepEqual(infos, want) {
+			t.Errorf("ListInfo(%q) = %+v, want %+v", prefix, infos, want)
+		}
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	p := New()
+	if err := p.Set(ctx, "db", &vault.Secret{
+		Value:      "hunter2",
+		ValueBytes: []byte{1, 2},
+		Fields:     map[string]string{"user": "admin"},
+		Metadata: vault.Metadata{
+			Tags:            map[string]string{"env": "prod"},
+			Labels:          []string{"a"},
+			ProtectedFields: []string{"user"},
+			Extra:           map[string]any{"owner": "ops"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	setValues(t, p, "api-key")
+
+	want := map[string]*vault.Secret{}
+	for _, path := range []string{"db", "api-key"} {
+		secret, err := p.Get(ctx, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[path] = secret
+	}
+
+	snap := p.Snapshot()
+	if !reflect.DeepEqual(snap, want) {
+		t.Fatalf("Snapshot = %v, want %v", snap, want)
+	}
+
+	// Neither changes through the provider nor to what Get returns reach
+	// the snapshot
+	if err := p.Set(ctx, "db", &vault.Secret{Value: "changed"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Delete(ctx, "api-key"); err != nil {
+		t.Fatal(err)
+	}
+	setValues(t, p, "new")
+	if !reflect.DeepEqual(snap, want) {
+		t.Fatalf("Expected the snapshot to be unchanged, got %v", snap)
+	}
+
+	p.Restore(snap)
+	for path, secret := range want {
+		got, err := p.Get(ctx, path)
+		if err != nil {
+			t.Fatalf("Get(%s) failed: %v", path, err)
+		}
+		if !reflect.DeepEqual(got, secret) {
+			t.Errorf("Get(%s) = %+v, want %+v", path, got, secret)
+		}
+	}
+	if exists, _ := p.Exists(ctx, "new"); exists || p.Count() != 2 {
+		t.Errorf("Expected exactly the snapshot's secrets, got %d", p.Count())
+	}
+
+	// Mutating the snapshot after a restore leaves the provider alone
+	snap["db"].Fields["user"] = "root"
+	snap["db"].ValueBytes[0] = 9
+	snap["db"].Metadata.Extra["owner"] = "eve"
+	snap["db"].Metadata.ProtectedFields[0] = "password"
+	got, _ := p.Get(ctx, "db")
+	if got.Fields["user"] != "admin" || got.ValueBytes[0] != 1 {
+		t.Errorf("Expected the restored secret not to alias the snapshot, got %+v", got)
+	}
+	if got.Metadata.Extra["owner"] != "ops" || got.Metadata.ProtectedFields[0] != "user" {
+		t.Errorf("Expected the restored metadata not to alias the snapshot, got %+v", got.Metadata)
+	}
+	snap["db"].Fields["user"] = "admin"
+	snap["db"].ValueBytes[0] = 1
+	snap["db"].Metadata.Extra["owner"] = "ops"
+	snap["db"].Metadata.ProtectedFields[0] = "user"
+
+	// Nor does mutating a fresh snapshot
+	again := p.Snapshot()
+	again["db"].Metadata.Extra["owner"] = "eve"
+	if got, _ := p.Get(ctx, "db"); got.Metadata.Extra["owner"] != "ops" {
+		t.Errorf("Expected the snapshot's Extra not to alias the provider, got %v", got.Metadata.Extra)
+	}
+
+	// A snapshot can be restored more than once
+	if err := p.Set(ctx, "db", &vault.Secret{Value: "changed again"}); err != nil {
+		t.Fatal(err)
+	}
+	p.Restore(snap)
+	if got, _ := p.Get(ctx, "db"); !reflect.DeepEqual(got, want["db"]) {
+		t.Errorf("Expected a second restore to return the prior state, got %+v", got)
+	}
+}
+
+func TestBoundedRestore(t *testing.T) {
+	p := NewBounded(2)
+	var evicted []string
+	p.OnEvict(func(path string, _ *vault.Secret) { evicted = append(evicted, path) })
+	setValues(t, p, "a", "b")
+	snap := p.Snapshot()
+	setValues(t, p, "c")
+
+	p.Restore(snap)
+	setValues(t, p, "d")
+
+	got, err := p.List(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	if want := []string{"b", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List = %v, want %v", got, want)
+	}
+	if want := []string{"a", "a"}; !reflect.DeepEqual(evicted, want) {
+		t.Errorf("Evicted = %v, want %v", evicted, want)
+	}
+}
@@ -0,0 +1,277 @@
+// Package resilient wraps a vault with retries and a circuit breaker, for
+// providers that talk to remote services.
+//
+// Usage:
+//
+//	v := resilient.New(remote, resilient.Policy{
+//	    Retries:          3,
+//	    Backoff:          100 * time.Millisecond,
+//	    BreakerThreshold: 5,
+//	})
+//	secret, err := v.Get(ctx, "api-key")
+//
+// Reads (Get, Exists, List) are retried on transient errors with
+// exponential backoff and jitter. Writes are never retried, since they may
+// have been applied. After BreakerThreshold consecutive transient failures
+// the circuit opens and every call fails fast with ErrCircuitOpen until
+// the cooldown has passed; the next call is then let through as a trial.
+package resilient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// ErrCircuitOpen is returned while the circuit breaker is open. It wraps
+// vault.ErrConnectionFailed.
+var ErrCircuitOpen = fmt.Errorf("%w: circuit breaker open", vault.ErrConnectionFailed)
+
+// Default policy values.
+const (
+	DefaultBackoff    = 100 * time.Millisecond
+	DefaultMaxBackoff = 5 * time.Second
+	DefaultCooldown   = 30 * time.Second
+)
+
+// Policy configures retries and circuit breaking.
+type Policy struct {
+	// Retries is how many times a failed read is retried. Zero disables retries.
+	Retries int
+
+	// Backoff is the delay before the first retry, doubled for each
+	// following retry (default: 100ms). The actual delay is randomized
+	// between half and all of it.
+	Backoff time.Duration
+
+	// MaxBackoff caps the delay between retries (default: 5s).
+	MaxBackoff time.Duration
+
+	// BreakerThreshold is the number of consecutive failed calls that opens
+	// the circuit. Zero disables the circuit breaker.
+	BreakerThreshold int
+
+	// Cooldown is how long the circuit stays open (default: 30s).
+	Cooldown time.Duration
+
+	// Retryable reports whether an error is transient. The default treats
+	// every error as transient except the standard vault errors that
+	// describe the request itself, such as not found or access denied,
+	// and context cancellation.
+	Retryable func(error) bool
+}
+
+// Provider wraps a vault.Vault with retries and a circuit breaker.
+type Provider struct {
+	inner  vault.Vault
+	policy Policy
+	now    func() time.Time
+
+	mu       sync.Mutex
+	failures int       // consecutive failed calls
+	openedAt time.Time // zero while the circuit is closed
+}
+
+// New wraps inner with the given policy.
+func New(inner vault.Vault, policy Policy) *Provider {
+	if policy.Backoff <= 0 {
+		policy.Backoff = DefaultBackoff
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = DefaultMaxBackoff
+	}
+	if policy.Cooldown <= 0 {
+		policy.Cooldown = DefaultCooldown
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = IsRetryable
+	}
+	return &Provider{inner: inner, policy: policy, now: time.Now}
+}
+
+// Middleware returns a vault.Middleware that wraps a vault with New, for
+// use with vault.Chain.
+func Middleware(policy Policy) vault.Middleware {
+	return func(inner vault.Vault) vault.Vault {
+		return New(inner, policy)
+	}
+}
+
+// permanentErrors are errors about the request itself, which a retry
+// cannot fix.
+var permanentErrors = []error{
+	vault.ErrSecretNotFound,
+	vault.ErrAccessDenied,
+	vault.ErrAuthenticationFailed,
+	vault.ErrInvalidPath,
+	vault.ErrReadOnly,
+	vault.ErrNotSupported,
+	vault.ErrVersionNotFound,
+	vault.ErrAlreadyExists,
+	vault.ErrClosed,
+	context.Canceled,
+	context.DeadlineExceeded,
+}
+
+// IsRetryable is the default Policy.Retryable.
+func IsRetryable(err error) bool {
+	for _, permanent := range permanentErrors {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+	return true
+}
+
+// Get retrieves a secret, retrying transient failures.
+func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	var secret *vault.Secret
+	err := p.read(ctx, "Get", path, func() error {
+		var err error
+		secret, err = p.inner.Get(ctx, path)
+		return err
+	})
+	return secret, err
+}
+
+// Set stores a secret. It is not retried.
+func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	return p.call("Set", path, func() error {
+		return p.inner.Set(ctx, path, secret)
+	})
+}
+
+// Delete removes a secret. It is not retried.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	return p.call("Delete", path, func() error {
+		return p.inner.Delete(ctx, path)
+	})
+}
+
+// Exists checks if a secret exists, retrying transient failures.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	var exists bool
+	err := p.read(ctx, "Exists", path, func() error {
+		var err error
+		exists, err = p.inner.Exists(ctx, path)
+		return err
+	})
+	return exists, err
+}
+
+// List returns secret paths matching the prefix, retrying transient failures.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	var paths []string
+	err := p.read(ctx, "List", prefix, func() error {
+		var err error
+		paths, err = p.inner.List(ctx, prefix)
+		return err
+	})
+	return paths, err
+}
+
+// Watch reports changes to secrets under prefix if the wrapped vault
+// supports watching, and returns vault.ErrNotSupported otherwise. Starting
+// the watch goes through the circuit breaker but is not retried.
+func (p *Provider) Watch(ctx context.Context, prefix string) (<-chan vault.WatchEvent, error) {
+	w, ok := vault.AsWatchable(p.inner)
+	if !ok {
+		return nil, vault.NewVaultError("Watch", prefix, p.Name(), vault.ErrNotSupported)
+	}
+
+	var events <-chan vault.WatchEvent
+	err := p.call("Watch", prefix, func() error {
+		var err error
+		events, err = w.Watch(ctx, prefix)
+		return err
+	})
+	return events, err
+}
+
+// Name returns the name of the wrapped provider.
+func (p *Provider) Name() string {
+	return p.inner.Name()
+}
+
+// Capabilities returns the capabilities of the wrapped provider.
+func (p *Provider) Capabilities() vault.Capabilities {
+	return p.inner.Capabilities()
+}
+
+// Close closes the wrapped provider.
+func (p *Provider) Close() error {
+	return p.inner.Close()
+}
+
+// Open returns true while the circuit breaker is open.
+func (p *Provider) Open() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.isOpen()
+}
+
+// read runs an idempotent call, retrying transient failures with backoff.
+func (p *Provider) read(ctx context.Context, op, path string, fn func() error) error {
+	backoff := p.policy.Backoff
+	for attempt := 0; ; attempt++ {
+		err := p.call(op, path, fn)
+		if err == nil || attempt >= p.policy.Retries ||
+			errors.Is(err, ErrCircuitOpen) || !p.policy.Retryable(err) {
+			return err
+		}
+
+		// Randomize between half and all of the backoff
+		delay := backoff/2 + rand.N(backoff/2+1)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+
+		backoff = min(backoff*2, p.policy.MaxBackoff)
+	}
+}
+
+// call runs fn through the circuit breaker.
+func (p *Provider) call(op, path string, fn func() error) error {
+	p.mu.Lock()
+	if p.isOpen() {
+		p.mu.Unlock()
+		return vault.NewVaultError(op, path, p.Name(), ErrCircuitOpen)
+	}
+	p.mu.Unlock()
+
+	err := fn()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil && p.policy.Retryable(err) {
+		p.failures++
+		if p.policy.BreakerThreshold > 0 && p.failures >= p.policy.BreakerThreshold {
+			p.openedAt = p.now()
+		}
+	} else {
+		// The service answered, even if with an error about the request
+		p.failures = 0
+		p.openedAt = time.Time{}
+	}
+	return err
+}
+
+// isOpen reports whether calls are currently rejected. Once the cooldown
+// has passed the circuit is half-open: calls go through, and the next
+// failure opens it again. Callers must hold p.mu.
+func (p *Provider) isOpen() bool {
+	return !p.openedAt.IsZero() && p.now().Sub(p.openedAt) < p.policy.Cooldown
+}
+
+// Ensure Provider implements vault.Vault and vault.WatchableVault.
+var (
+	_ vault.Vault          = (*Provider)(nil)
+	_ vault.WatchableVault = (*Provider)(nil)
+)
@@ -0,0 +1,153 @@
+package resilient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omnivault/providers/memory"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// flaky is a vault whose calls fail until failures reaches zero.
+type flaky struct {
+	vault.Vault
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flaky) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	f.calls++
+	if f.failures > 0 {
+		f.failures--
+		return nil, f.err
+	}
+	return f.Vault.Get(ctx, path)
+}
+
+func (f *flaky) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	f.calls++
+	if f.failures > 0 {
+		f.failures--
+		return f.err
+	}
+	return f.Vault.Set(ctx, path, secret)
+}
+
+func newFlaky(failures int, err error) *flaky {
+	return &flaky{
+		Vault:    memory.NewWithSecrets(map[string]string{"key": "value"}),
+		failures: failures,
+		err:      err,
+	}
+}
+
+func TestRetrySucceeds(t *testing.T) {
+	inner := newFlaky(2, vault.ErrConnectionFailed)
+	p := New(inner, Policy{Retries: 3, Backoff: time.Millisecond})
+
+	secret, err := p.Get(context.Background(), "key")
+	if err != nil {
+		t.Fatalf("Expected Get to succeed after retries, got %v", err)
+	}
+	if secret.Value != "value" {
+		t.Errorf("Expected 'value', got %q", secret.Value)
+	}
+	if inner.calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", inner.calls)
+	}
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	inner := newFlaky(5, vault.ErrConnectionFailed)
+	p := New(inner, Policy{Retries: 2, Backoff: time.Millisecond})
+
+	if _, err := p.Get(context.Background(), "key"); !errors.Is(err, vault.ErrConnectionFailed) {
+		t.Errorf("Expected ErrConnectionFailed, got %v", err)
+	}
+	if inner.calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", inner.calls)
+	}
+}
+
+func TestPermanentErrorNotRetried(t *testing.T) {
+	inner := newFlaky(1, vault.ErrAccessDenied)
+	p := New(inner, Policy{Retries: 3, Backoff: time.Millisecond})
+
+	if _, err := p.Get(context.Background(), "key"); !errors.Is(err, vault.ErrAccessDenied) {
+		t.Errorf("Expected ErrAccessDenied, got %v", err)
+	}
+	if inner.calls != 1 {
+		t.Errorf("Expected 1 call, got %d", inner.calls)
+	}
+}
+
+func TestWritesNotRetried(t *testing.T) {
+	inner := newFlaky(1, vault.ErrConnectionFailed)
+	p := New(inner, Policy{Retries: 3, Backoff: time.Millisecond})
+
+	if err := p.Set(context.Background(), "key", &vault.Secret{Value: "x"}); !errors.Is(err, vault.ErrConnectionFailed) {
+		t.Errorf("Expected ErrConnectionFailed, got %v", err)
+	}
+	if inner.calls != 1 {
+		t.Errorf("Expected 1 call, got %d", inner.calls)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	inner := newFlaky(3, vault.ErrConnectionFailed)
+	p := New(inner, Policy{BreakerThreshold: 3, Cooldown: time.Minute})
+
+	now := time.Now()
+	p.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := p.Get(ctx, "key"); !errors.Is(err, vault.ErrConnectionFailed) {
+			t.Fatalf("Call %d: expected ErrConnectionFailed, got %v", i, err)
+		}
+	}
+	if !p.Open() {
+		t.Fatal("Expected circuit to be open")
+	}
+
+	// While open, calls fail fast without reaching the inner vault
+	if _, err := p.Get(ctx, "key"); !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, vault.ErrConnectionFailed) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if inner.calls != 3 {
+		t.Errorf("Expected open circuit to skip the inner vault, got %d calls", inner.calls)
+	}
+
+	// After the cooldown a trial call goes through and closes the circuit
+	now = now.Add(time.Minute)
+	if _, err := p.Get(ctx, "key"); err != nil {
+		t.Fatalf("Expected trial call to succeed, got %v", err)
+	}
+	if p.Open() {
+		t.Error("Expected circuit to be closed after a successful call")
+	}
+}
+
+func TestCircuitReopensOnTrialFailure(t *testing.T) {
+	inner := newFlaky(10, vault.ErrConnectionFailed)
+	p := New(inner, Policy{BreakerThreshold: 2, Cooldown: time.Minute})
+
+	now := time.Now()
+	p.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, _ = p.Get(ctx, "key")
+	}
+	now = now.Add(time.Minute)
+
+	if _, err := p.Get(ctx, "key"); errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected trial call to reach the inner vault, got %v", err)
+	}
+	if !p.Open() {
+		t.Error("Expected failed trial call to reopen the circuit")
+	}
+}
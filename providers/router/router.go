@@ -0,0 +1,217 @@
+// Package router federates several vaults behind one, routing each secret
+// path to the vault registered for its longest matching prefix.
+//
+// Usage:
+//
+//	v := router.New(map[string]vault.Vault{
+//	    "team-a/":         teamA,
+//	    "team-b/":         teamB,
+//	    "team-b/archive/": archive,
+//	}, shared)
+//	secret, err := v.Get(ctx, "team-b/db-password") // read from teamB
+//
+// Paths are passed to the routed vault unchanged; wrap a route in
+// vault.Sub to strip its prefix. Paths that match no route go to the
+// fallback vault, or fail with ErrNoRoute if there is none. List merges
+// the results of every vault whose paths may match the prefix, keeping
+// only the paths each vault is routed for.
+package router
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// ErrNoRoute is returned for paths that match no route when there is no
+// fallback vault. It wraps vault.ErrInvalidPath.
+var ErrNoRoute = fmt.Errorf("%w: no route for path", vault.ErrInvalidPath)
+
+// route is a vault registered for a path prefix.
+type route struct {
+	prefix string
+	vault  vault.Vault
+}
+
+// Provider dispatches operations to vaults by path prefix.
+type Provider struct {
+	routes   []route // longest prefix first
+	fallback vault.Vault
+}
+
+// New creates a router from vaults keyed by path prefix. Routes with a nil
+// vault are ignored, and fallback may be nil.
+func New(routes map[string]vault.Vault, fallback vault.Vault) *Provider {
+	p := &Provider{fallback: fallback}
+	for prefix, v := range routes {
+		if v != nil {
+			p.routes = append(p.routes, route{prefix: prefix, vault: v})
+		}
+	}
+	sort.Slice(p.routes, func(i, j int) bool {
+		a, b := p.routes[i].prefix, p.routes[j].prefix
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return a < b
+	})
+	return p
+}
+
+// Route returns the vault that handles path and the prefix it was
+// registered for, which is empty for the fallback. It returns nil if no
+// route matches and there is no fallback.
+func (p *Provider) Route(path string) (vault.Vault, string) {
+	if i := p.routeIndex(path); i >= 0 {
+		return p.routes[i].vault, p.routes[i].prefix
+	}
+	return p.fallback, ""
+}
+
+// routeIndex returns the index of the longest route matching path, or -1.
+func (p *Provider) routeIndex(path string) int {
+	for i, r := range p.routes {
+		if strings.HasPrefix(path, r.prefix) {
+			return i
+		}
+	}
+	return -1
+}
+
+// vaultFor returns the vault for path, or an ErrNoRoute error.
+func (p *Provider) vaultFor(op, path string) (vault.Vault, error) {
+	v, _ := p.Route(path)
+	if v == nil {
+		return nil, vault.NewVaultError(op, path, p.Name(), ErrNoRoute)
+	}
+	return v, nil
+}
+
+// Get retrieves a secret from the vault routed for path.
+func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	v, err := p.vaultFor("Get", path)
+	if err != nil {
+		return nil, err
+	}
+	return v.Get(ctx, path)
+}
+
+// Set stores a secret in the vault routed for path.
+func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	v, err := p.vaultFor("Set", path)
+	if err != nil {
+		return err
+	}
+	return v.Set(ctx, path, secret)
+}
+
+// Delete removes a secret from the vault routed for path.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	v, err := p.vaultFor("Delete", path)
+	if err != nil {
+		return err
+	}
+	return v.Delete(ctx, path)
+}
+
+// Exists checks if a secret exists in the vault routed for path.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	v, err := p.vaultFor("Exists", path)
+	if err != nil {
+		return false, err
+	}
+	return v.Exists(ctx, path)
+}
+
+// List returns the paths matching prefix across every vault whose routed
+// paths may match it, sorted. Each vault only contributes the paths that
+// are routed to it, so a secret shadowed by a longer route is not listed.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	// A query inside a route can't reach the fallback
+	owner := p.routeIndex(prefix)
+
+	var results []string
+	collect := func(v vault.Vault, index int) error {
+		paths, err := v.List(ctx, prefix)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			if strings.HasPrefix(path, prefix) && p.routeIndex(path) == index {
+				results = append(results, path)
+			}
+		}
+		return nil
+	}
+
+	for i, r := range p.routes {
+		if i != owner && !strings.HasPrefix(r.prefix, prefix) {
+			continue
+		}
+		if err := collect(r.vault, i); err != nil {
+			return nil, err
+		}
+	}
+	if owner < 0 && p.fallback != nil {
+		if err := collect(p.fallback, -1); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(results)
+	return results, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "router"
+}
+
+// Capabilities returns the capabilities shared by every routed vault.
+func (p *Provider) Capabilities() vault.Capabilities {
+	caps := vault.Capabilities{Read: true, Write: true, Delete: true, List: true, Binary: true, MultiField: true}
+	for _, v := range p.vaults() {
+		c := v.Capabilities()
+		caps.Read = caps.Read && c.Read
+		caps.Write = caps.Write && c.Write
+		caps.Delete = caps.Delete && c.Delete
+		caps.List = caps.List && c.List
+		caps.Binary = caps.Binary && c.Binary
+		caps.MultiField = caps.MultiField && c.MultiField
+	}
+	return caps
+}
+
+// Close closes every routed vault and the fallback, once each.
+func (p *Provider) Close() error {
+	var lastErr error
+	for _, v := range p.vaults() {
+		if err := v.Close(); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// vaults returns the distinct routed vaults and the fallback.
+func (p *Provider) vaults() []vault.Vault {
+	var vaults []vault.Vault
+	seen := make(map[vault.Vault]bool)
+	add := func(v vault.Vault) {
+		if v != nil && !seen[v] {
+			seen[v] = true
+			vaults = append(vaults, v)
+		}
+	}
+	for _, r := range p.routes {
+		add(r.vault)
+	}
+	add(p.fallback)
+	return vaults
+}
+
+// Ensure Provider implements vault.Vault.
+var _ vault.Vault = (*Provider)(nil)
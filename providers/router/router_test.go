@@ -0,0 +1,107 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/agentplexus/omnivault/providers/memory"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+func TestLongestPrefixDispatch(t *testing.T) {
+	teamA := memory.New()
+	teamB := memory.New()
+	archive := memory.New()
+	shared := memory.New()
+	p := New(map[string]vault.Vault{
+		"team-a/":         teamA,
+		"team-b/":         teamB,
+		"team-b/archive/": archive,
+	}, shared)
+	ctx := context.Background()
+
+	writes := map[string]*memory.Provider{
+		"team-a/db":           teamA,
+		"team-b/api-key":      teamB,
+		"team-b/archive/old":  archive,
+		"team-bx/not-a-route": shared,
+		"global/token":        shared,
+	}
+	for path, want := range writes {
+		if err := p.Set(ctx, path, &vault.Secret{Value: path}); err != nil {
+			t.Fatalf("Set(%s) failed: %v", path, err)
+		}
+		if exists, _ := want.Exists(ctx, path); !exists {
+			t.Errorf("Expected %s to be stored in its routed vault", path)
+		}
+		if got, err := p.Get(ctx, path); err != nil || got.Value != path {
+			t.Errorf("Get(%s) = %v, %v", path, got, err)
+		}
+	}
+
+	if exists, _ := teamB.Exists(ctx, "team-b/archive/old"); exists {
+		t.Error("Expected the longer route to win over team-b/")
+	}
+	if v, prefix := p.Route("team-b/archive/old"); v != archive || prefix != "team-b/archive/" {
+		t.Errorf("Route = %v, %q; want the archive route", v, prefix)
+	}
+
+	if err := p.Delete(ctx, "team-a/db"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if exists, _ := teamA.Exists(ctx, "team-a/db"); exists {
+		t.Error("Expected Delete to reach the routed vault")
+	}
+}
+
+func TestNoRoute(t *testing.T) {
+	p := New(map[string]vault.Vault{"team-a/": memory.New()}, nil)
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "other/key", &vault.Secret{Value: "x"}); !errors.Is(err, ErrNoRoute) {
+		t.Errorf("Expected ErrNoRoute, got %v", err)
+	}
+	if _, err := p.Get(ctx, "other/key"); !errors.Is(err, vault.ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath, got %v", err)
+	}
+	if paths, err := p.List(ctx, ""); err != nil || len(paths) != 0 {
+		t.Errorf("Expected an empty list, got %v, %v", paths, err)
+	}
+}
+
+func TestMergedList(t *testing.T) {
+	teamA := memory.NewWithSecrets(map[string]string{"team-a/db": "1", "team-a/api": "2"})
+	// A stale copy under the archive route is shadowed and must not be listed
+	teamB := memory.NewWithSecrets(map[string]string{"team-b/key": "3", "team-b/archive/stale": "4"})
+	archive := memory.NewWithSecrets(map[string]string{"team-b/archive/old": "5"})
+	shared := memory.NewWithSecrets(map[string]string{"global/token": "6", "team-a/ignored": "7"})
+	p := New(map[string]vault.Vault{
+		"team-a/":         teamA,
+		"team-b/":         teamB,
+		"team-b/archive/": archive,
+	}, shared)
+	ctx := context.Background()
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"", []string{"global/token", "team-a/api", "team-a/db", "team-b/archive/old", "team-b/key"}},
+		{"team-", []string{"team-a/api", "team-a/db", "team-b/archive/old", "team-b/key"}},
+		{"team-b/", []string{"team-b/archive/old", "team-b/key"}},
+		{"team-b/archive/", []string{"team-b/archive/old"}},
+		{"team-a/d", []string{"team-a/db"}},
+		{"global/", []string{"global/token"}},
+	}
+	for _, tt := range tests {
+		got, err := p.List(ctx, tt.prefix)
+		if err != nil {
+			t.Fatalf("List(%q) failed: %v", tt.prefix, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("List(%q) = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+}
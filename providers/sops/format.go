@@ -0,0 +1,45 @@
+package sops
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/config"
+	"github.com/getsops/sops/v3/stores/dotenv"
+	"github.com/getsops/sops/v3/stores/json"
+	"github.com/getsops/sops/v3/stores/yaml"
+)
+
+// Supported file formats.
+const (
+	FormatYAML   = "yaml"
+	FormatJSON   = "json"
+	FormatDotenv = "dotenv"
+)
+
+// formatOf returns the format of a file from its extension.
+func formatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".env":
+		return FormatDotenv
+	}
+	return FormatYAML
+}
+
+// storeFor returns the sops store that reads a format.
+func storeFor(format string) (sops.Store, error) {
+	c := config.NewStoresConfig()
+	switch format {
+	case FormatYAML:
+		return yaml.NewStore(&c.YAML), nil
+	case FormatJSON:
+		return json.NewStore(&c.JSON), nil
+	case FormatDotenv:
+		return dotenv.NewStore(&c.Dotenv), nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
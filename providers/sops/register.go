@@ -0,0 +1,53 @@
+package sops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/agentplexus/omnivault"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// The sops library pulls in the SDKs of every cloud KMS it supports, so
+// the provider is not built into omnivault. Importing this package for its
+// side effects makes it available as omnivault.ProviderSOPS:
+//
+//	import _ "github.com/agentplexus/omnivault/providers/sops"
+func init() {
+	omnivault.RegisterProvider(omnivault.ProviderSOPS, newFromConfig)
+}
+
+// newFromConfig creates a provider from a client configuration, whose
+// ProviderConfig is a Config, a *Config, or the settings of a config file.
+func newFromConfig(config omnivault.Config) (vault.Vault, error) {
+	var sopsConfig Config
+
+	switch pc := config.ProviderConfig.(type) {
+	case Config:
+		sopsConfig = pc
+	case *Config:
+		if pc == nil {
+			return nil, fmt.Errorf("sops provider requires sops.Config in ProviderConfig")
+		}
+		sopsConfig = *pc
+	case map[string]any:
+		data, err := json.Marshal(pc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sops config: %w", err)
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&sopsConfig); err != nil {
+			return nil, fmt.Errorf("invalid sops config: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("sops provider requires sops.Config in ProviderConfig")
+	}
+
+	p, err := New(sopsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
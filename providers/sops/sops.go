@@ -0,0 +1,350 @@
+// Package sops provides a read-only vault implementation that serves the
+// values of a SOPS-encrypted file (https://getsops.io) as secrets.
+//
+// Usage:
+//
+//	v, err := sops.New(sops.Config{
+//	    Path:    "secrets.enc.yaml",
+//	    AgeKeys: []string{os.Getenv("SOPS_AGE_KEY")},
+//	})
+//	secret, err := v.Get(ctx, "db.password")
+//
+// Paths are the keys leading to a value joined with ".", with list
+// elements addressed by index ("hosts.0"). YAML, JSON, and dotenv files
+// are supported. Files are decrypted with the sops library, so any master
+// key sops supports can be used, and the file's MAC is verified before
+// any value is returned.
+package sops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/aes"
+	"github.com/getsops/sops/v3/age"
+	"github.com/getsops/sops/v3/keyservice"
+	"google.golang.org/grpc"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// Config holds configuration for the SOPS provider.
+type Config struct {
+	// Path is the SOPS-encrypted file.
+	Path string
+
+	// Format is FormatYAML, FormatJSON, or FormatDotenv. By default it is
+	// taken from the extension: ".json" is JSON, ".env" is dotenv, and
+	// anything else is YAML.
+	Format string
+
+	// AgeKeys are age identities ("AGE-SECRET-KEY-1...").
+	AgeKeys []string
+
+	// AgeKeyFile is a file of age identities, one per line, such as the
+	// keys.txt written by age-keygen.
+	AgeKeyFile string
+
+	// KeyServices are additional sops key services, such as a remote
+	// "sops keyservice" reached with keyservice.NewKeyServiceClient. They
+	// are tried after the local one.
+	KeyServices []keyservice.KeyServiceClient `json:"-"`
+}
+
+// Provider implements vault.Vault for a SOPS-encrypted file.
+type Provider struct {
+	config      Config
+	keyServices []keyservice.KeyServiceClient
+
+	mu      sync.Mutex
+	doc     *document
+	modTime time.Time
+	size    int64
+}
+
+// document is a decrypted file.
+type document struct {
+	values     map[string]string
+	paths      []string
+	modifiedAt *vault.Timestamp
+}
+
+// New creates a new SOPS provider. Without AgeKeys or AgeKeyFile, age
+// identities are read from the SOPS_AGE_KEY, SOPS_AGE_KEY_FILE, and
+// SOPS_AGE_KEY_CMD environment variables or sops/age/keys.txt in the
+// user's config directory, as the sops command does. Other master keys,
+// such as PGP or cloud KMS keys, use their usual credentials.
+func New(config Config) (*Provider, error) {
+	if config.Path == "" {
+		return nil, errors.New("path is required")
+	}
+	if config.Format == "" {
+		config.Format = formatOf(config.Path)
+	}
+	if _, err := storeFor(config.Format); err != nil {
+		return nil, err
+	}
+
+	keys := config.AgeKeys
+	if config.AgeKeyFile != "" {
+		data, err := os.ReadFile(config.AgeKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read age key file: %w", err)
+		}
+		keys = append(keys[:len(keys):len(keys)], string(data))
+	}
+
+	local := &localKeyService{local: keyservice.NewLocalClient()}
+	if len(keys) > 0 {
+		if err := local.identities.Import(keys...); err != nil {
+			return nil, err
+		}
+		if len(local.identities) == 0 {
+			return nil, errors.New("no age identities found")
+		}
+	}
+
+	keyServices := append([]keyservice.KeyServiceClient{local}, config.KeyServices...)
+	return &Provider{config: config, keyServices: keyServices}, nil
+}
+
+// localKeyService decrypts data keys in process, using the configured age
+// identities for age master keys when there are any.
+type localKeyService struct {
+	identities age.ParsedIdentities
+	local      keyservice.LocalClient
+}
+
+// Encrypt implements keyservice.KeyServiceClient.
+func (s *localKeyService) Encrypt(ctx context.Context, req *keyservice.EncryptRequest, opts ...grpc.CallOption) (*keyservice.EncryptResponse, error) {
+	return s.local.Encrypt(ctx, req, opts...)
+}
+
+// Decrypt implements keyservice.KeyServiceClient.
+func (s *localKeyService) Decrypt(ctx context.Context, req *keyservice.DecryptRequest, opts ...grpc.CallOption) (*keyservice.DecryptResponse, error) {
+	ageKey := req.GetKey().GetAgeKey()
+	if ageKey == nil || len(s.identities) == 0 {
+		return s.local.Decrypt(ctx, req, opts...)
+	}
+
+	key := &age.MasterKey{Recipient: ageKey.Recipient, EncryptedKey: string(req.Ciphertext)}
+	s.identities.ApplyToMasterKey(key)
+	plaintext, err := key.Decrypt()
+	if err != nil {
+		return nil, err
+	}
+	return &keyservice.DecryptResponse{Plaintext: plaintext}, nil
+}
+
+// load returns the decrypted file, decrypting it again if it changed.
+func (p *Provider) load(ctx context.Context) (*document, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, err := os.Stat(p.config.Path)
+	if err != nil {
+		return nil, err
+	}
+	if p.doc != nil && info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return p.doc, nil
+	}
+
+	data, err := os.ReadFile(p.config.Path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := p.decrypt(data)
+	if err != nil {
+		return nil, err
+	}
+
+	p.doc, p.modTime, p.size = doc, info.ModTime(), info.Size()
+	return doc, nil
+}
+
+// decrypt decrypts every value of a file and verifies its MAC, as the
+// sops decrypt package does.
+func (p *Provider) decrypt(data []byte) (*document, error) {
+	store, err := storeFor(p.config.Format)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := store.LoadEncryptedFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse file: %w", err)
+	}
+
+	dataKey, err := tree.Metadata.GetDataKeyWithKeyServices(p.keyServices, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", vault.ErrAccessDenied, err)
+	}
+
+	cipher := aes.NewCipher()
+	mac, err := tree.Decrypt(dataKey, cipher)
+	if err != nil {
+		return nil, err
+	}
+	want, err := cipher.Decrypt(tree.Metadata.MessageAuthenticationCode, dataKey, tree.Metadata.LastModified.Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt MAC: %w", err)
+	}
+	if want != mac {
+		return nil, errors.New("MAC mismatch: the file has been tampered with")
+	}
+
+	doc := &document{values: make(map[string]string)}
+	for _, branch := range tree.Branches {
+		if err := doc.add(branch, ""); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(doc.paths)
+	if !tree.Metadata.LastModified.IsZero() {
+		doc.modifiedAt = vault.NewTimestamp(tree.Metadata.LastModified)
+	}
+	return doc, nil
+}
+
+// add records the leaf values under v, with mapping keys joined by "."
+// and list elements addressed by index. Comments are skipped.
+func (d *document) add(v any, path string) error {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch v := v.(type) {
+	case sops.TreeBranch:
+		for _, item := range v {
+			if _, ok := item.Key.(sops.Comment); ok {
+				continue
+			}
+			if err := d.add(item.Value, join(fmt.Sprint(item.Key))); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []any:
+		i := 0
+		for _, e := range v {
+			if _, ok := e.(sops.Comment); ok {
+				continue
+			}
+			if err := d.add(e, join(strconv.Itoa(i))); err != nil {
+				return err
+			}
+			i++
+		}
+		return nil
+	case bool:
+		// sops writes "True" and "False"
+		d.set(path, strconv.FormatBool(v))
+		return nil
+	case nil:
+		d.set(path, "")
+		return nil
+	}
+
+	value, err := sops.ToBytes(v)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	d.set(path, string(value))
+	return nil
+}
+
+func (d *document) set(path, value string) {
+	if _, ok := d.values[path]; !ok {
+		d.paths = append(d.paths, path)
+	}
+	d.values[path] = value
+}
+
+// Get retrieves the value at a dotted path.
+func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	doc, err := p.load(ctx)
+	if err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), err)
+	}
+
+	value, ok := doc.values[path]
+	if !ok {
+		return nil, vault.NewVaultError("Get", path, p.Name(), vault.ErrSecretNotFound)
+	}
+
+	return &vault.Secret{
+		Value: value,
+		Metadata: vault.Metadata{
+			Provider:   p.Name(),
+			Path:       path,
+			ModifiedAt: doc.modifiedAt,
+		},
+	}, nil
+}
+
+// Set is not supported; the provider is read-only.
+func (p *Provider) Set(_ context.Context, path string, _ *vault.Secret) error {
+	return vault.NewVaultError("Set", path, p.Name(), vault.ErrReadOnly)
+}
+
+// Delete is not supported; the provider is read-only.
+func (p *Provider) Delete(_ context.Context, path string) error {
+	return vault.NewVaultError("Delete", path, p.Name(), vault.ErrReadOnly)
+}
+
+// Exists checks if a value exists at a dotted path.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	doc, err := p.load(ctx)
+	if err != nil {
+		return false, vault.NewVaultError("Exists", path, p.Name(), err)
+	}
+	_, ok := doc.values[path]
+	return ok, nil
+}
+
+// List returns the paths of all values matching the prefix.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	doc, err := p.load(ctx)
+	if err != nil {
+		return nil, vault.NewVaultError("List", prefix, p.Name(), err)
+	}
+
+	var results []string
+	for _, path := range doc.paths {
+		if strings.HasPrefix(path, prefix) {
+			results = append(results, path)
+		}
+	}
+	return results, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "sops"
+}
+
+// Capabilities returns the provider capabilities.
+func (p *Provider) Capabilities() vault.Capabilities {
+	return vault.Capabilities{
+		Read: true,
+		List: true,
+	}
+}
+
+// Close is a no-op for the SOPS provider.
+func (p *Provider) Close() error {
+	return nil
+}
+
+// Ensure Provider implements vault.Vault.
+var _ vault.Vault = (*Provider)(nil)
@@ -0,0 +1,258 @@
+package sops
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/getsops/sops/v3/keyservice"
+	"google.golang.org/grpc"
+
+	"github.com/agentplexus/omnivault"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// The testdata files were encrypted by the sops 3.12.1 command to the age
+// identity in testdata/keys.txt:
+//
+//	sops encrypt --age <recipient> secrets.yaml > testdata/secrets.enc.yaml
+//
+// secrets.enc.yaml holds db.{user,password,port}, a hosts list, a bool,
+// and an unencrypted region; secrets.enc.json holds api.{token,retries};
+// secrets.enc.env holds DB_PASSWORD and DEBUG.
+var testKeyFile = filepath.Join("testdata", "keys.txt")
+
+// copyFixture copies a fixture file so tests can modify it.
+func copyFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newKey returns a random age identity.
+func newKey(t *testing.T) string {
+	t.Helper()
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id.String()
+}
+
+func TestProvider(t *testing.T) {
+	p, err := New(Config{Path: filepath.Join("testdata", "secrets.enc.yaml"), AgeKeyFile: testKeyFile})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+
+	for path, want := range map[string]string{
+		"db.user":            "admin",
+		"db.password":        "hunter2",
+		"db.port":            "5432",
+		"hosts.1":            "b.example.com",
+		"enabled":            "true",
+		"region_unencrypted": "eu-west-1",
+	} {
+		secret, err := p.Get(ctx, path)
+		if err != nil {
+			t.Errorf("Get(%q) failed: %v", path, err)
+			continue
+		}
+		if secret.Value != want {
+			t.Errorf("Get(%q) = %q, want %q", path, secret.Value, want)
+		}
+		if secret.Metadata.ModifiedAt == nil || secret.Metadata.ModifiedAt.Format("2006-01-02") != "2026-10-15" {
+			t.Errorf("Expected ModifiedAt from lastmodified, got %v", secret.Metadata.ModifiedAt)
+		}
+	}
+
+	if _, err := p.Get(ctx, "db.missing"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+	if _, err := p.Get(ctx, "db"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound for a mapping, got %v", err)
+	}
+	if exists, err := p.Exists(ctx, "db.user"); err != nil || !exists {
+		t.Errorf("Expected db.user to exist, got %v, %v", exists, err)
+	}
+
+	paths, err := p.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	want := []string{"db.password", "db.port", "db.user", "enabled", "hosts.0", "hosts.1", "region_unencrypted"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("List = %v, want %v", paths, want)
+	}
+	if paths, _ := p.List(ctx, "db."); len(paths) != 3 {
+		t.Errorf("Expected 3 paths under db., got %v", paths)
+	}
+
+	if err := p.Set(ctx, "db.user", &vault.Secret{Value: "x"}); !errors.Is(err, vault.ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+	if caps := p.Capabilities(); caps.Write || caps.Delete || !caps.Read || !caps.List {
+		t.Errorf("Unexpected capabilities %+v", caps)
+	}
+}
+
+func TestWrongKey(t *testing.T) {
+	p, err := New(Config{Path: filepath.Join("testdata", "secrets.enc.yaml"), AgeKeys: []string{newKey(t)}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	if _, err := p.Get(context.Background(), "db.user"); !errors.Is(err, vault.ErrAccessDenied) {
+		t.Errorf("Expected ErrAccessDenied, got %v", err)
+	}
+}
+
+func TestTamperedFile(t *testing.T) {
+	path := copyFixture(t, "secrets.enc.yaml")
+	data, _ := os.ReadFile(path)
+	tampered := strings.Replace(string(data), "region_unencrypted: eu-west-1", "region_unencrypted: us-east-1", 1)
+	if err := os.WriteFile(path, []byte(tampered), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := New(Config{Path: path, AgeKeyFile: testKeyFile})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := p.Get(context.Background(), "db.user"); err == nil || !strings.Contains(err.Error(), "MAC mismatch") {
+		t.Errorf("Expected MAC mismatch, got %v", err)
+	}
+}
+
+func TestJSONAndDotenv(t *testing.T) {
+	ctx := context.Background()
+
+	for name, want := range map[string]map[string]string{
+		"secrets.enc.json": {"api.token": "s3cret", "api.retries": "3"},
+		"secrets.enc.env":  {"DB_PASSWORD": "hunter2", "DEBUG": "false"},
+	} {
+		p, err := New(Config{Path: filepath.Join("testdata", name), AgeKeyFile: testKeyFile})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		for key, want := range want {
+			if secret, err := p.Get(ctx, key); err != nil || secret.Value != want {
+				t.Errorf("%s: Get(%q) = %v, %v; want %q", name, key, secret, err, want)
+			}
+		}
+	}
+}
+
+func TestAgeKeyFromEnvironment(t *testing.T) {
+	key, err := os.ReadFile(testKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SOPS_AGE_KEY", string(key))
+	t.Setenv("SOPS_AGE_KEY_FILE", "")
+
+	p, err := New(Config{Path: filepath.Join("testdata", "secrets.enc.yaml")})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if secret, err := p.Get(context.Background(), "db.password"); err != nil || secret.Value != "hunter2" {
+		t.Errorf("Expected the key from SOPS_AGE_KEY to be used, got %v, %v", secret, err)
+	}
+}
+
+// recordingKeyService records the keys it is asked to decrypt with and
+// passes the request on to next, or fails if next is nil.
+type recordingKeyService struct {
+	next keyservice.KeyServiceClient
+	keys []*keyservice.Key
+}
+
+func (s *recordingKeyService) Encrypt(context.Context, *keyservice.EncryptRequest, ...grpc.CallOption) (*keyservice.EncryptResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *recordingKeyService) Decrypt(ctx context.Context, req *keyservice.DecryptRequest, opts ...grpc.CallOption) (*keyservice.DecryptResponse, error) {
+	s.keys = append(s.keys, req.Key)
+	if s.next == nil {
+		return nil, errors.New("key service unavailable")
+	}
+	return s.next.Decrypt(ctx, req, opts...)
+}
+
+func TestKeyServices(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join("testdata", "secrets.enc.yaml")
+
+	unavailable := &recordingKeyService{}
+	p, err := New(Config{Path: path, AgeKeys: []string{newKey(t)}, KeyServices: []keyservice.KeyServiceClient{unavailable}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := p.Get(ctx, "db.user"); !errors.Is(err, vault.ErrAccessDenied) {
+		t.Errorf("Expected ErrAccessDenied, got %v", err)
+	}
+	if len(unavailable.keys) != 1 || !strings.HasPrefix(unavailable.keys[0].GetAgeKey().GetRecipient(), "age1") {
+		t.Errorf("Expected the age key to be passed to the key service, got %v", unavailable.keys)
+	}
+
+	// A key service holding the right identity decrypts the file
+	remote, err := New(Config{Path: path, AgeKeyFile: testKeyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = New(Config{Path: path, AgeKeys: []string{newKey(t)}, KeyServices: []keyservice.KeyServiceClient{
+		&recordingKeyService{next: remote.keyServices[0]},
+	}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if secret, err := p.Get(ctx, "db.password"); err != nil || secret.Value != "hunter2" {
+		t.Errorf("Expected the key service to decrypt the data key, got %v, %v", secret, err)
+	}
+}
+
+func TestInvalidAgeKey(t *testing.T) {
+	path := filepath.Join("testdata", "secrets.enc.yaml")
+	if _, err := New(Config{Path: path, AgeKeys: []string{"age1notakey"}}); err == nil {
+		t.Error("Expected an error for a recipient")
+	}
+	if _, err := New(Config{Path: path, AgeKeys: []string{"# no keys here"}}); err == nil {
+		t.Error("Expected an error when no identities are found")
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join("testdata", "secrets.enc.yaml")
+
+	for _, providerConfig := range []any{
+		Config{Path: path, AgeKeyFile: testKeyFile},
+		map[string]any{"path": path, "agekeyfile": testKeyFile},
+	} {
+		client, err := omnivault.NewClient(omnivault.Config{Provider: omnivault.ProviderSOPS, ProviderConfig: providerConfig})
+		if err != nil {
+			t.Fatalf("NewClient(%T) failed: %v", providerConfig, err)
+		}
+		if value, err := client.GetValue(ctx, "db.password"); err != nil || value != "hunter2" {
+			t.Errorf("GetValue with %T = %q, %v; want hunter2", providerConfig, value, err)
+		}
+	}
+
+	_, err := omnivault.NewClient(omnivault.Config{Provider: omnivault.ProviderSOPS, ProviderConfig: map[string]any{"paht": path}})
+	if err == nil || !strings.Contains(err.Error(), "invalid sops config") {
+		t.Errorf("Expected an unknown setting to be rejected, got %v", err)
+	}
+}
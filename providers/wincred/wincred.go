@@ -0,0 +1,56 @@
+// Package wincred provides a vault implementation backed by the Windows
+// Credential Manager.
+//
+// Secrets are stored as generic credentials whose target name is the
+// configured service name and the secret path joined by a colon, so that:
+//
+//	v, err := wincred.New(wincred.Config{Service: "myapp"})
+//	secret, err := v.Get(ctx, "database/password")
+//
+// reads the credential with target "myapp:database/password".
+//
+// The provider calls the Credential Manager API in advapi32.dll. It is only
+// available on Windows; on other platforms New returns vault.ErrNotSupported.
+package wincred
+
+import "strings"
+
+// Default configuration values.
+const (
+	DefaultService = "omnivault"
+
+	// MaxValueSize is the largest secret value the Credential Manager can
+	// store (CRED_MAX_CREDENTIAL_BLOB_SIZE).
+	MaxValueSize = 5 * 512
+)
+
+// Config holds configuration for the Windows Credential Manager provider.
+type Config struct {
+	// Service namespaces all credentials created by this provider (default: "omnivault").
+	Service string
+}
+
+// withDefaults returns a copy of the config with defaults applied.
+func (c Config) withDefaults() Config {
+	if c.Service == "" {
+		c.Service = DefaultService
+	}
+	return c
+}
+
+// target returns the credential target name for a secret path.
+func (c Config) target(path string) string {
+	return c.Service + ":" + path
+}
+
+// filter returns the CredEnumerate filter matching all of the service's
+// credentials.
+func (c Config) filter() string {
+	return c.Service + ":*"
+}
+
+// pathOf returns the secret path of a credential target name, and false if
+// the target does not belong to the service.
+func (c Config) pathOf(target string) (string, bool) {
+	return strings.CutPrefix(target, c.Service+":")
+}
@@ -0,0 +1,63 @@
+package wincred
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+func TestConfigTargets(t *testing.T) {
+	config := Config{}.withDefaults()
+	if got := config.target("database/password"); got != "omnivault:database/password" {
+		t.Errorf("Unexpected target: %s", got)
+	}
+	if got := config.filter(); got != "omnivault:*" {
+		t.Errorf("Unexpected filter: %s", got)
+	}
+
+	config = Config{Service: "myapp"}.withDefaults()
+	if path, ok := config.pathOf("myapp:api/key"); !ok || path != "api/key" {
+		t.Errorf("pathOf = %q, %v; want api/key, true", path, ok)
+	}
+	if _, ok := config.pathOf("other:api/key"); ok {
+		t.Error("Expected a target of another service to be rejected")
+	}
+}
+
+// TestLive exercises the real Credential Manager. It is only run when
+// OMNIVAULT_WINCRED_LIVE=1.
+func TestLive(t *testing.T) {
+	if os.Getenv("OMNIVAULT_WINCRED_LIVE") != "1" {
+		t.Skip("set OMNIVAULT_WINCRED_LIVE=1 to run against the Credential Manager")
+	}
+
+	ctx := context.Background()
+	p, err := New(Config{Service: "omnivault-test"})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	defer p.Close()
+
+	if err := p.Set(ctx, "live/secret", &vault.Secret{Value: "value123"}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+	defer func() { _ = p.Delete(ctx, "live/secret") }()
+
+	secret, err := p.Get(ctx, "live/secret")
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if secret.Value != "value123" {
+		t.Errorf("Expected value 'value123', got '%s'", secret.Value)
+	}
+
+	paths, err := p.List(ctx, "live/")
+	if err != nil {
+		t.Fatalf("Failed to list secrets: %v", err)
+	}
+	if len(paths) != 1 {
+		t.Errorf("Expected 1 path, got %v", paths)
+	}
+}
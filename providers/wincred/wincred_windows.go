@@ -0,0 +1,221 @@
+//go:build windows
+
+package wincred
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// Credential Manager constants from wincred.h and winerror.h.
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+)
+
+var (
+	advapi32           = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW      = advapi32.NewProc("CredReadW")
+	procCredWriteW     = advapi32.NewProc("CredWriteW")
+	procCredDeleteW    = advapi32.NewProc("CredDeleteW")
+	procCredEnumerateW = advapi32.NewProc("CredEnumerateW")
+	procCredFree       = advapi32.NewProc("CredFree")
+)
+
+// credential mirrors the CREDENTIALW structure.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// Provider implements vault.Vault for the Windows Credential Manager.
+type Provider struct {
+	config Config
+}
+
+// New creates a new Windows Credential Manager provider.
+func New(config Config) (*Provider, error) {
+	if err := advapi32.Load(); err != nil {
+		return nil, vault.NewVaultError("New", "", "wincred", vault.ErrNotSupported)
+	}
+	return &Provider{config: config.withDefaults()}, nil
+}
+
+// callError converts the error of a failed Cred* call.
+func callError(err error) error {
+	if errors.Is(err, errorNotFound) {
+		return vault.ErrSecretNotFound
+	}
+	return err
+}
+
+// Get retrieves a secret from the Credential Manager.
+func (p *Provider) Get(ctx context.Context, path string) (*vault.Secret, error) {
+	target, err := syscall.UTF16PtrFromString(p.config.target(path))
+	if err != nil {
+		return nil, vault.NewVaultError("Get", path, p.Name(), vault.ErrInvalidPath)
+	}
+
+	var cred *credential
+	ret, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ret == 0 {
+		return nil, vault.NewVaultError("Get", path, p.Name(), callError(err))
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	value := ""
+	if cred.CredentialBlobSize > 0 {
+		value = string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize))
+	}
+	return &vault.Secret{
+		Value: value,
+		Metadata: vault.Metadata{
+			Provider: p.Name(),
+			Path:     path,
+		},
+	}, nil
+}
+
+// Set stores a secret in the Credential Manager, replacing any existing
+// credential.
+func (p *Provider) Set(ctx context.Context, path string, secret *vault.Secret) error {
+	blob := secret.Bytes()
+	if len(blob) > MaxValueSize {
+		return vault.NewVaultError("Set", path, p.Name(),
+			fmt.Errorf("value is %d bytes, the Credential Manager stores at most %d", len(blob), MaxValueSize))
+	}
+
+	target, err := syscall.UTF16PtrFromString(p.config.target(path))
+	if err != nil {
+		return vault.NewVaultError("Set", path, p.Name(), vault.ErrInvalidPath)
+	}
+	userName, _ := syscall.UTF16PtrFromString(p.config.Service)
+
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+		UserName:           userName,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+
+	if ret, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0); ret == 0 {
+		return vault.NewVaultError("Set", path, p.Name(), err)
+	}
+	return nil
+}
+
+// Delete removes a secret from the Credential Manager.
+func (p *Provider) Delete(ctx context.Context, path string) error {
+	target, err := syscall.UTF16PtrFromString(p.config.target(path))
+	if err != nil {
+		return vault.NewVaultError("Delete", path, p.Name(), vault.ErrInvalidPath)
+	}
+
+	ret, _, err := procCredDeleteW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0)
+	if ret == 0 && !errors.Is(err, errorNotFound) {
+		return vault.NewVaultError("Delete", path, p.Name(), err)
+	}
+	return nil
+}
+
+// Exists checks if a secret exists in the Credential Manager.
+func (p *Provider) Exists(ctx context.Context, path string) (bool, error) {
+	_, err := p.Get(ctx, path)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return false, nil
+	}
+	return false, err
+}
+
+// List returns all secret paths matching the prefix.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	filter, err := syscall.UTF16PtrFromString(p.config.filter())
+	if err != nil {
+		return nil, vault.NewVaultError("List", prefix, p.Name(), err)
+	}
+
+	var count uint32
+	var creds **credential
+	ret, _, err := procCredEnumerateW.Call(uintptr(unsafe.Pointer(filter)), 0,
+		uintptr(unsafe.Pointer(&count)), uintptr(unsafe.Pointer(&creds)))
+	if ret == 0 {
+		if errors.Is(err, errorNotFound) {
+			return []string{}, nil
+		}
+		return nil, vault.NewVaultError("List", prefix, p.Name(), err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(creds)))
+
+	paths := []string{}
+	for _, cred := range unsafe.Slice(creds, count) {
+		if cred.Type != credTypeGeneric {
+			continue
+		}
+		path, ok := p.config.pathOf(utf16PtrToString(cred.TargetName))
+		if ok && strings.HasPrefix(path, prefix) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// utf16PtrToString converts a NUL-terminated UTF-16 string to a string.
+func utf16PtrToString(p *uint16) string {
+	if p == nil {
+		return ""
+	}
+	n := 0
+	for ptr := unsafe.Pointer(p); *(*uint16)(ptr) != 0; n++ {
+		ptr = unsafe.Add(ptr, 2)
+	}
+	return syscall.UTF16ToString(unsafe.Slice(p, n))
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "wincred"
+}
+
+// Capabilities returns the provider capabilities.
+func (p *Provider) Capabilities() vault.Capabilities {
+	return vault.Capabilities{
+		Read:   true,
+		Write:  true,
+		Delete: true,
+		List:   true,
+	}
+}
+
+// Close is a no-op for the Credential Manager provider.
+func (p *Provider) Close() error {
+	return nil
+}
+
+// Ensure Provider implements vault.Vault.
+var _ vault.Vault = (*Provider)(nil)
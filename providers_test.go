@@ -0,0 +1,171 @@
+package omnivault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/agentplexus/omnivault/providers/memory"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// registerTestProvider registers a factory and removes it when the test ends.
+func registerTestProvider(t *testing.T, name ProviderName, factory ProviderFactory) {
+	t.Helper()
+	RegisterProvider(name, factory)
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, name)
+		registryMu.Unlock()
+	})
+}
+
+func TestRegisterProvider(t *testing.T) {
+	ctx := context.Background()
+
+	var got Config
+	registerTestProvider(t, "fake", func(config Config) (vault.Vault, error) {
+		got = config
+		return memory.NewWithSecrets(map[string]string{"api-key": "fake-value"}), nil
+	})
+
+	client, err := NewClient(Config{Provider: "fake", ProviderConfig: "settings"})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	value, err := client.GetValue(ctx, "api-key")
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if value != "fake-value" {
+		t.Errorf("Expected 'fake-value', got '%s'", value)
+	}
+	if got.ProviderConfig != "settings" {
+		t.Errorf("Expected factory to receive ProviderConfig, got %v", got.ProviderConfig)
+	}
+}
+
+func TestRegisterProviderFromConfigFile(t *testing.T) {
+	var settings map[string]any
+	registerTestProvider(t, "fake-file", func(config Config) (vault.Vault, error) {
+		settings, _ = config.ProviderConfig.(map[string]any)
+		return memory.New(), nil
+	})
+
+	_, err := NewClientFromJSON([]byte(`{"provider": "fake-file", "config": {"region": "eu"}}`))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if settings["region"] != "eu" {
+		t.Errorf("Expected raw settings to be passed through, got %v", settings)
+	}
+}
+
+func TestRegisterProviderFactoryError(t *testing.T) {
+	errFactory := errors.New("factory failed")
+	registerTestProvider(t, "failing", func(Config) (vault.Vault, error) {
+		return nil, errFactory
+	})
+
+	if _, err := NewClient(Config{Provider: "failing"}); !errors.Is(err, errFactory) {
+		t.Errorf("Expected factory error, got %v", err)
+	}
+}
+
+func TestUnknownProvider(t *testing.T) {
+	if _, err := NewClient(Config{Provider: "nonexistent"}); !errors.Is(err, ErrUnknownScheme) {
+		t.Errorf("Expected ErrUnknownScheme, got %v", err)
+	}
+}
+
+func TestRegisterProviderPanics(t *testing.T) {
+	factory := func(Config) (vault.Vault, error) { return memory.New(), nil }
+	registerTestProvider(t, "dup", factory)
+
+	tests := []struct {
+		name     string
+		provider ProviderName
+		factory  ProviderFactory
+	}{
+		{"EmptyName", "", factory},
+		{"NilFactory", "nil-factory", nil},
+		{"Duplicate", "dup", factory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected panic")
+				}
+			}()
+			RegisterProvider(tt.provider, tt.factory)
+		})
+	}
+}
+
+func TestRegisterProviderConcurrent(t *testing.T) {
+	const n = 20
+	factory := func(Config) (vault.Vault, error) { return memory.New(), nil }
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		name := ProviderName(fmt.Sprintf("concurrent-%d", i))
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			registerTestProvider(t, name, factory)
+		}()
+		go func() {
+			defer wg.Done()
+			// May run before or after registration
+			_, _ = NewClient(Config{Provider: name})
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		if _, err := NewClient(Config{Provider: ProviderName(fmt.Sprintf("concurrent-%d", i))}); err != nil {
+			t.Errorf("Failed to create client for provider %d: %v", i, err)
+		}
+	}
+}
+
+func TestAvailableProviders(t *testing.T) {
+	registerTestProvider(t, "fake", func(Config) (vault.Vault, error) {
+		return memory.New(), nil
+	})
+
+	infos := make(map[ProviderName]ProviderInfo)
+	for _, info := range AvailableProviders() {
+		infos[info.Name] = info
+	}
+
+	tests := []struct {
+		name ProviderName
+		want vault.Capabilities
+	}{
+		{ProviderEnv, vault.Capabilities{Read: true, List: true}},
+		{ProviderFile, vault.Capabilities{Read: true, Write: true, Delete: true, List: true, Binary: true, Watch: true}},
+		{ProviderMemory, vault.Capabilities{Read: true, Write: true, Delete: true, List: true, MultiField: true, Binary: true}},
+	}
+	for _, tt := range tests {
+		info, ok := infos[tt.name]
+		if !ok {
+			t.Errorf("Expected %s to be available", tt.name)
+			continue
+		}
+		if !info.Builtin {
+			t.Errorf("Expected %s to be built in", tt.name)
+		}
+		if info.Capabilities != tt.want {
+			t.Errorf("%s capabilities = %+v, want %+v", tt.name, info.Capabilities, tt.want)
+		}
+	}
+
+	if info, ok := infos["fake"]; !ok || info.Builtin {
+		t.Errorf("Expected the registered provider to be listed, got %+v", info)
+	}
+}
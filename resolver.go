@@ -2,7 +2,9 @@ package omnivault
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/agentplexus/omnivault/vault"
@@ -13,31 +15,142 @@ import (
 type Resolver struct {
 	mu        sync.RWMutex
 	providers map[string]vault.Vault
+
+	// Where references name a field, by scheme; FieldFromFragment if unset
+	conventions map[string]FieldConvention
+
+	// Auto-invalidation watchers, by scheme
+	autoInvalidate bool
+	watchers       map[string]context.CancelFunc
 }
 
 // NewResolver creates a new Resolver.
 func NewResolver() *Resolver {
 	return &Resolver{
-		providers: make(map[string]vault.Vault),
+		providers:   make(map[string]vault.Vault),
+		conventions: make(map[string]FieldConvention),
+		watchers:    make(map[string]context.CancelFunc),
 	}
 }
 
+// FieldConvention is where the secret references of a scheme name a field.
+type FieldConvention int
+
+const (
+	// FieldFromFragment takes the field from the fragment, as in
+	// "vault://secret/path#field". It is the default.
+	FieldFromFragment FieldConvention = iota
+
+	// FieldFromLastSegment takes the field from the last path segment and
+	// fetches the secret at the rest of the path, as 1Password does with
+	// "op://vault/item/field". A reference with a single segment names no
+	// field, and a fragment, if present, still names the field and keeps
+	// the path whole.
+	FieldFromLastSegment
+)
+
+// SetFieldConvention sets where references with the given scheme name a
+// field. Providers whose secret paths can't contain "/" usually want
+// FieldFromLastSegment.
+func (r *Resolver) SetFieldConvention(scheme string, c FieldConvention) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.conventions[scheme] = c
+}
+
+// splitField returns the path of the secret ref refers to and the field it
+// names, if any, following convention c.
+func splitField(ref vault.SecretRef, c FieldConvention) (path, field string) {
+	path, field = ref.Path(), ref.Fragment()
+	if c == FieldFromLastSegment && field == "" {
+		if i := strings.LastIndex(path, "/"); i > 0 && i < len(path)-1 {
+			return path[:i], path[i+1:]
+		}
+	}
+	return path, field
+}
+
 // Register adds a vault provider for the given scheme.
 // The scheme should match the URI scheme used in secret references
 // (e.g., "op" for op://..., "env" for env://...).
 func (r *Resolver) Register(scheme string, v vault.Vault) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	r.stopWatch(scheme)
 	r.providers[scheme] = v
+	if r.autoInvalidate {
+		_ = r.startWatch(scheme, v)
+	}
 }
 
 // Unregister removes a vault provider for the given scheme.
 func (r *Resolver) Unregister(scheme string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	r.stopWatch(scheme)
 	delete(r.providers, scheme)
 }
 
+// EnableAutoInvalidate watches every registered provider that supports
+// watching, including providers registered later, so that cached secrets
+// are invalidated as soon as they change. This applies to providers that
+// cache, such as a Client created with a CacheTTL around a provider that
+// implements vault.WatchableVault. Providers that can't be watched are
+// skipped. Watchers run until the provider is unregistered or the
+// resolver is closed. It returns the first error from starting a watcher.
+func (r *Resolver) EnableAutoInvalidate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.autoInvalidate = true
+
+	var firstErr error
+	for scheme, v := range r.providers {
+		if _, running := r.watchers[scheme]; running {
+			continue
+		}
+		if err := r.startWatch(scheme, v); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to watch %s: %w", scheme, err)
+		}
+	}
+	return firstErr
+}
+
+// startWatch starts watching v, if it supports watching. The events are
+// discarded; watching caching providers is what invalidates their cache.
+// Callers must hold r.mu.
+func (r *Resolver) startWatch(scheme string, v vault.Vault) error {
+	w, ok := vault.AsWatchable(v)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := w.Watch(ctx, "")
+	if err != nil {
+		cancel()
+		if errors.Is(err, vault.ErrNotSupported) {
+			return nil
+		}
+		return err
+	}
+
+	r.watchers[scheme] = cancel
+	go func() {
+		for range events {
+		}
+	}()
+	return nil
+}
+
+// stopWatch stops the watcher for scheme, if any. Callers must hold r.mu.
+func (r *Resolver) stopWatch(scheme string) {
+	if cancel, ok := r.watchers[scheme]; ok {
+		cancel()
+		delete(r.watchers, scheme)
+	}
+}
+
 // Get returns the vault provider for the given scheme.
 func (r *Resolver) Get(scheme string) (vault.Vault, bool) {
 	r.mu.RLock()
@@ -58,7 +171,8 @@ func (r *Resolver) Schemes() []string {
 }
 
 // Resolve resolves a secret reference URI and returns the secret value.
-// The URI format is: scheme://path[#field]
+// The URI format is: scheme://path[#field], or scheme://path/field for
+// schemes set to FieldFromLastSegment.
 //
 // Examples:
 //
@@ -66,15 +180,33 @@ func (r *Resolver) Schemes() []string {
 //	resolver.Resolve(ctx, "env://API_KEY")
 //	resolver.Resolve(ctx, "aws-sm://my-secret#password")
 func (r *Resolver) Resolve(ctx context.Context, uri string) (string, error) {
-	secret, err := r.ResolveSecret(ctx, uri)
+	return r.resolve(ctx, uri, nil)
+}
+
+// ResolveSecret resolves a secret reference URI and returns the full Secret.
+func (r *Resolver) ResolveSecret(ctx context.Context, uri string) (*vault.Secret, error) {
+	return r.resolveSecret(ctx, uri, nil)
+}
+
+// fetched is the result of fetching a secret, memoized within a batch.
+type fetched struct {
+	secret *vault.Secret
+	err    error
+}
+
+// resolve implements Resolve. See resolveSecret for memo.
+func (r *Resolver) resolve(ctx context.Context, uri string, memo map[string]fetched) (string, error) {
+	secret, err := r.resolveSecret(ctx, uri, memo)
 	if err != nil {
 		return "", err
 	}
 	return secret.String(), nil
 }
 
-// ResolveSecret resolves a secret reference URI and returns the full Secret.
-func (r *Resolver) ResolveSecret(ctx context.Context, uri string) (*vault.Secret, error) {
+// resolveSecret implements ResolveSecret. If memo is not nil, each secret
+// is fetched from its provider only once, however many references
+// (including references to different fields) point at it.
+func (r *Resolver) resolveSecret(ctx context.Context, uri string, memo map[string]fetched) (*vault.Secret, error) {
 	ref := vault.SecretRef(uri)
 	scheme := ref.Scheme()
 	if scheme == "" {
@@ -83,22 +215,31 @@ func (r *Resolver) ResolveSecret(ctx context.Context, uri string) (*vault.Secret
 
 	r.mu.RLock()
 	v, ok := r.providers[scheme]
+	convention := r.conventions[scheme]
 	r.mu.RUnlock()
 
 	if !ok {
 		return nil, fmt.Errorf("%w: %s", ErrProviderNotRegistered, scheme)
 	}
 
-	path := ref.Path()
-	secret, err := v.Get(ctx, path)
+	path, field := splitField(ref, convention)
+	key := scheme + "://" + path
+	f, ok := memo[key]
+	if !ok {
+		f.secret, f.err = v.Get(ctx, path)
+		if memo != nil {
+			memo[key] = f
+		}
+	}
+	secret, err := f.secret, f.err
 	if err != nil {
 		return nil, err
 	}
 
-	// If a fragment (field) is specified, extract just that field
-	if fragment := ref.Fragment(); fragment != "" && secret != nil {
+	// If a field is specified, extract just that field
+	if field != "" && secret != nil {
 		return &vault.Secret{
-			Value:    secret.GetField(fragment),
+			Value:    secret.GetField(field),
 			Metadata: secret.Metadata,
 		}, nil
 	}
@@ -106,6 +247,69 @@ func (r *Resolver) ResolveSecret(ctx context.Context, uri string) (*vault.Secret
 	return secret, nil
 }
 
+// MaxResolveDepth is the most references ResolveDeep follows after the
+// first one.
+const MaxResolveDepth = 10
+
+// ResolveDeep resolves a secret reference URI like Resolve, and while the
+// value is itself a secret reference with a registered scheme, resolves
+// that in turn. This allows a secret to hold only a reference to where the
+// value lives, for example to route each environment to a different
+// provider. Any other value, including a URL with an unregistered scheme,
+// ends the chain and is returned.
+//
+// A chain that leads back to a reference already followed returns
+// ErrReferenceCycle, and one that follows more than MaxResolveDepth
+// references returns ErrReferenceDepth.
+func (r *Resolver) ResolveDeep(ctx context.Context, uri string) (string, error) {
+	seen := make(map[string]bool)
+	for depth := 0; ; depth++ {
+		if seen[uri] {
+			return "", fmt.Errorf("%w: %s", ErrReferenceCycle, uri)
+		}
+		if depth > MaxResolveDepth {
+			return "", fmt.Errorf("%w: %s", ErrReferenceDepth, uri)
+		}
+		seen[uri] = true
+
+		value, err := r.Resolve(ctx, uri)
+		if err != nil {
+			if depth > 0 {
+				return "", fmt.Errorf("failed to resolve %s: %w", uri, err)
+			}
+			return "", err
+		}
+		if !r.isRegisteredRef(value) {
+			return value, nil
+		}
+		uri = value
+	}
+}
+
+// isRegisteredRef reports whether s is a well-formed secret reference
+// whose scheme has a registered provider.
+func (r *Resolver) isRegisteredRef(s string) bool {
+	ref := vault.SecretRef(s)
+	if !ref.Valid() {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.providers[ref.Scheme()]
+	return ok
+}
+
+// ResolveOr resolves a secret reference URI, or returns fallback if the
+// secret does not exist. Other errors, including malformed references and
+// unregistered schemes, are returned.
+func (r *Resolver) ResolveOr(ctx context.Context, uri, fallback string) (string, error) {
+	value, err := r.Resolve(ctx, uri)
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return fallback, nil
+	}
+	return value, err
+}
+
 // MustResolve resolves a secret reference or panics if an error occurs.
 func (r *Resolver) MustResolve(ctx context.Context, uri string) string {
 	value, err := r.Resolve(ctx, uri)
@@ -116,11 +320,13 @@ func (r *Resolver) MustResolve(ctx context.Context, uri string) string {
 }
 
 // ResolveAll resolves multiple secret references and returns a map of URI to value.
+// Each secret is fetched once, even if several URIs refer to it.
 // If any resolution fails, it returns an error.
 func (r *Resolver) ResolveAll(ctx context.Context, uris []string) (map[string]string, error) {
+	memo := make(map[string]fetched)
 	results := make(map[string]string, len(uris))
 	for _, uri := range uris {
-		value, err := r.Resolve(ctx, uri)
+		value, err := r.resolve(ctx, uri, memo)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve %s: %w", uri, err)
 		}
@@ -134,6 +340,10 @@ func (r *Resolver) Close() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	for scheme := range r.watchers {
+		r.stopWatch(scheme)
+	}
+
 	var lastErr error
 	for _, v := range r.providers {
 		if err := v.Close(); err != nil {
@@ -143,6 +353,25 @@ func (r *Resolver) Close() error {
 	return lastErr
 }
 
+// Validate checks that each URI is a well-formed secret reference whose
+// scheme has a registered provider, without fetching any secrets. The
+// returned slice has an error for each URI, nil where the URI is valid.
+func (r *Resolver) Validate(uris []string) []error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	errs := make([]error, len(uris))
+	for i, uri := range uris {
+		ref := vault.SecretRef(uri)
+		if !ref.Valid() {
+			errs[i] = fmt.Errorf("%w: %s", ErrInvalidSecretRef, uri)
+		} else if _, ok := r.providers[ref.Scheme()]; !ok {
+			errs[i] = fmt.Errorf("%w: %s", ErrProviderNotRegistered, ref.Scheme())
+		}
+	}
+	return errs
+}
+
 // IsSecretRef checks if a string looks like a secret reference URI.
 func IsSecretRef(s string) bool {
 	ref := vault.SecretRef(s)
@@ -152,18 +381,25 @@ func IsSecretRef(s string) bool {
 // ResolveString resolves a string if it's a secret reference, otherwise returns it as-is.
 // This is useful for processing configuration values that may or may not be secret references.
 func (r *Resolver) ResolveString(ctx context.Context, s string) (string, error) {
+	return r.resolveString(ctx, s, nil)
+}
+
+// resolveString implements ResolveString. See resolveSecret for memo.
+func (r *Resolver) resolveString(ctx context.Context, s string, memo map[string]fetched) (string, error) {
 	if !IsSecretRef(s) {
 		return s, nil
 	}
-	return r.Resolve(ctx, s)
+	return r.resolve(ctx, s, memo)
 }
 
 // ResolveMap resolves all values in a map that are secret references.
-// Non-reference values are passed through unchanged.
+// Non-reference values are passed through unchanged. Each secret is
+// fetched once, even if several values refer to it.
 func (r *Resolver) ResolveMap(ctx context.Context, m map[string]string) (map[string]string, error) {
+	memo := make(map[string]fetched)
 	result := make(map[string]string, len(m))
 	for k, v := range m {
-		resolved, err := r.ResolveString(ctx, v)
+		resolved, err := r.resolveString(ctx, v, memo)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve %s: %w", k, err)
 		}
@@ -0,0 +1,327 @@
+package omnivault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omnivault/providers/audit"
+	"github.com/agentplexus/omnivault/providers/cache"
+	"github.com/agentplexus/omnivault/providers/file"
+	"github.com/agentplexus/omnivault/providers/memory"
+	"github.com/agentplexus/omnivault/providers/resilient"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+func TestResolverAutoInvalidate(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "api-key")
+	if err := os.WriteFile(secretFile, []byte("v1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fp, err := file.New(file.Config{Directory: dir, WatchInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to create file provider: %v", err)
+	}
+	client, err := NewClient(Config{CustomVault: fp, CacheTTL: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	r := NewResolver()
+	defer r.Close()
+	r.Register("file", client)
+	if err := r.EnableAutoInvalidate(); err != nil {
+		t.Fatalf("EnableAutoInvalidate failed: %v", err)
+	}
+
+	if value := r.MustResolve(ctx, "file://api-key"); value != "v1" {
+		t.Fatalf("Expected 'v1', got %q", value)
+	}
+
+	// Change the file behind the cache's back
+	if err := os.WriteFile(secretFile, []byte("v2-rotated"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForValue(t, r, "file://api-key", "v2-rotated")
+}
+
+func TestResolverAutoInvalidateChain(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "api-key")
+	if err := os.WriteFile(secretFile, []byte("v1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fp, err := file.New(file.Config{Directory: dir, WatchInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to create file provider: %v", err)
+	}
+
+	// The cache is invalidated through the wrappers around it
+	v := vault.Chain(fp,
+		resilient.Middleware(resilient.Policy{Retries: 1}),
+		cache.Middleware(time.Hour),
+		audit.Middleware(nil))
+
+	r := NewResolver()
+	defer r.Close()
+	r.Register("file", v)
+	if err := r.EnableAutoInvalidate(); err != nil {
+		t.Fatalf("EnableAutoInvalidate failed: %v", err)
+	}
+
+	if value := r.MustResolve(ctx, "file://api-key"); value != "v1" {
+		t.Fatalf("Expected 'v1', got %q", value)
+	}
+	if err := os.WriteFile(secretFile, []byte("v2-rotated"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	waitForValue(t, r, "file://api-key", "v2-rotated")
+}
+
+// waitForValue resolves ref until it returns want, failing the test if it
+// doesn't within two seconds.
+func waitForValue(t *testing.T, r *Resolver, ref, want string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		value := r.MustResolve(context.Background(), ref)
+		if value == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Cached value %q was not invalidated after the file changed", value)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestResolverAutoInvalidateSkipsUnwatchable(t *testing.T) {
+	r := NewResolver()
+	defer r.Close()
+
+	client, err := NewClient(Config{Provider: ProviderMemory, CacheTTL: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	r.Register("mem", client)
+
+	if err := r.EnableAutoInvalidate(); err != nil {
+		t.Errorf("Expected unwatchable providers to be skipped, got %v", err)
+	}
+}
+
+func TestResolveMapFetchesEachSecretOnce(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	if err := mem.Set(ctx, "db", &vault.Secret{Value: "hunter2", Fields: map[string]string{"user": "admin"}}); err != nil {
+		t.Fatal(err)
+	}
+	cv := &countingVault{Vault: mem}
+
+	r := NewResolver()
+	r.Register("mem", cv)
+
+	m := map[string]string{"region": "eu-west-1", "db_user": "mem://db#user"}
+	for _, svc := range []string{"api", "worker", "cron", "billing", "search"} {
+		m[svc+"_password"] = "mem://db"
+	}
+
+	got, err := r.ResolveMap(ctx, m)
+	if err != nil {
+		t.Fatalf("ResolveMap failed: %v", err)
+	}
+	if n := cv.gets.Load(); n != 1 {
+		t.Errorf("Expected 1 fetch of db, got %d", n)
+	}
+	if got["region"] != "eu-west-1" || got["db_user"] != "admin" || len(got) != len(m) {
+		t.Errorf("Unexpected result: %v", got)
+	}
+	for _, svc := range []string{"api", "worker", "cron", "billing", "search"} {
+		if got[svc+"_password"] != "hunter2" {
+			t.Errorf("Expected %s_password 'hunter2', got %q", svc, got[svc+"_password"])
+		}
+	}
+
+	// Memoization is per call
+	if _, err := r.ResolveAll(ctx, []string{"mem://db", "mem://db", "mem://db#user"}); err != nil {
+		t.Fatalf("ResolveAll failed: %v", err)
+	}
+	if n := cv.gets.Load(); n != 2 {
+		t.Errorf("Expected ResolveAll to fetch db once more, got %d fetches", n)
+	}
+
+	// Errors are reported as before
+	if _, err := r.ResolveMap(ctx, map[string]string{"a": "mem://missing", "b": "mem://missing"}); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+	if n := cv.gets.Load(); n != 3 {
+		t.Errorf("Expected 1 fetch of missing, got %d", n-2)
+	}
+}
+
+func TestResolverValidate(t *testing.T) {
+	cv := &countingVault{Vault: memory.New()}
+	r := NewResolver()
+	r.Register("mem", cv)
+
+	uris := []string{"mem://db", "mem://db#user", "aws-sm://db", "mem:/db", "mem://", "not a ref"}
+	errs := r.Validate(uris)
+	if len(errs) != len(uris) {
+		t.Fatalf("Expected %d errors, got %d", len(uris), len(errs))
+	}
+	for i, want := range []error{nil, nil, ErrProviderNotRegistered, ErrInvalidSecretRef, ErrInvalidSecretRef, ErrInvalidSecretRef} {
+		if want == nil && errs[i] != nil {
+			t.Errorf("Validate(%q) = %v, want nil", uris[i], errs[i])
+		} else if !errors.Is(errs[i], want) {
+			t.Errorf("Validate(%q) = %v, want %v", uris[i], errs[i], want)
+		}
+	}
+	if n := cv.gets.Load(); n != 0 {
+		t.Errorf("Expected no fetches, got %d", n)
+	}
+}
+
+func TestResolverResolveOr(t *testing.T) {
+	ctx := context.Background()
+	r := NewResolver()
+	r.Register("mem", memory.NewWithSecrets(map[string]string{"port": "5432"}))
+	r.Register("locked", &failingVault{Vault: memory.New(), err: vault.ErrAccessDenied})
+
+	if got, err := r.ResolveOr(ctx, "mem://port", "3306"); err != nil || got != "5432" {
+		t.Errorf("Expected present value '5432', got %q, %v", got, err)
+	}
+	if got, err := r.ResolveOr(ctx, "mem://missing", "3306"); err != nil || got != "3306" {
+		t.Errorf("Expected fallback '3306', got %q, %v", got, err)
+	}
+	if _, err := r.ResolveOr(ctx, "locked://port", "3306"); !errors.Is(err, vault.ErrAccessDenied) {
+		t.Errorf("Expected ErrAccessDenied, got %v", err)
+	}
+	if _, err := r.ResolveOr(ctx, "nope://port", "3306"); !errors.Is(err, ErrProviderNotRegistered) {
+		t.Errorf("Expected ErrProviderNotRegistered, got %v", err)
+	}
+}
+
+func TestResolverFieldConvention(t *testing.T) {
+	ctx := context.Background()
+	newVault := func() vault.Vault {
+		mem := memory.New()
+		for path, secret := range map[string]*vault.Secret{
+			"Private/GitHub":          {Value: "token", Fields: map[string]string{"username": "octocat", "password": "hunter2"}},
+			"Private/GitHub/password": {Value: "a secret named like a field"},
+		} {
+			if err := mem.Set(ctx, path, secret); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return mem
+	}
+	r := NewResolver()
+	r.Register("mem", newVault())
+	r.Register("op", newVault())
+	r.SetFieldConvention("op", FieldFromLastSegment)
+
+	tests := map[string]string{
+		// Fragment convention: the whole path is the secret
+		"mem://Private/GitHub#username": "octocat",
+		"mem://Private/GitHub/password": "a secret named like a field",
+
+		// Last segment convention: the last segment is the field
+		"op://Private/GitHub/username": "octocat",
+		"op://Private/GitHub/password": "hunter2",
+		"op://Private/GitHub/missing":  "",
+		"op://Private/GitHub#password": "hunter2",
+	}
+	for uri, want := range tests {
+		got, err := r.Resolve(ctx, uri)
+		if err != nil {
+			t.Errorf("Resolve(%s) failed: %v", uri, err)
+		} else if got != want {
+			t.Errorf("Resolve(%s) = %q, want %q", uri, got, want)
+		}
+	}
+
+	// The secret path leaves out the field
+	if _, err := r.Resolve(ctx, "op://Private/username"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound for op://Private/username, got %v", err)
+	}
+	r.SetFieldConvention("op", FieldFromFragment)
+	if got, err := r.Resolve(ctx, "op://Private/GitHub/password"); err != nil || got != "a secret named like a field" {
+		t.Errorf("Expected the fragment convention to be restored, got %q, %v", got, err)
+	}
+}
+
+func TestResolverResolveDeep(t *testing.T) {
+	ctx := context.Background()
+	r := NewResolver()
+	r.Register("mem", memory.NewWithSecrets(map[string]string{
+		"prod/db":     "other://db#password",
+		"staging/db":  "mem://prod/db",
+		"dev/db":      "mem://staging/db",
+		"website":     "https://example.com",
+		"loop/a":      "mem://loop/b",
+		"loop/b":      "mem://loop/a",
+		"self":        "mem://self",
+		"dangling":    "mem://missing",
+		"plain/value": "literal",
+	}))
+	other := memory.New()
+	if err := other.Set(ctx, "db", &vault.Secret{Value: "unused", Fields: map[string]string{"password": "hunter2"}}); err != nil {
+		t.Fatal(err)
+	}
+	r.Register("other", other)
+
+	tests := map[string]string{
+		"mem://plain/value": "literal",
+		"mem://prod/db":     "hunter2",
+		"mem://dev/db":      "hunter2",
+		"mem://website":     "https://example.com",
+	}
+	for uri, want := range tests {
+		got, err := r.ResolveDeep(ctx, uri)
+		if err != nil {
+			t.Errorf("ResolveDeep(%s) failed: %v", uri, err)
+		} else if got != want {
+			t.Errorf("ResolveDeep(%s) = %q, want %q", uri, got, want)
+		}
+	}
+	if got, err := r.Resolve(ctx, "mem://dev/db"); err != nil || got != "mem://staging/db" {
+		t.Errorf("Expected Resolve not to follow references, got %q, %v", got, err)
+	}
+
+	for _, uri := range []string{"mem://loop/a", "mem://self"} {
+		if _, err := r.ResolveDeep(ctx, uri); !errors.Is(err, ErrReferenceCycle) {
+			t.Errorf("ResolveDeep(%s): expected ErrReferenceCycle, got %v", uri, err)
+		}
+	}
+	if _, err := r.ResolveDeep(ctx, "mem://dangling"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound for a dangling reference, got %v", err)
+	}
+
+	// A chain one longer than MaxResolveDepth
+	chain := memory.New()
+	for i := 0; i <= MaxResolveDepth; i++ {
+		if err := chain.Set(ctx, fmt.Sprint(i), &vault.Secret{Value: fmt.Sprintf("chain://%d", i+1)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := chain.Set(ctx, fmt.Sprint(MaxResolveDepth+1), &vault.Secret{Value: "end"}); err != nil {
+		t.Fatal(err)
+	}
+	r.Register("chain", chain)
+	if got, err := r.ResolveDeep(ctx, "chain://1"); err != nil || got != "end" {
+		t.Errorf("Expected a chain of MaxResolveDepth references to resolve, got %q, %v", got, err)
+	}
+	if _, err := r.ResolveDeep(ctx, "chain://0"); !errors.Is(err, ErrReferenceDepth) {
+		t.Errorf("Expected ErrReferenceDepth, got %v", err)
+	}
+}
@@ -17,6 +17,9 @@ type BatchVault = vault.BatchVault
 // Secret represents a stored secret with its value and metadata.
 type Secret = vault.Secret
 
+// SecretInfo describes a secret without its value or fields.
+type SecretInfo = vault.SecretInfo
+
 // Metadata contains additional information about a secret.
 type Metadata = vault.Metadata
 
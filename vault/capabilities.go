@@ -0,0 +1,70 @@
+package vault
+
+// Capability names accepted by Supports. They match the JSON field names of
+// Capabilities.
+const (
+	CapRead       = "read"
+	CapWrite      = "write"
+	CapDelete     = "delete"
+	CapList       = "list"
+	CapVersioning = "versioning"
+	CapRotation   = "rotation"
+	CapBinary     = "binary"
+	CapMultiField = "multiField"
+	CapBatch      = "batch"
+	CapWatch      = "watch"
+)
+
+// AsExtended returns v as an ExtendedVault if it implements it.
+func AsExtended(v Vault) (ExtendedVault, bool) {
+	ev, ok := v.(ExtendedVault)
+	return ev, ok
+}
+
+// AsBatch returns v as a BatchVault if it implements it.
+func AsBatch(v Vault) (BatchVault, bool) {
+	bv, ok := v.(BatchVault)
+	return bv, ok
+}
+
+// AsWatchable returns v as a WatchableVault if it implements it.
+func AsWatchable(v Vault) (WatchableVault, bool) {
+	wv, ok := v.(WatchableVault)
+	return wv, ok
+}
+
+// Supports reports whether v supports the named capability (see CapRead
+// and the other Cap constants). Capabilities backed by an optional
+// interface, such as CapBatch, also require v to implement it, so a
+// provider that claims a capability it can't provide is not trusted.
+// Unknown names are not supported.
+func Supports(v Vault, capability string) bool {
+	caps := v.Capabilities()
+	switch capability {
+	case CapRead:
+		return caps.Read
+	case CapWrite:
+		return caps.Write
+	case CapDelete:
+		return caps.Delete
+	case CapList:
+		return caps.List
+	case CapBinary:
+		return caps.Binary
+	case CapMultiField:
+		return caps.MultiField
+	case CapVersioning:
+		_, ok := AsExtended(v)
+		return caps.Versioning && ok
+	case CapRotation:
+		_, ok := AsExtended(v)
+		return caps.Rotation && ok
+	case CapBatch:
+		_, ok := AsBatch(v)
+		return caps.Batch && ok
+	case CapWatch:
+		_, ok := AsWatchable(v)
+		return caps.Watch && ok
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package vault
+
+import (
+	"context"
+	"testing"
+)
+
+// stubVault is a Vault with fixed capabilities.
+type stubVault struct {
+	caps Capabilities
+}
+
+func (v *stubVault) Get(context.Context, string) (*Secret, error)   { return nil, ErrSecretNotFound }
+func (v *stubVault) Set(context.Context, string, *Secret) error     { return nil }
+func (v *stubVault) Delete(context.Context, string) error           { return nil }
+func (v *stubVault) Exists(context.Context, string) (bool, error)   { return false, nil }
+func (v *stubVault) List(context.Context, string) ([]string, error) { return nil, nil }
+func (v *stubVault) Name() string                                   { return "stub" }
+func (v *stubVault) Capabilities() Capabilities                     { return v.caps }
+func (v *stubVault) Close() error                                   { return nil }
+
+// batchVault is a stubVault that implements BatchVault.
+type batchVault struct {
+	stubVault
+}
+
+func (v *batchVault) GetBatch(context.Context, []string) (map[string]*Secret, error) {
+	return nil, nil
+}
+func (v *batchVault) SetBatch(context.Context, map[string]*Secret) error { return nil }
+func (v *batchVault) DeleteBatch(context.Context, []string) error        { return nil }
+
+func TestAsBatch(t *testing.T) {
+	bv := &batchVault{stubVault{caps: Capabilities{Read: true, Batch: true}}}
+	if got, ok := AsBatch(bv); !ok || got != bv {
+		t.Errorf("AsBatch(batchVault) = %v, %v; want the vault, true", got, ok)
+	}
+	if !Supports(bv, CapBatch) || !Supports(bv, CapRead) {
+		t.Error("Expected batchVault to support batch and read")
+	}
+
+	if _, ok := AsExtended(bv); ok {
+		t.Error("Expected batchVault not to be an ExtendedVault")
+	}
+	if _, ok := AsWatchable(bv); ok {
+		t.Error("Expected batchVault not to be a WatchableVault")
+	}
+
+	plain := &stubVault{caps: Capabilities{Read: true}}
+	if got, ok := AsBatch(plain); ok || got != nil {
+		t.Errorf("AsBatch(stubVault) = %v, %v; want nil, false", got, ok)
+	}
+	if Supports(plain, CapBatch) || Supports(plain, CapWrite) {
+		t.Error("Expected stubVault to support neither batch nor write")
+	}
+}
+
+func TestSupportsCrossChecksInterfaces(t *testing.T) {
+	// Claiming a capability without implementing its interface
+	v := &stubVault{caps: Capabilities{Batch: true, Watch: true, Versioning: true, Rotation: true}}
+	for _, c := range []string{CapBatch, CapWatch, CapVersioning, CapRotation} {
+		if Supports(v, c) {
+			t.Errorf("Expected %s not to be supported without its interface", c)
+		}
+	}
+
+	// Implementing the interface without claiming the capability
+	if Supports(&batchVault{}, CapBatch) {
+		t.Error("Expected batch not to be supported when Capabilities.Batch is false")
+	}
+
+	if Supports(v, "teleport") {
+		t.Error("Expected unknown capability not to be supported")
+	}
+}
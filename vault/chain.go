@@ -0,0 +1,24 @@
+package vault
+
+// Middleware wraps a Vault to add behavior such as caching, auditing or
+// retries. The returned Vault must close the one it wraps when closed, so
+// that closing a chain closes its base.
+type Middleware func(Vault) Vault
+
+// Chain wraps base in each middleware in turn, so the first middleware is
+// the innermost and the last one sees every call first:
+//
+//	v := vault.Chain(remote,
+//	    cache.Middleware(time.Minute), // hits never reach remote
+//	    audit.Middleware(sink),        // sees every call, including cache hits
+//	    resilient.Middleware(policy),  // retries the whole stack
+//	)
+//
+// Closing the result closes every layer down to base.
+func Chain(base Vault, middlewares ...Middleware) Vault {
+	v := base
+	for _, mw := range middlewares {
+		v = mw(v)
+	}
+	return v
+}
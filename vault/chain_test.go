@@ -0,0 +1,91 @@
+package vault_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/agentplexus/omnivault/providers/audit"
+	"github.com/agentplexus/omnivault/providers/cache"
+	"github.com/agentplexus/omnivault/providers/memory"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+// readAudit returns a sink recording the paths of Get events, and the
+// recorded paths.
+func readAudit() (func(audit.Event), *[]string) {
+	var reads []string
+	return func(e audit.Event) {
+		if e.Op == audit.OpGet {
+			reads = append(reads, e.Path)
+		}
+	}, &reads
+}
+
+func TestChainOrder(t *testing.T) {
+	ctx := context.Background()
+
+	// Audit outside the cache sees every read, including cache hits
+	outerSink, outerReads := readAudit()
+	outer := vault.Chain(memory.NewWithSecrets(map[string]string{"api-key": "a"}),
+		cache.Middleware(time.Minute),
+		audit.Middleware(outerSink),
+	)
+
+	// Audit inside the cache only sees the reads that miss it
+	innerSink, innerReads := readAudit()
+	inner := vault.Chain(memory.NewWithSecrets(map[string]string{"api-key": "a"}),
+		audit.Middleware(innerSink),
+		cache.Middleware(time.Minute),
+	)
+
+	for range 3 {
+		for _, v := range []vault.Vault{outer, inner} {
+			if secret, err := v.Get(ctx, "api-key"); err != nil || secret.Value != "a" {
+				t.Fatalf("Get = %v, %v", secret, err)
+			}
+		}
+	}
+
+	if want := []string{"api-key", "api-key", "api-key"}; !reflect.DeepEqual(*outerReads, want) {
+		t.Errorf("Outer audit reads = %v, want %v", *outerReads, want)
+	}
+	if want := []string{"api-key"}; !reflect.DeepEqual(*innerReads, want) {
+		t.Errorf("Inner audit reads = %v, want %v", *innerReads, want)
+	}
+}
+
+func TestChainWrapsInOrder(t *testing.T) {
+	var order []string
+	tag := func(name string) vault.Middleware {
+		return func(inner vault.Vault) vault.Vault {
+			order = append(order, name)
+			return inner
+		}
+	}
+
+	vault.Chain(memory.New(), tag("first"), tag("second"), tag("third"))
+	if want := []string{"first", "second", "third"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Middlewares applied in order %v, want %v", order, want)
+	}
+}
+
+func TestChainCloseReachesBase(t *testing.T) {
+	base := memory.New()
+	v := vault.Chain(base, cache.Middleware(time.Minute), audit.Middleware(nil))
+
+	if err := v.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !base.Closed() {
+		t.Error("Expected Close to reach the base vault")
+	}
+}
+
+func TestChainWithoutMiddlewares(t *testing.T) {
+	base := memory.New()
+	if v := vault.Chain(base); v != vault.Vault(base) {
+		t.Errorf("Expected Chain without middlewares to return the base vault, got %v", v)
+	}
+}
@@ -0,0 +1,94 @@
+package vault
+
+import (
+	"bytes"
+	"sort"
+)
+
+// DiffResult describes the differences between two sets of secrets.
+type DiffResult struct {
+	// Added lists paths present only in the second set.
+	Added []string `json:"added,omitempty"`
+
+	// Removed lists paths present only in the first set.
+	Removed []string `json:"removed,omitempty"`
+
+	// Changed lists paths present in both sets whose value or fields differ.
+	Changed []SecretChange `json:"changed,omitempty"`
+}
+
+// SecretChange describes how a secret differs between two sets.
+type SecretChange struct {
+	Path string `json:"path"`
+
+	// Value is true if the primary value differs.
+	Value bool `json:"value,omitempty"`
+
+	// Fields names the fields that were added, removed, or changed.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Empty returns true if there are no differences.
+func (d DiffResult) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff compares two sets of secrets keyed by path and reports the paths
+// added in b, removed from a, and changed between them. Only values and
+// fields are compared; metadata such as timestamps and tags is ignored.
+// All lists are sorted by path.
+func Diff(a, b map[string]*Secret) DiffResult {
+	var result DiffResult
+
+	for path, before := range a {
+		after, ok := b[path]
+		if !ok {
+			result.Removed = append(result.Removed, path)
+			continue
+		}
+		if change, changed := diffSecret(path, before, after); changed {
+			result.Changed = append(result.Changed, change)
+		}
+	}
+	for path := range b {
+		if _, ok := a[path]; !ok {
+			result.Added = append(result.Added, path)
+		}
+	}
+
+	sort.Strings(result.Added)
+	sort.Strings(result.Removed)
+	sort.Slice(result.Changed, func(i, j int) bool {
+		return result.Changed[i].Path < result.Changed[j].Path
+	})
+	return result
+}
+
+// diffSecret compares the value and fields of two secrets.
+func diffSecret(path string, a, b *Secret) (SecretChange, bool) {
+	if a == nil {
+		a = &Secret{}
+	}
+	if b == nil {
+		b = &Secret{}
+	}
+
+	change := SecretChange{
+		Path:  path,
+		Value: a.Value != b.Value || !bytes.Equal(a.ValueBytes, b.ValueBytes),
+	}
+
+	for name, v := range a.Fields {
+		if w, ok := b.Fields[name]; !ok || v != w {
+			change.Fields = append(change.Fields, name)
+		}
+	}
+	for name := range b.Fields {
+		if _, ok := a.Fields[name]; !ok {
+			change.Fields = append(change.Fields, name)
+		}
+	}
+	sort.Strings(change.Fields)
+
+	return change, change.Value || len(change.Fields) > 0
+}
@@ -0,0 +1,55 @@
+package vault
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := map[string]*Secret{
+		"same":    {Value: "x", Fields: map[string]string{"user": "admin"}},
+		"removed": {Value: "gone"},
+		"value":   {Value: "old"},
+		"fields":  {Fields: map[string]string{"user": "admin", "host": "db1", "old": "1"}},
+	}
+	b := map[string]*Secret{
+		"same":   {Value: "x", Fields: map[string]string{"user": "admin"}},
+		"added":  {Value: "new"},
+		"value":  {Value: "new"},
+		"fields": {Fields: map[string]string{"user": "admin", "host": "db2", "new": "1"}},
+	}
+
+	got := Diff(a, b)
+	want := DiffResult{
+		Added:   []string{"added"},
+		Removed: []string{"removed"},
+		Changed: []SecretChange{
+			{Path: "fields", Fields: []string{"host", "new", "old"}},
+			{Path: "value", Value: true},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %+v, want %+v", got, want)
+	}
+	if got.Empty() {
+		t.Error("Expected non-empty diff")
+	}
+}
+
+func TestDiffIdentical(t *testing.T) {
+	secrets := map[string]*Secret{
+		"a": {Value: "x", Fields: map[string]string{"k": "v"}},
+		"b": {ValueBytes: []byte{0, 1, 2}},
+	}
+	copied := map[string]*Secret{
+		"a": {Value: "x", Fields: map[string]string{"k": "v"}, Metadata: Metadata{Tags: map[string]string{"env": "prod"}}},
+		"b": {ValueBytes: []byte{0, 1, 2}},
+	}
+
+	if d := Diff(secrets, copied); !d.Empty() {
+		t.Errorf("Expected empty diff for identical secrets, got %+v", d)
+	}
+	if d := Diff(nil, nil); !d.Empty() {
+		t.Errorf("Expected empty diff for empty sets, got %+v", d)
+	}
+}
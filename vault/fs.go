@@ -0,0 +1,218 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FS returns a read-only file system view of v. Each secret is a file
+// holding its value, and each path segment before the last is a directory,
+// so Open("database/password") reads the secret at "database/password" and
+// ReadDir("database") lists the secrets and directories under "database/".
+//
+// A path that is both a secret and a prefix of other secrets is shown as a
+// file. Secrets whose paths are not valid fs paths (see fs.ValidPath) are
+// not visible.
+func FS(v Vault) fs.FS {
+	return &vaultFS{v: v}
+}
+
+// vaultFS implements fs.FS and fs.ReadDirFS over a Vault.
+type vaultFS struct {
+	v Vault
+}
+
+// Open opens the secret or directory at name.
+func (f *vaultFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name != "." {
+		secret, err := f.v.Get(context.Background(), name)
+		if err == nil {
+			return newSecretFile(name, secret), nil
+		}
+		if !errors.Is(err, ErrSecretNotFound) {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+	}
+
+	entries, err := f.readDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &dirFile{info: dirInfo(name), entries: entries}, nil
+}
+
+// ReadDir lists the directory at name, sorted by name.
+func (f *vaultFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	entries, err := f.readDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	return entries, nil
+}
+
+// readDir returns the entries under name. A directory with no secrets does
+// not exist.
+func (f *vaultFS) readDir(name string) ([]fs.DirEntry, error) {
+	prefix := ""
+	if name != "." {
+		prefix = name + "/"
+	}
+
+	paths, err := f.v.List(context.Background(), prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// A child is a directory if any path continues past it, unless the
+	// child is also a secret
+	children := make(map[string]bool)
+	for _, p := range paths {
+		rel, ok := strings.CutPrefix(p, prefix)
+		if !ok || !fs.ValidPath(p) {
+			continue
+		}
+		child, rest, isDir := strings.Cut(rel, "/")
+		if child == "" || (isDir && rest == "") {
+			continue
+		}
+		if seenDir, seen := children[child]; !seen || (seenDir && !isDir) {
+			children[child] = isDir
+		}
+	}
+
+	if len(children) == 0 && name != "." {
+		return nil, fs.ErrNotExist
+	}
+
+	entries := make([]fs.DirEntry, 0, len(children))
+	for child, isDir := range children {
+		if isDir {
+			entries = append(entries, fs.FileInfoToDirEntry(dirInfo(child)))
+		} else {
+			entries = append(entries, &secretEntry{fsys: f, name: child, path: prefix + child})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// fileInfo describes a secret or directory.
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (i *fileInfo) Name() string       { return i.name }
+func (i *fileInfo) Size() int64        { return i.size }
+func (i *fileInfo) ModTime() time.Time { return i.modTime }
+func (i *fileInfo) IsDir() bool        { return i.dir }
+func (i *fileInfo) Sys() any           { return nil }
+
+func (i *fileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+// dirInfo returns the file info of the directory at name.
+func dirInfo(name string) *fileInfo {
+	return &fileInfo{name: path.Base(name), dir: true}
+}
+
+// secretInfo returns the file info of a secret.
+func secretInfo(name string, secret *Secret) *fileInfo {
+	info := &fileInfo{name: path.Base(name), size: int64(len(secret.Bytes()))}
+	if secret.Metadata.ModifiedAt != nil {
+		info.modTime = secret.Metadata.ModifiedAt.Time
+	}
+	return info
+}
+
+// secretEntry is the directory entry of a secret. Its info is fetched on
+// demand, so listing a directory does not read every secret.
+type secretEntry struct {
+	fsys *vaultFS
+	name string
+	path string
+}
+
+func (e *secretEntry) Name() string      { return e.name }
+func (e *secretEntry) IsDir() bool       { return false }
+func (e *secretEntry) Type() fs.FileMode { return 0 }
+
+func (e *secretEntry) Info() (fs.FileInfo, error) {
+	secret, err := e.fsys.v.Get(context.Background(), e.path)
+	if err != nil {
+		if errors.Is(err, ErrSecretNotFound) {
+			err = fs.ErrNotExist
+		}
+		return nil, &fs.PathError{Op: "stat", Path: e.path, Err: err}
+	}
+	return secretInfo(e.path, secret), nil
+}
+
+// secretFile is an open secret.
+type secretFile struct {
+	info *fileInfo
+	r    *bytes.Reader
+}
+
+func newSecretFile(name string, secret *Secret) *secretFile {
+	return &secretFile{info: secretInfo(name, secret), r: bytes.NewReader(secret.Bytes())}
+}
+
+func (f *secretFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *secretFile) Read(b []byte) (int, error) { return f.r.Read(b) }
+func (f *secretFile) Close() error               { return nil }
+
+// dirFile is an open directory.
+type dirFile struct {
+	info    *fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dirFile) Close() error               { return nil }
+
+func (d *dirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *dirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+	return remaining[:n], nil
+}
+
+// Ensure vaultFS implements fs.ReadDirFS.
+var _ fs.ReadDirFS = (*vaultFS)(nil)
@@ -0,0 +1,89 @@
+package vault_test
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/agentplexus/omnivault/providers/memory"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+func newTestFS() fs.FS {
+	return vault.FS(memory.NewWithSecrets(map[string]string{
+		"database/password":    "hunter2",
+		"database/replica/url": "postgres://replica",
+		"api-key":              "abc",
+	}))
+}
+
+func TestFSReadFile(t *testing.T) {
+	fsys := newTestFS()
+
+	data, err := fs.ReadFile(fsys, "database/password")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(data) != "hunter2" {
+		t.Errorf("ReadFile = %q, want %q", data, "hunter2")
+	}
+
+	if _, err := fs.ReadFile(fsys, "database/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist, got %v", err)
+	}
+	if _, err := fsys.Open("/database/password"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("Expected fs.ErrInvalid for an absolute path, got %v", err)
+	}
+}
+
+func TestFSReadDir(t *testing.T) {
+	fsys := newTestFS()
+
+	entries, err := fs.ReadDir(fsys, "database")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"password", "replica"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ReadDir = %v, want %v", names, want)
+	}
+	if entries[0].IsDir() || !entries[1].IsDir() {
+		t.Error("Expected password to be a file and replica a directory")
+	}
+
+	if _, err := fs.ReadDir(fsys, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestFSWalkDir(t *testing.T) {
+	fsys := newTestFS()
+
+	var files []string
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkDir failed: %v", err)
+	}
+	if want := []string{"api-key", "database/password", "database/replica/url"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("WalkDir files = %v, want %v", files, want)
+	}
+}
+
+func TestFSConformance(t *testing.T) {
+	if err := fstest.TestFS(newTestFS(), "api-key", "database/password", "database/replica/url"); err != nil {
+		t.Fatal(err)
+	}
+}
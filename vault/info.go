@@ -0,0 +1,77 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// SecretInfo describes a secret without its value or fields.
+type SecretInfo struct {
+	// Path is the path of the secret.
+	Path string `json:"path"`
+
+	// HasValue reports whether the secret has a non-empty value.
+	HasValue bool `json:"hasValue"`
+
+	// HasFields reports whether the secret has any fields.
+	HasFields bool `json:"hasFields"`
+
+	// Metadata is the secret's metadata.
+	Metadata Metadata `json:"metadata"`
+}
+
+// InfoOf returns the SecretInfo of secret, stored at path.
+func InfoOf(path string, secret *Secret) SecretInfo {
+	return SecretInfo{
+		Path:      path,
+		HasValue:  secret.Value != "" || len(secret.ValueBytes) > 0,
+		HasFields: len(secret.Fields) > 0,
+		Metadata:  secret.Clone().Metadata,
+	}
+}
+
+// MetadataLister is implemented by providers that can list secrets with
+// their metadata without fetching each value, e.g. from file attributes.
+type MetadataLister interface {
+	Vault
+
+	// ListInfo returns the SecretInfo of every secret whose path starts
+	// with prefix, sorted by path.
+	ListInfo(ctx context.Context, prefix string) ([]SecretInfo, error)
+}
+
+// AsMetadataLister returns v as a MetadataLister if it implements it.
+func AsMetadataLister(v Vault) (MetadataLister, bool) {
+	ml, ok := v.(MetadataLister)
+	return ml, ok
+}
+
+// ListInfo returns the SecretInfo of every secret in v whose path starts
+// with prefix, sorted by path. It uses v's ListInfo if v is a
+// MetadataLister, and otherwise calls List and then Get for each path,
+// skipping secrets deleted in between.
+func ListInfo(ctx context.Context, v Vault, prefix string) ([]SecretInfo, error) {
+	if ml, ok := AsMetadataLister(v); ok {
+		return ml.ListInfo(ctx, prefix)
+	}
+
+	paths, err := v.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	infos := make([]SecretInfo, 0, len(paths))
+	for _, path := range paths {
+		secret, err := v.Get(ctx, path)
+		if err != nil {
+			if errors.Is(err, ErrSecretNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		infos = append(infos, InfoOf(path, secret))
+	}
+	return infos, nil
+}
@@ -0,0 +1,50 @@
+package vault_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/agentplexus/omnivault/providers/memory"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+func TestListInfoFallback(t *testing.T) {
+	inner := memory.New()
+	ctx := context.Background()
+	secrets := map[string]*vault.Secret{
+		"myapp/db":    {Fields: map[string]string{"user": "admin"}, Metadata: vault.Metadata{Tags: map[string]string{"env": "prod"}}},
+		"myapp/token": {Value: "t0k"},
+		"other/x":     {Value: "x"},
+	}
+	for path, secret := range secrets {
+		if err := inner.Set(ctx, path, secret); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	// Sub is not a MetadataLister, so ListInfo falls back to List and Get
+	sub := vault.Sub(inner, "myapp")
+	if _, ok := vault.AsMetadataLister(sub); ok {
+		t.Fatal("Expected Sub not to implement MetadataLister")
+	}
+	got, err := vault.ListInfo(ctx, sub, "")
+	if err != nil {
+		t.Fatalf("ListInfo failed: %v", err)
+	}
+
+	want, err := inner.ListInfo(ctx, "myapp/")
+	if err != nil {
+		t.Fatalf("ListInfo failed: %v", err)
+	}
+	for i := range want {
+		want[i].Path = want[i].Path[len("myapp/"):]
+		want[i].Metadata.Path = want[i].Path
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListInfo = %+v, want %+v", got, want)
+	}
+	if len(got) != 2 || !got[0].HasFields || got[0].HasValue || !got[1].HasValue {
+		t.Errorf("Unexpected infos: %+v", got)
+	}
+}
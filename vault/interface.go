@@ -85,6 +85,34 @@ type BatchVault interface {
 	DeleteBatch(ctx context.Context, paths []string) error
 }
 
+// WatchableVault provides change notifications for providers that support
+// them (see Capabilities.Watch).
+type WatchableVault interface {
+	Vault
+
+	// Watch reports changes to secrets whose paths start with prefix until
+	// ctx is done, then closes the channel.
+	Watch(ctx context.Context, prefix string) (<-chan WatchEvent, error)
+}
+
+// WatchEventType is the kind of change reported by Watch.
+type WatchEventType string
+
+// Watch event types.
+const (
+	// WatchEventSet means a secret was created or changed.
+	WatchEventSet WatchEventType = "set"
+
+	// WatchEventDelete means a secret was deleted.
+	WatchEventDelete WatchEventType = "delete"
+)
+
+// WatchEvent is a change to a secret reported by Watch.
+type WatchEvent struct {
+	Type WatchEventType
+	Path string
+}
+
 // Version represents a version of a secret.
 type Version struct {
 	ID        string
@@ -0,0 +1,156 @@
+package vault
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Warning is a likely mistake found in a secret by Lint.
+type Warning struct {
+	// Rule is the name of the rule that found the mistake.
+	Rule string `json:"rule"`
+
+	// Field is the field holding the mistake, or empty for the value.
+	Field string `json:"field,omitempty"`
+
+	// Message describes the mistake.
+	Message string `json:"message"`
+}
+
+// String returns the warning as "field: message", or just the message for
+// the value.
+func (w Warning) String() string {
+	if w.Field == "" {
+		return w.Message
+	}
+	return w.Field + ": " + w.Message
+}
+
+// LintRule checks a secret for one kind of mistake.
+type LintRule struct {
+	// Name identifies the rule in warnings, e.g. "whitespace".
+	Name string
+
+	// Check returns a message for each problem found, keyed by the field
+	// holding it ("" for the value).
+	Check func(secret *Secret) map[string]string
+}
+
+// LintRules are the rules applied by Lint, in order. Programs can append
+// their own rules.
+var LintRules = []LintRule{
+	{Name: "whitespace", Check: lintWhitespace},
+	{Name: "placeholder", Check: lintPlaceholder},
+	{Name: "private-key", Check: lintPrivateKey},
+}
+
+// Lint checks a secret for common copy-paste mistakes, such as trailing
+// whitespace or a placeholder value, and returns a warning for each. The
+// warnings are advisory: a secret with warnings may still be correct.
+func Lint(secret *Secret) []Warning {
+	var warnings []Warning
+	for _, rule := range LintRules {
+		found := rule.Check(secret)
+		fields := make([]string, 0, len(found))
+		for field := range found {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			warnings = append(warnings, Warning{Rule: rule.Name, Field: field, Message: found[field]})
+		}
+	}
+	return warnings
+}
+
+// lintValues calls check with the value and each field value, and collects
+// the messages it returns.
+func lintValues(secret *Secret, check func(value string) string) map[string]string {
+	found := make(map[string]string)
+	if msg := check(secret.String()); msg != "" {
+		found[""] = msg
+	}
+	for field, value := range secret.Fields {
+		if msg := check(value); msg != "" {
+			found[field] = msg
+		}
+	}
+	return found
+}
+
+// lintWhitespace reports values with leading or trailing whitespace, which
+// usually comes from copying a line or a terminal selection. PEM blocks
+// end with a newline and are not reported for it.
+func lintWhitespace(secret *Secret) map[string]string {
+	return lintValues(secret, func(value string) string {
+		if value == "" {
+			return ""
+		}
+		if strings.HasPrefix(value, "-----BEGIN ") {
+			value = strings.TrimSuffix(value, "\n")
+		}
+		switch {
+		case strings.TrimRight(value, " \t\r\n") != value:
+			return "has trailing whitespace"
+		case strings.TrimLeft(value, " \t\r\n") != value:
+			return "has leading whitespace"
+		}
+		return ""
+	})
+}
+
+// placeholders are values left in place of a real secret, compared in
+// lower case.
+var placeholders = map[string]bool{
+	"changeme": true, "change-me": true, "change_me": true, "change me": true,
+	"replaceme": true, "replace-me": true, "replace_me": true,
+	"todo": true, "tbd": true, "fixme": true, "placeholder": true,
+	"example": true, "dummy": true, "null": true, "none": true, "undefined": true,
+	"secret": true, "password": true, "xxx": true,
+}
+
+// placeholderPattern matches template syntax and "your-api-key" style
+// instructions.
+var placeholderPattern = regexp.MustCompile(`(?i)^(<[^<>]+>|\$\{[^}]+\}|\{\{[^}]+\}\}|x{3,}|\*{3,}|your[-_ ].+)$`)
+
+// lintPlaceholder reports values that look like a placeholder.
+func lintPlaceholder(secret *Secret) map[string]string {
+	return lintValues(secret, func(value string) string {
+		v := strings.TrimSpace(value)
+		if placeholders[strings.ToLower(v)] || placeholderPattern.MatchString(v) {
+			return fmt.Sprintf("%q looks like a placeholder", v)
+		}
+		return ""
+	})
+}
+
+// privateKeyFields are field names that hold a key, so a key in the value
+// is the secret's intended content.
+var privateKeyFields = []string{"private_key", "privatekey", "private-key", "key", "public_key", "publickey", "public-key"}
+
+// lintPrivateKey reports a private key stored in the value of a secret
+// with no key field, where it is easily mistaken for a password, and PEM
+// blocks that were cut off while copying.
+func lintPrivateKey(secret *Secret) map[string]string {
+	found := lintValues(secret, func(value string) string {
+		if strings.Contains(value, "-----BEGIN ") && !strings.Contains(value, "-----END ") {
+			return "PEM block has no END line; was it cut off?"
+		}
+		return ""
+	})
+
+	if _, ok := found[""]; ok || !strings.Contains(secret.String(), "PRIVATE KEY-----") {
+		return found
+	}
+	for field := range secret.Fields {
+		for _, name := range privateKeyFields {
+			if strings.EqualFold(field, name) {
+				return found
+			}
+		}
+	}
+	found[""] = "value holds a private key; store it in a private_key field"
+	return found
+}
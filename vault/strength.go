@@ -0,0 +1,352 @@
+package vault
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// StrengthResult describes the estimated strength of a password.
+type StrengthResult struct {
+	// Score ranges from 0 (too guessable) to 4 (very unguessable).
+	Score int `json:"score"`
+
+	// Guesses is the estimated number of guesses needed to crack the password.
+	Guesses float64 `json:"guesses"`
+
+	// Warnings explains the weaknesses found, if any.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Score thresholds, in log10 guesses, following zxcvbn.
+const (
+	strengthScore1 = 3  // 10^3 guesses
+	strengthScore2 = 6  // 10^6 guesses
+	strengthScore3 = 8  // 10^8 guesses
+	strengthScore4 = 10 // 10^10 guesses
+)
+
+// Warning messages reported by EstimateStrength.
+const (
+	warnCommon   = "this is a commonly used password"
+	warnRepeat   = "repeated characters like \"aaa\" are easy to guess"
+	warnSequence = "sequences like \"abc\" or \"1234\" are easy to guess"
+	warnKeyboard = "keyboard patterns like \"qwerty\" are easy to guess"
+	warnYear     = "years are easy to guess"
+	warnShort    = "use a longer password"
+)
+
+// commonPasswords lists frequently used passwords and words, most common first.
+// The position is used as the guess rank.
+var commonPasswords = []string{
+	"password", "123456", "qwerty", "letmein", "admin", "welcome", "monkey",
+	"dragon", "master", "login", "abc123", "princess", "football", "baseball",
+	"shadow", "sunshine", "iloveyou", "trustno1", "superman", "batman",
+	"hello", "secret", "freedom", "whatever", "starwars", "computer",
+	"michael", "jennifer", "charlie", "jordan", "hunter", "ranger", "buster",
+	"soccer", "hockey", "killer", "george", "andrew", "summer", "winter",
+	"spring", "autumn", "flower", "cookie", "pepper", "ginger", "orange",
+	"banana", "chocolate", "purple", "silver", "golden", "diamond", "tigger",
+	"pokemon", "naruto", "matrix", "mustang", "harley", "thunder", "love",
+	"god", "money", "access", "passw0rd", "changeme", "default", "test",
+	"guest", "root", "user", "vault", "omnivault", "mypassword", "pass",
+}
+
+// commonRank maps a common password to its 1-based rank.
+var commonRank = func() map[string]int {
+	m := make(map[string]int, len(commonPasswords))
+	for i, p := range commonPasswords {
+		m[p] = i + 1
+	}
+	return m
+}()
+
+// keyboardRows are adjacent key runs on a US keyboard.
+var keyboardRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]\\",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+	"1qaz2wsx3edc4rfv5tgb6yhn7ujm8ik9ol0p",
+}
+
+// leetSubstitutions maps common character substitutions back to letters.
+var leetSubstitutions = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '3': 'e', '6': 'g', '1': 'i', '!': 'i',
+	'0': 'o', '$': 's', '5': 's', '7': 't', '+': 't', '2': 'z',
+}
+
+// strengthMatch is a guessable pattern found in a password.
+type strengthMatch struct {
+	start, end int     // rune indexes, end exclusive
+	log10      float64 // log10 of guesses for this segment
+	warning    string
+}
+
+// EstimateStrength estimates how hard a password is to guess using a
+// zxcvbn-style heuristic. It finds common passwords (including simple
+// character substitutions), repeats, sequences, keyboard patterns, and years,
+// and charges brute force for everything else. The password is split into
+// the combination of patterns that minimizes the total guesses.
+//
+// It can be used both for master passwords and for scoring generated secrets.
+func EstimateStrength(password string) StrengthResult {
+	runes := []rune(password)
+	n := len(runes)
+	if n == 0 {
+		return StrengthResult{Score: 0, Guesses: 1, Warnings: []string{warnShort}}
+	}
+
+	matches := findStrengthMatches(runes)
+	charCost := math.Log10(float64(charsetSize(runes)))
+
+	// best[i] is the minimal log10 guesses for runes[:i]
+	best := make([]float64, n+1)
+	via := make([]*strengthMatch, n+1)
+	for i := 1; i <= n; i++ {
+		best[i] = best[i-1] + charCost
+		for j := range matches {
+			m := &matches[j]
+			if m.end == i && best[m.start]+m.log10 < best[i] {
+				best[i] = best[m.start] + m.log10
+				via[i] = m
+			}
+		}
+	}
+
+	// Collect warnings from the patterns on the optimal path
+	var warnings []string
+	seen := make(map[string]bool)
+	for i := n; i > 0; {
+		m := via[i]
+		if m == nil {
+			i--
+			continue
+		}
+		if !seen[m.warning] {
+			seen[m.warning] = true
+			warnings = append([]string{m.warning}, warnings...)
+		}
+		i = m.start
+	}
+
+	logGuesses := math.Min(best[n], 300)
+	score := strengthScore(logGuesses)
+	if score < 3 && n < 12 {
+		warnings = append(warnings, warnShort)
+	}
+
+	return StrengthResult{
+		Score:    score,
+		Guesses:  math.Pow(10, logGuesses),
+		Warnings: warnings,
+	}
+}
+
+// strengthScore converts log10 guesses into a 0-4 score.
+func strengthScore(logGuesses float64) int {
+	switch {
+	case logGuesses < strengthScore1:
+		return 0
+	case logGuesses < strengthScore2:
+		return 1
+	case logGuesses < strengthScore3:
+		return 2
+	case logGuesses < strengthScore4:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// charsetSize returns the brute-force alphabet size implied by the characters used.
+func charsetSize(runes []rune) int {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	size := 0
+	if lower {
+		size += 26
+	}
+	if upper {
+		size += 26
+	}
+	if digit {
+		size += 10
+	}
+	if symbol {
+		size += 33
+	}
+	if other {
+		size += 100
+	}
+	return size
+}
+
+// findStrengthMatches returns every guessable pattern in the password.
+func findStrengthMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	matches = append(matches, commonMatches(runes)...)
+	matches = append(matches, repeatMatches(runes)...)
+	matches = append(matches, sequenceMatches(runes)...)
+	matches = append(matches, keyboardMatches(runes)...)
+	matches = append(matches, yearMatches(runes)...)
+	return matches
+}
+
+// commonMatches finds common passwords, ignoring case and leet substitutions.
+func commonMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	for i := range runes {
+		for j := i + 3; j <= len(runes); j++ {
+			word := string(runes[i:j])
+			lower := strings.ToLower(word)
+
+			leet := false
+			rank, ok := commonRank[lower]
+			if !ok {
+				rank, ok = commonRank[unleet(lower)]
+				leet = true
+			}
+			if !ok {
+				continue
+			}
+
+			// Floor the guesses so repeated words still add up
+			guesses := math.Log10(math.Max(float64(rank), 10))
+			if lower != word {
+				guesses += math.Log10(2)
+			}
+			if leet {
+				guesses += math.Log10(2)
+			}
+			matches = append(matches, strengthMatch{start: i, end: j, log10: guesses, warning: warnCommon})
+		}
+	}
+	return matches
+}
+
+// unleet replaces common character substitutions with the letters they stand for.
+func unleet(s string) string {
+	return strings.Map(func(r rune) rune {
+		if sub, ok := leetSubstitutions[r]; ok {
+			return sub
+		}
+		return r
+	}, s)
+}
+
+// repeatMatches finds runs of three or more identical characters.
+func repeatMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	for i := 0; i < len(runes); {
+		j := i + 1
+		for j < len(runes) && runes[j] == runes[i] {
+			j++
+		}
+		if j-i >= 3 {
+			guesses := math.Log10(float64(charsetSize(runes[i:i+1]) * (j - i)))
+			matches = append(matches, strengthMatch{start: i, end: j, log10: guesses, warning: warnRepeat})
+		}
+		i = j
+	}
+	return matches
+}
+
+// sequenceMatches finds runs of three or more characters with a constant
+// step of one, such as "abc", "4321", or "xyz".
+func sequenceMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	for i := 0; i+2 < len(runes); {
+		delta := runes[i+1] - runes[i]
+		if delta != 1 && delta != -1 {
+			i++
+			continue
+		}
+		j := i + 2
+		for j < len(runes) && runes[j]-runes[j-1] == delta {
+			j++
+		}
+		if j-i >= 3 {
+			start := 26.0
+			switch runes[i] {
+			case 'a', 'A', 'z', 'Z', '0', '1', '9':
+				start = 4
+			}
+			if unicode.IsDigit(runes[i]) {
+				start = math.Min(start, 10)
+			}
+			guesses := math.Log10(start * float64(j-i))
+			if delta < 0 {
+				guesses += math.Log10(2)
+			}
+			matches = append(matches, strengthMatch{start: i, end: j, log10: guesses, warning: warnSequence})
+		}
+		i = j - 1
+	}
+	return matches
+}
+
+// keyboardMatches finds runs of four or more adjacent keys.
+func keyboardMatches(runes []rune) []strengthMatch {
+	lower := []rune(strings.ToLower(string(runes)))
+
+	var matches []strengthMatch
+	for i := range lower {
+		longest := 0
+		for j := i + 4; j <= len(lower); j++ {
+			segment := string(lower[i:j])
+			found := false
+			for _, row := range keyboardRows {
+				if strings.Contains(row, segment) || strings.Contains(reverseString(row), segment) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				break
+			}
+			longest = j - i
+		}
+		if longest > 0 {
+			guesses := math.Log10(float64(len(keyboardRows) * 10 * longest))
+			matches = append(matches, strengthMatch{start: i, end: i + longest, log10: guesses, warning: warnKeyboard})
+		}
+	}
+	return matches
+}
+
+// yearMatches finds four-digit years between 1900 and 2099.
+func yearMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	for i := 0; i+4 <= len(runes); i++ {
+		s := string(runes[i : i+4])
+		if (strings.HasPrefix(s, "19") || strings.HasPrefix(s, "20")) &&
+			unicode.IsDigit(runes[i+2]) && unicode.IsDigit(runes[i+3]) {
+			matches = append(matches, strengthMatch{start: i, end: i + 4, log10: math.Log10(200), warning: warnYear})
+		}
+	}
+	return matches
+}
+
+// reverseString returns s with its runes in reverse order.
+func reverseString(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
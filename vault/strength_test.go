@@ -0,0 +1,79 @@
+package vault
+
+import "testing"
+
+func TestEstimateStrengthWeak(t *testing.T) {
+	weak := []string{
+		"password",
+		"Password1",
+		"P@ssw0rd",
+		"123456789",
+		"qwerty123",
+		"aaaaaaaaaa",
+		"abcdefgh",
+		"letmein2024",
+		"iloveyou",
+	}
+	for _, password := range weak {
+		result := EstimateStrength(password)
+		if result.Score > 1 {
+			t.Errorf("EstimateStrength(%q) score = %d (guesses %.0f), want <= 1", password, result.Score, result.Guesses)
+		}
+		if len(result.Warnings) == 0 {
+			t.Errorf("EstimateStrength(%q) returned no warnings", password)
+		}
+	}
+}
+
+func TestEstimateStrengthStrong(t *testing.T) {
+	strong := []string{
+		"kT9#vQ2!mZx7&Lp4@Wn8",
+		"h7Rq2Lx9Vb4Nc8Mz3Kp6",
+		"correct-zebra-lantern-oxide-97",
+		"Xy8$Qw#ue3!Ba&72",
+	}
+	for _, password := range strong {
+		result := EstimateStrength(password)
+		if result.Score < 4 {
+			t.Errorf("EstimateStrength(%q) score = %d (guesses %.0f), want 4", password, result.Score, result.Guesses)
+		}
+	}
+}
+
+func TestEstimateStrengthWarnings(t *testing.T) {
+	tests := []struct {
+		password string
+		warning  string
+	}{
+		{"password", warnCommon},
+		{"zzzzzzzz", warnRepeat},
+		{"ab1234567", warnSequence},
+		{"asdfghjk", warnKeyboard},
+		{"x1987", warnYear},
+	}
+	for _, tt := range tests {
+		result := EstimateStrength(tt.password)
+		found := false
+		for _, w := range result.Warnings {
+			if w == tt.warning {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("EstimateStrength(%q) warnings = %v, want %q", tt.password, result.Warnings, tt.warning)
+		}
+	}
+}
+
+func TestEstimateStrengthMonotonic(t *testing.T) {
+	empty := EstimateStrength("")
+	if empty.Score != 0 {
+		t.Errorf("Expected score 0 for empty password, got %d", empty.Score)
+	}
+
+	short := EstimateStrength("x7Kq")
+	long := EstimateStrength("x7Kq9vLm2Rt8")
+	if long.Guesses <= short.Guesses {
+		t.Errorf("Expected longer password to need more guesses: %.0f <= %.0f", long.Guesses, short.Guesses)
+	}
+}
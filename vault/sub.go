@@ -0,0 +1,78 @@
+package vault
+
+import (
+	"context"
+	"strings"
+)
+
+// Sub returns a view of inner in which every path is relative to prefix,
+// like a chroot for secrets. Get, Set, Delete, and Exists prepend the
+// prefix, and List only returns paths under the prefix, with the prefix
+// stripped. A prefix without a trailing slash is treated as a directory,
+// so Sub(v, "myapp") and Sub(v, "myapp/") are the same view.
+//
+// Closing the view does not close inner, which may be shared.
+func Sub(inner Vault, prefix string) Vault {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &subVault{inner: inner, prefix: prefix}
+}
+
+// subVault is a Vault scoped to a path prefix of another Vault.
+type subVault struct {
+	inner  Vault
+	prefix string
+}
+
+func (s *subVault) Get(ctx context.Context, path string) (*Secret, error) {
+	secret, err := s.inner.Get(ctx, s.prefix+path)
+	if err != nil {
+		return nil, err
+	}
+	if secret.Metadata.Path == s.prefix+path {
+		secret.Metadata.Path = path
+	}
+	return secret, nil
+}
+
+func (s *subVault) Set(ctx context.Context, path string, secret *Secret) error {
+	return s.inner.Set(ctx, s.prefix+path, secret)
+}
+
+func (s *subVault) Delete(ctx context.Context, path string) error {
+	return s.inner.Delete(ctx, s.prefix+path)
+}
+
+func (s *subVault) Exists(ctx context.Context, path string) (bool, error) {
+	return s.inner.Exists(ctx, s.prefix+path)
+}
+
+func (s *subVault) List(ctx context.Context, prefix string) ([]string, error) {
+	paths, err := s.inner.List(ctx, s.prefix+prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// Providers may match prefixes loosely, so filter again
+	results := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if rel, ok := strings.CutPrefix(path, s.prefix); ok && strings.HasPrefix(rel, prefix) {
+			results = append(results, rel)
+		}
+	}
+	return results, nil
+}
+
+func (s *subVault) Name() string {
+	return s.inner.Name()
+}
+
+func (s *subVault) Capabilities() Capabilities {
+	return s.inner.Capabilities()
+}
+
+// Close is a no-op; the inner vault is left open.
+func (s *subVault) Close() error {
+	return nil
+}
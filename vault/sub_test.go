@@ -0,0 +1,78 @@
+package vault_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/agentplexus/omnivault/providers/memory"
+	"github.com/agentplexus/omnivault/vault"
+)
+
+func TestSubList(t *testing.T) {
+	inner := memory.NewWithSecrets(map[string]string{
+		"myapp/db/password": "a",
+		"myapp/api-key":     "b",
+		"myappx/other":      "c",
+		"other/secret":      "d",
+	})
+	sub := vault.Sub(inner, "myapp")
+	ctx := context.Background()
+
+	paths, err := sub.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	sort.Strings(paths)
+	if want := []string{"api-key", "db/password"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("List(\"\") = %v, want %v", paths, want)
+	}
+
+	paths, err = sub.List(ctx, "db/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if want := []string{"db/password"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("List(\"db/\") = %v, want %v", paths, want)
+	}
+}
+
+func TestSubReadWrite(t *testing.T) {
+	inner := memory.New()
+	sub := vault.Sub(inner, "myapp/")
+	ctx := context.Background()
+
+	if err := sub.Set(ctx, "token", &vault.Secret{Value: "t0k"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// The secret is stored under the prefix in the inner vault
+	if exists, _ := inner.Exists(ctx, "myapp/token"); !exists {
+		t.Error("Expected secret at myapp/token in the inner vault")
+	}
+
+	secret, err := sub.Get(ctx, "token")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if secret.Value != "t0k" || secret.Metadata.Path != "token" {
+		t.Errorf("Expected short path and value, got %q %q", secret.Metadata.Path, secret.Value)
+	}
+
+	if err := sub.Delete(ctx, "token"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := sub.Get(ctx, "token"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+
+	// Closing the view leaves the inner vault usable
+	if err := sub.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := inner.List(ctx, ""); err != nil {
+		t.Errorf("Expected inner vault to stay open, got %v", err)
+	}
+}
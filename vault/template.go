@@ -0,0 +1,78 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// TemplateKey is the Metadata.Extra key holding a secret's value template.
+const TemplateKey = "template"
+
+// ErrNoTemplate is returned when rendering a secret that has no template.
+var ErrNoTemplate = errors.New("secret has no template")
+
+// Template returns the secret's value template, or "" if it has none.
+func (s *Secret) Template() string {
+	text, _ := s.Metadata.Extra[TemplateKey].(string)
+	return text
+}
+
+// SetTemplate sets the secret's value template. An empty text removes it.
+func (s *Secret) SetTemplate(text string) {
+	if text == "" {
+		delete(s.Metadata.Extra, TemplateKey)
+		return
+	}
+	if s.Metadata.Extra == nil {
+		s.Metadata.Extra = make(map[string]any, 1)
+	}
+	s.Metadata.Extra[TemplateKey] = text
+}
+
+// ValidateTemplate reports whether text is a valid value template.
+func ValidateTemplate(text string) error {
+	_, err := parseTemplate(text)
+	return err
+}
+
+// parseTemplate parses a value template.
+func parseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("secret").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Render expands the secret's template, a Go text/template, over its
+// fields, for example to derive a connection string:
+//
+//	postgres://{{.username}}:{{.password}}@{{.host}}/{{.database}}
+//
+// The template only sees the secret's own fields, so it can't read other
+// secrets or the environment, and field values are never expanded
+// themselves. A reference to a missing field is an error. Render returns
+// ErrNoTemplate if the secret has no template.
+func (s *Secret) Render() (string, error) {
+	text := s.Template()
+	if text == "" {
+		return "", ErrNoTemplate
+	}
+
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	fields := s.Fields
+	if fields == nil {
+		fields = map[string]string{}
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, fields); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return b.String(), nil
+}
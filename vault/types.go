@@ -2,6 +2,9 @@ package vault
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -18,6 +21,10 @@ type Secret struct {
 	// Common for password managers that store username, password, URL, etc.
 	Fields map[string]string `json:"fields,omitempty"`
 
+	// Notes is free-form text describing the secret, such as where it is
+	// used or how to rotate it.
+	Notes string `json:"notes,omitempty"`
+
 	// Metadata contains additional information about the secret.
 	Metadata Metadata `json:"metadata,omitempty"`
 }
@@ -65,6 +72,43 @@ func (s *Secret) Bytes() []byte {
 	return []byte(s.Value)
 }
 
+// Clone returns a deep copy of the secret.
+func (s *Secret) Clone() *Secret {
+	if s == nil {
+		return nil
+	}
+
+	c := *s
+	if s.ValueBytes != nil {
+		c.ValueBytes = append([]byte(nil), s.ValueBytes...)
+	}
+	if s.Fields != nil {
+		c.Fields = make(map[string]string, len(s.Fields))
+		for k, v := range s.Fields {
+			c.Fields[k] = v
+		}
+	}
+	if s.Metadata.Tags != nil {
+		c.Metadata.Tags = make(map[string]string, len(s.Metadata.Tags))
+		for k, v := range s.Metadata.Tags {
+			c.Metadata.Tags[k] = v
+		}
+	}
+	if s.Metadata.Labels != nil {
+		c.Metadata.Labels = append([]string(nil), s.Metadata.Labels...)
+	}
+	if s.Metadata.ProtectedFields != nil {
+		c.Metadata.ProtectedFields = append([]string(nil), s.Metadata.ProtectedFields...)
+	}
+	if s.Metadata.Extra != nil {
+		c.Metadata.Extra = make(map[string]any, len(s.Metadata.Extra))
+		for k, v := range s.Metadata.Extra {
+			c.Metadata.Extra[k] = v
+		}
+	}
+	return &c
+}
+
 // Metadata contains additional information about a secret.
 type Metadata struct {
 	// CreatedAt is when the secret was created.
@@ -76,6 +120,10 @@ type Metadata struct {
 	// ExpiresAt is when the secret expires, if applicable.
 	ExpiresAt *Timestamp `json:"expiresAt,omitempty"`
 
+	// LastAccessedAt is when the secret was last read, if the provider
+	// tracks it.
+	LastAccessedAt *Timestamp `json:"lastAccessedAt,omitempty"`
+
 	// Version is the version identifier of the secret.
 	Version string `json:"version,omitempty"`
 
@@ -93,6 +141,24 @@ type Metadata struct {
 
 	// Extra contains provider-specific metadata.
 	Extra map[string]any `json:"extra,omitempty"`
+
+	// Protected requires re-confirmation (e.g., the master password) before
+	// the secret value is revealed, even when the vault is unlocked.
+	Protected bool `json:"protected,omitempty"`
+
+	// ProtectedFields lists fields that are omitted from normal reads and
+	// only revealed when requested by name with confirmation.
+	ProtectedFields []string `json:"protectedFields,omitempty"`
+}
+
+// IsFieldProtected reports whether the named field is a protected field.
+func (m Metadata) IsFieldProtected(name string) bool {
+	for _, f := range m.ProtectedFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
 }
 
 // Timestamp wraps time.Time to provide custom JSON marshaling.
@@ -181,7 +247,114 @@ func (r SecretRef) Fragment() string {
 	return ""
 }
 
+// Valid reports whether the reference is well formed: a scheme of a letter
+// followed by letters, digits, "+", "-" or ".", then "://" and a non-empty
+// path. It does not check that the scheme has a provider.
+func (r SecretRef) Valid() bool {
+	scheme := r.Scheme()
+	if scheme == "" || !strings.HasPrefix(string(r)[len(scheme):], "://") {
+		return false
+	}
+	for i, c := range scheme {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case i > 0 && (c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return r.Path() != ""
+}
+
 // String returns the string representation of the secret reference.
 func (r SecretRef) String() string {
 	return string(r)
 }
+
+// ParsedRef is a secret reference split into its components, with
+// percent-encoding decoded.
+type ParsedRef struct {
+	// Scheme selects the provider, e.g. "vault".
+	Scheme string
+
+	// User is the userinfo before an "@" in the first path segment, or
+	// nil if there is none.
+	User *url.Userinfo
+
+	// Path is everything between "://" (and the userinfo) and the query
+	// or fragment, e.g. "secret/path".
+	Path string
+
+	// Query holds the parameters after "?", e.g. version=2. It is never nil.
+	Query url.Values
+
+	// Fragment is the part after "#", usually a field name.
+	Fragment string
+}
+
+// Parse splits the reference into its components, decoding percent-escapes
+// in each. Unlike URLs, the first path segment is not treated as a host,
+// so it may contain characters such as ":" that hosts can't, as in
+// "aws-sm://arn:aws:secretsmanager:...". For example,
+// "vault://secret/my%20app?version=2#password" has the path
+// "secret/my app", the query version=2 and the fragment "password". It
+// returns an error wrapping ErrInvalidPath if the reference is not Valid,
+// its path is empty, or it has a malformed escape.
+func (r SecretRef) Parse() (ParsedRef, error) {
+	invalid := func(reason string) (ParsedRef, error) {
+		return ParsedRef{}, fmt.Errorf("%w: secret reference %q: %s", ErrInvalidPath, string(r), reason)
+	}
+
+	scheme := r.Scheme()
+	if !r.Valid() {
+		return invalid("expected scheme://path")
+	}
+
+	rest := string(r)[len(scheme)+len("://"):]
+	parsed := ParsedRef{Scheme: scheme}
+
+	rest, fragment, _ := strings.Cut(rest, "#")
+	rest, rawQuery, _ := strings.Cut(rest, "?")
+
+	var err error
+	if parsed.Fragment, err = url.PathUnescape(fragment); err != nil {
+		return invalid("bad escape in fragment")
+	}
+	if parsed.Query, err = url.ParseQuery(rawQuery); err != nil {
+		return invalid("bad query")
+	}
+
+	// Userinfo can only appear in the first segment
+	first, _, _ := strings.Cut(rest, "/")
+	if at := strings.LastIndex(first, "@"); at >= 0 {
+		if parsed.User, err = parseUserinfo(first[:at]); err != nil {
+			return invalid("bad escape in userinfo")
+		}
+		rest = rest[at+1:]
+	}
+
+	if parsed.Path, err = url.PathUnescape(rest); err != nil {
+		return invalid("bad escape in path")
+	}
+	if parsed.Path == "" {
+		return invalid("empty path")
+	}
+	return parsed, nil
+}
+
+// parseUserinfo decodes a "user" or "user:password" userinfo.
+func parseUserinfo(raw string) (*url.Userinfo, error) {
+	rawUser, rawPassword, hasPassword := strings.Cut(raw, ":")
+	user, err := url.PathUnescape(rawUser)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPassword {
+		return url.User(user), nil
+	}
+	password, err := url.PathUnescape(rawPassword)
+	if err != nil {
+		return nil, err
+	}
+	return url.UserPassword(user, password), nil
+}
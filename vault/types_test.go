@@ -0,0 +1,91 @@
+package vault
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestSecretRefValid(t *testing.T) {
+	tests := []struct {
+		ref  SecretRef
+		want bool
+	}{
+		{"op://vault/item/field", true},
+		{"env://API_KEY", true},
+		{"file:///etc/secret", true},
+		{"aws-sm://my-secret#password", true},
+		{"", false},
+		{"API_KEY", false},
+		{"env:API_KEY", false},
+		{"env://", false},
+		{"env://#field", false},
+		{"://path", false},
+		{"1p://vault/item", false},
+		{"o p://vault/item", false},
+	}
+	for _, tt := range tests {
+		if got := tt.ref.Valid(); got != tt.want {
+			t.Errorf("SecretRef(%q).Valid() = %v, want %v", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestSecretRefParse(t *testing.T) {
+	tests := []struct {
+		ref      SecretRef
+		scheme   string
+		user     string
+		path     string
+		query    url.Values
+		fragment string
+	}{
+		{"op://vault/item/field", "op", "", "vault/item/field", url.Values{}, ""},
+		{"vault://secret/path?version=2#field", "vault", "", "secret/path", url.Values{"version": {"2"}}, "field"},
+		{"vault://secret/path?version=2&mount=kv&mount=kv2", "vault", "", "secret/path", url.Values{"version": {"2"}, "mount": {"kv", "kv2"}}, ""},
+		{"vault://secret/my%20app%2Fprod?label=a%26b#pass%23word", "vault", "", "secret/my app/prod", url.Values{"label": {"a&b"}}, "pass#word"},
+		{"file:///etc/secret", "file", "", "/etc/secret", url.Values{}, ""},
+		{"aws-sm://arn:aws:secretsmanager:us-east-1:123:secret:db#password", "aws-sm", "", "arn:aws:secretsmanager:us-east-1:123:secret:db", url.Values{}, "password"},
+		{"bw://alice%40example.com@vault/item", "bw", "alice@example.com", "vault/item", url.Values{}, ""},
+		{"vault://secret?#", "vault", "", "secret", url.Values{}, ""},
+		{"env://a@b", "env", "a", "b", url.Values{}, ""},
+		{"env://path/with@sign", "env", "", "path/with@sign", url.Values{}, ""},
+	}
+	for _, tt := range tests {
+		got, err := tt.ref.Parse()
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.ref, err)
+			continue
+		}
+		user := ""
+		if got.User != nil {
+			user = got.User.Username()
+		}
+		if got.Scheme != tt.scheme || user != tt.user || got.Path != tt.path || got.Fragment != tt.fragment {
+			t.Errorf("Parse(%q) = %q, %q, %q, %q; want %q, %q, %q, %q",
+				tt.ref, got.Scheme, user, got.Path, got.Fragment, tt.scheme, tt.user, tt.path, tt.fragment)
+		}
+		if !reflect.DeepEqual(got.Query, tt.query) {
+			t.Errorf("Parse(%q).Query = %v, want %v", tt.ref, got.Query, tt.query)
+		}
+	}
+}
+
+func TestSecretRefParseUserPassword(t *testing.T) {
+	got, err := SecretRef("db://admin:p%3Ass@host/name").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if password, ok := got.User.Password(); got.User.Username() != "admin" || !ok || password != "p:ss" {
+		t.Errorf("Unexpected userinfo %v", got.User)
+	}
+}
+
+func TestSecretRefParseInvalid(t *testing.T) {
+	for _, ref := range []SecretRef{"", "API_KEY", "env://", "vault://?version=2", "vault://bad%zzescape", "vault://path?a=%zz", "vault://path#%zz"} {
+		if _, err := ref.Parse(); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("Parse(%q) = %v, want ErrInvalidPath", ref, err)
+		}
+	}
+}